@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/chains"
@@ -23,6 +24,9 @@ type Executor struct {
 
 	MaxIterations           int
 	ReturnIntermediateSteps bool
+	// MaxConcurrentActions is the maximum number of the actions of a step
+	// run concurrently. Actions run one after the other below 2.
+	MaxConcurrentActions int
 }
 
 var (
@@ -42,6 +46,7 @@ func NewExecutor(agent Agent, opts ...Option) *Executor {
 		Memory:                  options.memory,
 		MaxIterations:           options.maxIterations,
 		ReturnIntermediateSteps: options.returnIntermediateSteps,
+		MaxConcurrentActions:    options.maxConcurrentActions,
 		CallbacksHandler:        options.callbacksHandler,
 		ErrorHandler:            options.errorHandler,
 	}
@@ -106,6 +111,14 @@ func (e *Executor) doIteration( // nolint
 		return steps, e.getReturn(finish, steps), nil
 	}
 
+	if e.MaxConcurrentActions > 1 && len(actions) > 1 {
+		actionSteps, err := e.doActionsConcurrently(ctx, nameToTool, actions)
+		if err != nil {
+			return steps, nil, err
+		}
+		return append(steps, actionSteps...), nil, nil
+	}
+
 	for _, action := range actions {
 		steps, err = e.doAction(ctx, steps, nameToTool, action)
 		if err != nil {
@@ -126,23 +139,85 @@ func (e *Executor) doAction(
 		e.CallbacksHandler.HandleAgentAction(ctx, action)
 	}
 
+	step, err := runAction(ctx, nameToTool, action)
+	if err != nil {
+		return nil, err
+	}
+	return append(steps, step), nil
+}
+
+// doActionsConcurrently runs up to MaxConcurrentActions of the actions at a
+// time, returning their steps in the order of the actions. The first error
+// cancels the actions still running.
+func (e *Executor) doActionsConcurrently(
+	ctx context.Context,
+	nameToTool map[string]tools.Tool,
+	actions []schema.AgentAction,
+) ([]schema.AgentStep, error) {
+	if e.CallbacksHandler != nil {
+		for _, action := range actions {
+			e.CallbacksHandler.HandleAgentAction(ctx, action)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	steps := make([]schema.AgentStep, len(actions))
+	errs := make([]error, len(actions))
+	slots := make(chan struct{}, e.MaxConcurrentActions)
+	var wg sync.WaitGroup
+	for i, action := range actions {
+		wg.Add(1)
+		go func(i int, action schema.AgentAction) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			steps[i], errs[i] = runAction(ctx, nameToTool, action)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i, action)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return steps, nil
+}
+
+// runAction calls the tool of action, returning its observation as a step.
+func runAction(ctx context.Context, nameToTool map[string]tools.Tool, action schema.AgentAction) (schema.AgentStep, error) {
 	tool, ok := nameToTool[strings.ToUpper(action.Tool)]
 	if !ok {
-		return append(steps, schema.AgentStep{
+		return schema.AgentStep{
 			Action:      action,
 			Observation: fmt.Sprintf("%s is not a valid tool, try another one", action.Tool),
-		}), nil
+		}, nil
 	}
 
 	observation, err := tool.Call(ctx, action.ToolInput)
 	if err != nil {
-		return nil, err
+		return schema.AgentStep{}, err
 	}
 
-	return append(steps, schema.AgentStep{
+	return schema.AgentStep{
 		Action:      action,
 		Observation: observation,
-	}), nil
+	}, nil
 }
 
 func (e *Executor) getReturn(finish *schema.AgentFinish, steps []schema.AgentStep) map[string]any {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -73,6 +74,82 @@ func TestExecutorWithErrorHandler(t *testing.T) {
 	}, a.recordedIntermediateSteps)
 }
 
+// parallelAgent plans its actions once, then finishes.
+type parallelAgent struct {
+	actions []schema.AgentAction
+	tools   []tools.Tool
+
+	recordedIntermediateSteps []schema.AgentStep
+}
+
+func (a *parallelAgent) Plan(
+	_ context.Context,
+	intermediateSteps []schema.AgentStep,
+	_ map[string]string,
+) ([]schema.AgentAction, *schema.AgentFinish, error) {
+	if len(intermediateSteps) > 0 {
+		a.recordedIntermediateSteps = intermediateSteps
+		return nil, &schema.AgentFinish{ReturnValues: map[string]any{"output": "done"}}, nil
+	}
+	return a.actions, nil, nil
+}
+
+func (a *parallelAgent) GetInputKeys() []string {
+	return nil
+}
+
+func (a *parallelAgent) GetOutputKeys() []string {
+	return []string{"output"}
+}
+
+func (a *parallelAgent) GetTools() []tools.Tool {
+	return a.tools
+}
+
+// barrierTool blocks until all the expected calls are running.
+type barrierTool struct {
+	wg *sync.WaitGroup
+}
+
+func (t barrierTool) Name() string {
+	return "barrier"
+}
+
+func (t barrierTool) Description() string {
+	return "Waits for the other calls."
+}
+
+func (t barrierTool) Call(_ context.Context, input string) (string, error) {
+	t.wg.Done()
+	t.wg.Wait()
+	return strings.ToUpper(input), nil
+}
+
+func TestExecutorWithMaxConcurrentActions(t *testing.T) {
+	t.Parallel()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	a := &parallelAgent{
+		actions: []schema.AgentAction{
+			{Tool: "barrier", ToolInput: "a", ToolID: "1"},
+			{Tool: "barrier", ToolInput: "b", ToolID: "2"},
+			{Tool: "barrier", ToolInput: "c", ToolID: "3"},
+		},
+		tools: []tools.Tool{barrierTool{wg: &wg}},
+	}
+	executor := agents.NewExecutor(a, agents.WithMaxConcurrentActions(3))
+
+	result, err := chains.Call(context.Background(), executor, nil)
+	require.NoError(t, err)
+	require.Equal(t, "done", result["output"])
+	require.Len(t, a.recordedIntermediateSteps, 3)
+	for i, observation := range []string{"A", "B", "C"} {
+		require.Equal(t, a.actions[i], a.recordedIntermediateSteps[i].Action)
+		require.Equal(t, observation, a.recordedIntermediateSteps[i].Observation)
+	}
+}
+
 func TestExecutorWithMRKLAgent(t *testing.T) {
 	t.Parallel()
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
@@ -105,20 +106,18 @@ func (o *OpenAIFunctionsAgent) Plan(
 				Role: role,
 				Parts: []llms.ContentPart{llms.ToolCallResponse{
 					ToolCallID: p.ID,
+					Name:       p.Name,
 					Content:    p.Content,
 				}},
 			}
 
 		case llms.AIChatMessage:
-			mc = llms.MessageContent{
-				Role: role,
-				Parts: []llms.ContentPart{
-					llms.ToolCall{
-						ID:           p.ToolCalls[0].ID,
-						Type:         p.ToolCalls[0].Type,
-						FunctionCall: p.ToolCalls[0].FunctionCall,
-					},
-				},
+			mc = llms.MessageContent{Role: role}
+			if p.Content != "" {
+				mc.Parts = append(mc.Parts, llms.TextContent{Text: p.Content})
+			}
+			for _, toolCall := range p.ToolCalls {
+				mc.Parts = append(mc.Parts, toolCall)
 			}
 		default:
 			mc = llms.MessageContent{
@@ -173,29 +172,76 @@ func createOpenAIFunctionPrompt(opts Options) prompts.ChatPromptTemplate {
 	return tmpl
 }
 
+// constructScratchPad returns the messages of the steps. The tool calls of
+// consecutive steps are sent as a single assistant message followed by their
+// results, the shape of parallel tool calls, and the steps without tool call
+// ID as function messages.
 func (o *OpenAIFunctionsAgent) constructScratchPad(steps []schema.AgentStep) []llms.ChatMessage {
 	if len(steps) == 0 {
 		return nil
 	}
 
 	messages := make([]llms.ChatMessage, 0)
-	for _, step := range steps {
-		messages = append(messages, llms.FunctionChatMessage{
-			Name:    step.Action.Tool,
-			Content: step.Observation,
-		})
+	for i := 0; i < len(steps); {
+		if steps[i].Action.ToolID == "" {
+			messages = append(messages, llms.FunctionChatMessage{
+				Name:    steps[i].Action.Tool,
+				Content: steps[i].Observation,
+			})
+			i++
+			continue
+		}
+
+		j := i
+		var call llms.AIChatMessage
+		for ; j < len(steps) && steps[j].Action.ToolID != ""; j++ {
+			call.ToolCalls = append(call.ToolCalls, llms.ToolCall{
+				ID:   steps[j].Action.ToolID,
+				Type: "function",
+				FunctionCall: &llms.FunctionCall{
+					Name:      steps[j].Action.Tool,
+					Arguments: toolArguments(steps[j].Action.ToolInput),
+				},
+			})
+		}
+		messages = append(messages, call)
+		for ; i < j; i++ {
+			messages = append(messages, llms.ToolChatMessage{
+				ID:      steps[i].Action.ToolID,
+				Name:    steps[i].Action.Tool,
+				Content: steps[i].Observation,
+			})
+		}
 	}
 
 	return messages
 }
 
+// toolArguments returns the JSON arguments of a tool call with input, which
+// ParseOutput extracted from the "__arg1" argument unless it was a JSON
+// object.
+func toolArguments(input string) string {
+	if strings.HasPrefix(strings.TrimSpace(input), "{") && json.Valid([]byte(input)) {
+		return input
+	}
+	arguments, _ := json.Marshal(map[string]string{"__arg1": input}) // nolint:errchkjson
+	return string(arguments)
+}
+
+// ParseOutput returns an action for each of the tool calls of the first
+// choice of the response, or the finish of the agent when there are none.
 func (o *OpenAIFunctionsAgent) ParseOutput(contentResp *llms.ContentResponse) (
 	[]schema.AgentAction, *schema.AgentFinish, error,
 ) {
 	choice := contentResp.Choices[0]
 
+	toolCalls := choice.ToolCalls
+	if len(toolCalls) == 0 && choice.FuncCall != nil {
+		toolCalls = []llms.ToolCall{{FunctionCall: choice.FuncCall}}
+	}
+
 	// finish
-	if choice.FuncCall == nil {
+	if len(toolCalls) == 0 {
 		return nil, &schema.AgentFinish{
 			ReturnValues: map[string]any{
 				"output": choice.Content,
@@ -204,35 +250,40 @@ func (o *OpenAIFunctionsAgent) ParseOutput(contentResp *llms.ContentResponse) (
 		}, nil
 	}
 
-	// action
-	functionCall := choice.FuncCall
-	functionName := functionCall.Name
-	toolInputStr := functionCall.Arguments
-	toolInputMap := make(map[string]any, 0)
-	err := json.Unmarshal([]byte(toolInputStr), &toolInputMap)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	toolInput := toolInputStr
-	if arg1, ok := toolInputMap["__arg1"]; ok {
-		toolInputCheck, ok := arg1.(string)
-		if ok {
-			toolInput = toolInputCheck
-		}
-	}
-
 	contentMsg := "\n"
 	if choice.Content != "" {
 		contentMsg = fmt.Sprintf("responded: %s\n", choice.Content)
 	}
 
-	return []schema.AgentAction{
-		{
+	// actions
+	actions := make([]schema.AgentAction, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		if toolCall.FunctionCall == nil {
+			continue
+		}
+		functionName := toolCall.FunctionCall.Name
+		toolInputStr := toolCall.FunctionCall.Arguments
+		toolInputMap := make(map[string]any, 0)
+		err := json.Unmarshal([]byte(toolInputStr), &toolInputMap)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		toolInput := toolInputStr
+		if arg1, ok := toolInputMap["__arg1"]; ok {
+			toolInputCheck, ok := arg1.(string)
+			if ok {
+				toolInput = toolInputCheck
+			}
+		}
+
+		actions = append(actions, schema.AgentAction{
 			Tool:      functionName,
 			ToolInput: toolInput,
 			Log:       fmt.Sprintf("Invoking: %s with %s \n %s \n", functionName, toolInputStr, contentMsg),
-			ToolID:    choice.ToolCalls[0].ID,
-		},
-	}, nil, nil
+			ToolID:    toolCall.ID,
+		})
+	}
+
+	return actions, nil, nil
 }
@@ -14,6 +14,7 @@ type Options struct {
 	callbacksHandler        callbacks.Handler
 	errorHandler            *ParserErrorHandler
 	maxIterations           int
+	maxConcurrentActions    int
 	returnIntermediateSteps bool
 	outputKey               string
 	promptPrefix            string
@@ -154,6 +155,16 @@ func WithCallbacksHandler(handler callbacks.Handler) Option {
 	}
 }
 
+// WithMaxConcurrentActions is an option for running up to n of the actions
+// planned by the agent in one step, such as parallel tool calls, concurrently.
+// The tools must then be safe for concurrent use. Actions run one after the
+// other by default.
+func WithMaxConcurrentActions(n int) Option {
+	return func(co *Options) {
+		co.maxConcurrentActions = n
+	}
+}
+
 // WithParserErrorHandler is an option for setting a parser error handler to an executor.
 func WithParserErrorHandler(errorHandler *ParserErrorHandler) Option {
 	return func(co *Options) {
@@ -0,0 +1,88 @@
+package callbacks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+type sessionKey struct{}
+
+// WithSession returns a context whose LLM calls are accounted to the session
+// by UsageTracker, e.g. a conversation or a user.
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFromContext returns the session of ctx set with WithSession, if any.
+func SessionFromContext(ctx context.Context) string {
+	session, _ := ctx.Value(sessionKey{}).(string)
+	return session
+}
+
+// UsageTracker is a handler aggregating the token usage of the LLM calls it
+// is notified of, in total and per session, for cost tracking.
+type UsageTracker struct {
+	SimpleHandler
+
+	// OnUsage, if set, is called with the usage of every call, along with
+	// its session.
+	OnUsage func(ctx context.Context, session string, usage llms.Usage)
+
+	mu       sync.Mutex
+	total    llms.Usage
+	requests int
+	sessions map[string]llms.Usage
+}
+
+var _ Handler = &UsageTracker{}
+
+// NewUsageTracker creates a new UsageTracker.
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{sessions: map[string]llms.Usage{}}
+}
+
+// HandleLLMGenerateContentEnd records the usage of res.
+func (t *UsageTracker) HandleLLMGenerateContentEnd(ctx context.Context, res *llms.ContentResponse) {
+	usage := llms.ResponseUsage(res)
+	session := SessionFromContext(ctx)
+
+	t.mu.Lock()
+	t.total = t.total.Add(usage)
+	t.requests++
+	if session != "" {
+		if t.sessions == nil {
+			t.sessions = map[string]llms.Usage{}
+		}
+		t.sessions[session] = t.sessions[session].Add(usage)
+	}
+	t.mu.Unlock()
+
+	if t.OnUsage != nil {
+		t.OnUsage(ctx, session, usage)
+	}
+}
+
+// Total returns the usage of all the calls, along with their number.
+func (t *UsageTracker) Total() (llms.Usage, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total, t.requests
+}
+
+// Session returns the usage of the calls of session.
+func (t *UsageTracker) Session(session string) llms.Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessions[session]
+}
+
+// Reset forgets the recorded usage.
+func (t *UsageTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total = llms.Usage{}
+	t.requests = 0
+	t.sessions = map[string]llms.Usage{}
+}
@@ -0,0 +1,41 @@
+package callbacks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestUsageTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewUsageTracker()
+	var sessions []string
+	tracker.OnUsage = func(_ context.Context, session string, _ llms.Usage) {
+		sessions = append(sessions, session)
+	}
+
+	ctx := WithSession(context.Background(), "alice")
+	tracker.HandleLLMGenerateContentEnd(ctx, &llms.ContentResponse{Usage: llms.Usage{
+		PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, CachedTokens: 4,
+	}})
+	tracker.HandleLLMGenerateContentEnd(ctx, &llms.ContentResponse{Usage: llms.NewUsage(3, 2)})
+	// Usage only reported in the generation info.
+	tracker.HandleLLMGenerateContentEnd(context.Background(), &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{{GenerationInfo: map[string]any{"input_tokens": int32(7), "output_tokens": int32(1)}}},
+	})
+
+	total, requests := tracker.Total()
+	require.Equal(t, llms.Usage{PromptTokens: 20, CompletionTokens: 8, TotalTokens: 28, CachedTokens: 4}, total)
+	require.Equal(t, 3, requests)
+	require.Equal(t, llms.Usage{PromptTokens: 13, CompletionTokens: 7, TotalTokens: 20, CachedTokens: 4}, tracker.Session("alice"))
+	require.Equal(t, llms.Usage{}, tracker.Session("bob"))
+	require.Equal(t, []string{"alice", "alice", ""}, sessions)
+
+	tracker.Reset()
+	total, requests = tracker.Total()
+	require.Equal(t, llms.Usage{}, total)
+	require.Equal(t, 0, requests)
+}
@@ -0,0 +1,55 @@
+package chains
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DocumentEscaping configures how the chains combining documents sanitize
+// the content of the documents before putting it in a prompt, so malformed or
+// adversarial content can't break the rendering of the prompt or pass for its
+// instructions. The zero value leaves the content unchanged.
+type DocumentEscaping struct {
+	// Fence, if not empty, is put on a line before and after the content of
+	// every document, e.g. "```" or "<<<DOCUMENT>>>". Occurrences of the
+	// fence in the content are removed so the content can't close it.
+	Fence string
+
+	// StripControlCharacters removes invalid UTF-8 and the control
+	// characters other than newlines and tabs from the content.
+	StripControlCharacters bool
+
+	// MaxLength, if positive, truncates the content of every document to at
+	// most MaxLength runes.
+	MaxLength int
+}
+
+// Escape returns content sanitized as configured.
+func (e DocumentEscaping) Escape(content string) string {
+	if e.StripControlCharacters {
+		content = stripControlCharacters(content)
+	}
+	if e.MaxLength > 0 && utf8.RuneCountInString(content) > e.MaxLength {
+		content = string([]rune(content)[:e.MaxLength])
+	}
+	if e.Fence == "" {
+		return content
+	}
+	// Removing the fence can join its surroundings into a new one.
+	for strings.Contains(content, e.Fence) {
+		content = strings.ReplaceAll(content, e.Fence, "")
+	}
+	return e.Fence + "\n" + content + "\n" + e.Fence
+}
+
+// stripControlCharacters removes invalid UTF-8 and the control characters
+// other than newlines and tabs from s.
+func stripControlCharacters(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || (unicode.IsControl(r) && r != '\n' && r != '\t') {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -38,6 +38,10 @@ type MapReduceDocuments struct {
 
 	// Whether to add the intermediate steps to the output.
 	ReturnIntermediateSteps bool
+
+	// Escaping sanitizes the content of the documents before giving them to
+	// the LLMChain.
+	Escaping DocumentEscaping
 }
 
 var _ Chain = MapReduceDocuments{}
@@ -103,7 +107,7 @@ func (c MapReduceDocuments) getApplyInputs(values map[string]any, docs []schema.
 	inputs := make([]map[string]any, 0, len(docs))
 	for _, d := range docs {
 		curInput := c.copyInputValuesWithoutInputKey(values)
-		curInput[llmChainInputVariable] = d.PageContent
+		curInput[llmChainInputVariable] = c.Escaping.Escape(d.PageContent)
 		inputs = append(inputs, curInput)
 	}
 
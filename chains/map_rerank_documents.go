@@ -45,6 +45,10 @@ type MapRerankDocuments struct {
 
 	// When true, the intermediate steps of the map rerank are returned.
 	ReturnIntermediateSteps bool
+
+	// Escaping sanitizes the content of the documents before giving them to
+	// the LLMChain.
+	Escaping DocumentEscaping
 }
 
 var _ Chain = MapRerankDocuments{}
@@ -129,7 +133,7 @@ func (c MapRerankDocuments) getApplyInputs(values map[string]any, docs []schema.
 	inputs := make([]map[string]any, 0, len(docs))
 	for _, d := range docs {
 		curInput := c.copyInputValuesWithoutInputKey(values)
-		curInput[llmChainInputVariable] = d.PageContent
+		curInput[llmChainInputVariable] = c.Escaping.Escape(d.PageContent)
 		inputs = append(inputs, curInput)
 	}
 
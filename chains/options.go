@@ -2,6 +2,8 @@ package chains
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
@@ -161,6 +163,43 @@ func WithCallback(callbackHandler callbacks.Handler) ChainCallOption {
 	}
 }
 
+// ValidateCallOptions checks the options for values no model accepts, such as
+// negative token counts or a top-p outside [0, 1], returning all the problems
+// found along with how to fix them.
+func ValidateCallOptions(options ...ChainCallOption) error {
+	opts := &chainCallOption{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	var problems []error
+	if opts.maxTokensSet && opts.MaxTokens < 0 {
+		problems = append(problems, fmt.Errorf("WithMaxTokens: negative value %d, use a positive limit", opts.MaxTokens))
+	}
+	if opts.temperatureSet && opts.Temperature < 0 {
+		problems = append(problems, fmt.Errorf("WithTemperature: negative value %v, use 0 for the most deterministic output", opts.Temperature))
+	}
+	if opts.topkSet && opts.TopK < 0 {
+		problems = append(problems, fmt.Errorf("WithTopK: negative value %d, use a positive number of tokens", opts.TopK))
+	}
+	if opts.toppSet && (opts.TopP < 0 || opts.TopP > 1) {
+		problems = append(problems, fmt.Errorf("WithTopP: value %v outside [0, 1], use a cumulative probability", opts.TopP))
+	}
+	if opts.minLengthSet && opts.MinLength < 0 {
+		problems = append(problems, fmt.Errorf("WithMinLength: negative value %d, use a positive length", opts.MinLength))
+	}
+	if opts.maxLengthSet && opts.MaxLength < 0 {
+		problems = append(problems, fmt.Errorf("WithMaxLength: negative value %d, use a positive length", opts.MaxLength))
+	}
+	if opts.minLengthSet && opts.maxLengthSet && opts.MinLength > opts.MaxLength {
+		problems = append(problems, fmt.Errorf("WithMinLength: %d is greater than the max length %d, lower it or raise WithMaxLength", opts.MinLength, opts.MaxLength))
+	}
+	if opts.repetitionPenaltySet && opts.RepetitionPenalty < 0 {
+		problems = append(problems, fmt.Errorf("WithRepetitionPenalty: negative value %v, use 1 for no penalty", opts.RepetitionPenalty))
+	}
+	return errors.Join(problems...)
+}
+
 func getLLMCallOptions(options ...ChainCallOption) []llms.CallOption { //nolint:cyclop
 	opts := &chainCallOption{}
 	for _, option := range options {
@@ -0,0 +1,23 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCallOptions(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, ValidateCallOptions(WithMaxTokens(256), WithTemperature(0.2), WithTopP(0.9)))
+
+	err := ValidateCallOptions(
+		WithMaxTokens(-1),
+		WithTopP(1.5),
+		WithMinLength(100),
+		WithMaxLength(10),
+	)
+	require.Error(t, err)
+	require.ErrorContains(t, err, "WithMaxTokens: negative value -1")
+	require.ErrorContains(t, err, "WithTopP: value 1.5 outside [0, 1]")
+	require.ErrorContains(t, err, "WithMinLength: 100 is greater than the max length 10")
+}
@@ -34,6 +34,10 @@ type RefineDocuments struct {
 	OutputKey            string
 	DocumentVariableName string
 	InitialResponseName  string
+
+	// Escaping sanitizes the content of the documents before formatting
+	// them with the document prompt.
+	Escaping DocumentEscaping
 }
 
 var _ Chain = RefineDocuments{}
@@ -122,7 +126,7 @@ func (c RefineDocuments) constructRefineInputs(doc schema.Document, lastResponse
 func (c RefineDocuments) getBaseInputs(doc schema.Document, rest map[string]any) (map[string]any, error) {
 	var err error
 	baseInfo := make(map[string]any, len(doc.Metadata)+1)
-	baseInfo["page_content"] = doc.PageContent
+	baseInfo["page_content"] = c.Escaping.Escape(doc.PageContent)
 	for key, value := range doc.Metadata {
 		baseInfo[key] = value
 	}
@@ -34,6 +34,9 @@ type StuffDocuments struct {
 
 	// Separator is the string used to join the documents.
 	Separator string
+
+	// Escaping sanitizes the content of the documents before joining them.
+	Escaping DocumentEscaping
 }
 
 var _ Chain = StuffDocuments{}
@@ -88,7 +91,7 @@ func (c StuffDocuments) joinDocuments(docs []schema.Document) string {
 	var text string
 	docLen := len(docs)
 	for k, doc := range docs {
-		text += doc.PageContent
+		text += c.Escaping.Escape(doc.PageContent)
 		if k != docLen-1 {
 			text += c.Separator
 		}
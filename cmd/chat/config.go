@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tmc/langchaingo/util/alloydbutil"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	defaultIPType       = "PUBLIC"
+	defaultNumDocuments = 4
+)
+
+// config is the chat configuration, read from a YAML file in which
+// ${VARIABLE} references are expanded from the environment, e.g. for the
+// database password. See config.example.yaml.
+type config struct {
+	AlloyDB     alloyDBConfig     `json:"alloydb"`
+	VectorStore vectorStoreConfig `json:"vectorstore"`
+	ChatHistory chatHistoryConfig `json:"chat_history"`
+	LLM         llmConfig         `json:"llm"`
+}
+
+type alloyDBConfig struct {
+	User      string `json:"user"`
+	Password  string `json:"password"`
+	Database  string `json:"database"`
+	ProjectID string `json:"project_id"`
+	Region    string `json:"region"`
+	Cluster   string `json:"cluster"`
+	Instance  string `json:"instance"`
+	IPType    string `json:"ip_type"`
+}
+
+type vectorStoreConfig struct {
+	Table        string `json:"table"`
+	SchemaName   string `json:"schema_name"`
+	NumDocuments int    `json:"num_documents"`
+}
+
+type chatHistoryConfig struct {
+	Table string `json:"table"`
+}
+
+type llmConfig struct {
+	// Provider is one of the keys of providers.
+	Provider       string `json:"provider"`
+	Model          string `json:"model"`
+	EmbeddingModel string `json:"embedding_model"`
+	// ServerURL is the URL of the Ollama server.
+	ServerURL string `json:"server_url"`
+	// Location is the Google Cloud location of Vertex AI.
+	Location string `json:"location"`
+}
+
+// loadConfig reads the configuration file at path, sets the defaults and
+// validates it.
+func loadConfig(path string) (config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config{}, fmt.Errorf("failed to read config: %w", err)
+	}
+	return parseConfig(data)
+}
+
+// parseConfig parses a YAML configuration, rejecting unknown fields.
+func parseConfig(data []byte) (config, error) {
+	var cfg config
+	if err := yaml.UnmarshalStrict([]byte(os.ExpandEnv(string(data))), &cfg); err != nil {
+		return config{}, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if cfg.AlloyDB.IPType == "" {
+		cfg.AlloyDB.IPType = defaultIPType
+	}
+	if cfg.VectorStore.NumDocuments == 0 {
+		cfg.VectorStore.NumDocuments = defaultNumDocuments
+	}
+	if err := cfg.validate(); err != nil {
+		return config{}, err
+	}
+	return cfg, nil
+}
+
+// validate returns the problems of the configuration which aren't checked by
+// the engine, joined.
+func (c config) validate() error {
+	var problems []error
+	if c.VectorStore.Table == "" {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "vectorstore.table",
+			Problem: "missing vector store table",
+			Hint:    "set the table the documents were ingested into",
+		})
+	}
+	if c.VectorStore.NumDocuments < 0 {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "vectorstore.num_documents",
+			Problem: fmt.Sprintf("negative number of documents %d", c.VectorStore.NumDocuments),
+			Hint:    "set a positive number of documents to retrieve, or leave it unset for 4",
+		})
+	}
+	if c.ChatHistory.Table == "" {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "chat_history.table",
+			Problem: "missing chat history table",
+			Hint:    "set the table the conversations are stored in, it is created if missing",
+		})
+	}
+	if _, ok := providers[c.LLM.Provider]; !ok {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "llm.provider",
+			Problem: fmt.Sprintf("unknown provider %q", c.LLM.Provider),
+			Hint:    fmt.Sprintf("use one of: %s", strings.Join(providerNames(), ", ")),
+		})
+	}
+	return errors.Join(problems...)
+}
+
+// engineOptions returns the options of the engine connecting to the
+// configured instance.
+func (c alloyDBConfig) engineOptions() []alloydbutil.Option {
+	return []alloydbutil.Option{
+		alloydbutil.WithUser(c.User),
+		alloydbutil.WithPassword(c.Password),
+		alloydbutil.WithDatabase(c.Database),
+		alloydbutil.WithAlloyDBInstance(c.ProjectID, c.Region, c.Cluster, c.Instance),
+		alloydbutil.WithIPType(c.IPType),
+	}
+}
+
+func providerNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+func TestLoadConfigExample(t *testing.T) {
+	t.Setenv("ALLOYDB_PASSWORD", "secret")
+	cfg, err := loadConfig("config.example.yaml")
+	require.NoError(t, err)
+	require.Equal(t, "secret", cfg.AlloyDB.Password)
+	require.Equal(t, "documents", cfg.VectorStore.Table)
+	require.Equal(t, "openai", cfg.LLM.Provider)
+	require.NoError(t, alloydbutil.ValidateOptions(cfg.AlloyDB.engineOptions()...))
+}
+
+func TestParseConfigDefaults(t *testing.T) {
+	t.Parallel()
+	cfg, err := parseConfig([]byte(`
+vectorstore:
+  table: documents
+chat_history:
+  table: chat_history
+llm:
+  provider: ollama
+`))
+	require.NoError(t, err)
+	require.Equal(t, defaultIPType, cfg.AlloyDB.IPType)
+	require.Equal(t, defaultNumDocuments, cfg.VectorStore.NumDocuments)
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	t.Parallel()
+	_, err := parseConfig([]byte(`
+vectorstore:
+  num_documents: -1
+llm:
+  provider: unknown
+`))
+	var configErr *alloydbutil.ConfigError
+	require.True(t, errors.As(err, &configErr))
+	for _, field := range []string{"vectorstore.table", "vectorstore.num_documents", "chat_history.table", "llm.provider"} {
+		require.ErrorContains(t, err, field)
+	}
+
+	_, err = parseConfig([]byte(`
+vectorstore:
+  tabel: documents
+`))
+	require.ErrorContains(t, err, "tabel")
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	t.Parallel()
+	_, err := loadConfig("missing.yaml")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,100 @@
+// Command chat is an interactive terminal chat over the documents of an
+// AlloyDB vector store, to try out a deployed configuration without writing
+// a client.
+//
+// Usage:
+//
+//	go run github.com/tmc/langchaingo/cmd/chat -config chat.yaml -session my-session
+//
+// The configuration names the AlloyDB instance, the vector store and chat
+// history tables, and the LLM provider, see config.example.yaml. Questions
+// are answered by a conversational retrieval chain, with the answers
+// streamed as they are generated and the conversation kept in the chat
+// history table. The vector store is opened in read-only mode. Besides
+// questions, the following commands help debugging the configuration:
+//
+//	/search <query>  show the documents retrieved for query, with their scores
+//	/history         show the messages of the session
+//	/help            show the commands
+//	/quit            exit
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/memory"
+	alloydbmemory "github.com/tmc/langchaingo/memory/alloydb"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/alloydb"
+)
+
+func main() {
+	configPath := flag.String("config", "chat.yaml", "path of the YAML configuration")
+	sessionID := flag.String("session", "", "chat history session to resume, a new one by default")
+	flag.Parse()
+
+	if err := run(context.Background(), *configPath, *sessionID); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, configPath, sessionID string) error {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if sessionID == "" {
+		sessionID = fmt.Sprintf("chat-%d", time.Now().Unix())
+	}
+
+	llm, embedder, err := providers[cfg.LLM.Provider](ctx, cfg)
+	if err != nil {
+		return err
+	}
+	engine, err := alloydbutil.NewPostgresEngine(ctx, cfg.AlloyDB.engineOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to create engine: %w", err)
+	}
+	defer engine.Close()
+
+	storeOpts := []alloydb.VectorStoreOption{alloydb.WithK(cfg.VectorStore.NumDocuments), alloydb.WithReadOnly()}
+	if cfg.VectorStore.SchemaName != "" {
+		storeOpts = append(storeOpts, alloydb.WithSchemaName(cfg.VectorStore.SchemaName))
+	}
+	store, err := alloydb.NewVectorStore(engine, embedder, cfg.VectorStore.Table, storeOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to create vector store: %w", err)
+	}
+	if err := engine.InitChatHistoryTable(ctx, cfg.ChatHistory.Table); err != nil {
+		return fmt.Errorf("failed to initialize chat history table: %w", err)
+	}
+	history, err := alloydbmemory.NewChatMessageHistory(ctx, engine, cfg.ChatHistory.Table, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to create chat history: %w", err)
+	}
+
+	chain := chains.NewConversationalRetrievalQAFromLLM(
+		llm,
+		vectorstores.ToRetriever(&store, cfg.VectorStore.NumDocuments),
+		memory.NewConversationBuffer(memory.WithChatHistory(&history)),
+	)
+	r := &repl{
+		in:  os.Stdin,
+		out: os.Stdout,
+		ask: func(ctx context.Context, question string, stream func(context.Context, []byte) error) (string, error) {
+			return chains.Run(ctx, chain, question, chains.WithStreamingFunc(stream))
+		},
+		store:        &store,
+		history:      &history,
+		numDocuments: cfg.VectorStore.NumDocuments,
+	}
+	fmt.Printf("session %s, type /help for the commands\n", sessionID)
+	return r.run(ctx)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/googleai"
+	"github.com/tmc/langchaingo/llms/googleai/vertex"
+	"github.com/tmc/langchaingo/llms/ollama"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// provider creates the LLM and the embedder of an LLM provider. The
+// embedder must be the one the documents were ingested with.
+type provider func(ctx context.Context, cfg config) (llms.Model, embeddings.Embedder, error)
+
+var providers = map[string]provider{
+	"openai": func(_ context.Context, cfg config) (llms.Model, embeddings.Embedder, error) {
+		llm, err := openai.New(openai.WithModel(or(cfg.LLM.Model, "gpt-4o-mini")),
+			openai.WithEmbeddingModel(or(cfg.LLM.EmbeddingModel, "text-embedding-3-small")))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create LLM: %w", err)
+		}
+		embedder, err := embeddings.NewEmbedder(llm)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create embedder: %w", err)
+		}
+		return llm, embedder, nil
+	},
+	"vertex": func(ctx context.Context, cfg config) (llms.Model, embeddings.Embedder, error) {
+		llm, err := vertex.New(ctx,
+			googleai.WithCloudProject(cfg.AlloyDB.ProjectID),
+			googleai.WithCloudLocation(or(cfg.LLM.Location, "us-central1")),
+			googleai.WithDefaultModel(or(cfg.LLM.Model, "gemini-1.5-flash")),
+			googleai.WithDefaultEmbeddingModel(or(cfg.LLM.EmbeddingModel, "text-embedding-005")))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create LLM: %w", err)
+		}
+		embedder, err := embeddings.NewEmbedder(llm)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create embedder: %w", err)
+		}
+		return llm, embedder, nil
+	},
+	"ollama": func(_ context.Context, cfg config) (llms.Model, embeddings.Embedder, error) {
+		serverURL := or(cfg.LLM.ServerURL, "http://localhost:11434")
+		llm, err := ollama.New(ollama.WithServerURL(serverURL), ollama.WithModel(or(cfg.LLM.Model, "llama3")))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create LLM: %w", err)
+		}
+		embeddingLLM, err := ollama.New(ollama.WithServerURL(serverURL),
+			ollama.WithModel(or(cfg.LLM.EmbeddingModel, "nomic-embed-text")))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create embedding LLM: %w", err)
+		}
+		embedder, err := embeddings.NewEmbedder(embeddingLLM)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create embedder: %w", err)
+		}
+		return llm, embedder, nil
+	},
+}
+
+// or returns value, or fallback when it is empty.
+func or(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+const (
+	prompt = "> "
+	// previewLength is the number of characters of the documents printed by
+	// /search.
+	previewLength = 200
+)
+
+const help = `Type a question to ask it, or a command:
+  /search <query>  show the documents retrieved for query, with their scores
+  /history         show the messages of the session
+  /help            show this help
+  /quit            exit
+`
+
+// askFunc asks question to the chain, streaming the chunks of the answer to
+// stream when the LLM supports streaming, and returns the answer.
+type askFunc func(ctx context.Context, question string, stream func(ctx context.Context, chunk []byte) error) (string, error)
+
+// repl is an interactive chat reading questions and commands from in and
+// writing the answers to out.
+type repl struct {
+	in           io.Reader
+	out          io.Writer
+	ask          askFunc
+	store        vectorstores.VectorStore
+	history      schema.ChatMessageHistory
+	numDocuments int
+}
+
+// run reads lines until in is exhausted, /quit is typed or ctx is done.
+// Errors of the questions and commands are printed, only the errors reading
+// in or writing out are returned.
+func (r *repl) run(ctx context.Context) error {
+	scanner := bufio.NewScanner(r.in)
+	for {
+		if _, err := fmt.Fprint(r.out, prompt); err != nil {
+			return err
+		}
+		if !scanner.Scan() {
+			if _, err := fmt.Fprintln(r.out); err != nil {
+				return err
+			}
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "/quit" || line == "/exit" {
+			return nil
+		}
+		if err := r.handle(ctx, line); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if _, err := fmt.Fprintf(r.out, "error: %v\n", err); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handle runs the command or asks the question of line.
+func (r *repl) handle(ctx context.Context, line string) error {
+	command, arg, _ := strings.Cut(line, " ")
+	switch command {
+	case "":
+		return nil
+	case "/help":
+		_, err := fmt.Fprint(r.out, help)
+		return err
+	case "/search":
+		return r.search(ctx, strings.TrimSpace(arg))
+	case "/history":
+		return r.printHistory(ctx)
+	}
+	if strings.HasPrefix(command, "/") {
+		return fmt.Errorf("unknown command %s, type /help for the commands", command)
+	}
+	return r.question(ctx, line)
+}
+
+// question asks line, printing the answer as it is streamed.
+func (r *repl) question(ctx context.Context, line string) error {
+	streamed := false
+	answer, err := r.ask(ctx, line, func(_ context.Context, chunk []byte) error {
+		streamed = true
+		_, err := r.out.Write(chunk)
+		return err
+	})
+	if streamed {
+		if _, err := fmt.Fprintln(r.out); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !streamed {
+		_, err = fmt.Fprintln(r.out, answer)
+	}
+	return err
+}
+
+// search prints the documents retrieved for query, as the chain retrieves
+// them.
+func (r *repl) search(ctx context.Context, query string) error {
+	if query == "" {
+		return fmt.Errorf("missing query, usage: /search <query>")
+	}
+	docs, err := r.store.SimilaritySearch(ctx, query, r.numDocuments)
+	if err != nil {
+		return fmt.Errorf("failed to search documents: %w", err)
+	}
+	if len(docs) == 0 {
+		_, err := fmt.Fprintln(r.out, "no documents found")
+		return err
+	}
+	for i, doc := range docs {
+		if _, err := fmt.Fprintf(r.out, "[%d] score %.4f %v\n    %s\n",
+			i+1, doc.Score, doc.Metadata, preview(doc.PageContent)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printHistory prints the messages of the session.
+func (r *repl) printHistory(ctx context.Context) error {
+	messages, err := r.history.Messages(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get messages: %w", err)
+	}
+	if len(messages) == 0 {
+		_, err := fmt.Fprintln(r.out, "no messages")
+		return err
+	}
+	for _, message := range messages {
+		if _, err := fmt.Fprintf(r.out, "%s: %s\n", message.GetType(), message.GetContent()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preview returns the first characters of content on a single line.
+func preview(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+	runes := []rune(content)
+	if len(runes) <= previewLength {
+		return content
+	}
+	return string(runes[:previewLength]) + "..."
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+type fakeStore struct {
+	docs []schema.Document
+	k    int
+}
+
+func (s *fakeStore) AddDocuments(context.Context, []schema.Document, ...vectorstores.Option) ([]string, error) {
+	return nil, nil
+}
+
+func (s *fakeStore) SimilaritySearch(_ context.Context, _ string, k int, _ ...vectorstores.Option) ([]schema.Document, error) { // nolint: lll
+	s.k = k
+	return s.docs, nil
+}
+
+func runREPL(t *testing.T, input string, ask askFunc) string {
+	t.Helper()
+	history := memory.NewChatMessageHistory(memory.WithPreviousMessages([]llms.ChatMessage{
+		llms.HumanChatMessage{Content: "hi"},
+		llms.AIChatMessage{Content: "hello"},
+	}))
+	var out strings.Builder
+	r := &repl{
+		in:  strings.NewReader(input),
+		out: &out,
+		ask: ask,
+		store: &fakeStore{docs: []schema.Document{
+			{PageContent: "alloydb\n  docs", Metadata: map[string]any{"source": "a.txt"}, Score: 0.5},
+		}},
+		history:      history,
+		numDocuments: 4,
+	}
+	require.NoError(t, r.run(context.Background()))
+	return out.String()
+}
+
+func TestREPLStreamsAnswers(t *testing.T) {
+	t.Parallel()
+	out := runREPL(t, "what is alloydb?\n", func(ctx context.Context, question string, stream func(context.Context, []byte) error) (string, error) { // nolint: lll
+		require.Equal(t, "what is alloydb?", question)
+		for _, chunk := range []string{"a ", "database"} {
+			require.NoError(t, stream(ctx, []byte(chunk)))
+		}
+		return "a database", nil
+	})
+	require.Equal(t, "> a database\n> \n", out)
+}
+
+func TestREPLPrintsUnstreamedAnswers(t *testing.T) {
+	t.Parallel()
+	out := runREPL(t, "question\n/quit\nignored\n", func(context.Context, string, func(context.Context, []byte) error) (string, error) { // nolint: lll
+		return "answer", nil
+	})
+	require.Equal(t, "> answer\n> ", out)
+}
+
+func TestREPLCommands(t *testing.T) {
+	t.Parallel()
+	ask := func(context.Context, string, func(context.Context, []byte) error) (string, error) {
+		return "", errors.New("chain failed")
+	}
+	out := runREPL(t, "\n/search alloydb\n/search\n/history\n/unknown\nquestion\n", ask)
+	require.Contains(t, out, "[1] score 0.5000 map[source:a.txt]\n    alloydb docs\n")
+	require.Contains(t, out, "error: missing query")
+	require.Contains(t, out, "human: hi\nai: hello\n")
+	require.Contains(t, out, "error: unknown command /unknown")
+	require.Contains(t, out, "error: chain failed")
+}
+
+func TestPreview(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "a b", preview(" a\n\tb "))
+	long := preview(strings.Repeat("é", previewLength+1))
+	require.Equal(t, strings.Repeat("é", previewLength)+"...", long)
+}
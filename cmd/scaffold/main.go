@@ -0,0 +1,45 @@
+// Command scaffold generates a runnable example project wired to the AlloyDB
+// engine, vector store and chat history, and to a chosen LLM provider.
+//
+// Usage:
+//
+//	go run github.com/tmc/langchaingo/cmd/scaffold -out ./myapp -module example.com/myapp -provider vertex
+//
+// The generated project contains an HTTP server answering questions over the
+// ingested documents, an ingest worker loading text files into the vector
+// store, a Dockerfile and a README describing the configuration.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+func main() {
+	out := flag.String("out", "", "directory to write the project to")
+	module := flag.String("module", "", "module path of the generated project")
+	providerName := flag.String("provider", "vertex",
+		fmt.Sprintf("LLM provider, one of: %s", strings.Join(providerNames(), ", ")))
+	force := flag.Bool("force", false, "overwrite existing files")
+	flag.Parse()
+
+	if *out == "" || *module == "" {
+		flag.Usage()
+		log.Fatal("-out and -module are required")
+	}
+	cfg, err := newConfig(*module, *providerName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	files, err := generate(*out, cfg, *force)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		fmt.Println("created", f)
+	}
+}
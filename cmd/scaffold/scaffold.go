@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//go:embed all:templates
+var templates embed.FS
+
+// ErrFileExists is returned when a generated file already exists and
+// overwriting was not requested.
+var ErrFileExists = errors.New("file already exists")
+
+// provider describes how the generated project creates the LLM and the
+// embedder of an LLM provider.
+type provider struct {
+	// Imports are the packages used by Setup.
+	Imports []string
+	// Setup are the statements assigning llm and embedder from cfg.
+	Setup string
+	// VectorSize is the dimension of the default embedding model.
+	VectorSize int
+	// Env are the provider specific environment variables and their
+	// example values.
+	Env [][2]string
+}
+
+var providers = map[string]provider{
+	"openai": {
+		Imports: []string{"github.com/tmc/langchaingo/llms/openai"},
+		Setup: `llm, err := openai.New(openai.WithModel(env("OPENAI_MODEL", "gpt-4o-mini")),
+		openai.WithEmbeddingModel(env("OPENAI_EMBEDDING_MODEL", "text-embedding-3-small")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM: %w", err)
+	}
+	embedder, err := embeddings.NewEmbedder(llm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}`,
+		VectorSize: 1536,
+		Env:        [][2]string{{"OPENAI_API_KEY", "sk-..."}},
+	},
+	"vertex": {
+		Imports: []string{
+			"github.com/tmc/langchaingo/llms/googleai",
+			"github.com/tmc/langchaingo/llms/googleai/vertex",
+		},
+		Setup: `llm, err := vertex.New(ctx,
+		googleai.WithCloudProject(cfg.ProjectID),
+		googleai.WithCloudLocation(env("GOOGLE_CLOUD_LOCATION", "us-central1")),
+		googleai.WithDefaultModel(env("VERTEX_MODEL", "gemini-1.5-flash")),
+		googleai.WithDefaultEmbeddingModel(env("VERTEX_EMBEDDING_MODEL", "text-embedding-005")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM: %w", err)
+	}
+	embedder, err := embeddings.NewEmbedder(llm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}`,
+		VectorSize: 768,
+		Env:        [][2]string{{"GOOGLE_CLOUD_LOCATION", "us-central1"}},
+	},
+	"ollama": {
+		Imports: []string{"github.com/tmc/langchaingo/llms/ollama"},
+		Setup: `serverURL := env("OLLAMA_SERVER_URL", "http://localhost:11434")
+	llm, err := ollama.New(ollama.WithServerURL(serverURL), ollama.WithModel(env("OLLAMA_MODEL", "llama3")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM: %w", err)
+	}
+	embeddingLLM, err := ollama.New(ollama.WithServerURL(serverURL),
+		ollama.WithModel(env("OLLAMA_EMBEDDING_MODEL", "nomic-embed-text")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding LLM: %w", err)
+	}
+	embedder, err := embeddings.NewEmbedder(embeddingLLM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}`,
+		VectorSize: 768,
+		Env:        [][2]string{{"OLLAMA_SERVER_URL", "http://localhost:11434"}},
+	},
+}
+
+// config is the data the templates are executed with.
+type config struct {
+	Module       string
+	Name         string
+	ProviderName string
+	Provider     provider
+}
+
+func newConfig(module, providerName string) (config, error) {
+	p, ok := providers[providerName]
+	if !ok {
+		return config{}, fmt.Errorf("unknown provider %q, expected one of: %s",
+			providerName, strings.Join(providerNames(), ", "))
+	}
+	return config{
+		Module:       module,
+		Name:         path.Base(module),
+		ProviderName: providerName,
+		Provider:     p,
+	}, nil
+}
+
+func providerNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// generate renders the templates into dir and returns the created files.
+// Generated Go files are formatted.
+func generate(dir string, cfg config, force bool) ([]string, error) {
+	var created []string
+	err := fs.WalkDir(templates, "templates", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := render(name, cfg)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(strings.TrimSuffix(strings.TrimPrefix(name, "templates/"), ".tmpl")))
+		if _, err := os.Stat(target); err == nil && !force {
+			return fmt.Errorf("%s: %w", target, ErrFileExists)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(target, content, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		created = append(created, target)
+		return nil
+	})
+	return created, err
+}
+
+// render executes the template name with cfg.
+func render(name string, cfg config) ([]byte, error) {
+	tmpl, err := template.ParseFS(templates, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, cfg); err != nil {
+		return nil, fmt.Errorf("failed to execute template %s: %w", name, err)
+	}
+	if !strings.HasSuffix(name, ".go.tmpl") {
+		return buf.Bytes(), nil
+	}
+	content, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", name, err)
+	}
+	return content, nil
+}
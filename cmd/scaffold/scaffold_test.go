@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+	for _, name := range providerNames() {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := newConfig("example.com/myapp", name)
+			require.NoError(t, err)
+			dir := t.TempDir()
+			files, err := generate(dir, cfg, false)
+			require.NoError(t, err)
+
+			for _, f := range []string{
+				"go.mod", ".env.example", "Dockerfile", "README.md",
+				"internal/app/app.go", "cmd/server/main.go", "cmd/ingest/main.go",
+			} {
+				require.Contains(t, files, filepath.Join(dir, f))
+			}
+			for _, f := range files {
+				if !strings.HasSuffix(f, ".go") {
+					continue
+				}
+				_, err := parser.ParseFile(token.NewFileSet(), f, nil, parser.AllErrors)
+				require.NoError(t, err)
+			}
+
+			mod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+			require.NoError(t, err)
+			require.Contains(t, string(mod), "module example.com/myapp")
+
+			_, err = generate(dir, cfg, false)
+			require.ErrorIs(t, err, ErrFileExists)
+			_, err = generate(dir, cfg, true)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNewConfigUnknownProvider(t *testing.T) {
+	t.Parallel()
+	_, err := newConfig("example.com/myapp", "unknown")
+	require.Error(t, err)
+	require.False(t, errors.Is(err, ErrFileExists))
+}
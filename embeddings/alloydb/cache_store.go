@@ -0,0 +1,97 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+const defaultSchemaName = "public"
+
+// CacheStore is an embeddings.CacheStore keeping the vectors in a table
+// created with alloydbutil.InitEmbeddingCacheTable.
+type CacheStore struct {
+	engine     alloydbutil.PostgresEngine
+	tableName  string
+	schemaName string
+}
+
+var _ embeddings.CacheStore = CacheStore{}
+
+// CacheStoreOption is a function for creating a new CacheStore with other
+// than the default values.
+type CacheStoreOption func(s *CacheStore)
+
+// WithSchemaName sets the schema of the table of the CacheStore. It defaults
+// to "public".
+func WithSchemaName(schemaName string) CacheStoreOption {
+	return func(s *CacheStore) {
+		s.schemaName = schemaName
+	}
+}
+
+// NewCacheStore creates a new CacheStore keeping the vectors in tableName.
+func NewCacheStore(engine alloydbutil.PostgresEngine, tableName string, opts ...CacheStoreOption) (CacheStore, error) {
+	s := CacheStore{
+		engine:     engine,
+		tableName:  tableName,
+		schemaName: defaultSchemaName,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.engine.Pool == nil {
+		return CacheStore{}, errors.New("missing cache store engine")
+	}
+	if s.tableName == "" {
+		return CacheStore{}, errors.New("missing cache store table name")
+	}
+	return s, nil
+}
+
+// Get returns the vectors of keys, nil for the keys not found.
+func (s CacheStore) Get(ctx context.Context, keys []string) ([][]float32, error) {
+	query := fmt.Sprintf(`SELECT key, embedding FROM %q.%q WHERE key = ANY($1)`, s.schemaName, s.tableName)
+	rows, err := s.engine.Pool.Query(ctx, query, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embeddings: %w", err)
+	}
+	found := make(map[string][]float32, len(keys))
+	var (
+		key    string
+		vector []float32
+	)
+	_, err = pgx.ForEachRow(rows, []any{&key, &vector}, func() error {
+		found[key] = vector
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embeddings: %w", err)
+	}
+	vectors := make([][]float32, len(keys))
+	for i, key := range keys {
+		vectors[i] = found[key]
+	}
+	return vectors, nil
+}
+
+// Set inserts or replaces the vectors of keys.
+func (s CacheStore) Set(ctx context.Context, keys []string, vectors [][]float32) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	query := fmt.Sprintf(`INSERT INTO %q.%q (key, embedding) VALUES ($1, $2)
+ON CONFLICT (key) DO UPDATE SET embedding = EXCLUDED.embedding, created_at = NOW()`, s.schemaName, s.tableName)
+	b := &pgx.Batch{}
+	for i, key := range keys {
+		b.Queue(query, key, vectors[i])
+	}
+	if err := s.engine.Pool.SendBatch(ctx, b).Close(); err != nil {
+		return fmt.Errorf("failed to set embeddings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package alloydb
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+func TestNewCacheStoreValidation(t *testing.T) {
+	t.Parallel()
+	if _, err := NewCacheStore(alloydbutil.PostgresEngine{}, "embeddings"); err == nil {
+		t.Error("missing engine: expected an error")
+	}
+	engine := alloydbutil.PostgresEngine{Pool: &pgxpool.Pool{}}
+	if _, err := NewCacheStore(engine, ""); err == nil {
+		t.Error("missing table: expected an error")
+	}
+
+	s, err := NewCacheStore(engine, "embeddings", WithSchemaName("cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.schemaName != "cache" {
+		t.Errorf("expected schema cache, got %s", s.schemaName)
+	}
+}
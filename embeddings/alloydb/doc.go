@@ -0,0 +1,16 @@
+// Package alloydb provides an embeddings.CacheStore keeping the vectors of
+// embedded texts in an AlloyDB table, so that they are shared by processes
+// and survive restarts:
+//
+//	if err := engine.InitEmbeddingCacheTable(ctx, alloydbutil.EmbeddingCacheTableOptions{
+//	    TableName: "embeddings",
+//	}); err != nil {
+//	    return err
+//	}
+//	store, err := alloydb.NewCacheStore(engine, "embeddings")
+//	if err != nil {
+//	    return err
+//	}
+//	embedder := embeddings.NewCacheBackedEmbedder(vertexEmbedder, store,
+//	    embeddings.WithCacheNamespace("text-embedding-005"))
+package alloydb
@@ -0,0 +1,189 @@
+package embeddings
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	_defaultBM25K1        = 1.2
+	_defaultBM25B         = 0.75
+	_defaultBM25Dimension = 1 << 20
+)
+
+// BM25Stats are the statistics of the corpus of a BM25 embedder, which can
+// be saved to embed the queries of a corpus without fitting it again.
+type BM25Stats struct {
+	// Documents is the number of documents.
+	Documents int `json:"documents"`
+	// Tokens is the total number of tokens of the documents.
+	Tokens int `json:"tokens"`
+	// DocumentFrequencies are the numbers of documents containing each
+	// dimension.
+	DocumentFrequencies map[int]int `json:"documentFrequencies"`
+}
+
+// BM25 is a SparseEmbedder weighting the terms of texts with the BM25
+// ranking function, so that the dot product of the embeddings of a query and
+// of a document is their BM25 score. The terms are hashed into the dimensions
+// of the embeddings, which needs no vocabulary, and the statistics of the
+// corpus are collected by Fit. It is safe for concurrent use.
+type BM25 struct {
+	k1        float64
+	b         float64
+	dimension int
+
+	mu    sync.RWMutex
+	stats BM25Stats
+}
+
+var _ SparseEmbedder = &BM25{}
+
+// BM25Option is a function for configuring a BM25 embedder.
+type BM25Option func(e *BM25)
+
+// WithBM25Parameters sets the term frequency saturation k1, 1.2 by default,
+// and the length normalization b, 0.75 by default, of the BM25 weights.
+func WithBM25Parameters(k1, b float64) BM25Option {
+	return func(e *BM25) {
+		e.k1 = k1
+		e.b = b
+	}
+}
+
+// WithBM25Dimension sets the dimension of the embeddings, 2^20 by default.
+func WithBM25Dimension(dimension int) BM25Option {
+	return func(e *BM25) {
+		e.dimension = dimension
+	}
+}
+
+// WithBM25Stats sets the statistics of the corpus, e.g. saved from another
+// embedder, to which Fit adds.
+func WithBM25Stats(stats BM25Stats) BM25Option {
+	return func(e *BM25) {
+		e.stats = stats
+	}
+}
+
+// NewBM25 returns a BM25 embedder.
+func NewBM25(opts ...BM25Option) *BM25 {
+	e := &BM25{
+		k1:        _defaultBM25K1,
+		b:         _defaultBM25B,
+		dimension: _defaultBM25Dimension,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.stats.DocumentFrequencies == nil {
+		e.stats.DocumentFrequencies = map[int]int{}
+	}
+	return e
+}
+
+// Fit adds texts to the statistics of the corpus, which weight the terms by
+// their rarity in the queries and normalize the lengths of the documents.
+func (e *BM25) Fit(texts []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, text := range texts {
+		counts, n := e.termCounts(text)
+		e.stats.Documents++
+		e.stats.Tokens += n
+		for index := range counts {
+			e.stats.DocumentFrequencies[index]++
+		}
+	}
+}
+
+// Stats returns a copy of the statistics of the corpus.
+func (e *BM25) Stats() BM25Stats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	stats := e.stats
+	stats.DocumentFrequencies = make(map[int]int, len(e.stats.DocumentFrequencies))
+	for index, n := range e.stats.DocumentFrequencies {
+		stats.DocumentFrequencies[index] = n
+	}
+	return stats
+}
+
+// EmbedSparseDocuments returns the embeddings of texts, weighting their
+// terms by their saturated frequency normalized by the length of the text.
+func (e *BM25) EmbedSparseDocuments(_ context.Context, texts []string) ([]SparseVector, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	vectors := make([]SparseVector, len(texts))
+	for i, text := range texts {
+		counts, n := e.termCounts(text)
+		averageLength := float64(n)
+		if e.stats.Documents > 0 {
+			averageLength = float64(e.stats.Tokens) / float64(e.stats.Documents)
+		}
+		norm := 1.0
+		if averageLength > 0 {
+			norm = 1 - e.b + e.b*float64(n)/averageLength
+		}
+		weights := make(map[int]float64, len(counts))
+		for index, count := range counts {
+			tf := float64(count)
+			weights[index] = tf * (e.k1 + 1) / (tf + e.k1*norm)
+		}
+		vectors[i] = e.sparseVector(weights)
+	}
+	return vectors, nil
+}
+
+// EmbedSparseQuery returns the embedding of the query text, weighting its
+// terms by their inverse document frequency, or equally before Fit.
+func (e *BM25) EmbedSparseQuery(_ context.Context, text string) (SparseVector, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	counts, _ := e.termCounts(text)
+	weights := make(map[int]float64, len(counts))
+	for index := range counts {
+		weights[index] = 1
+		if e.stats.Documents > 0 {
+			df := float64(e.stats.DocumentFrequencies[index])
+			weights[index] = math.Log(1 + (float64(e.stats.Documents)-df+0.5)/(df+0.5))
+		}
+	}
+	return e.sparseVector(weights), nil
+}
+
+// termCounts returns the number of occurrences of the terms of text by
+// dimension, and the number of terms.
+func (e *BM25) termCounts(text string) (map[int]int, int) {
+	terms := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	counts := make(map[int]int, len(terms))
+	for _, term := range terms {
+		h := fnv.New32a()
+		h.Write([]byte(term))
+		counts[int(h.Sum32()%uint32(e.dimension))]++ //nolint:gosec
+	}
+	return counts, len(terms)
+}
+
+func (e *BM25) sparseVector(weights map[int]float64) SparseVector {
+	v := SparseVector{
+		Indices:   make([]int, 0, len(weights)),
+		Values:    make([]float32, 0, len(weights)),
+		Dimension: e.dimension,
+	}
+	for index := range weights {
+		v.Indices = append(v.Indices, index)
+	}
+	sort.Ints(v.Indices)
+	for _, index := range v.Indices {
+		v.Values = append(v.Values, float32(weights[index]))
+	}
+	return v
+}
@@ -0,0 +1,79 @@
+package embeddings
+
+import (
+	"context"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSparseVectorDot(t *testing.T) {
+	t.Parallel()
+
+	v := SparseVector{Indices: []int{1, 3, 5}, Values: []float32{1, 2, 3}, Dimension: 8}
+	w := SparseVector{Indices: []int{0, 3, 5, 7}, Values: []float32{4, 5, 6, 7}, Dimension: 8}
+	assert.InDelta(t, 28, v.Dot(w), 1e-6)
+	assert.InDelta(t, 28, w.Dot(v), 1e-6)
+	assert.Zero(t, v.Dot(SparseVector{}))
+}
+
+func TestBM25(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	corpus := []string{
+		"the cat sat on the mat",
+		"the dog sat on the log",
+		"the cat and the dog",
+		"a platypus",
+	}
+	e := NewBM25(WithBM25Dimension(1024))
+	e.Fit(corpus)
+	docs, err := e.EmbedSparseDocuments(ctx, corpus)
+	require.NoError(t, err)
+	require.Len(t, docs, len(corpus))
+	for _, doc := range docs {
+		assert.Equal(t, 1024, doc.Dimension)
+		assert.True(t, sort.IntsAreSorted(doc.Indices))
+	}
+
+	score := func(query string) []float32 {
+		t.Helper()
+		q, err := e.EmbedSparseQuery(ctx, query)
+		require.NoError(t, err)
+		scores := make([]float32, len(docs))
+		for i, doc := range docs {
+			scores[i] = q.Dot(doc)
+		}
+		return scores
+	}
+
+	scores := score("Cat!")
+	assert.Greater(t, scores[0], float32(0))
+	assert.Zero(t, scores[1])
+	// The shorter document is more relevant.
+	assert.Greater(t, scores[2], scores[0])
+
+	// The rare terms weigh more than the common ones.
+	scores = score("the platypus")
+	assert.Greater(t, scores[3], scores[0])
+
+	// The scores match the BM25 formula.
+	idf := math.Log(1 + (4-2+0.5)/(2+0.5))
+	averageLength := 19.0 / 4
+	tf := 1.0
+	want := idf * tf * 2.2 / (tf + 1.2*(1-0.75+0.75*5/averageLength))
+	assert.InDelta(t, want, score("cat")[2], 1e-5)
+
+	// The statistics can be restored.
+	stats := e.Stats()
+	assert.Equal(t, 4, stats.Documents)
+	assert.Equal(t, 19, stats.Tokens)
+	restored := NewBM25(WithBM25Dimension(1024), WithBM25Stats(stats))
+	q, err := restored.EmbedSparseQuery(ctx, "cat")
+	require.NoError(t, err)
+	assert.InDelta(t, want, q.Dot(docs[2]), 1e-5)
+}
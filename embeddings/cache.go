@@ -0,0 +1,147 @@
+package embeddings
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// CacheStore is the interface that needs to be implemented by the stores of
+// CacheBackedEmbedder, such as LRUCacheStore.
+type CacheStore interface {
+	// Get returns the vectors of keys, nil for the keys not found.
+	Get(ctx context.Context, keys []string) ([][]float32, error)
+	// Set stores the vectors of keys.
+	Set(ctx context.Context, keys []string, vectors [][]float32) error
+}
+
+// CacheBackedEmbedder is an Embedder caching the vectors of another
+// Embedder in a CacheStore, keyed by a hash of the texts, so that embedding
+// texts again, e.g. when re-indexing documents, only embeds the texts not
+// cached.
+type CacheBackedEmbedder struct {
+	embedder  Embedder
+	store     CacheStore
+	namespace string
+}
+
+var _ Embedder = &CacheBackedEmbedder{}
+
+// CacheOption is a function for configuring a CacheBackedEmbedder.
+type CacheOption func(e *CacheBackedEmbedder)
+
+// WithCacheNamespace sets the namespace of the keys of the cache, e.g. the
+// name of the embedding model, so that embedders with different models can
+// share a store.
+func WithCacheNamespace(namespace string) CacheOption {
+	return func(e *CacheBackedEmbedder) {
+		e.namespace = namespace
+	}
+}
+
+// NewCacheBackedEmbedder returns an Embedder caching the vectors of
+// embedder in store.
+func NewCacheBackedEmbedder(embedder Embedder, store CacheStore, opts ...CacheOption) *CacheBackedEmbedder {
+	e := &CacheBackedEmbedder{
+		embedder: embedder,
+		store:    store,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// EmbedDocuments returns the cached vectors of texts, embedding and caching
+// the others.
+func (e *CacheBackedEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	keys := make([]string, len(texts))
+	for i, text := range texts {
+		keys[i] = e.key("", text)
+	}
+	vectors, err := e.store.Get(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached embeddings: %w", err)
+	}
+	if len(vectors) != len(keys) {
+		return nil, fmt.Errorf("cache store returned %d embeddings for %d keys", len(vectors), len(keys))
+	}
+
+	// The same text may be passed several times, but is embedded once.
+	var (
+		missingTexts []string
+		missingKeys  []string
+		missing      = map[string][]int{}
+	)
+	for i, vector := range vectors {
+		if vector != nil {
+			continue
+		}
+		if _, ok := missing[keys[i]]; !ok {
+			missingTexts = append(missingTexts, texts[i])
+			missingKeys = append(missingKeys, keys[i])
+		}
+		missing[keys[i]] = append(missing[keys[i]], i)
+	}
+	if len(missingTexts) == 0 {
+		return vectors, nil
+	}
+
+	embedded, err := e.embedder.EmbedDocuments(ctx, missingTexts)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(missingTexts) {
+		return nil, fmt.Errorf("embedder returned %d embeddings for %d texts", len(embedded), len(missingTexts))
+	}
+	if err := e.store.Set(ctx, missingKeys, embedded); err != nil {
+		return nil, fmt.Errorf("failed to cache embeddings: %w", err)
+	}
+	for i, key := range missingKeys {
+		for _, j := range missing[key] {
+			vectors[j] = embedded[i]
+		}
+	}
+	return vectors, nil
+}
+
+// EmbedQuery returns the cached vector of the query text, embedding and
+// caching it if it isn't cached.
+func (e *CacheBackedEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	key := e.key("query:", text)
+	vectors, err := e.store.Get(ctx, []string{key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached embeddings: %w", err)
+	}
+	if len(vectors) == 1 && vectors[0] != nil {
+		return vectors[0], nil
+	}
+	vector, err := e.embedder.EmbedQuery(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.store.Set(ctx, []string{key}, [][]float32{vector}); err != nil {
+		return nil, fmt.Errorf("failed to cache embeddings: %w", err)
+	}
+	return vector, nil
+}
+
+// Dimensions returns the dimension of the embeddings of the cached embedder
+// if it knows it, 0 otherwise.
+func (e *CacheBackedEmbedder) Dimensions() int {
+	if d, ok := e.embedder.(Dimensioner); ok {
+		return d.Dimensions()
+	}
+	return 0
+}
+
+// key returns the key of the vector of text in the cache. The queries are
+// keyed with a prefix, as some models embed them differently.
+func (e *CacheBackedEmbedder) key(prefix, text string) string {
+	hash := sha256.Sum256([]byte(text))
+	if e.namespace != "" {
+		prefix = e.namespace + ":" + prefix
+	}
+	return prefix + hex.EncodeToString(hash[:])
+}
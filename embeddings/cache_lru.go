@@ -0,0 +1,76 @@
+package embeddings
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// LRUCacheStore is an in-memory CacheStore keeping the most recently used
+// vectors. It is safe for concurrent use.
+type LRUCacheStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  *list.List
+	elements map[string]*list.Element
+}
+
+var _ CacheStore = &LRUCacheStore{}
+
+// lruEntry is an entry of an LRUCacheStore.
+type lruEntry struct {
+	key    string
+	vector []float32
+}
+
+// NewLRUCacheStore returns an LRUCacheStore keeping at most capacity
+// vectors, or every vector if capacity isn't positive.
+func NewLRUCacheStore(capacity int) *LRUCacheStore {
+	return &LRUCacheStore{
+		capacity: capacity,
+		entries:  list.New(),
+		elements: map[string]*list.Element{},
+	}
+}
+
+// Get returns the vectors of keys, nil for the keys not found.
+func (s *LRUCacheStore) Get(_ context.Context, keys []string) ([][]float32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vectors := make([][]float32, len(keys))
+	for i, key := range keys {
+		if element, ok := s.elements[key]; ok {
+			s.entries.MoveToFront(element)
+			vectors[i] = element.Value.(*lruEntry).vector //nolint:forcetypeassert
+		}
+	}
+	return vectors, nil
+}
+
+// Set stores the vectors of keys, evicting the least recently used vectors
+// beyond the capacity of the store.
+func (s *LRUCacheStore) Set(_ context.Context, keys []string, vectors [][]float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, key := range keys {
+		if element, ok := s.elements[key]; ok {
+			element.Value.(*lruEntry).vector = vectors[i] //nolint:forcetypeassert
+			s.entries.MoveToFront(element)
+			continue
+		}
+		s.elements[key] = s.entries.PushFront(&lruEntry{key: key, vector: vectors[i]})
+		if s.capacity > 0 && s.entries.Len() > s.capacity {
+			oldest := s.entries.Back()
+			s.entries.Remove(oldest)
+			delete(s.elements, oldest.Value.(*lruEntry).key) //nolint:forcetypeassert
+		}
+	}
+	return nil
+}
+
+// Len returns the number of vectors in the store.
+func (s *LRUCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries.Len()
+}
@@ -0,0 +1,114 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder embeds texts as their length, recording the embedded
+// texts.
+type countingEmbedder struct {
+	mu       sync.Mutex
+	embedded []string
+}
+
+func (e *countingEmbedder) EmbedDocuments(_ context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.embedded = append(e.embedded, texts...)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text))}
+	}
+	return vectors, nil
+}
+
+func (e *countingEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.EmbedDocuments(ctx, []string{"query " + text})
+	return vectors[0], err
+}
+
+func TestCacheBackedEmbedder(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	embedder := &countingEmbedder{}
+	store := NewLRUCacheStore(0)
+	e := NewCacheBackedEmbedder(embedder, store, WithCacheNamespace("model"))
+
+	vectors, err := e.EmbedDocuments(ctx, []string{"a", "bb", "a"})
+	require.NoError(t, err)
+	require.Equal(t, [][]float32{{1}, {2}, {1}}, vectors)
+	require.Equal(t, []string{"a", "bb"}, embedder.embedded)
+
+	vectors, err = e.EmbedDocuments(ctx, []string{"bb", "ccc"})
+	require.NoError(t, err)
+	require.Equal(t, [][]float32{{2}, {3}}, vectors)
+	require.Equal(t, []string{"a", "bb", "ccc"}, embedder.embedded)
+
+	// The queries are cached apart from the documents.
+	for i := 0; i < 2; i++ {
+		vector, err := e.EmbedQuery(ctx, "a")
+		require.NoError(t, err)
+		require.Equal(t, []float32{7}, vector)
+	}
+	require.Equal(t, []string{"a", "bb", "ccc", "query a"}, embedder.embedded)
+	require.Equal(t, 4, store.Len())
+
+	// Another namespace doesn't share the vectors.
+	other := NewCacheBackedEmbedder(embedder, store)
+	_, err = other.EmbedDocuments(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.Len(t, embedder.embedded, 5)
+}
+
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, []string) ([][]float32, error) {
+	return nil, errors.New("unavailable")
+}
+
+func (failingStore) Set(context.Context, []string, [][]float32) error { return nil }
+
+func TestCacheBackedEmbedderStoreError(t *testing.T) {
+	t.Parallel()
+	e := NewCacheBackedEmbedder(&countingEmbedder{}, failingStore{})
+	_, err := e.EmbedDocuments(context.Background(), []string{"a"})
+	require.ErrorContains(t, err, "unavailable")
+	_, err = e.EmbedQuery(context.Background(), "a")
+	require.ErrorContains(t, err, "unavailable")
+}
+
+func TestLRUCacheStore(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := NewLRUCacheStore(2)
+	require.NoError(t, s.Set(ctx, []string{"a", "b"}, [][]float32{{1}, {2}}))
+	// Reading a makes b the least recently used.
+	vectors, err := s.Get(ctx, []string{"a", "missing"})
+	require.NoError(t, err)
+	require.Equal(t, [][]float32{{1}, nil}, vectors)
+
+	require.NoError(t, s.Set(ctx, []string{"c"}, [][]float32{{3}}))
+	vectors, err = s.Get(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Equal(t, [][]float32{{1}, nil, {3}}, vectors)
+	require.Equal(t, 2, s.Len())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := strings.Repeat("k", i)
+			_ = s.Set(ctx, []string{key}, [][]float32{{float32(i)}})
+			_, _ = s.Get(ctx, []string{key})
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 2, s.Len())
+}
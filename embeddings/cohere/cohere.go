@@ -0,0 +1,145 @@
+package cohere
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+const (
+	// InputTypeSearchDocument is the input type of the documents stored for
+	// search, the default input type of the documents.
+	InputTypeSearchDocument = "search_document"
+	// InputTypeSearchQuery is the input type of the search queries, the
+	// default input type of the queries.
+	InputTypeSearchQuery = "search_query"
+	// InputTypeClassification is the input type of the texts classified
+	// with their embeddings.
+	InputTypeClassification = "classification"
+	// InputTypeClustering is the input type of the texts clustered with their
+	// embeddings.
+	InputTypeClustering = "clustering"
+)
+
+var _ embeddings.Embedder = &Cohere{}
+
+// Cohere is the embedder using the Cohere api to create embeddings. The v3
+// models embed the documents and the queries differently, which Cohere does
+// with their input types.
+type Cohere struct {
+	baseURL           string
+	token             string
+	client            *http.Client
+	documentInputType string
+	queryInputType    string
+	Model             string
+	Truncate          string
+	StripNewLines     bool
+	BatchSize         int
+}
+
+// NewCohere returns a new embedder that uses the Cohere api.
+// The default model is "embed-english-v3.0". Use `WithModel` to change the model.
+func NewCohere(opts ...Option) (*Cohere, error) {
+	c, err := applyOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type embedRequest struct {
+	Model          string   `json:"model"`
+	Texts          []string `json:"texts"`
+	InputType      string   `json:"input_type,omitempty"`
+	EmbeddingTypes []string `json:"embedding_types"`
+	Truncate       string   `json:"truncate,omitempty"`
+}
+
+type embedResponse struct {
+	Embeddings struct {
+		Float [][]float32 `json:"float"`
+	} `json:"embeddings"`
+}
+
+// EmbedDocuments implements the `embeddings.Embedder` and creates an embedding for each of the texts.
+func (c *Cohere) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	batchedTexts := embeddings.BatchTexts(
+		embeddings.MaybeRemoveNewLines(texts, c.StripNewLines),
+		c.BatchSize,
+	)
+
+	embeddings := make([][]float32, 0, len(texts))
+	for _, batch := range batchedTexts {
+		batchEmbeddings, err := c.embed(ctx, batch, c.documentInputType)
+		if err != nil {
+			return nil, fmt.Errorf("embed documents request error: %w", err)
+		}
+		embeddings = append(embeddings, batchEmbeddings...)
+	}
+	return embeddings, nil
+}
+
+// EmbedQuery implements the `embeddings.Embedder` and creates an embedding for the query text.
+func (c *Cohere) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	texts := embeddings.MaybeRemoveNewLines([]string{text}, c.StripNewLines)
+	embeddings, err := c.embed(ctx, texts, c.queryInputType)
+	if err != nil {
+		return nil, fmt.Errorf("embed query request error: %w", err)
+	}
+	return embeddings[0], nil
+}
+
+// embed creates the embeddings of texts, embedded as inputType.
+func (c *Cohere) embed(ctx context.Context, texts []string, inputType string) ([][]float32, error) {
+	reqBody, err := json.Marshal(embedRequest{
+		Model:          c.Model,
+		Texts:          texts,
+		InputType:      inputType,
+		EmbeddingTypes: []string{"float"},
+		Truncate:       c.Truncate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/embed", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+
+	var embedResp embedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, err
+	}
+	if len(embedResp.Embeddings.Float) != len(texts) {
+		return nil, fmt.Errorf("returned %d embeddings for %d texts", len(embedResp.Embeddings.Float), len(texts))
+	}
+	return embedResp.Embeddings.Float, nil
+}
+
+func decodeError(resp *http.Response) error {
+	var errResp struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+		return fmt.Errorf("unexpected error: %w", err)
+	}
+	return fmt.Errorf("embedding error: status code %d: %s", resp.StatusCode, errResp.Message)
+}
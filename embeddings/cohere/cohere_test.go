@@ -0,0 +1,84 @@
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCohereEmbeddings(t *testing.T) {
+	t.Parallel()
+
+	if cohereKey := os.Getenv("COHERE_API_KEY"); cohereKey == "" {
+		t.Skip("COHERE_API_KEY not set")
+	}
+	e, err := NewCohere()
+	require.NoError(t, err)
+
+	_, err = e.EmbedQuery(context.Background(), "Hello world!")
+	require.NoError(t, err)
+
+	embeddings, err := e.EmbedDocuments(context.Background(), []string{"Hello world", "The world is ending", "good bye"})
+	require.NoError(t, err)
+	assert.Len(t, embeddings, 3)
+}
+
+func TestCohereInputTypes(t *testing.T) {
+	t.Parallel()
+
+	var requests []embedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/embed", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		var req embedRequest
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&req)) {
+			return
+		}
+		requests = append(requests, req)
+		if req.Texts[0] == "fail" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message": "too many requests"}`)) //nolint:errcheck
+			return
+		}
+		var resp embedResponse
+		for i := range req.Texts {
+			resp.Embeddings.Float = append(resp.Embeddings.Float, []float32{float32(i)})
+		}
+		json.NewEncoder(w).Encode(resp) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	e, err := NewCohere(WithToken("token"), WithBaseURL(server.URL), WithBatchSize(2))
+	require.NoError(t, err)
+	vectors, err := e.EmbedDocuments(context.Background(), []string{"a", "b\nc", "d"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0}, {1}, {0}}, vectors)
+	vector, err := e.EmbedQuery(context.Background(), "q")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0}, vector)
+
+	require.Len(t, requests, 3)
+	assert.Equal(t, []string{"a", "b c"}, requests[0].Texts)
+	assert.Equal(t, InputTypeSearchDocument, requests[0].InputType)
+	assert.Equal(t, []string{"float"}, requests[0].EmbeddingTypes)
+	assert.Equal(t, _defaultModel, requests[0].Model)
+	assert.Equal(t, InputTypeSearchDocument, requests[1].InputType)
+	assert.Equal(t, InputTypeSearchQuery, requests[2].InputType)
+
+	_, err = e.EmbedQuery(context.Background(), "fail")
+	require.ErrorContains(t, err, "status code 429: too many requests")
+
+	requests = nil
+	e, err = NewCohere(WithToken("token"), WithBaseURL(server.URL), WithInputType(InputTypeClustering))
+	require.NoError(t, err)
+	_, err = e.EmbedQuery(context.Background(), "q")
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, InputTypeClustering, requests[0].InputType)
+}
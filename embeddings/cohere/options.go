@@ -0,0 +1,103 @@
+package cohere
+
+import (
+	"errors"
+	"net/http"
+	"os"
+)
+
+const (
+	_defaultBaseURL       = "https://api.cohere.com/v1"
+	_defaultBatchSize     = 96
+	_defaultStripNewLines = true
+	_defaultModel         = "embed-english-v3.0"
+)
+
+// Option is a function type that can be used to modify the client.
+type Option func(c *Cohere)
+
+// WithModel is an option for providing the model name to use.
+func WithModel(model string) Option {
+	return func(c *Cohere) {
+		c.Model = model
+	}
+}
+
+// WithClient is an option for providing a custom http client.
+func WithClient(client http.Client) Option {
+	return func(c *Cohere) {
+		c.client = &client
+	}
+}
+
+// WithToken is an option for providing the Cohere token.
+func WithToken(token string) Option {
+	return func(c *Cohere) {
+		c.token = token
+	}
+}
+
+// WithBaseURL is an option for providing the base url of the Cohere API.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Cohere) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithInputType is an option for embedding both the documents and the
+// queries with inputType, e.g. InputTypeClassification, instead of
+// InputTypeSearchDocument and InputTypeSearchQuery.
+func WithInputType(inputType string) Option {
+	return func(c *Cohere) {
+		c.documentInputType = inputType
+		c.queryInputType = inputType
+	}
+}
+
+// WithTruncate is an option for specifying how the texts longer than the
+// maximum length of the model are truncated: "NONE", "START" or "END".
+func WithTruncate(truncate string) Option {
+	return func(c *Cohere) {
+		c.Truncate = truncate
+	}
+}
+
+// WithStripNewLines is an option for specifying the should it strip new lines.
+func WithStripNewLines(stripNewLines bool) Option {
+	return func(c *Cohere) {
+		c.StripNewLines = stripNewLines
+	}
+}
+
+// WithBatchSize is an option for specifying the batch size, at most 96.
+func WithBatchSize(batchSize int) Option {
+	return func(c *Cohere) {
+		c.BatchSize = batchSize
+	}
+}
+
+func applyOptions(opts ...Option) (*Cohere, error) {
+	o := &Cohere{
+		baseURL:           _defaultBaseURL,
+		documentInputType: InputTypeSearchDocument,
+		queryInputType:    InputTypeSearchQuery,
+		Model:             _defaultModel,
+		StripNewLines:     _defaultStripNewLines,
+		BatchSize:         _defaultBatchSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.client == nil {
+		o.client = http.DefaultClient
+	}
+	if o.token == "" {
+		token := os.Getenv("COHERE_API_KEY")
+		if token != "" {
+			o.token = token
+		} else {
+			return nil, errors.New("missing the Cohere API key, set it as COHERE_API_KEY environment variable")
+		}
+	}
+	return o, nil
+}
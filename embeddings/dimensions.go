@@ -0,0 +1,50 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// dimensionProbe is the text embedded to find the dimension of the
+// embeddings of an embedder which doesn't know it.
+const dimensionProbe = "dimension probe"
+
+// ErrDimensionMismatch is returned by CheckDimensions when the embeddings of
+// an embedder don't have the expected dimension.
+var ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// Dimensioner is implemented by the embedders knowing the dimension of their
+// embeddings, 0 if they don't know it, without embedding a text.
+type Dimensioner interface {
+	Dimensions() int
+}
+
+// Dimensions returns the dimension of the embeddings of embedder, from its
+// Dimensions method if it knows it, and by embedding a probe text otherwise.
+func Dimensions(ctx context.Context, embedder Embedder) (int, error) {
+	if d, ok := embedder.(Dimensioner); ok && d.Dimensions() > 0 {
+		return d.Dimensions(), nil
+	}
+	vector, err := embedder.EmbedQuery(ctx, dimensionProbe)
+	if err != nil {
+		return 0, fmt.Errorf("failed to embed dimension probe: %w", err)
+	}
+	return len(vector), nil
+}
+
+// CheckDimensions returns an error wrapping ErrDimensionMismatch if the
+// embeddings of embedder don't have want dimensions, e.g. the size of the
+// vector column storing them, so that a misconfigured store fails when it is
+// created rather than on its first insert.
+func CheckDimensions(ctx context.Context, embedder Embedder, want int) error {
+	got, err := Dimensions(ctx, embedder)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("%w: the embedder returns %d-dimensional embeddings, but %d dimensions are expected",
+			ErrDimensionMismatch, got, want)
+	}
+	return nil
+}
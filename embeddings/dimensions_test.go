@@ -0,0 +1,39 @@
+package embeddings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDimensions(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// The embedders not knowing their dimension are probed.
+	embedder := &countingEmbedder{}
+	d, err := Dimensions(ctx, embedder)
+	require.NoError(t, err)
+	require.Equal(t, 1, d)
+	require.Len(t, embedder.embedded, 1)
+
+	e, err := NewEmbedder(&fixedClient{})
+	require.NoError(t, err)
+	d, err = Dimensions(ctx, NewCacheBackedEmbedder(e, NewLRUCacheStore(0)))
+	require.NoError(t, err)
+	require.Equal(t, 3, d)
+
+	// The others aren't, including through the wrappers.
+	e, err = NewEmbedder(&dimensionClient{}, WithOutputDimension(2))
+	require.NoError(t, err)
+	d, err = Dimensions(ctx, NewRateLimitedEmbedder(e))
+	require.NoError(t, err)
+	require.Equal(t, 2, d)
+	require.Empty(t, e.client.(*dimensionClient).dimensions)
+
+	require.NoError(t, CheckDimensions(ctx, e, 2))
+	err = CheckDimensions(ctx, e, 768)
+	require.ErrorIs(t, err, ErrDimensionMismatch)
+	require.ErrorContains(t, err, "the embedder returns 2-dimensional embeddings, but 768 dimensions are expected")
+}
@@ -8,6 +8,11 @@ The main components of this package are:
     from texts, with optional batching.
   - [NewEmbedder] creates implementations of [Embedder] from provider LLM
     (or Chat) clients.
+  - [NewCacheBackedEmbedder] caches the vectors of an [Embedder] in a
+    [CacheStore], such as [LRUCacheStore] or the stores of the alloydb and
+    rediscache subpackages.
+  - [NewRateLimitedEmbedder] limits the rate and concurrency of the calls to
+    an [Embedder], retrying the calls that are throttled.
 
 See the package example below.
 */
@@ -15,6 +15,14 @@ func NewEmbedder(client EmbedderClient, opts ...Option) (*EmbedderImpl, error) {
 		client:        client,
 		StripNewLines: defaultStripNewLines,
 		BatchSize:     defaultBatchSize,
+		countTokens:   estimateTokens,
+	}
+	if limiter, ok := client.(BatchLimiter); ok {
+		maxTexts, maxTokens := limiter.EmbeddingBatchLimits()
+		if maxTexts > 0 {
+			e.BatchSize = min(e.BatchSize, maxTexts)
+		}
+		e.MaxTokensPerBatch = maxTokens
 	}
 
 	for _, opt := range opts {
@@ -45,11 +53,38 @@ func (e EmbedderClientFunc) CreateEmbedding(ctx context.Context, texts []string)
 	return e(ctx, texts)
 }
 
+// BatchLimiter is implemented by the EmbedderClients whose requests are
+// limited to a number of texts or tokens. NewEmbedder splits the batches of
+// texts to respect these limits, unless overridden by WithBatchSize and
+// WithMaxTokensPerBatch.
+type BatchLimiter interface {
+	// EmbeddingBatchLimits returns the maximum number of texts and of tokens
+	// of a request, 0 when unlimited.
+	EmbeddingBatchLimits() (maxTexts, maxTokens int)
+}
+
+// DimensionEmbedderClient is implemented by the EmbedderClients able to
+// create embeddings of a reduced dimension, e.g. OpenAI's text-embedding-3
+// models. The returned vectors may still be larger than dimension, e.g. for
+// the models without the option, and are then truncated.
+type DimensionEmbedderClient interface {
+	EmbedderClient
+	CreateEmbeddingWithDimension(ctx context.Context, texts []string, dimension int) ([][]float32, error)
+}
+
 type EmbedderImpl struct {
 	client EmbedderClient
 
 	StripNewLines bool
 	BatchSize     int
+	// MaxTokensPerBatch is the maximum number of tokens of the texts of a
+	// batch, unlimited if 0.
+	MaxTokensPerBatch int
+	// OutputDimension is the dimension of the vectors, the native dimension
+	// of the model if 0.
+	OutputDimension int
+
+	countTokens func(text string) int
 }
 
 // EmbedQuery embeds a single text.
@@ -58,7 +93,7 @@ func (ei *EmbedderImpl) EmbedQuery(ctx context.Context, text string) ([]float32,
 		text = strings.ReplaceAll(text, "\n", " ")
 	}
 
-	emb, err := ei.client.CreateEmbedding(ctx, []string{text})
+	emb, err := ei.createEmbedding(ctx, []string{text})
 	if err != nil {
 		return nil, fmt.Errorf("error embedding query: %w", err)
 	}
@@ -69,7 +104,52 @@ func (ei *EmbedderImpl) EmbedQuery(ctx context.Context, text string) ([]float32,
 // EmbedDocuments creates one vector embedding for each of the texts.
 func (ei *EmbedderImpl) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
 	texts = MaybeRemoveNewLines(texts, ei.StripNewLines)
-	return BatchedEmbed(ctx, ei.client, texts, ei.BatchSize)
+	if ei.MaxTokensPerBatch <= 0 {
+		return BatchedEmbed(ctx, EmbedderClientFunc(ei.createEmbedding), texts, ei.BatchSize)
+	}
+
+	emb := make([][]float32, 0, len(texts))
+	for _, batch := range SplitBatches(texts, ei.BatchSize, ei.MaxTokensPerBatch, ei.countTokens) {
+		curBatchEmbeddings, err := ei.createEmbedding(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("error embedding batch: %w", err)
+		}
+		emb = append(emb, curBatchEmbeddings...)
+	}
+	return emb, nil
+}
+
+// Dimensions returns the output dimension of the embeddings, 0 if it isn't
+// set.
+func (ei *EmbedderImpl) Dimensions() int {
+	return ei.OutputDimension
+}
+
+// createEmbedding creates the embeddings of texts with the client, of the
+// output dimension if set: passed to the client if it supports it, and
+// applied by truncating the vectors otherwise.
+func (ei *EmbedderImpl) createEmbedding(ctx context.Context, texts []string) ([][]float32, error) {
+	if ei.OutputDimension <= 0 {
+		return ei.client.CreateEmbedding(ctx, texts)
+	}
+	var (
+		emb [][]float32
+		err error
+	)
+	if client, ok := ei.client.(DimensionEmbedderClient); ok {
+		emb, err = client.CreateEmbeddingWithDimension(ctx, texts, ei.OutputDimension)
+	} else {
+		emb, err = ei.client.CreateEmbedding(ctx, texts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, vector := range emb {
+		if emb[i], err = ReduceDimension(vector, ei.OutputDimension); err != nil {
+			return nil, err
+		}
+	}
+	return emb, nil
 }
 
 func MaybeRemoveNewLines(texts []string, removeNewLines bool) []string {
@@ -95,6 +175,34 @@ func BatchTexts(texts []string, batchSize int) [][]string {
 	return batchedTexts
 }
 
+// SplitBatches splits texts into batches of at most batchSize texts and
+// maxTokens tokens, as counted by countTokens. A text of more than maxTokens
+// tokens is alone in its batch. batchSize and maxTokens are unlimited when
+// not positive.
+func SplitBatches(texts []string, batchSize, maxTokens int, countTokens func(string) int) [][]string {
+	var (
+		batches [][]string
+		start   int
+		tokens  int
+	)
+	for i, text := range texts {
+		n := 0
+		if maxTokens > 0 {
+			n = countTokens(text)
+		}
+		full := batchSize > 0 && i-start >= batchSize
+		if i > start && (full || (maxTokens > 0 && tokens+n > maxTokens)) {
+			batches = append(batches, texts[start:i])
+			start, tokens = i, 0
+		}
+		tokens += n
+	}
+	if start < len(texts) {
+		batches = append(batches, texts[start:])
+	}
+	return batches
+}
+
 // BatchedEmbed creates embeddings for the given input texts, batching them
 // into batches of batchSize if needed.
 func BatchedEmbed(ctx context.Context, embedder EmbedderClient, texts []string, batchSize int) ([][]float32, error) {
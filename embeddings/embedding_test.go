@@ -1,9 +1,11 @@
 package embeddings
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBatchTexts(t *testing.T) {
@@ -60,3 +62,134 @@ func TestBatchTexts(t *testing.T) {
 		assert.Equal(t, tc.expected, BatchTexts(tc.texts, tc.batchSize))
 	}
 }
+
+func TestSplitBatches(t *testing.T) {
+	t.Parallel()
+
+	count := func(text string) int { return len(text) }
+	cases := []struct {
+		texts     []string
+		batchSize int
+		maxTokens int
+		expected  [][]string
+	}{
+		{
+			texts:    []string{},
+			expected: nil,
+		},
+		{
+			texts:     []string{"a", "bb", "ccc", "d"},
+			batchSize: 10,
+			maxTokens: 3,
+			expected:  [][]string{{"a", "bb"}, {"ccc"}, {"d"}},
+		},
+		{
+			texts:     []string{"a", "toolong", "b", "c", "d"},
+			batchSize: 2,
+			maxTokens: 4,
+			expected:  [][]string{{"a"}, {"toolong"}, {"b", "c"}, {"d"}},
+		},
+		{
+			texts:    []string{"a", "bb", "ccc"},
+			expected: [][]string{{"a", "bb", "ccc"}},
+		},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, SplitBatches(tc.texts, tc.batchSize, tc.maxTokens, count))
+	}
+}
+
+// limitedClient is an EmbedderClient with batch limits, recording the size
+// of its requests.
+type limitedClient struct {
+	requests []int
+}
+
+func (c *limitedClient) CreateEmbedding(_ context.Context, texts []string) ([][]float32, error) {
+	c.requests = append(c.requests, len(texts))
+	return make([][]float32, len(texts)), nil
+}
+
+func (c *limitedClient) EmbeddingBatchLimits() (int, int) { return 3, 10 }
+
+func TestEmbedderBatchLimits(t *testing.T) {
+	t.Parallel()
+
+	texts := []string{"aaaa", "bbbb", "cccc", "dd", "e", "f", "g", "h"}
+	client := &limitedClient{}
+	e, err := NewEmbedder(client, WithBatchTokenCounter(func(text string) int { return len(text) }))
+	require.NoError(t, err)
+	vectors, err := e.EmbedDocuments(context.Background(), texts)
+	require.NoError(t, err)
+	assert.Len(t, vectors, len(texts))
+	assert.Equal(t, []int{2, 3, 3}, client.requests)
+
+	// The limits of the provider can be overridden.
+	client = &limitedClient{}
+	e, err = NewEmbedder(client, WithBatchSize(8), WithMaxTokensPerBatch(0))
+	require.NoError(t, err)
+	_, err = e.EmbedDocuments(context.Background(), texts)
+	require.NoError(t, err)
+	assert.Equal(t, []int{8}, client.requests)
+}
+
+// fixedClient is an EmbedderClient returning the vector [3, 4, 12] for every
+// text.
+type fixedClient struct{}
+
+func (c *fixedClient) CreateEmbedding(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range vectors {
+		vectors[i] = []float32{3, 4, 12}
+	}
+	return vectors, nil
+}
+
+// dimensionClient is a fixedClient supporting the output dimension,
+// recording the dimensions requested.
+type dimensionClient struct {
+	fixedClient
+	dimensions []int
+}
+
+func (c *dimensionClient) CreateEmbeddingWithDimension(
+	ctx context.Context, texts []string, dimension int,
+) ([][]float32, error) {
+	c.dimensions = append(c.dimensions, dimension)
+	vectors, err := c.CreateEmbedding(ctx, texts)
+	for i := range vectors {
+		vectors[i] = vectors[i][:dimension]
+	}
+	return vectors, err
+}
+
+func TestEmbedderOutputDimension(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// The vectors are truncated and normalized by the embedder.
+	e, err := NewEmbedder(&fixedClient{}, WithOutputDimension(2))
+	require.NoError(t, err)
+	vectors, err := e.EmbedDocuments(ctx, []string{"a", "b"})
+	require.NoError(t, err)
+	require.Len(t, vectors, 2)
+	assert.Equal(t, []float32{0.6, 0.8}, vectors[1])
+	vector, err := e.EmbedQuery(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.6, 0.8}, vector)
+
+	// The dimension is passed to the clients supporting it.
+	client := &dimensionClient{}
+	e, err = NewEmbedder(client, WithOutputDimension(1), WithMaxTokensPerBatch(100))
+	require.NoError(t, err)
+	vectors, err = e.EmbedDocuments(ctx, []string{"a"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{3}}, vectors)
+	assert.Equal(t, []int{1}, client.dimensions)
+
+	e, err = NewEmbedder(&fixedClient{}, WithOutputDimension(4))
+	require.NoError(t, err)
+	_, err = e.EmbedQuery(ctx, "a")
+	require.ErrorIs(t, err, ErrDimensionTooLarge)
+}
@@ -0,0 +1,20 @@
+package embeddings
+
+import "context"
+
+// MultimodalEmbedder is the interface for creating embeddings of images in
+// the same embedding space as the embeddings of texts, so that images are
+// searched with text queries.
+type MultimodalEmbedder interface {
+	Embedder
+	// EmbedImages returns a vector for each image, given its encoded bytes.
+	EmbedImages(ctx context.Context, images [][]byte) ([][]float32, error)
+}
+
+// ImageURIEmbedder is implemented by the multimodal embedders able to fetch
+// the images themselves, such as Vertex AI fetching images from Cloud
+// Storage.
+type ImageURIEmbedder interface {
+	// EmbedImageURIs returns a vector for each image URI.
+	EmbedImageURIs(ctx context.Context, uris []string) ([][]float32, error)
+}
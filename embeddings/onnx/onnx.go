@@ -0,0 +1,179 @@
+// Package onnx provides an embedder running sentence-transformer models
+// exported to ONNX locally, so that documents can be embedded without calling
+// an embedding service, e.g. in air-gapped deployments.
+//
+// The texts are tokenized in Go and the model is run by a Session, which
+// wraps the ONNX Runtime binding of the application, e.g.
+// github.com/yalue/onnxruntime_go, so that this module doesn't depend on cgo
+// and on the ONNX Runtime shared library:
+//
+//	type ortSession struct{ s *ort.DynamicAdvancedSession }
+//
+//	func (o ortSession) Run(_ context.Context, inputIDs, attentionMask, tokenTypeIDs []int64, batchSize, seqLen int) ([]float32, error) {
+//	    shape := ort.NewShape(int64(batchSize), int64(seqLen))
+//	    var inputs []ort.Value
+//	    for _, data := range [][]int64{inputIDs, attentionMask, tokenTypeIDs} {
+//	        t, err := ort.NewTensor(shape, data)
+//	        if err != nil {
+//	            return nil, err
+//	        }
+//	        defer t.Destroy()
+//	        inputs = append(inputs, t)
+//	    }
+//	    outputs := []ort.Value{nil}
+//	    if err := o.s.Run(inputs, outputs); err != nil {
+//	        return nil, err
+//	    }
+//	    defer outputs[0].Destroy()
+//	    return slices.Clone(outputs[0].(*ort.Tensor[float32]).GetData()), nil
+//	}
+//
+// with a session created for the inputs "input_ids", "attention_mask" and
+// "token_type_ids" and the output "last_hidden_state", and the tokenizer of
+// the vocab.txt file of the model:
+//
+//	tokenizer, err := onnx.LoadWordPiece("all-MiniLM-L6-v2/vocab.txt", true)
+//	...
+//	client, err := onnx.New(ortSession{s}, onnx.WithTokenizer(tokenizer))
+//	...
+//	embedder, err := embeddings.NewEmbedder(client)
+package onnx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+var (
+	// ErrMissingTokenizer is returned by New when no tokenizer is set.
+	ErrMissingTokenizer = errors.New("missing tokenizer")
+	// ErrUnexpectedOutput is returned when the size of the output of a
+	// Session doesn't match its inputs.
+	ErrUnexpectedOutput = errors.New("unexpected output size")
+	// ErrNoTokens is returned when the tokenizer returns no tokens for a
+	// text, which has no hidden states to pool.
+	ErrNoTokens = errors.New("no tokens")
+)
+
+// Session runs a model exported to ONNX.
+type Session interface {
+	// Run runs the model on batchSize sequences of seqLen tokens, whose
+	// inputs are flattened row-major, and returns the last hidden state of
+	// shape [batchSize, seqLen, dimension], flattened the same way.
+	Run(ctx context.Context, inputIDs, attentionMask, tokenTypeIDs []int64, batchSize, seqLen int) ([]float32, error)
+}
+
+// Tokenizer tokenizes the texts embedded by a model.
+type Tokenizer interface {
+	// Encode returns the ids of the tokens of text, including the special
+	// tokens of the model, truncated to maxLength tokens if maxLength is
+	// positive.
+	Encode(text string, maxLength int) []int64
+}
+
+// Pooling is the strategy pooling the hidden states of the tokens of a text
+// into its embedding.
+type Pooling int
+
+const (
+	// MeanPooling averages the hidden states of the tokens, as most
+	// sentence transformers do.
+	MeanPooling Pooling = iota
+	// CLSPooling uses the hidden state of the first token.
+	CLSPooling
+)
+
+// ONNX is the embedder client running models exported to ONNX locally.
+type ONNX struct {
+	session   Session
+	tokenizer Tokenizer
+	Pooling   Pooling
+	MaxLength int
+	Normalize bool
+}
+
+var _ embeddings.EmbedderClient = (*ONNX)(nil)
+
+// New returns a new embedding client running its model with session. The
+// tokenizer of the model must be set with WithTokenizer.
+func New(session Session, opts ...Option) (*ONNX, error) {
+	return applyOptions(session, opts...)
+}
+
+// CreateEmbedding implements the `embeddings.EmbedderClient` and creates an
+// embedding vector for each of the supplied texts, running the model on them
+// as a single batch.
+func (o *ONNX) CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	tokens := make([][]int64, len(texts))
+	seqLen := 0
+	for i, text := range texts {
+		tokens[i] = o.tokenizer.Encode(text, o.MaxLength)
+		if len(tokens[i]) == 0 {
+			return nil, fmt.Errorf("%w for text %d", ErrNoTokens, i)
+		}
+		seqLen = max(seqLen, len(tokens[i]))
+	}
+
+	// The sequences are padded to the longest one, the padding being masked.
+	size := len(texts) * seqLen
+	inputIDs := make([]int64, size)
+	attentionMask := make([]int64, size)
+	for i, ids := range tokens {
+		copy(inputIDs[i*seqLen:], ids)
+		for j := range ids {
+			attentionMask[i*seqLen+j] = 1
+		}
+	}
+	hidden, err := o.session.Run(ctx, inputIDs, attentionMask, make([]int64, size), len(texts), seqLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run model: %w", err)
+	}
+	if len(hidden) == 0 || len(hidden)%size != 0 {
+		return nil, fmt.Errorf("%w: %d values for %d tokens", ErrUnexpectedOutput, len(hidden), size)
+	}
+	dimension := len(hidden) / size
+
+	result := make([][]float32, len(texts))
+	for i, ids := range tokens {
+		states := hidden[i*seqLen*dimension : (i+1)*seqLen*dimension]
+		result[i] = o.pool(states, len(ids), dimension)
+	}
+	return result, nil
+}
+
+// pool returns the embedding of the hidden states of a text of n tokens.
+func (o *ONNX) pool(states []float32, n, dimension int) []float32 {
+	vector := make([]float32, dimension)
+	if o.Pooling == CLSPooling {
+		copy(vector, states[:dimension])
+	} else {
+		for j := 0; j < n; j++ {
+			for k := range vector {
+				vector[k] += states[j*dimension+k]
+			}
+		}
+		for k := range vector {
+			vector[k] /= float32(n)
+		}
+	}
+	if o.Normalize {
+		var norm float64
+		for _, v := range vector {
+			norm += float64(v) * float64(v)
+		}
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for k := range vector {
+				vector[k] = float32(float64(vector[k]) / norm)
+			}
+		}
+	}
+	return vector
+}
@@ -0,0 +1,95 @@
+package onnx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSession returns the hidden states [id, mask] for every token, recording
+// its inputs.
+type fakeSession struct {
+	inputIDs      []int64
+	attentionMask []int64
+	batchSize     int
+	seqLen        int
+}
+
+func (s *fakeSession) Run(_ context.Context, inputIDs, attentionMask, _ []int64, batchSize, seqLen int) ([]float32, error) {
+	s.inputIDs, s.attentionMask, s.batchSize, s.seqLen = inputIDs, attentionMask, batchSize, seqLen
+	hidden := make([]float32, 0, 2*len(inputIDs))
+	for i, id := range inputIDs {
+		hidden = append(hidden, float32(id), float32(attentionMask[i]))
+	}
+	return hidden, nil
+}
+
+func TestCreateEmbedding(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	_, err := New(&fakeSession{})
+	require.ErrorIs(t, err, ErrMissingTokenizer)
+
+	tokenizer, err := NewWordPiece(testVocab, true)
+	require.NoError(t, err)
+	session := &fakeSession{}
+	o, err := New(session, WithTokenizer(tokenizer), WithNormalize(false))
+	require.NoError(t, err)
+
+	vectors, err := o.CreateEmbedding(ctx, []string{"hello world", "hello"})
+	require.NoError(t, err)
+	require.Equal(t, 2, session.batchSize)
+	require.Equal(t, 4, session.seqLen)
+	require.Equal(t, []int64{2, 4, 5, 3, 2, 4, 3, 0}, session.inputIDs)
+	require.Equal(t, []int64{1, 1, 1, 1, 1, 1, 1, 0}, session.attentionMask)
+	// The padding isn't averaged.
+	require.Equal(t, [][]float32{{3.5, 1}, {3, 1}}, vectors)
+
+	o, err = New(session, WithTokenizer(tokenizer), WithPooling(CLSPooling))
+	require.NoError(t, err)
+	vectors, err = o.CreateEmbedding(ctx, []string{"hello"})
+	require.NoError(t, err)
+	require.InDelta(t, 2/2.236068, vectors[0][0], 1e-6)
+	require.InDelta(t, 1/2.236068, vectors[0][1], 1e-6)
+
+	vectors, err = o.CreateEmbedding(ctx, nil)
+	require.NoError(t, err)
+	require.Empty(t, vectors)
+}
+
+func TestCreateEmbeddingUnexpectedOutput(t *testing.T) {
+	t.Parallel()
+
+	tokenizer, err := NewWordPiece(testVocab, true)
+	require.NoError(t, err)
+	o, err := New(sessionFunc(func() []float32 { return []float32{1, 2, 3, 4} }), WithTokenizer(tokenizer))
+	require.NoError(t, err)
+	_, err = o.CreateEmbedding(context.Background(), []string{"hello"})
+	require.ErrorIs(t, err, ErrUnexpectedOutput)
+}
+
+// tokenizerFunc is a Tokenizer encoding texts with a function.
+type tokenizerFunc func(text string) []int64
+
+func (f tokenizerFunc) Encode(text string, _ int) []int64 {
+	return f(text)
+}
+
+func TestCreateEmbeddingNoTokens(t *testing.T) {
+	t.Parallel()
+
+	session := &fakeSession{}
+	o, err := New(session, WithTokenizer(tokenizerFunc(func(string) []int64 { return nil })))
+	require.NoError(t, err)
+	_, err = o.CreateEmbedding(context.Background(), []string{"", ""})
+	require.ErrorIs(t, err, ErrNoTokens)
+	require.Zero(t, session.batchSize)
+}
+
+type sessionFunc func() []float32
+
+func (f sessionFunc) Run(context.Context, []int64, []int64, []int64, int, int) ([]float32, error) {
+	return f(), nil
+}
@@ -0,0 +1,57 @@
+package onnx
+
+const (
+	_defaultMaxLength = 256
+	_defaultNormalize = true
+	_defaultPooling   = MeanPooling
+)
+
+// Option is a function type that can be used to modify the client.
+type Option func(o *ONNX)
+
+// WithTokenizer is an option for providing the tokenizer of the model, e.g. a
+// WordPiece tokenizer.
+func WithTokenizer(tokenizer Tokenizer) Option {
+	return func(o *ONNX) {
+		o.tokenizer = tokenizer
+	}
+}
+
+// WithPooling sets the pooling strategy. Default is mean pooling.
+func WithPooling(pooling Pooling) Option {
+	return func(o *ONNX) {
+		o.Pooling = pooling
+	}
+}
+
+// WithMaxLength sets the maximum number of tokens of a text, the longer texts
+// being truncated. Default is 256, the maximum length of all-MiniLM-L6-v2.
+func WithMaxLength(maxLength int) Option {
+	return func(o *ONNX) {
+		o.MaxLength = maxLength
+	}
+}
+
+// WithNormalize sets whether the embeddings are normalized to unit length.
+// Default is true.
+func WithNormalize(normalize bool) Option {
+	return func(o *ONNX) {
+		o.Normalize = normalize
+	}
+}
+
+func applyOptions(session Session, opts ...Option) (*ONNX, error) {
+	o := &ONNX{
+		session:   session,
+		Pooling:   _defaultPooling,
+		MaxLength: _defaultMaxLength,
+		Normalize: _defaultNormalize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.tokenizer == nil {
+		return nil, ErrMissingTokenizer
+	}
+	return o, nil
+}
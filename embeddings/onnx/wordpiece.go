@@ -0,0 +1,162 @@
+package onnx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxWordLength is the length in runes above which a word is tokenized as
+// the unknown token, as the BERT tokenizer does.
+const maxWordLength = 100
+
+// ErrMissingSpecialToken is returned when the vocabulary of a WordPiece
+// tokenizer lacks one of the [CLS], [SEP] and [UNK] tokens.
+var ErrMissingSpecialToken = errors.New("vocabulary is missing a special token")
+
+// WordPiece is the tokenizer of the BERT models, and of the sentence
+// transformers derived from them such as all-MiniLM-L6-v2.
+type WordPiece struct {
+	vocab     map[string]int64
+	lowercase bool
+	cls       int64
+	sep       int64
+	unk       int64
+}
+
+var _ Tokenizer = &WordPiece{}
+
+// NewWordPiece returns a WordPiece tokenizer of vocab, the tokens whose ids
+// are their indexes. Uncased models, whose vocabulary is lowercase, need
+// lowercase, which also strips the accents of the texts.
+func NewWordPiece(vocab []string, lowercase bool) (*WordPiece, error) {
+	w := &WordPiece{
+		vocab:     make(map[string]int64, len(vocab)),
+		lowercase: lowercase,
+	}
+	for i, token := range vocab {
+		w.vocab[token] = int64(i)
+	}
+	for token, id := range map[string]*int64{"[CLS]": &w.cls, "[SEP]": &w.sep, "[UNK]": &w.unk} {
+		var ok bool
+		if *id, ok = w.vocab[token]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingSpecialToken, token)
+		}
+	}
+	return w, nil
+}
+
+// LoadWordPiece returns the WordPiece tokenizer of the vocab.txt file of a
+// model, holding a token per line.
+func LoadWordPiece(path string, lowercase bool) (*WordPiece, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocabulary: %w", err)
+	}
+	defer f.Close()
+
+	var vocab []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		vocab = append(vocab, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+	return NewWordPiece(vocab, lowercase)
+}
+
+// Encode returns the ids of the tokens of text between [CLS] and [SEP],
+// truncated to maxLength tokens if maxLength is positive.
+func (w *WordPiece) Encode(text string, maxLength int) []int64 {
+	ids := []int64{w.cls}
+	for _, word := range w.words(text) {
+		ids = append(ids, w.wordPieces(word)...)
+	}
+	if maxLength > 1 && len(ids) > maxLength-1 {
+		ids = ids[:maxLength-1]
+	}
+	return append(ids, w.sep)
+}
+
+// words splits text into words and punctuation characters, after cleaning
+// and possibly lowercasing it.
+func (w *WordPiece) words(text string) []string {
+	if w.lowercase {
+		text = strings.ToLower(text)
+		text = norm.NFD.String(text)
+	}
+
+	var (
+		words []string
+		word  strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case r == 0 || r == unicode.ReplacementChar || (unicode.IsControl(r) && !unicode.IsSpace(r)):
+		case w.lowercase && unicode.Is(unicode.Mn, r):
+		case unicode.IsSpace(r):
+			flush()
+		case isPunctuation(r) || isCJK(r):
+			flush()
+			words = append(words, string(r))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return words
+}
+
+// wordPieces returns the ids of the longest tokens of the vocabulary
+// composing word, or of the unknown token if there are none.
+func (w *WordPiece) wordPieces(word string) []int64 {
+	runes := []rune(word)
+	if len(runes) > maxWordLength {
+		return []int64{w.unk}
+	}
+	var ids []int64
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		for ; end > start; end-- {
+			piece := string(runes[start:end])
+			if start > 0 {
+				piece = "##" + piece
+			}
+			if id, ok := w.vocab[piece]; ok {
+				ids = append(ids, id)
+				break
+			}
+		}
+		if end == start {
+			return []int64{w.unk}
+		}
+		start = end
+	}
+	return ids
+}
+
+// isPunctuation reports whether r is split from the words, as the ASCII
+// symbols are by BERT in addition to the unicode punctuation.
+func isPunctuation(r rune) bool {
+	if r >= 33 && r <= 47 || r >= 58 && r <= 64 || r >= 91 && r <= 96 || r >= 123 && r <= 126 {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
+
+// isCJK reports whether r is a CJK ideograph, tokenized as a word.
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
@@ -0,0 +1,57 @@
+package onnx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testVocab = []string{"[PAD]", "[UNK]", "[CLS]", "[SEP]", "hello", "world", "un", "##aff", "##able", ",", "!", "cafe", "中"}
+
+func TestWordPiece(t *testing.T) {
+	t.Parallel()
+
+	w, err := NewWordPiece(testVocab, true)
+	require.NoError(t, err)
+
+	tests := []struct {
+		text      string
+		maxLength int
+		want      []int64
+	}{
+		{"Hello, world!", 0, []int64{2, 4, 9, 5, 10, 3}},
+		{"unaffable", 0, []int64{2, 6, 7, 8, 3}},
+		{"unknown hello", 0, []int64{2, 1, 4, 3}},
+		{"Café\tworld", 0, []int64{2, 11, 5, 3}},
+		{"中文", 0, []int64{2, 12, 1, 3}},
+		{"hello world hello", 3, []int64{2, 4, 3}},
+		{strings.Repeat("a", maxWordLength+1), 0, []int64{2, 1, 3}},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, w.Encode(tt.text, tt.maxLength), tt.text)
+	}
+
+	// Cased models keep the case.
+	w, err = NewWordPiece(testVocab, false)
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 1, 5, 3}, w.Encode("Hello world", 0))
+
+	_, err = NewWordPiece([]string{"[CLS]", "[SEP]"}, true)
+	require.ErrorIs(t, err, ErrMissingSpecialToken)
+}
+
+func TestLoadWordPiece(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "vocab.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(testVocab, "\r\n")), 0o600))
+	w, err := LoadWordPiece(path, true)
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 4, 3}, w.Encode("hello", 0))
+
+	_, err = LoadWordPiece(filepath.Join(t.TempDir(), "missing.txt"), true)
+	require.Error(t, err)
+}
@@ -20,3 +20,31 @@ func WithBatchSize(batchSize int) Option {
 		p.BatchSize = batchSize
 	}
 }
+
+// WithMaxTokensPerBatch is an option for specifying the maximum number of
+// tokens of a batch, e.g. the token limit of a request of the provider.
+func WithMaxTokensPerBatch(maxTokens int) Option {
+	return func(p *EmbedderImpl) {
+		p.MaxTokensPerBatch = maxTokens
+	}
+}
+
+// WithBatchTokenCounter is an option for specifying the function counting
+// the tokens of a text for WithMaxTokensPerBatch. The tokens are estimated
+// as a quarter of the length of the texts by default.
+func WithBatchTokenCounter(countTokens func(text string) int) Option {
+	return func(p *EmbedderImpl) {
+		p.countTokens = countTokens
+	}
+}
+
+// WithOutputDimension is an option for specifying the dimension of the
+// vectors, e.g. the dimension of the column storing them. It is passed to the
+// providers supporting it, such as OpenAI's text-embedding-3 models and
+// Vertex, and the vectors are otherwise truncated and normalized, which
+// suits the models trained with Matryoshka representation learning.
+func WithOutputDimension(dimension int) Option {
+	return func(p *EmbedderImpl) {
+		p.OutputDimension = dimension
+	}
+}
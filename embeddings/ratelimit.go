@@ -0,0 +1,287 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitedEmbedder is an Embedder limiting the rate and concurrency of
+// the calls to another Embedder, and retrying the calls failing with a rate
+// limit or server error, so that bulk indexing isn't throttled into failure.
+// It is safe for concurrent use.
+type RateLimitedEmbedder struct {
+	embedder Embedder
+
+	requests    *tokenBucket
+	tokens      *tokenBucket
+	countTokens func(text string) int
+	slots       chan struct{}
+
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	retryIf        func(error) bool
+
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+	random func() float64
+}
+
+var _ Embedder = &RateLimitedEmbedder{}
+
+// RateLimitOption is a function for configuring a RateLimitedEmbedder.
+type RateLimitOption func(e *RateLimitedEmbedder)
+
+// WithRequestsPerSecond limits the calls to qps per second, allowing bursts
+// of a second of calls.
+func WithRequestsPerSecond(qps float64) RateLimitOption {
+	return func(e *RateLimitedEmbedder) {
+		e.requests = &tokenBucket{rate: qps, burst: math.Max(1, qps)}
+	}
+}
+
+// WithTokensPerMinute limits the tokens of the embedded texts to tpm per
+// minute, allowing bursts of a minute of tokens. The tokens are estimated as
+// a quarter of the length of the texts, unless WithTokenCounter is set.
+func WithTokensPerMinute(tpm int) RateLimitOption {
+	return func(e *RateLimitedEmbedder) {
+		e.tokens = &tokenBucket{rate: float64(tpm) / 60, burst: float64(tpm)}
+	}
+}
+
+// WithTokenCounter sets the function counting the tokens of a text for
+// WithTokensPerMinute.
+func WithTokenCounter(countTokens func(text string) int) RateLimitOption {
+	return func(e *RateLimitedEmbedder) {
+		e.countTokens = countTokens
+	}
+}
+
+// WithMaxConcurrency limits the calls in flight to n.
+func WithMaxConcurrency(n int) RateLimitOption {
+	return func(e *RateLimitedEmbedder) {
+		e.slots = make(chan struct{}, n)
+	}
+}
+
+// WithRetries sets the maximum number of attempts of a call, including the
+// first one, and the backoff between them: it starts below initialBackoff,
+// doubles with every retry up to maxBackoff, and is chosen at random below
+// that bound. It defaults to 3 attempts with backoffs between 500ms and 30s;
+// maxAttempts below 2 disables retries.
+func WithRetries(maxAttempts int, initialBackoff, maxBackoff time.Duration) RateLimitOption {
+	return func(e *RateLimitedEmbedder) {
+		e.maxAttempts = maxAttempts
+		e.initialBackoff = initialBackoff
+		e.maxBackoff = maxBackoff
+	}
+}
+
+// WithRetryIf sets the function reporting whether a failed call is retried.
+// It defaults to IsRetryableError.
+func WithRetryIf(retryIf func(error) bool) RateLimitOption {
+	return func(e *RateLimitedEmbedder) {
+		e.retryIf = retryIf
+	}
+}
+
+// NewRateLimitedEmbedder returns an Embedder limiting and retrying the
+// calls to embedder.
+func NewRateLimitedEmbedder(embedder Embedder, opts ...RateLimitOption) *RateLimitedEmbedder {
+	e := &RateLimitedEmbedder{
+		embedder:       embedder,
+		countTokens:    estimateTokens,
+		maxAttempts:    3,
+		initialBackoff: 500 * time.Millisecond,
+		maxBackoff:     30 * time.Second,
+		retryIf:        IsRetryableError,
+		now:            time.Now,
+		sleep:          sleep,
+		random:         rand.Float64, //nolint:gosec
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// EmbedDocuments embeds texts in a single call to the wrapped embedder.
+func (e *RateLimitedEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	var vectors [][]float32
+	err := e.do(ctx, texts, func(ctx context.Context) error {
+		var err error
+		vectors, err = e.embedder.EmbedDocuments(ctx, texts)
+		return err
+	})
+	return vectors, err
+}
+
+// EmbedQuery embeds text.
+func (e *RateLimitedEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	var vector []float32
+	err := e.do(ctx, []string{text}, func(ctx context.Context) error {
+		var err error
+		vector, err = e.embedder.EmbedQuery(ctx, text)
+		return err
+	})
+	return vector, err
+}
+
+// Dimensions returns the dimension of the embeddings of the limited embedder
+// if it knows it, 0 otherwise.
+func (e *RateLimitedEmbedder) Dimensions() int {
+	if d, ok := e.embedder.(Dimensioner); ok {
+		return d.Dimensions()
+	}
+	return 0
+}
+
+// do calls fn, embedding texts, within the limits, until it succeeds, fails
+// with an error not retried or the attempts are exhausted.
+func (e *RateLimitedEmbedder) do(ctx context.Context, texts []string, fn func(ctx context.Context) error) error {
+	tokens := 0
+	if e.tokens != nil {
+		for _, text := range texts {
+			tokens += e.countTokens(text)
+		}
+	}
+	for attempt := 1; ; attempt++ {
+		err := e.call(ctx, tokens, fn)
+		if err == nil || attempt >= e.maxAttempts || ctx.Err() != nil || !e.retryIf(err) {
+			return err
+		}
+		if err := e.sleep(ctx, e.backoff(attempt-1)); err != nil {
+			return errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+// call waits for the limits, then calls fn.
+func (e *RateLimitedEmbedder) call(ctx context.Context, tokens int, fn func(ctx context.Context) error) error {
+	if e.slots != nil {
+		select {
+		case e.slots <- struct{}{}:
+			defer func() { <-e.slots }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if e.requests != nil {
+		if err := e.requests.wait(ctx, 1, e.now, e.sleep); err != nil {
+			return err
+		}
+	}
+	if e.tokens != nil && tokens > 0 {
+		if err := e.tokens.wait(ctx, float64(tokens), e.now, e.sleep); err != nil {
+			return err
+		}
+	}
+	return fn(ctx)
+}
+
+// backoff returns the jittered delay before retry number attempt, starting
+// at 0.
+func (e *RateLimitedEmbedder) backoff(attempt int) time.Duration {
+	ceiling := e.initialBackoff
+	for i := 0; i < attempt && ceiling < e.maxBackoff; i++ {
+		ceiling *= 2
+	}
+	if e.maxBackoff > 0 && ceiling > e.maxBackoff {
+		ceiling = e.maxBackoff
+	}
+	return time.Duration(e.random() * float64(ceiling))
+}
+
+// retryableStatus matches the rate limit and server error status codes in
+// the messages of the errors of the providers, e.g. "status code: 429" or
+// "Error 503".
+var retryableStatus = regexp.MustCompile(`(?i)\b(status|code|error|http)\b[^0-9]{0,12}\b(429|5\d\d)\b`)
+
+// IsRetryableError reports whether err looks like a rate limit error or a
+// transient server error, from the status code or status of the provider in
+// its message, after which an embedding call can be retried.
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	msg := err.Error()
+	if retryableStatus.MatchString(msg) {
+		return true
+	}
+	msg = strings.ToLower(msg)
+	for _, s := range []string{"rate limit", "too many requests", "resource_exhausted", "resource exhausted", "unavailable", "overloaded"} { //nolint:lll
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// estimateTokens estimates the tokens of text as a quarter of its length.
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tokenBucket is a token bucket refilled at rate tokens per second up to
+// burst tokens. Waits for more tokens than available are served in order,
+// the bucket going into debt.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// wait waits until n tokens are available, then takes them.
+func (b *tokenBucket) wait(ctx context.Context, n float64, now func() time.Time,
+	sleep func(context.Context, time.Duration) error,
+) error {
+	if b.rate <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	t := now()
+	if b.last.IsZero() {
+		b.tokens = b.burst
+	} else {
+		b.tokens = math.Min(b.burst, b.tokens+t.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = t
+	b.tokens -= n
+	var d time.Duration
+	if b.tokens < 0 {
+		d = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if d == 0 {
+		return nil
+	}
+	if err := sleep(ctx, d); err != nil {
+		// The tokens weren't used.
+		b.mu.Lock()
+		b.tokens += n
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
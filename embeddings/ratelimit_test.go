@@ -0,0 +1,174 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock advanced by the sleeps.
+type fakeClock struct {
+	mu     sync.Mutex
+	t      time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps = append(c.sleeps, d)
+	c.t = c.t.Add(d)
+	return ctx.Err()
+}
+
+// flakyEmbedder fails its first calls with err.
+type flakyEmbedder struct {
+	countingEmbedder
+	failures int
+	err      error
+}
+
+func (e *flakyEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.failures > 0 {
+		e.failures--
+		return nil, e.err
+	}
+	return e.countingEmbedder.EmbedDocuments(ctx, texts)
+}
+
+func newTestRateLimitedEmbedder(embedder Embedder, opts ...RateLimitOption) (*RateLimitedEmbedder, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	e := NewRateLimitedEmbedder(embedder, opts...)
+	e.now = clock.now
+	e.sleep = clock.sleep
+	e.random = func() float64 { return 1 }
+	return e, clock
+}
+
+func TestRateLimitedEmbedderLimits(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	e, clock := newTestRateLimitedEmbedder(&countingEmbedder{}, WithRequestsPerSecond(2), WithTokensPerMinute(60))
+
+	// The first calls use the bursts.
+	for i := 0; i < 2; i++ {
+		_, err := e.EmbedDocuments(ctx, []string{"abcd"})
+		require.NoError(t, err)
+	}
+	require.Empty(t, clock.sleeps)
+
+	// Then the calls wait for the requests, ...
+	_, err := e.EmbedQuery(ctx, "abcd")
+	require.NoError(t, err)
+	require.Equal(t, []time.Duration{500 * time.Millisecond}, clock.sleeps)
+
+	// ... and the tokens: after a second, 3 of the 60 tokens were used and
+	// 1 refilled, so 60 more tokens take 2 more seconds.
+	_, err = e.EmbedDocuments(ctx, []string{strings.Repeat("a", 4*60)})
+	require.NoError(t, err)
+	require.Equal(t, []time.Duration{500 * time.Millisecond, 500 * time.Millisecond, 2 * time.Second}, clock.sleeps)
+}
+
+func TestRateLimitedEmbedderRetries(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	throttled := errors.New("API returned unexpected status code: 429: slow down")
+	embedder := &flakyEmbedder{failures: 2, err: throttled}
+	e, clock := newTestRateLimitedEmbedder(embedder, WithRetries(3, time.Second, 10*time.Second))
+
+	vectors, err := e.EmbedDocuments(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.Equal(t, [][]float32{{1}}, vectors)
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second}, clock.sleeps)
+
+	embedder.failures = 3
+	_, err = e.EmbedDocuments(ctx, []string{"a"})
+	require.ErrorIs(t, err, throttled)
+
+	// Other errors aren't retried.
+	embedder.failures, embedder.err = 2, errors.New("invalid input")
+	_, err = e.EmbedDocuments(ctx, []string{"a"})
+	require.EqualError(t, err, "invalid input")
+	require.Equal(t, 1, embedder.failures)
+}
+
+// embedDocumentsFunc is an Embedder embedding texts with a function.
+type embedDocumentsFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+func (f embedDocumentsFunc) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return f(ctx, texts)
+}
+
+func (f embedDocumentsFunc) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := f(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+func TestRateLimitedEmbedderConcurrency(t *testing.T) {
+	t.Parallel()
+	var (
+		mu              sync.Mutex
+		inFlight, peak  int
+		release         = make(chan struct{})
+		embedderStarted = make(chan struct{}, 8)
+	)
+	embedder := embedDocumentsFunc(func(_ context.Context, texts []string) ([][]float32, error) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		embedderStarted <- struct{}{}
+		<-release
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return make([][]float32, len(texts)), nil
+	})
+	e := NewRateLimitedEmbedder(embedder, WithMaxConcurrency(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := e.EmbedDocuments(context.Background(), []string{"a"})
+			assert.NoError(t, err)
+		}()
+	}
+	for i := 0; i < 6; i++ {
+		<-embedderStarted
+		release <- struct{}{}
+	}
+	wg.Wait()
+	require.Equal(t, 2, peak)
+}
+
+func TestIsRetryableError(t *testing.T) {
+	t.Parallel()
+	for msg, retryable := range map[string]bool{
+		"API returned unexpected status code: 429":                      true,
+		"googleapi: Error 503: The service is overloaded":               true,
+		"rpc error: code = ResourceExhausted desc = Resource exhausted": true,
+		"Rate limit reached for text-embedding-3-small":                 true,
+		"API returned unexpected status code: 400":                      false,
+		"batch size 512 exceeds the maximum":                            false,
+	} {
+		require.Equal(t, retryable, IsRetryableError(errors.New(msg)), msg)
+	}
+	require.False(t, IsRetryableError(context.Canceled))
+}
@@ -0,0 +1,11 @@
+// Package rediscache provides an embeddings.CacheStore keeping the vectors
+// of embedded texts in Redis, optionally expiring them:
+//
+//	client, err := rueidis.NewClient(rueidis.ClientOption{InitAddress: []string{"localhost:6379"}})
+//	if err != nil {
+//	    return err
+//	}
+//	embedder := embeddings.NewCacheBackedEmbedder(openaiEmbedder,
+//	    rediscache.New(client, rediscache.WithTTL(30*24*time.Hour)),
+//	    embeddings.WithCacheNamespace("text-embedding-3-small"))
+package rediscache
@@ -0,0 +1,114 @@
+package rediscache
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+const defaultPrefix = "embedding:"
+
+// Store is an embeddings.CacheStore keeping the vectors in Redis strings,
+// as little endian float32 values.
+type Store struct {
+	client rueidis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+var _ embeddings.CacheStore = &Store{}
+
+// Option is a function for configuring the Store.
+type Option func(s *Store)
+
+// WithPrefix sets the prefix of the Redis keys of the vectors. It defaults
+// to "embedding:".
+func WithPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = prefix
+	}
+}
+
+// WithTTL sets the time after which the vectors expire. They don't expire
+// by default.
+func WithTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.ttl = ttl
+	}
+}
+
+// New returns a Store keeping the vectors with client.
+func New(client rueidis.Client, opts ...Option) *Store {
+	s := &Store{
+		client: client,
+		prefix: defaultPrefix,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Get returns the vectors of keys, nil for the keys not found. The keys are
+// read with one GET each, pipelined, so that they can live in different
+// slots of a cluster.
+func (s *Store) Get(ctx context.Context, keys []string) ([][]float32, error) {
+	cmds := make(rueidis.Commands, len(keys))
+	for i, key := range keys {
+		cmds[i] = s.client.B().Get().Key(s.prefix + key).Build()
+	}
+	vectors := make([][]float32, len(keys))
+	for i, res := range s.client.DoMulti(ctx, cmds...) {
+		data, err := res.AsBytes()
+		if rueidis.IsRedisNil(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get embedding: %w", err)
+		}
+		vectors[i] = decodeVector(data)
+	}
+	return vectors, nil
+}
+
+// Set stores the vectors of keys.
+func (s *Store) Set(ctx context.Context, keys []string, vectors [][]float32) error {
+	cmds := make(rueidis.Commands, len(keys))
+	for i, key := range keys {
+		args := []string{encodeVector(vectors[i])}
+		if s.ttl > 0 {
+			args = append(args, "PX", strconv.FormatInt(s.ttl.Milliseconds(), 10))
+		}
+		cmds[i] = s.client.B().Arbitrary("SET").Keys(s.prefix + key).Args(args...).Build()
+	}
+	for _, res := range s.client.DoMulti(ctx, cmds...) {
+		if err := res.Error(); err != nil {
+			return fmt.Errorf("failed to set embedding: %w", err)
+		}
+	}
+	return nil
+}
+
+// encodeVector encodes v as little endian float32 values.
+func encodeVector(v []float32) string {
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return string(b)
+}
+
+// decodeVector decodes the little endian float32 values of b.
+func decodeVector(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
@@ -0,0 +1,16 @@
+package rediscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVectorEncoding(t *testing.T) {
+	t.Parallel()
+	v := []float32{0, 1.5, -2.25, 3e-8}
+	data := encodeVector(v)
+	require.Len(t, data, 16)
+	require.Equal(t, v, decodeVector([]byte(data)))
+	require.Empty(t, decodeVector(nil))
+}
@@ -0,0 +1,41 @@
+package embeddings
+
+import "context"
+
+// SparseVector is a sparse embedding, such as the term weights of SPLADE or
+// BM25: the Values of the dimensions Indices, in increasing order, of a
+// vector of Dimension dimensions.
+type SparseVector struct {
+	Indices   []int
+	Values    []float32
+	Dimension int
+}
+
+// Dot returns the dot product of v and w, the similarity of the sparse
+// embeddings.
+func (v SparseVector) Dot(w SparseVector) float32 {
+	var dot float32
+	for i, j := 0, 0; i < len(v.Indices) && j < len(w.Indices); {
+		switch {
+		case v.Indices[i] < w.Indices[j]:
+			i++
+		case v.Indices[i] > w.Indices[j]:
+			j++
+		default:
+			dot += v.Values[i] * w.Values[j]
+			i++
+			j++
+		}
+	}
+	return dot
+}
+
+// SparseEmbedder is the interface for creating sparse embeddings of texts,
+// which the vector stores supporting them search along with the dense
+// embeddings in hybrid searches.
+type SparseEmbedder interface {
+	// EmbedSparseDocuments returns the sparse embeddings of texts.
+	EmbedSparseDocuments(ctx context.Context, texts []string) ([]SparseVector, error)
+	// EmbedSparseQuery returns the sparse embedding of the query text.
+	EmbedSparseQuery(ctx context.Context, text string) (SparseVector, error)
+}
@@ -2,6 +2,7 @@ package embeddings
 
 import (
 	"errors"
+	"fmt"
 	"math"
 )
 
@@ -12,6 +13,9 @@ var (
 	// ErrAllTextsLenZero is returned if all texts to be embedded has the combined
 	// length of zero.
 	ErrAllTextsLenZero = errors.New("all texts have length 0")
+	// ErrDimensionTooLarge is returned by ReduceDimension when a vector is
+	// smaller than the requested dimension.
+	ErrDimensionTooLarge = errors.New("vector smaller than the requested dimension")
 )
 
 func CombineVectors(vectors [][]float32, weights []int) ([]float32, error) {
@@ -81,3 +85,25 @@ func getNorm(v []float32) float32 {
 
 	return float32(math.Sqrt(float64(sum)))
 }
+
+// ReduceDimension returns the first dimension values of vector, normalized
+// to unit length, which is how the models trained with Matryoshka
+// representation learning, such as OpenAI's text-embedding-3, are
+// shortened. The vector is returned unchanged if it already has the
+// dimension.
+func ReduceDimension(vector []float32, dimension int) ([]float32, error) {
+	if len(vector) < dimension {
+		return nil, fmt.Errorf("%w: %d < %d", ErrDimensionTooLarge, len(vector), dimension)
+	}
+	if len(vector) == dimension {
+		return vector, nil
+	}
+	reduced := make([]float32, dimension)
+	copy(reduced, vector)
+	if norm := getNorm(reduced); norm > 0 {
+		for i := range reduced {
+			reduced[i] /= norm
+		}
+	}
+	return reduced, nil
+}
@@ -82,3 +82,19 @@ func TestGetNorm(t *testing.T) {
 		assert.InEpsilon(t, tc.expected, getNorm(tc.vector), 0.0001)
 	}
 }
+
+func TestReduceDimension(t *testing.T) {
+	t.Parallel()
+
+	reduced, err := ReduceDimension([]float32{3, 4, 12}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.6, 0.8}, reduced)
+
+	vector := []float32{1, 2}
+	reduced, err = ReduceDimension(vector, 2)
+	require.NoError(t, err)
+	assert.Equal(t, vector, reduced)
+
+	_, err = ReduceDimension(vector, 3)
+	require.ErrorIs(t, err, ErrDimensionTooLarge)
+}
@@ -37,6 +37,23 @@ func WithToken(token string) Option {
 	}
 }
 
+// WithBaseURL is an option for providing the base url of the VoyageAI API.
+func WithBaseURL(baseURL string) Option {
+	return func(v *VoyageAI) {
+		v.baseURL = baseURL
+	}
+}
+
+// WithInputType is an option for embedding both the documents and the
+// queries with inputType instead of InputTypeDocument and InputTypeQuery,
+// e.g. "" to embed them without the retrieval prompts of the models.
+func WithInputType(inputType string) Option {
+	return func(v *VoyageAI) {
+		v.documentInputType = inputType
+		v.queryInputType = inputType
+	}
+}
+
 // WithStripNewLines is an option for specifying the should it strip new lines.
 func WithStripNewLines(stripNewLines bool) Option {
 	return func(v *VoyageAI) {
@@ -53,10 +70,12 @@ func WithBatchSize(batchSize int) Option {
 
 func applyOptions(opts ...Option) (*VoyageAI, error) {
 	o := &VoyageAI{
-		baseURL:       _defaultBaseURL,
-		Model:         _defaultModel,
-		StripNewLines: _defaultStripNewLines,
-		BatchSize:     _defaultBatchSize,
+		baseURL:           _defaultBaseURL,
+		documentInputType: InputTypeDocument,
+		queryInputType:    InputTypeQuery,
+		Model:             _defaultModel,
+		StripNewLines:     _defaultStripNewLines,
+		BatchSize:         _defaultBatchSize,
 	}
 	for _, opt := range opts {
 		opt(o)
@@ -10,16 +10,27 @@ import (
 	"github.com/tmc/langchaingo/embeddings"
 )
 
+const (
+	// InputTypeDocument is the input type of the documents stored for
+	// retrieval, the default input type of the documents.
+	InputTypeDocument = "document"
+	// InputTypeQuery is the input type of the retrieval queries, the default
+	// input type of the queries.
+	InputTypeQuery = "query"
+)
+
 var _ embeddings.Embedder = &VoyageAI{}
 
 // VoyageAI is the embedder using the VoyageAI api to create embeddings.
 type VoyageAI struct {
-	baseURL       string
-	token         string
-	client        *http.Client
-	Model         string
-	StripNewLines bool
-	BatchSize     int
+	baseURL           string
+	token             string
+	client            *http.Client
+	documentInputType string
+	queryInputType    string
+	Model             string
+	StripNewLines     bool
+	BatchSize         int
 }
 
 // NewVoyageAI returns a new embedder that uses the VoyageAI api.
@@ -38,10 +49,10 @@ type embeddingResponse struct {
 	}
 }
 
-type embedDocumentsRequest struct {
+type embeddingRequest struct {
 	Model     string   `json:"model"`
 	Input     []string `json:"input"`
-	InputType string   `json:"input_type"`
+	InputType string   `json:"input_type,omitempty"`
 }
 
 // EmbedDocuments implements the `embeddings.Embedder` and creates an embedding for each of the texts.
@@ -53,51 +64,34 @@ func (v *VoyageAI) EmbedDocuments(ctx context.Context, texts []string) ([][]floa
 
 	embeddings := make([][]float32, 0, len(texts))
 	for _, batch := range batchedTexts {
-		req := embedDocumentsRequest{
-			Model:     v.Model,
-			Input:     batch,
-			InputType: "document",
-		}
-
-		resp, err := v.request(ctx, "/embeddings", req)
+		batchEmbeddings, err := v.embed(ctx, batch, v.documentInputType)
 		if err != nil {
 			return nil, fmt.Errorf("embed documents request error: %w", err)
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, v.decodeError(resp)
-		}
-
-		var embeddingResp embeddingResponse
-		if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
-			return nil, err
-		}
-
-		for _, data := range embeddingResp.Data {
-			embeddings = append(embeddings, data.Embedding)
-		}
+		embeddings = append(embeddings, batchEmbeddings...)
 	}
 	return embeddings, nil
 }
 
-type embedQueryRequest struct {
-	Model     string `json:"model"`
-	Input     string `json:"input"`
-	InputType string `json:"input_type"`
-}
-
 // EmbedQuery implements the `embeddings.Embedder` and creates an embedding for the query text.
 func (v *VoyageAI) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
-	req := embedQueryRequest{
-		Model:     v.Model,
-		Input:     text,
-		InputType: "query",
-	}
-	resp, err := v.request(ctx, "/embeddings", req)
+	embeddings, err := v.embed(ctx, []string{text}, v.queryInputType)
 	if err != nil {
 		return nil, fmt.Errorf("embed query request error: %w", err)
 	}
+	return embeddings[0], nil
+}
+
+// embed creates the embeddings of texts, embedded as inputType.
+func (v *VoyageAI) embed(ctx context.Context, texts []string, inputType string) ([][]float32, error) {
+	resp, err := v.request(ctx, "/embeddings", embeddingRequest{
+		Model:     v.Model,
+		Input:     texts,
+		InputType: inputType,
+	})
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
@@ -108,7 +102,14 @@ func (v *VoyageAI) EmbedQuery(ctx context.Context, text string) ([]float32, erro
 	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
 		return nil, err
 	}
-	return embeddingResp.Data[0].Embedding, nil
+	if len(embeddingResp.Data) != len(texts) {
+		return nil, fmt.Errorf("returned %d embeddings for %d texts", len(embeddingResp.Data), len(texts))
+	}
+	embeddings := make([][]float32, len(embeddingResp.Data))
+	for i, data := range embeddingResp.Data {
+		embeddings[i] = data.Embedding
+	}
+	return embeddings, nil
 }
 
 func (v *VoyageAI) request(ctx context.Context, path string, body any) (*http.Response, error) {
@@ -135,5 +136,5 @@ func (v *VoyageAI) decodeError(resp *http.Response) error {
 	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
 		return fmt.Errorf("unexpected error: %w", err)
 	}
-	return fmt.Errorf("embedding error: %s", errResp.Detail)
+	return fmt.Errorf("embedding error: status code %d: %s", resp.StatusCode, errResp.Detail)
 }
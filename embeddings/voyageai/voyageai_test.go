@@ -2,6 +2,9 @@ package voyageai
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -25,3 +28,49 @@ func TestVoyageAIEmbeddings(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, embeddings, 3)
 }
+
+func TestVoyageAIInputTypes(t *testing.T) {
+	t.Parallel()
+
+	var requests []embeddingRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/embeddings", r.URL.Path)
+		var req embeddingRequest
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&req)) {
+			return
+		}
+		requests = append(requests, req)
+		var resp embeddingResponse
+		resp.Data = make([]struct {
+			Embedding []float32 `json:"embedding"`
+		}, len(req.Input))
+		for i := range req.Input {
+			resp.Data[i].Embedding = []float32{float32(i)}
+		}
+		json.NewEncoder(w).Encode(resp) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	e, err := NewVoyageAI(WithToken("token"), WithBaseURL(server.URL), WithBatchSize(2))
+	require.NoError(t, err)
+	vectors, err := e.EmbedDocuments(context.Background(), []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0}, {1}, {0}}, vectors)
+	vector, err := e.EmbedQuery(context.Background(), "q")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0}, vector)
+
+	require.Len(t, requests, 3)
+	assert.Equal(t, InputTypeDocument, requests[0].InputType)
+	assert.Equal(t, InputTypeDocument, requests[1].InputType)
+	assert.Equal(t, InputTypeQuery, requests[2].InputType)
+	assert.Equal(t, []string{"q"}, requests[2].Input)
+
+	requests = nil
+	e, err = NewVoyageAI(WithToken("token"), WithBaseURL(server.URL), WithInputType(""))
+	require.NoError(t, err)
+	_, err = e.EmbedQuery(context.Background(), "q")
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Empty(t, requests[0].InputType)
+}
@@ -0,0 +1,54 @@
+package evaluation
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+// EmbeddingSimilarity scores predictions by the cosine similarity of their
+// embedding to the embedding of their reference, so that paraphrases of the
+// reference score close to 1. Negative similarities score 0.
+type EmbeddingSimilarity struct {
+	Embedder embeddings.Embedder
+}
+
+var _ Evaluator = EmbeddingSimilarity{}
+
+// NewEmbeddingSimilarity creates an EmbeddingSimilarity evaluator embedding
+// the strings with embedder.
+func NewEmbeddingSimilarity(embedder embeddings.Embedder) EmbeddingSimilarity {
+	return EmbeddingSimilarity{Embedder: embedder}
+}
+
+// EvaluateStrings implements the Evaluator interface.
+func (e EmbeddingSimilarity) EvaluateStrings(ctx context.Context, prediction, reference string) (float64, error) {
+	vectors, err := e.Embedder.EmbedDocuments(ctx, []string{prediction, reference})
+	if err != nil {
+		return 0, fmt.Errorf("failed to embed strings: %w", err)
+	}
+	if len(vectors) != 2 {
+		return 0, fmt.Errorf("embedder returned %d embeddings for 2 strings", len(vectors))
+	}
+	if len(vectors[0]) != len(vectors[1]) {
+		return 0, fmt.Errorf("embeddings have different dimensions %d and %d", len(vectors[0]), len(vectors[1]))
+	}
+	return max(cosineSimilarity(vectors[0], vectors[1]), 0), nil
+}
+
+// cosineSimilarity returns the cosine similarity of a and b, which have the
+// same dimension, 0 when either is zero.
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,52 @@
+// Package evaluation contains evaluators scoring the outputs of chains and
+// models against reference outputs without an LLM judge, e.g. for regression
+// tests of prompts.
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrMismatchedLengths is returned by Evaluate when the numbers of
+// predictions and references differ.
+var ErrMismatchedLengths = errors.New("number of predictions and references differ")
+
+// Evaluator scores a prediction against a reference output. Scores are
+// between 0 and 1, 1 meaning the prediction matches the reference.
+type Evaluator interface {
+	EvaluateStrings(ctx context.Context, prediction, reference string) (float64, error)
+}
+
+// EvaluatorFunc is an adapter to allow the use of ordinary functions as
+// Evaluators.
+type EvaluatorFunc func(ctx context.Context, prediction, reference string) (float64, error)
+
+// EvaluateStrings calls f(ctx, prediction, reference).
+func (f EvaluatorFunc) EvaluateStrings(ctx context.Context, prediction, reference string) (float64, error) {
+	return f(ctx, prediction, reference)
+}
+
+// Evaluate scores the predictions against the references of the same index
+// with evaluator, and returns the scores along with their mean.
+func Evaluate(ctx context.Context, evaluator Evaluator, predictions, references []string) ([]float64, float64, error) {
+	if len(predictions) != len(references) {
+		return nil, 0, fmt.Errorf("%w: %d predictions, %d references",
+			ErrMismatchedLengths, len(predictions), len(references))
+	}
+	if len(predictions) == 0 {
+		return nil, 0, nil
+	}
+	scores := make([]float64, len(predictions))
+	var sum float64
+	for i := range predictions {
+		score, err := evaluator.EvaluateStrings(ctx, predictions[i], references[i])
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to evaluate prediction %d: %w", i, err)
+		}
+		scores[i] = score
+		sum += score
+	}
+	return scores, sum / float64(len(scores)), nil
+}
@@ -0,0 +1,114 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExactMatch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	score, err := ExactMatch{}.EvaluateStrings(ctx, "Paris", "paris")
+	require.NoError(t, err)
+	require.InDelta(t, 0.0, score, 1e-9)
+
+	score, err = ExactMatch{IgnoreCase: true, IgnoreSpace: true}.EvaluateStrings(ctx, " Paris\n", "paris")
+	require.NoError(t, err)
+	require.InDelta(t, 1.0, score, 1e-9)
+}
+
+func TestLevenshtein(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"héllo", "hello", 1},
+	}
+	for _, tt := range tests {
+		require.Equalf(t, tt.want, Levenshtein(tt.a, tt.b), "%q, %q", tt.a, tt.b)
+		require.Equalf(t, tt.want, Levenshtein(tt.b, tt.a), "%q, %q", tt.b, tt.a)
+	}
+
+	score, err := LevenshteinSimilarity{}.EvaluateStrings(context.Background(), "kitten", "sitting")
+	require.NoError(t, err)
+	require.InDelta(t, 1-3.0/7, score, 1e-9)
+}
+
+func TestTokenOverlap(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	score, err := TokenOverlap{}.EvaluateStrings(ctx, "The cat sat.", "the cat sat on the mat")
+	require.NoError(t, err)
+	// Precision 3/3, recall 3/6.
+	require.InDelta(t, 2.0/3, score, 1e-9)
+
+	score, err = TokenOverlap{}.EvaluateStrings(ctx, "dog", "the cat")
+	require.NoError(t, err)
+	require.InDelta(t, 0.0, score, 1e-9)
+
+	score, err = TokenOverlap{}.EvaluateStrings(ctx, "", "")
+	require.NoError(t, err)
+	require.InDelta(t, 1.0, score, 1e-9)
+}
+
+// letterEmbedder embeds texts as the counts of the letters a, b and c.
+type letterEmbedder struct{}
+
+func (e letterEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i], _ = e.EmbedQuery(ctx, text)
+	}
+	return vectors, nil
+}
+
+func (letterEmbedder) EmbedQuery(_ context.Context, text string) ([]float32, error) {
+	return []float32{
+		float32(strings.Count(text, "a")),
+		float32(strings.Count(text, "b")),
+		float32(strings.Count(text, "c")),
+	}, nil
+}
+
+func TestEmbeddingSimilarity(t *testing.T) {
+	t.Parallel()
+	evaluator := NewEmbeddingSimilarity(letterEmbedder{})
+
+	score, err := evaluator.EvaluateStrings(context.Background(), "ab", "ba")
+	require.NoError(t, err)
+	require.InDelta(t, 1.0, score, 1e-6)
+
+	score, err = evaluator.EvaluateStrings(context.Background(), "a", "b")
+	require.NoError(t, err)
+	require.InDelta(t, 0.0, score, 1e-6)
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	scores, mean, err := Evaluate(ctx, ExactMatch{}, []string{"a", "b"}, []string{"a", "c"})
+	require.NoError(t, err)
+	require.Equal(t, []float64{1, 0}, scores)
+	require.InDelta(t, 0.5, mean, 1e-9)
+
+	_, _, err = Evaluate(ctx, ExactMatch{}, []string{"a"}, nil)
+	require.ErrorIs(t, err, ErrMismatchedLengths)
+
+	errEvaluate := errors.New("evaluation failed")
+	_, _, err = Evaluate(ctx, EvaluatorFunc(func(context.Context, string, string) (float64, error) {
+		return 0, errEvaluate
+	}), []string{"a"}, []string{"a"})
+	require.ErrorIs(t, err, errEvaluate)
+}
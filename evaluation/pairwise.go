@@ -0,0 +1,199 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/prompts"
+)
+
+//nolint:lll
+const defaultPairwisePrompt = `Act as an impartial judge and evaluate the quality of the responses provided by two AI assistants to the user question below. Choose the assistant that follows the user's instructions and answers the question better, considering helpfulness, relevance, accuracy, depth and level of detail.{{if .reference}} Compare both responses with the reference answer.{{end}} Do not let the order, the length of the responses or the names of the assistants influence your decision. Be as objective as possible.
+
+After a short explanation, output your final verdict strictly in this format: "[[A]]" if assistant A is better, "[[B]]" if assistant B is better, and "[[C]]" for a tie.
+
+[User Question]
+{{.input}}
+{{if .reference}}
+[Reference Answer]
+{{.reference}}
+{{end}}
+[The Start of Assistant A's Answer]
+{{.answer_a}}
+[The End of Assistant A's Answer]
+
+[The Start of Assistant B's Answer]
+{{.answer_b}}
+[The End of Assistant B's Answer]`
+
+// ErrNoVerdict is returned when the judge response has no verdict.
+var ErrNoVerdict = errors.New("judge response has no verdict")
+
+// Preference is the outcome of a pairwise comparison.
+type Preference string
+
+const (
+	// PreferA means that the first prediction is better.
+	PreferA Preference = "A"
+	// PreferB means that the second prediction is better.
+	PreferB Preference = "B"
+	// Tie means that neither prediction is better.
+	Tie Preference = "tie"
+)
+
+// PairwiseRecord is the record of a pairwise comparison of two predictions.
+type PairwiseRecord struct {
+	Input       string
+	PredictionA string
+	PredictionB string
+	Reference   string
+	// Outcome is the verdict of both orders when they agree, and a tie
+	// otherwise.
+	Outcome Preference
+	// Verdicts are the verdicts with the predictions presented in order,
+	// then swapped. Both are expressed with respect to PredictionA and
+	// PredictionB.
+	Verdicts [2]Preference
+}
+
+// PairwiseJudge compares two predictions with a judge model. As judges tend
+// to favor the answer presented first, the predictions are judged in both
+// orders, and only the preferences holding in both orders count.
+type PairwiseJudge struct {
+	// LLM is the judge model.
+	LLM llms.Model
+	// Prompt is the prompt of the judge, with the input, reference, answer_a
+	// and answer_b variables. The judge must answer with [[A]], [[B]] or
+	// [[C]] for a tie.
+	Prompt prompts.PromptTemplate
+	// CallOptions are the options of the calls to the judge model.
+	CallOptions []llms.CallOption
+	// Record, if not nil, is called with the records of the comparisons,
+	// e.g. to store them in the tables of an evaluation.
+	Record func(ctx context.Context, record PairwiseRecord) error
+}
+
+// NewPairwiseJudge creates a PairwiseJudge with llm as judge model.
+func NewPairwiseJudge(llm llms.Model) *PairwiseJudge {
+	return &PairwiseJudge{
+		LLM:    llm,
+		Prompt: prompts.NewPromptTemplate(defaultPairwisePrompt, []string{"input", "reference", "answer_a", "answer_b"}),
+	}
+}
+
+// Compare judges which of predictionA and predictionB better answers input,
+// with respect to reference, if not empty.
+func (j *PairwiseJudge) Compare(ctx context.Context, input, predictionA, predictionB, reference string) (PairwiseRecord, error) {
+	record := PairwiseRecord{
+		Input:       input,
+		PredictionA: predictionA,
+		PredictionB: predictionB,
+		Reference:   reference,
+	}
+	inOrder, err := j.judge(ctx, input, predictionA, predictionB, reference)
+	if err != nil {
+		return record, err
+	}
+	swapped, err := j.judge(ctx, input, predictionB, predictionA, reference)
+	if err != nil {
+		return record, err
+	}
+	record.Verdicts = [2]Preference{inOrder, swapped.swap()}
+	record.Outcome = Tie
+	if record.Verdicts[0] == record.Verdicts[1] {
+		record.Outcome = record.Verdicts[0]
+	}
+	if j.Record != nil {
+		if err := j.Record(ctx, record); err != nil {
+			return record, fmt.Errorf("failed to record comparison: %w", err)
+		}
+	}
+	return record, nil
+}
+
+// judge returns the verdict of the judge model with answerA presented first.
+func (j *PairwiseJudge) judge(ctx context.Context, input, answerA, answerB, reference string) (Preference, error) {
+	prompt, err := j.Prompt.Format(map[string]any{
+		"input":     input,
+		"reference": reference,
+		"answer_a":  answerA,
+		"answer_b":  answerB,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to format judge prompt: %w", err)
+	}
+	response, err := llms.GenerateFromSinglePrompt(ctx, j.LLM, prompt, j.CallOptions...)
+	if err != nil {
+		return "", fmt.Errorf("failed to call judge model: %w", err)
+	}
+	return parseVerdict(response)
+}
+
+// parseVerdict returns the last verdict of a judge response.
+func parseVerdict(response string) (Preference, error) {
+	i := max(
+		strings.LastIndex(response, "[[A]]"),
+		strings.LastIndex(response, "[[B]]"),
+		strings.LastIndex(response, "[[C]]"),
+	)
+	if i < 0 {
+		return "", fmt.Errorf("%w: %q", ErrNoVerdict, response)
+	}
+	switch response[i+2] {
+	case 'A':
+		return PreferA, nil
+	case 'B':
+		return PreferB, nil
+	default:
+		return Tie, nil
+	}
+}
+
+// swap returns the preference with the predictions swapped.
+func (p Preference) swap() Preference {
+	switch p {
+	case PreferA:
+		return PreferB
+	case PreferB:
+		return PreferA
+	default:
+		return p
+	}
+}
+
+// PairwiseSummary sums up the outcomes of pairwise comparisons, from the
+// point of view of the first prediction.
+type PairwiseSummary struct {
+	Wins   int
+	Losses int
+	Ties   int
+}
+
+// SummarizePairwise sums up the outcomes of records.
+func SummarizePairwise(records []PairwiseRecord) PairwiseSummary {
+	var s PairwiseSummary
+	for _, record := range records {
+		switch record.Outcome {
+		case PreferA:
+			s.Wins++
+		case PreferB:
+			s.Losses++
+		default:
+			s.Ties++
+		}
+	}
+	return s
+}
+
+// WinRate returns the fraction of the comparisons won by the first
+// prediction, counting ties as half a win, or 0 without comparisons.
+func (s PairwiseSummary) WinRate() float64 {
+	n := s.Wins + s.Losses + s.Ties
+	if n == 0 {
+		return 0
+	}
+	return (float64(s.Wins) + float64(s.Ties)/2) / float64(n)
+}
@@ -0,0 +1,58 @@
+package evaluation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms/fake"
+)
+
+func TestPairwiseJudge(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		responses    []string
+		wantOutcome  Preference
+		wantVerdicts [2]Preference
+	}{
+		// The second response judges the predictions swapped.
+		{"consistent", []string{"A is more accurate. [[A]]", "B is more accurate. [[B]]"}, PreferA, [2]Preference{PreferA, PreferA}},
+		{"positional bias", []string{"[[A]]", "[[A]]"}, Tie, [2]Preference{PreferA, PreferB}},
+		{"tie", []string{"[[C]]", "[[C]]"}, Tie, [2]Preference{Tie, Tie}},
+		{"last verdict", []string{"Not [[A]] but [[B]]", "[[A]]"}, PreferB, [2]Preference{PreferB, PreferB}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			judge := NewPairwiseJudge(fake.NewFakeLLM(tt.responses))
+			var recorded []PairwiseRecord
+			judge.Record = func(_ context.Context, record PairwiseRecord) error {
+				recorded = append(recorded, record)
+				return nil
+			}
+			record, err := judge.Compare(context.Background(), "question", "first", "second", "")
+			require.NoError(t, err)
+			require.Equal(t, tt.wantOutcome, record.Outcome)
+			require.Equal(t, tt.wantVerdicts, record.Verdicts)
+			require.Equal(t, []PairwiseRecord{record}, recorded)
+		})
+	}
+}
+
+func TestPairwiseJudgeNoVerdict(t *testing.T) {
+	t.Parallel()
+	judge := NewPairwiseJudge(fake.NewFakeLLM([]string{"Both are fine."}))
+	_, err := judge.Compare(context.Background(), "question", "first", "second", "reference")
+	require.ErrorIs(t, err, ErrNoVerdict)
+}
+
+func TestSummarizePairwise(t *testing.T) {
+	t.Parallel()
+	summary := SummarizePairwise([]PairwiseRecord{
+		{Outcome: PreferA}, {Outcome: PreferA}, {Outcome: PreferB}, {Outcome: Tie},
+	})
+	require.Equal(t, PairwiseSummary{Wins: 2, Losses: 1, Ties: 1}, summary)
+	require.InDelta(t, 0.625, summary.WinRate(), 1e-9)
+	require.InDelta(t, 0.0, PairwiseSummary{}.WinRate(), 1e-9)
+}
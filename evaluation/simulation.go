@@ -0,0 +1,213 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+const (
+	defaultMaxTurns = 10
+
+	// doneToken is answered by the simulated user once its goal is reached.
+	doneToken = "[[DONE]]"
+
+	//nolint:lll
+	simulatedUserPrompt = `You are role-playing a user talking with an AI assistant, to test the assistant. Stay in character: write only the next message of the user, in the first person, as the user would type it, and never act as the assistant. Once your goal is reached, or if the assistant can't help you, reply with %s only.
+
+[Persona]
+%s
+
+[Goal]
+%s`
+
+	//nolint:lll
+	goalCompletionPrompt = `Act as an impartial judge and evaluate whether the AI assistant helped the user reach their goal in the conversation below. Consider whether the goal was fully reached, and the correctness of the information the assistant gave.
+
+After a short explanation, rate the goal completion on a scale of 0 to 10, strictly in this format: "[[rating]]", e.g. "[[7]]".
+
+[Goal]
+%s
+
+[Conversation]
+%s`
+)
+
+// ErrNoRating is returned when the judge response has no rating.
+var ErrNoRating = errors.New("judge response has no rating")
+
+var ratingRegexp = regexp.MustCompile(`\[\[(\d+(?:\.\d+)?)\]\]`)
+
+// Agent is the agent under test of a simulation, answering a user message.
+// It keeps the state of the conversation, e.g. in its memory.
+type Agent func(ctx context.Context, input string) (string, error)
+
+// ChainAgent returns an Agent running chain, e.g. an agents.Executor with
+// memory, with the user messages as input.
+func ChainAgent(chain chains.Chain, options ...chains.ChainCallOption) Agent {
+	return func(ctx context.Context, input string) (string, error) {
+		return chains.Run(ctx, chain, input, options...)
+	}
+}
+
+// Scenario is a scenario of a simulated conversation.
+type Scenario struct {
+	// Persona describes the user, e.g. "A customer who ordered a laptop
+	// last week, impatient and terse".
+	Persona string
+	// Goal is what the user wants to achieve, e.g. "Get a refund for the
+	// laptop, which arrived damaged".
+	Goal string
+	// Script are the first messages of the user, sent as they are. The
+	// following ones are generated by the user model.
+	Script []string
+	// MaxTurns is the maximum number of exchanges of the conversation. It
+	// defaults to 10.
+	MaxTurns int
+}
+
+// SimulationResult is the result of a simulated conversation.
+type SimulationResult struct {
+	Transcript []llms.ChatMessage
+	// Score is the goal completion rated by the judge, between 0 and 1.
+	Score float64
+	// Explanation is the response of the judge.
+	Explanation string
+}
+
+// Simulator simulates conversations of an agent with a user played by a
+// model, following the persona, goal and script of scenarios, and rates the
+// goal completion of the conversations with a judge model, for regression
+// tests of multi-turn behavior.
+type Simulator struct {
+	// User is the model playing the user.
+	User llms.Model
+	// Agent is the agent under test.
+	Agent Agent
+	// Judge is the model rating the goal completion. It defaults to User.
+	Judge llms.Model
+	// History, if not nil, records the transcript of the conversations, e.g.
+	// a memory/alloydb.ChatMessageHistory to store them in AlloyDB.
+	History schema.ChatMessageHistory
+}
+
+// NewSimulator creates a Simulator of agent with user playing the user and
+// rating the conversations.
+func NewSimulator(user llms.Model, agent Agent) *Simulator {
+	return &Simulator{User: user, Agent: agent}
+}
+
+// Run simulates a conversation following scenario until the user reaches its
+// goal or the maximum number of turns, and rates its goal completion.
+func (s *Simulator) Run(ctx context.Context, scenario Scenario) (SimulationResult, error) {
+	maxTurns := scenario.MaxTurns
+	if maxTurns <= 0 {
+		maxTurns = defaultMaxTurns
+	}
+	var result SimulationResult
+	for turn := 0; turn < maxTurns; turn++ {
+		input, err := s.userMessage(ctx, scenario, result.Transcript, turn)
+		if err != nil {
+			return result, err
+		}
+		if strings.Contains(input, doneToken) {
+			break
+		}
+		if err := s.record(ctx, &result, llms.HumanChatMessage{Content: input}); err != nil {
+			return result, err
+		}
+		output, err := s.Agent(ctx, input)
+		if err != nil {
+			return result, fmt.Errorf("agent failed at turn %d: %w", turn, err)
+		}
+		if err := s.record(ctx, &result, llms.AIChatMessage{Content: output}); err != nil {
+			return result, err
+		}
+	}
+
+	score, explanation, err := s.rate(ctx, scenario.Goal, result.Transcript)
+	if err != nil {
+		return result, err
+	}
+	result.Score, result.Explanation = score, explanation
+	return result, nil
+}
+
+// userMessage returns the message of the user at turn, from the script or
+// generated by the user model.
+func (s *Simulator) userMessage(ctx context.Context, scenario Scenario, transcript []llms.ChatMessage, turn int) (string, error) {
+	if turn < len(scenario.Script) {
+		return scenario.Script[turn], nil
+	}
+	// The user model plays the AI side of the conversation.
+	messages := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, fmt.Sprintf(simulatedUserPrompt, doneToken, scenario.Persona, scenario.Goal)),
+	}
+	for _, message := range transcript {
+		role := llms.ChatMessageTypeHuman
+		if message.GetType() == llms.ChatMessageTypeHuman {
+			role = llms.ChatMessageTypeAI
+		}
+		messages = append(messages, llms.TextParts(role, message.GetContent()))
+	}
+	if len(transcript) == 0 {
+		messages = append(messages, llms.TextParts(llms.ChatMessageTypeHuman, "Hello, how can I help you?"))
+	}
+	resp, err := s.User.GenerateContent(ctx, messages)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate user message at turn %d: %w", turn, err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("failed to generate user message at turn %d: empty response", turn)
+	}
+	return strings.TrimSpace(resp.Choices[0].Content), nil
+}
+
+// record appends message to the transcript of result and to the history.
+func (s *Simulator) record(ctx context.Context, result *SimulationResult, message llms.ChatMessage) error {
+	result.Transcript = append(result.Transcript, message)
+	if s.History == nil {
+		return nil
+	}
+	if err := s.History.AddMessage(ctx, message); err != nil {
+		return fmt.Errorf("failed to record transcript: %w", err)
+	}
+	return nil
+}
+
+// rate returns the goal completion of transcript rated by the judge model,
+// along with its explanation.
+func (s *Simulator) rate(ctx context.Context, goal string, transcript []llms.ChatMessage) (float64, string, error) {
+	judge := s.Judge
+	if judge == nil {
+		judge = s.User
+	}
+	lines := make([]string, len(transcript))
+	for i, message := range transcript {
+		role := "User"
+		if message.GetType() == llms.ChatMessageTypeAI {
+			role = "Assistant"
+		}
+		lines[i] = fmt.Sprintf("%s: %s", role, message.GetContent())
+	}
+	response, err := llms.GenerateFromSinglePrompt(ctx, judge, fmt.Sprintf(goalCompletionPrompt, goal, strings.Join(lines, "\n")))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to call judge model: %w", err)
+	}
+	matches := ratingRegexp.FindAllStringSubmatch(response, -1)
+	if len(matches) == 0 {
+		return 0, response, fmt.Errorf("%w: %q", ErrNoRating, response)
+	}
+	rating, err := strconv.ParseFloat(matches[len(matches)-1][1], 64)
+	if err != nil {
+		return 0, response, fmt.Errorf("%w: %w", ErrNoRating, err)
+	}
+	return min(rating, 10) / 10, response, nil
+}
@@ -0,0 +1,74 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/fake"
+	"github.com/tmc/langchaingo/memory"
+)
+
+func TestSimulator(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var inputs []string
+	agent := func(_ context.Context, input string) (string, error) {
+		inputs = append(inputs, input)
+		return "answer " + input, nil
+	}
+	history := memory.NewChatMessageHistory()
+	simulator := NewSimulator(fake.NewFakeLLM([]string{"I need a refund", "[[DONE]]"}), agent)
+	simulator.Judge = fake.NewFakeLLM([]string{"The refund was granted. [[8]]"})
+	simulator.History = history
+
+	result, err := simulator.Run(ctx, Scenario{
+		Persona: "A customer",
+		Goal:    "Get a refund",
+		Script:  []string{"Hello"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Hello", "I need a refund"}, inputs)
+	require.Equal(t, []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "Hello"},
+		llms.AIChatMessage{Content: "answer Hello"},
+		llms.HumanChatMessage{Content: "I need a refund"},
+		llms.AIChatMessage{Content: "answer I need a refund"},
+	}, result.Transcript)
+	require.InDelta(t, 0.8, result.Score, 1e-9)
+
+	recorded, err := history.Messages(ctx)
+	require.NoError(t, err)
+	require.Equal(t, result.Transcript, recorded)
+}
+
+func TestSimulatorMaxTurns(t *testing.T) {
+	t.Parallel()
+	agent := func(context.Context, string) (string, error) { return "no", nil }
+	simulator := NewSimulator(fake.NewFakeLLM([]string{"please"}), agent)
+	simulator.Judge = fake.NewFakeLLM([]string{"[[0]]"})
+
+	result, err := simulator.Run(context.Background(), Scenario{Goal: "Get a yes", MaxTurns: 3})
+	require.NoError(t, err)
+	require.Len(t, result.Transcript, 6)
+	require.InDelta(t, 0.0, result.Score, 1e-9)
+}
+
+func TestSimulatorErrors(t *testing.T) {
+	t.Parallel()
+	errAgent := errors.New("agent failed")
+	simulator := NewSimulator(fake.NewFakeLLM([]string{"hi"}), func(context.Context, string) (string, error) {
+		return "", errAgent
+	})
+	_, err := simulator.Run(context.Background(), Scenario{Goal: "goal"})
+	require.ErrorIs(t, err, errAgent)
+
+	simulator = NewSimulator(fake.NewFakeLLM([]string{"[[DONE]]"}), func(context.Context, string) (string, error) {
+		return "", nil
+	})
+	simulator.Judge = fake.NewFakeLLM([]string{"No rating."})
+	_, err = simulator.Run(context.Background(), Scenario{Goal: "goal"})
+	require.ErrorIs(t, err, ErrNoRating)
+}
@@ -0,0 +1,114 @@
+package evaluation
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// ExactMatch scores 1 the predictions equal to their reference, and 0 the
+// others.
+type ExactMatch struct {
+	// IgnoreCase compares the strings case-insensitively.
+	IgnoreCase bool
+	// IgnoreSpace ignores the leading and trailing white space of the
+	// strings.
+	IgnoreSpace bool
+}
+
+var _ Evaluator = ExactMatch{}
+
+// EvaluateStrings implements the Evaluator interface.
+func (e ExactMatch) EvaluateStrings(_ context.Context, prediction, reference string) (float64, error) {
+	if e.IgnoreSpace {
+		prediction, reference = strings.TrimSpace(prediction), strings.TrimSpace(reference)
+	}
+	if prediction == reference || (e.IgnoreCase && strings.EqualFold(prediction, reference)) {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// LevenshteinSimilarity scores predictions by their edit distance to their
+// reference, normalized by the length of the longest of the two: 1 for equal
+// strings, 0 for strings without a character in common.
+type LevenshteinSimilarity struct{}
+
+var _ Evaluator = LevenshteinSimilarity{}
+
+// EvaluateStrings implements the Evaluator interface.
+func (LevenshteinSimilarity) EvaluateStrings(_ context.Context, prediction, reference string) (float64, error) {
+	n := max(len([]rune(prediction)), len([]rune(reference)))
+	if n == 0 {
+		return 1, nil
+	}
+	return 1 - float64(Levenshtein(prediction, reference))/float64(n), nil
+}
+
+// Levenshtein returns the minimum number of single character insertions,
+// deletions and substitutions changing a into b.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// Only the previous row of the distance matrix is kept.
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			substitution := diagonal
+			if ra[i-1] != rb[j-1] {
+				substitution++
+			}
+			diagonal = row[j]
+			row[j] = min(row[j]+1, row[j-1]+1, substitution)
+		}
+	}
+	return row[len(rb)]
+}
+
+// TokenOverlap scores predictions by the F1 score of their words found in
+// their reference, as ROUGE-1 does: the harmonic mean of the fraction of the
+// words of the prediction found in the reference and the fraction of the
+// words of the reference found in the prediction. Words are compared
+// case-insensitively, ignoring punctuation.
+type TokenOverlap struct{}
+
+var _ Evaluator = TokenOverlap{}
+
+// EvaluateStrings implements the Evaluator interface.
+func (TokenOverlap) EvaluateStrings(_ context.Context, prediction, reference string) (float64, error) {
+	predicted, expected := words(prediction), words(reference)
+	if len(predicted) == 0 && len(expected) == 0 {
+		return 1, nil
+	}
+	if len(predicted) == 0 || len(expected) == 0 {
+		return 0, nil
+	}
+	counts := make(map[string]int, len(expected))
+	for _, word := range expected {
+		counts[word]++
+	}
+	overlap := 0
+	for _, word := range predicted {
+		if counts[word] > 0 {
+			counts[word]--
+			overlap++
+		}
+	}
+	if overlap == 0 {
+		return 0, nil
+	}
+	precision := float64(overlap) / float64(len(predicted))
+	recall := float64(overlap) / float64(len(expected))
+	return 2 * precision * recall / (precision + recall), nil
+}
+
+// words returns the lowercase words of s.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,157 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tmc/langchaingo/indexes"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+const defaultSchemaName = "public"
+
+// RecordManager is an indexes.RecordManager recording the documents indexed
+// in a vector store in a table created with
+// alloydbutil.InitRecordManagerTable. The records of several vector stores
+// can share a table under different namespaces.
+type RecordManager struct {
+	engine     alloydbutil.PostgresEngine
+	tableName  string
+	schemaName string
+	namespace  string
+}
+
+var _ indexes.RecordManager = RecordManager{}
+
+// RecordManagerOption is a function for creating a new RecordManager with
+// other than the default values.
+type RecordManagerOption func(m *RecordManager)
+
+// WithSchemaName sets the schema of the table of the RecordManager. It
+// defaults to "public".
+func WithSchemaName(schemaName string) RecordManagerOption {
+	return func(m *RecordManager) {
+		m.schemaName = schemaName
+	}
+}
+
+// NewRecordManager creates a new RecordManager keeping the records of
+// namespace, e.g. the name of the vector store table, in tableName.
+func NewRecordManager(engine alloydbutil.PostgresEngine,
+	tableName string,
+	namespace string,
+	opts ...RecordManagerOption,
+) (RecordManager, error) {
+	m := RecordManager{
+		engine:     engine,
+		tableName:  tableName,
+		schemaName: defaultSchemaName,
+		namespace:  namespace,
+	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	if m.engine.Pool == nil {
+		return RecordManager{}, errors.New("missing record manager engine")
+	}
+	if m.tableName == "" {
+		return RecordManager{}, errors.New("missing record manager table name")
+	}
+	if m.namespace == "" {
+		return RecordManager{}, errors.New("missing record manager namespace")
+	}
+	return m, nil
+}
+
+// Now returns the current time of the database, which timestamps the
+// records.
+func (m RecordManager) Now(ctx context.Context) (time.Time, error) {
+	var now time.Time
+	if err := m.engine.Pool.QueryRow(ctx, `SELECT NOW()`).Scan(&now); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get database time: %w", err)
+	}
+	return now, nil
+}
+
+// Get returns the existing records of keys.
+func (m RecordManager) Get(ctx context.Context, keys []string) ([]indexes.Record, error) {
+	query := fmt.Sprintf(`SELECT key, source_id, document_id, updated_at FROM %q.%q WHERE namespace = $1 AND key = ANY($2)`,
+		m.schemaName, m.tableName)
+	records, err := m.queryRecords(ctx, query, m.namespace, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get records: %w", err)
+	}
+	return records, nil
+}
+
+// Update inserts or replaces records in a single transaction, timestamped
+// with the current time of the database.
+func (m RecordManager) Update(ctx context.Context, records []indexes.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	query := fmt.Sprintf(`INSERT INTO %q.%q (namespace, key, source_id, document_id) VALUES ($1, $2, $3, $4)
+ON CONFLICT (namespace, key) DO UPDATE SET source_id = EXCLUDED.source_id, document_id = EXCLUDED.document_id, updated_at = NOW()`,
+		m.schemaName, m.tableName)
+	b := &pgx.Batch{}
+	for _, record := range records {
+		b.Queue(query, m.namespace, record.Key, record.SourceID, record.DocumentID)
+	}
+	err := m.engine.WithTx(ctx, func(tx pgx.Tx) error {
+		return tx.SendBatch(ctx, b).Close()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update records: %w", err)
+	}
+	return nil
+}
+
+// List returns the records updated before before, of the given sources when
+// there are any.
+func (m RecordManager) List(ctx context.Context, before time.Time, sourceIDs []string) ([]indexes.Record, error) {
+	query := fmt.Sprintf(`SELECT key, source_id, document_id, updated_at FROM %q.%q WHERE namespace = $1 AND updated_at < $2`,
+		m.schemaName, m.tableName)
+	args := []any{m.namespace, before}
+	if len(sourceIDs) > 0 {
+		query += " AND source_id = ANY($3)"
+		args = append(args, sourceIDs)
+	}
+	records, err := m.queryRecords(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list records: %w", err)
+	}
+	return records, nil
+}
+
+// Delete deletes the records of keys.
+func (m RecordManager) Delete(ctx context.Context, keys []string) error {
+	query := fmt.Sprintf(`DELETE FROM %q.%q WHERE namespace = $1 AND key = ANY($2)`, m.schemaName, m.tableName)
+	if _, err := m.engine.Pool.Exec(ctx, query, m.namespace, keys); err != nil {
+		return fmt.Errorf("failed to delete records: %w", err)
+	}
+	return nil
+}
+
+// queryRecords runs query, which selects the columns of a record, on the
+// primary instance, as the records must be up to date.
+func (m RecordManager) queryRecords(ctx context.Context, query string, args ...any) ([]indexes.Record, error) {
+	rows, err := m.engine.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		records []indexes.Record
+		record  indexes.Record
+	)
+	_, err = pgx.ForEachRow(rows, []any{&record.Key, &record.SourceID, &record.DocumentID, &record.UpdatedAt}, func() error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,36 @@
+package alloydb
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+func TestNewRecordManagerValidation(t *testing.T) {
+	t.Parallel()
+	engine := alloydbutil.PostgresEngine{Pool: &pgxpool.Pool{}}
+	tests := []struct {
+		name      string
+		engine    alloydbutil.PostgresEngine
+		tableName string
+		namespace string
+	}{
+		{"missing engine", alloydbutil.PostgresEngine{}, "records", "items"},
+		{"missing table", engine, "", "items"},
+		{"missing namespace", engine, "records", ""},
+	}
+	for _, tt := range tests {
+		if _, err := NewRecordManager(tt.engine, tt.tableName, tt.namespace); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	m, err := NewRecordManager(engine, "records", "items", WithSchemaName("indexing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.schemaName != "indexing" {
+		t.Errorf("expected schema indexing, got %s", m.schemaName)
+	}
+}
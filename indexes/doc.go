@@ -0,0 +1,16 @@
+/*
+Package indexes keeps a vector store in sync with a source of documents.
+
+Index hashes the documents of the source and records the hashes, along with
+the ids of the documents in the vector store, in a RecordManager. Documents
+whose hash is already recorded are skipped instead of being embedded again,
+and the documents no longer in the source are deleted from the vector store
+depending on the cleanup mode:
+
+  - CleanupNone never deletes documents.
+  - CleanupIncremental deletes the previous versions of the documents of the
+    sources being indexed, identified by a metadata key, once all the
+    documents of each source are indexed.
+  - CleanupFull deletes all the documents not indexed by the run, after it.
+*/
+package indexes
@@ -0,0 +1,324 @@
+package indexes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+const defaultBatchSize = 100
+
+var (
+	// ErrNoSourceIDKey is returned by Index for incremental cleanups without
+	// source id metadata key.
+	ErrNoSourceIDKey = errors.New("incremental cleanup requires a source id key")
+	// ErrMissingSourceID is returned by Index for incremental cleanups when a
+	// document has no source id.
+	ErrMissingSourceID = errors.New("document has no source id")
+)
+
+// CleanupMode determines which documents Index deletes from the vector
+// store.
+type CleanupMode int
+
+const (
+	// CleanupNone never deletes documents.
+	CleanupNone CleanupMode = iota
+	// CleanupIncremental deletes the documents of the sources of the indexed
+	// documents which weren't indexed by the run, as soon as the last
+	// document of each source is indexed.
+	CleanupIncremental
+	// CleanupFull deletes all the documents which weren't indexed by the run,
+	// once all the documents are indexed.
+	CleanupFull
+)
+
+// VectorStore is a vector store whose documents can be deleted by id.
+type VectorStore interface {
+	vectorstores.VectorStore
+	DeleteDocuments(ctx context.Context, ids []string) (int, error)
+}
+
+// Record is the record of a document indexed in a vector store.
+type Record struct {
+	// Key is the hash of the content and metadata of the document.
+	Key string
+	// SourceID is the id of the source of the document, if any.
+	SourceID string
+	// DocumentID is the id of the document in the vector store.
+	DocumentID string
+	// UpdatedAt is the time the document was last indexed.
+	UpdatedAt time.Time
+}
+
+// RecordManager records the documents indexed in a vector store.
+type RecordManager interface {
+	// Now returns the current time of the record manager, with which it
+	// timestamps the records.
+	Now(ctx context.Context) (time.Time, error)
+	// Get returns the existing records of keys.
+	Get(ctx context.Context, keys []string) ([]Record, error)
+	// Update inserts or replaces records, timestamped with the current time.
+	Update(ctx context.Context, records []Record) error
+	// List returns the records updated before before, of the given sources
+	// when there are any.
+	List(ctx context.Context, before time.Time, sourceIDs []string) ([]Record, error)
+	// Delete deletes the records of keys.
+	Delete(ctx context.Context, keys []string) error
+}
+
+// Result counts the documents added, skipped and deleted by Index.
+type Result struct {
+	// Added is the number of documents added to the vector store.
+	Added int
+	// Skipped is the number of documents already in the vector store,
+	// including the duplicates of the source.
+	Skipped int
+	// Deleted is the number of documents deleted from the vector store.
+	Deleted int
+}
+
+// Option is a function for indexing documents with other than the default
+// values.
+type Option func(o *options)
+
+type options struct {
+	cleanup            CleanupMode
+	sourceIDKey        string
+	batchSize          int
+	vectorStoreOptions []vectorstores.Option
+}
+
+// WithCleanup sets the cleanup mode of the indexing. It defaults to
+// CleanupNone.
+func WithCleanup(mode CleanupMode) Option {
+	return func(o *options) {
+		o.cleanup = mode
+	}
+}
+
+// WithSourceIDKey sets the metadata key of the id of the source of the
+// documents, e.g. "source" for the file the documents were loaded from. It is
+// required by incremental cleanups.
+func WithSourceIDKey(key string) Option {
+	return func(o *options) {
+		o.sourceIDKey = key
+	}
+}
+
+// WithBatchSize sets the number of documents added to the vector store at
+// once. It defaults to 100.
+func WithBatchSize(batchSize int) Option {
+	return func(o *options) {
+		o.batchSize = batchSize
+	}
+}
+
+// WithVectorStoreOptions sets the options with which the documents are added
+// to the vector store.
+func WithVectorStoreOptions(opts ...vectorstores.Option) Option {
+	return func(o *options) {
+		o.vectorStoreOptions = opts
+	}
+}
+
+// Index adds the documents of source which aren't already in vector store to
+// it, deleting the outdated documents depending on the cleanup mode, and
+// keeps track of the documents in records. Documents are identified by the
+// hash of their content and metadata, so an edited document is added again
+// and its previous version is outdated.
+func Index(ctx context.Context,
+	source []schema.Document,
+	vectorStore VectorStore,
+	records RecordManager,
+	opts ...Option,
+) (Result, error) {
+	o := options{batchSize: defaultBatchSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.batchSize <= 0 {
+		o.batchSize = defaultBatchSize
+	}
+	if o.cleanup == CleanupIncremental && o.sourceIDKey == "" {
+		return Result{}, ErrNoSourceIDKey
+	}
+
+	keys := make([]string, len(source))
+	sourceIDs := make([]string, len(source))
+	// lastIndex is the index of the last document of each source.
+	lastIndex := make(map[string]int)
+	for i, doc := range source {
+		key, err := documentKey(doc)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to hash document %d: %w", i, err)
+		}
+		keys[i] = key
+		if o.sourceIDKey == "" {
+			continue
+		}
+		sourceID, ok := doc.Metadata[o.sourceIDKey].(string)
+		if !ok && o.cleanup == CleanupIncremental {
+			return Result{}, fmt.Errorf("%w: document %d", ErrMissingSourceID, i)
+		}
+		sourceIDs[i] = sourceID
+		lastIndex[sourceID] = i
+	}
+
+	start, err := records.Now(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to get the time of the record manager: %w", err)
+	}
+	var result Result
+	seen := make(map[string]bool, len(source))
+	for batchStart := 0; batchStart < len(source); batchStart += o.batchSize {
+		batchEnd := min(batchStart+o.batchSize, len(source))
+		var batch []int
+		for i := batchStart; i < batchEnd; i++ {
+			if seen[keys[i]] {
+				result.Skipped++
+				continue
+			}
+			seen[keys[i]] = true
+			batch = append(batch, i)
+		}
+		if err := indexBatch(ctx, source, keys, sourceIDs, batch, vectorStore, records, o, &result); err != nil {
+			return result, err
+		}
+		if o.cleanup != CleanupIncremental {
+			continue
+		}
+		// The sources are cleaned up once all their documents are indexed.
+		var indexed []string
+		for i := batchStart; i < batchEnd; i++ {
+			if lastIndex[sourceIDs[i]] == i {
+				indexed = append(indexed, sourceIDs[i])
+			}
+		}
+		if len(indexed) == 0 {
+			continue
+		}
+		if err := cleanup(ctx, vectorStore, records, start, indexed, &result); err != nil {
+			return result, err
+		}
+	}
+	if o.cleanup == CleanupFull {
+		if err := cleanup(ctx, vectorStore, records, start, nil, &result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// indexBatch adds the documents of source at the indexes of batch which
+// aren't recorded yet to vectorStore, and records all of them.
+func indexBatch(ctx context.Context,
+	source []schema.Document,
+	keys, sourceIDs []string,
+	batch []int,
+	vectorStore VectorStore,
+	records RecordManager,
+	o options,
+	result *Result,
+) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	batchKeys := make([]string, len(batch))
+	for j, i := range batch {
+		batchKeys[j] = keys[i]
+	}
+	existing, err := records.Get(ctx, batchKeys)
+	if err != nil {
+		return fmt.Errorf("failed to get records: %w", err)
+	}
+	documentIDs := make(map[string]string, len(existing))
+	for _, record := range existing {
+		documentIDs[record.Key] = record.DocumentID
+	}
+
+	var (
+		updated []Record
+		added   []schema.Document
+		addedAt []int
+	)
+	for _, i := range batch {
+		if id, ok := documentIDs[keys[i]]; ok {
+			updated = append(updated, Record{Key: keys[i], SourceID: sourceIDs[i], DocumentID: id})
+			continue
+		}
+		added = append(added, source[i])
+		addedAt = append(addedAt, i)
+	}
+	if len(added) > 0 {
+		ids, err := vectorStore.AddDocuments(ctx, added, o.vectorStoreOptions...)
+		if err != nil {
+			return fmt.Errorf("failed to add documents: %w", err)
+		}
+		if len(ids) != len(added) {
+			return fmt.Errorf("vector store returned %d ids for %d documents", len(ids), len(added))
+		}
+		for j, i := range addedAt {
+			updated = append(updated, Record{Key: keys[i], SourceID: sourceIDs[i], DocumentID: ids[j]})
+		}
+	}
+	if err := records.Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update records: %w", err)
+	}
+	result.Added += len(added)
+	result.Skipped += len(batch) - len(added)
+	return nil
+}
+
+// cleanup deletes the documents recorded before start, of sourceIDs when
+// there are any, from vectorStore and records.
+func cleanup(ctx context.Context,
+	vectorStore VectorStore,
+	records RecordManager,
+	start time.Time,
+	sourceIDs []string,
+	result *Result,
+) error {
+	outdated, err := records.List(ctx, start, sourceIDs)
+	if err != nil {
+		return fmt.Errorf("failed to list outdated records: %w", err)
+	}
+	if len(outdated) == 0 {
+		return nil
+	}
+	ids := make([]string, len(outdated))
+	keys := make([]string, len(outdated))
+	for i, record := range outdated {
+		ids[i], keys[i] = record.DocumentID, record.Key
+	}
+	// The records are only deleted once the documents are, so that a failed
+	// cleanup is retried by the next run.
+	if _, err := vectorStore.DeleteDocuments(ctx, ids); err != nil {
+		return fmt.Errorf("failed to delete outdated documents: %w", err)
+	}
+	if err := records.Delete(ctx, keys); err != nil {
+		return fmt.Errorf("failed to delete outdated records: %w", err)
+	}
+	result.Deleted += len(outdated)
+	return nil
+}
+
+// documentKey returns the hash of the content and metadata of doc.
+func documentKey(doc schema.Document) (string, error) {
+	data, err := json.Marshal(struct {
+		Content  string         `json:"content"`
+		Metadata map[string]any `json:"metadata"`
+	}{doc.PageContent, doc.Metadata})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,180 @@
+package indexes
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// fakeVectorStore keeps the documents in memory.
+type fakeVectorStore struct {
+	docs   map[string]schema.Document
+	nextID int
+	added  int
+}
+
+func newFakeVectorStore() *fakeVectorStore {
+	return &fakeVectorStore{docs: map[string]schema.Document{}}
+}
+
+func (s *fakeVectorStore) AddDocuments(_ context.Context, docs []schema.Document, _ ...vectorstores.Option) ([]string, error) {
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		s.nextID++
+		ids[i] = fmt.Sprint(s.nextID)
+		s.docs[ids[i]] = doc
+	}
+	s.added += len(docs)
+	return ids, nil
+}
+
+func (s *fakeVectorStore) SimilaritySearch(context.Context, string, int, ...vectorstores.Option) ([]schema.Document, error) {
+	return nil, nil
+}
+
+func (s *fakeVectorStore) DeleteDocuments(_ context.Context, ids []string) (int, error) {
+	deleted := 0
+	for _, id := range ids {
+		if _, ok := s.docs[id]; ok {
+			delete(s.docs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *fakeVectorStore) contents() []string {
+	var contents []string
+	for _, doc := range s.docs {
+		contents = append(contents, doc.PageContent)
+	}
+	slices.Sort(contents)
+	return contents
+}
+
+// fakeRecordManager keeps the records in memory, with a clock ticking at
+// every call.
+type fakeRecordManager struct {
+	records map[string]Record
+	now     time.Time
+}
+
+func newFakeRecordManager() *fakeRecordManager {
+	return &fakeRecordManager{records: map[string]Record{}, now: time.Unix(0, 0)}
+}
+
+func (m *fakeRecordManager) tick() time.Time {
+	m.now = m.now.Add(time.Second)
+	return m.now
+}
+
+func (m *fakeRecordManager) Now(context.Context) (time.Time, error) {
+	return m.tick(), nil
+}
+
+func (m *fakeRecordManager) Get(_ context.Context, keys []string) ([]Record, error) {
+	var records []Record
+	for _, key := range keys {
+		if record, ok := m.records[key]; ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (m *fakeRecordManager) Update(_ context.Context, records []Record) error {
+	now := m.tick()
+	for _, record := range records {
+		record.UpdatedAt = now
+		m.records[record.Key] = record
+	}
+	return nil
+}
+
+func (m *fakeRecordManager) List(_ context.Context, before time.Time, sourceIDs []string) ([]Record, error) {
+	var records []Record
+	for _, record := range m.records {
+		if record.UpdatedAt.Before(before) && (len(sourceIDs) == 0 || slices.Contains(sourceIDs, record.SourceID)) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (m *fakeRecordManager) Delete(_ context.Context, keys []string) error {
+	for _, key := range keys {
+		delete(m.records, key)
+	}
+	return nil
+}
+
+func doc(content, source string) schema.Document {
+	return schema.Document{PageContent: content, Metadata: map[string]any{"source": source}}
+}
+
+func TestIndexSkipsUnchangedDocuments(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	vs, records := newFakeVectorStore(), newFakeRecordManager()
+	source := []schema.Document{doc("a", "1"), doc("b", "1"), doc("a", "1")}
+
+	result, err := Index(ctx, source, vs, records)
+	require.NoError(t, err)
+	require.Equal(t, Result{Added: 2, Skipped: 1}, result)
+
+	result, err = Index(ctx, source, vs, records, WithBatchSize(1))
+	require.NoError(t, err)
+	require.Equal(t, Result{Skipped: 3}, result)
+	require.Equal(t, 2, vs.added)
+}
+
+func TestIndexIncrementalCleanup(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	vs, records := newFakeVectorStore(), newFakeRecordManager()
+	opts := []Option{WithCleanup(CleanupIncremental), WithSourceIDKey("source"), WithBatchSize(1)}
+
+	_, err := Index(ctx, []schema.Document{doc("a", "1"), doc("b", "1"), doc("c", "2")}, vs, records, opts...)
+	require.NoError(t, err)
+
+	// The unchanged document of the first source isn't deleted by the
+	// cleanup of the first batch, and the second source is kept.
+	result, err := Index(ctx, []schema.Document{doc("b", "1"), doc("a2", "1")}, vs, records, opts...)
+	require.NoError(t, err)
+	require.Equal(t, Result{Added: 1, Skipped: 1, Deleted: 1}, result)
+	require.Equal(t, []string{"a2", "b", "c"}, vs.contents())
+	require.Equal(t, 4, vs.added)
+	require.Len(t, records.records, 3)
+}
+
+func TestIndexFullCleanup(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	vs, records := newFakeVectorStore(), newFakeRecordManager()
+
+	_, err := Index(ctx, []schema.Document{doc("a", "1"), doc("b", "2")}, vs, records)
+	require.NoError(t, err)
+	result, err := Index(ctx, []schema.Document{doc("b", "2")}, vs, records, WithCleanup(CleanupFull))
+	require.NoError(t, err)
+	require.Equal(t, Result{Skipped: 1, Deleted: 1}, result)
+	require.Equal(t, []string{"b"}, vs.contents())
+}
+
+func TestIndexIncrementalCleanupErrors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	vs, records := newFakeVectorStore(), newFakeRecordManager()
+
+	_, err := Index(ctx, nil, vs, records, WithCleanup(CleanupIncremental))
+	require.ErrorIs(t, err, ErrNoSourceIDKey)
+	_, err = Index(ctx, []schema.Document{{PageContent: "a"}}, vs, records,
+		WithCleanup(CleanupIncremental), WithSourceIDKey("source"))
+	require.ErrorIs(t, err, ErrMissingSourceID)
+	require.Zero(t, vs.added)
+}
@@ -2,11 +2,13 @@ package anthropic
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
@@ -20,6 +22,7 @@ var (
 	ErrInvalidContentType       = errors.New("invalid content type")
 	ErrUnsupportedMessageType   = errors.New("unsupported message type")
 	ErrUnsupportedContentType   = errors.New("unsupported content type")
+	ErrThinkingToolChoice       = errors.New("extended thinking only supports the auto and none tool choices")
 )
 
 const (
@@ -84,6 +87,10 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(opts)
 	}
 
+	if err := opts.RateLimiter.Wait(ctx, messages, *opts); err != nil {
+		return nil, err
+	}
+
 	if o.client.UseLegacyTextCompletionsAPI {
 		return generateCompletionsContent(ctx, o, messages, opts)
 	}
@@ -134,8 +141,7 @@ func generateMessagesContent(ctx context.Context, o *LLM, messages []llms.Messag
 		return nil, fmt.Errorf("anthropic: failed to process messages: %w", err)
 	}
 
-	tools := toolsToTools(opts.Tools)
-	result, err := o.client.CreateMessage(ctx, &anthropicclient.MessageRequest{
+	req := &anthropicclient.MessageRequest{
 		Model:         opts.Model,
 		Messages:      chatMessages,
 		System:        systemPrompt,
@@ -143,9 +149,24 @@ func generateMessagesContent(ctx context.Context, o *LLM, messages []llms.Messag
 		StopWords:     opts.StopWords,
 		Temperature:   opts.Temperature,
 		TopP:          opts.TopP,
-		Tools:         tools,
+		Tools:         toolsToTools(opts.Tools),
 		StreamingFunc: opts.StreamingFunc,
-	})
+
+		StreamingToolCallFunc:  opts.StreamingToolCallFunc,
+		StreamingReasoningFunc: opts.StreamingReasoningFunc,
+	}
+	if opts.ThinkingBudget > 0 {
+		req.Thinking = &anthropicclient.Thinking{Type: "enabled", BudgetTokens: opts.ThinkingBudget}
+		// Thinking only supports the default temperature.
+		if req.Temperature == 0 {
+			req.Temperature = 1
+		}
+	}
+	if err := setToolChoice(req, opts); err != nil {
+		return nil, fmt.Errorf("anthropic: %w", err)
+	}
+	setResponseSchema(req, opts.ResponseSchema)
+	result, err := o.client.CreateMessage(ctx, req)
 	if err != nil {
 		if o.CallbacksHandler != nil {
 			o.CallbacksHandler.HandleLLMError(ctx, err)
@@ -156,56 +177,89 @@ func generateMessagesContent(ctx context.Context, o *LLM, messages []llms.Messag
 		return nil, ErrEmptyResponse
 	}
 
-	choices := make([]*llms.ContentChoice, len(result.Content))
-	for i, content := range result.Content {
+	choices, err := convertContents(result, opts.ResponseSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	promptTokens := result.Usage.InputTokens + result.Usage.CacheCreationInputTokens + result.Usage.CacheReadInputTokens
+	resp := &llms.ContentResponse{
+		Choices: choices,
+		Usage:   llms.NewUsage(promptTokens, result.Usage.OutputTokens),
+	}
+	resp.Usage.CachedTokens = result.Usage.CacheReadInputTokens
+	return resp, nil
+}
+
+// convertContents returns the choice of the content blocks of result: the
+// text of its text blocks, a tool call for each of its tool use blocks,
+// which Claude uses for parallel tool calls, and the reasoning of its
+// thinking blocks.
+func convertContents(result *anthropicclient.MessageResponsePayload, schema *llms.ResponseSchema) ([]*llms.ContentChoice, error) {
+	if len(result.Content) == 0 {
+		return nil, nil
+	}
+	choice := &llms.ContentChoice{
+		StopReason: result.StopReason,
+		GenerationInfo: map[string]any{
+			"InputTokens":  result.Usage.InputTokens,
+			"OutputTokens": result.Usage.OutputTokens,
+		},
+	}
+	for _, content := range result.Content {
 		switch content.GetType() {
 		case "text":
-			if textContent, ok := content.(*anthropicclient.TextContent); ok {
-				choices[i] = &llms.ContentChoice{
-					Content:    textContent.Text,
-					StopReason: result.StopReason,
-					GenerationInfo: map[string]any{
-						"InputTokens":  result.Usage.InputTokens,
-						"OutputTokens": result.Usage.OutputTokens,
-					},
-				}
-			} else {
+			textContent, ok := content.(*anthropicclient.TextContent)
+			if !ok {
 				return nil, fmt.Errorf("anthropic: %w for text message", ErrInvalidContentType)
 			}
+			choice.Content += textContent.Text
 		case "tool_use":
-			if toolUseContent, ok := content.(*anthropicclient.ToolUseContent); ok {
-				argumentsJSON, err := json.Marshal(toolUseContent.Input)
-				if err != nil {
-					return nil, fmt.Errorf("anthropic: failed to marshal tool use arguments: %w", err)
-				}
-				choices[i] = &llms.ContentChoice{
-					ToolCalls: []llms.ToolCall{
-						{
-							ID: toolUseContent.ID,
-							FunctionCall: &llms.FunctionCall{
-								Name:      toolUseContent.Name,
-								Arguments: string(argumentsJSON),
-							},
-						},
-					},
-					StopReason: result.StopReason,
-					GenerationInfo: map[string]any{
-						"InputTokens":  result.Usage.InputTokens,
-						"OutputTokens": result.Usage.OutputTokens,
-					},
-				}
-			} else {
+			toolUseContent, ok := content.(*anthropicclient.ToolUseContent)
+			if !ok {
 				return nil, fmt.Errorf("anthropic: %w for tool use message", ErrInvalidContentType)
 			}
+			argumentsJSON, err := json.Marshal(toolUseContent.Input)
+			if err != nil {
+				return nil, fmt.Errorf("anthropic: failed to marshal tool use arguments: %w", err)
+			}
+			if schema != nil && toolUseContent.Name == schema.Name {
+				// The structured response is the input of the forced
+				// tool call.
+				choice.Content = string(argumentsJSON)
+				continue
+			}
+			choice.ToolCalls = append(choice.ToolCalls, llms.ToolCall{
+				ID:   toolUseContent.ID,
+				Type: "function",
+				FunctionCall: &llms.FunctionCall{
+					Name:      toolUseContent.Name,
+					Arguments: string(argumentsJSON),
+				},
+			})
+		case "thinking":
+			thinkingContent, ok := content.(*anthropicclient.ThinkingContent)
+			if !ok {
+				return nil, fmt.Errorf("anthropic: %w for thinking message", ErrInvalidContentType)
+			}
+			choice.Reasoning = append(choice.Reasoning, llms.ReasoningContent{
+				Text:      thinkingContent.Thinking,
+				Signature: thinkingContent.Signature,
+			})
+		case "redacted_thinking":
+			redactedContent, ok := content.(*anthropicclient.RedactedThinkingContent)
+			if !ok {
+				return nil, fmt.Errorf("anthropic: %w for redacted thinking message", ErrInvalidContentType)
+			}
+			choice.Reasoning = append(choice.Reasoning, llms.ReasoningContent{RedactedData: redactedContent.Data})
 		default:
 			return nil, fmt.Errorf("anthropic: %w: %v", ErrUnsupportedContentType, content.GetType())
 		}
 	}
-
-	resp := &llms.ContentResponse{
-		Choices: choices,
+	if len(choice.ToolCalls) > 0 {
+		choice.FuncCall = choice.ToolCalls[0].FunctionCall
 	}
-	return resp, nil
+	return []*llms.ContentChoice{choice}, nil
 }
 
 func toolsToTools(tools []llms.Tool) []anthropicclient.Tool {
@@ -220,10 +274,75 @@ func toolsToTools(tools []llms.Tool) []anthropicclient.Tool {
 	return toolReq
 }
 
+// setToolChoice sets the tool choice of the request from the tool choice and
+// parallel tool calls of the call options.
+func setToolChoice(req *anthropicclient.MessageRequest, opts *llms.CallOptions) error {
+	mode, function, err := llms.ResolveToolChoice(opts.ToolChoice)
+	if err != nil {
+		return err
+	}
+	if req.Thinking != nil && (mode == llms.ToolChoiceRequired || mode == llms.ToolChoiceFunction) {
+		return ErrThinkingToolChoice
+	}
+	disableParallelToolUse := opts.ParallelToolCalls != nil && !*opts.ParallelToolCalls
+	if len(req.Tools) == 0 || (mode == "" && !disableParallelToolUse) {
+		return nil
+	}
+	req.ToolChoice = &anthropicclient.ToolChoice{
+		Type:                   "auto",
+		DisableParallelToolUse: disableParallelToolUse,
+	}
+	switch mode {
+	case "", llms.ToolChoiceAuto:
+	case llms.ToolChoiceNone:
+		req.ToolChoice.Type = "none"
+	case llms.ToolChoiceRequired:
+		req.ToolChoice.Type = "any"
+	case llms.ToolChoiceFunction:
+		req.ToolChoice.Type = "tool"
+		req.ToolChoice.Name = function
+	}
+	return nil
+}
+
+// setResponseSchema constrains the response to the schema by forcing a call
+// to a tool taking the structured response as input, Anthropic having no
+// structured output mode. Tool calls can't be forced with extended thinking,
+// so the system prompt asks for a response conforming to the schema instead.
+func setResponseSchema(req *anthropicclient.MessageRequest, schema *llms.ResponseSchema) {
+	if schema == nil {
+		return
+	}
+	if req.Thinking != nil {
+		schemaJSON, _ := json.MarshalIndent(schema.Schema, "", "  ")
+		instructions := "Respond only with a JSON value, without any other text, conforming to this JSON schema:\n```json\n" +
+			string(schemaJSON) + "\n```"
+		if schema.Description != "" {
+			instructions = schema.Description + "\n" + instructions
+		}
+		if req.System != "" {
+			instructions = req.System + "\n\n" + instructions
+		}
+		req.System = instructions
+		return
+	}
+	description := schema.Description
+	if description == "" {
+		description = "Respond with the structured response as input."
+	}
+	req.Tools = append(req.Tools, anthropicclient.Tool{
+		Name:        schema.Name,
+		Description: description,
+		InputSchema: schema.Schema,
+	})
+	req.ToolChoice = &anthropicclient.ToolChoice{Type: "tool", Name: schema.Name}
+}
+
 func processMessages(messages []llms.MessageContent) ([]anthropicclient.ChatMessage, string, error) {
 	chatMessages := make([]anthropicclient.ChatMessage, 0, len(messages))
 	systemPrompt := ""
-	for _, msg := range messages {
+	for i, msg := range messages {
+		lastWasTool := i > 0 && messages[i-1].Role == llms.ChatMessageTypeTool
 		switch msg.Role {
 		case llms.ChatMessageTypeSystem:
 			content, err := handleSystemMessage(msg)
@@ -248,6 +367,13 @@ func processMessages(messages []llms.MessageContent) ([]anthropicclient.ChatMess
 			if err != nil {
 				return nil, "", fmt.Errorf("anthropic: failed to handle tool message: %w", err)
 			}
+			// The results of parallel tool calls must be in a single message.
+			if n := len(chatMessages); n > 0 && lastWasTool {
+				previous, _ := chatMessages[n-1].Content.([]anthropicclient.Content)
+				current, _ := chatMessage.Content.([]anthropicclient.Content)
+				chatMessages[n-1].Content = append(previous, current...)
+				continue
+			}
 			chatMessages = append(chatMessages, chatMessage)
 		case llms.ChatMessageTypeGeneric, llms.ChatMessageTypeFunction:
 			return nil, "", fmt.Errorf("anthropic: %w: %v", ErrUnsupportedMessageType, msg.Role)
@@ -266,44 +392,175 @@ func handleSystemMessage(msg llms.MessageContent) (string, error) {
 }
 
 func handleHumanMessage(msg llms.MessageContent) (anthropicclient.ChatMessage, error) {
-	if textContent, ok := msg.Parts[0].(llms.TextContent); ok {
-		return anthropicclient.ChatMessage{
-			Role:    RoleUser,
-			Content: textContent.Text,
-		}, nil
+	if len(msg.Parts) == 1 {
+		if textContent, ok := msg.Parts[0].(llms.TextContent); ok {
+			return anthropicclient.ChatMessage{
+				Role:    RoleUser,
+				Content: textContent.Text,
+			}, nil
+		}
+	}
+	content := make([]anthropicclient.Content, 0, len(msg.Parts))
+	for _, part := range msg.Parts {
+		c, err := humanMessageContent(part)
+		if err != nil {
+			return anthropicclient.ChatMessage{}, err
+		}
+		content = append(content, c)
+	}
+	if len(content) == 0 {
+		return anthropicclient.ChatMessage{}, fmt.Errorf("anthropic: %w for human message", ErrInvalidContentType)
 	}
-	return anthropicclient.ChatMessage{}, fmt.Errorf("anthropic: %w for human message", ErrInvalidContentType)
+	return anthropicclient.ChatMessage{
+		Role:    RoleUser,
+		Content: content,
+	}, nil
 }
 
-func handleAIMessage(msg llms.MessageContent) (anthropicclient.ChatMessage, error) {
-	if toolCall, ok := msg.Parts[0].(llms.ToolCall); ok {
-		var inputStruct map[string]interface{}
-		err := json.Unmarshal([]byte(toolCall.FunctionCall.Arguments), &inputStruct)
-		if err != nil {
-			return anthropicclient.ChatMessage{}, fmt.Errorf("anthropic: failed to unmarshal tool call arguments: %w", err)
+// The largest inline image and document the API accepts, in bytes.
+const (
+	maxImageSize    = 5 << 20
+	maxDocumentSize = 32 << 20
+)
+
+func humanMessageContent(part llms.ContentPart) (anthropicclient.Content, error) {
+	switch p := part.(type) {
+	case llms.TextContent:
+		return &anthropicclient.TextContent{Type: "text", Text: p.Text}, nil
+	case llms.BinaryContent:
+		return binaryContent(p)
+	case llms.ImageURLContent:
+		if strings.HasPrefix(p.URL, "data:") {
+			data, err := llms.ParseDataURL(p.URL)
+			if err != nil {
+				return nil, fmt.Errorf("anthropic: %w", err)
+			}
+			return binaryContent(data)
 		}
-		toolUse := anthropicclient.ToolUseContent{
-			Type:  "tool_use",
-			ID:    toolCall.ID,
-			Name:  toolCall.FunctionCall.Name,
-			Input: inputStruct,
+		return anthropicclient.ImageContent{
+			Type:   "image",
+			Source: anthropicclient.ContentSource{Type: "url", URL: p.URL},
+		}, nil
+	case llms.FileURLContent:
+		switch {
+		case strings.HasPrefix(p.MIMEType, "image/"):
+			return anthropicclient.ImageContent{
+				Type:   "image",
+				Source: anthropicclient.ContentSource{Type: "url", URL: p.URL},
+			}, nil
+		case p.MIMEType == "application/pdf":
+			return anthropicclient.DocumentContent{
+				Type:   "document",
+				Source: anthropicclient.ContentSource{Type: "url", URL: p.URL},
+			}, nil
 		}
+		return nil, fmt.Errorf("anthropic: %w: file URL of type %q", ErrUnsupportedContentType, p.MIMEType)
+	default:
+		return nil, fmt.Errorf("anthropic: %w for human message: %T", ErrInvalidContentType, part)
+	}
+}
 
-		return anthropicclient.ChatMessage{
-			Role:    RoleAssistant,
-			Content: []anthropicclient.Content{toolUse},
+// binaryContent returns images as image blocks, and PDFs and plain text as
+// document blocks.
+func binaryContent(part llms.BinaryContent) (anthropicclient.Content, error) {
+	if part.MIMEType == "" {
+		part.MIMEType = llms.DetectMIMEType(part.Data)
+	}
+	switch {
+	case strings.HasPrefix(part.MIMEType, "image/"):
+		if err := llms.CheckContentSize(part, maxImageSize); err != nil {
+			return nil, fmt.Errorf("anthropic: %w", err)
+		}
+		return anthropicclient.ImageContent{
+			Type: "image",
+			Source: anthropicclient.ContentSource{
+				Type:      "base64",
+				MediaType: part.MIMEType,
+				Data:      base64.StdEncoding.EncodeToString(part.Data),
+			},
+		}, nil
+	case part.MIMEType == "application/pdf":
+		if err := llms.CheckContentSize(part, maxDocumentSize); err != nil {
+			return nil, fmt.Errorf("anthropic: %w", err)
+		}
+		return anthropicclient.DocumentContent{
+			Type: "document",
+			Source: anthropicclient.ContentSource{
+				Type:      "base64",
+				MediaType: part.MIMEType,
+				Data:      base64.StdEncoding.EncodeToString(part.Data),
+			},
 		}, nil
+	case strings.HasPrefix(part.MIMEType, "text/plain"):
+		if err := llms.CheckContentSize(part, maxDocumentSize); err != nil {
+			return nil, fmt.Errorf("anthropic: %w", err)
+		}
+		return anthropicclient.DocumentContent{
+			Type: "document",
+			Source: anthropicclient.ContentSource{
+				Type:      "text",
+				MediaType: "text/plain",
+				Data:      string(part.Data),
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("anthropic: %w: %q", ErrUnsupportedContentType, part.MIMEType)
 	}
-	if textContent, ok := msg.Parts[0].(llms.TextContent); ok {
-		return anthropicclient.ChatMessage{
-			Role: RoleAssistant,
-			Content: []anthropicclient.Content{&anthropicclient.TextContent{
+}
+
+func handleAIMessage(msg llms.MessageContent) (anthropicclient.ChatMessage, error) {
+	content := make([]anthropicclient.Content, 0, len(msg.Parts))
+	// The thinking blocks must precede the other blocks.
+	for _, part := range msg.Parts {
+		p, ok := part.(llms.ReasoningContent)
+		if !ok {
+			continue
+		}
+		if p.RedactedData != "" {
+			content = append(content, anthropicclient.RedactedThinkingContent{
+				Type: "redacted_thinking",
+				Data: p.RedactedData,
+			})
+			continue
+		}
+		content = append(content, anthropicclient.ThinkingContent{
+			Type:      "thinking",
+			Thinking:  p.Text,
+			Signature: p.Signature,
+		})
+	}
+	for _, part := range msg.Parts {
+		switch p := part.(type) {
+		case llms.ReasoningContent:
+			// Already added.
+		case llms.TextContent:
+			content = append(content, &anthropicclient.TextContent{
 				Type: "text",
-				Text: textContent.Text,
-			}},
-		}, nil
+				Text: p.Text,
+			})
+		case llms.ToolCall:
+			var inputStruct map[string]interface{}
+			err := json.Unmarshal([]byte(p.FunctionCall.Arguments), &inputStruct)
+			if err != nil {
+				return anthropicclient.ChatMessage{}, fmt.Errorf("anthropic: failed to unmarshal tool call arguments: %w", err)
+			}
+			content = append(content, anthropicclient.ToolUseContent{
+				Type:  "tool_use",
+				ID:    p.ID,
+				Name:  p.FunctionCall.Name,
+				Input: inputStruct,
+			})
+		default:
+			return anthropicclient.ChatMessage{}, fmt.Errorf("anthropic: %w for AI message", ErrInvalidContentType)
+		}
+	}
+	if len(content) == 0 {
+		return anthropicclient.ChatMessage{}, fmt.Errorf("anthropic: %w for AI message", ErrInvalidContentType)
 	}
-	return anthropicclient.ChatMessage{}, fmt.Errorf("anthropic: %w for AI message", ErrInvalidContentType)
+	return anthropicclient.ChatMessage{
+		Role:    RoleAssistant,
+		Content: content,
+	}, nil
 }
 
 type ToolResult struct {
@@ -313,17 +570,32 @@ type ToolResult struct {
 }
 
 func handleToolMessage(msg llms.MessageContent) (anthropicclient.ChatMessage, error) {
-	if toolCallResponse, ok := msg.Parts[0].(llms.ToolCallResponse); ok {
-		toolContent := anthropicclient.ToolResultContent{
+	content := make([]anthropicclient.Content, 0, len(msg.Parts))
+	for _, part := range msg.Parts {
+		toolCallResponse, ok := part.(llms.ToolCallResponse)
+		if !ok {
+			return anthropicclient.ChatMessage{}, fmt.Errorf("anthropic: %w for tool message", ErrInvalidContentType)
+		}
+		content = append(content, anthropicclient.ToolResultContent{
 			Type:      "tool_result",
 			ToolUseID: toolCallResponse.ToolCallID,
 			Content:   toolCallResponse.Content,
-		}
+		})
+	}
+	if len(content) == 0 {
+		return anthropicclient.ChatMessage{}, fmt.Errorf("anthropic: %w for tool message", ErrInvalidContentType)
+	}
+	return anthropicclient.ChatMessage{
+		Role:    RoleUser,
+		Content: content,
+	}, nil
+}
 
-		return anthropicclient.ChatMessage{
-			Role:    RoleUser,
-			Content: []anthropicclient.Content{toolContent},
-		}, nil
+// Capabilities implements the llms.CapabilityReporter interface, reporting
+// the capabilities of the model.
+func (o *LLM) Capabilities() llms.ModelCapabilities {
+	if capabilities, ok := llms.LookupModelCapabilities(o.client.Model); ok {
+		return capabilities
 	}
-	return anthropicclient.ChatMessage{}, fmt.Errorf("anthropic: %w for tool message", ErrInvalidContentType)
+	return llms.ModelCapabilities{Streaming: true}
 }
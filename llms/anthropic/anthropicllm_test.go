@@ -0,0 +1,169 @@
+package anthropic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/anthropic/internal/anthropicclient"
+)
+
+func TestProcessMessagesParallelToolCalls(t *testing.T) {
+	t.Parallel()
+	messages := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Paris and Rome?"),
+		{
+			Role: llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{
+				llms.TextContent{Text: "Checking both."},
+				llms.ToolCall{ID: "t1", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}},
+				llms.ToolCall{ID: "t2", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Rome"}`}},
+			},
+		},
+		{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "t1", Content: "sunny"}}},
+		{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "t2", Content: "rainy"}}},
+	}
+
+	chatMessages, _, err := processMessages(messages)
+	require.NoError(t, err)
+	require.Len(t, chatMessages, 3)
+
+	assistant, ok := chatMessages[1].Content.([]anthropicclient.Content)
+	require.True(t, ok)
+	require.Len(t, assistant, 3)
+	assert.Equal(t, "t2", assistant[2].(anthropicclient.ToolUseContent).ID)
+
+	// The results of the parallel calls are merged in a single message.
+	results, ok := chatMessages[2].Content.([]anthropicclient.Content)
+	require.True(t, ok)
+	assert.Equal(t, RoleUser, chatMessages[2].Role)
+	assert.Equal(t, []anthropicclient.Content{
+		anthropicclient.ToolResultContent{Type: "tool_result", ToolUseID: "t1", Content: "sunny"},
+		anthropicclient.ToolResultContent{Type: "tool_result", ToolUseID: "t2", Content: "rainy"},
+	}, results)
+}
+
+func TestProcessMessagesMultimodal(t *testing.T) {
+	t.Parallel()
+	messages := []llms.MessageContent{{
+		Role: llms.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{
+			llms.TextPart("Compare the chart with the report."),
+			llms.BinaryPart("image/png", []byte("png")),
+			llms.BinaryPart("application/pdf", []byte("pdf")),
+			llms.ImageURLPart("https://example.com/chart.png"),
+			llms.FileURLPart("application/pdf", "https://example.com/report.pdf"),
+		},
+	}}
+
+	chatMessages, _, err := processMessages(messages)
+	require.NoError(t, err)
+	require.Len(t, chatMessages, 1)
+	assert.Equal(t, []anthropicclient.Content{
+		&anthropicclient.TextContent{Type: "text", Text: "Compare the chart with the report."},
+		anthropicclient.ImageContent{Type: "image", Source: anthropicclient.ContentSource{Type: "base64", MediaType: "image/png", Data: "cG5n"}},
+		anthropicclient.DocumentContent{Type: "document", Source: anthropicclient.ContentSource{Type: "base64", MediaType: "application/pdf", Data: "cGRm"}},
+		anthropicclient.ImageContent{Type: "image", Source: anthropicclient.ContentSource{Type: "url", URL: "https://example.com/chart.png"}},
+		anthropicclient.DocumentContent{Type: "document", Source: anthropicclient.ContentSource{Type: "url", URL: "https://example.com/report.pdf"}},
+	}, chatMessages[0].Content)
+
+	_, _, err = processMessages([]llms.MessageContent{{
+		Role:  llms.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{llms.BinaryPart("image/jpeg", make([]byte, maxImageSize+1))},
+	}})
+	require.ErrorIs(t, err, llms.ErrContentTooLarge)
+
+	_, _, err = processMessages([]llms.MessageContent{{
+		Role:  llms.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{llms.BinaryPart("audio/wav", []byte("wav"))},
+	}})
+	require.ErrorIs(t, err, ErrUnsupportedContentType)
+}
+
+func TestConvertContentsParallelToolCalls(t *testing.T) {
+	t.Parallel()
+	result := &anthropicclient.MessageResponsePayload{
+		StopReason: "tool_use",
+		Content: []anthropicclient.Content{
+			&anthropicclient.TextContent{Type: "text", Text: "Checking both."},
+			&anthropicclient.ToolUseContent{Type: "tool_use", ID: "t1", Name: "weather", Input: map[string]any{"city": "Paris"}},
+			&anthropicclient.ToolUseContent{Type: "tool_use", ID: "t2", Name: "weather", Input: map[string]any{"city": "Rome"}},
+		},
+	}
+
+	choices, err := convertContents(result, nil)
+	require.NoError(t, err)
+	require.Len(t, choices, 1)
+	assert.Equal(t, "Checking both.", choices[0].Content)
+	require.Len(t, choices[0].ToolCalls, 2)
+	assert.Equal(t, "t2", choices[0].ToolCalls[1].ID)
+	assert.JSONEq(t, `{"city":"Rome"}`, choices[0].ToolCalls[1].FunctionCall.Arguments)
+	assert.Equal(t, choices[0].ToolCalls[0].FunctionCall, choices[0].FuncCall)
+}
+
+func TestConvertContentsThinking(t *testing.T) {
+	t.Parallel()
+	result := &anthropicclient.MessageResponsePayload{
+		StopReason: "end_turn",
+		Content: []anthropicclient.Content{
+			&anthropicclient.ThinkingContent{Type: "thinking", Thinking: "The user greets me.", Signature: "c2ln"},
+			&anthropicclient.RedactedThinkingContent{Type: "redacted_thinking", Data: "ZW5j"},
+			&anthropicclient.TextContent{Type: "text", Text: "Hello!"},
+		},
+	}
+
+	choices, err := convertContents(result, nil)
+	require.NoError(t, err)
+	require.Len(t, choices, 1)
+	assert.Equal(t, "Hello!", choices[0].Content)
+	reasoning := []llms.ReasoningContent{
+		{Text: "The user greets me.", Signature: "c2ln"},
+		{RedactedData: "ZW5j"},
+	}
+	assert.Equal(t, reasoning, choices[0].Reasoning)
+
+	// The thinking is passed back first in the AI message.
+	parts := []llms.ContentPart{llms.TextContent{Text: "Hello!"}}
+	for _, r := range reasoning {
+		parts = append(parts, r)
+	}
+	chatMessages, _, err := processMessages([]llms.MessageContent{{Role: llms.ChatMessageTypeAI, Parts: parts}})
+	require.NoError(t, err)
+	assert.Equal(t, []anthropicclient.Content{
+		anthropicclient.ThinkingContent{Type: "thinking", Thinking: "The user greets me.", Signature: "c2ln"},
+		anthropicclient.RedactedThinkingContent{Type: "redacted_thinking", Data: "ZW5j"},
+		&anthropicclient.TextContent{Type: "text", Text: "Hello!"},
+	}, chatMessages[0].Content)
+}
+
+func TestThinkingToolChoice(t *testing.T) {
+	t.Parallel()
+	thinking := func() *anthropicclient.MessageRequest {
+		return &anthropicclient.MessageRequest{
+			System:   "Be brief.",
+			Tools:    []anthropicclient.Tool{{Name: "weather"}},
+			Thinking: &anthropicclient.Thinking{Type: "enabled", BudgetTokens: 1024},
+		}
+	}
+	for _, choice := range []any{llms.ToolChoiceRequired, llms.FunctionToolChoice("weather")} {
+		err := setToolChoice(thinking(), &llms.CallOptions{ToolChoice: choice})
+		require.ErrorIs(t, err, ErrThinkingToolChoice)
+	}
+	req := thinking()
+	require.NoError(t, setToolChoice(req, &llms.CallOptions{ToolChoice: llms.ToolChoiceAuto}))
+	assert.Equal(t, "auto", req.ToolChoice.Type)
+
+	// The response schema is asked for in the system prompt.
+	schema := &llms.ResponseSchema{Name: "city", Schema: map[string]any{"type": "object"}}
+	req = thinking()
+	setResponseSchema(req, schema)
+	assert.Len(t, req.Tools, 1)
+	assert.Nil(t, req.ToolChoice)
+	assert.True(t, strings.HasPrefix(req.System, "Be brief.\n\nRespond only with a JSON value"), req.System)
+
+	req = &anthropicclient.MessageRequest{}
+	setResponseSchema(req, schema)
+	assert.Equal(t, &anthropicclient.ToolChoice{Type: "tool", Name: "city"}, req.ToolChoice)
+}
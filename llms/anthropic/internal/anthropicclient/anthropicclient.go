@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+
+	"github.com/tmc/langchaingo/llms"
 )
 
 const (
@@ -131,10 +133,14 @@ type MessageRequest struct {
 	MaxTokens   int           `json:"max_tokens,omitempty"`
 	TopP        float64       `json:"top_p,omitempty"`
 	Tools       []Tool        `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice   `json:"tool_choice,omitempty"`
 	StopWords   []string      `json:"stop_sequences,omitempty"`
 	Stream      bool          `json:"stream,omitempty"`
+	Thinking    *Thinking     `json:"thinking,omitempty"`
 
-	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
+	StreamingFunc          func(ctx context.Context, chunk []byte) error             `json:"-"`
+	StreamingToolCallFunc  func(ctx context.Context, delta llms.ToolCallDelta) error `json:"-"`
+	StreamingReasoningFunc func(ctx context.Context, chunk []byte) error             `json:"-"`
 }
 
 // CreateMessage creates message for the messages api.
@@ -148,8 +154,13 @@ func (c *Client) CreateMessage(ctx context.Context, r *MessageRequest) (*Message
 		StopWords:     r.StopWords,
 		TopP:          r.TopP,
 		Tools:         r.Tools,
+		ToolChoice:    r.ToolChoice,
 		Stream:        r.Stream,
+		Thinking:      r.Thinking,
 		StreamingFunc: r.StreamingFunc,
+
+		StreamingToolCallFunc:  r.StreamingToolCallFunc,
+		StreamingReasoningFunc: r.StreamingReasoningFunc,
 	})
 	if err != nil {
 		return nil, err
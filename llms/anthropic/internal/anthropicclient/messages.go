@@ -11,6 +11,8 @@ import (
 	"log"
 	"net/http"
 	"strings"
+
+	"github.com/tmc/langchaingo/llms"
 )
 
 var (
@@ -23,6 +25,9 @@ var (
 	ErrInvalidDeltaTextField   = fmt.Errorf("invalid delta text field type")
 	ErrContentIndexOutOfRange  = fmt.Errorf("content index out of range")
 	ErrFailedCastToTextContent = fmt.Errorf("failed to cast content to TextContent")
+	ErrFailedCastToToolUse     = fmt.Errorf("failed to cast content to ToolUseContent")
+	ErrFailedCastToThinking    = fmt.Errorf("failed to cast content to ThinkingContent")
+	ErrInvalidDeltaJSONField   = fmt.Errorf("invalid delta partial json field type")
 	ErrInvalidFieldType        = fmt.Errorf("invalid field type")
 )
 
@@ -40,9 +45,27 @@ type messagePayload struct {
 	Stream      bool          `json:"stream,omitempty"`
 	Temperature float64       `json:"temperature"`
 	Tools       []Tool        `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice   `json:"tool_choice,omitempty"`
 	TopP        float64       `json:"top_p,omitempty"`
+	Thinking    *Thinking     `json:"thinking,omitempty"`
+
+	StreamingFunc          func(ctx context.Context, chunk []byte) error             `json:"-"`
+	StreamingToolCallFunc  func(ctx context.Context, delta llms.ToolCallDelta) error `json:"-"`
+	StreamingReasoningFunc func(ctx context.Context, chunk []byte) error             `json:"-"`
+}
 
-	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
+// streaming reports whether the response to the payload is streamed.
+func (p *messagePayload) streaming() bool {
+	return p.StreamingFunc != nil || p.StreamingToolCallFunc != nil || p.StreamingReasoningFunc != nil
+}
+
+// Thinking enables extended thinking.
+type Thinking struct {
+	// Type is "enabled".
+	Type string `json:"type"`
+	// BudgetTokens is the maximum number of tokens to think with, at least
+	// 1024 and less than the max tokens of the request.
+	BudgetTokens int `json:"budget_tokens"`
 }
 
 // Tool used for the request message payload.
@@ -52,6 +75,16 @@ type Tool struct {
 	InputSchema any    `json:"input_schema,omitempty"`
 }
 
+// ToolChoice is how the model uses the tools of the request.
+type ToolChoice struct {
+	// Type is one of "auto", "any", "tool" or "none".
+	Type string `json:"type"`
+	// Name is the name of the tool to use, for the "tool" type.
+	Name string `json:"name,omitempty"`
+	// DisableParallelToolUse makes the model use at most one tool.
+	DisableParallelToolUse bool `json:"disable_parallel_tool_use,omitempty"`
+}
+
 // Content can be TextContent or ToolUseContent depending on the type.
 type Content interface {
 	GetType() string
@@ -71,6 +104,9 @@ type ToolUseContent struct {
 	ID    string                 `json:"id"`
 	Name  string                 `json:"name"`
 	Input map[string]interface{} `json:"input"`
+
+	// partialInput is the JSON input streamed so far.
+	partialInput string
 }
 
 func (tuc ToolUseContent) GetType() string {
@@ -87,6 +123,56 @@ func (trc ToolResultContent) GetType() string {
 	return trc.Type
 }
 
+// ThinkingContent is the thinking of the model before its answer.
+type ThinkingContent struct {
+	Type      string `json:"type"`
+	Thinking  string `json:"thinking"`
+	Signature string `json:"signature"`
+}
+
+func (tc ThinkingContent) GetType() string {
+	return tc.Type
+}
+
+// RedactedThinkingContent is thinking encrypted by the safety systems.
+type RedactedThinkingContent struct {
+	Type string `json:"type"`
+	Data string `json:"data"`
+}
+
+func (rtc RedactedThinkingContent) GetType() string {
+	return rtc.Type
+}
+
+// ImageContent is an image of a user message.
+type ImageContent struct {
+	Type   string        `json:"type"`
+	Source ContentSource `json:"source"`
+}
+
+func (ic ImageContent) GetType() string {
+	return ic.Type
+}
+
+// DocumentContent is a document, such as a PDF, of a user message.
+type DocumentContent struct {
+	Type   string        `json:"type"`
+	Source ContentSource `json:"source"`
+}
+
+func (dc DocumentContent) GetType() string {
+	return dc.Type
+}
+
+// ContentSource is the source of an image or a document: base64 data, plain
+// text or a URL.
+type ContentSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
 type MessageResponsePayload struct {
 	Content      []Content `json:"content"`
 	ID           string    `json:"id"`
@@ -98,6 +184,11 @@ type MessageResponsePayload struct {
 	Usage        struct {
 		InputTokens  int `json:"input_tokens"`
 		OutputTokens int `json:"output_tokens"`
+		// CacheCreationInputTokens and CacheReadInputTokens are the input
+		// tokens written to and read from the prompt cache, not counted in
+		// InputTokens.
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
 	} `json:"usage"`
 }
 
@@ -134,6 +225,18 @@ func (m *MessageResponsePayload) UnmarshalJSON(data []byte) error {
 				return err
 			}
 			m.Content = append(m.Content, tuc)
+		case "thinking":
+			tc := &ThinkingContent{}
+			if err := json.Unmarshal(raw, tc); err != nil {
+				return err
+			}
+			m.Content = append(m.Content, tc)
+		case "redacted_thinking":
+			rtc := &RedactedThinkingContent{}
+			if err := json.Unmarshal(raw, rtc); err != nil {
+				return err
+			}
+			m.Content = append(m.Content, rtc)
 		default:
 			return fmt.Errorf("unknown content type: %s\n%v", typeStruct.Type, string(raw))
 		}
@@ -146,6 +249,10 @@ func (c *Client) setMessageDefaults(payload *messagePayload) {
 	// Set defaults
 	if payload.MaxTokens == 0 {
 		payload.MaxTokens = 2048
+		// The max tokens include the thinking tokens.
+		if payload.Thinking != nil {
+			payload.MaxTokens += payload.Thinking.BudgetTokens
+		}
 	}
 
 	if len(payload.StopWords) == 0 {
@@ -163,7 +270,7 @@ func (c *Client) setMessageDefaults(payload *messagePayload) {
 	default:
 		payload.Model = defaultModel
 	}
-	if payload.StreamingFunc != nil {
+	if payload.streaming() {
 		payload.Stream = true
 	}
 }
@@ -186,7 +293,7 @@ func (c *Client) createMessage(ctx context.Context, payload *messagePayload) (*M
 		return nil, c.decodeError(resp)
 	}
 
-	if payload.StreamingFunc != nil {
+	if payload.streaming() {
 		return parseStreamingMessageResponse(ctx, resp, payload)
 	}
 
@@ -257,11 +364,11 @@ func processStreamEvent(ctx context.Context, event map[string]interface{}, paylo
 	case "message_start":
 		return handleMessageStartEvent(event, response)
 	case "content_block_start":
-		return handleContentBlockStartEvent(event, response)
+		return handleContentBlockStartEvent(ctx, event, response, payload)
 	case "content_block_delta":
 		return handleContentBlockDeltaEvent(ctx, event, response, payload)
 	case "content_block_stop":
-		// Nothing to do here
+		return handleContentBlockStopEvent(event, response)
 	case "message_delta":
 		return handleMessageDeltaEvent(event, response)
 	case "message_stop":
@@ -297,11 +404,17 @@ func handleMessageStartEvent(event map[string]interface{}, response MessageRespo
 	response.Role = getString(message, "role")
 	response.Type = getString(message, "type")
 	response.Usage.InputTokens = int(inputTokens)
+	if cacheCreation, ok := usage["cache_creation_input_tokens"].(float64); ok {
+		response.Usage.CacheCreationInputTokens = int(cacheCreation)
+	}
+	if cacheRead, ok := usage["cache_read_input_tokens"].(float64); ok {
+		response.Usage.CacheReadInputTokens = int(cacheRead)
+	}
 
 	return response, nil
 }
 
-func handleContentBlockStartEvent(event map[string]interface{}, response MessageResponsePayload) (MessageResponsePayload, error) {
+func handleContentBlockStartEvent(ctx context.Context, event map[string]interface{}, response MessageResponsePayload, payload *messagePayload) (MessageResponsePayload, error) {
 	indexValue, ok := event["index"].(float64)
 	if !ok {
 		return response, ErrInvalidIndexField
@@ -309,15 +422,47 @@ func handleContentBlockStartEvent(event map[string]interface{}, response Message
 	index := int(indexValue)
 
 	var eventType string
-	if cb, ok := event["content_block"].(map[string]any); ok {
+	cb, _ := event["content_block"].(map[string]any)
+	if cb != nil {
 		typ, _ := cb["type"].(string)
 		eventType = typ
 	}
+	if len(response.Content) > index {
+		return response, nil
+	}
 
-	if len(response.Content) <= index {
+	switch eventType {
+	case "thinking":
+		response.Content = append(response.Content, &ThinkingContent{Type: eventType})
+		return response, nil
+	case "redacted_thinking":
+		response.Content = append(response.Content, &RedactedThinkingContent{
+			Type: eventType,
+			Data: getString(cb, "data"),
+		})
+		return response, nil
+	}
+	if eventType != "tool_use" {
 		response.Content = append(response.Content, &TextContent{
 			Type: eventType,
 		})
+		return response, nil
+	}
+	toolUse := &ToolUseContent{
+		Type: eventType,
+		ID:   getString(cb, "id"),
+		Name: getString(cb, "name"),
+	}
+	response.Content = append(response.Content, toolUse)
+	if payload.StreamingToolCallFunc != nil {
+		err := payload.StreamingToolCallFunc(ctx, llms.ToolCallDelta{
+			Index: toolUseIndex(response.Content, index),
+			ID:    toolUse.ID,
+			Name:  toolUse.Name,
+		})
+		if err != nil {
+			return response, fmt.Errorf("streaming tool call func returned an error: %w", err)
+		}
 	}
 	return response, nil
 }
@@ -337,35 +482,110 @@ func handleContentBlockDeltaEvent(ctx context.Context, event map[string]interfac
 	if !ok {
 		return response, ErrInvalidDeltaTypeField
 	}
+	if len(response.Content) <= index {
+		return response, ErrContentIndexOutOfRange
+	}
 
-	if deltaType == "text_delta" {
+	switch deltaType {
+	case "text_delta":
 		text, ok := delta["text"].(string)
 		if !ok {
 			return response, ErrInvalidDeltaTextField
 		}
-		if len(response.Content) <= index {
-			return response, ErrContentIndexOutOfRange
-		}
 		textContent, ok := response.Content[index].(*TextContent)
 		if !ok {
 			return response, ErrFailedCastToTextContent
 		}
 		textContent.Text += text
-	}
 
-	if payload.StreamingFunc != nil {
-		text, ok := delta["text"].(string)
+		if payload.StreamingFunc != nil {
+			err := payload.StreamingFunc(ctx, []byte(text))
+			if err != nil {
+				return response, fmt.Errorf("streaming func returned an error: %w", err)
+			}
+		}
+	case "thinking_delta":
+		thinking, ok := delta["thinking"].(string)
 		if !ok {
 			return response, ErrInvalidDeltaTextField
 		}
-		err := payload.StreamingFunc(ctx, []byte(text))
-		if err != nil {
-			return response, fmt.Errorf("streaming func returned an error: %w", err)
+		thinkingContent, ok := response.Content[index].(*ThinkingContent)
+		if !ok {
+			return response, ErrFailedCastToThinking
+		}
+		thinkingContent.Thinking += thinking
+
+		if payload.StreamingReasoningFunc != nil {
+			err := payload.StreamingReasoningFunc(ctx, []byte(thinking))
+			if err != nil {
+				return response, fmt.Errorf("streaming reasoning func returned an error: %w", err)
+			}
+		}
+	case "signature_delta":
+		thinkingContent, ok := response.Content[index].(*ThinkingContent)
+		if !ok {
+			return response, ErrFailedCastToThinking
+		}
+		thinkingContent.Signature += getString(delta, "signature")
+	case "input_json_delta":
+		partialJSON, ok := delta["partial_json"].(string)
+		if !ok {
+			return response, ErrInvalidDeltaJSONField
+		}
+		toolUse, ok := response.Content[index].(*ToolUseContent)
+		if !ok {
+			return response, ErrFailedCastToToolUse
+		}
+		toolUse.partialInput += partialJSON
+
+		if payload.StreamingToolCallFunc != nil && partialJSON != "" {
+			err := payload.StreamingToolCallFunc(ctx, llms.ToolCallDelta{
+				Index:          toolUseIndex(response.Content, index),
+				ArgumentsDelta: partialJSON,
+			})
+			if err != nil {
+				return response, fmt.Errorf("streaming tool call func returned an error: %w", err)
+			}
 		}
 	}
 	return response, nil
 }
 
+// handleContentBlockStopEvent decodes the streamed input of tool use blocks.
+func handleContentBlockStopEvent(event map[string]interface{}, response MessageResponsePayload) (MessageResponsePayload, error) {
+	indexValue, ok := event["index"].(float64)
+	if !ok {
+		return response, ErrInvalidIndexField
+	}
+	index := int(indexValue)
+	if len(response.Content) <= index {
+		return response, ErrContentIndexOutOfRange
+	}
+	toolUse, ok := response.Content[index].(*ToolUseContent)
+	if !ok {
+		return response, nil
+	}
+	toolUse.Input = map[string]interface{}{}
+	if toolUse.partialInput != "" {
+		if err := json.Unmarshal([]byte(toolUse.partialInput), &toolUse.Input); err != nil {
+			return response, fmt.Errorf("decode tool input: %w", err)
+		}
+	}
+	return response, nil
+}
+
+// toolUseIndex returns the position of the tool use block at index among the
+// tool use blocks of content.
+func toolUseIndex(content []Content, index int) int {
+	n := 0
+	for _, c := range content[:index] {
+		if _, ok := c.(*ToolUseContent); ok {
+			n++
+		}
+	}
+	return n
+}
+
 func handleMessageDeltaEvent(event map[string]interface{}, response MessageResponsePayload) (MessageResponsePayload, error) {
 	delta, ok := event["delta"].(map[string]interface{})
 	if !ok {
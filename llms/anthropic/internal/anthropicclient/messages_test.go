@@ -0,0 +1,136 @@
+package anthropicclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestParseStreamingMessageResponse_ToolUse(t *testing.T) {
+	t.Parallel()
+	mockBody := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-3","usage":{"input_tokens":10,"output_tokens":1}}}
+
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me check."}}
+
+data: {"type":"content_block_stop","index":0}
+
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"weather","input":{}}}
+
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}
+
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}
+
+data: {"type":"content_block_stop","index":1}
+
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":20}}
+
+data: {"type":"message_stop"}
+`
+	r := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(mockBody)),
+	}
+
+	var text string
+	var deltas []llms.ToolCallDelta
+	payload := &messagePayload{
+		StreamingFunc: func(_ context.Context, chunk []byte) error {
+			text += string(chunk)
+			return nil
+		},
+		StreamingToolCallFunc: func(_ context.Context, delta llms.ToolCallDelta) error {
+			deltas = append(deltas, delta)
+			return nil
+		},
+	}
+
+	resp, err := parseStreamingMessageResponse(context.Background(), r, payload)
+	require.NoError(t, err)
+	assert.Equal(t, "Let me check.", text)
+	assert.Equal(t, []llms.ToolCallDelta{
+		{Index: 0, ID: "toolu_1", Name: "weather"},
+		{Index: 0, ArgumentsDelta: `{"city":`},
+		{Index: 0, ArgumentsDelta: `"Paris"}`},
+	}, deltas)
+
+	require.Len(t, resp.Content, 2)
+	toolUse, ok := resp.Content[1].(*ToolUseContent)
+	require.True(t, ok)
+	assert.Equal(t, "weather", toolUse.Name)
+	assert.Equal(t, map[string]interface{}{"city": "Paris"}, toolUse.Input)
+	assert.Equal(t, "tool_use", resp.StopReason)
+}
+
+func TestParseStreamingMessageResponse_Thinking(t *testing.T) {
+	t.Parallel()
+	mockBody := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-3-7-sonnet","usage":{"input_tokens":10,"output_tokens":1}}}
+
+data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}
+
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"The user "}}
+
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"greets me."}}
+
+data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"c2ln"}}
+
+data: {"type":"content_block_stop","index":0}
+
+data: {"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"ZW5j"}}
+
+data: {"type":"content_block_stop","index":1}
+
+data: {"type":"content_block_start","index":2,"content_block":{"type":"text","text":""}}
+
+data: {"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"Hello!"}}
+
+data: {"type":"content_block_stop","index":2}
+
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":30}}
+
+data: {"type":"message_stop"}
+`
+	r := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(mockBody)),
+	}
+
+	var text, reasoning string
+	payload := &messagePayload{
+		StreamingFunc: func(_ context.Context, chunk []byte) error {
+			text += string(chunk)
+			return nil
+		},
+		StreamingReasoningFunc: func(_ context.Context, chunk []byte) error {
+			reasoning += string(chunk)
+			return nil
+		},
+	}
+
+	resp, err := parseStreamingMessageResponse(context.Background(), r, payload)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello!", text)
+	assert.Equal(t, "The user greets me.", reasoning)
+	assert.Equal(t, []Content{
+		&ThinkingContent{Type: "thinking", Thinking: "The user greets me.", Signature: "c2ln"},
+		&RedactedThinkingContent{Type: "redacted_thinking", Data: "ZW5j"},
+		&TextContent{Type: "text", Text: "Hello!"},
+	}, resp.Content)
+}
+
+func TestSetMessageDefaults_Thinking(t *testing.T) {
+	t.Parallel()
+	c := &Client{}
+	payload := &messagePayload{Thinking: &Thinking{Type: "enabled", BudgetTokens: 4096}}
+	c.setMessageDefaults(payload)
+	assert.Equal(t, 2048+4096, payload.MaxTokens)
+}
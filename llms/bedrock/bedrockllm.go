@@ -3,6 +3,7 @@ package bedrock
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
@@ -17,6 +18,8 @@ const defaultModel = ModelAmazonTitanTextLiteV1
 type LLM struct {
 	modelID          string
 	client           *bedrockclient.Client
+	invokeModelAPI   bool
+	converseAPI      bool
 	CallbacksHandler callbacks.Handler
 }
 
@@ -29,6 +32,8 @@ func New(opts ...Option) (*LLM, error) {
 	return &LLM{
 		client:           c,
 		modelID:          o.modelID,
+		invokeModelAPI:   o.invokeModelAPI,
+		converseAPI:      o.converseAPI,
 		CallbacksHandler: o.callbackHandler,
 	}, nil
 }
@@ -71,12 +76,11 @@ func (l *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(&opts)
 	}
 
-	m, err := processMessages(messages)
-	if err != nil {
+	if err := opts.RateLimiter.Wait(ctx, messages, opts); err != nil {
 		return nil, err
 	}
 
-	res, err := l.client.CreateCompletion(ctx, opts.Model, m, opts)
+	res, err := l.createCompletion(ctx, messages, opts)
 	if err != nil {
 		if l.CallbacksHandler != nil {
 			l.CallbacksHandler.HandleLLMError(ctx, err)
@@ -91,6 +95,48 @@ func (l *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 	return res, nil
 }
 
+// invokeModelDefaultPrefixes are the prefixes of the IDs of the models using
+// the InvokeModel API unless WithConverseAPI is set, as they don't support
+// all the features of their InvokeModel payloads over Converse, e.g. the
+// system prompts.
+var invokeModelDefaultPrefixes = []string{ //nolint:gochecknoglobals
+	"amazon.titan-text-",
+	"ai21.j2-",
+	"cohere.command-text-",
+	"cohere.command-light-text-",
+}
+
+// useConverseAPI reports whether the completions of model are created with
+// the Converse API.
+func (l *LLM) useConverseAPI(model string) bool {
+	switch {
+	case l.invokeModelAPI:
+		return false
+	case l.converseAPI:
+		return true
+	}
+	for _, prefix := range invokeModelDefaultPrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// createCompletion creates the completion with the Converse API, or with the
+// payloads specific to the providers of the models if the invoke model API is
+// used.
+func (l *LLM) createCompletion(ctx context.Context, messages []llms.MessageContent, opts llms.CallOptions) (*llms.ContentResponse, error) {
+	if l.useConverseAPI(opts.Model) {
+		return l.client.CreateConverseCompletion(ctx, opts.Model, messages, opts)
+	}
+	m, err := processMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+	return l.client.CreateCompletion(ctx, opts.Model, m, opts)
+}
+
 func processMessages(messages []llms.MessageContent) ([]bedrockclient.Message, error) {
 	bedrockMsgs := make([]bedrockclient.Message, 0, len(messages))
 
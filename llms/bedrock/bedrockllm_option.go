@@ -12,6 +12,8 @@ type options struct {
 	modelID         string
 	client          *bedrockruntime.Client
 	callbackHandler callbacks.Handler
+	invokeModelAPI  bool
+	converseAPI     bool
 }
 
 // WithModel allows setting a custom modelId.
@@ -43,3 +45,27 @@ func WithCallback(callbackHandler callbacks.Handler) Option {
 		o.callbackHandler = callbackHandler
 	}
 }
+
+// WithInvokeModelAPI makes the LLM use the InvokeModel API with the request
+// payloads specific to the providers of the models, instead of the Converse
+// API.
+//
+// The InvokeModel API supports the models without Converse support, but
+// neither tool calling nor streaming with other models than Anthropic's.
+func WithInvokeModelAPI() Option {
+	return func(o *options) {
+		o.invokeModelAPI = true
+		o.converseAPI = false
+	}
+}
+
+// WithConverseAPI makes the LLM use the Converse API with all the models,
+// including the ones using the InvokeModel API by default, e.g. to call tools
+// with the Amazon Titan, AI21 Jurassic-2 and Cohere Command models, which
+// reject the system prompts over Converse.
+func WithConverseAPI() Option {
+	return func(o *options) {
+		o.converseAPI = true
+		o.invokeModelAPI = false
+	}
+}
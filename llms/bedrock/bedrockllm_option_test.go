@@ -0,0 +1,32 @@
+package bedrock
+
+import "testing"
+
+func TestUseConverseAPI(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		opts  []Option
+		model string
+		want  bool
+	}{
+		{nil, ModelAnthropicClaudeV3Haiku, true},
+		{nil, ModelMetaLlama38bInstructV1, true},
+		{nil, ModelAmazonNovaLiteV1, true},
+		{nil, ModelAmazonTitanTextLiteV1, false},
+		{nil, ModelAi21J2MidV1, false},
+		{nil, ModelCohereCommandLightTextV14, false},
+		{[]Option{WithConverseAPI()}, ModelAmazonTitanTextLiteV1, true},
+		{[]Option{WithInvokeModelAPI()}, ModelAnthropicClaudeV3Haiku, false},
+		{[]Option{WithInvokeModelAPI(), WithConverseAPI()}, ModelAi21J2MidV1, true},
+	}
+	for _, tt := range tests {
+		o := &options{}
+		for _, opt := range tt.opts {
+			opt(o)
+		}
+		l := &LLM{invokeModelAPI: o.invokeModelAPI, converseAPI: o.converseAPI}
+		if got := l.useConverseAPI(tt.model); got != tt.want {
+			t.Errorf("useConverseAPI(%s) with %d options = %v, want %v", tt.model, len(tt.opts), got, tt.want)
+		}
+	}
+}
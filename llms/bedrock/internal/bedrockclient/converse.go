@@ -0,0 +1,584 @@
+package bedrockclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Ref: https://docs.aws.amazon.com/bedrock/latest/userguide/conversation-inference.html
+
+// CreateConverseCompletion creates a new completion response with the
+// Converse API, which has the same request and response format for all the
+// models supporting it, or with the ConverseStream API if a streaming
+// function is set.
+func (c *Client) CreateConverseCompletion(ctx context.Context,
+	modelID string,
+	messages []llms.MessageContent,
+	options llms.CallOptions,
+) (*llms.ContentResponse, error) {
+	system, msgs, err := convertConverseMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+	toolConfig, err := convertConverseTools(options)
+	if err != nil {
+		return nil, err
+	}
+	inferenceConfig := convertConverseInferenceConfig(options)
+	additionalFields := converseAdditionalModelRequestFields(options)
+
+	if options.StreamingFunc != nil || options.StreamingToolCallFunc != nil || options.StreamingReasoningFunc != nil {
+		output, err := c.client.ConverseStream(ctx, &bedrockruntime.ConverseStreamInput{
+			ModelId:         aws.String(modelID),
+			System:          system,
+			Messages:        msgs,
+			InferenceConfig: inferenceConfig,
+			ToolConfig:      toolConfig,
+
+			AdditionalModelRequestFields: additionalFields,
+		})
+		if err != nil {
+			return nil, err
+		}
+		stream := output.GetStream()
+		if stream == nil {
+			return nil, errors.New("no stream")
+		}
+		defer stream.Close()
+		return parseConverseStream(ctx, stream, options)
+	}
+
+	output, err := c.client.Converse(ctx, &bedrockruntime.ConverseInput{
+		ModelId:         aws.String(modelID),
+		System:          system,
+		Messages:        msgs,
+		InferenceConfig: inferenceConfig,
+		ToolConfig:      toolConfig,
+
+		AdditionalModelRequestFields: additionalFields,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertConverseOutput(output)
+}
+
+// convertConverseMessages converts the messages to the system prompt and the
+// conversation of the Converse API. The consecutive messages of the same
+// role, such as the results of parallel tool calls, are merged as the
+// conversation must alternate between the user and the assistant.
+func convertConverseMessages(messages []llms.MessageContent) ([]types.SystemContentBlock, []types.Message, error) {
+	var system []types.SystemContentBlock
+	msgs := make([]types.Message, 0, len(messages))
+	documents := 0
+	for _, m := range messages {
+		if m.Role == llms.ChatMessageTypeSystem {
+			for _, part := range m.Parts {
+				text, ok := part.(llms.TextContent)
+				if !ok {
+					return nil, nil, errors.New("system prompt must be text")
+				}
+				system = append(system, &types.SystemContentBlockMemberText{Value: text.Text})
+			}
+			continue
+		}
+
+		role, err := getConverseRole(m.Role)
+		if err != nil {
+			return nil, nil, err
+		}
+		content := make([]types.ContentBlock, 0, len(m.Parts))
+		for _, part := range m.Parts {
+			block, err := convertConverseContentBlock(part)
+			if err != nil {
+				return nil, nil, err
+			}
+			// The documents must have distinct names in the conversation.
+			if doc, ok := block.(*types.ContentBlockMemberDocument); ok {
+				documents++
+				doc.Value.Name = aws.String(fmt.Sprintf("document-%d", documents))
+			}
+			content = append(content, block)
+		}
+		if len(msgs) > 0 && msgs[len(msgs)-1].Role == role {
+			msgs[len(msgs)-1].Content = append(msgs[len(msgs)-1].Content, content...)
+			continue
+		}
+		msgs = append(msgs, types.Message{Role: role, Content: content})
+	}
+	return system, msgs, nil
+}
+
+// getConverseRole converts the role of a message to its Converse role, the
+// tool results being sent by the user.
+func getConverseRole(role llms.ChatMessageType) (types.ConversationRole, error) {
+	switch role {
+	case llms.ChatMessageTypeAI:
+		return types.ConversationRoleAssistant, nil
+	case llms.ChatMessageTypeHuman, llms.ChatMessageTypeGeneric, llms.ChatMessageTypeTool:
+		return types.ConversationRoleUser, nil
+	case llms.ChatMessageTypeSystem, llms.ChatMessageTypeFunction:
+		fallthrough
+	default:
+		return "", fmt.Errorf("role %s not supported", role)
+	}
+}
+
+func convertConverseContentBlock(part llms.ContentPart) (types.ContentBlock, error) {
+	switch part := part.(type) {
+	case llms.TextContent:
+		return &types.ContentBlockMemberText{Value: part.Text}, nil
+	case llms.BinaryContent:
+		return convertConverseBinaryContent(part)
+	case llms.FileURLContent:
+		return convertConverseFileURL(part)
+	case llms.ReasoningContent:
+		return convertConverseReasoningContent(part)
+	case llms.ToolCall:
+		if part.FunctionCall == nil {
+			return nil, errors.New("tool call without function call")
+		}
+		input, err := argumentsDocument(part.FunctionCall.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("tool call %s: %w", part.ID, err)
+		}
+		return &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+			ToolUseId: aws.String(part.ID),
+			Name:      aws.String(part.FunctionCall.Name),
+			Input:     input,
+		}}, nil
+	case llms.ToolCallResponse:
+		return &types.ContentBlockMemberToolResult{Value: types.ToolResultBlock{
+			ToolUseId: aws.String(part.ToolCallID),
+			Content: []types.ToolResultContentBlock{
+				&types.ToolResultContentBlockMemberText{Value: part.Content},
+			},
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported content part type %T", part)
+	}
+}
+
+// The largest image and document the Converse API accepts, in bytes.
+const (
+	maxConverseImageSize    = 3_750_000
+	maxConverseDocumentSize = 4_500_000
+)
+
+// converseDocumentFormats are the document formats of the Converse API by
+// MIME type.
+var converseDocumentFormats = map[string]types.DocumentFormat{
+	"application/pdf":    types.DocumentFormatPdf,
+	"text/csv":           types.DocumentFormatCsv,
+	"application/msword": types.DocumentFormatDoc,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": types.DocumentFormatDocx,
+	"application/vnd.ms-excel": types.DocumentFormatXls,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": types.DocumentFormatXlsx,
+	"text/html":     types.DocumentFormatHtml,
+	"text/plain":    types.DocumentFormatTxt,
+	"text/markdown": types.DocumentFormatMd,
+}
+
+// convertConverseBinaryContent converts binary content to an image block or
+// a document block. Documents are named by convertConverseMessages.
+func convertConverseBinaryContent(part llms.BinaryContent) (types.ContentBlock, error) {
+	if part.MIMEType == "" {
+		part.MIMEType = llms.DetectMIMEType(part.Data)
+	}
+	mimeType, _, _ := strings.Cut(part.MIMEType, ";")
+	if format, ok := strings.CutPrefix(mimeType, "image/"); ok {
+		if err := llms.CheckContentSize(part, maxConverseImageSize); err != nil {
+			return nil, err
+		}
+		if format == "jpg" {
+			format = string(types.ImageFormatJpeg)
+		}
+		return &types.ContentBlockMemberImage{Value: types.ImageBlock{
+			Format: types.ImageFormat(format),
+			Source: &types.ImageSourceMemberBytes{Value: part.Data},
+		}}, nil
+	}
+	format, ok := converseDocumentFormats[mimeType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported MIME type %s", part.MIMEType)
+	}
+	if err := llms.CheckContentSize(part, maxConverseDocumentSize); err != nil {
+		return nil, err
+	}
+	return &types.ContentBlockMemberDocument{Value: types.DocumentBlock{
+		Format: format,
+		Source: &types.DocumentSourceMemberBytes{Value: part.Data},
+	}}, nil
+}
+
+// convertConverseFileURL converts a file in S3 to an image block or a
+// document block.
+func convertConverseFileURL(part llms.FileURLContent) (types.ContentBlock, error) {
+	if !strings.HasPrefix(part.URL, "s3://") {
+		return nil, fmt.Errorf("unsupported file URL %s, only S3 URIs are", part.URL)
+	}
+	location := types.S3Location{Uri: aws.String(part.URL)}
+	if format, ok := strings.CutPrefix(part.MIMEType, "image/"); ok {
+		if format == "jpg" {
+			format = string(types.ImageFormatJpeg)
+		}
+		return &types.ContentBlockMemberImage{Value: types.ImageBlock{
+			Format: types.ImageFormat(format),
+			Source: &types.ImageSourceMemberS3Location{Value: location},
+		}}, nil
+	}
+	format, ok := converseDocumentFormats[part.MIMEType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported MIME type %s", part.MIMEType)
+	}
+	return &types.ContentBlockMemberDocument{Value: types.DocumentBlock{
+		Format: format,
+		Source: &types.DocumentSourceMemberS3Location{Value: location},
+	}}, nil
+}
+
+// convertConverseReasoningContent converts the reasoning of a previous turn,
+// passed back to the model, to a reasoning block.
+func convertConverseReasoningContent(part llms.ReasoningContent) (types.ContentBlock, error) {
+	if part.RedactedData != "" {
+		data, err := base64.StdEncoding.DecodeString(part.RedactedData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redacted reasoning: %w", err)
+		}
+		return &types.ContentBlockMemberReasoningContent{
+			Value: &types.ReasoningContentBlockMemberRedactedContent{Value: data},
+		}, nil
+	}
+	text := types.ReasoningTextBlock{Text: aws.String(part.Text)}
+	if part.Signature != "" {
+		text.Signature = aws.String(part.Signature)
+	}
+	return &types.ContentBlockMemberReasoningContent{
+		Value: &types.ReasoningContentBlockMemberReasoningText{Value: text},
+	}, nil
+}
+
+// convertConverseReasoning converts a reasoning block of a response.
+func convertConverseReasoning(block types.ReasoningContentBlock) (llms.ReasoningContent, bool) {
+	switch block := block.(type) {
+	case *types.ReasoningContentBlockMemberReasoningText:
+		return llms.ReasoningContent{
+			Text:      aws.ToString(block.Value.Text),
+			Signature: aws.ToString(block.Value.Signature),
+		}, true
+	case *types.ReasoningContentBlockMemberRedactedContent:
+		return llms.ReasoningContent{RedactedData: base64.StdEncoding.EncodeToString(block.Value)}, true
+	default:
+		return llms.ReasoningContent{}, false
+	}
+}
+
+// argumentsDocument converts the JSON encoded arguments of a tool call to a
+// document.
+func argumentsDocument(arguments string) (document.Interface, error) {
+	var input any = map[string]any{}
+	if arguments != "" {
+		if err := json.Unmarshal([]byte(arguments), &input); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	return document.NewLazyDocument(input), nil
+}
+
+// jsonDocument converts a value to a document through its JSON encoding, the
+// documents not using the json struct tags.
+func jsonDocument(v any) (document.Interface, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var input any
+	if err := json.Unmarshal(b, &input); err != nil {
+		return nil, err
+	}
+	return document.NewLazyDocument(input), nil
+}
+
+// convertConverseTools converts the tools and the tool choice of the call.
+// No tools are sent when the tool choice is none, the Converse API having no
+// such choice.
+func convertConverseTools(options llms.CallOptions) (*types.ToolConfiguration, error) {
+	mode, function, err := llms.ResolveToolChoice(options.ToolChoice)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.Tools) == 0 || mode == llms.ToolChoiceNone {
+		return nil, nil
+	}
+
+	config := &types.ToolConfiguration{
+		Tools: make([]types.Tool, 0, len(options.Tools)),
+	}
+	for _, tool := range options.Tools {
+		if tool.Function == nil {
+			return nil, fmt.Errorf("tool type %v not supported", tool.Type)
+		}
+		parameters := tool.Function.Parameters
+		if parameters == nil {
+			parameters = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		schema, err := jsonDocument(parameters)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: %w", tool.Function.Name, err)
+		}
+		config.Tools = append(config.Tools, &types.ToolMemberToolSpec{Value: types.ToolSpecification{
+			Name:        aws.String(tool.Function.Name),
+			Description: aws.String(tool.Function.Description),
+			InputSchema: &types.ToolInputSchemaMemberJson{Value: schema},
+		}})
+	}
+
+	switch mode {
+	case "":
+	case llms.ToolChoiceAuto:
+		config.ToolChoice = &types.ToolChoiceMemberAuto{}
+	case llms.ToolChoiceRequired:
+		config.ToolChoice = &types.ToolChoiceMemberAny{}
+	case llms.ToolChoiceFunction:
+		config.ToolChoice = &types.ToolChoiceMemberTool{Value: types.SpecificToolChoice{
+			Name: aws.String(function),
+		}}
+	}
+	return config, nil
+}
+
+func convertConverseInferenceConfig(options llms.CallOptions) *types.InferenceConfiguration {
+	config := &types.InferenceConfiguration{
+		StopSequences: options.StopWords,
+	}
+	if options.MaxTokens > 0 {
+		config.MaxTokens = aws.Int32(int32(options.MaxTokens)) //nolint:gosec
+	} else if options.ThinkingBudget > 0 {
+		// The max tokens include the thinking tokens.
+		config.MaxTokens = aws.Int32(int32(options.ThinkingBudget + defaultThinkingMaxTokens)) //nolint:gosec
+	}
+	if options.Temperature > 0 {
+		config.Temperature = aws.Float32(float32(options.Temperature))
+	}
+	if options.TopP > 0 {
+		config.TopP = aws.Float32(float32(options.TopP))
+	}
+	return config
+}
+
+// defaultThinkingMaxTokens is the number of tokens of the answer when thinking
+// is enabled without max tokens.
+const defaultThinkingMaxTokens = 2048
+
+// converseAdditionalModelRequestFields returns the model specific fields of
+// the request: the thinking configuration of the Claude models.
+func converseAdditionalModelRequestFields(options llms.CallOptions) document.Interface {
+	if options.ThinkingBudget <= 0 {
+		return nil
+	}
+	return document.NewLazyDocument(map[string]any{
+		"thinking": map[string]any{
+			"type":          "enabled",
+			"budget_tokens": options.ThinkingBudget,
+		},
+	})
+}
+
+// convertConverseOutput converts the output of the Converse API to a single
+// choice holding the text and the tool calls of the response.
+func convertConverseOutput(output *bedrockruntime.ConverseOutput) (*llms.ContentResponse, error) {
+	message, ok := output.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return nil, errors.New("no message in response")
+	}
+
+	choice := &llms.ContentChoice{
+		StopReason: string(output.StopReason),
+	}
+	for _, block := range message.Value.Content {
+		switch block := block.(type) {
+		case *types.ContentBlockMemberText:
+			choice.Content += block.Value
+		case *types.ContentBlockMemberToolUse:
+			var arguments []byte
+			if block.Value.Input != nil {
+				var err error
+				arguments, err = block.Value.Input.MarshalSmithyDocument()
+				if err != nil {
+					return nil, err
+				}
+			}
+			choice.ToolCalls = append(choice.ToolCalls, llms.ToolCall{
+				ID:   aws.ToString(block.Value.ToolUseId),
+				Type: "function",
+				FunctionCall: &llms.FunctionCall{
+					Name:      aws.ToString(block.Value.Name),
+					Arguments: string(arguments),
+				},
+			})
+		case *types.ContentBlockMemberReasoningContent:
+			if reasoning, ok := convertConverseReasoning(block.Value); ok {
+				choice.Reasoning = append(choice.Reasoning, reasoning)
+			}
+		}
+	}
+	if len(choice.ToolCalls) > 0 {
+		choice.FuncCall = choice.ToolCalls[0].FunctionCall
+	}
+
+	usage := convertConverseUsage(output.Usage)
+	choice.GenerationInfo = map[string]any{
+		"input_tokens":  usage.PromptTokens,
+		"output_tokens": usage.CompletionTokens,
+	}
+	return &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{choice},
+		Usage:   usage,
+	}, nil
+}
+
+func convertConverseUsage(usage *types.TokenUsage) llms.Usage {
+	if usage == nil {
+		return llms.Usage{}
+	}
+	// The input tokens don't include the ones read from or written to the
+	// prompt cache.
+	cacheRead := int(aws.ToInt32(usage.CacheReadInputTokens))
+	promptTokens := int(aws.ToInt32(usage.InputTokens)) + cacheRead + int(aws.ToInt32(usage.CacheWriteInputTokens))
+	u := llms.NewUsage(promptTokens, int(aws.ToInt32(usage.OutputTokens)))
+	u.CachedTokens = cacheRead
+	return u
+}
+
+// parseConverseStream reads the events of a ConverseStream response, sending
+// the text to the streaming function, the fragments of the tool calls to the
+// streaming tool call function and the reasoning to the streaming reasoning
+// function.
+func parseConverseStream(
+	ctx context.Context,
+	stream bedrockruntime.ConverseStreamOutputReader,
+	options llms.CallOptions,
+) (*llms.ContentResponse, error) {
+	choice := &llms.ContentChoice{}
+	// toolCalls maps the content block indices of the events, which count
+	// the text blocks too, to the indices of the tool calls.
+	toolCalls := map[int32]int{}
+	arguments := map[int]*strings.Builder{}
+	// reasoning maps the content block indices of the events to the indices
+	// of the reasoning of the choice.
+	reasoning := map[int32]int{}
+	redacted := map[int][]byte{}
+	var usage llms.Usage
+
+	for e := range stream.Events() {
+		switch e := e.(type) {
+		case *types.ConverseStreamOutputMemberContentBlockStart:
+			start, ok := e.Value.Start.(*types.ContentBlockStartMemberToolUse)
+			if !ok {
+				continue
+			}
+			index := len(choice.ToolCalls)
+			toolCalls[aws.ToInt32(e.Value.ContentBlockIndex)] = index
+			arguments[index] = &strings.Builder{}
+			choice.ToolCalls = append(choice.ToolCalls, llms.ToolCall{
+				ID:   aws.ToString(start.Value.ToolUseId),
+				Type: "function",
+				FunctionCall: &llms.FunctionCall{
+					Name: aws.ToString(start.Value.Name),
+				},
+			})
+			if options.StreamingToolCallFunc != nil {
+				err := options.StreamingToolCallFunc(ctx, llms.ToolCallDelta{
+					Index: index,
+					ID:    aws.ToString(start.Value.ToolUseId),
+					Name:  aws.ToString(start.Value.Name),
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+		case *types.ConverseStreamOutputMemberContentBlockDelta:
+			switch delta := e.Value.Delta.(type) {
+			case *types.ContentBlockDeltaMemberText:
+				choice.Content += delta.Value
+				if options.StreamingFunc != nil {
+					if err := options.StreamingFunc(ctx, []byte(delta.Value)); err != nil {
+						return nil, err
+					}
+				}
+			case *types.ContentBlockDeltaMemberReasoningContent:
+				blockIndex := aws.ToInt32(e.Value.ContentBlockIndex)
+				index, ok := reasoning[blockIndex]
+				if !ok {
+					index = len(choice.Reasoning)
+					reasoning[blockIndex] = index
+					choice.Reasoning = append(choice.Reasoning, llms.ReasoningContent{})
+				}
+				switch delta := delta.Value.(type) {
+				case *types.ReasoningContentBlockDeltaMemberText:
+					choice.Reasoning[index].Text += delta.Value
+					if options.StreamingReasoningFunc != nil {
+						if err := options.StreamingReasoningFunc(ctx, []byte(delta.Value)); err != nil {
+							return nil, err
+						}
+					}
+				case *types.ReasoningContentBlockDeltaMemberSignature:
+					choice.Reasoning[index].Signature += delta.Value
+				case *types.ReasoningContentBlockDeltaMemberRedactedContent:
+					redacted[index] = append(redacted[index], delta.Value...)
+				}
+			case *types.ContentBlockDeltaMemberToolUse:
+				index, ok := toolCalls[aws.ToInt32(e.Value.ContentBlockIndex)]
+				if !ok {
+					return nil, errors.New("tool use delta without tool use start")
+				}
+				input := aws.ToString(delta.Value.Input)
+				arguments[index].WriteString(input)
+				if options.StreamingToolCallFunc != nil {
+					err := options.StreamingToolCallFunc(ctx, llms.ToolCallDelta{
+						Index:          index,
+						ArgumentsDelta: input,
+					})
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+		case *types.ConverseStreamOutputMemberMessageStop:
+			choice.StopReason = string(e.Value.StopReason)
+		case *types.ConverseStreamOutputMemberMetadata:
+			usage = convertConverseUsage(e.Value.Usage)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range choice.ToolCalls {
+		choice.ToolCalls[i].FunctionCall.Arguments = arguments[i].String()
+	}
+	for i, data := range redacted {
+		choice.Reasoning[i].RedactedData = base64.StdEncoding.EncodeToString(data)
+	}
+	if len(choice.ToolCalls) > 0 {
+		choice.FuncCall = choice.ToolCalls[0].FunctionCall
+	}
+	choice.GenerationInfo = map[string]any{
+		"input_tokens":  usage.PromptTokens,
+		"output_tokens": usage.CompletionTokens,
+	}
+	return &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{choice},
+		Usage:   usage,
+	}, nil
+}
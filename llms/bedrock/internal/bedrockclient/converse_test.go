@@ -0,0 +1,314 @@
+package bedrockclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestConvertConverseMessages(t *testing.T) {
+	t.Parallel()
+	messages := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "You are a weather bot."),
+		llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Paris and Rome?"),
+		{
+			Role: llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{
+				llms.ToolCall{ID: "t1", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}},
+				llms.ToolCall{ID: "t2", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Rome"}`}},
+			},
+		},
+		{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "t1", Content: "sunny"}}},
+		{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "t2", Content: "rainy"}}},
+	}
+
+	system, msgs, err := convertConverseMessages(messages)
+	require.NoError(t, err)
+	assert.Equal(t, []types.SystemContentBlock{
+		&types.SystemContentBlockMemberText{Value: "You are a weather bot."},
+	}, system)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, types.ConversationRoleUser, msgs[0].Role)
+	assert.Equal(t, types.ConversationRoleAssistant, msgs[1].Role)
+
+	toolUse, ok := msgs[1].Content[1].(*types.ContentBlockMemberToolUse)
+	require.True(t, ok)
+	assert.Equal(t, "t2", aws.ToString(toolUse.Value.ToolUseId))
+	input, err := toolUse.Value.Input.MarshalSmithyDocument()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"city":"Rome"}`, string(input))
+
+	// The results of the parallel calls are merged in a single user message.
+	assert.Equal(t, types.ConversationRoleUser, msgs[2].Role)
+	require.Len(t, msgs[2].Content, 2)
+	result, ok := msgs[2].Content[1].(*types.ContentBlockMemberToolResult)
+	require.True(t, ok)
+	assert.Equal(t, "t2", aws.ToString(result.Value.ToolUseId))
+}
+
+func TestConvertConverseMessagesMultimodal(t *testing.T) {
+	t.Parallel()
+	messages := []llms.MessageContent{{
+		Role: llms.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{
+			llms.TextPart("Compare the chart with the reports."),
+			llms.BinaryPart("image/png", []byte("png")),
+			llms.BinaryPart("application/pdf", []byte("pdf")),
+			llms.FileURLPart("text/csv", "s3://bucket/report.csv"),
+		},
+	}}
+
+	_, msgs, err := convertConverseMessages(messages)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, []types.ContentBlock{
+		&types.ContentBlockMemberText{Value: "Compare the chart with the reports."},
+		&types.ContentBlockMemberImage{Value: types.ImageBlock{
+			Format: types.ImageFormatPng,
+			Source: &types.ImageSourceMemberBytes{Value: []byte("png")},
+		}},
+		&types.ContentBlockMemberDocument{Value: types.DocumentBlock{
+			Name:   aws.String("document-1"),
+			Format: types.DocumentFormatPdf,
+			Source: &types.DocumentSourceMemberBytes{Value: []byte("pdf")},
+		}},
+		&types.ContentBlockMemberDocument{Value: types.DocumentBlock{
+			Name:   aws.String("document-2"),
+			Format: types.DocumentFormatCsv,
+			Source: &types.DocumentSourceMemberS3Location{Value: types.S3Location{Uri: aws.String("s3://bucket/report.csv")}},
+		}},
+	}, msgs[0].Content)
+
+	_, _, err = convertConverseMessages([]llms.MessageContent{{
+		Role:  llms.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{llms.BinaryPart("application/pdf", make([]byte, maxConverseDocumentSize+1))},
+	}})
+	require.ErrorIs(t, err, llms.ErrContentTooLarge)
+
+	_, _, err = convertConverseMessages([]llms.MessageContent{{
+		Role:  llms.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{llms.BinaryPart("audio/wav", []byte("wav"))},
+	}})
+	require.Error(t, err)
+}
+
+func TestConvertConverseTools(t *testing.T) {
+	t.Parallel()
+	tools := []llms.Tool{{
+		Type: "function",
+		Function: &llms.FunctionDefinition{
+			Name:        "weather",
+			Description: "Get the weather of a city.",
+			Parameters: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"city": map[string]any{"type": "string"}},
+			},
+		},
+	}}
+
+	config, err := convertConverseTools(llms.CallOptions{Tools: tools})
+	require.NoError(t, err)
+	require.Len(t, config.Tools, 1)
+	assert.Nil(t, config.ToolChoice)
+	spec, ok := config.Tools[0].(*types.ToolMemberToolSpec)
+	require.True(t, ok)
+	schema, ok := spec.Value.InputSchema.(*types.ToolInputSchemaMemberJson)
+	require.True(t, ok)
+	b, err := schema.Value.MarshalSmithyDocument()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object","properties":{"city":{"type":"string"}}}`, string(b))
+
+	config, err = convertConverseTools(llms.CallOptions{Tools: tools, ToolChoice: llms.FunctionToolChoice("weather")})
+	require.NoError(t, err)
+	assert.Equal(t, &types.ToolChoiceMemberTool{Value: types.SpecificToolChoice{Name: aws.String("weather")}}, config.ToolChoice)
+
+	config, err = convertConverseTools(llms.CallOptions{Tools: tools, ToolChoice: llms.ToolChoiceNone})
+	require.NoError(t, err)
+	assert.Nil(t, config)
+}
+
+func TestConvertConverseOutput(t *testing.T) {
+	t.Parallel()
+	output := &bedrockruntime.ConverseOutput{
+		Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+			Role: types.ConversationRoleAssistant,
+			Content: []types.ContentBlock{
+				&types.ContentBlockMemberText{Value: "Checking."},
+				&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String("t1"),
+					Name:      aws.String("weather"),
+					Input:     document.NewLazyDocument(map[string]any{"city": "Paris"}),
+				}},
+			},
+		}},
+		StopReason: types.StopReasonToolUse,
+		Usage: &types.TokenUsage{
+			InputTokens:           aws.Int32(10),
+			OutputTokens:          aws.Int32(5),
+			CacheReadInputTokens:  aws.Int32(4),
+			CacheWriteInputTokens: aws.Int32(2),
+		},
+	}
+
+	resp, err := convertConverseOutput(output)
+	require.NoError(t, err)
+	require.Len(t, resp.Choices, 1)
+	choice := resp.Choices[0]
+	assert.Equal(t, "Checking.", choice.Content)
+	assert.Equal(t, "tool_use", choice.StopReason)
+	require.Len(t, choice.ToolCalls, 1)
+	assert.Equal(t, "t1", choice.ToolCalls[0].ID)
+	assert.JSONEq(t, `{"city":"Paris"}`, choice.ToolCalls[0].FunctionCall.Arguments)
+	assert.Equal(t, choice.ToolCalls[0].FunctionCall, choice.FuncCall)
+	assert.Equal(t, llms.Usage{PromptTokens: 16, CompletionTokens: 5, TotalTokens: 21, CachedTokens: 4}, resp.Usage)
+}
+
+type fakeConverseStream struct {
+	events chan types.ConverseStreamOutput
+}
+
+func newFakeConverseStream(events ...types.ConverseStreamOutput) *fakeConverseStream {
+	s := &fakeConverseStream{events: make(chan types.ConverseStreamOutput, len(events))}
+	for _, e := range events {
+		s.events <- e
+	}
+	close(s.events)
+	return s
+}
+
+func (s *fakeConverseStream) Events() <-chan types.ConverseStreamOutput { return s.events }
+func (s *fakeConverseStream) Close() error                              { return nil }
+func (s *fakeConverseStream) Err() error                                { return nil }
+
+func TestParseConverseStream(t *testing.T) {
+	t.Parallel()
+	stream := newFakeConverseStream(
+		&types.ConverseStreamOutputMemberMessageStart{Value: types.MessageStartEvent{Role: types.ConversationRoleAssistant}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(0),
+			Delta:             &types.ContentBlockDeltaMemberText{Value: "Let me check."},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockStart{Value: types.ContentBlockStartEvent{
+			ContentBlockIndex: aws.Int32(1),
+			Start: &types.ContentBlockStartMemberToolUse{Value: types.ToolUseBlockStart{
+				ToolUseId: aws.String("t1"),
+				Name:      aws.String("weather"),
+			}},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(1),
+			Delta:             &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: aws.String(`{"city":`)}},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(1),
+			Delta:             &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: aws.String(`"Paris"}`)}},
+		}},
+		&types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{StopReason: types.StopReasonToolUse}},
+		&types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{
+			Usage: &types.TokenUsage{InputTokens: aws.Int32(10), OutputTokens: aws.Int32(20)},
+		}},
+	)
+
+	var text string
+	var deltas []llms.ToolCallDelta
+	options := llms.CallOptions{
+		StreamingFunc: func(_ context.Context, chunk []byte) error {
+			text += string(chunk)
+			return nil
+		},
+		StreamingToolCallFunc: func(_ context.Context, delta llms.ToolCallDelta) error {
+			deltas = append(deltas, delta)
+			return nil
+		},
+	}
+
+	resp, err := parseConverseStream(context.Background(), stream, options)
+	require.NoError(t, err)
+	assert.Equal(t, "Let me check.", text)
+	assert.Equal(t, []llms.ToolCallDelta{
+		{Index: 0, ID: "t1", Name: "weather"},
+		{Index: 0, ArgumentsDelta: `{"city":`},
+		{Index: 0, ArgumentsDelta: `"Paris"}`},
+	}, deltas)
+
+	require.Len(t, resp.Choices, 1)
+	choice := resp.Choices[0]
+	assert.Equal(t, "Let me check.", choice.Content)
+	assert.Equal(t, "tool_use", choice.StopReason)
+	require.Len(t, choice.ToolCalls, 1)
+	assert.Equal(t, `{"city":"Paris"}`, choice.ToolCalls[0].FunctionCall.Arguments)
+	assert.Equal(t, llms.NewUsage(10, 20), resp.Usage)
+}
+
+func TestParseConverseStreamReasoning(t *testing.T) {
+	t.Parallel()
+	stream := newFakeConverseStream(
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(0),
+			Delta: &types.ContentBlockDeltaMemberReasoningContent{
+				Value: &types.ReasoningContentBlockDeltaMemberText{Value: "The user "},
+			},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(0),
+			Delta: &types.ContentBlockDeltaMemberReasoningContent{
+				Value: &types.ReasoningContentBlockDeltaMemberText{Value: "greets me."},
+			},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(0),
+			Delta: &types.ContentBlockDeltaMemberReasoningContent{
+				Value: &types.ReasoningContentBlockDeltaMemberSignature{Value: "c2ln"},
+			},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(1),
+			Delta:             &types.ContentBlockDeltaMemberText{Value: "Hello!"},
+		}},
+	)
+
+	var reasoning string
+	resp, err := parseConverseStream(context.Background(), stream, llms.CallOptions{
+		StreamingReasoningFunc: func(_ context.Context, chunk []byte) error {
+			reasoning += string(chunk)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "The user greets me.", reasoning)
+	assert.Equal(t, "Hello!", resp.Choices[0].Content)
+	assert.Equal(t, []llms.ReasoningContent{{Text: "The user greets me.", Signature: "c2ln"}}, resp.Choices[0].Reasoning)
+}
+
+func TestConverseThinking(t *testing.T) {
+	t.Parallel()
+	options := llms.CallOptions{ThinkingBudget: 4096}
+	assert.Equal(t, int32(4096+defaultThinkingMaxTokens), aws.ToInt32(convertConverseInferenceConfig(options).MaxTokens))
+	fields, err := converseAdditionalModelRequestFields(options).MarshalSmithyDocument()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"thinking":{"type":"enabled","budget_tokens":4096}}`, string(fields))
+	assert.Nil(t, converseAdditionalModelRequestFields(llms.CallOptions{}))
+
+	// The reasoning is passed back in the assistant messages.
+	_, msgs, err := convertConverseMessages([]llms.MessageContent{{
+		Role: llms.ChatMessageTypeAI,
+		Parts: []llms.ContentPart{
+			llms.ReasoningContent{Text: "The user greets me.", Signature: "c2ln"},
+			llms.TextPart("Hello!"),
+		},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, &types.ContentBlockMemberReasoningContent{
+		Value: &types.ReasoningContentBlockMemberReasoningText{Value: types.ReasoningTextBlock{
+			Text:      aws.String("The user greets me."),
+			Signature: aws.String("c2ln"),
+		}},
+	}, msgs[0].Content[0])
+}
@@ -193,6 +193,7 @@ func createAnthropicCompletion(ctx context.Context,
 	}
 	return &llms.ContentResponse{
 		Choices: Contentchoices,
+		Usage:   llms.NewUsage(output.Usage.InputTokens, output.Usage.OutputTokens),
 	}, nil
 }
 
@@ -241,6 +242,7 @@ func parseStreamingCompletionResponse(ctx context.Context, client *bedrockruntim
 	defer stream.Close()
 
 	contentchoices := []*llms.ContentChoice{{GenerationInfo: map[string]interface{}{}}}
+	var inputTokens, outputTokens int
 	for e := range stream.Events() {
 		if err = stream.Err(); err != nil {
 			return nil, err
@@ -255,7 +257,8 @@ func parseStreamingCompletionResponse(ctx context.Context, client *bedrockruntim
 
 			switch resp.Type {
 			case "message_start":
-				contentchoices[0].GenerationInfo["input_tokens"] = resp.Message.Usage.InputTokens
+				inputTokens = resp.Message.Usage.InputTokens
+				contentchoices[0].GenerationInfo["input_tokens"] = inputTokens
 			case "content_block_delta":
 				if err = options.StreamingFunc(ctx, []byte(resp.Delta.Text)); err != nil {
 					return nil, err
@@ -263,7 +266,8 @@ func parseStreamingCompletionResponse(ctx context.Context, client *bedrockruntim
 				contentchoices[0].Content += resp.Delta.Text
 			case "message_delta":
 				contentchoices[0].StopReason = resp.Delta.StopReason
-				contentchoices[0].GenerationInfo["output_tokens"] = resp.Usage.OutputTokens
+				outputTokens = resp.Usage.OutputTokens
+				contentchoices[0].GenerationInfo["output_tokens"] = outputTokens
 			}
 		}
 	}
@@ -273,6 +277,7 @@ func parseStreamingCompletionResponse(ctx context.Context, client *bedrockruntim
 
 	return &llms.ContentResponse{
 		Choices: contentchoices,
+		Usage:   llms.NewUsage(inputTokens, outputTokens),
 	}, nil
 }
 
@@ -39,6 +39,33 @@ const (
 	// Languages: English (GA), Multilingual in 100+ languages (Preview).
 	ModelAmazonTitanTextExpressV1 = "amazon.titan-text-express-v1"
 
+	// Amazon Nova Micro is a text only model delivering the lowest latency
+	// responses at a very low cost.
+	//
+	// Nova models are only supported with the Converse API.
+	//
+	// Max tokens: 128k
+	// Languages: 200+ languages.
+	ModelAmazonNovaMicroV1 = "amazon.nova-micro-v1:0"
+
+	// Amazon Nova Lite is a very low cost multimodal model processing image,
+	// video and text inputs fast.
+	//
+	// Nova models are only supported with the Converse API.
+	//
+	// Max tokens: 300k
+	// Languages: 200+ languages.
+	ModelAmazonNovaLiteV1 = "amazon.nova-lite-v1:0"
+
+	// Amazon Nova Pro is a highly capable multimodal model with the best
+	// combination of accuracy, speed and cost for a wide range of tasks.
+	//
+	// Nova models are only supported with the Converse API.
+	//
+	// Max tokens: 300k
+	// Languages: 200+ languages.
+	ModelAmazonNovaProV1 = "amazon.nova-pro-v1:0"
+
 	// Claude 3 Sonnet by Anthropic strikes the ideal balance between intelligence and
 	// speed—particularly for enterprise workloads. It offers maximum utility at a lower
 	// price than competitors, and is engineered to be the dependable, high-endurance
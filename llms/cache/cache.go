@@ -2,9 +2,6 @@ package cache
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
 
 	"github.com/tmc/langchaingo/llms"
 )
@@ -19,19 +16,17 @@ type Backend interface {
 
 // Cacher is an LLM wrapper that caches the responses from the LLM.
 type Cacher struct {
-	llm   llms.Model
-	cache Backend
+	model *llms.CachedModel
 }
 
 // assert that `Cacher` implements the `llms.Model` interface.
 var _ llms.Model = (*Cacher)(nil)
 
 // New wraps a Model and adds caching capabilities using the provided
-// cache backend.
-func New(llm llms.Model, backend Backend) *Cacher {
+// cache backend, and the semantic cache of the options, if any.
+func New(llm llms.Model, backend Backend, opts ...llms.CachedModelOption) *Cacher {
 	return &Cacher{
-		llm:   llm,
-		cache: backend,
+		model: llms.NewCachedModel(llm, backend, opts...),
 	}
 }
 
@@ -50,47 +45,11 @@ func (c *Cacher) Call(ctx context.Context, prompt string, options ...llms.CallOp
 // messages. It's the most general interface for multi-modal LLMs that support
 // chat-like interactions.
 func (c *Cacher) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
-	var opts llms.CallOptions
-	for _, opt := range options {
-		opt(&opts)
-	}
-
-	key, err := hashKeyForCache(messages, opts)
-	if err != nil {
-		return nil, err
-	}
-
-	if response := c.cache.Get(ctx, key); response != nil {
-		if opts.StreamingFunc != nil && len(response.Choices) > 0 {
-			// only stream the first choice.
-			if err := opts.StreamingFunc(ctx, []byte(response.Choices[0].Content)); err != nil {
-				return nil, err
-			}
-		}
-
-		return response, nil
-	}
-
-	response, err := c.llm.GenerateContent(ctx, messages, options...)
-	if err != nil {
-		return nil, err
-	}
-
-	c.cache.Put(ctx, key, response)
-
-	return response, nil
+	return c.model.GenerateContent(ctx, messages, options...)
 }
 
 // hashKeyForCache is a helper function that generates a unique key for a given
 // set of messages and call options.
 func hashKeyForCache(messages []llms.MessageContent, opts llms.CallOptions) (string, error) {
-	hash := sha256.New()
-	enc := json.NewEncoder(hash)
-	if err := enc.Encode(messages); err != nil {
-		return "", err
-	}
-	if err := enc.Encode(opts); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(hash.Sum(nil)), nil
+	return llms.CacheKey(messages, opts)
 }
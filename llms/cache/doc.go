@@ -1,4 +1,5 @@
 // Package cache provides a generic wrapper that adds caching to a `llms.Model`. Responses are
 // cached under a key calculated based on the provided messages and options. Different cache
-// backends can be used when creating the wrapper.
+// backends can be used when creating the wrapper. Responses to similar prompts can also be
+// returned with a Semantic cache backed by a vector store.
 package cache
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+const (
+	// ScopeMetadataKey is the metadata key of the scope of the cached
+	// prompts, which the stores used by Semantic must support filtering on.
+	ScopeMetadataKey = "cache_scope"
+	// ResponseMetadataKey is the metadata key of the JSON encoded response
+	// to the cached prompts.
+	ResponseMetadataKey = "cache_response"
+
+	defaultSimilarityThreshold = 0.95
+)
+
+// Semantic is an llms.SemanticCache backed by a vector store, returning the
+// response to the most similar cached prompt when its similarity score is at
+// least the threshold. Set it with llms.WithSemanticCache.
+type Semantic struct {
+	store     vectorstores.VectorStore
+	threshold float32
+}
+
+var _ llms.SemanticCache = &Semantic{}
+
+// SemanticOption is a function configuring a Semantic cache.
+type SemanticOption func(*Semantic)
+
+// WithSimilarityThreshold sets the minimum similarity score of a cached
+// prompt for its response to be returned, 0.95 by default. Lower thresholds
+// return more responses to prompts that only look alike.
+func WithSimilarityThreshold(threshold float32) SemanticOption {
+	return func(s *Semantic) {
+		s.threshold = threshold
+	}
+}
+
+// NewSemantic creates a semantic cache storing the prompts in store, along
+// with their scope and response in their metadata.
+func NewSemantic(store vectorstores.VectorStore, opts ...SemanticOption) *Semantic {
+	s := &Semantic{store: store, threshold: defaultSimilarityThreshold}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Lookup returns the response to the most similar prompt of scope. Errors are
+// ignored, and reported as cache misses.
+func (s *Semantic) Lookup(ctx context.Context, scope, prompt string) *llms.ContentResponse {
+	docs, err := s.store.SimilaritySearch(ctx, prompt, 1,
+		vectorstores.WithScoreThreshold(s.threshold),
+		vectorstores.WithFilters(map[string]any{ScopeMetadataKey: scope}),
+	)
+	if err != nil || len(docs) == 0 {
+		return nil
+	}
+	// Don't rely on the filtering of the store.
+	if docs[0].Metadata[ScopeMetadataKey] != scope {
+		return nil
+	}
+	encoded, ok := docs[0].Metadata[ResponseMetadataKey].(string)
+	if !ok {
+		return nil
+	}
+	var response llms.ContentResponse
+	if err := json.Unmarshal([]byte(encoded), &response); err != nil {
+		return nil
+	}
+	return &response
+}
+
+// Store adds the prompt to the store. Errors are ignored.
+func (s *Semantic) Store(ctx context.Context, scope, prompt string, response *llms.ContentResponse) {
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	_, _ = s.store.AddDocuments(ctx, []schema.Document{{
+		PageContent: prompt,
+		Metadata: map[string]any{
+			ScopeMetadataKey:    scope,
+			ResponseMetadataKey: string(encoded),
+		},
+	}})
+}
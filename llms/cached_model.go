@@ -0,0 +1,189 @@
+package llms
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// ResponseCache is the interface of the exact-match caches of
+// NewCachedModel, such as the backends of the llms/cache package.
+type ResponseCache interface {
+	// Get returns the response cached under key, nil if there is none.
+	Get(ctx context.Context, key string) *ContentResponse
+	// Put caches response under key.
+	Put(ctx context.Context, key string, response *ContentResponse)
+}
+
+// SemanticCache is the interface of the caches returning the response of a
+// similar prompt, such as a vector store backed cache. Responses are only
+// shared between calls of the same scope, the hash of their options.
+type SemanticCache interface {
+	// Lookup returns the response cached for a prompt similar enough to
+	// prompt, nil if there is none.
+	Lookup(ctx context.Context, scope, prompt string) *ContentResponse
+	// Store caches the response to prompt.
+	Store(ctx context.Context, scope, prompt string, response *ContentResponse)
+}
+
+// CachedModel is a Model caching the responses of the model it wraps, to cut
+// the latency and cost of repeated prompts. Responses are cached under the
+// messages and the call options, including the model and temperature, so
+// calls with different options don't share responses. As the default model
+// of the wrapped model isn't part of the key, a cache must not be shared by
+// models with different defaults.
+type CachedModel struct {
+	model    Model
+	cache    ResponseCache
+	semantic SemanticCache
+}
+
+var _ Model = &CachedModel{}
+
+// CachedModelOption is a function configuring a CachedModel.
+type CachedModelOption func(*CachedModel)
+
+// WithSemanticCache also looks up the responses of similar prompts in cache
+// when there is no exact match. Only the calls whose messages are all text
+// are cached semantically.
+func WithSemanticCache(cache SemanticCache) CachedModelOption {
+	return func(m *CachedModel) {
+		m.semantic = cache
+	}
+}
+
+// NewCachedModel wraps model, caching its responses in cache.
+func NewCachedModel(model Model, cache ResponseCache, opts ...CachedModelOption) *CachedModel {
+	m := &CachedModel{model: model, cache: cache}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// GenerateContent returns the cached response to messages, generating it
+// with the wrapped model on cache misses. The reasoning, content and tool
+// calls of the first choice of cached responses are streamed in single
+// chunks.
+func (m *CachedModel) GenerateContent(ctx context.Context, messages []MessageContent, options ...CallOption) (*ContentResponse, error) { //nolint:lll
+	var opts CallOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	key, err := CacheKey(messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	response := m.cache.Get(ctx, key)
+
+	var scope, prompt string
+	semantic := false
+	if m.semantic != nil {
+		if prompt, semantic = textPrompt(messages); semantic {
+			if scope, err = CacheKey(nil, opts); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if response == nil && semantic {
+		response = m.semantic.Lookup(ctx, scope, prompt)
+	}
+
+	if response != nil {
+		if len(response.Choices) > 0 {
+			if err := streamCached(ctx, opts, response.Choices[0]); err != nil {
+				return nil, err
+			}
+		}
+		return response, nil
+	}
+
+	response, err = m.model.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	m.cache.Put(ctx, key, response)
+	if semantic {
+		m.semantic.Store(ctx, scope, prompt, response)
+	}
+	return response, nil
+}
+
+// streamCached streams the reasoning, content and tool calls of the cached
+// choice to the streaming functions of opts, each in a single chunk.
+func streamCached(ctx context.Context, opts CallOptions, choice *ContentChoice) error {
+	if opts.StreamingReasoningFunc != nil {
+		for _, reasoning := range choice.Reasoning {
+			if reasoning.Text == "" {
+				continue
+			}
+			if err := opts.StreamingReasoningFunc(ctx, []byte(reasoning.Text)); err != nil {
+				return err
+			}
+		}
+	}
+	if opts.StreamingFunc != nil && choice.Content != "" {
+		if err := opts.StreamingFunc(ctx, []byte(choice.Content)); err != nil {
+			return err
+		}
+	}
+	if opts.StreamingToolCallFunc != nil {
+		for i, toolCall := range choice.ToolCalls {
+			delta := ToolCallDelta{Index: i, ID: toolCall.ID}
+			if toolCall.FunctionCall != nil {
+				delta.Name = toolCall.FunctionCall.Name
+				delta.ArgumentsDelta = toolCall.FunctionCall.Arguments
+			}
+			if err := opts.StreamingToolCallFunc(ctx, delta); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Call implements the Model interface.
+func (m *CachedModel) Call(ctx context.Context, prompt string, options ...CallOption) (string, error) {
+	return GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// Capabilities reports the capabilities of the wrapped model.
+func (m *CachedModel) Capabilities() ModelCapabilities {
+	return Capabilities(m.model)
+}
+
+// CacheKey returns the key of the response to messages with the call
+// options opts, the hash of their JSON encoding.
+func CacheKey(messages []MessageContent, opts CallOptions) (string, error) {
+	hash := sha256.New()
+	enc := json.NewEncoder(hash)
+	if err := enc.Encode(messages); err != nil {
+		return "", err
+	}
+	if err := enc.Encode(opts); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// textPrompt returns the text of messages with their roles, and false if a
+// message has non-text parts.
+func textPrompt(messages []MessageContent) (string, bool) {
+	var b strings.Builder
+	for _, m := range messages {
+		for _, part := range m.Parts {
+			text, ok := part.(TextContent)
+			if !ok {
+				return "", false
+			}
+			b.WriteString(string(m.Role))
+			b.WriteString(": ")
+			b.WriteString(text.Text)
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), b.Len() > 0
+}
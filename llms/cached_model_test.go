@@ -0,0 +1,165 @@
+package llms
+
+import (
+	"context"
+	"testing"
+)
+
+// countingModel responds with the number of calls made to it.
+type countingModel struct {
+	silentModel
+	calls int
+}
+
+func (m *countingModel) GenerateContent(context.Context, []MessageContent, ...CallOption) (*ContentResponse, error) {
+	m.calls++
+	return &ContentResponse{Choices: []*ContentChoice{{Content: string(rune('0' + m.calls))}}}, nil
+}
+
+type mapCache map[string]*ContentResponse
+
+func (c mapCache) Get(_ context.Context, key string) *ContentResponse {
+	return c[key]
+}
+
+func (c mapCache) Put(_ context.Context, key string, response *ContentResponse) {
+	c[key] = response
+}
+
+// prefixCache is a semantic cache matching the prompts with the same first
+// word.
+type prefixCache map[string]*ContentResponse
+
+func (c prefixCache) Lookup(_ context.Context, scope, prompt string) *ContentResponse {
+	return c[scope+firstWord(prompt)]
+}
+
+func (c prefixCache) Store(_ context.Context, scope, prompt string, response *ContentResponse) {
+	c[scope+firstWord(prompt)] = response
+}
+
+func firstWord(prompt string) string {
+	// Skip the role.
+	word, _, _ := cutWord(prompt)
+	word, _, _ = cutWord(prompt[len(word)+1:])
+	return word
+}
+
+func cutWord(s string) (string, string, bool) {
+	for i, r := range s {
+		if r == ' ' {
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+func TestCachedModel(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	model := &countingModel{}
+	cached := NewCachedModel(model, mapCache{})
+
+	for _, want := range []string{"1", "1"} {
+		got, err := GenerateFromSinglePrompt(ctx, cached, "hello")
+		if err != nil || got != want {
+			t.Fatalf("expected %q, got %q, %v", want, got, err)
+		}
+	}
+	// Different options aren't served from the cache.
+	got, err := GenerateFromSinglePrompt(ctx, cached, "hello", WithTemperature(0.5))
+	if err != nil || got != "2" {
+		t.Fatalf("expected a new response for another temperature, got %q, %v", got, err)
+	}
+	got, err = GenerateFromSinglePrompt(ctx, cached, "hello", WithModel("other"))
+	if err != nil || got != "3" {
+		t.Fatalf("expected a new response for another model, got %q, %v", got, err)
+	}
+
+	var streamed string
+	got, err = GenerateFromSinglePrompt(ctx, cached, "hello", WithTemperature(0.5), WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		streamed += string(chunk)
+		return nil
+	}))
+	if err != nil || got != "2" || streamed != "2" {
+		t.Fatalf("expected the cached response to be streamed, got %q, %q, %v", got, streamed, err)
+	}
+	if model.calls != 3 {
+		t.Errorf("expected 3 calls, got %d", model.calls)
+	}
+}
+
+// toolCallingModel responds with some reasoning and a tool call.
+type toolCallingModel struct {
+	silentModel
+}
+
+func (toolCallingModel) GenerateContent(context.Context, []MessageContent, ...CallOption) (*ContentResponse, error) {
+	return &ContentResponse{Choices: []*ContentChoice{{
+		Reasoning: []ReasoningContent{{Text: "It's sunny."}},
+		ToolCalls: []ToolCall{{
+			ID:           "call_1",
+			Type:         "function",
+			FunctionCall: &FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`},
+		}},
+	}}}, nil
+}
+
+func TestCachedModelStreamsToolCallsAndReasoning(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	cached := NewCachedModel(toolCallingModel{}, mapCache{})
+	messages := []MessageContent{TextParts(ChatMessageTypeHuman, "weather in Paris?")}
+	if _, err := cached.GenerateContent(ctx, messages); err != nil {
+		t.Fatal(err)
+	}
+
+	var reasoning string
+	var deltas []ToolCallDelta
+	_, err := cached.GenerateContent(ctx, messages,
+		WithStreamingReasoningFunc(func(_ context.Context, chunk []byte) error {
+			reasoning += string(chunk)
+			return nil
+		}),
+		WithStreamingToolCallFunc(func(_ context.Context, delta ToolCallDelta) error {
+			deltas = append(deltas, delta)
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ToolCallDelta{ID: "call_1", Name: "weather", ArgumentsDelta: `{"city":"Paris"}`}
+	if reasoning != "It's sunny." || len(deltas) != 1 || deltas[0] != want {
+		t.Fatalf("expected the cached reasoning and tool call to be streamed, got %q, %+v", reasoning, deltas)
+	}
+}
+
+func TestCachedModelSemantic(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	model := &countingModel{}
+	semantic := prefixCache{}
+	cached := NewCachedModel(model, mapCache{}, WithSemanticCache(semantic))
+
+	got, err := GenerateFromSinglePrompt(ctx, cached, "weather in Paris?")
+	if err != nil || got != "1" {
+		t.Fatalf("expected %q, got %q, %v", "1", got, err)
+	}
+	got, err = GenerateFromSinglePrompt(ctx, cached, "weather in Paris today?")
+	if err != nil || got != "1" {
+		t.Fatalf("expected the response to the similar prompt, got %q, %v", got, err)
+	}
+	got, err = GenerateFromSinglePrompt(ctx, cached, "weather in Paris today?", WithTemperature(1))
+	if err != nil || got != "2" {
+		t.Fatalf("expected similar prompts with other options not to match, got %q, %v", got, err)
+	}
+
+	// Messages with non-text parts aren't cached semantically.
+	image := []MessageContent{{Role: ChatMessageTypeHuman, Parts: []ContentPart{TextPart("photo"), ImageURLPart("https://example.com/a.png")}}}
+	if _, err := cached.GenerateContent(ctx, image); err != nil {
+		t.Fatal(err)
+	}
+	if len(semantic) != 2 {
+		t.Errorf("expected 2 semantic entries, got %d", len(semantic))
+	}
+}
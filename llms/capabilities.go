@@ -0,0 +1,118 @@
+package llms
+
+import (
+	"strings"
+	"sync"
+)
+
+// ModelCapabilities describes the features a model supports, so that generic
+// code can adapt to the model, e.g. by not offering tools to a model without
+// tool calling, instead of failing at runtime.
+type ModelCapabilities struct {
+	// MaxContextTokens is the size of the context window of the model, 0 if
+	// unknown.
+	MaxContextTokens int
+	// ToolCalling is whether the model supports WithTools.
+	ToolCalling bool
+	// JSONMode is whether the model supports WithJSONMode.
+	JSONMode bool
+	// StructuredOutput is whether the model natively constrains its
+	// response to the schema of WithResponseSchema.
+	StructuredOutput bool
+	// Vision is whether the model accepts image parts.
+	Vision bool
+	// Streaming is whether the model supports WithStreamingFunc.
+	Streaming bool
+	// Reasoning is whether the model reasons before its answer, see
+	// WithReasoningEffort and WithThinkingBudget.
+	Reasoning bool
+}
+
+// CapabilityReporter is implemented by the models reporting their
+// capabilities.
+type CapabilityReporter interface {
+	Capabilities() ModelCapabilities
+}
+
+// Capabilities returns the capabilities of model, which are unknown, i.e.
+// zero, when the model doesn't report them.
+func Capabilities(model Model) ModelCapabilities {
+	if reporter, ok := model.(CapabilityReporter); ok {
+		return reporter.Capabilities()
+	}
+	return ModelCapabilities{}
+}
+
+// nolint:gochecknoglobals
+var (
+	capabilityOverridesMu sync.RWMutex
+	capabilityOverrides   = map[string]ModelCapabilities{}
+)
+
+// nolint:gochecknoglobals
+var modelCapabilities = map[string]ModelCapabilities{
+	"gpt-3.5-turbo":     {MaxContextTokens: 16385, ToolCalling: true, JSONMode: true, Streaming: true},
+	"gpt-4":             {MaxContextTokens: 8192, ToolCalling: true, Streaming: true},
+	"gpt-4-32k":         {MaxContextTokens: 32768, ToolCalling: true, Streaming: true},
+	"gpt-4-turbo":       {MaxContextTokens: 128000, ToolCalling: true, JSONMode: true, Vision: true, Streaming: true},
+	"gpt-4o":            {MaxContextTokens: 128000, ToolCalling: true, JSONMode: true, StructuredOutput: true, Vision: true, Streaming: true},
+	"o1":                {MaxContextTokens: 200000, ToolCalling: true, JSONMode: true, StructuredOutput: true, Vision: true, Streaming: true, Reasoning: true},
+	"o3":                {MaxContextTokens: 200000, ToolCalling: true, JSONMode: true, StructuredOutput: true, Vision: true, Streaming: true, Reasoning: true},
+	"o3-mini":           {MaxContextTokens: 200000, ToolCalling: true, JSONMode: true, StructuredOutput: true, Streaming: true, Reasoning: true},
+	"o4-mini":           {MaxContextTokens: 200000, ToolCalling: true, JSONMode: true, StructuredOutput: true, Vision: true, Streaming: true, Reasoning: true},
+	"claude-2":          {MaxContextTokens: 100000, Streaming: true},
+	"claude-3":          {MaxContextTokens: 200000, ToolCalling: true, StructuredOutput: true, Vision: true, Streaming: true},
+	"claude-3-7-sonnet": {MaxContextTokens: 200000, ToolCalling: true, StructuredOutput: true, Vision: true, Streaming: true, Reasoning: true},
+	"claude-sonnet-4":   {MaxContextTokens: 200000, ToolCalling: true, StructuredOutput: true, Vision: true, Streaming: true, Reasoning: true},
+	"claude-opus-4":     {MaxContextTokens: 200000, ToolCalling: true, StructuredOutput: true, Vision: true, Streaming: true, Reasoning: true},
+	"gemini-1.0-pro":    {MaxContextTokens: 32760, ToolCalling: true, Streaming: true},
+	"gemini-pro":        {MaxContextTokens: 32760, ToolCalling: true, Streaming: true},
+	"gemini-1.5-flash":  {MaxContextTokens: 1048576, ToolCalling: true, JSONMode: true, StructuredOutput: true, Vision: true, Streaming: true},
+	"gemini-1.5-pro":    {MaxContextTokens: 2097152, ToolCalling: true, JSONMode: true, StructuredOutput: true, Vision: true, Streaming: true},
+	"gemini-2.0-flash":  {MaxContextTokens: 1048576, ToolCalling: true, JSONMode: true, StructuredOutput: true, Vision: true, Streaming: true},
+	"grok-3":            {MaxContextTokens: 131072, ToolCalling: true, JSONMode: true, StructuredOutput: true, Streaming: true},
+	"grok-3-mini":       {MaxContextTokens: 131072, ToolCalling: true, JSONMode: true, StructuredOutput: true, Streaming: true, Reasoning: true},
+	"grok-4":            {MaxContextTokens: 256000, ToolCalling: true, JSONMode: true, StructuredOutput: true, Vision: true, Streaming: true},
+	"open-mistral-7b":   {MaxContextTokens: 32768, JSONMode: true, Streaming: true},
+	"mistral-small":     {MaxContextTokens: 32768, ToolCalling: true, JSONMode: true, StructuredOutput: true, Streaming: true},
+	"mistral-large":     {MaxContextTokens: 131072, ToolCalling: true, JSONMode: true, StructuredOutput: true, Streaming: true},
+}
+
+// RegisterModelCapabilities overrides the capabilities reported for the
+// models named modelName or whose name starts with it, e.g. for fine-tuned or
+// self-hosted models. The longest matching name wins.
+func RegisterModelCapabilities(modelName string, capabilities ModelCapabilities) {
+	capabilityOverridesMu.Lock()
+	defer capabilityOverridesMu.Unlock()
+	capabilityOverrides[modelName] = capabilities
+}
+
+// LookupModelCapabilities returns the capabilities of the model named
+// modelName, or of the longest known name it starts with, e.g. "gpt-4o" for
+// "gpt-4o-2024-08-06", and whether they are known. The capabilities
+// registered with RegisterModelCapabilities take precedence.
+func LookupModelCapabilities(modelName string) (ModelCapabilities, bool) {
+	capabilityOverridesMu.RLock()
+	capabilities, ok := longestPrefixMatch(capabilityOverrides, modelName)
+	capabilityOverridesMu.RUnlock()
+	if ok {
+		return capabilities, true
+	}
+	return longestPrefixMatch(modelCapabilities, modelName)
+}
+
+// longestPrefixMatch returns the value of the longest key of m modelName
+// starts with.
+func longestPrefixMatch(m map[string]ModelCapabilities, modelName string) (ModelCapabilities, bool) {
+	var (
+		match ModelCapabilities
+		found string
+		ok    bool
+	)
+	for name, capabilities := range m {
+		if strings.HasPrefix(modelName, name) && len(name) > len(found) {
+			match, found, ok = capabilities, name, true
+		}
+	}
+	return match, ok
+}
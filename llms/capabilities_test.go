@@ -0,0 +1,69 @@
+package llms
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLookupModelCapabilities(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		model  string
+		want   ModelCapabilities
+		wantOK bool
+	}{
+		{"gpt-4o-2024-08-06", modelCapabilities["gpt-4o"], true},
+		{"gpt-4-0613", modelCapabilities["gpt-4"], true},
+		{"claude-3-5-sonnet-20240620", modelCapabilities["claude-3"], true},
+		{"claude-3-7-sonnet-20250219", modelCapabilities["claude-3-7-sonnet"], true},
+		{"o3-mini-2025-01-31", modelCapabilities["o3-mini"], true},
+		{"gemini-1.5-pro-002", modelCapabilities["gemini-1.5-pro"], true},
+		{"unknown", ModelCapabilities{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := LookupModelCapabilities(tt.model)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("LookupModelCapabilities(%q) = %+v, %v, want %+v, %v", tt.model, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestRegisterModelCapabilities(t *testing.T) {
+	t.Parallel()
+	override := ModelCapabilities{MaxContextTokens: 4096, Streaming: true}
+	RegisterModelCapabilities("gpt-4o-test-finetune", override)
+	if got, _ := LookupModelCapabilities("gpt-4o-test-finetune:v2"); got != override {
+		t.Errorf("expected the override %+v, got %+v", override, got)
+	}
+	if got, _ := LookupModelCapabilities("gpt-4o-mini"); got != modelCapabilities["gpt-4o"] {
+		t.Errorf("expected the other models to be unaffected, got %+v", got)
+	}
+}
+
+type reportingModel struct {
+	Model
+}
+
+func (reportingModel) Capabilities() ModelCapabilities {
+	return ModelCapabilities{Vision: true}
+}
+
+type silentModel struct{}
+
+func (silentModel) GenerateContent(context.Context, []MessageContent, ...CallOption) (*ContentResponse, error) {
+	return &ContentResponse{}, nil
+}
+
+func (silentModel) Call(context.Context, string, ...CallOption) (string, error) {
+	return "", nil
+}
+
+func TestCapabilities(t *testing.T) {
+	t.Parallel()
+	if got := Capabilities(reportingModel{}); !got.Vision {
+		t.Errorf("expected the reported capabilities, got %+v", got)
+	}
+	if got := Capabilities(silentModel{}); got != (ModelCapabilities{}) {
+		t.Errorf("expected unknown capabilities, got %+v", got)
+	}
+}
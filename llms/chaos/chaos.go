@@ -0,0 +1,125 @@
+// Package chaos provides an llms.Model wrapper injecting latency, errors and
+// truncated streams, to verify retry, fallback and circuit-breaker behavior
+// in tests and staging environments.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+var (
+	// ErrInjectedFault is the default error returned for injected failures.
+	ErrInjectedFault = errors.New("chaos: injected fault")
+	// ErrStreamTruncated is returned by the streaming function of a call
+	// whose stream is truncated.
+	ErrStreamTruncated = errors.New("chaos: stream truncated")
+)
+
+// LLM wraps a model and injects faults into its calls.
+type LLM struct {
+	model llms.Model
+
+	latency       time.Duration
+	errorRate     float64
+	err           error
+	truncateRate  float64
+	truncateAfter int
+	random        func() float64
+}
+
+var _ llms.Model = (*LLM)(nil)
+
+// Option is a function that configures an LLM.
+type Option func(*LLM)
+
+// WithLatency delays every call by the given duration.
+func WithLatency(latency time.Duration) Option {
+	return func(l *LLM) {
+		l.latency = latency
+	}
+}
+
+// WithErrorRate makes the given fraction (0-1) of the calls fail.
+func WithErrorRate(rate float64) Option {
+	return func(l *LLM) {
+		l.errorRate = rate
+	}
+}
+
+// WithError sets the error returned for failed calls. Defaults to
+// ErrInjectedFault.
+func WithError(err error) Option {
+	return func(l *LLM) {
+		l.err = err
+	}
+}
+
+// WithTruncatedStreams stops the given fraction (0-1) of the streaming calls
+// after afterChunks chunks, with ErrStreamTruncated.
+func WithTruncatedStreams(rate float64, afterChunks int) Option {
+	return func(l *LLM) {
+		l.truncateRate = rate
+		l.truncateAfter = afterChunks
+	}
+}
+
+// New wraps model with the given faults.
+func New(model llms.Model, opts ...Option) *LLM {
+	l := &LLM{
+		model:  model,
+		err:    ErrInjectedFault,
+		random: rand.Float64, //nolint:gosec
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// GenerateContent calls the wrapped model unless a fault is injected.
+func (l *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	if l.latency > 0 {
+		select {
+		case <-time.After(l.latency):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if l.random() < l.errorRate {
+		return nil, l.err
+	}
+
+	if l.truncateRate > 0 && l.random() < l.truncateRate {
+		opts := llms.CallOptions{}
+		for _, opt := range options {
+			opt(&opts)
+		}
+		if opts.StreamingFunc != nil {
+			options = append(options, llms.WithStreamingFunc(truncate(opts.StreamingFunc, l.truncateAfter)))
+		}
+	}
+	return l.model.GenerateContent(ctx, messages, options...)
+}
+
+// Call calls the wrapped model unless a fault is injected.
+func (l *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, l, prompt, options...)
+}
+
+// truncate returns a streaming function forwarding the first n chunks to fn
+// and failing afterwards.
+func truncate(fn func(context.Context, []byte) error, n int) func(context.Context, []byte) error {
+	chunks := 0
+	return func(ctx context.Context, chunk []byte) error {
+		if chunks >= n {
+			return ErrStreamTruncated
+		}
+		chunks++
+		return fn(ctx, chunk)
+	}
+}
@@ -0,0 +1,87 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+type streamingLLM struct {
+	chunks []string
+}
+
+func (s streamingLLM) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	content := ""
+	for _, chunk := range s.chunks {
+		if opts.StreamingFunc != nil {
+			if err := opts.StreamingFunc(ctx, []byte(chunk)); err != nil {
+				return nil, err
+			}
+		}
+		content += chunk
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: content}}}, nil
+}
+
+func (s streamingLLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, s, prompt, options...)
+}
+
+func TestErrorRate(t *testing.T) {
+	t.Parallel()
+	errUnavailable := errors.New("unavailable")
+	llm := New(streamingLLM{chunks: []string{"ok"}}, WithErrorRate(0.5), WithError(errUnavailable))
+
+	llm.random = func() float64 { return 0.4 }
+	if _, err := llm.Call(context.Background(), "prompt"); !errors.Is(err, errUnavailable) {
+		t.Errorf("expected injected error, got %v", err)
+	}
+
+	llm.random = func() float64 { return 0.6 }
+	output, err := llm.Call(context.Background(), "prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "ok" {
+		t.Errorf("expected 'ok', got '%s'", output)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	t.Parallel()
+	llm := New(streamingLLM{chunks: []string{"ok"}}, WithLatency(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := llm.Call(ctx, "prompt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestTruncatedStreams(t *testing.T) {
+	t.Parallel()
+	llm := New(streamingLLM{chunks: []string{"a", "b", "c"}}, WithTruncatedStreams(1, 2))
+
+	var streamed []string
+	_, err := llm.Call(context.Background(), "prompt", llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		streamed = append(streamed, string(chunk))
+		return nil
+	}))
+	if !errors.Is(err, ErrStreamTruncated) {
+		t.Errorf("expected truncated stream, got %v", err)
+	}
+	if len(streamed) != 2 {
+		t.Errorf("expected 2 chunks before truncation, got %v", streamed)
+	}
+
+	// Calls without a streaming function are not affected.
+	if _, err := llm.Call(context.Background(), "prompt"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
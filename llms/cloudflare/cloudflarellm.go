@@ -62,6 +62,10 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(&opts)
 	}
 
+	if err := opts.RateLimiter.Wait(ctx, messages, opts); err != nil {
+		return nil, err
+	}
+
 	// Our input is a sequence of Message, each of which potentially has
 	// a sequence of Part that is text.
 	// We have to convert it to a format Cloudflare understands: []Message, which
@@ -0,0 +1,315 @@
+// Package fallback provides an llms.Model calling several providers, in
+// order or balanced by weight, and falling back to the next provider when a
+// call fails with a rate limit or server error, so that chat keeps working
+// during the outage of a single provider.
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrNoProvider is returned when the circuits of all the providers are open.
+var ErrNoProvider = errors.New("fallback: no provider available")
+
+// Provider is a model called by a Model, with its settings.
+type Provider struct {
+	// Model is the model of the provider.
+	Model llms.Model
+	// Name identifies the provider in the errors. It defaults to its
+	// position in the providers.
+	Name string
+	// Weight is the share of the calls first sent to the provider with
+	// WithWeightedRoundRobin. Weights below 1 count as 1.
+	Weight int
+	// Timeout bounds the calls to the provider, 0 for no limit. A call
+	// timing out falls back to the next provider.
+	Timeout time.Duration
+}
+
+// Model is an llms.Model calling its providers until one succeeds. Calls
+// failing with an error that isn't a rate limit, server or network error,
+// such as an invalid request, aren't retried with the next provider, nor are
+// streaming calls that already delivered chunks. It is safe for concurrent
+// use.
+type Model struct {
+	providers []Provider
+	breakers  []*breaker
+
+	roundRobin     bool
+	mu             sync.Mutex
+	currentWeights []int
+
+	failureThreshold int
+	cooldown         time.Duration
+	fallbackIf       func(error) bool
+	now              func() time.Time
+}
+
+var _ llms.Model = &Model{}
+
+// Option is a function configuring a Model.
+type Option func(*Model)
+
+// WithWeightedRoundRobin spreads the first attempts of the calls over the
+// providers by weight, instead of always starting with the first provider.
+// The next providers are tried in order on failures.
+func WithWeightedRoundRobin() Option {
+	return func(m *Model) {
+		m.roundRobin = true
+	}
+}
+
+// WithCircuitBreaker skips a provider for cooldown after failureThreshold
+// consecutive calls to it fell back, then lets a single call probe it. It
+// defaults to 5 failures and 30s; a threshold below 1 disables the breakers.
+func WithCircuitBreaker(failureThreshold int, cooldown time.Duration) Option {
+	return func(m *Model) {
+		m.failureThreshold = failureThreshold
+		m.cooldown = cooldown
+	}
+}
+
+// WithFallbackIf sets the function reporting whether a failed call falls
+// back to the next provider. It defaults to IsFallbackError.
+func WithFallbackIf(fallbackIf func(error) bool) Option {
+	return func(m *Model) {
+		m.fallbackIf = fallbackIf
+	}
+}
+
+// New returns a Model calling providers.
+func New(providers []Provider, opts ...Option) *Model {
+	m := &Model{
+		providers:        providers,
+		breakers:         make([]*breaker, len(providers)),
+		currentWeights:   make([]int, len(providers)),
+		failureThreshold: 5,
+		cooldown:         30 * time.Second,
+		fallbackIf:       IsFallbackError,
+		now:              time.Now,
+	}
+	for i := range m.breakers {
+		m.breakers[i] = &breaker{}
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// NewFromModels returns a Model calling models in order.
+func NewFromModels(models ...llms.Model) *Model {
+	providers := make([]Provider, len(models))
+	for i, model := range models {
+		providers[i] = Provider{Model: model}
+	}
+	return New(providers)
+}
+
+// GenerateContent calls the providers until one succeeds or fails with an
+// error not falling back. When they all fail, the errors of all the
+// attempts are returned.
+func (m *Model) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	var errs []error
+	for _, i := range m.order() {
+		if !m.breakers[i].allow(m.now(), m.failureThreshold, m.cooldown) {
+			continue
+		}
+		streamed := false
+		callOptions := options[:len(options):len(options)]
+		if opts.StreamingFunc != nil {
+			streamingFunc := opts.StreamingFunc
+			callOptions = append(callOptions, llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+				streamed = true
+				return streamingFunc(ctx, chunk)
+			}))
+		}
+		if opts.StreamingToolCallFunc != nil {
+			streamingToolCallFunc := opts.StreamingToolCallFunc
+			callOptions = append(callOptions, llms.WithStreamingToolCallFunc(func(ctx context.Context, delta llms.ToolCallDelta) error { //nolint:lll
+				streamed = true
+				return streamingToolCallFunc(ctx, delta)
+			}))
+		}
+		if opts.StreamingReasoningFunc != nil {
+			streamingReasoningFunc := opts.StreamingReasoningFunc
+			callOptions = append(callOptions, llms.WithStreamingReasoningFunc(func(ctx context.Context, chunk []byte) error {
+				streamed = true
+				return streamingReasoningFunc(ctx, chunk)
+			}))
+		}
+
+		response, err := m.call(ctx, i, messages, callOptions)
+		if err == nil {
+			m.breakers[i].success()
+			return response, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", m.name(i), err))
+		if ctx.Err() != nil {
+			return nil, errors.Join(errs...)
+		}
+		if !m.fallbackIf(err) {
+			// The provider is up, the request failed.
+			m.breakers[i].success()
+			return nil, errors.Join(errs...)
+		}
+		m.breakers[i].failure(m.now(), m.failureThreshold, m.cooldown)
+		if streamed {
+			return nil, errors.Join(errs...)
+		}
+	}
+	if len(errs) == 0 {
+		return nil, ErrNoProvider
+	}
+	return nil, errors.Join(errs...)
+}
+
+// Call implements the llms.Model interface.
+func (m *Model) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// Capabilities reports the capabilities of the first provider.
+func (m *Model) Capabilities() llms.ModelCapabilities {
+	if len(m.providers) == 0 {
+		return llms.ModelCapabilities{}
+	}
+	return llms.Capabilities(m.providers[0].Model)
+}
+
+// call calls provider i within its timeout.
+func (m *Model) call(ctx context.Context, i int, messages []llms.MessageContent, options []llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	provider := m.providers[i]
+	if provider.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, provider.Timeout)
+		defer cancel()
+	}
+	return provider.Model.GenerateContent(ctx, messages, options...)
+}
+
+// name returns the name of provider i.
+func (m *Model) name(i int) string {
+	if m.providers[i].Name != "" {
+		return m.providers[i].Name
+	}
+	return fmt.Sprintf("provider %d", i)
+}
+
+// order returns the indexes of the providers in the order of the attempts of
+// a call. With round robin, the first provider is picked with the smooth
+// weighted round robin of nginx, and the others follow it in order.
+func (m *Model) order() []int {
+	n := len(m.providers)
+	first := 0
+	if m.roundRobin && n > 0 {
+		m.mu.Lock()
+		total := 0
+		for i, provider := range m.providers {
+			weight := max(provider.Weight, 1)
+			m.currentWeights[i] += weight
+			total += weight
+			if m.currentWeights[i] > m.currentWeights[first] {
+				first = i
+			}
+		}
+		m.currentWeights[first] -= total
+		m.mu.Unlock()
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = (first + i) % n
+	}
+	return order
+}
+
+// breaker is the circuit breaker of a provider.
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// allow reports whether a call can be made to the provider. Once the
+// cooldown of an open circuit elapsed, a single call probes the provider
+// while the circuit stays open for another cooldown.
+func (b *breaker) allow(now time.Time, threshold int, cooldown time.Duration) bool {
+	if threshold < 1 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < threshold {
+		return true
+	}
+	if now.Before(b.openUntil) {
+		return false
+	}
+	b.openUntil = now.Add(cooldown)
+	return true
+}
+
+// success closes the circuit.
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+}
+
+// failure counts a failure, opening the circuit for cooldown at the
+// threshold.
+func (b *breaker) failure(now time.Time, threshold int, cooldown time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if threshold >= 1 && b.failures >= threshold {
+		b.openUntil = now.Add(cooldown)
+	}
+}
+
+// fallbackStatus matches the rate limit and server error status codes in
+// the messages of the errors of the providers, e.g. "status code: 429" or
+// "Error 503".
+var fallbackStatus = regexp.MustCompile(`(?i)\b(status|code|error|http)\b[^0-9]{0,12}\b(429|5\d\d)\b`)
+
+// IsFallbackError reports whether err is a rate limit error, a server error,
+// from the status code or status of the provider in its message, a network
+// error or a timeout of the provider, after which the call can be made to
+// another provider.
+func IsFallbackError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	msg := err.Error()
+	if fallbackStatus.MatchString(msg) {
+		return true
+	}
+	msg = strings.ToLower(msg)
+	for _, s := range []string{"rate limit", "too many requests", "resource_exhausted", "resource exhausted", "unavailable", "overloaded"} { //nolint:lll
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
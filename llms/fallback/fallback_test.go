@@ -0,0 +1,225 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// stubModel responds with its name, or fails with err.
+type stubModel struct {
+	name            string
+	err             error
+	chunks          int
+	toolCallChunks  int
+	reasoningChunks int
+	delay           time.Duration
+	calls           int
+}
+
+func (s *stubModel) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) { //nolint:lll
+	s.calls++
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	for i := 0; i < s.chunks && opts.StreamingFunc != nil; i++ {
+		if err := opts.StreamingFunc(ctx, []byte(s.name)); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < s.toolCallChunks && opts.StreamingToolCallFunc != nil; i++ {
+		if err := opts.StreamingToolCallFunc(ctx, llms.ToolCallDelta{Name: s.name}); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < s.reasoningChunks && opts.StreamingReasoningFunc != nil; i++ {
+		if err := opts.StreamingReasoningFunc(ctx, []byte(s.name)); err != nil {
+			return nil, err
+		}
+	}
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: s.name}}}, nil
+}
+
+func (s *stubModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, s, prompt, options...)
+}
+
+var (
+	errRateLimited = errors.New("API returned unexpected status code: 429: slow down")
+	errBadRequest  = errors.New("API returned unexpected status code: 400: invalid model")
+)
+
+func TestModelFallsBack(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	primary := &stubModel{name: "primary", err: errRateLimited}
+	secondary := &stubModel{name: "secondary"}
+	m := NewFromModels(primary, secondary)
+
+	got, err := m.Call(ctx, "hello")
+	if err != nil || got != "secondary" {
+		t.Fatalf("expected the secondary response, got %q, %v", got, err)
+	}
+
+	// Invalid requests don't fall back.
+	primary.err = errBadRequest
+	if _, err := m.Call(ctx, "hello"); !errors.Is(err, errBadRequest) {
+		t.Fatalf("expected the primary error, got %v", err)
+	}
+	if secondary.calls != 1 {
+		t.Errorf("expected 1 call to the secondary, got %d", secondary.calls)
+	}
+
+	// All the errors are returned when all the providers fail.
+	primary.err = errRateLimited
+	secondary.err = errors.New("Error 503: backend unavailable")
+	_, err = m.Call(ctx, "hello")
+	if !errors.Is(err, errRateLimited) || !errors.Is(err, secondary.err) {
+		t.Fatalf("expected the errors of both providers, got %v", err)
+	}
+}
+
+func TestModelTimeout(t *testing.T) {
+	t.Parallel()
+	slow := &stubModel{name: "slow", delay: time.Second}
+	fast := &stubModel{name: "fast"}
+	m := New([]Provider{{Model: slow, Timeout: 10 * time.Millisecond}, {Model: fast}})
+
+	got, err := m.Call(context.Background(), "hello")
+	if err != nil || got != "fast" {
+		t.Fatalf("expected the fast response, got %q, %v", got, err)
+	}
+}
+
+func TestModelStreamedCallsDontFallBack(t *testing.T) {
+	t.Parallel()
+	primary := &stubModel{name: "primary", err: errRateLimited, chunks: 1}
+	secondary := &stubModel{name: "secondary"}
+	m := NewFromModels(primary, secondary)
+
+	var streamed string
+	_, err := m.Call(context.Background(), "hello", llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		streamed += string(chunk)
+		return nil
+	}))
+	if !errors.Is(err, errRateLimited) || streamed != "primary" || secondary.calls != 0 {
+		t.Fatalf("expected the streamed call to fail, got %q, %v, %d calls", streamed, err, secondary.calls)
+	}
+}
+
+func TestModelStreamedToolCallsAndReasoningDontFallBack(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	primary := &stubModel{name: "primary", err: errRateLimited, toolCallChunks: 1}
+	secondary := &stubModel{name: "secondary"}
+	m := NewFromModels(primary, secondary)
+
+	var toolCalls []string
+	_, err := m.Call(ctx, "hello", llms.WithStreamingToolCallFunc(func(_ context.Context, delta llms.ToolCallDelta) error {
+		toolCalls = append(toolCalls, delta.Name)
+		return nil
+	}))
+	if !errors.Is(err, errRateLimited) || len(toolCalls) != 1 || secondary.calls != 0 {
+		t.Fatalf("expected the streamed tool call to fail, got %v, %v, %d calls", toolCalls, err, secondary.calls)
+	}
+
+	primary.toolCallChunks, primary.reasoningChunks = 0, 1
+	var reasoning string
+	_, err = m.Call(ctx, "hello", llms.WithStreamingReasoningFunc(func(_ context.Context, chunk []byte) error {
+		reasoning += string(chunk)
+		return nil
+	}))
+	if !errors.Is(err, errRateLimited) || reasoning != "primary" || secondary.calls != 0 {
+		t.Fatalf("expected the streamed reasoning to fail, got %q, %v, %d calls", reasoning, err, secondary.calls)
+	}
+}
+
+func TestModelCircuitBreaker(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	primary := &stubModel{name: "primary", err: errRateLimited}
+	secondary := &stubModel{name: "secondary"}
+	m := New([]Provider{{Model: primary}, {Model: secondary}}, WithCircuitBreaker(2, time.Minute))
+	now := time.Unix(0, 0)
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		if _, err := m.Call(ctx, "hello"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if primary.calls != 2 {
+		t.Fatalf("expected the circuit to open after 2 failures, got %d calls", primary.calls)
+	}
+
+	// A single call probes the provider after the cooldown.
+	now = now.Add(time.Minute)
+	primary.err = nil
+	got, err := m.Call(ctx, "hello")
+	if err != nil || got != "primary" {
+		t.Fatalf("expected the probe to succeed, got %q, %v", got, err)
+	}
+	got, err = m.Call(ctx, "hello")
+	if err != nil || got != "primary" {
+		t.Fatalf("expected the circuit to be closed, got %q, %v", got, err)
+	}
+
+	// Calls fail when all the circuits are open.
+	m = New([]Provider{{Model: primary}}, WithCircuitBreaker(1, time.Minute))
+	primary.err = errRateLimited
+	_, _ = m.Call(ctx, "hello")
+	if _, err := m.Call(ctx, "hello"); !errors.Is(err, ErrNoProvider) {
+		t.Fatalf("expected ErrNoProvider, got %v", err)
+	}
+}
+
+func TestModelWeightedRoundRobin(t *testing.T) {
+	t.Parallel()
+	a := &stubModel{name: "a"}
+	b := &stubModel{name: "b"}
+	m := New([]Provider{{Model: a, Weight: 3}, {Model: b, Weight: 1}}, WithWeightedRoundRobin())
+
+	for i := 0; i < 8; i++ {
+		if _, err := m.Call(context.Background(), "hello"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if a.calls != 6 || b.calls != 2 {
+		t.Fatalf("expected 6 and 2 calls, got %d and %d", a.calls, b.calls)
+	}
+}
+
+func TestIsFallbackError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errRateLimited, true},
+		{errors.New("googleapi: Error 503: The service is currently unavailable"), true},
+		{errors.New("anthropic: overloaded_error"), true},
+		{context.DeadlineExceeded, true},
+		{errBadRequest, false},
+		{context.Canceled, false},
+		{errors.New("invalid API key"), false},
+	}
+	for _, tt := range tests {
+		if got := IsFallbackError(tt.err); got != tt.want {
+			t.Errorf("IsFallbackError(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -22,8 +22,12 @@ func TextPart(s string) TextContent {
 }
 
 // BinaryPart creates a new BinaryContent from the given MIME type (e.g.
-// "image/png" and binary data).
+// "image/png" and binary data). The MIME type is detected from the data when
+// empty.
 func BinaryPart(mime string, data []byte) BinaryContent {
+	if mime == "" {
+		mime = DetectMIMEType(data)
+	}
 	return BinaryContent{
 		MIMEType: mime,
 		Data:     data,
@@ -73,6 +77,47 @@ func (iuc ImageURLContent) String() string {
 
 func (ImageURLContent) isPart() {}
 
+// FileURLPart creates a new FileURLContent from the given MIME type and URL.
+func FileURLPart(mime string, url string) FileURLContent {
+	return FileURLContent{
+		MIMEType: mime,
+		URL:      url,
+	}
+}
+
+// FileURLContent is content with an URL pointing to a file, such as a PDF
+// or audio file, that the provider fetches itself, e.g. a file uploaded to
+// the provider or in a cloud storage bucket.
+type FileURLContent struct {
+	MIMEType string
+	URL      string
+}
+
+func (fuc FileURLContent) String() string {
+	return fuc.URL
+}
+
+func (FileURLContent) isPart() {}
+
+// ReasoningContent is the reasoning of a model before its answer, such as the
+// thinking blocks of Claude or the reasoning of OpenAI-compatible servers.
+// Pass it back in the parts of the AI message to continue a conversation with
+// tool calls, as some providers require.
+type ReasoningContent struct {
+	Text string
+	// Signature verifies the reasoning when it's passed back to the model.
+	Signature string
+	// RedactedData is the encrypted reasoning that was redacted by the
+	// safety systems of the provider, with an empty Text.
+	RedactedData string
+}
+
+func (rc ReasoningContent) String() string {
+	return rc.Text
+}
+
+func (ReasoningContent) isPart() {}
+
 // BinaryContent is content holding some binary data with a MIME type.
 type BinaryContent struct {
 	MIMEType string
@@ -106,6 +151,22 @@ type ToolCall struct {
 
 func (ToolCall) isPart() {}
 
+// ToolCallDelta is a fragment of a tool call of a streaming response.
+// Concatenating the ArgumentsDelta of the fragments with the same Index gives
+// the arguments of the tool call.
+type ToolCallDelta struct {
+	// Index is the position of the tool call among the tool calls of the
+	// response.
+	Index int `json:"index"`
+	// ID is the unique identifier of the tool call, set on its first
+	// fragment.
+	ID string `json:"id,omitempty"`
+	// Name is the name of the called function, set on its first fragment.
+	Name string `json:"name,omitempty"`
+	// ArgumentsDelta is the next part of the JSON encoded arguments.
+	ArgumentsDelta string `json:"arguments_delta,omitempty"`
+}
+
 // ToolCallResponse is the response returned by a tool call.
 type ToolCallResponse struct {
 	// ToolCallID is the ID of the tool call this response is for.
@@ -122,6 +183,11 @@ func (ToolCallResponse) isPart() {}
 // It can potentially return multiple content choices.
 type ContentResponse struct {
 	Choices []*ContentChoice
+
+	// Usage is the number of tokens consumed by the call, for all the
+	// choices. Use ResponseUsage to also read the usage of models only
+	// reporting it in the generation info of the choices.
+	Usage Usage
 }
 
 // ContentChoice is one of the response choices returned by GenerateContent
@@ -143,6 +209,34 @@ type ContentChoice struct {
 
 	// ToolCalls is a list of tool calls the model asks to invoke.
 	ToolCalls []ToolCall
+
+	// SafetyRatings are the ratings of the content in the harm categories,
+	// for the models reporting them.
+	SafetyRatings []SafetyRating
+
+	// Citations are the sources the content is attributed to, for the models
+	// reporting them.
+	Citations []Citation
+
+	// Reasoning is the reasoning of the model before the content, for the
+	// models reporting it.
+	Reasoning []ReasoningContent
+
+	// Logprobs are the log probabilities of the tokens of the content, when
+	// requested with WithLogprobs.
+	Logprobs []TokenLogprob
+}
+
+// TokenLogprob is the log probability of a generated token.
+type TokenLogprob struct {
+	Token   string
+	Logprob float64
+	// Bytes is the UTF-8 encoding of the token, for the tokens that are
+	// only part of a character.
+	Bytes []byte
+	// TopLogprobs are the most likely tokens at the position of the token,
+	// and their log probabilities.
+	TopLogprobs []TokenLogprob
 }
 
 // TextParts is a helper function to create a MessageContent with a role and a
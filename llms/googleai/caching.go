@@ -0,0 +1,160 @@
+package googleai
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/tmc/langchaingo/llms"
+	"google.golang.org/api/iterator"
+)
+
+// CachedContent is content cached server-side, such as a long document,
+// which calls reference by name with llms.WithCachedContent instead of
+// sending it again.
+type CachedContent struct {
+	// Name is the resource name of the cached content.
+	Name string
+	// Model is the model the content is cached for. The calls using the
+	// cached content must use this model.
+	Model string
+	// ExpireTime is when the cached content is deleted.
+	ExpireTime time.Time
+	// CreateTime is when the cached content was created.
+	CreateTime time.Time
+	// UpdateTime is when the cached content was last updated.
+	UpdateTime time.Time
+}
+
+// CachedContentOption is a function configuring the content created with
+// CreateCachedContent.
+type CachedContentOption func(*cachedContentOptions)
+
+type cachedContentOptions struct {
+	model      string
+	ttl        time.Duration
+	expireTime time.Time
+	tools      []llms.Tool
+}
+
+// WithCachedContentModel sets the model to cache the content for. The
+// default model of the client is used if not set.
+func WithCachedContentModel(model string) CachedContentOption {
+	return func(o *cachedContentOptions) {
+		o.model = model
+	}
+}
+
+// WithCachedContentTTL sets how long the cached content is kept. The server
+// default, one hour, is used if neither the TTL nor the expire time is set.
+func WithCachedContentTTL(ttl time.Duration) CachedContentOption {
+	return func(o *cachedContentOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithCachedContentExpireTime sets when the cached content is deleted.
+func WithCachedContentExpireTime(expireTime time.Time) CachedContentOption {
+	return func(o *cachedContentOptions) {
+		o.expireTime = expireTime
+	}
+}
+
+// WithCachedContentTools caches the tools along with the content. The calls
+// using cached content can't pass tools of their own.
+func WithCachedContentTools(tools []llms.Tool) CachedContentOption {
+	return func(o *cachedContentOptions) {
+		o.tools = tools
+	}
+}
+
+// CreateCachedContent caches messages server-side, a system message becoming
+// the cached system instruction. The returned content is referenced by name
+// with llms.WithCachedContent, the calls using it only sending the rest of
+// the conversation. The models require a minimum number of tokens to cache.
+func (g *GoogleAI) CreateCachedContent(
+	ctx context.Context,
+	messages []llms.MessageContent,
+	options ...CachedContentOption,
+) (*CachedContent, error) {
+	opts := cachedContentOptions{
+		model: g.opts.DefaultModel,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if !opts.expireTime.IsZero() && opts.ttl != 0 {
+		return nil, errors.New("conflicting options, can't use a TTL and an expire time together")
+	}
+
+	system, contents, err := convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+	tools, err := convertTools(opts.tools)
+	if err != nil {
+		return nil, err
+	}
+
+	cc, err := g.client.CreateCachedContent(ctx, &genai.CachedContent{
+		Model:             opts.model,
+		SystemInstruction: system,
+		Contents:          contents,
+		Tools:             tools,
+		Expiration: genai.ExpireTimeOrTTL{
+			ExpireTime: opts.expireTime,
+			TTL:        opts.ttl,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertCachedContent(cc), nil
+}
+
+// GetCachedContent returns the cached content with the given name.
+func (g *GoogleAI) GetCachedContent(ctx context.Context, name string) (*CachedContent, error) {
+	cc, err := g.client.GetCachedContent(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return convertCachedContent(cc), nil
+}
+
+// ListCachedContents returns all the cached contents.
+func (g *GoogleAI) ListCachedContents(ctx context.Context) ([]*CachedContent, error) {
+	var contents []*CachedContent
+	iter := g.client.ListCachedContents(ctx)
+	for {
+		cc, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			return contents, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, convertCachedContent(cc))
+	}
+}
+
+// DeleteCachedContent deletes the cached content with the given name before
+// it expires.
+func (g *GoogleAI) DeleteCachedContent(ctx context.Context, name string) error {
+	return g.client.DeleteCachedContent(ctx, name)
+}
+
+// convertCachedContent converts a genai cached content to its description.
+func convertCachedContent(cc *genai.CachedContent) *CachedContent {
+	expireTime := cc.Expiration.ExpireTime
+	if expireTime.IsZero() && cc.Expiration.TTL != 0 {
+		expireTime = cc.UpdateTime.Add(cc.Expiration.TTL)
+	}
+	return &CachedContent{
+		Name:       cc.Name,
+		Model:      cc.Model,
+		ExpireTime: expireTime,
+		CreateTime: cc.CreateTime,
+		UpdateTime: cc.UpdateTime,
+	}
+}
@@ -0,0 +1,30 @@
+package googleai
+
+import (
+	"github.com/google/generative-ai-go/genai"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// convertCitations converts the citation metadata of a candidate to
+// langchain citations. The citations of the Google AI and Vertex SDKs
+// differ, so this isn't generated for the vertex package.
+func convertCitations(metadata *genai.CitationMetadata) []llms.Citation {
+	if metadata == nil || len(metadata.CitationSources) == 0 {
+		return nil
+	}
+	citations := make([]llms.Citation, 0, len(metadata.CitationSources))
+	for _, source := range metadata.CitationSources {
+		citation := llms.Citation{License: source.License}
+		if source.StartIndex != nil {
+			citation.StartIndex = int(*source.StartIndex)
+		}
+		if source.EndIndex != nil {
+			citation.EndIndex = int(*source.EndIndex)
+		}
+		if source.URI != nil {
+			citation.URI = *source.URI
+		}
+		citations = append(citations, citation)
+	}
+	return citations
+}
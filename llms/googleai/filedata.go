@@ -0,0 +1,12 @@
+package googleai
+
+import (
+	"github.com/google/generative-ai-go/genai"
+)
+
+// fileData returns the part of a file referenced by its URI, such as a file
+// uploaded with the File API. The file data of the Google AI and Vertex SDKs
+// differ, so this isn't generated for the vertex package.
+func fileData(mimeType, uri string) genai.Part {
+	return genai.FileData{MIMEType: mimeType, URI: uri}
+}
@@ -58,6 +58,10 @@ func (g *GoogleAI) GenerateContent(
 		opt(&opts)
 	}
 
+	if err := opts.RateLimiter.Wait(ctx, messages, opts); err != nil {
+		return nil, err
+	}
+
 	model := g.client.GenerativeModel(opts.Model)
 	model.SetCandidateCount(int32(opts.CandidateCount))
 	model.SetMaxOutputTokens(int32(opts.MaxTokens))
@@ -65,6 +69,7 @@ func (g *GoogleAI) GenerateContent(
 	model.SetTopP(float32(opts.TopP))
 	model.SetTopK(int32(opts.TopK))
 	model.StopSequences = opts.StopWords
+	model.CachedContentName = opts.CachedContent
 	model.SafetySettings = []*genai.SafetySetting{
 		{
 			Category:  genai.HarmCategoryDangerousContent,
@@ -83,10 +88,18 @@ func (g *GoogleAI) GenerateContent(
 			Threshold: genai.HarmBlockThreshold(g.opts.HarmThreshold),
 		},
 	}
+	for _, setting := range opts.SafetySettings {
+		if err := setSafetySetting(model, setting); err != nil {
+			return nil, err
+		}
+	}
 	var err error
 	if model.Tools, err = convertTools(opts.Tools); err != nil {
 		return nil, err
 	}
+	if err := setToolConfig(model, opts.ToolChoice); err != nil {
+		return nil, err
+	}
 
 	// set model.ResponseMIMEType from either opts.JSONMode or opts.ResponseMIMEType
 	switch {
@@ -97,6 +110,15 @@ func (g *GoogleAI) GenerateContent(
 	case opts.ResponseMIMEType == "" && opts.JSONMode:
 		model.ResponseMIMEType = ResponseMIMETypeJson
 	}
+	if opts.ResponseSchema != nil {
+		if model.ResponseMIMEType != "" && model.ResponseMIMEType != ResponseMIMETypeJson {
+			return nil, fmt.Errorf("conflicting options, can't use a response schema with ResponseMIMEType %q", model.ResponseMIMEType)
+		}
+		model.ResponseMIMEType = ResponseMIMETypeJson
+		if model.ResponseSchema, err = convertResponseSchema(opts.ResponseSchema.Schema); err != nil {
+			return nil, fmt.Errorf("response schema: %w", err)
+		}
+	}
 
 	var response *llms.ContentResponse
 
@@ -123,10 +145,10 @@ func (g *GoogleAI) GenerateContent(
 // convertCandidates converts a sequence of genai.Candidate to a response.
 func convertCandidates(candidates []*genai.Candidate, usage *genai.UsageMetadata) (*llms.ContentResponse, error) {
 	var contentResponse llms.ContentResponse
-	var toolCalls []llms.ToolCall
 
 	for _, candidate := range candidates {
 		buf := strings.Builder{}
+		var toolCalls []llms.ToolCall
 
 		if candidate.Content != nil {
 			for _, part := range candidate.Content.Parts {
@@ -141,7 +163,11 @@ func convertCandidates(candidates []*genai.Candidate, usage *genai.UsageMetadata
 					if err != nil {
 						return nil, err
 					}
+					// Gemini function calls have no ID, the function
+					// responses being matched by name.
 					toolCall := llms.ToolCall{
+						ID:   fmt.Sprintf("%s-%d", v.Name, len(toolCalls)),
+						Type: "function",
 						FunctionCall: &llms.FunctionCall{
 							Name:      v.Name,
 							Arguments: string(b),
@@ -154,6 +180,19 @@ func convertCandidates(candidates []*genai.Candidate, usage *genai.UsageMetadata
 			}
 		}
 
+		// Empty content is only expected from a complete response, the
+		// other reasons being the blocked responses.
+		switch candidate.FinishReason {
+		case genai.FinishReasonUnspecified, genai.FinishReasonStop, genai.FinishReasonMaxTokens:
+		default:
+			if buf.Len() == 0 && len(toolCalls) == 0 {
+				return nil, &llms.ContentBlockedError{
+					Reason:        candidate.FinishReason.String(),
+					SafetyRatings: convertSafetyRatings(candidate.SafetyRatings),
+				}
+			}
+		}
+
 		metadata := make(map[string]any)
 		metadata[CITATIONS] = candidate.CitationMetadata
 		metadata[SAFETY] = candidate.SafetyRatings
@@ -164,13 +203,26 @@ func convertCandidates(candidates []*genai.Candidate, usage *genai.UsageMetadata
 			metadata["total_tokens"] = usage.TotalTokenCount
 		}
 
-		contentResponse.Choices = append(contentResponse.Choices,
-			&llms.ContentChoice{
-				Content:        buf.String(),
-				StopReason:     candidate.FinishReason.String(),
-				GenerationInfo: metadata,
-				ToolCalls:      toolCalls,
-			})
+		choice := &llms.ContentChoice{
+			Content:        buf.String(),
+			StopReason:     candidate.FinishReason.String(),
+			GenerationInfo: metadata,
+			ToolCalls:      toolCalls,
+			SafetyRatings:  convertSafetyRatings(candidate.SafetyRatings),
+			Citations:      convertCitations(candidate.CitationMetadata),
+		}
+		if len(toolCalls) > 0 {
+			choice.FuncCall = toolCalls[0].FunctionCall
+		}
+		contentResponse.Choices = append(contentResponse.Choices, choice)
+	}
+	if usage != nil {
+		contentResponse.Usage = llms.Usage{
+			PromptTokens:     int(usage.PromptTokenCount),
+			CompletionTokens: int(usage.CandidatesTokenCount),
+			TotalTokens:      int(usage.TotalTokenCount),
+		}
+		contentResponse.Usage.CachedTokens = int(usage.CachedContentTokenCount)
 	}
 	return &contentResponse, nil
 }
@@ -185,13 +237,34 @@ func convertParts(parts []llms.ContentPart) ([]genai.Part, error) {
 		case llms.TextContent:
 			out = genai.Text(p.Text)
 		case llms.BinaryContent:
-			out = genai.Blob{MIMEType: p.MIMEType, Data: p.Data}
+			blob, err := convertBinaryContent(p)
+			if err != nil {
+				return nil, err
+			}
+			out = blob
 		case llms.ImageURLContent:
+			if strings.HasPrefix(p.URL, "data:") {
+				data, err := llms.ParseDataURL(p.URL)
+				if err != nil {
+					return nil, err
+				}
+				blob, err := convertBinaryContent(data)
+				if err != nil {
+					return nil, err
+				}
+				out = blob
+				break
+			}
 			typ, data, err := imageutil.DownloadImageData(p.URL)
 			if err != nil {
 				return nil, err
 			}
 			out = genai.ImageData(typ, data)
+		case llms.FileURLContent:
+			out = fileData(p.MIMEType, p.URL)
+		case llms.ReasoningContent:
+			// The reasoning of previous turns isn't sent back.
+			continue
 		case llms.ToolCall:
 			fc := p.FunctionCall
 			var argsMap map[string]any
@@ -216,6 +289,22 @@ func convertParts(parts []llms.ContentPart) ([]genai.Part, error) {
 	return convertedParts, nil
 }
 
+// maxInlineDataSize is the largest inline data the API accepts, in bytes.
+// Larger files must be uploaded and passed as file URLs.
+const maxInlineDataSize = 20 << 20
+
+// convertBinaryContent converts binary content, such as an image, a PDF or
+// audio, to inline data.
+func convertBinaryContent(p llms.BinaryContent) (genai.Blob, error) {
+	if p.MIMEType == "" {
+		p.MIMEType = llms.DetectMIMEType(p.Data)
+	}
+	if err := llms.CheckContentSize(p, maxInlineDataSize); err != nil {
+		return genai.Blob{}, err
+	}
+	return genai.Blob{MIMEType: p.MIMEType, Data: p.Data}, nil
+}
+
 // convertContent converts between a langchain MessageContent and genai content.
 func convertContent(content llms.MessageContent) (*genai.Content, error) {
 	parts, err := convertParts(content.Parts)
@@ -247,6 +336,32 @@ func convertContent(content llms.MessageContent) (*genai.Content, error) {
 	return c, nil
 }
 
+// convertMessages converts langchain messages to the system instruction and
+// the genai contents of a conversation.
+func convertMessages(messages []llms.MessageContent) (*genai.Content, []*genai.Content, error) {
+	var system *genai.Content
+	history := make([]*genai.Content, 0, len(messages))
+	for i, mc := range messages {
+		content, err := convertContent(mc)
+		if err != nil {
+			return nil, nil, err
+		}
+		if mc.Role == RoleSystem {
+			system = content
+			continue
+		}
+		// The responses of parallel function calls must be in a single
+		// content.
+		if mc.Role == llms.ChatMessageTypeTool && i > 0 && messages[i-1].Role == llms.ChatMessageTypeTool {
+			last := history[len(history)-1]
+			last.Parts = append(last.Parts, content.Parts...)
+			continue
+		}
+		history = append(history, content)
+	}
+	return system, history, nil
+}
+
 // generateFromSingleMessage generates content from the parts of a single
 // message.
 func generateFromSingleMessage(
@@ -260,12 +375,12 @@ func generateFromSingleMessage(
 		return nil, err
 	}
 
-	if opts.StreamingFunc == nil {
+	if opts.StreamingFunc == nil && opts.StreamingToolCallFunc == nil {
 		// When no streaming is requested, just call GenerateContent and return
 		// the complete response with a list of candidates.
 		resp, err := model.GenerateContent(ctx, convertedParts...)
 		if err != nil {
-			return nil, err
+			return nil, convertError(err)
 		}
 
 		if len(resp.Candidates) == 0 {
@@ -283,17 +398,12 @@ func generateFromMessages(
 	messages []llms.MessageContent,
 	opts *llms.CallOptions,
 ) (*llms.ContentResponse, error) {
-	history := make([]*genai.Content, 0, len(messages))
-	for _, mc := range messages {
-		content, err := convertContent(mc)
-		if err != nil {
-			return nil, err
-		}
-		if mc.Role == RoleSystem {
-			model.SystemInstruction = content
-			continue
-		}
-		history = append(history, content)
+	system, history, err := convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+	if system != nil {
+		model.SystemInstruction = system
 	}
 
 	// Given N total messages, genai's chat expects the first N-1 messages as
@@ -305,10 +415,10 @@ func generateFromMessages(
 	session := model.StartChat()
 	session.History = history
 
-	if opts.StreamingFunc == nil {
+	if opts.StreamingFunc == nil && opts.StreamingToolCallFunc == nil {
 		resp, err := session.SendMessage(ctx, reqContent.Parts...)
 		if err != nil {
-			return nil, err
+			return nil, convertError(err)
 		}
 
 		if len(resp.Candidates) == 0 {
@@ -322,7 +432,9 @@ func generateFromMessages(
 
 // convertAndStreamFromIterator takes an iterator of GenerateContentResponse
 // and produces a llms.ContentResponse reply from it, while streaming the
-// resulting text into the opts-provided streaming function.
+// resulting text and function calls into the opts-provided streaming
+// functions. Gemini streams whole function calls, so each of them is a single
+// delta.
 // Note that this is tricky in the face of multiple
 // candidates, so this code assumes only a single candidate for now.
 func convertAndStreamFromIterator(
@@ -333,6 +445,7 @@ func convertAndStreamFromIterator(
 	candidate := &genai.Candidate{
 		Content: &genai.Content{},
 	}
+	toolCalls := 0
 DoStream:
 	for {
 		resp, err := iter.Next()
@@ -340,7 +453,7 @@ DoStream:
 			break DoStream
 		}
 		if err != nil {
-			return nil, fmt.Errorf("error in stream mode: %w", err)
+			return nil, fmt.Errorf("error in stream mode: %w", convertError(err))
 		}
 
 		if len(resp.Candidates) != 1 {
@@ -348,19 +461,35 @@ DoStream:
 		}
 		respCandidate := resp.Candidates[0]
 
+		candidate.FinishReason = respCandidate.FinishReason
+		candidate.SafetyRatings = respCandidate.SafetyRatings
+		candidate.CitationMetadata = respCandidate.CitationMetadata
 		if respCandidate.Content == nil {
 			break DoStream
 		}
 		candidate.Content.Parts = append(candidate.Content.Parts, respCandidate.Content.Parts...)
 		candidate.Content.Role = respCandidate.Content.Role
-		candidate.FinishReason = respCandidate.FinishReason
-		candidate.SafetyRatings = respCandidate.SafetyRatings
-		candidate.CitationMetadata = respCandidate.CitationMetadata
 		candidate.TokenCount += respCandidate.TokenCount
 
 		for _, part := range respCandidate.Content.Parts {
-			if text, ok := part.(genai.Text); ok {
-				if opts.StreamingFunc(ctx, []byte(text)) != nil {
+			switch p := part.(type) {
+			case genai.Text:
+				if opts.StreamingFunc != nil && opts.StreamingFunc(ctx, []byte(p)) != nil {
+					break DoStream
+				}
+			case genai.FunctionCall:
+				args, err := json.Marshal(p.Args)
+				if err != nil {
+					return nil, err
+				}
+				delta := llms.ToolCallDelta{
+					Index:          toolCalls,
+					ID:             fmt.Sprintf("%s-%d", p.Name, toolCalls),
+					Name:           p.Name,
+					ArgumentsDelta: string(args),
+				}
+				toolCalls++
+				if opts.StreamingToolCallFunc != nil && opts.StreamingToolCallFunc(ctx, delta) != nil {
 					break DoStream
 				}
 			}
@@ -370,6 +499,135 @@ DoStream:
 	return convertCandidates([]*genai.Candidate{candidate}, mresp.UsageMetadata)
 }
 
+// setSafetySetting sets the blocking threshold of a harm category of model.
+func setSafetySetting(model *genai.GenerativeModel, setting llms.SafetySetting) error {
+	var category genai.HarmCategory
+	switch setting.Category {
+	case llms.HarmCategoryHarassment:
+		category = genai.HarmCategoryHarassment
+	case llms.HarmCategoryHateSpeech:
+		category = genai.HarmCategoryHateSpeech
+	case llms.HarmCategorySexuallyExplicit:
+		category = genai.HarmCategorySexuallyExplicit
+	case llms.HarmCategoryDangerousContent:
+		category = genai.HarmCategoryDangerousContent
+	default:
+		return fmt.Errorf("unsupported harm category %q", setting.Category)
+	}
+
+	var threshold genai.HarmBlockThreshold
+	switch setting.Threshold {
+	case llms.HarmBlockLowAndAbove:
+		threshold = genai.HarmBlockLowAndAbove
+	case llms.HarmBlockMediumAndAbove:
+		threshold = genai.HarmBlockMediumAndAbove
+	case llms.HarmBlockOnlyHigh:
+		threshold = genai.HarmBlockOnlyHigh
+	case llms.HarmBlockNone:
+		threshold = genai.HarmBlockNone
+	default:
+		return fmt.Errorf("unsupported harm block threshold %q", setting.Threshold)
+	}
+
+	for _, s := range model.SafetySettings {
+		if s.Category == category {
+			s.Threshold = threshold
+			return nil
+		}
+	}
+	model.SafetySettings = append(model.SafetySettings, &genai.SafetySetting{
+		Category:  category,
+		Threshold: threshold,
+	})
+	return nil
+}
+
+// convertSafetyRatings converts genai safety ratings to langchain ones.
+func convertSafetyRatings(ratings []*genai.SafetyRating) []llms.SafetyRating {
+	if len(ratings) == 0 {
+		return nil
+	}
+	converted := make([]llms.SafetyRating, 0, len(ratings))
+	for _, r := range ratings {
+		rating := llms.SafetyRating{Blocked: r.Blocked}
+		switch r.Category {
+		case genai.HarmCategoryHarassment:
+			rating.Category = llms.HarmCategoryHarassment
+		case genai.HarmCategoryHateSpeech:
+			rating.Category = llms.HarmCategoryHateSpeech
+		case genai.HarmCategorySexuallyExplicit:
+			rating.Category = llms.HarmCategorySexuallyExplicit
+		case genai.HarmCategoryDangerousContent:
+			rating.Category = llms.HarmCategoryDangerousContent
+		default:
+			rating.Category = llms.HarmCategory(r.Category.String())
+		}
+		switch r.Probability {
+		case genai.HarmProbabilityNegligible:
+			rating.Probability = "negligible"
+		case genai.HarmProbabilityLow:
+			rating.Probability = "low"
+		case genai.HarmProbabilityMedium:
+			rating.Probability = "medium"
+		case genai.HarmProbabilityHigh:
+			rating.Probability = "high"
+		default:
+			rating.Probability = r.Probability.String()
+		}
+		converted = append(converted, rating)
+	}
+	return converted
+}
+
+// convertError converts the genai errors of the blocked prompts and
+// responses to llms.ContentBlockedError.
+func convertError(err error) error {
+	var blocked *genai.BlockedError
+	if !errors.As(err, &blocked) {
+		return err
+	}
+	switch {
+	case blocked.PromptFeedback != nil:
+		return &llms.ContentBlockedError{
+			Reason:        blocked.PromptFeedback.BlockReason.String(),
+			Prompt:        true,
+			SafetyRatings: convertSafetyRatings(blocked.PromptFeedback.SafetyRatings),
+		}
+	case blocked.Candidate != nil:
+		return &llms.ContentBlockedError{
+			Reason:        blocked.Candidate.FinishReason.String(),
+			SafetyRatings: convertSafetyRatings(blocked.Candidate.SafetyRatings),
+		}
+	}
+	return err
+}
+
+// setToolConfig sets the function calling mode of model from the tool choice
+// of the call options. Gemini has no option to prevent parallel function
+// calls, so ParallelToolCalls is ignored.
+func setToolConfig(model *genai.GenerativeModel, choice any) error {
+	mode, function, err := llms.ResolveToolChoice(choice)
+	if err != nil {
+		return err
+	}
+	config := &genai.FunctionCallingConfig{}
+	switch mode {
+	case "":
+		return nil
+	case llms.ToolChoiceAuto:
+		config.Mode = genai.FunctionCallingAuto
+	case llms.ToolChoiceNone:
+		config.Mode = genai.FunctionCallingNone
+	case llms.ToolChoiceRequired:
+		config.Mode = genai.FunctionCallingAny
+	case llms.ToolChoiceFunction:
+		config.Mode = genai.FunctionCallingAny
+		config.AllowedFunctionNames = []string{function}
+	}
+	model.ToolConfig = &genai.ToolConfig{FunctionCallingConfig: config}
+	return nil
+}
+
 // convertTools converts from a list of langchaingo tools to a list of genai
 // tools.
 func convertTools(tools []llms.Tool) ([]*genai.Tool, error) {
@@ -458,6 +716,78 @@ func convertTools(tools []llms.Tool) ([]*genai.Tool, error) {
 	return genaiTools, nil
 }
 
+// convertResponseSchema converts a JSON schema to a genai schema, which
+// supports a subset of OpenAPI 3.0 schemas.
+func convertResponseSchema(schema map[string]any) (*genai.Schema, error) {
+	result := &genai.Schema{}
+	switch ty := schema["type"].(type) {
+	case nil:
+	case string:
+		result.Type = convertToolSchemaType(ty)
+	case []any:
+		// Nullable types are written as ["string", "null"].
+		for _, t := range ty {
+			switch t {
+			case "null":
+				result.Nullable = true
+			default:
+				tyString, ok := t.(string)
+				if !ok || result.Type != genai.TypeUnspecified {
+					return nil, fmt.Errorf("unsupported type %v", ty)
+				}
+				result.Type = convertToolSchemaType(tyString)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type %v", ty)
+	}
+	if result.Type == genai.TypeUnspecified {
+		return nil, fmt.Errorf("unsupported type %v", schema["type"])
+	}
+	result.Description, _ = schema["description"].(string)
+	result.Format, _ = schema["format"].(string)
+	if nullable, ok := schema["nullable"].(bool); ok {
+		result.Nullable = nullable
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		for _, e := range enum {
+			result.Enum = append(result.Enum, fmt.Sprint(e))
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		var err error
+		if result.Items, err = convertResponseSchema(items); err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+	}
+	if properties, ok := schema["properties"].(map[string]any); ok {
+		result.Properties = make(map[string]*genai.Schema, len(properties))
+		for name, property := range properties {
+			propertyMap, ok := property.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("property %q: expected a schema", name)
+			}
+			var err error
+			if result.Properties[name], err = convertResponseSchema(propertyMap); err != nil {
+				return nil, fmt.Errorf("property %q: %w", name, err)
+			}
+		}
+	}
+	switch required := schema["required"].(type) {
+	case []string:
+		result.Required = required
+	case []any:
+		for _, r := range required {
+			rString, ok := r.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected string for required")
+			}
+			result.Required = append(result.Required, rString)
+		}
+	}
+	return result, nil
+}
+
 // convertToolSchemaType converts a tool's schema type from its langchaingo
 // representation (string) to a genai enum.
 func convertToolSchemaType(ty string) genai.Type {
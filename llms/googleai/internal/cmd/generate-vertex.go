@@ -1,8 +1,11 @@
-// Code generator for vertex.go from googleai.go
+// Code generator for vertex.go from googleai.go, and for the other files of
+// the vertex package generated from the googleai package. The name of the
+// source file is passed with the -source flag.
 // nolint
 package main
 
 import (
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/format"
@@ -16,6 +19,9 @@ import (
 )
 
 func main() {
+	source := flag.String("source", "googleai.go", "name of the source file")
+	flag.Parse()
+
 	fset := token.NewFileSet()
 	file, err := parser.ParseFile(fset, "src.go", os.Stdin, parser.ParseComments)
 	if err != nil {
@@ -40,13 +46,14 @@ func main() {
 		return true
 	})
 
-	fmt.Println(strings.TrimLeft(preamble, "\r\n"))
+	fmt.Printf(strings.TrimLeft(preamble, "\r\n"), *source)
 	format.Node(os.Stdout, fset, file)
 }
 
 const preamble = `
-// DO NOT EDIT THIS FILE -- it is automatically generated from googleai.go
+// DO NOT EDIT THIS FILE -- it is automatically generated from %s
 // See the README file in this directory for additional details
+
 `
 
 func rewriteImport(x *ast.ImportSpec) {
@@ -93,6 +100,11 @@ func removeTokenCount(fun *ast.FuncDecl) {
 						idx = i
 						break
 					}
+					// Vertex doesn't report the cached token count.
+					if lhs, ok := lhs0.(*ast.SelectorExpr); ok && getIdentName(lhs.Sel) == "CachedTokens" {
+						idx = i
+						break
+					}
 				}
 			}
 
@@ -2,6 +2,7 @@ package palmclient
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"runtime"
@@ -32,6 +33,8 @@ const (
 	TextModelName      = "text-bison"
 	ChatModelName      = "chat-bison"
 
+	MultimodalEmbeddingModelName = "multimodalembedding@001"
+
 	defaultMaxConns = 4
 )
 
@@ -113,11 +116,17 @@ func (c *PaLMClient) CreateCompletion(ctx context.Context, r *CompletionRequest)
 // EmbeddingRequest is a request to create an embedding.
 type EmbeddingRequest struct {
 	Input []string `json:"input"`
+	// OutputDimensionality is the number of dimensions of the embeddings,
+	// the native dimension of the model if 0.
+	OutputDimensionality int `json:"outputDimensionality,omitempty"`
 }
 
 // CreateEmbedding creates embeddings.
 func (c *PaLMClient) CreateEmbedding(ctx context.Context, r *EmbeddingRequest) ([][]float32, error) {
 	params := map[string]interface{}{}
+	if r.OutputDimensionality > 0 {
+		params["outputDimensionality"] = r.OutputDimensionality
+	}
 	responses, err := c.batchPredict(ctx, embeddingModelName, r.Input, params)
 	if err != nil {
 		return nil, err
@@ -134,23 +143,117 @@ func (c *PaLMClient) CreateEmbedding(ctx context.Context, r *EmbeddingRequest) (
 		if !ok {
 			return nil, fmt.Errorf("%w: %v", ErrMissingValue, "values")
 		}
-		floatValues := []float32{}
-		for _, v := range values {
-			val, ok := v.(float32)
-			if !ok {
-				valF64, ok := v.(float64)
-				if !ok {
-					return nil, fmt.Errorf("%w: %v is not a float64 or float32, it is a %T", ErrInvalidValue, "value", v)
-				}
-				val = float32(valF64)
-			}
-			floatValues = append(floatValues, val)
+		floatValues, err := convertFloats(values)
+		if err != nil {
+			return nil, err
 		}
 		embeddings = append(embeddings, floatValues)
 	}
 	return embeddings, nil
 }
 
+// MultimodalEmbeddingRequest is a request to embed a text, an image or both
+// in the shared embedding space of the multimodal embedding model.
+type MultimodalEmbeddingRequest struct {
+	Text string
+	// Image holds the bytes of the image, ImageURI the Cloud Storage URI
+	// (gs://...) of the image if Image is empty.
+	Image    []byte
+	ImageURI string
+	// Dimension is the number of dimensions of the embeddings (128, 256, 512
+	// or 1408), the native dimension of the model if 0.
+	Dimension int
+}
+
+// MultimodalEmbedding holds the embeddings of a multimodal embedding request.
+type MultimodalEmbedding struct {
+	Text  []float32
+	Image []float32
+}
+
+// CreateMultimodalEmbedding embeds a text, an image or both.
+func (c *PaLMClient) CreateMultimodalEmbedding(ctx context.Context, r *MultimodalEmbeddingRequest) (*MultimodalEmbedding, error) { //nolint:lll
+	instance := map[string]interface{}{}
+	if r.Text != "" {
+		instance["text"] = r.Text
+	}
+	switch {
+	case len(r.Image) > 0:
+		instance["image"] = map[string]interface{}{
+			"bytesBase64Encoded": base64.StdEncoding.EncodeToString(r.Image),
+		}
+	case r.ImageURI != "":
+		instance["image"] = map[string]interface{}{
+			"gcsUri": r.ImageURI,
+		}
+	}
+	if len(instance) == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrMissingValue, "text or image")
+	}
+	content, err := structpb.NewStruct(instance)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]interface{}{}
+	if r.Dimension > 0 {
+		params["dimension"] = r.Dimension
+	}
+	parameters, err := structpb.NewStruct(params)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Predict(ctx, &aiplatformpb.PredictRequest{
+		Endpoint:   c.projectLocationPublisherModelPath(c.projectID, "us-central1", "google", MultimodalEmbeddingModelName),
+		Instances:  []*structpb.Value{structpb.NewStructValue(content)},
+		Parameters: structpb.NewStructValue(parameters),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.GetPredictions()) == 0 {
+		return nil, ErrEmptyResponse
+	}
+	return parseMultimodalEmbedding(resp.GetPredictions()[0].GetStructValue().AsMap())
+}
+
+func parseMultimodalEmbedding(value map[string]interface{}) (*MultimodalEmbedding, error) {
+	embedding := &MultimodalEmbedding{}
+	if values, ok := value["textEmbedding"].([]interface{}); ok {
+		floatValues, err := convertFloats(values)
+		if err != nil {
+			return nil, err
+		}
+		embedding.Text = floatValues
+	}
+	if values, ok := value["imageEmbedding"].([]interface{}); ok {
+		floatValues, err := convertFloats(values)
+		if err != nil {
+			return nil, err
+		}
+		embedding.Image = floatValues
+	}
+	if embedding.Text == nil && embedding.Image == nil {
+		return nil, fmt.Errorf("%w: %v", ErrMissingValue, "textEmbedding or imageEmbedding")
+	}
+	return embedding, nil
+}
+
+func convertFloats(values []interface{}) ([]float32, error) {
+	floatValues := make([]float32, 0, len(values))
+	for _, v := range values {
+		val, ok := v.(float32)
+		if !ok {
+			valF64, ok := v.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%w: %v is not a float64 or float32, it is a %T", ErrInvalidValue, "value", v)
+			}
+			val = float32(valF64)
+		}
+		floatValues = append(floatValues, val)
+	}
+	return floatValues, nil
+}
+
 // ChatRequest is a request to create an embedding.
 type ChatRequest struct {
 	Context        string         `json:"context"`
@@ -39,3 +39,12 @@ func New(ctx context.Context, opts ...Option) (*GoogleAI, error) {
 	gi.client = client
 	return gi, nil
 }
+
+// Capabilities implements the llms.CapabilityReporter interface, reporting
+// the capabilities of the default model.
+func (g *GoogleAI) Capabilities() llms.ModelCapabilities {
+	if capabilities, ok := llms.LookupModelCapabilities(g.opts.DefaultModel); ok {
+		return capabilities
+	}
+	return llms.ModelCapabilities{Streaming: true}
+}
@@ -94,6 +94,7 @@ var testConfigs = []testConfig{
 	{testMaxTokensSetting, nil},
 	{testTools, nil},
 	{testToolsWithInterfaceRequired, nil},
+	{testStructuredOutput, nil},
 	{
 		testMultiContentText,
 		[]googleai.Option{googleai.WithHarmThreshold(googleai.HarmBlockMediumAndAbove)},
@@ -518,6 +519,31 @@ func testToolsWithInterfaceRequired(t *testing.T, llm llms.Model) {
 	assert.NotZero(t, resp.Choices[0].GenerationInfo["output_tokens"])
 }
 
+func testStructuredOutput(t *testing.T, llm llms.Model) {
+	t.Helper()
+	t.Parallel()
+
+	type planet struct {
+		Name  string `json:"name"`
+		Moons int    `json:"moons" describe:"number of known moons"`
+	}
+	type planets struct {
+		Planets []planet `json:"planets"`
+	}
+	content := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "List the planets of the solar system with their number of moons"),
+	}
+	rsp, err := llms.GenerateStructured[planets](context.Background(), llm, content)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, rsp.Planets)
+	names := make([]string, 0, len(rsp.Planets))
+	for _, p := range rsp.Planets {
+		names = append(names, p.Name)
+	}
+	assert.Contains(t, names, "Jupiter")
+}
+
 func testMaxTokensSetting(t *testing.T, llm llms.Model) {
 	t.Helper()
 	t.Parallel()
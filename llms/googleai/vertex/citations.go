@@ -0,0 +1,25 @@
+package vertex
+
+import (
+	"cloud.google.com/go/vertexai/genai"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// convertCitations converts the citation metadata of a candidate to
+// langchain citations.
+func convertCitations(metadata *genai.CitationMetadata) []llms.Citation {
+	if metadata == nil || len(metadata.Citations) == 0 {
+		return nil
+	}
+	citations := make([]llms.Citation, 0, len(metadata.Citations))
+	for _, c := range metadata.Citations {
+		citations = append(citations, llms.Citation{
+			StartIndex: int(c.StartIndex),
+			EndIndex:   int(c.EndIndex),
+			URI:        c.URI,
+			Title:      c.Title,
+			License:    c.License,
+		})
+	}
+	return citations
+}
@@ -10,8 +10,15 @@ import (
 
 // CreateEmbedding creates embeddings from texts.
 func (g *Vertex) CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error) {
+	return g.CreateEmbeddingWithDimension(ctx, texts, 0)
+}
+
+// CreateEmbeddingWithDimension creates embeddings of the given dimension
+// from texts, of the native dimension of the model if 0.
+func (g *Vertex) CreateEmbeddingWithDimension(ctx context.Context, texts []string, dimension int) ([][]float32, error) {
 	embeddings, err := g.palmClient.CreateEmbedding(ctx, &palmclient.EmbeddingRequest{
-		Input: texts,
+		Input:                texts,
+		OutputDimensionality: dimension,
 	})
 	if err != nil {
 		return [][]float32{}, err
@@ -26,3 +33,10 @@ func (g *Vertex) CreateEmbedding(ctx context.Context, texts []string) ([][]float
 
 	return embeddings, nil
 }
+
+// EmbeddingBatchLimits returns the maximum number of texts and of tokens of
+// an embeddings request, so that embeddings.NewEmbedder splits larger
+// batches.
+func (g *Vertex) EmbeddingBatchLimits() (maxTexts, maxTokens int) {
+	return 250, 20_000
+}
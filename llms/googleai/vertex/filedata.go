@@ -0,0 +1,11 @@
+package vertex
+
+import (
+	"cloud.google.com/go/vertexai/genai"
+)
+
+// fileData returns the part of a file referenced by its URI, such as a
+// Cloud Storage URI.
+func fileData(mimeType, uri string) genai.Part {
+	return genai.FileData{MIMEType: mimeType, FileURI: uri}
+}
@@ -0,0 +1,99 @@
+package vertex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/llms/googleai/internal/palmclient"
+)
+
+// MultimodalEmbedder embeds texts and images in the shared embedding space
+// of the Vertex AI multimodal embedding model, so that images are searched
+// with text queries.
+type MultimodalEmbedder struct {
+	client *palmclient.PaLMClient
+	// Dimension is the number of dimensions of the embeddings (128, 256, 512
+	// or 1408), the native dimension of the model if 0.
+	Dimension int
+}
+
+var (
+	_ embeddings.MultimodalEmbedder = &MultimodalEmbedder{}
+	_ embeddings.ImageURIEmbedder   = &MultimodalEmbedder{}
+)
+
+// MultimodalEmbedder returns an embedder of texts and images using the
+// multimodal embedding model, embedding with the given number of dimensions
+// or the native dimension of the model if 0.
+func (g *Vertex) MultimodalEmbedder(dimension int) *MultimodalEmbedder {
+	return &MultimodalEmbedder{client: g.palmClient, Dimension: dimension}
+}
+
+// EmbedDocuments embeds texts. The model embeds a single text per request.
+func (e *MultimodalEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		vector, err := e.EmbedQuery(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+// EmbedQuery embeds a single text.
+func (e *MultimodalEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	embedding, err := e.client.CreateMultimodalEmbedding(ctx, &palmclient.MultimodalEmbeddingRequest{
+		Text:      text,
+		Dimension: e.Dimension,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed text: %w", err)
+	}
+	if embedding.Text == nil {
+		return nil, fmt.Errorf("failed to embed text: %w", palmclient.ErrEmptyResponse)
+	}
+	return embedding.Text, nil
+}
+
+// EmbedImages embeds images given their encoded bytes, of at most 20MB each.
+func (e *MultimodalEmbedder) EmbedImages(ctx context.Context, images [][]byte) ([][]float32, error) {
+	requests := make([]*palmclient.MultimodalEmbeddingRequest, 0, len(images))
+	for _, image := range images {
+		requests = append(requests, &palmclient.MultimodalEmbeddingRequest{Image: image, Dimension: e.Dimension})
+	}
+	return e.embedImages(ctx, requests)
+}
+
+// EmbedImageURIs embeds images stored in Cloud Storage given their gs://
+// URIs.
+func (e *MultimodalEmbedder) EmbedImageURIs(ctx context.Context, uris []string) ([][]float32, error) {
+	requests := make([]*palmclient.MultimodalEmbeddingRequest, 0, len(uris))
+	for _, uri := range uris {
+		requests = append(requests, &palmclient.MultimodalEmbeddingRequest{ImageURI: uri, Dimension: e.Dimension})
+	}
+	return e.embedImages(ctx, requests)
+}
+
+func (e *MultimodalEmbedder) embedImages(ctx context.Context, requests []*palmclient.MultimodalEmbeddingRequest) ([][]float32, error) { //nolint:lll
+	vectors := make([][]float32, 0, len(requests))
+	for _, r := range requests {
+		embedding, err := e.client.CreateMultimodalEmbedding(ctx, r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed image: %w", err)
+		}
+		if embedding.Image == nil {
+			return nil, fmt.Errorf("failed to embed image: %w", palmclient.ErrEmptyResponse)
+		}
+		vectors = append(vectors, embedding.Image)
+	}
+	return vectors, nil
+}
+
+// Dimensions returns the number of dimensions of the embeddings, 0 when
+// using the native dimension of the model.
+func (e *MultimodalEmbedder) Dimensions() int {
+	return e.Dimension
+}
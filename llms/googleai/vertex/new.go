@@ -58,3 +58,12 @@ func New(ctx context.Context, opts ...googleai.Option) (*Vertex, error) {
 	}
 	return v, nil
 }
+
+// Capabilities implements the llms.CapabilityReporter interface, reporting
+// the capabilities of the default model.
+func (g *Vertex) Capabilities() llms.ModelCapabilities {
+	if capabilities, ok := llms.LookupModelCapabilities(g.opts.DefaultModel); ok {
+		return capabilities
+	}
+	return llms.ModelCapabilities{Streaming: true}
+}
@@ -40,6 +40,10 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(opts)
 	}
 
+	if err := opts.RateLimiter.Wait(ctx, messages, *opts); err != nil {
+		return nil, err
+	}
+
 	// Assume we get a single text message
 	msg0 := messages[0]
 	part := msg0.Parts[0]
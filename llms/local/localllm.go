@@ -69,6 +69,10 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(opts)
 	}
 
+	if err := opts.RateLimiter.Wait(ctx, messages, *opts); err != nil {
+		return nil, err
+	}
+
 	// If o.client.GlobalAsArgs is true
 	if o.client.GlobalAsArgs {
 		// Then add the option to the args in --key=value format
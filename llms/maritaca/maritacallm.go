@@ -60,6 +60,10 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(&opts)
 	}
 
+	if err := opts.RateLimiter.Wait(ctx, messages, opts); err != nil {
+		return nil, err
+	}
+
 	// Override LLM model if set as llms.CallOption
 	model := o.options.model
 	if opts.Model != "" {
@@ -146,7 +150,14 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 
 	choices := createChoice(resp)
 
-	response := &llms.ContentResponse{Choices: choices}
+	response := &llms.ContentResponse{
+		Choices: choices,
+		Usage: llms.Usage{
+			PromptTokens:     resp.Metrics.Usage.PromptTokens,
+			CompletionTokens: resp.Metrics.Usage.CompletionTokens,
+			TotalTokens:      resp.Metrics.Usage.TotalTokens,
+		},
+	}
 
 	if o.CallbacksHandler != nil {
 		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, response)
@@ -43,6 +43,15 @@ func (mc *MessageContent) UnmarshalJSON(data []byte) error {
 				Data     string `json:"data"`
 				MIMEType string `json:"mime_type"`
 			} `json:"binary,omitempty"`
+			FileURL struct {
+				URL      string `json:"url"`
+				MIMEType string `json:"mime_type"`
+			} `json:"file_url,omitempty"`
+			Reasoning struct {
+				Text         string `json:"text"`
+				Signature    string `json:"signature,omitempty"`
+				RedactedData string `json:"redacted_data,omitempty"`
+			} `json:"reasoning,omitempty"`
 			ID       string `json:"id"`
 			ToolCall struct {
 				ID           string        `json:"id"`
@@ -76,6 +85,17 @@ func (mc *MessageContent) UnmarshalJSON(data []byte) error {
 				return fmt.Errorf("failed to decode binary data: %w", err)
 			}
 			mc.Parts = append(mc.Parts, BinaryContent{MIMEType: part.Binary.MIMEType, Data: decoded})
+		case "file_url":
+			mc.Parts = append(mc.Parts, FileURLContent{
+				MIMEType: part.FileURL.MIMEType,
+				URL:      part.FileURL.URL,
+			})
+		case "reasoning":
+			mc.Parts = append(mc.Parts, ReasoningContent{
+				Text:         part.Reasoning.Text,
+				Signature:    part.Reasoning.Signature,
+				RedactedData: part.Reasoning.RedactedData,
+			})
 		case "tool_call":
 			mc.Parts = append(mc.Parts, ToolCall{
 				ID:           part.ToolCall.ID,
@@ -203,6 +223,83 @@ func (bc *BinaryContent) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func (fuc FileURLContent) MarshalJSON() ([]byte, error) {
+	m := struct {
+		Type    string            `json:"type"`
+		FileURL map[string]string `json:"file_url"`
+	}{
+		Type: "file_url",
+		FileURL: map[string]string{
+			"mime_type": fuc.MIMEType,
+			"url":       fuc.URL,
+		},
+	}
+	return json.Marshal(m)
+}
+
+func (fuc *FileURLContent) UnmarshalJSON(data []byte) error {
+	var m struct {
+		Type    string `json:"type"`
+		FileURL *struct {
+			URL      string `json:"url"`
+			MIMEType string `json:"mime_type"`
+		} `json:"file_url"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	if m.Type != "file_url" {
+		return fmt.Errorf("invalid type for FileURLContent: %v", m.Type)
+	}
+	if m.FileURL == nil {
+		return fmt.Errorf("invalid file_url field in FileURLContent")
+	}
+	fuc.MIMEType = m.FileURL.MIMEType
+	fuc.URL = m.FileURL.URL
+	return nil
+}
+
+func (rc ReasoningContent) MarshalJSON() ([]byte, error) {
+	m := struct {
+		Type      string            `json:"type"`
+		Reasoning map[string]string `json:"reasoning"`
+	}{
+		Type:      "reasoning",
+		Reasoning: map[string]string{"text": rc.Text},
+	}
+	if rc.Signature != "" {
+		m.Reasoning["signature"] = rc.Signature
+	}
+	if rc.RedactedData != "" {
+		m.Reasoning["redacted_data"] = rc.RedactedData
+	}
+	return json.Marshal(m)
+}
+
+func (rc *ReasoningContent) UnmarshalJSON(data []byte) error {
+	var m struct {
+		Type      string `json:"type"`
+		Reasoning *struct {
+			Text         string `json:"text"`
+			Signature    string `json:"signature"`
+			RedactedData string `json:"redacted_data"`
+		} `json:"reasoning"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	if m.Type != "reasoning" {
+		return fmt.Errorf("invalid type for ReasoningContent: %v", m.Type)
+	}
+	if m.Reasoning == nil {
+		return fmt.Errorf("invalid reasoning field in ReasoningContent")
+	}
+	rc.Text = m.Reasoning.Text
+	rc.Signature = m.Reasoning.Signature
+	rc.RedactedData = m.Reasoning.RedactedData
+	return nil
+}
+
 func (tc ToolCall) MarshalJSON() ([]byte, error) {
 	fc, err := json.Marshal(tc.FunctionCall)
 	if err != nil {
@@ -244,8 +341,11 @@ func (tc *ToolCall) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("invalid type field in ToolCall")
 	}
 	var fc FunctionCall
-	fcData, ok := toolCall["function"].(json.RawMessage)
-	if ok {
+	if function, ok := toolCall["function"].(map[string]any); ok {
+		fcData, err := json.Marshal(function)
+		if err != nil {
+			return fmt.Errorf("error marshalling function call: %w", err)
+		}
 		if err := json.Unmarshal(fcData, &fc); err != nil {
 			return fmt.Errorf("error unmarshalling function call: %w", err)
 		}
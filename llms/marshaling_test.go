@@ -392,6 +392,28 @@ func TestRoundtripping(t *testing.T) { // nolint:funlen // We make an exception
 role: user
 `,
 		},
+		{
+			name: "file url",
+			in: MessageContent{
+				Role: "user",
+				Parts: []ContentPart{
+					TextContent{Text: "Summarize the report."},
+					FileURLContent{MIMEType: "application/pdf", URL: "gs://bucket/report.pdf"},
+				},
+			},
+			assertedJSON: `{"role":"user","parts":[{"text":"Summarize the report.","type":"text"},{"type":"file_url","file_url":{"mime_type":"application/pdf","url":"gs://bucket/report.pdf"}}]}`,
+		},
+		{
+			name: "reasoning",
+			in: MessageContent{
+				Role: "ai",
+				Parts: []ContentPart{
+					ReasoningContent{Text: "The user wants a greeting.", Signature: "c2ln"},
+					TextContent{Text: "Hello!"},
+				},
+			},
+			assertedJSON: `{"role":"ai","parts":[{"type":"reasoning","reasoning":{"signature":"c2ln","text":"The user wants a greeting."}},{"text":"Hello!","type":"text"}]}`,
+		},
 		{
 			name: "tool use",
 			in: MessageContent{
@@ -533,3 +555,23 @@ role: assistant
 		})
 	}
 }
+
+func TestToolCallRoundtrip(t *testing.T) {
+	t.Parallel()
+	in := ToolCall{
+		ID:           "t01",
+		Type:         "function",
+		FunctionCall: &FunctionCall{Name: "get_current_weather", Arguments: `{ "location": "New York" }`},
+	}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out ToolCall
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(in, out); diff != "" {
+		t.Errorf("Roundtrip JSON mismatch (-want +got):\n%s", diff)
+	}
+}
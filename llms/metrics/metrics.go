@@ -0,0 +1,199 @@
+// Package metrics provides an llms.Model wrapper measuring the latency of
+// streaming generations, the time to the first token and the throughput of
+// the stream, to compare providers and models on the latency users feel.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// instrumentationName identifies the metrics of this package.
+const instrumentationName = "github.com/tmc/langchaingo/llms/metrics"
+
+// Stats are the measurements of a streaming generation.
+type Stats struct {
+	// Provider is the provider of the model, as given to New.
+	Provider string
+	// Model is the model of the call options, or the default model set with
+	// WithModel.
+	Model string
+	// TimeToFirstToken is the time from the call to the first chunk.
+	TimeToFirstToken time.Duration
+	// Duration is the time from the call to the last chunk.
+	Duration time.Duration
+	// Chunks is the number of streamed chunks.
+	Chunks int
+	// OutputTokens is the number of generated tokens reported by the
+	// provider, or the number of chunks when it isn't reported.
+	OutputTokens int
+	// TokensPerSecond is the number of output tokens divided by the time
+	// from the first to the last chunk. It is zero for single chunk streams.
+	TokensPerSecond float64
+}
+
+// Handler receives the stats of the streaming generations.
+type Handler interface {
+	HandleStreamingStats(ctx context.Context, stats Stats)
+}
+
+// HandlerFunc is a function used as a Handler.
+type HandlerFunc func(ctx context.Context, stats Stats)
+
+// HandleStreamingStats calls f.
+func (f HandlerFunc) HandleStreamingStats(ctx context.Context, stats Stats) {
+	f(ctx, stats)
+}
+
+// LLM wraps a model and measures its streaming generations. Generations
+// without a streaming function aren't measured.
+type LLM struct {
+	model    llms.Model
+	provider string
+
+	defaultModel  string
+	meterProvider metric.MeterProvider
+	handler       Handler
+
+	timeToFirstToken metric.Float64Histogram
+	tokensPerSecond  metric.Float64Histogram
+}
+
+var _ llms.Model = (*LLM)(nil)
+
+// Option is a function that configures an LLM.
+type Option func(*LLM)
+
+// WithModel sets the model name reported for the calls that don't set one
+// in their call options, i.e. the default model of the wrapped client.
+func WithModel(model string) Option {
+	return func(l *LLM) {
+		l.defaultModel = model
+	}
+}
+
+// WithMeterProvider records the time to first token and the throughput of
+// the streams in the llm.stream.time_to_first_token and
+// llm.stream.tokens_per_second histograms, with the provider and model as
+// attributes.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(l *LLM) {
+		l.meterProvider = mp
+	}
+}
+
+// WithHandler sets the handler the stats of every streaming generation are
+// passed to.
+func WithHandler(handler Handler) Option {
+	return func(l *LLM) {
+		l.handler = handler
+	}
+}
+
+// New wraps model, whose provider is e.g. "openai" or "googleai".
+func New(model llms.Model, provider string, opts ...Option) (*LLM, error) {
+	l := &LLM{
+		model:    model,
+		provider: provider,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.meterProvider == nil {
+		return l, nil
+	}
+	meter := l.meterProvider.Meter(instrumentationName)
+	var err error
+	l.timeToFirstToken, err = meter.Float64Histogram("llm.stream.time_to_first_token",
+		metric.WithDescription("Time from the call to the first chunk of streaming generations."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	l.tokensPerSecond, err = meter.Float64Histogram("llm.stream.tokens_per_second",
+		metric.WithDescription("Output tokens per second of streaming generations after the first chunk."), metric.WithUnit("{token}/s"))
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// GenerateContent calls the wrapped model, measuring the stream when the
+// call options have a streaming function.
+func (l *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.StreamingFunc == nil {
+		return l.model.GenerateContent(ctx, messages, options...)
+	}
+
+	start := time.Now()
+	var first, last time.Time
+	chunks := 0
+	stream := opts.StreamingFunc
+	options = append(options, llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+		last = time.Now()
+		if chunks == 0 {
+			first = last
+		}
+		chunks++
+		return stream(ctx, chunk)
+	}))
+	resp, err := l.model.GenerateContent(ctx, messages, options...)
+	if err != nil || chunks == 0 {
+		return resp, err
+	}
+
+	stats := Stats{
+		Provider:         l.provider,
+		Model:            opts.Model,
+		TimeToFirstToken: first.Sub(start),
+		Duration:         last.Sub(start),
+		Chunks:           chunks,
+		OutputTokens:     outputTokens(resp, chunks),
+	}
+	if stats.Model == "" {
+		stats.Model = l.defaultModel
+	}
+	if d := last.Sub(first); d > 0 {
+		stats.TokensPerSecond = float64(stats.OutputTokens) / d.Seconds()
+	}
+	l.record(ctx, stats)
+	return resp, nil
+}
+
+// Call calls the wrapped model.
+func (l *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, l, prompt, options...)
+}
+
+// record passes stats to the histograms and handler.
+func (l *LLM) record(ctx context.Context, stats Stats) {
+	if l.timeToFirstToken != nil {
+		attrs := metric.WithAttributes(
+			attribute.String("gen_ai.system", stats.Provider),
+			attribute.String("gen_ai.request.model", stats.Model),
+		)
+		l.timeToFirstToken.Record(ctx, stats.TimeToFirstToken.Seconds(), attrs)
+		if stats.TokensPerSecond > 0 {
+			l.tokensPerSecond.Record(ctx, stats.TokensPerSecond, attrs)
+		}
+	}
+	if l.handler != nil {
+		l.handler.HandleStreamingStats(ctx, stats)
+	}
+}
+
+// outputTokens returns the number of output tokens of resp reported by the
+// provider, or chunks.
+func outputTokens(resp *llms.ContentResponse, chunks int) int {
+	if n := llms.ResponseUsage(resp).CompletionTokens; n > 0 {
+		return n
+	}
+	return chunks
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+type streamingLLM struct {
+	chunks         []string
+	generationInfo map[string]any
+}
+
+func (s streamingLLM) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	content := ""
+	for _, chunk := range s.chunks {
+		if opts.StreamingFunc != nil {
+			if err := opts.StreamingFunc(ctx, []byte(chunk)); err != nil {
+				return nil, err
+			}
+		}
+		content += chunk
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: content, GenerationInfo: s.generationInfo}}}, nil
+}
+
+func (s streamingLLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, s, prompt, options...)
+}
+
+func TestStreamingStats(t *testing.T) {
+	t.Parallel()
+	var stats []Stats
+	model := streamingLLM{chunks: []string{"a", "b", "c"}, generationInfo: map[string]any{"CompletionTokens": 7}}
+	llm, err := New(model, "openai",
+		WithModel("gpt-4o"),
+		WithMeterProvider(noop.NewMeterProvider()),
+		WithHandler(HandlerFunc(func(_ context.Context, s Stats) { stats = append(stats, s) })),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var streamed string
+	_, err = llm.Call(context.Background(), "prompt", llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		streamed += string(chunk)
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streamed != "abc" {
+		t.Errorf("expected the chunks to be streamed, got %q", streamed)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("expected stats of 1 generation, got %d", len(stats))
+	}
+	got := stats[0]
+	if got.Provider != "openai" || got.Model != "gpt-4o" || got.Chunks != 3 || got.OutputTokens != 7 {
+		t.Errorf("unexpected stats %+v", got)
+	}
+	if got.TimeToFirstToken <= 0 || got.Duration < got.TimeToFirstToken {
+		t.Errorf("unexpected durations %+v", got)
+	}
+}
+
+func TestNonStreamingCallsAreNotMeasured(t *testing.T) {
+	t.Parallel()
+	handled := false
+	llm, err := New(streamingLLM{chunks: []string{"a"}}, "openai",
+		WithHandler(HandlerFunc(func(context.Context, Stats) { handled = true })))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := llm.Call(context.Background(), "prompt", llms.WithModel("gpt-4o")); err != nil {
+		t.Fatal(err)
+	}
+	if handled {
+		t.Error("expected no stats for a generation without streaming function")
+	}
+}
+
+func TestOutputTokensFallsBackToChunks(t *testing.T) {
+	t.Parallel()
+	resp := &llms.ContentResponse{Choices: []*llms.ContentChoice{{GenerationInfo: map[string]any{"output_tokens": int32(5)}}}}
+	if n := outputTokens(resp, 2); n != 5 {
+		t.Errorf("expected 5 output tokens, got %d", n)
+	}
+	if n := outputTokens(&llms.ContentResponse{Choices: []*llms.ContentChoice{{}}}, 2); n != 2 {
+		t.Errorf("expected the number of chunks, got %d", n)
+	}
+}
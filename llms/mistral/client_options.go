@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms/mistral/internal/mistralclient"
 )
 
 type clientOptions struct {
@@ -13,6 +14,7 @@ type clientOptions struct {
 	timeout          time.Duration
 	model            string
 	callbacksHandler callbacks.Handler
+	httpClient       mistralclient.Doer
 }
 
 type Option func(*clientOptions)
@@ -24,7 +26,7 @@ func WithAPIKey(apiKey string) Option {
 	}
 }
 
-// Sets the API endpoint for the Model being instantiated, defaults to "https://api.mistral.ai" (subject to change; this default is pulled from the mistral-go client library, https://github.com/Gage-Technologies/mistral-go).
+// Sets the API endpoint for the Model being instantiated, defaults to "https://api.mistral.ai".
 func WithEndpoint(endpoint string) Option {
 	return func(o *clientOptions) {
 		o.endpoint = endpoint
@@ -58,3 +60,10 @@ func WithCallbacksHandler(callbacksHandler callbacks.Handler) Option {
 		o.callbacksHandler = callbacksHandler
 	}
 }
+
+// Sets the HTTP client used to send the requests, e.g. to add middleware. The timeout set with WithTimeout doesn't apply to this client.
+func WithHTTPClient(client mistralclient.Doer) Option {
+	return func(o *clientOptions) {
+		o.httpClient = client
+	}
+}
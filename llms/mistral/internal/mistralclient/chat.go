@@ -0,0 +1,247 @@
+package mistralclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ChatRequest is a request to create a chat completion.
+type ChatRequest struct {
+	Model            string        `json:"model"`
+	Messages         []ChatMessage `json:"messages"`
+	Temperature      float64       `json:"temperature,omitempty"`
+	TopP             float64       `json:"top_p,omitempty"`
+	MaxTokens        int           `json:"max_tokens,omitempty"`
+	N                int           `json:"n,omitempty"`
+	RandomSeed       int           `json:"random_seed,omitempty"`
+	StopWords        []string      `json:"stop,omitempty"`
+	FrequencyPenalty float64       `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64       `json:"presence_penalty,omitempty"`
+	// SafePrompt injects the safety prompt of Mistral before the messages.
+	SafePrompt bool `json:"safe_prompt,omitempty"`
+
+	// ResponseFormat is the format of the response, JSON or JSON conforming
+	// to a schema.
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+
+	Tools []Tool `json:"tools,omitempty"`
+	// ToolChoice is "auto", "none", "any" or a ToolChoice.
+	ToolChoice        any   `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
+
+	Stream bool `json:"stream,omitempty"`
+
+	// StreamingFunc is a function to be called for each chunk of a streaming response.
+	// Return an error to stop streaming early.
+	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
+	// StreamingToolCallFunc is a function to be called for each tool call of
+	// a streaming response.
+	StreamingToolCallFunc func(ctx context.Context, delta llms.ToolCallDelta) error `json:"-"`
+}
+
+// StreamOptions are the options of a streaming response.
+type StreamOptions struct {
+	// IncludeUsage makes the last chunk report the usage of the request.
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ResponseFormat is the format of the response.
+type ResponseFormat struct {
+	// Type is "text", "json_object" or "json_schema".
+	Type       string              `json:"type"`
+	JSONSchema *ResponseJSONSchema `json:"json_schema,omitempty"`
+}
+
+// ResponseJSONSchema is the schema the response conforms to.
+type ResponseJSONSchema struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
+	Strict bool           `json:"strict,omitempty"`
+}
+
+// ChatMessage is a message of a chat.
+type ChatMessage struct {
+	// Role is "system", "user", "assistant" or "tool".
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Name      string     `json:"name,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID is the ID of the tool call the content of a tool message is
+	// the result of, Name the name of the called function.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// Tool is a tool the model may call.
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition is the definition of a function the model may call.
+type FunctionDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+// ToolChoice forces the call of a function.
+type ToolChoice struct {
+	Type     string             `json:"type"`
+	Function ToolChoiceFunction `json:"function"`
+}
+
+// ToolChoiceFunction is the function of a ToolChoice.
+type ToolChoiceFunction struct {
+	Name string `json:"name"`
+}
+
+// ToolCall is a call of a function by the model.
+type ToolCall struct {
+	// Index is the position of the tool call in the tool calls of the
+	// message, set in the chunks of streaming responses.
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall is the function called by a tool call.
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ChatResponse is the response to a chat request.
+type ChatResponse struct {
+	ID      string        `json:"id"`
+	Model   string        `json:"model"`
+	Created int64         `json:"created"`
+	Choices []*ChatChoice `json:"choices"`
+	Usage   Usage         `json:"usage"`
+}
+
+// ChatChoice is a choice of a chat response.
+type ChatChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// Usage is the token usage of a request.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// streamChunk is a chunk of a streaming response.
+type streamChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Created int64  `json:"created"`
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Content   string     `json:"content"`
+			ToolCalls []ToolCall `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage"`
+}
+
+func (r *ChatRequest) streaming() bool {
+	return r.StreamingFunc != nil || r.StreamingToolCallFunc != nil
+}
+
+func (c *Client) createChat(ctx context.Context, payload *ChatRequest) (*ChatResponse, error) {
+	payload.Stream = payload.streaming()
+
+	resp, err := c.do(ctx, "/v1/chat/completions", payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if payload.streaming() {
+		return parseStreamingChatResponse(ctx, resp, payload)
+	}
+	var response ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &response, nil
+}
+
+// parseStreamingChatResponse reads the server-sent events of a streaming
+// response, combining its chunks in a single response. Mistral sends each
+// tool call whole in a single chunk, rather than fragments of its arguments,
+// and the usage in the last chunk.
+func parseStreamingChatResponse(ctx context.Context, r *http.Response, payload *ChatRequest) (*ChatResponse, error) {
+	response := &ChatResponse{
+		Choices: []*ChatChoice{{Message: ChatMessage{Role: "assistant"}}},
+	}
+	choice := response.Choices[0]
+
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("decode stream chunk: %w", err)
+		}
+		response.ID, response.Model, response.Created = chunk.ID, chunk.Model, chunk.Created
+		if chunk.Usage != nil {
+			response.Usage = *chunk.Usage
+		}
+		for _, c := range chunk.Choices {
+			if c.FinishReason != "" {
+				choice.FinishReason = c.FinishReason
+			}
+			if c.Delta.Content != "" {
+				choice.Message.Content += c.Delta.Content
+				if payload.StreamingFunc != nil {
+					if err := payload.StreamingFunc(ctx, []byte(c.Delta.Content)); err != nil {
+						return nil, fmt.Errorf("streaming func returned an error: %w", err)
+					}
+				}
+			}
+			for _, toolCall := range c.Delta.ToolCalls {
+				index := len(choice.Message.ToolCalls)
+				if toolCall.Index != nil {
+					index = *toolCall.Index
+				}
+				choice.Message.ToolCalls = append(choice.Message.ToolCalls, toolCall)
+				if payload.StreamingToolCallFunc != nil {
+					err := payload.StreamingToolCallFunc(ctx, llms.ToolCallDelta{
+						Index:          index,
+						ID:             toolCall.ID,
+						Name:           toolCall.Function.Name,
+						ArgumentsDelta: toolCall.Function.Arguments,
+					})
+					if err != nil {
+						return nil, fmt.Errorf("streaming tool call func returned an error: %w", err)
+					}
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read stream: %w", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,169 @@
+package mistralclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultEndpoint is the endpoint of La Plateforme, the Mistral API.
+	DefaultEndpoint = "https://api.mistral.ai"
+	// DefaultMaxRetries is the default number of retries of the failed
+	// requests.
+	DefaultMaxRetries = 5
+	// DefaultTimeout is the default timeout of the requests.
+	DefaultTimeout = 120 * time.Second
+)
+
+// Client is a client for the Mistral API.
+type Client struct {
+	apiKey     string
+	endpoint   string
+	maxRetries int
+
+	httpClient Doer
+}
+
+// Option is an option for the Mistral client.
+type Option func(*Client)
+
+// Doer performs a HTTP request.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// WithHTTPClient allows setting a custom HTTP client, which replaces the
+// client with the timeout of New.
+func WithHTTPClient(client Doer) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// New returns a new Mistral client, retrying the requests failing because of
+// rate limits or server errors up to maxRetries times.
+func New(apiKey, endpoint string, maxRetries int, timeout time.Duration, opts ...Option) *Client {
+	c := &Client{
+		apiKey:     apiKey,
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		maxRetries: maxRetries,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+	if c.endpoint == "" {
+		c.endpoint = DefaultEndpoint
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CreateChat creates a chat completion, streamed if the request has a
+// streaming function.
+func (c *Client) CreateChat(ctx context.Context, r *ChatRequest) (*ChatResponse, error) {
+	return c.createChat(ctx, r)
+}
+
+func (c *Client) do(ctx context.Context, path string, payload any) (*http.Response, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+path, bytes.NewReader(payloadBytes))
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("send request: %w", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		if attempt >= c.maxRetries || !retryable(resp.StatusCode) {
+			defer resp.Body.Close()
+			return nil, errorFromResponse(resp)
+		}
+		resp.Body.Close()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff(attempt)):
+		}
+	}
+}
+
+// retryable reports whether the requests failing with status code are
+// retried.
+func retryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// backoff returns the delay before the retry following attempt, doubling
+// from half a second up to ten seconds.
+func backoff(attempt int) time.Duration {
+	d := 500 * time.Millisecond << attempt
+	if d <= 0 || d > 10*time.Second {
+		return 10 * time.Second
+	}
+	return d
+}
+
+// maxErrorBodySize is the size of the body of the error responses read for
+// their message.
+const maxErrorBodySize = 1 << 16
+
+func errorFromResponse(r *http.Response) error {
+	msg := fmt.Sprintf("API returned unexpected status code: %d", r.StatusCode)
+
+	// No need to check the error here: if it fails, we'll just return the
+	// status code.
+	body, _ := io.ReadAll(io.LimitReader(r.Body, maxErrorBodySize))
+	if detail := errorDetail(body); detail != "" {
+		return fmt.Errorf("%s: %s", msg, detail) // nolint:goerr113
+	}
+	return errors.New(msg) // nolint:goerr113
+}
+
+// errorDetail returns the message of an error response body: its message,
+// which Mistral sets to a string or an object, or the validation errors of
+// its detail, or else the body itself when it isn't JSON.
+func errorDetail(body []byte) string {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return ""
+	}
+	var resp struct {
+		Message json.RawMessage `json:"message"`
+		Detail  json.RawMessage `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return string(body)
+	}
+	var s string
+	switch {
+	case json.Unmarshal(resp.Message, &s) == nil && s != "":
+		return s
+	case len(resp.Message) > 0 && string(resp.Message) != "null":
+		return string(resp.Message)
+	case json.Unmarshal(resp.Detail, &s) == nil && s != "":
+		return s
+	case len(resp.Detail) > 0:
+		return string(resp.Detail)
+	}
+	return ""
+}
@@ -3,16 +3,30 @@ package mistral
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 
-	sdk "github.com/gage-technologies/mistral-go"
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/mistral/internal/mistralclient"
+)
+
+// ModelOpenMistral7b is the default model.
+const ModelOpenMistral7b = "open-mistral-7b"
+
+var (
+	// ErrEmptyResponse is returned when Mistral returns no choices.
+	ErrEmptyResponse = errors.New("no response")
+	// ErrUnsupportedMessageType is returned for the messages of a role Mistral doesn't support.
+	ErrUnsupportedMessageType = errors.New("unsupported message type")
+	// ErrInvalidContentType is returned for the content parts Mistral doesn't support in a message.
+	ErrInvalidContentType = errors.New("invalid content type")
 )
 
 // Model encapsulates an instantiated Mistral client, the client options used to instantiate the client, and a callback handler provided by Langchain Go.
 type Model struct {
-	client           *sdk.MistralClient
+	client           *mistralclient.Client
 	clientOptions    *clientOptions
 	CallbacksHandler callbacks.Handler
 }
@@ -23,258 +37,258 @@ var _ llms.Model = (*Model)(nil)
 // Instantiates a new Mistral Model.
 func New(opts ...Option) (*Model, error) {
 	options := &clientOptions{
-		apiKey:     os.Getenv("MISTRAL_API_KEY"),
-		endpoint:   sdk.Endpoint,
-		maxRetries: sdk.DefaultMaxRetries,
-		timeout:    sdk.DefaultTimeout,
-		model:      sdk.ModelOpenMistral7b,
+		apiKey:           os.Getenv("MISTRAL_API_KEY"),
+		endpoint:         mistralclient.DefaultEndpoint,
+		maxRetries:       mistralclient.DefaultMaxRetries,
+		timeout:          mistralclient.DefaultTimeout,
+		model:            ModelOpenMistral7b,
+		callbacksHandler: callbacks.SimpleHandler{},
 	}
 
 	for _, opt := range opts {
 		opt(options)
 	}
 
+	var clientOpts []mistralclient.Option
+	if options.httpClient != nil {
+		clientOpts = append(clientOpts, mistralclient.WithHTTPClient(options.httpClient))
+	}
 	return &Model{
 		clientOptions:    options,
-		client:           sdk.NewMistralClient(options.apiKey, options.endpoint, options.maxRetries, options.timeout),
-		CallbacksHandler: callbacks.SimpleHandler{},
+		client:           mistralclient.New(options.apiKey, options.endpoint, options.maxRetries, options.timeout, clientOpts...),
+		CallbacksHandler: options.callbacksHandler,
 	}, nil
 }
 
 // Call implements the langchaingo llms.Model interface.
 func (m *Model) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
-	callOptions := resolveDefaultOptions(sdk.DefaultChatRequestParams, m.clientOptions)
-	setCallOptions(options, callOptions)
-	mistralChatParams := mistralChatParamsFromCallOptions(callOptions)
-
-	messages := make([]sdk.ChatMessage, 0)
-	messages = append(messages, sdk.ChatMessage{
-		Role:    "user",
-		Content: prompt,
-	})
-	res, err := m.client.Chat("", messages, &mistralChatParams)
-	if err != nil {
-		m.CallbacksHandler.HandleLLMError(ctx, err)
-		return "", err
-	}
-	if len(res.Choices) != 1 {
-		m.CallbacksHandler.HandleLLMError(ctx, err)
-		return "", errors.New("unexpected response from Mistral SDK, length of the Choices slice must be 1")
-	}
-
-	return res.Choices[0].Message.Content, nil
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
 }
 
 // GenerateContent implements the langchaingo llms.Model interface.
 func (m *Model) GenerateContent(ctx context.Context, langchainMessages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
-	callOptions := resolveDefaultOptions(sdk.DefaultChatRequestParams, m.clientOptions)
-	setCallOptions(options, callOptions)
-	m.CallbacksHandler.HandleLLMGenerateContentStart(ctx, langchainMessages)
-
-	chatOpts := mistralChatParamsFromCallOptions(callOptions)
+	if m.CallbacksHandler != nil {
+		m.CallbacksHandler.HandleLLMGenerateContentStart(ctx, langchainMessages)
+	}
+	callOptions := &llms.CallOptions{Model: m.clientOptions.model}
+	for _, opt := range options {
+		opt(callOptions)
+	}
+	if err := callOptions.RateLimiter.Wait(ctx, langchainMessages, *callOptions); err != nil {
+		return nil, err
+	}
 
 	messages, err := convertToMistralChatMessages(langchainMessages)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("mistral: %w", err)
+	}
+	req, err := mistralChatRequestFromCallOptions(callOptions, messages)
+	if err != nil {
+		return nil, fmt.Errorf("mistral: %w", err)
 	}
 
-	if callOptions.StreamingFunc != nil {
-		return generateStreamingContent(ctx, m, callOptions, messages, chatOpts)
+	res, err := m.client.CreateChat(ctx, req)
+	if err != nil {
+		if m.CallbacksHandler != nil {
+			m.CallbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, fmt.Errorf("mistral: %w", err)
+	}
+	if len(res.Choices) == 0 {
+		return nil, ErrEmptyResponse
 	}
-	return generateNonStreamingContent(ctx, m, callOptions, messages, chatOpts)
-}
 
-func setCallOptions(options []llms.CallOption, callOpts *llms.CallOptions) {
-	for _, opt := range options {
-		opt(callOpts)
+	resp := convertMistralChatResponse(res)
+	if m.CallbacksHandler != nil {
+		m.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, resp)
 	}
+	return resp, nil
 }
 
-func resolveDefaultOptions(sdkDefaults sdk.ChatRequestParams, c *clientOptions) *llms.CallOptions {
-	// Supported models: https://docs.mistral.ai/platform/endpoints/
-	// TODO: Mistral also supports ResponseType, which, when set to "json", ensures the model's output is strictly a JSON object.
-	// Question: Should `ResponseType` be made a part of llms.CallOptions and pulled whenever Call or GenerateContent is called?
-	// The following llms.CallOptions are not supported at the moment by mistral SDK:
-	// MinLength, MaxLength,N (how many chat completion choices to generate for each input message), RepetitionPenalty, FrequencyPenalty, and PresencePenalty.
-	return &llms.CallOptions{
-		Model: c.model,
-		// MaxTokens is the maximum number of tokens to generate.
-		MaxTokens: sdkDefaults.MaxTokens,
-		// Temperature is the temperature for sampling, between 0 and 1.
-		Temperature: sdkDefaults.Temperature,
-		// TopP is the cumulative probability for top-p sampling.
-		TopP: sdkDefaults.TopP,
-		// Seed is a seed for deterministic sampling.
-		Seed: sdkDefaults.RandomSeed,
-		// Function defitions to include in the request.
-		Functions: make([]llms.FunctionDefinition, 0),
+// mistralChatRequestFromCallOptions returns the chat request of the call.
+// Mistral also supports N, FrequencyPenalty and PresencePenalty, but not
+// MinLength, MaxLength, TopK nor RepetitionPenalty.
+func mistralChatRequestFromCallOptions(callOpts *llms.CallOptions, messages []mistralclient.ChatMessage) (*mistralclient.ChatRequest, error) {
+	req := &mistralclient.ChatRequest{
+		Model:             callOpts.Model,
+		Messages:          messages,
+		Temperature:       callOpts.Temperature,
+		TopP:              callOpts.TopP,
+		MaxTokens:         callOpts.MaxTokens,
+		N:                 callOpts.N,
+		RandomSeed:        callOpts.Seed,
+		StopWords:         callOpts.StopWords,
+		FrequencyPenalty:  callOpts.FrequencyPenalty,
+		PresencePenalty:   callOpts.PresencePenalty,
+		ParallelToolCalls: callOpts.ParallelToolCalls,
+		StreamingFunc:     callOpts.StreamingFunc,
+
+		StreamingToolCallFunc: callOpts.StreamingToolCallFunc,
 	}
-}
 
-func mistralChatParamsFromCallOptions(callOpts *llms.CallOptions) sdk.ChatRequestParams {
-	chatOpts := sdk.DefaultChatRequestParams
-	chatOpts.MaxTokens = callOpts.MaxTokens
-	chatOpts.Temperature = callOpts.Temperature
-	chatOpts.RandomSeed = callOpts.Seed
-	chatOpts.Tools = make([]sdk.Tool, 0)
-	if len(callOpts.Tools) > 0 {
-		for _, tool := range callOpts.Tools {
-			chatOpts.Tools = append(chatOpts.Tools, sdk.Tool{
-				Type: "function",
-				Function: sdk.Function{
-					Name:        tool.Function.Name,
-					Description: tool.Function.Description,
-					Parameters:  tool.Function.Parameters,
-				},
-			})
-		}
-	} else {
-		for _, function := range callOpts.Functions {
-			chatOpts.Tools = append(chatOpts.Tools, sdk.Tool{
-				Type: "function",
-				Function: sdk.Function{
-					Name:        function.Name,
-					Description: function.Description,
-					Parameters:  function.Parameters,
-				},
-			})
+	for _, function := range callOpts.Functions {
+		req.Tools = append(req.Tools, mistralclient.Tool{
+			Type: "function",
+			Function: mistralclient.FunctionDefinition{
+				Name:        function.Name,
+				Description: function.Description,
+				Parameters:  function.Parameters,
+			},
+		})
+	}
+	for _, tool := range callOpts.Tools {
+		if tool.Function == nil {
+			return nil, fmt.Errorf("tool type %v not supported", tool.Type)
 		}
+		req.Tools = append(req.Tools, mistralclient.Tool{
+			Type: "function",
+			Function: mistralclient.FunctionDefinition{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				Parameters:  tool.Function.Parameters,
+			},
+		})
 	}
-	return chatOpts
-}
 
-func generateNonStreamingContent(ctx context.Context, m *Model, callOptions *llms.CallOptions, messages []sdk.ChatMessage, chatOpts sdk.ChatRequestParams) (*llms.ContentResponse, error) {
-	res, err := m.client.Chat(callOptions.Model, messages, &chatOpts)
-	m.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, nil)
+	mode, function, err := llms.ResolveToolChoice(callOpts.ToolChoice)
 	if err != nil {
-		m.CallbacksHandler.HandleLLMError(ctx, err)
 		return nil, err
 	}
+	if len(req.Tools) > 0 {
+		switch mode {
+		case "":
+		case llms.ToolChoiceAuto, llms.ToolChoiceNone:
+			req.ToolChoice = string(mode)
+		case llms.ToolChoiceRequired:
+			req.ToolChoice = "any"
+		case llms.ToolChoiceFunction:
+			req.ToolChoice = mistralclient.ToolChoice{
+				Type:     "function",
+				Function: mistralclient.ToolChoiceFunction{Name: function},
+			}
+		}
+	}
 
-	if len(res.Choices) < 1 {
-		m.CallbacksHandler.HandleLLMError(ctx, err)
-		return nil, errors.New("unexpected response from Mistral SDK, length of the Choices slice must be greater than or equal 1")
+	switch {
+	case callOpts.ResponseSchema != nil:
+		req.ResponseFormat = &mistralclient.ResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &mistralclient.ResponseJSONSchema{
+				Name:   callOpts.ResponseSchema.Name,
+				Schema: callOpts.ResponseSchema.Schema,
+				Strict: callOpts.ResponseSchema.Strict,
+			},
+		}
+	case callOpts.JSONMode:
+		req.ResponseFormat = &mistralclient.ResponseFormat{Type: "json_object"}
 	}
+	return req, nil
+}
 
-	langchainContentResponse := &llms.ContentResponse{
-		Choices: make([]*llms.ContentChoice, 0),
+func convertMistralChatResponse(res *mistralclient.ChatResponse) *llms.ContentResponse {
+	resp := &llms.ContentResponse{
+		Usage: llms.NewUsage(res.Usage.PromptTokens, res.Usage.CompletionTokens),
 	}
-	for idx, choice := range res.Choices {
-		langchainContentResponse.Choices = append(langchainContentResponse.Choices, &llms.ContentChoice{
+	for _, choice := range res.Choices {
+		contentChoice := &llms.ContentChoice{
 			Content:    choice.Message.Content,
-			StopReason: string(choice.FinishReason),
+			StopReason: choice.FinishReason,
 			GenerationInfo: map[string]any{
 				"created": res.Created,
 				"model":   res.Model,
 				"usage":   res.Usage,
 			},
-		})
-		toolCalls := choice.Message.ToolCalls
-		if len(toolCalls) > 0 {
-			langchainContentResponse.Choices[idx].FuncCall = (*llms.FunctionCall)(&toolCalls[0].Function)
-			for _, tool := range toolCalls {
-				langchainContentResponse.Choices[0].ToolCalls = append(langchainContentResponse.Choices[0].ToolCalls, llms.ToolCall{
-					ID:   tool.Id,
-					Type: string(tool.Type),
-					FunctionCall: &llms.FunctionCall{
-						Name:      tool.Function.Name,
-						Arguments: tool.Function.Arguments,
-					},
-				})
-			}
 		}
+		for _, toolCall := range choice.Message.ToolCalls {
+			contentChoice.ToolCalls = append(contentChoice.ToolCalls, llms.ToolCall{
+				ID:   toolCall.ID,
+				Type: toolCall.Type,
+				FunctionCall: &llms.FunctionCall{
+					Name:      toolCall.Function.Name,
+					Arguments: toolCall.Function.Arguments,
+				},
+			})
+		}
+		if len(contentChoice.ToolCalls) > 0 {
+			contentChoice.FuncCall = contentChoice.ToolCalls[0].FunctionCall
+		}
+		resp.Choices = append(resp.Choices, contentChoice)
 	}
-	m.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, langchainContentResponse)
-
-	return langchainContentResponse, nil
+	return resp
 }
 
-func generateStreamingContent(ctx context.Context, m *Model, callOptions *llms.CallOptions, messages []sdk.ChatMessage, chatOpts sdk.ChatRequestParams) (*llms.ContentResponse, error) {
-	chatResChan, err := m.client.ChatStream(callOptions.Model, messages, &chatOpts)
-	if err != nil {
-		m.CallbacksHandler.HandleLLMError(ctx, err)
-		return nil, err
-	}
-	langchainContentResponse := &llms.ContentResponse{
-		Choices: make([]*llms.ContentChoice, 1),
-	}
-	langchainContentResponse.Choices[0] = &llms.ContentChoice{
-		Content:        "",
-		GenerationInfo: map[string]any{},
-	}
-
-	for chatResChunk := range chatResChan {
-		chunkStr := ""
-		langchainContentResponse.Choices[0].GenerationInfo["created"] = chatResChunk.Created
-		langchainContentResponse.Choices[0].GenerationInfo["model"] = chatResChunk.Model
-		langchainContentResponse.Choices[0].GenerationInfo["usage"] = chatResChunk.Usage
-		if chatResChunk.Error == nil {
-			for _, choice := range chatResChunk.Choices {
-				chunkStr += choice.Delta.Content
-				langchainContentResponse.Choices[0].Content += choice.Delta.Content
-				langchainContentResponse.Choices[0].StopReason = string(choice.FinishReason)
-				if len(choice.Delta.ToolCalls) > 0 {
-					langchainContentResponse.Choices[0].FuncCall = (*llms.FunctionCall)(&choice.Delta.ToolCalls[0].Function)
-					for _, tool := range choice.Delta.ToolCalls {
-						langchainContentResponse.Choices[0].ToolCalls = append(langchainContentResponse.Choices[0].ToolCalls, llms.ToolCall{
-							ID:   tool.Id,
-							Type: string(tool.Type),
-							FunctionCall: &llms.FunctionCall{
-								Name:      tool.Function.Name,
-								Arguments: tool.Function.Arguments,
-							},
-						})
-					}
+func convertToMistralChatMessages(langchainMessages []llms.MessageContent) ([]mistralclient.ChatMessage, error) {
+	messages := make([]mistralclient.ChatMessage, 0, len(langchainMessages))
+	for _, msg := range langchainMessages {
+		role, err := mistralChatMessageRole(msg.Role)
+		if err != nil {
+			return nil, err
+		}
+		if role == "tool" {
+			// Each result of the parallel tool calls is a message of its own.
+			for _, part := range msg.Parts {
+				p, ok := part.(llms.ToolCallResponse)
+				if !ok {
+					return nil, fmt.Errorf("%w for tool message: %T", ErrInvalidContentType, part)
 				}
+				messages = append(messages, mistralclient.ChatMessage{
+					Role:       role,
+					Content:    p.Content,
+					Name:       p.Name,
+					ToolCallID: p.ToolCallID,
+				})
 			}
-			err := callOptions.StreamingFunc(ctx, []byte(chunkStr))
-			if err != nil {
-				return langchainContentResponse, err
-			}
-		} else {
-			return langchainContentResponse, chatResChunk.Error
+			continue
 		}
-	}
-
-	return langchainContentResponse, nil
-}
 
-func convertToMistralChatMessages(langchainMessages []llms.MessageContent) ([]sdk.ChatMessage, error) {
-	messages := make([]sdk.ChatMessage, 0)
-	for _, msg := range langchainMessages {
+		chatMsg := mistralclient.ChatMessage{Role: role}
+		var text strings.Builder
 		for _, part := range msg.Parts {
 			switch p := part.(type) {
 			case llms.TextContent:
-				chatMsg := sdk.ChatMessage{Content: p.Text, Role: string(msg.Role)}
-				setMistralChatMessageRole(&msg, &chatMsg) // #nosec G601
-				if chatMsg.Content != "" && chatMsg.Role != "" {
-					messages = append(messages, chatMsg)
-				}
-			case llms.ToolCallResponse:
-				chatMsg := sdk.ChatMessage{Role: string(msg.Role), Content: p.Content}
-				setMistralChatMessageRole(&msg, &chatMsg) // #nosec G601
-				messages = append(messages, chatMsg)
+				text.WriteString(p.Text)
 			case llms.ToolCall:
-				chatMsg := sdk.ChatMessage{Role: string(msg.Role), ToolCalls: []sdk.ToolCall{{Id: p.ID, Type: sdk.ToolTypeFunction, Function: sdk.FunctionCall{Name: p.FunctionCall.Name, Arguments: p.FunctionCall.Arguments}}}}
-				setMistralChatMessageRole(&msg, &chatMsg) // #nosec G601
-				messages = append(messages, chatMsg)
+				if role != "assistant" || p.FunctionCall == nil {
+					return nil, fmt.Errorf("%w for %s message: tool call", ErrInvalidContentType, role)
+				}
+				chatMsg.ToolCalls = append(chatMsg.ToolCalls, mistralclient.ToolCall{
+					ID:   p.ID,
+					Type: "function",
+					Function: mistralclient.FunctionCall{
+						Name:      p.FunctionCall.Name,
+						Arguments: p.FunctionCall.Arguments,
+					},
+				})
 			default:
-				return nil, errors.New("unsupported content type encountered while preparing chat messages to send to mistral platform")
+				return nil, fmt.Errorf("%w for %s message: %T", ErrInvalidContentType, role, part)
 			}
 		}
+		chatMsg.Content = text.String()
+		messages = append(messages, chatMsg)
 	}
 	return messages, nil
 }
 
-func setMistralChatMessageRole(msg *llms.MessageContent, chatMsg *sdk.ChatMessage) {
-	switch msg.Role {
+func mistralChatMessageRole(role llms.ChatMessageType) (string, error) {
+	switch role {
 	case llms.ChatMessageTypeAI:
-		chatMsg.Role = "assistant"
+		return "assistant", nil
 	case llms.ChatMessageTypeGeneric, llms.ChatMessageTypeHuman:
-		chatMsg.Role = "user"
+		return "user", nil
 	case llms.ChatMessageTypeFunction, llms.ChatMessageTypeTool:
-		chatMsg.Role = "tool"
+		return "tool", nil
 	case llms.ChatMessageTypeSystem:
-		chatMsg.Role = "system"
+		return "system", nil
+	default:
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedMessageType, role)
+	}
+}
+
+// Capabilities implements the llms.CapabilityReporter interface, reporting
+// the capabilities of the model.
+func (m *Model) Capabilities() llms.ModelCapabilities {
+	if capabilities, ok := llms.LookupModelCapabilities(m.clientOptions.model); ok {
+		return capabilities
 	}
+	return llms.ModelCapabilities{Streaming: true}
 }
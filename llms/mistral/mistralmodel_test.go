@@ -0,0 +1,147 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func newTestModel(t *testing.T, handler http.HandlerFunc, opts ...Option) *Model {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	model, err := New(append([]Option{WithAPIKey("test-key"), WithEndpoint(server.URL)}, opts...)...)
+	require.NoError(t, err)
+	return model
+}
+
+func TestGenerateContentToolCalls(t *testing.T) {
+	t.Parallel()
+	var request map[string]any
+	model := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_, _ = io.WriteString(w, `{
+			"id": "1", "model": "mistral-large-latest", "created": 1,
+			"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {
+				"role": "assistant", "content": "",
+				"tool_calls": [{"id": "abcdef123", "type": "function", "function": {"name": "weather", "arguments": "{\"city\":\"Rome\"}"}}]
+			}}],
+			"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+		}`)
+	}, WithModel("mistral-large-latest"))
+
+	messages := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Paris and Rome?"),
+		{
+			Role: llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{
+				llms.ToolCall{ID: "123456789", Type: "function", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}},
+			},
+		},
+		{
+			Role: llms.ChatMessageTypeTool,
+			Parts: []llms.ContentPart{
+				llms.ToolCallResponse{ToolCallID: "123456789", Name: "weather", Content: "sunny"},
+			},
+		},
+	}
+	tools := []llms.Tool{{
+		Type:     "function",
+		Function: &llms.FunctionDefinition{Name: "weather", Parameters: map[string]any{"type": "object"}},
+	}}
+	resp, err := model.GenerateContent(context.Background(), messages,
+		llms.WithTools(tools), llms.WithToolChoice(llms.ToolChoiceRequired), llms.WithSeed(42))
+	require.NoError(t, err)
+
+	assert.Equal(t, "mistral-large-latest", request["model"])
+	assert.Equal(t, "any", request["tool_choice"])
+	assert.InDelta(t, 42, request["random_seed"], 0)
+	sent, ok := request["messages"].([]any)
+	require.True(t, ok)
+	require.Len(t, sent, 3)
+	assert.Equal(t, map[string]any{
+		"role": "tool", "content": "sunny", "name": "weather", "tool_call_id": "123456789",
+	}, sent[2])
+
+	require.Len(t, resp.Choices, 1)
+	require.Len(t, resp.Choices[0].ToolCalls, 1)
+	assert.Equal(t, "abcdef123", resp.Choices[0].ToolCalls[0].ID)
+	assert.Equal(t, `{"city":"Rome"}`, resp.Choices[0].FuncCall.Arguments)
+	assert.Equal(t, llms.NewUsage(10, 5), resp.Usage)
+}
+
+func TestGenerateContentJSONMode(t *testing.T) {
+	t.Parallel()
+	var request map[string]any
+	model := newTestModel(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_, _ = io.WriteString(w, `{"choices": [{"message": {"role": "assistant", "content": "{\"ok\":true}"}, "finish_reason": "stop"}]}`)
+	})
+
+	resp, err := model.Call(context.Background(), "Answer in JSON.", llms.WithJSONMode())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"type": "json_object"}, request["response_format"])
+	assert.Equal(t, `{"ok":true}`, resp)
+}
+
+func TestGenerateContentStreaming(t *testing.T) {
+	t.Parallel()
+	model := newTestModel(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"id":"1","model":"open-mistral-7b","choices":[{"index":0,"delta":{"role":"assistant","content":"Bonjour"}}]}
+
+data: {"id":"1","model":"open-mistral-7b","choices":[{"index":0,"delta":{"content":" !"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}
+
+data: [DONE]
+`)
+	})
+
+	var chunks []string
+	resp, err := model.GenerateContent(context.Background(),
+		[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Say hello in French.")},
+		llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+			chunks = append(chunks, string(chunk))
+			return nil
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bonjour", " !"}, chunks)
+	assert.Equal(t, "Bonjour !", resp.Choices[0].Content)
+	assert.Equal(t, "stop", resp.Choices[0].StopReason)
+	assert.Equal(t, llms.NewUsage(3, 2), resp.Usage)
+}
+
+func TestGenerateContentRetries(t *testing.T) {
+	t.Parallel()
+	var attempts atomic.Int32
+	model := newTestModel(t, func(w http.ResponseWriter, _ *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = io.WriteString(w, `{"object":"error","message":"Requests rate limit exceeded","type":"rate_limited"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"choices": [{"message": {"role": "assistant", "content": "Hello"}, "finish_reason": "stop"}]}`)
+	})
+
+	resp, err := model.Call(context.Background(), "Hello")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", resp)
+	assert.Equal(t, int32(2), attempts.Load())
+
+	model = newTestModel(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"message":"Unauthorized","request_id":"1"}`)
+	}, WithMaxRetries(0))
+	_, err = model.Call(context.Background(), "Hello")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401: Unauthorized")
+}
@@ -0,0 +1,93 @@
+package llms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DetectMIMEType returns the MIME type of data detected from its first bytes,
+// such as "image/png", "application/pdf" or "audio/wav", or
+// "application/octet-stream" if unknown.
+func DetectMIMEType(data []byte) string {
+	// The formats http.DetectContentType doesn't know, or knows by other
+	// names than the models.
+	switch {
+	case bytes.HasPrefix(data, []byte("fLaC")):
+		return "audio/flac"
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0 && data[1]&0x06 != 0:
+		// An MPEG audio frame without ID3 tag.
+		return "audio/mpeg"
+	case len(data) >= 12 && bytes.Equal(data[4:8], []byte("ftyp")) &&
+		(bytes.Equal(data[8:12], []byte("M4A ")) || bytes.Equal(data[8:12], []byte("M4B "))):
+		return "audio/mp4"
+	}
+
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	switch mimeType {
+	case "audio/wave":
+		return "audio/wav"
+	case "application/ogg":
+		return "audio/ogg"
+	}
+	return mimeType
+}
+
+// ParseDataURL decodes a base64 data URL, such as the string of a
+// BinaryContent, into a BinaryContent.
+func ParseDataURL(url string) (BinaryContent, error) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return BinaryContent{}, fmt.Errorf("not a data URL: %.32q", url)
+	}
+	mediaType, data, ok := strings.Cut(rest, ",")
+	if !ok {
+		return BinaryContent{}, errors.New("invalid data URL: missing data")
+	}
+	mimeType, ok := strings.CutSuffix(mediaType, ";base64")
+	if !ok {
+		return BinaryContent{}, errors.New("invalid data URL: only base64 data is supported")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return BinaryContent{}, fmt.Errorf("invalid data URL: %w", err)
+	}
+	return BinaryPart(mimeType, decoded), nil
+}
+
+// ErrContentTooLarge is the error ContentTooLargeError matches with
+// errors.Is.
+var ErrContentTooLarge = errors.New("content too large")
+
+// ContentTooLargeError is returned by the models when binary content exceeds
+// the size their provider accepts inline, before sending the request.
+type ContentTooLargeError struct {
+	MIMEType string
+	// Size and Limit are the size of the content and the limit, in bytes.
+	Size  int
+	Limit int
+}
+
+func (e *ContentTooLargeError) Error() string {
+	return fmt.Sprintf("%v: %s of %d bytes, the limit is %d bytes", ErrContentTooLarge, e.MIMEType, e.Size, e.Limit)
+}
+
+func (e *ContentTooLargeError) Unwrap() error {
+	return ErrContentTooLarge
+}
+
+// CheckContentSize returns a ContentTooLargeError if the data of part is
+// larger than limit bytes.
+func CheckContentSize(part BinaryContent, limit int) error {
+	if len(part.Data) > limit {
+		return &ContentTooLargeError{MIMEType: part.MIMEType, Size: len(part.Data), Limit: limit}
+	}
+	return nil
+}
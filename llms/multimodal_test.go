@@ -0,0 +1,63 @@
+package llms
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectMIMEType(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"png", []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR"), "image/png"},
+		{"jpeg", []byte("\xFF\xD8\xFF\xE0\x00\x10JFIF"), "image/jpeg"},
+		{"pdf", []byte("%PDF-1.7\n"), "application/pdf"},
+		{"wav", []byte("RIFF\x24\x08\x00\x00WAVEfmt "), "audio/wav"},
+		{"mp3 with ID3 tag", []byte("ID3\x03\x00\x00\x00\x00\x00\x00"), "audio/mpeg"},
+		{"mp3 frame", []byte("\xFF\xFB\x90\x64\x00\x00"), "audio/mpeg"},
+		{"flac", []byte("fLaC\x00\x00\x00\x22"), "audio/flac"},
+		{"ogg", []byte("OggS\x00\x02\x00\x00"), "audio/ogg"},
+		{"text", []byte("Hello, world!"), "text/plain"},
+		{"unknown", []byte{0x00, 0x01, 0x02, 0x03}, "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, DetectMIMEType(tt.data))
+		})
+	}
+
+	assert.Equal(t, "application/pdf", BinaryPart("", []byte("%PDF-1.7\n")).MIMEType)
+}
+
+func TestParseDataURL(t *testing.T) {
+	t.Parallel()
+	part := BinaryPart("image/png", []byte("\x89PNG\x0D\x0A\x1A\x0A"))
+	parsed, err := ParseDataURL(part.String())
+	require.NoError(t, err)
+	assert.Equal(t, part, parsed)
+
+	_, err = ParseDataURL("https://example.com/image.png")
+	require.Error(t, err)
+	_, err = ParseDataURL("data:text/plain,Hello")
+	require.Error(t, err)
+}
+
+func TestCheckContentSize(t *testing.T) {
+	t.Parallel()
+	part := BinaryPart("application/pdf", make([]byte, 10))
+	require.NoError(t, CheckContentSize(part, 10))
+
+	err := CheckContentSize(part, 5)
+	require.ErrorIs(t, err, ErrContentTooLarge)
+	var tooLarge *ContentTooLargeError
+	require.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, 10, tooLarge.Size)
+	assert.Equal(t, "content too large: application/pdf of 10 bytes, the limit is 5 bytes", err.Error())
+}
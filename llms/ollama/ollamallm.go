@@ -55,6 +55,10 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(&opts)
 	}
 
+	if err := opts.RateLimiter.Wait(ctx, messages, opts); err != nil {
+		return nil, err
+	}
+
 	// Override LLM model if set as llms.CallOption
 	model := o.options.model
 	if opts.Model != "" {
@@ -158,7 +162,10 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		},
 	}
 
-	response := &llms.ContentResponse{Choices: choices}
+	response := &llms.ContentResponse{
+		Choices: choices,
+		Usage:   llms.NewUsage(resp.PromptEvalCount, resp.EvalCount),
+	}
 
 	if o.CallbacksHandler != nil {
 		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, response)
@@ -230,3 +237,12 @@ func makeOllamaOptionsFromOptions(ollamaOptions ollamaclient.Options, opts llms.
 
 	return ollamaOptions
 }
+
+// Capabilities implements the llms.CapabilityReporter interface, reporting
+// the capabilities of the model.
+func (o *LLM) Capabilities() llms.ModelCapabilities {
+	if capabilities, ok := llms.LookupModelCapabilities(o.options.model); ok {
+		return capabilities
+	}
+	return llms.ModelCapabilities{Streaming: true}
+}
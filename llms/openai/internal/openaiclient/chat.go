@@ -43,6 +43,10 @@ type ChatRequest struct {
 	PresencePenalty     float64  `json:"presence_penalty,omitempty"`
 	Seed                int      `json:"seed,omitempty"`
 
+	// ReasoningEffort is how much the reasoning models reason before their
+	// answer: "low", "medium" or "high".
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+
 	// ResponseFormat is the format of the response.
 	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 
@@ -59,6 +63,9 @@ type ChatRequest struct {
 	// This can be either a string or a ToolChoice object.
 	// If it is a string, it should be one of 'none', or 'auto', otherwise it should be a ToolChoice object specifying a specific tool to use.
 	ToolChoice any `json:"tool_choice,omitempty"`
+	// ParallelToolCalls enables parallel function calling during tool use. It
+	// can only be set along with Tools.
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
 
 	// Options for streaming response. Only set this when you set stream: true.
 	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
@@ -66,6 +73,12 @@ type ChatRequest struct {
 	// StreamingFunc is a function to be called for each chunk of a streaming response.
 	// Return an error to stop streaming early.
 	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
+	// StreamingToolCallFunc is a function to be called for each fragment of
+	// the tool calls of a streaming response.
+	StreamingToolCallFunc func(ctx context.Context, delta llms.ToolCallDelta) error `json:"-"`
+	// StreamingReasoningFunc is a function to be called for each chunk of
+	// the reasoning of a streaming response.
+	StreamingReasoningFunc func(ctx context.Context, chunk []byte) error `json:"-"`
 
 	// Deprecated: use Tools instead.
 	Functions []FunctionDefinition `json:"functions,omitempty"`
@@ -103,6 +116,9 @@ type ToolFunction struct {
 
 // ToolCall is a call to a tool.
 type ToolCall struct {
+	// Index is the position of the tool call in the tool calls of the
+	// message, set on the fragments of streaming responses.
+	Index    *int         `json:"index,omitempty"`
 	ID       string       `json:"id,omitempty"`
 	Type     ToolType     `json:"type"`
 	Function ToolFunction `json:"function,omitempty"`
@@ -123,6 +139,22 @@ type ResponseFormatJSONSchema struct {
 	Name   string                            `json:"name"`
 	Strict bool                              `json:"strict"`
 	Schema *ResponseFormatJSONSchemaProperty `json:"schema"`
+	// RawSchema is an arbitrary JSON schema, sent instead of Schema when
+	// set.
+	RawSchema any `json:"-"`
+}
+
+// MarshalJSON marshals the schema, sending RawSchema when set.
+func (s ResponseFormatJSONSchema) MarshalJSON() ([]byte, error) {
+	type alias ResponseFormatJSONSchema
+	if s.RawSchema == nil {
+		return json.Marshal(alias(s))
+	}
+	return json.Marshal(struct {
+		Name   string `json:"name"`
+		Strict bool   `json:"strict"`
+		Schema any    `json:"schema"`
+	}{s.Name, s.Strict, s.RawSchema})
 }
 
 // ResponseFormat is the format of the response.
@@ -157,6 +189,10 @@ type ChatMessage struct { //nolint:musttag
 	// ToolCallID is the ID of the tool call this message is for.
 	// Only present in tool messages.
 	ToolCallID string `json:"tool_call_id,omitempty"`
+
+	// ReasoningContent is the reasoning of the model before its answer,
+	// returned by some OpenAI-compatible servers. It isn't sent back.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
 }
 
 func (m ChatMessage) MarshalJSON() ([]byte, error) {
@@ -168,12 +204,15 @@ func (m ChatMessage) MarshalJSON() ([]byte, error) {
 		m.MultiContent = nil
 	}
 	if len(m.MultiContent) > 0 {
+		content, err := contentParts(m.MultiContent)
+		if err != nil {
+			return nil, err
+		}
 		msg := struct {
-			Role         string             `json:"role"`
-			Content      string             `json:"-"`
-			MultiContent []llms.ContentPart `json:"content,omitempty"`
-			Name         string             `json:"name,omitempty"`
-			ToolCalls    []ToolCall         `json:"tool_calls,omitempty"`
+			Role         string     `json:"role"`
+			MultiContent []any      `json:"content,omitempty"`
+			Name         string     `json:"name,omitempty"`
+			ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
 
 			// Deprecated: use ToolCalls instead.
 			FunctionCall *FunctionCall `json:"function_call,omitempty"`
@@ -181,7 +220,14 @@ func (m ChatMessage) MarshalJSON() ([]byte, error) {
 			// ToolCallID is the ID of the tool call this message is for.
 			// Only present in tool messages.
 			ToolCallID string `json:"tool_call_id,omitempty"`
-		}(m)
+		}{
+			Role:         m.Role,
+			MultiContent: content,
+			Name:         m.Name,
+			ToolCalls:    m.ToolCalls,
+			FunctionCall: m.FunctionCall,
+			ToolCallID:   m.ToolCallID,
+		}
 		return json.Marshal(msg)
 	}
 	msg := struct {
@@ -196,6 +242,8 @@ func (m ChatMessage) MarshalJSON() ([]byte, error) {
 		// ToolCallID is the ID of the tool call this message is for.
 		// Only present in tool messages.
 		ToolCallID string `json:"tool_call_id,omitempty"`
+
+		ReasoningContent string `json:"-"`
 	}(m)
 	return json.Marshal(msg)
 }
@@ -221,6 +269,8 @@ func (m *ChatMessage) UnmarshalJSON(data []byte) error {
 		// ToolCallID is the ID of the tool call this message is for.
 		// Only present in tool messages.
 		ToolCallID string `json:"tool_call_id,omitempty"`
+
+		ReasoningContent string `json:"reasoning_content,omitempty"`
 	}{}
 	err := json.Unmarshal(data, &msg)
 	if err != nil {
@@ -286,6 +336,9 @@ type ChatUsage struct {
 	CompletionTokensDetails struct {
 		ReasoningTokens int `json:"reasoning_tokens"`
 	} `json:"completion_tokens_details"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
 }
 
 // ChatCompletionResponse is a response to a chat request.
@@ -306,6 +359,9 @@ type Usage struct {
 	CompletionTokensDetails struct {
 		ReasoningTokens int `json:"reasoning_tokens"`
 	} `json:"completion_tokens_details"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
 }
 
 // StreamedChatResponsePayload is a chunk from the stream.
@@ -320,10 +376,14 @@ type StreamedChatResponsePayload struct {
 			Role         string        `json:"role,omitempty"`
 			Content      string        `json:"content,omitempty"`
 			FunctionCall *FunctionCall `json:"function_call,omitempty"`
+			// ReasoningContent is the reasoning of some OpenAI-compatible
+			// servers.
+			ReasoningContent string `json:"reasoning_content,omitempty"`
 			// ToolCalls is a list of tools that were called in the message.
 			ToolCalls []*ToolCall `json:"tool_calls,omitempty"`
 		} `json:"delta,omitempty"`
 		FinishReason FinishReason `json:"finish_reason,omitempty"`
+		LogProbs     *LogProbs    `json:"logprobs,omitempty"`
 	} `json:"choices,omitempty"`
 	SystemFingerprint string `json:"system_fingerprint"`
 	// An optional field that will only be present when you set stream_options: {"include_usage": true} in your request.
@@ -365,8 +425,13 @@ type FunctionCall struct {
 	Arguments string `json:"arguments"`
 }
 
+// streaming reports whether the response to the request is streamed.
+func (r *ChatRequest) streaming() bool {
+	return r.StreamingFunc != nil || r.StreamingToolCallFunc != nil || r.StreamingReasoningFunc != nil
+}
+
 func (c *Client) createChat(ctx context.Context, payload *ChatRequest) (*ChatCompletionResponse, error) {
-	if payload.StreamingFunc != nil {
+	if payload.streaming() {
 		payload.Stream = true
 		if payload.StreamOptions == nil {
 			payload.StreamOptions = &StreamOptions{IncludeUsage: true}
@@ -374,7 +439,7 @@ func (c *Client) createChat(ctx context.Context, payload *ChatRequest) (*ChatCom
 	}
 	// Build request payload
 
-	payloadBytes, err := json.Marshal(payload)
+	payloadBytes, err := c.marshalBody(payload)
 	if err != nil {
 		return nil, err
 	}
@@ -384,7 +449,7 @@ func (c *Client) createChat(ctx context.Context, payload *ChatRequest) (*ChatCom
 	if c.baseURL == "" {
 		c.baseURL = defaultBaseURL
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.buildURL("/chat/completions", payload.Model), body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.buildURL(c.chatCompletionsPath, payload.Model), body)
 	if err != nil {
 		return nil, err
 	}
@@ -399,18 +464,9 @@ func (c *Client) createChat(ctx context.Context, payload *ChatRequest) (*ChatCom
 	defer r.Body.Close()
 
 	if r.StatusCode != http.StatusOK {
-		msg := fmt.Sprintf("API returned unexpected status code: %d", r.StatusCode)
-
-		// No need to check the error here: if it fails, we'll just return the
-		// status code.
-		var errResp errorMessage
-		if err := json.NewDecoder(r.Body).Decode(&errResp); err != nil {
-			return nil, errors.New(msg) // nolint:goerr113
-		}
-
-		return nil, fmt.Errorf("%s: %s", msg, errResp.Error.Message) // nolint:goerr113
+		return nil, errorFromResponse(r)
 	}
-	if payload.StreamingFunc != nil {
+	if payload.streaming() {
 		return parseStreamingChatResponse(ctx, r, payload)
 	}
 	// Parse response
@@ -427,7 +483,11 @@ func parseStreamingChatResponse(ctx context.Context, r *http.Response, payload *
 		defer close(responseChan)
 		for scanner.Scan() {
 			line := scanner.Text()
-			if line == "" {
+			// Skip the blank lines, and the comments and other fields of
+			// the events some servers send, e.g. to keep the connection
+			// alive.
+			if line == "" || strings.HasPrefix(line, ":") || strings.HasPrefix(line, "event:") ||
+				strings.HasPrefix(line, "id:") || strings.HasPrefix(line, "retry:") {
 				continue
 			}
 
@@ -475,12 +535,27 @@ func combineStreamingChatResponse(
 			response.Usage.PromptTokens = streamResponse.Usage.PromptTokens
 			response.Usage.TotalTokens = streamResponse.Usage.TotalTokens
 			response.Usage.CompletionTokensDetails.ReasoningTokens = streamResponse.Usage.CompletionTokensDetails.ReasoningTokens
+			response.Usage.PromptTokensDetails.CachedTokens = streamResponse.Usage.PromptTokensDetails.CachedTokens
 		}
 
 		if len(streamResponse.Choices) == 0 {
 			continue
 		}
 		choice := streamResponse.Choices[0]
+		if choice.Delta.ReasoningContent != "" {
+			response.Choices[0].Message.ReasoningContent += choice.Delta.ReasoningContent
+			if payload.StreamingReasoningFunc != nil {
+				if err := payload.StreamingReasoningFunc(ctx, []byte(choice.Delta.ReasoningContent)); err != nil {
+					return nil, fmt.Errorf("streaming reasoning func returned an error: %w", err)
+				}
+			}
+		}
+		if choice.LogProbs != nil {
+			if response.Choices[0].LogProbs == nil {
+				response.Choices[0].LogProbs = &LogProbs{}
+			}
+			response.Choices[0].LogProbs.Content = append(response.Choices[0].LogProbs.Content, choice.LogProbs.Content...)
+		}
 		chunk := []byte(choice.Delta.Content)
 		response.Choices[0].Message.Content += choice.Delta.Content
 		response.Choices[0].FinishReason = choice.FinishReason
@@ -490,8 +565,16 @@ func combineStreamingChatResponse(
 		}
 
 		if len(choice.Delta.ToolCalls) > 0 {
-			chunk, response.Choices[0].Message.ToolCalls = updateToolCalls(response.Choices[0].Message.ToolCalls,
+			var deltas []llms.ToolCallDelta
+			chunk, response.Choices[0].Message.ToolCalls, deltas = updateToolCalls(response.Choices[0].Message.ToolCalls,
 				choice.Delta.ToolCalls)
+			if payload.StreamingToolCallFunc != nil {
+				for _, delta := range deltas {
+					if err := payload.StreamingToolCallFunc(ctx, delta); err != nil {
+						return nil, fmt.Errorf("streaming tool call func returned an error: %w", err)
+					}
+				}
+			}
 		}
 
 		if payload.StreamingFunc != nil {
@@ -514,29 +597,65 @@ func updateFunctionCall(message ChatMessage, functionCall *FunctionCall) []byte
 	return chunk
 }
 
-func updateToolCalls(tools []ToolCall, delta []*ToolCall) ([]byte, []ToolCall) {
+// updateToolCalls merges the fragments of tool calls of delta into tools, by
+// index when the server sets it, and returns them as a chunk and as deltas.
+func updateToolCalls(tools []ToolCall, delta []*ToolCall) ([]byte, []ToolCall, []llms.ToolCallDelta) {
 	if len(delta) == 0 {
-		return []byte{}, tools
+		return []byte{}, tools, nil
 	}
+	deltas := make([]llms.ToolCallDelta, 0, len(delta))
 	for _, t := range delta {
-		// if we have arguments append to the last Tool call
-		if t.Type == `` && t.Function.Arguments != `` {
-			lindex := len(tools) - 1
-			if lindex < 0 {
+		var index int
+		switch {
+		case t.Index != nil:
+			index = *t.Index
+		case t.Type == ``:
+			// if we have arguments append to the last Tool call
+			if t.Function.Arguments == `` || len(tools) == 0 {
 				continue
 			}
-
-			tools[lindex].Function.Arguments += t.Function.Arguments
-			continue
+			index = len(tools) - 1
+		default:
+			index = len(tools)
 		}
 
-		// Otherwise, this is a new tool call, append that to the stack
-		tools = append(tools, *t)
+		if i := toolCallPosition(tools, index); i >= 0 {
+			tools[i].Function.Arguments += t.Function.Arguments
+			if tools[i].ID == `` {
+				tools[i].ID = t.ID
+			}
+			if tools[i].Function.Name == `` {
+				tools[i].Function.Name = t.Function.Name
+			}
+		} else {
+			// Otherwise, this is a new tool call, append that to the stack
+			tools = append(tools, *t)
+		}
+		deltas = append(deltas, llms.ToolCallDelta{
+			Index:          index,
+			ID:             t.ID,
+			Name:           t.Function.Name,
+			ArgumentsDelta: t.Function.Arguments,
+		})
 	}
 
 	chunk, _ := json.Marshal(delta) // nolint:errchkjson
 
-	return chunk, tools
+	return chunk, tools, deltas
+}
+
+// toolCallPosition returns the position in tools of the tool call at index,
+// -1 if it isn't there yet.
+func toolCallPosition(tools []ToolCall, index int) int {
+	for i, tool := range tools {
+		if tool.Index != nil && *tool.Index == index {
+			return i
+		}
+	}
+	if index < len(tools) && tools[index].Index == nil {
+		return index
+	}
+	return -1
 }
 
 // StreamingChatResponseTools is a helper function to append tool calls to the stack.
@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
 )
 
 func TestParseStreamingChatResponse_FinishReason(t *testing.T) {
@@ -33,6 +34,87 @@ func TestParseStreamingChatResponse_FinishReason(t *testing.T) {
 	assert.Equal(t, FinishReason("stop"), resp.Choices[0].FinishReason)
 }
 
+func TestParseStreamingChatResponse_ToolCallDeltas(t *testing.T) {
+	t.Parallel()
+	mockBody := `data: {"choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"weather","arguments":""}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"time","arguments":""}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"function":{"arguments":"{}"}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+`
+	r := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(mockBody)),
+	}
+
+	var deltas []llms.ToolCallDelta
+	req := &ChatRequest{
+		StreamingToolCallFunc: func(_ context.Context, delta llms.ToolCallDelta) error {
+			deltas = append(deltas, delta)
+			return nil
+		},
+	}
+
+	resp, err := parseStreamingChatResponse(context.Background(), r, req)
+	require.NoError(t, err)
+	require.Len(t, resp.Choices[0].Message.ToolCalls, 2)
+	assert.Equal(t, "weather", resp.Choices[0].Message.ToolCalls[0].Function.Name)
+	assert.Equal(t, `{"city":"Paris"}`, resp.Choices[0].Message.ToolCalls[0].Function.Arguments)
+	assert.Equal(t, "time", resp.Choices[0].Message.ToolCalls[1].Function.Name)
+	assert.Equal(t, `{}`, resp.Choices[0].Message.ToolCalls[1].Function.Arguments)
+	assert.Equal(t, []llms.ToolCallDelta{
+		{Index: 0, ID: "call_1", Name: "weather"},
+		{Index: 1, ID: "call_2", Name: "time"},
+		{Index: 0, ArgumentsDelta: `{"city":`},
+		{Index: 1, ArgumentsDelta: `{}`},
+		{Index: 0, ArgumentsDelta: `"Paris"}`},
+	}, deltas)
+}
+
+func TestParseStreamingChatResponse_Reasoning(t *testing.T) {
+	t.Parallel()
+	mockBody := `data: {"choices":[{"index":0,"delta":{"role":"assistant","reasoning_content":"The user "}}]}
+
+data: {"choices":[{"index":0,"delta":{"reasoning_content":"greets me."}}]}
+
+data: {"choices":[{"index":0,"delta":{"content":"Hello!"},"finish_reason":"stop"}]}
+
+data: {"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":12,"total_tokens":17,"completion_tokens_details":{"reasoning_tokens":10}}}
+
+data: [DONE]
+`
+	r := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(mockBody)),
+	}
+
+	var reasoning []string
+	req := &ChatRequest{
+		StreamingReasoningFunc: func(_ context.Context, chunk []byte) error {
+			reasoning = append(reasoning, string(chunk))
+			return nil
+		},
+	}
+
+	resp, err := parseStreamingChatResponse(context.Background(), r, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"The user ", "greets me."}, reasoning)
+	assert.Equal(t, "The user greets me.", resp.Choices[0].Message.ReasoningContent)
+	assert.Equal(t, "Hello!", resp.Choices[0].Message.Content)
+	assert.Equal(t, 10, resp.Usage.CompletionTokensDetails.ReasoningTokens)
+
+	// The reasoning isn't sent back.
+	data, err := json.Marshal(resp.Choices[0].Message)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"role":"","content":"Hello!"}`, string(data))
+}
+
 func TestChatMessage_MarshalUnmarshal(t *testing.T) {
 	t.Parallel()
 	msg := ChatMessage{
@@ -52,3 +134,85 @@ func TestChatMessage_MarshalUnmarshal(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, msg, msg2)
 }
+
+func TestResponseFormatJSONSchema_RawSchema(t *testing.T) {
+	t.Parallel()
+	format := ResponseFormat{
+		Type: "json_schema",
+		JSONSchema: &ResponseFormatJSONSchema{
+			Name:   "person",
+			Strict: true,
+			RawSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"name": map[string]any{"type": []string{"string", "null"}}},
+			},
+		},
+	}
+	data, err := json.Marshal(format)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"json_schema","json_schema":{"name":"person","strict":true,`+
+		`"schema":{"type":"object","properties":{"name":{"type":["string","null"]}}}}}`, string(data))
+
+	format.JSONSchema.RawSchema = nil
+	format.JSONSchema.Schema = &ResponseFormatJSONSchemaProperty{Type: "object"}
+	data, err = json.Marshal(format)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"json_schema","json_schema":{"name":"person","strict":true,`+
+		`"schema":{"type":"object","additionalProperties":false}}}`, string(data))
+}
+
+func TestChatMessage_MarshalMultiContent(t *testing.T) {
+	t.Parallel()
+	msg := ChatMessage{
+		Role: "user",
+		MultiContent: []llms.ContentPart{
+			llms.TextPart("Describe these."),
+			llms.BinaryPart("image/png", []byte("png")),
+			llms.BinaryPart("application/pdf", []byte("pdf")),
+			llms.BinaryPart("audio/wav", []byte("wav")),
+			llms.FileURLPart("image/jpeg", "https://example.com/cat.jpg"),
+		},
+	}
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"role":"user","content":[
+		{"type":"text","text":"Describe these."},
+		{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5n"}},
+		{"type":"file","file":{"filename":"document.pdf","file_data":"data:application/pdf;base64,cGRm"}},
+		{"type":"input_audio","input_audio":{"data":"d2F2","format":"wav"}},
+		{"type":"image_url","image_url":{"url":"https://example.com/cat.jpg"}}
+	]}`, string(data))
+
+	msg.MultiContent = []llms.ContentPart{llms.BinaryPart("image/png", make([]byte, maxImageSize+1))}
+	_, err = json.Marshal(msg)
+	require.ErrorIs(t, err, llms.ErrContentTooLarge)
+
+	msg.MultiContent = []llms.ContentPart{llms.BinaryPart("audio/flac", []byte("flac"))}
+	_, err = json.Marshal(msg)
+	require.Error(t, err)
+}
+
+func TestParseStreamingChatResponse_LogProbs(t *testing.T) {
+	t.Parallel()
+	mockBody := `data: {"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"},"logprobs":{"content":[{"token":"Hel","logprob":-0.1,"top_logprobs":[]}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"content":"lo"},"logprobs":{"content":[{"token":"lo","logprob":-0.2,"top_logprobs":[]}]},"finish_reason":"stop"}]}
+
+data: [DONE]
+`
+	r := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(mockBody)),
+	}
+
+	req := &ChatRequest{
+		StreamingFunc: func(_ context.Context, _ []byte) error { return nil },
+	}
+	resp, err := parseStreamingChatResponse(context.Background(), r, req)
+	require.NoError(t, err)
+	require.NotNil(t, resp.Choices[0].LogProbs)
+	assert.Equal(t, []LogProb{
+		{Token: "Hel", LogProb: -0.1, TopLogProbs: []TopLogProbs{}},
+		{Token: "lo", LogProb: -0.2, TopLogProbs: []TopLogProbs{}},
+	}, resp.Choices[0].LogProbs.Content)
+}
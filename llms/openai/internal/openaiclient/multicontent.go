@@ -0,0 +1,108 @@
+package openaiclient
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// The largest inline content the API accepts, in bytes.
+const (
+	maxImageSize = 20 << 20
+	maxFileSize  = 32 << 20
+	maxAudioSize = 25 << 20
+)
+
+// contentParts converts the parts of a message to the content parts of the
+// chat API: images as image_url parts, PDFs as file parts and audio as
+// input_audio parts.
+func contentParts(parts []llms.ContentPart) ([]any, error) {
+	content := make([]any, 0, len(parts))
+	for _, part := range parts {
+		switch p := part.(type) {
+		case llms.BinaryContent:
+			c, err := binaryContentPart(p)
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, c)
+		case llms.FileURLContent:
+			if !strings.HasPrefix(p.MIMEType, "image/") {
+				return nil, fmt.Errorf("file URL of type %q not supported, only images are", p.MIMEType)
+			}
+			content = append(content, llms.ImageURLContent{URL: p.URL})
+		default:
+			content = append(content, part)
+		}
+	}
+	return content, nil
+}
+
+func binaryContentPart(part llms.BinaryContent) (any, error) {
+	mimeType := part.MIMEType
+	if mimeType == "" {
+		mimeType = llms.DetectMIMEType(part.Data)
+		part.MIMEType = mimeType
+	}
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		if err := llms.CheckContentSize(part, maxImageSize); err != nil {
+			return nil, err
+		}
+		return llms.ImageURLContent{URL: part.String()}, nil
+	case mimeType == "application/pdf":
+		if err := llms.CheckContentSize(part, maxFileSize); err != nil {
+			return nil, err
+		}
+		return fileContentPart{
+			Type: "file",
+			File: fileContent{Filename: "document.pdf", FileData: part.String()},
+		}, nil
+	case strings.HasPrefix(mimeType, "audio/"):
+		format, ok := audioFormats[mimeType]
+		if !ok {
+			return nil, fmt.Errorf("audio of type %q not supported, only wav and mp3 are", mimeType)
+		}
+		if err := llms.CheckContentSize(part, maxAudioSize); err != nil {
+			return nil, err
+		}
+		return inputAudioContentPart{
+			Type: "input_audio",
+			InputAudio: inputAudio{
+				Data:   base64.StdEncoding.EncodeToString(part.Data),
+				Format: format,
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("binary content of type %q not supported", mimeType)
+	}
+}
+
+var audioFormats = map[string]string{
+	"audio/wav":   "wav",
+	"audio/x-wav": "wav",
+	"audio/mpeg":  "mp3",
+	"audio/mp3":   "mp3",
+}
+
+type fileContentPart struct {
+	Type string      `json:"type"`
+	File fileContent `json:"file"`
+}
+
+type fileContent struct {
+	Filename string `json:"filename"`
+	FileData string `json:"file_data"`
+}
+
+type inputAudioContentPart struct {
+	Type       string     `json:"type"`
+	InputAudio inputAudio `json:"input_audio"`
+}
+
+type inputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
+}
@@ -1,9 +1,12 @@
 package openaiclient
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 )
@@ -11,6 +14,8 @@ import (
 const (
 	defaultBaseURL              = "https://api.openai.com/v1"
 	defaultFunctionCallBehavior = "auto"
+	defaultChatCompletionsPath  = "/chat/completions"
+	defaultEmbeddingsPath       = "/embeddings"
 )
 
 // ErrEmptyResponse is returned when the OpenAI API returns an empty response.
@@ -38,11 +43,42 @@ type Client struct {
 	apiVersion string
 
 	ResponseFormat *ResponseFormat
+
+	chatCompletionsPath string
+	embeddingsPath      string
+	extraBody           map[string]any
 }
 
 // Option is an option for the OpenAI client.
 type Option func(*Client) error
 
+// WithChatCompletionsPath sets the path of the chat completions endpoint,
+// relative to the base URL.
+func WithChatCompletionsPath(path string) Option {
+	return func(c *Client) error {
+		c.chatCompletionsPath = path
+		return nil
+	}
+}
+
+// WithEmbeddingsPath sets the path of the embeddings endpoint, relative to
+// the base URL.
+func WithEmbeddingsPath(path string) Option {
+	return func(c *Client) error {
+		c.embeddingsPath = path
+		return nil
+	}
+}
+
+// WithExtraBody sets parameters added to the body of the chat requests,
+// overriding the fields of the request with the same names.
+func WithExtraBody(extraBody map[string]any) Option {
+	return func(c *Client) error {
+		c.extraBody = extraBody
+		return nil
+	}
+}
+
 // Doer performs a HTTP request.
 type Doer interface {
 	Do(req *http.Request) (*http.Response, error)
@@ -64,6 +100,9 @@ func New(token string, model string, baseURL string, organization string,
 		apiVersion:     apiVersion,
 		httpClient:     httpClient,
 		ResponseFormat: responseFormat,
+
+		chatCompletionsPath: defaultChatCompletionsPath,
+		embeddingsPath:      defaultEmbeddingsPath,
 	}
 
 	for _, opt := range opts {
@@ -98,6 +137,9 @@ func (c *Client) CreateCompletion(ctx context.Context, r *CompletionRequest) (*C
 type EmbeddingRequest struct {
 	Model string   `json:"model"`
 	Input []string `json:"input"`
+	// Dimensions is the number of dimensions of the embeddings, supported by
+	// the text-embedding-3 models and later.
+	Dimensions int `json:"dimensions,omitempty"`
 }
 
 // CreateEmbedding creates embeddings.
@@ -107,8 +149,9 @@ func (c *Client) CreateEmbedding(ctx context.Context, r *EmbeddingRequest) ([][]
 	}
 
 	resp, err := c.createEmbedding(ctx, &embeddingPayload{
-		Model: r.Model,
-		Input: r.Input,
+		Model:      r.Model,
+		Input:      r.Input,
+		Dimensions: r.Dimensions,
 	})
 	if err != nil {
 		return nil, err
@@ -151,9 +194,12 @@ func IsAzure(apiType APIType) bool {
 
 func (c *Client) setHeaders(req *http.Request) {
 	req.Header.Set("Content-Type", "application/json")
-	if c.apiType == APITypeOpenAI || c.apiType == APITypeAzureAD {
+	switch {
+	case c.token == "":
+		// OpenAI-compatible servers may not require a token.
+	case c.apiType == APITypeOpenAI || c.apiType == APITypeAzureAD:
 		req.Header.Set("Authorization", "Bearer "+c.token)
-	} else {
+	default:
 		req.Header.Set("api-key", c.token)
 	}
 	if c.organization != "" {
@@ -170,6 +216,81 @@ func (c *Client) buildURL(suffix string, model string) string {
 	return fmt.Sprintf("%s%s", c.baseURL, suffix)
 }
 
+// marshalBody marshals the payload of a request, adding the extra body
+// parameters of the client.
+func (c *Client) marshalBody(payload any) ([]byte, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil || len(c.extraBody) == 0 {
+		return payloadBytes, err
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(payloadBytes, &body); err != nil {
+		return nil, err
+	}
+	for k, v := range c.extraBody {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("extra body parameter %q: %w", k, err)
+		}
+		body[k] = raw
+	}
+	return json.Marshal(body)
+}
+
+// errorFromResponse returns the error of an unsuccessful response, with the
+// message of its body in the formats of OpenAI and of the compatible servers.
+func errorFromResponse(r *http.Response) error {
+	msg := fmt.Sprintf("API returned unexpected status code: %d", r.StatusCode)
+
+	// No need to check the error here: if it fails, we'll just return the
+	// status code.
+	body, _ := io.ReadAll(io.LimitReader(r.Body, maxErrorBodySize))
+	if detail := errorDetail(body); detail != "" {
+		return fmt.Errorf("%s: %s", msg, detail) // nolint:goerr113
+	}
+	return errors.New(msg) // nolint:goerr113
+}
+
+// maxErrorBodySize is the size of the body of the error responses read for
+// their message.
+const maxErrorBodySize = 1 << 16
+
+// errorDetail returns the message of an error response body: the message of
+// its error object, its error, message or detail string, or else the body
+// itself when it isn't JSON.
+func errorDetail(body []byte) string {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return ""
+	}
+	var resp struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+		Detail  json.RawMessage `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return string(body)
+	}
+	var errObj struct {
+		Message string `json:"message"`
+	}
+	var s string
+	switch {
+	case json.Unmarshal(resp.Error, &errObj) == nil && errObj.Message != "":
+		return errObj.Message
+	case json.Unmarshal(resp.Error, &s) == nil && s != "":
+		return s
+	case resp.Message != "":
+		return resp.Message
+	case json.Unmarshal(resp.Detail, &s) == nil && s != "":
+		return s
+	case len(resp.Detail) > 0:
+		// e.g. the validation errors of FastAPI servers.
+		return string(resp.Detail)
+	}
+	return ""
+}
+
 func (c *Client) buildAzureURL(suffix string, model string) string {
 	baseURL := c.baseURL
 	baseURL = strings.TrimRight(baseURL, "/")
@@ -0,0 +1,105 @@
+package openaiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCompatibleServer(t *testing.T) {
+	t.Parallel()
+	var gotPath string
+	var gotBody map[string]any
+	var gotHeader http.Header
+	doer := doerFunc(func(req *http.Request) (*http.Response, error) {
+		gotPath = req.URL.Path
+		gotHeader = req.Header
+		if err := json.NewDecoder(req.Body).Decode(&gotBody); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`)),
+		}, nil
+	})
+
+	c, err := New("", "my-model", "http://localhost:8000/api/", "", APITypeOpenAI, "", doer, "", nil,
+		WithChatCompletionsPath("/v1/chat"),
+		WithExtraBody(map[string]any{"top_k": 20, "temperature": 0.1}),
+	)
+	require.NoError(t, err)
+
+	resp, err := c.CreateChat(context.Background(), &ChatRequest{
+		Messages:    []*ChatMessage{{Role: "user", Content: "hello"}},
+		Temperature: 0.7,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hi", resp.Choices[0].Message.Content)
+
+	assert.Equal(t, "/api/v1/chat", gotPath)
+	assert.Empty(t, gotHeader.Get("Authorization"))
+	assert.Empty(t, gotHeader.Get("OpenAI-Organization"))
+	assert.Equal(t, "my-model", gotBody["model"])
+	assert.InDelta(t, 20, gotBody["top_k"], 0)
+	// The extra body overrides the parameters of the request.
+	assert.InDelta(t, 0.1, gotBody["temperature"], 0)
+}
+
+func TestErrorDetail(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"error":{"message":"invalid model","type":"invalid_request_error"}}`, "invalid model"},
+		{`{"error":"model not found"}`, "model not found"},
+		{`{"object":"error","message":"context too long"}`, "context too long"},
+		{`{"detail":"Not Found"}`, "Not Found"},
+		{`{"detail":[{"loc":["body","model"],"msg":"field required"}]}`, `[{"loc":["body","model"],"msg":"field required"}]`},
+		{"Bad Gateway\n", "Bad Gateway"},
+		{``, ``},
+		{`{}`, ``},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, errorDetail([]byte(tt.body)), tt.body)
+	}
+}
+
+func TestParseStreamingChatResponse_EventFields(t *testing.T) {
+	t.Parallel()
+	mockBody := `: ping
+
+event: message
+data: {"choices":[{"index":0,"delta":{"role":"assistant","content":"hel"}}]}
+
+: ping
+data: {"choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}
+
+data: [DONE]
+`
+	r := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(mockBody)),
+	}
+	req := &ChatRequest{
+		StreamingFunc: func(_ context.Context, _ []byte) error {
+			return nil
+		},
+	}
+
+	resp, err := parseStreamingChatResponse(context.Background(), r, req)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", resp.Choices[0].Message.Content)
+}
@@ -43,13 +43,25 @@ func newClient(opts ...Option) (*options, *openaiclient.Client, error) {
 		}
 	}
 
-	if len(options.token) == 0 {
+	// OpenAI-compatible servers, e.g. local vLLM ones, may not require a token.
+	if len(options.token) == 0 && (options.baseURL == "" || openaiclient.IsAzure(openaiclient.APIType(options.apiType))) {
 		return options, nil, ErrMissingToken
 	}
 
+	var clientOpts []openaiclient.Option
+	if options.chatCompletionsPath != "" {
+		clientOpts = append(clientOpts, openaiclient.WithChatCompletionsPath(options.chatCompletionsPath))
+	}
+	if options.embeddingsPath != "" {
+		clientOpts = append(clientOpts, openaiclient.WithEmbeddingsPath(options.embeddingsPath))
+	}
+	if len(options.extraBody) > 0 {
+		clientOpts = append(clientOpts, openaiclient.WithExtraBody(options.extraBody))
+	}
+
 	cli, err := openaiclient.New(options.token, options.model, options.baseURL, options.organization,
 		openaiclient.APIType(options.apiType), options.apiVersion, options.httpClient, options.embeddingModel,
-		options.responseFormat,
+		options.responseFormat, clientOpts...,
 	)
 	return options, cli, err
 }
@@ -3,6 +3,7 @@ package openai
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
@@ -54,6 +55,10 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		opt(&opts)
 	}
 
+	if err := opts.RateLimiter.Wait(ctx, messages, opts); err != nil {
+		return nil, err
+	}
+
 	chatMsgs := make([]*ChatMessage, 0, len(messages))
 	for _, mc := range messages {
 		msg := &ChatMessage{MultiContent: mc.Parts}
@@ -69,20 +74,24 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		case llms.ChatMessageTypeFunction:
 			msg.Role = RoleFunction
 		case llms.ChatMessageTypeTool:
-			msg.Role = RoleTool
-			// Here we extract tool calls from the message and populate the ToolCalls field.
-
-			// parse mc.Parts (which should have one entry of type ToolCallResponse) and populate msg.Content and msg.ToolCallID
-			if len(mc.Parts) != 1 {
-				return nil, fmt.Errorf("expected exactly one part for role %v, got %v", mc.Role, len(mc.Parts))
+			// parse mc.Parts (which should only have entries of type
+			// ToolCallResponse), each result of the parallel tool calls
+			// being a message of its own.
+			if len(mc.Parts) == 0 {
+				return nil, fmt.Errorf("expected at least one part for role %v", mc.Role)
 			}
-			switch p := mc.Parts[0].(type) {
-			case llms.ToolCallResponse:
-				msg.ToolCallID = p.ToolCallID
-				msg.Content = p.Content
-			default:
-				return nil, fmt.Errorf("expected part of type ToolCallResponse for role %v, got %T", mc.Role, mc.Parts[0])
+			for _, part := range mc.Parts {
+				p, ok := part.(llms.ToolCallResponse)
+				if !ok {
+					return nil, fmt.Errorf("expected part of type ToolCallResponse for role %v, got %T", mc.Role, part)
+				}
+				chatMsgs = append(chatMsgs, &ChatMessage{
+					Role:       RoleTool,
+					ToolCallID: p.ToolCallID,
+					Content:    p.Content,
+				})
 			}
+			continue
 
 		default:
 			return nil, fmt.Errorf("role %v not supported", mc.Role)
@@ -107,10 +116,16 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 
 		MaxCompletionTokens: opts.MaxTokens,
 
+		StreamingToolCallFunc:  opts.StreamingToolCallFunc,
+		StreamingReasoningFunc: opts.StreamingReasoningFunc,
+		ReasoningEffort:        string(opts.ReasoningEffort),
+
 		ToolChoice:           opts.ToolChoice,
 		FunctionCallBehavior: openaiclient.FunctionCallBehavior(opts.FunctionCallBehavior),
 		Seed:                 opts.Seed,
 		Metadata:             opts.Metadata,
+		LogProbs:             opts.Logprobs,
+		TopLogProbs:          opts.TopLogprobs,
 	}
 	if opts.JSONMode {
 		req.ResponseFormat = ResponseFormatJSON
@@ -136,11 +151,29 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		}
 		req.Tools = append(req.Tools, t)
 	}
+	// "any" is accepted as the Anthropic name of "required".
+	if mode, _, err := llms.ResolveToolChoice(opts.ToolChoice); err == nil && mode != "" && mode != llms.ToolChoiceFunction {
+		req.ToolChoice = string(mode)
+	}
+	if len(req.Tools) > 0 {
+		req.ParallelToolCalls = opts.ParallelToolCalls
+	}
 
 	// if o.client.ResponseFormat is set, use it for the request
 	if o.client.ResponseFormat != nil {
 		req.ResponseFormat = o.client.ResponseFormat
 	}
+	// the response schema of the call takes precedence over it
+	if opts.ResponseSchema != nil {
+		req.ResponseFormat = &ResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &ResponseFormatJSONSchema{
+				Name:      opts.ResponseSchema.Name,
+				Strict:    opts.ResponseSchema.Strict,
+				RawSchema: opts.ResponseSchema.Schema,
+			},
+		}
+	}
 
 	result, err := o.client.CreateChat(ctx, req)
 	if err != nil {
@@ -162,15 +195,29 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 				"ReasoningTokens":  result.Usage.CompletionTokensDetails.ReasoningTokens,
 			},
 		}
+		if c.Message.ReasoningContent != "" {
+			choices[i].Reasoning = []llms.ReasoningContent{{Text: c.Message.ReasoningContent}}
+		}
+		if c.LogProbs != nil {
+			choices[i].Logprobs = logprobsFromLogProbs(c.LogProbs.Content)
+		}
 
 		// Legacy function call handling
-		if c.FinishReason == "function_call" {
+		if c.FinishReason == "function_call" && c.Message.FunctionCall != nil {
 			choices[i].FuncCall = &llms.FunctionCall{
 				Name:      c.Message.FunctionCall.Name,
 				Arguments: c.Message.FunctionCall.Arguments,
 			}
 		}
-		for _, tool := range c.Message.ToolCalls {
+		for j, tool := range c.Message.ToolCalls {
+			// Some OpenAI-compatible servers leave out the ID or the type
+			// of the tool calls.
+			if tool.ID == "" {
+				tool.ID = fmt.Sprintf("call_%d", j)
+			}
+			if tool.Type == "" {
+				tool.Type = openaiclient.ToolTypeFunction
+			}
 			choices[i].ToolCalls = append(choices[i].ToolCalls, llms.ToolCall{
 				ID:   tool.ID,
 				Type: string(tool.Type),
@@ -185,7 +232,16 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 			choices[i].FuncCall = choices[i].ToolCalls[0].FunctionCall
 		}
 	}
-	response := &llms.ContentResponse{Choices: choices}
+	response := &llms.ContentResponse{
+		Choices: choices,
+		Usage: llms.Usage{
+			PromptTokens:     result.Usage.PromptTokens,
+			CompletionTokens: result.Usage.CompletionTokens,
+			TotalTokens:      result.Usage.TotalTokens,
+			CachedTokens:     result.Usage.PromptTokensDetails.CachedTokens,
+			ReasoningTokens:  result.Usage.CompletionTokensDetails.ReasoningTokens,
+		},
+	}
 	if o.CallbacksHandler != nil {
 		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, response)
 	}
@@ -194,9 +250,25 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 
 // CreateEmbedding creates embeddings for the given input texts.
 func (o *LLM) CreateEmbedding(ctx context.Context, inputTexts []string) ([][]float32, error) {
+	return o.createEmbedding(ctx, inputTexts, 0)
+}
+
+// CreateEmbeddingWithDimension creates embeddings of the given dimension
+// for the given input texts. The dimension is only sent for the
+// text-embedding-3 models, the embeddings of the other models having their
+// native dimension.
+func (o *LLM) CreateEmbeddingWithDimension(ctx context.Context, inputTexts []string, dimension int) ([][]float32, error) {
+	if !strings.HasPrefix(o.client.EmbeddingModel, "text-embedding-3") {
+		dimension = 0
+	}
+	return o.createEmbedding(ctx, inputTexts, dimension)
+}
+
+func (o *LLM) createEmbedding(ctx context.Context, inputTexts []string, dimension int) ([][]float32, error) {
 	embeddings, err := o.client.CreateEmbedding(ctx, &openaiclient.EmbeddingRequest{
-		Input: inputTexts,
-		Model: o.client.EmbeddingModel,
+		Input:      inputTexts,
+		Model:      o.client.EmbeddingModel,
+		Dimensions: dimension,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create openai embeddings: %w", err)
@@ -210,6 +282,13 @@ func (o *LLM) CreateEmbedding(ctx context.Context, inputTexts []string) ([][]flo
 	return embeddings, nil
 }
 
+// EmbeddingBatchLimits returns the maximum number of texts and of tokens of
+// an embeddings request, so that embeddings.NewEmbedder splits larger
+// batches.
+func (o *LLM) EmbeddingBatchLimits() (maxTexts, maxTokens int) {
+	return 2048, 300_000
+}
+
 // ExtractToolParts extracts the tool parts from a message.
 func ExtractToolParts(msg *ChatMessage) ([]llms.ContentPart, []llms.ToolCall) {
 	var content []llms.ContentPart
@@ -222,6 +301,8 @@ func ExtractToolParts(msg *ChatMessage) ([]llms.ContentPart, []llms.ToolCall) {
 			content = append(content, p)
 		case llms.BinaryContent:
 			content = append(content, p)
+		case llms.FileURLContent:
+			content = append(content, p)
 		case llms.ToolCall:
 			toolCalls = append(toolCalls, p)
 		}
@@ -229,6 +310,24 @@ func ExtractToolParts(msg *ChatMessage) ([]llms.ContentPart, []llms.ToolCall) {
 	return content, toolCalls
 }
 
+// logprobsFromLogProbs converts the log probabilities of the tokens of a
+// choice to llms.TokenLogprobs.
+func logprobsFromLogProbs(logProbs []openaiclient.LogProb) []llms.TokenLogprob {
+	logprobs := make([]llms.TokenLogprob, 0, len(logProbs))
+	for _, lp := range logProbs {
+		logprob := llms.TokenLogprob{Token: lp.Token, Logprob: lp.LogProb, Bytes: lp.Bytes}
+		for _, top := range lp.TopLogProbs {
+			logprob.TopLogprobs = append(logprob.TopLogprobs, llms.TokenLogprob{
+				Token:   top.Token,
+				Logprob: top.LogProb,
+				Bytes:   top.Bytes,
+			})
+		}
+		logprobs = append(logprobs, logprob)
+	}
+	return logprobs
+}
+
 // toolFromTool converts an llms.Tool to a Tool.
 func toolFromTool(t llms.Tool) (openaiclient.Tool, error) {
 	tool := openaiclient.Tool{
@@ -268,3 +367,12 @@ func toolCallFromToolCall(tc llms.ToolCall) openaiclient.ToolCall {
 		},
 	}
 }
+
+// Capabilities implements the llms.CapabilityReporter interface, reporting
+// the capabilities of the model.
+func (o *LLM) Capabilities() llms.ModelCapabilities {
+	if capabilities, ok := llms.LookupModelCapabilities(o.client.Model); ok {
+		return capabilities
+	}
+	return llms.ModelCapabilities{Streaming: true}
+}
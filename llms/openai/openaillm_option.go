@@ -40,6 +40,11 @@ type options struct {
 	embeddingModel string
 
 	callbackHandler callbacks.Handler
+
+	// paths of the endpoints, for OpenAI-compatible servers
+	chatCompletionsPath string
+	embeddingsPath      string
+	extraBody           map[string]any
 }
 
 // Option is a functional option for the OpenAI client.
@@ -84,6 +89,8 @@ func WithEmbeddingModel(embeddingModel string) Option {
 // WithBaseURL passes the OpenAI base url to the client. If not set, the base url
 // is read from the OPENAI_BASE_URL environment variable. If still not set in ENV
 // VAR OPENAI_BASE_URL, then the default value is https://api.openai.com/v1 is used.
+// The token is optional with a base URL, for the OpenAI-compatible servers not
+// requiring one.
 func WithBaseURL(baseURL string) Option {
 	return func(opts *options) {
 		opts.baseURL = baseURL
@@ -91,7 +98,9 @@ func WithBaseURL(baseURL string) Option {
 }
 
 // WithOrganization passes the OpenAI organization to the client. If not set, the
-// organization is read from the OPENAI_ORGANIZATION.
+// organization is read from the OPENAI_ORGANIZATION. The OpenAI-Organization
+// header is only sent with an organization, pass an empty one to not send it
+// to OpenAI-compatible servers.
 func WithOrganization(organization string) Option {
 	return func(opts *options) {
 		opts.organization = organization
@@ -135,3 +144,32 @@ func WithResponseFormat(responseFormat *ResponseFormat) Option {
 		opts.responseFormat = responseFormat
 	}
 }
+
+// WithChatCompletionsPath sets the path of the chat completions endpoint,
+// relative to the base URL, for OpenAI-compatible servers not serving it at
+// the default /chat/completions.
+func WithChatCompletionsPath(path string) Option {
+	return func(opts *options) {
+		opts.chatCompletionsPath = path
+	}
+}
+
+// WithEmbeddingsPath sets the path of the embeddings endpoint, relative to
+// the base URL, for OpenAI-compatible servers not serving it at the default
+// /embeddings.
+func WithEmbeddingsPath(path string) Option {
+	return func(opts *options) {
+		opts.embeddingsPath = path
+	}
+}
+
+// WithExtraBody passes parameters to add to the body of the chat requests,
+// such as the parameters specific to OpenAI-compatible servers, e.g.
+// {"top_k": 20} for vLLM. They override the parameters of the requests with
+// the same names, e.g. {"max_tokens": 100} for the servers not supporting
+// max_completion_tokens.
+func WithExtraBody(extraBody map[string]any) Option {
+	return func(opts *options) {
+		opts.extraBody = extraBody
+	}
+}
@@ -0,0 +1,47 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestGenerateContentLogprobs(t *testing.T) {
+	t.Parallel()
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_, _ = io.WriteString(w, `{"choices": [{
+			"index": 0, "finish_reason": "stop",
+			"message": {"role": "assistant", "content": "Yes"},
+			"logprobs": {"content": [{"token": "Yes", "logprob": -0.01, "bytes": [89, 101, 115], "top_logprobs": [
+				{"token": "Yes", "logprob": -0.01, "bytes": [89, 101, 115]},
+				{"token": "No", "logprob": -4.6, "bytes": [78, 111]}
+			]}]}
+		}]}`)
+	}))
+	t.Cleanup(server.Close)
+	llm, err := New(WithToken("test-token"), WithBaseURL(server.URL))
+	require.NoError(t, err)
+
+	resp, err := llm.GenerateContent(context.Background(),
+		[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Is the sky blue?")},
+		llms.WithLogprobs(2))
+	require.NoError(t, err)
+	assert.Equal(t, true, request["logprobs"])
+	assert.InDelta(t, 2, request["top_logprobs"], 0)
+	assert.Equal(t, []llms.TokenLogprob{{
+		Token: "Yes", Logprob: -0.01, Bytes: []byte("Yes"),
+		TopLogprobs: []llms.TokenLogprob{
+			{Token: "Yes", Logprob: -0.01, Bytes: []byte("Yes")},
+			{Token: "No", Logprob: -4.6, Bytes: []byte("No")},
+		},
+	}}, resp.Choices[0].Logprobs)
+}
@@ -1,6 +1,9 @@
 package llms
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 // CallOption is a function that configures a CallOptions.
 type CallOption func(*CallOptions)
@@ -21,6 +24,10 @@ type CallOptions struct {
 	// StreamingFunc is a function to be called for each chunk of a streaming response.
 	// Return an error to stop streaming early.
 	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
+	// StreamingToolCallFunc is a function to be called for each fragment of
+	// the tool calls of a streaming response.
+	// Return an error to stop streaming early.
+	StreamingToolCallFunc func(ctx context.Context, delta ToolCallDelta) error `json:"-"`
 	// TopK is the number of tokens to consider for top-k sampling.
 	TopK int `json:"top_k"`
 	// TopP is the cumulative probability for top-p sampling.
@@ -47,6 +54,9 @@ type CallOptions struct {
 	Tools []Tool `json:"tools,omitempty"`
 	// ToolChoice is the choice of tool to use, it can either be "none", "auto" (the default behavior), or a specific tool as described in the ToolChoice type.
 	ToolChoice any `json:"tool_choice"`
+	// ParallelToolCalls, if not nil, allows or prevents the model from
+	// calling several tools in a single response.
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
 
 	// Function defitions to include in the request.
 	// Deprecated: Use Tools instead.
@@ -66,6 +76,43 @@ type CallOptions struct {
 	// Supported MIME types are: text/plain: (default) Text output.
 	// application/json: JSON response in the response candidates.
 	ResponseMIMEType string `json:"response_mime_type,omitempty"`
+
+	// ResponseSchema is the JSON schema the response must conform to.
+	ResponseSchema *ResponseSchema `json:"response_schema,omitempty"`
+
+	// SafetySettings are the blocking thresholds of harm categories.
+	// Currently only supported by googleai llms.
+	SafetySettings []SafetySetting `json:"safety_settings,omitempty"`
+
+	// CachedContent is the name of the content cached server-side to use as
+	// the prefix of the request.
+	// Currently only supported by googleai llms.
+	CachedContent string `json:"cached_content,omitempty"`
+
+	// ReasoningEffort is how much the reasoning models reason before their
+	// answer.
+	// Currently only supported by openai and xai llms.
+	ReasoningEffort ReasoningEffort `json:"reasoning_effort,omitempty"`
+
+	// ThinkingBudget is the maximum number of tokens the model thinks with
+	// before its answer, enabling the extended thinking of Claude.
+	// Currently only supported by anthropic and bedrock llms.
+	ThinkingBudget int `json:"thinking_budget,omitempty"`
+
+	// Logprobs is whether to return the log probabilities of the generated
+	// tokens, and TopLogprobs the number of most likely tokens to return at
+	// each position.
+	// Currently only supported by openai and xai llms.
+	Logprobs    bool `json:"logprobs,omitempty"`
+	TopLogprobs int  `json:"top_logprobs,omitempty"`
+
+	// StreamingReasoningFunc is a function to be called for each chunk of
+	// the reasoning of a streaming response.
+	// Return an error to stop streaming early.
+	StreamingReasoningFunc func(ctx context.Context, chunk []byte) error `json:"-"`
+
+	// RateLimiter limits the calls client-side, see WithRateLimiter.
+	RateLimiter *RateLimiter `json:"-"`
 }
 
 // Tool is a tool that can be used by the model.
@@ -102,6 +149,75 @@ type FunctionReference struct {
 	Name string `json:"name"`
 }
 
+// ToolChoiceMode is how the model chooses the tools to call, mapped by every
+// backend to its own mechanism.
+type ToolChoiceMode string
+
+const (
+	// ToolChoiceAuto lets the model decide whether to call tools.
+	ToolChoiceAuto ToolChoiceMode = "auto"
+	// ToolChoiceNone prevents the model from calling tools.
+	ToolChoiceNone ToolChoiceMode = "none"
+	// ToolChoiceRequired makes the model call at least one tool.
+	ToolChoiceRequired ToolChoiceMode = "required"
+	// ToolChoiceFunction makes the model call a specific function. Use
+	// FunctionToolChoice to choose it.
+	ToolChoiceFunction ToolChoiceMode = "function"
+)
+
+// FunctionToolChoice returns the tool choice making the model call the
+// function name.
+func FunctionToolChoice(name string) ToolChoice {
+	return ToolChoice{Type: "function", Function: &FunctionReference{Name: name}}
+}
+
+// ResolveToolChoice returns the mode of the choice set with WithToolChoice,
+// along with the name of the function to call for ToolChoiceFunction. choice
+// can be nil, a ToolChoiceMode or its string value, a FunctionCallBehavior,
+// or a ToolChoice. The mode is empty when choice is nil, leaving the default
+// behavior of the backend.
+func ResolveToolChoice(choice any) (ToolChoiceMode, string, error) {
+	var mode ToolChoiceMode
+	switch c := choice.(type) {
+	case nil:
+		return "", "", nil
+	case ToolChoiceMode:
+		mode = c
+	case string:
+		mode = ToolChoiceMode(c)
+	case FunctionCallBehavior:
+		mode = ToolChoiceMode(c)
+	case ToolChoice:
+		return resolveToolChoice(c)
+	case *ToolChoice:
+		if c == nil {
+			return "", "", nil
+		}
+		return resolveToolChoice(*c)
+	default:
+		return "", "", fmt.Errorf("unsupported tool choice type %T", choice)
+	}
+	switch mode {
+	case ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+		return mode, "", nil
+	case "any":
+		// Anthropic's name of ToolChoiceRequired.
+		return ToolChoiceRequired, "", nil
+	case "":
+		return "", "", nil
+	case ToolChoiceFunction:
+		// The function name is only set with FunctionToolChoice.
+	}
+	return "", "", fmt.Errorf("unsupported tool choice %q", mode)
+}
+
+func resolveToolChoice(c ToolChoice) (ToolChoiceMode, string, error) {
+	if c.Function == nil || c.Function.Name == "" {
+		return "", "", fmt.Errorf("tool choice of type %q has no function name", c.Type)
+	}
+	return ToolChoiceFunction, c.Function.Name, nil
+}
+
 // FunctionCallBehavior is the behavior to use when calling functions.
 type FunctionCallBehavior string
 
@@ -162,6 +278,16 @@ func WithStreamingFunc(streamingFunc func(ctx context.Context, chunk []byte) err
 	}
 }
 
+// WithStreamingToolCallFunc specifies the function receiving the fragments
+// of the tool calls as they are streamed, so that they can be rendered
+// before the response is complete. It streams the response even without a
+// StreamingFunc.
+func WithStreamingToolCallFunc(streamingToolCallFunc func(ctx context.Context, delta ToolCallDelta) error) CallOption {
+	return func(o *CallOptions) {
+		o.StreamingToolCallFunc = streamingToolCallFunc
+	}
+}
+
 // WithTopK will add an option to use top-k sampling.
 func WithTopK(topK int) CallOption {
 	return func(o *CallOptions) {
@@ -243,6 +369,8 @@ func WithFunctions(functions []FunctionDefinition) CallOption {
 
 // WithToolChoice will add an option to set the choice of tool to use.
 // It can either be "none", "auto" (the default behavior), or a specific tool as described in the ToolChoice type.
+// The ToolChoiceMode values and FunctionToolChoice are mapped to the native
+// mechanism of the OpenAI, Anthropic and Google AI backends.
 func WithToolChoice(choice any) CallOption {
 	// TODO: Add type validation for choice.
 	return func(o *CallOptions) {
@@ -250,6 +378,14 @@ func WithToolChoice(choice any) CallOption {
 	}
 }
 
+// WithParallelToolCalls will add an option to allow or prevent the model from
+// calling several tools in a single response. Not all backends support it.
+func WithParallelToolCalls(enabled bool) CallOption {
+	return func(o *CallOptions) {
+		o.ParallelToolCalls = &enabled
+	}
+}
+
 // WithTools will add an option to set the tools to use.
 func WithTools(tools []Tool) CallOption {
 	return func(o *CallOptions) {
@@ -280,3 +416,75 @@ func WithResponseMIMEType(responseMIMEType string) CallOption {
 		o.ResponseMIMEType = responseMIMEType
 	}
 }
+
+// WithResponseSchema will add an option to constrain the response to the
+// JSON schema, with the native structured output mode of the backends
+// supporting it. Use GenerateStructured to decode and validate the response.
+func WithResponseSchema(schema *ResponseSchema) CallOption {
+	return func(o *CallOptions) {
+		o.ResponseSchema = schema
+	}
+}
+
+// WithCachedContent will add an option to use the content cached server-side
+// with the given name, e.g. created with googleai's CreateCachedContent, as
+// the prefix of the request. The model of the call must be the one the
+// content was cached for.
+// Currently only supported by googleai llms.
+func WithCachedContent(name string) CallOption {
+	return func(o *CallOptions) {
+		o.CachedContent = name
+	}
+}
+
+// ReasoningEffort is how much a reasoning model reasons before its answer,
+// trading latency and reasoning tokens for the quality of the answer.
+type ReasoningEffort string
+
+const (
+	// ReasoningEffortLow favors speed and fewer reasoning tokens.
+	ReasoningEffortLow ReasoningEffort = "low"
+	// ReasoningEffortMedium is the default of most reasoning models.
+	ReasoningEffortMedium ReasoningEffort = "medium"
+	// ReasoningEffortHigh favors more complete reasoning.
+	ReasoningEffortHigh ReasoningEffort = "high"
+)
+
+// WithReasoningEffort will add an option to set how much the reasoning
+// models, such as the OpenAI o-series, reason before their answer.
+// Currently only supported by openai and xai llms.
+func WithReasoningEffort(effort ReasoningEffort) CallOption {
+	return func(o *CallOptions) {
+		o.ReasoningEffort = effort
+	}
+}
+
+// WithThinkingBudget will add an option to enable extended thinking, with
+// at most budgetTokens tokens of thinking before the answer. The thinking
+// is returned in the Reasoning of the choices.
+// Currently only supported by anthropic and bedrock llms.
+func WithThinkingBudget(budgetTokens int) CallOption {
+	return func(o *CallOptions) {
+		o.ThinkingBudget = budgetTokens
+	}
+}
+
+// WithStreamingReasoningFunc will add an option to set a streaming function
+// for the reasoning of the model, so that it can be displayed while the
+// model reasons.
+func WithStreamingReasoningFunc(streamingReasoningFunc func(ctx context.Context, chunk []byte) error) CallOption {
+	return func(o *CallOptions) {
+		o.StreamingReasoningFunc = streamingReasoningFunc
+	}
+}
+
+// WithLogprobs will add an option to return the log probabilities of the
+// generated tokens in the Logprobs of the choices, with the topN most likely
+// tokens at each position, e.g. to score the confidence of the response.
+// Currently only supported by openai and xai llms.
+func WithLogprobs(topN int) CallOption {
+	return func(o *CallOptions) {
+		o.Logprobs = true
+		o.TopLogprobs = topN
+	}
+}
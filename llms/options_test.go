@@ -0,0 +1,47 @@
+package llms
+
+import "testing"
+
+func TestResolveToolChoice(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		choice       any
+		wantMode     ToolChoiceMode
+		wantFunction string
+		wantErr      bool
+	}{
+		{"unset", nil, "", "", false},
+		{"mode", ToolChoiceRequired, ToolChoiceRequired, "", false},
+		{"string", "none", ToolChoiceNone, "", false},
+		{"anthropic any", "any", ToolChoiceRequired, "", false},
+		{"function call behavior", FunctionCallBehaviorAuto, ToolChoiceAuto, "", false},
+		{"function", FunctionToolChoice("search"), ToolChoiceFunction, "search", false},
+		{"function pointer", &ToolChoice{Type: "function", Function: &FunctionReference{Name: "search"}}, ToolChoiceFunction, "search", false},
+		{"function without name", ToolChoice{Type: "function"}, "", "", true},
+		{"function mode without name", ToolChoiceFunction, "", "", true},
+		{"unknown mode", "sometimes", "", "", true},
+		{"unsupported type", 42, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			mode, function, err := ResolveToolChoice(tt.choice)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveToolChoice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if mode != tt.wantMode || function != tt.wantFunction {
+				t.Errorf("ResolveToolChoice() = %q, %q, want %q, %q", mode, function, tt.wantMode, tt.wantFunction)
+			}
+		})
+	}
+}
+
+func TestWithParallelToolCalls(t *testing.T) {
+	t.Parallel()
+	var opts CallOptions
+	WithParallelToolCalls(false)(&opts)
+	if opts.ParallelToolCalls == nil || *opts.ParallelToolCalls {
+		t.Errorf("expected parallel tool calls to be disabled, got %v", opts.ParallelToolCalls)
+	}
+}
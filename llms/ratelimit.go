@@ -0,0 +1,224 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned by the calls exceeding the budget of a fail-fast
+// RateLimiter.
+var ErrRateLimited = errors.New("llms: client-side rate limit exceeded")
+
+// RateLimiter limits the requests and tokens per minute of the model calls
+// made with WithRateLimiter, so that bulk chains don't trip the quotas of the
+// providers. A RateLimiter is safe for concurrent use, and is meant to be
+// shared by all the calls counting against the same quota.
+type RateLimiter struct {
+	requests    *tokenBucket
+	tokens      *tokenBucket
+	countTokens func(text string) int
+	failFast    bool
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// RateLimiterOption is a function configuring a RateLimiter.
+type RateLimiterOption func(*RateLimiter)
+
+// WithRequestsPerMinute limits the calls to rpm per minute, allowing bursts
+// of a minute of calls.
+func WithRequestsPerMinute(rpm int) RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.requests = &tokenBucket{rate: float64(rpm) / 60, burst: float64(rpm)}
+	}
+}
+
+// WithTokensPerMinute limits the tokens of the calls to tpm per minute,
+// allowing bursts of a minute of tokens. The tokens of a call are those of
+// its messages, estimated as a quarter of the length of their text unless
+// WithRateLimitTokenCounter is set, and its MaxTokens.
+func WithTokensPerMinute(tpm int) RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.tokens = &tokenBucket{rate: float64(tpm) / 60, burst: float64(tpm)}
+	}
+}
+
+// WithRateLimitTokenCounter sets the function counting the tokens of a text
+// for WithTokensPerMinute, e.g. with CountTokens.
+func WithRateLimitTokenCounter(countTokens func(text string) int) RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.countTokens = countTokens
+	}
+}
+
+// WithFailFast makes the calls exceeding the budget fail with ErrRateLimited
+// instead of waiting for it to refill.
+func WithFailFast() RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.failFast = true
+	}
+}
+
+// NewRateLimiter creates a rate limiter with the given budgets. Without
+// budgets, it doesn't limit the calls.
+func NewRateLimiter(opts ...RateLimiterOption) *RateLimiter {
+	l := &RateLimiter{
+		countTokens: func(text string) int { return (len(text) + 3) / 4 },
+		now:         time.Now,
+		sleep:       sleepContext,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WithRateLimiter limits the call with limiter, which the model waits for,
+// or fails with ErrRateLimited in fail-fast mode, before calling the
+// provider.
+func WithRateLimiter(limiter *RateLimiter) CallOption {
+	return func(o *CallOptions) {
+		o.RateLimiter = limiter
+	}
+}
+
+// Wait takes a request and the tokens of a call with messages and opts from
+// the budgets, waiting for them to be available. It is called by the models
+// before calling their provider, and doesn't limit anything when l is nil.
+func (l *RateLimiter) Wait(ctx context.Context, messages []MessageContent, opts CallOptions) error {
+	if l == nil {
+		return nil
+	}
+	tokens := 0
+	if l.tokens != nil {
+		for _, m := range messages {
+			for _, part := range m.Parts {
+				if text, ok := part.(TextContent); ok {
+					tokens += l.countTokens(text.Text)
+				}
+			}
+		}
+		tokens += max(opts.MaxTokens, 0)
+	}
+
+	if l.failFast {
+		return l.take(tokens)
+	}
+	if l.requests != nil {
+		if err := l.requests.wait(ctx, 1, l.now, l.sleep); err != nil {
+			return err
+		}
+	}
+	if l.tokens != nil && tokens > 0 {
+		if err := l.tokens.wait(ctx, float64(tokens), l.now, l.sleep); err != nil {
+			if l.requests != nil {
+				l.requests.give(1)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// take takes a request and tokens from the budgets if they are both
+// available, and fails with ErrRateLimited otherwise.
+func (l *RateLimiter) take(tokens int) error {
+	t := l.now()
+	if l.requests != nil && !l.requests.take(1, t) {
+		return ErrRateLimited
+	}
+	if l.tokens != nil && tokens > 0 && !l.tokens.take(float64(tokens), t) {
+		if l.requests != nil {
+			l.requests.give(1)
+		}
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tokenBucket is a token bucket refilled at rate tokens per second up to
+// burst tokens. Waits for more tokens than available are served in order,
+// the bucket going into debt.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens accumulated since the last refill. b.mu must be
+// held.
+func (b *tokenBucket) refill(t time.Time) {
+	if b.last.IsZero() {
+		b.tokens = b.burst
+	} else {
+		b.tokens = math.Min(b.burst, b.tokens+t.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = t
+}
+
+// take takes n tokens if they are available.
+func (b *tokenBucket) take(n float64, t time.Time) bool {
+	if b.rate <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(t)
+	if b.tokens < n {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
+
+// give returns n unused tokens.
+func (b *tokenBucket) give(n float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += n
+}
+
+// wait waits until n tokens are available, then takes them.
+func (b *tokenBucket) wait(ctx context.Context, n float64, now func() time.Time,
+	sleep func(context.Context, time.Duration) error,
+) error {
+	if b.rate <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	b.refill(now())
+	b.tokens -= n
+	var d time.Duration
+	if b.tokens < 0 {
+		d = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if d == 0 {
+		return nil
+	}
+	if err := sleep(ctx, d); err != nil {
+		// The tokens weren't used.
+		b.give(n)
+		return err
+	}
+	return nil
+}
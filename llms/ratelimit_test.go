@@ -0,0 +1,117 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock advanced by the sleeps.
+type fakeClock struct {
+	t     time.Time
+	slept time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) sleep(_ context.Context, d time.Duration) error {
+	c.t = c.t.Add(d)
+	c.slept += d
+	return nil
+}
+
+func newTestRateLimiter(opts ...RateLimiterOption) (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	l := NewRateLimiter(opts...)
+	l.now = clock.now
+	l.sleep = clock.sleep
+	return l, clock
+}
+
+func TestRateLimiterRequestsPerMinute(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	l, clock := newTestRateLimiter(WithRequestsPerMinute(2))
+
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx, nil, CallOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if clock.slept != 30*time.Second {
+		t.Fatalf("expected the third call to wait 30s, waited %v", clock.slept)
+	}
+}
+
+func TestRateLimiterTokensPerMinute(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	l, clock := newTestRateLimiter(WithTokensPerMinute(60), WithRateLimitTokenCounter(func(text string) int {
+		return len(text)
+	}))
+	messages := []MessageContent{TextParts(ChatMessageTypeHuman, "0123456789")}
+
+	// 10 tokens of prompt and 50 of completion use the budget of a minute.
+	if err := l.Wait(ctx, messages, CallOptions{MaxTokens: 50}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Wait(ctx, messages, CallOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if clock.slept != 10*time.Second {
+		t.Fatalf("expected the second call to wait 10s, waited %v", clock.slept)
+	}
+}
+
+func TestRateLimiterCanceledWaitGivesBackRequest(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	l, clock := newTestRateLimiter(WithRequestsPerMinute(1), WithTokensPerMinute(60))
+	l.sleep = func(context.Context, time.Duration) error {
+		return context.Canceled
+	}
+
+	// The request is taken, then the wait for the tokens is canceled.
+	if err := l.Wait(ctx, nil, CallOptions{MaxTokens: 120}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the wait to be canceled, got %v", err)
+	}
+	l.sleep = clock.sleep
+	if err := l.Wait(ctx, nil, CallOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if clock.slept != 0 {
+		t.Fatalf("expected the request to be given back, waited %v", clock.slept)
+	}
+}
+
+func TestRateLimiterFailFast(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	l, clock := newTestRateLimiter(WithRequestsPerMinute(1), WithFailFast())
+
+	if err := l.Wait(ctx, nil, CallOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Wait(ctx, nil, CallOptions{}); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	clock.t = clock.t.Add(time.Minute)
+	if err := l.Wait(ctx, nil, CallOptions{}); err != nil {
+		t.Fatalf("expected the budget to be refilled, got %v", err)
+	}
+}
+
+func TestRateLimiterNil(t *testing.T) {
+	t.Parallel()
+	var opts CallOptions
+	if err := opts.RateLimiter.Wait(context.Background(), nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	WithRateLimiter(NewRateLimiter())(&opts)
+	if opts.RateLimiter == nil {
+		t.Fatal("expected the rate limiter to be set")
+	}
+}
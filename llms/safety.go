@@ -0,0 +1,111 @@
+package llms
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// HarmCategory is a category of harmful content the models filter.
+type HarmCategory string
+
+const (
+	// HarmCategoryHarassment is harassment content.
+	HarmCategoryHarassment HarmCategory = "harassment"
+	// HarmCategoryHateSpeech is hate speech content.
+	HarmCategoryHateSpeech HarmCategory = "hate_speech"
+	// HarmCategorySexuallyExplicit is sexually explicit content.
+	HarmCategorySexuallyExplicit HarmCategory = "sexually_explicit"
+	// HarmCategoryDangerousContent is dangerous content.
+	HarmCategoryDangerousContent HarmCategory = "dangerous_content"
+)
+
+// HarmBlockThreshold is the probability of harm from which the content of a
+// category is blocked.
+type HarmBlockThreshold string
+
+const (
+	// HarmBlockLowAndAbove blocks the content with a low, medium or high
+	// probability of harm.
+	HarmBlockLowAndAbove HarmBlockThreshold = "block_low_and_above"
+	// HarmBlockMediumAndAbove blocks the content with a medium or high
+	// probability of harm.
+	HarmBlockMediumAndAbove HarmBlockThreshold = "block_medium_and_above"
+	// HarmBlockOnlyHigh blocks the content with a high probability of harm.
+	HarmBlockOnlyHigh HarmBlockThreshold = "block_only_high"
+	// HarmBlockNone blocks no content.
+	HarmBlockNone HarmBlockThreshold = "block_none"
+)
+
+// SafetySetting is the threshold from which the content of a harm category
+// is blocked.
+type SafetySetting struct {
+	Category  HarmCategory       `json:"category"`
+	Threshold HarmBlockThreshold `json:"threshold"`
+}
+
+// WithSafetySettings will add an option to set the blocking thresholds of
+// harm categories for the call, the other categories keeping the thresholds
+// of the model.
+// Currently only supported by googleai llms.
+func WithSafetySettings(settings ...SafetySetting) CallOption {
+	return func(o *CallOptions) {
+		o.SafetySettings = settings
+	}
+}
+
+// SafetyRating is the probability of content being harmful in a harm
+// category, as rated by the model.
+type SafetyRating struct {
+	Category HarmCategory
+	// Probability is "negligible", "low", "medium" or "high".
+	Probability string
+	// Blocked reports whether the content was blocked because of this rating.
+	Blocked bool
+}
+
+// Citation is a source the content of a response is attributed to.
+type Citation struct {
+	// StartIndex and EndIndex delimit the attributed segment of the content,
+	// in bytes.
+	StartIndex int
+	EndIndex   int
+	URI        string
+	Title      string
+	License    string
+}
+
+// ErrContentBlocked is the error ContentBlockedError matches with errors.Is.
+var ErrContentBlocked = errors.New("content blocked")
+
+// ContentBlockedError is returned by the models when the prompt or the
+// response is blocked by their safety filters, instead of an empty response.
+type ContentBlockedError struct {
+	// Reason is the reason reported by the model, e.g. "FinishReasonSafety".
+	Reason string
+	// Prompt reports whether the prompt was blocked rather than the response.
+	Prompt bool
+	// SafetyRatings are the ratings of the blocked prompt or response.
+	SafetyRatings []SafetyRating
+}
+
+func (e *ContentBlockedError) Error() string {
+	what := "response"
+	if e.Prompt {
+		what = "prompt"
+	}
+	var blocked []string
+	for _, rating := range e.SafetyRatings {
+		if rating.Blocked {
+			blocked = append(blocked, string(rating.Category))
+		}
+	}
+	if len(blocked) == 0 {
+		return fmt.Sprintf("%v: %s: %s", ErrContentBlocked, what, e.Reason)
+	}
+	return fmt.Sprintf("%v: %s: %s (%s)", ErrContentBlocked, what, e.Reason, strings.Join(blocked, ", "))
+}
+
+func (e *ContentBlockedError) Unwrap() error {
+	return ErrContentBlocked
+}
@@ -0,0 +1,37 @@
+package llms
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestContentBlockedError(t *testing.T) {
+	t.Parallel()
+	var err error = &ContentBlockedError{
+		Reason: "FinishReasonSafety",
+		SafetyRatings: []SafetyRating{
+			{Category: HarmCategoryHarassment, Probability: "negligible"},
+			{Category: HarmCategoryDangerousContent, Probability: "high", Blocked: true},
+		},
+	}
+	if !errors.Is(err, ErrContentBlocked) {
+		t.Fatal("expected the error to match ErrContentBlocked")
+	}
+	if got, want := err.Error(), "content blocked: response: FinishReasonSafety (dangerous_content)"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	err = &ContentBlockedError{Reason: "BlockReasonOther", Prompt: true}
+	if got, want := err.Error(), "content blocked: prompt: BlockReasonOther"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithSafetySettings(t *testing.T) {
+	t.Parallel()
+	var opts CallOptions
+	WithSafetySettings(SafetySetting{Category: HarmCategoryHateSpeech, Threshold: HarmBlockNone})(&opts)
+	if len(opts.SafetySettings) != 1 || opts.SafetySettings[0].Threshold != HarmBlockNone {
+		t.Fatalf("unexpected safety settings %v", opts.SafetySettings)
+	}
+}
@@ -0,0 +1,489 @@
+package llms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ResponseSchema is the JSON schema the response of the model must conform
+// to, set with WithResponseSchema. Backends with a native structured output
+// mode constrain the generation to the schema: the JSON schema response
+// format of OpenAI, the response schema of Gemini, and a forced tool call for
+// Anthropic.
+type ResponseSchema struct {
+	// Name is the name of the schema, e.g. the name of the returned type.
+	Name string `json:"name"`
+	// Description describes the response to the model.
+	Description string `json:"description,omitempty"`
+	// Schema is the JSON schema, e.g. as returned by SchemaFor.
+	Schema map[string]any `json:"schema"`
+	// Strict makes the backends supporting it reject schemas they can't
+	// enforce exactly, which requires every property of the objects to be
+	// required and no additional properties.
+	Strict bool `json:"strict,omitempty"`
+	// Wrapped is set by SchemaFor for the types not encoded as JSON objects
+	// with properties, e.g. slices, as the structured output of OpenAI and
+	// Anthropic must be an object: Schema is an object whose "value"
+	// property is the encoding of the type, which DecodeStructured unwraps.
+	Wrapped bool `json:"wrapped,omitempty"`
+}
+
+// ErrInvalidStructuredOutput is the error StructuredOutputError matches with
+// errors.Is.
+var ErrInvalidStructuredOutput = errors.New("invalid structured output")
+
+// StructuredOutputError is returned by GenerateStructured when the response
+// of the model isn't valid JSON, doesn't conform to the schema, or doesn't
+// decode into the requested type.
+type StructuredOutputError struct {
+	// Content is the content of the response.
+	Content string
+	// Problems lists what is wrong with the response, e.g. "$.age: expected
+	// integer, got string".
+	Problems []string
+}
+
+func (e *StructuredOutputError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrInvalidStructuredOutput, strings.Join(e.Problems, "; "))
+}
+
+func (e *StructuredOutputError) Unwrap() error {
+	return ErrInvalidStructuredOutput
+}
+
+// GenerateStructured asks the model to respond to messages with a JSON value
+// of type T, and returns the decoded value. The schema of T is generated with
+// SchemaFor, unless one is set with WithResponseSchema. Models without
+// native structured output, as reported by their capabilities, are
+// instructed with the schema in the last message, and use JSON mode when
+// they support it. The response is validated against the schema, a
+// *StructuredOutputError listing the problems is returned when it doesn't
+// conform.
+func GenerateStructured[T any](ctx context.Context, model Model, messages []MessageContent, options ...CallOption) (T, error) { //nolint:lll
+	var result T
+	opts := CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	schema := opts.ResponseSchema
+	if schema == nil {
+		var err error
+		schema, err = SchemaFor[T]()
+		if err != nil {
+			return result, err
+		}
+		options = append(options, WithResponseSchema(schema))
+	}
+
+	capabilities := Capabilities(model)
+	if !capabilities.StructuredOutput {
+		messages = withSchemaInstructions(messages, schema)
+		if capabilities.JSONMode && opts.ResponseMIMEType == "" {
+			options = append(options, WithJSONMode())
+		}
+	}
+
+	resp, err := model.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return result, err
+	}
+	if len(resp.Choices) == 0 {
+		return result, errors.New("empty response from model")
+	}
+	content := resp.Choices[0].Content
+	if err := DecodeStructured(content, schema, &result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// DecodeStructured decodes the JSON value of content, which may be wrapped
+// in a markdown code block, into v after validating it against schema. The
+// "value" property of the responses to a wrapped schema is decoded.
+func DecodeStructured(content string, schema *ResponseSchema, v any) error {
+	data := []byte(extractJSON(content))
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return &StructuredOutputError{Content: content, Problems: []string{err.Error()}}
+	}
+	if schema != nil {
+		if problems := ValidateJSON(schema.Schema, value); len(problems) > 0 {
+			return &StructuredOutputError{Content: content, Problems: problems}
+		}
+	}
+	if schema != nil && schema.Wrapped {
+		var wrapper struct {
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return &StructuredOutputError{Content: content, Problems: []string{err.Error()}}
+		}
+		data = wrapper.Value
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return &StructuredOutputError{Content: content, Problems: []string{err.Error()}}
+	}
+	return nil
+}
+
+// extractJSON returns the JSON value of content, removing the markdown code
+// block models often wrap it in.
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if rest, ok := strings.CutPrefix(content, "```"); ok {
+		// Skip the language of the code block, e.g. json.
+		if i := strings.IndexByte(rest, '\n'); i >= 0 {
+			rest = rest[i+1:]
+		}
+		content = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+	}
+	return content
+}
+
+// withSchemaInstructions returns a copy of messages whose last message asks
+// the model to respond with JSON conforming to schema.
+func withSchemaInstructions(messages []MessageContent, schema *ResponseSchema) []MessageContent {
+	schemaJSON, _ := json.MarshalIndent(schema.Schema, "", "  ")
+	instructions := "Respond only with a JSON value, without any other text, conforming to this JSON schema:\n```json\n" +
+		string(schemaJSON) + "\n```"
+	if schema.Description != "" {
+		instructions = schema.Description + "\n" + instructions
+	}
+	if len(messages) == 0 {
+		return []MessageContent{TextParts(ChatMessageTypeHuman, instructions)}
+	}
+	messages = append([]MessageContent{}, messages...)
+	last := messages[len(messages)-1]
+	last.Parts = append(append([]ContentPart{}, last.Parts...), TextPart(instructions))
+	messages[len(messages)-1] = last
+	return messages
+}
+
+// SchemaFor returns the response schema of the JSON encoding of T, named
+// after T. Struct fields are named after their json tag, and required unless
+// tagged omitempty. The "describe" tag of a field sets its description. The
+// schema is strict when every field is required. The schemas of the other
+// types, e.g. []Item, map[string]int or string, are wrapped in an object.
+func SchemaFor[T any]() (*ResponseSchema, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	g := &schemaGenerator{visiting: map[reflect.Type]bool{}, strict: true}
+	schema, err := g.schema(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the schema of %v: %w", t, err)
+	}
+	// Tool names, which Anthropic uses for the schema, are limited to
+	// letters, digits, underscores and dashes, which excludes the names of
+	// instantiated generic types.
+	name := t.Name()
+	if name == "" || strings.ContainsFunc(name, func(r rune) bool {
+		return r != '_' && r != '-' && !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && !('0' <= r && r <= '9')
+	}) {
+		name = "response"
+	}
+	if _, ok := schema["properties"]; !ok {
+		schema = map[string]any{
+			"type":                 "object",
+			"properties":           map[string]any{"value": schema},
+			"required":             []string{"value"},
+			"additionalProperties": false,
+		}
+		return &ResponseSchema{Name: name, Schema: schema, Strict: g.strict, Wrapped: true}, nil
+	}
+	return &ResponseSchema{Name: name, Schema: schema, Strict: g.strict}, nil
+}
+
+type schemaGenerator struct {
+	// visiting holds the struct types being generated, to reject recursive
+	// types.
+	visiting map[reflect.Type]bool
+	// strict is whether every property of the objects is required.
+	strict bool
+}
+
+// nolint:gochecknoglobals
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+func (g *schemaGenerator) schema(t reflect.Type) (map[string]any, error) { //nolint:cyclop
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	}
+	if t == rawMessageType || (t.Kind() != reflect.Interface && t.Implements(jsonMarshalerType)) {
+		// The encoding is unknown.
+		return map[string]any{}, nil
+	}
+
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Encoded in base64.
+			return map[string]any{"type": "string"}, nil
+		}
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %v", t.Key())
+		}
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		// Objects with arbitrary properties can't be strict.
+		g.strict = false
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		return nil, fmt.Errorf("unsupported type %v", t)
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) (map[string]any, error) {
+	if g.visiting[t] {
+		return nil, fmt.Errorf("recursive type %v", t)
+	}
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+
+	properties := map[string]any{}
+	required := []string{}
+	if err := g.addFields(t, properties, &required); err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}, nil
+}
+
+// addFields adds the properties of the fields of the struct type t,
+// including the ones of its embedded structs.
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]any, required *[]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, tagOptions, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := g.addFields(embedded, properties, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property, err := g.schema(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if describe := field.Tag.Get("describe"); describe != "" {
+			property["description"] = describe
+		}
+		properties[name] = property
+		if strings.Contains(tagOptions, "omitempty") {
+			g.strict = false
+			continue
+		}
+		*required = append(*required, name)
+	}
+	return nil
+}
+
+// ValidateJSON validates the decoded JSON value against the JSON schema,
+// returning the problems found. It supports the type, enum, properties,
+// required, additionalProperties, items and anyOf keywords.
+func ValidateJSON(schema map[string]any, value any) []string {
+	var problems []string
+	validateJSON("$", schema, value, &problems)
+	return problems
+}
+
+func validateJSON(path string, schema map[string]any, value any, problems *[]string) { //nolint:cyclop
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		if !validatesAnyOf(path, anyOf, value) {
+			*problems = append(*problems, fmt.Sprintf("%s: matches none of the allowed schemas", path))
+		}
+		return
+	}
+	if types := schemaTypes(schema); len(types) > 0 {
+		got := jsonType(value)
+		if !typeAllowed(types, got, value) {
+			*problems = append(*problems, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(types, " or "), got))
+			return
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok && !inEnum(enum, value) {
+		*problems = append(*problems, fmt.Sprintf("%s: %v is not one of %v", path, value, enum))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		for _, name := range stringSlice(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := properties[name].(map[string]any); ok {
+				validateJSON(path+"."+name, property, v[name], problems)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					*problems = append(*problems, fmt.Sprintf("%s: unexpected property %q", path, name))
+				}
+			case map[string]any:
+				validateJSON(path+"."+name, additional, v[name], problems)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				validateJSON(fmt.Sprintf("%s[%d]", path, i), items, item, problems)
+			}
+		}
+	}
+}
+
+func validatesAnyOf(path string, anyOf []any, value any) bool {
+	for _, s := range anyOf {
+		schema, ok := s.(map[string]any)
+		if !ok {
+			continue
+		}
+		var problems []string
+		validateJSON(path, schema, value, &problems)
+		if len(problems) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaTypes returns the types allowed by the type keyword of schema, which
+// is a type or a list of types, along with null when it is nullable.
+func schemaTypes(schema map[string]any) []string {
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []any:
+		types = stringSlice(t)
+	case []string:
+		types = t
+	}
+	if nullable, _ := schema["nullable"].(bool); nullable && len(types) > 0 {
+		types = append(types, "null")
+	}
+	return types
+}
+
+func typeAllowed(types []string, got string, value any) bool {
+	for _, t := range types {
+		if t == got {
+			return true
+		}
+		if f, ok := value.(float64); ok && t == "integer" && f == float64(int64(f)) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON schema type of a value decoded by encoding/json.
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(e, value) {
+			return true
+		}
+		// Enums of integers are decoded as float64.
+		if f, ok := value.(float64); ok && reflect.ValueOf(e).CanInt() && float64(reflect.ValueOf(e).Int()) == f {
+			return true
+		}
+	}
+	return false
+}
+
+func stringSlice(v any) []string {
+	switch s := v.(type) {
+	case []string:
+		return s
+	case []any:
+		strs := make([]string, 0, len(s))
+		for _, e := range s {
+			if str, ok := e.(string); ok {
+				strs = append(strs, str)
+			}
+		}
+		return strs
+	default:
+		return nil
+	}
+}
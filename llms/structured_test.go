@@ -0,0 +1,247 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type structuredAddress struct {
+	City string `json:"city"`
+}
+
+type structuredPerson struct {
+	Name    string             `json:"name" describe:"full name"`
+	Age     int                `json:"age"`
+	Tags    []string           `json:"tags,omitempty"`
+	Address *structuredAddress `json:"address"`
+	Born    time.Time          `json:"born"`
+	Ignored string             `json:"-"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	t.Parallel()
+	schema, err := SchemaFor[structuredPerson]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string", "description": "full name"},
+			"age":  map[string]any{"type": "integer"},
+			"tags": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"address": map[string]any{
+				"type":                 "object",
+				"properties":           map[string]any{"city": map[string]any{"type": "string"}},
+				"required":             []string{"city"},
+				"additionalProperties": false,
+			},
+			"born": map[string]any{"type": "string", "format": "date-time"},
+		},
+		"required":             []string{"name", "age", "address", "born"},
+		"additionalProperties": false,
+	}
+	if !reflect.DeepEqual(schema.Schema, want) {
+		t.Errorf("unexpected schema %v", schema.Schema)
+	}
+	if schema.Name != "structuredPerson" || schema.Strict {
+		t.Errorf("expected a non-strict schema named after the type, got %q, %v", schema.Name, schema.Strict)
+	}
+
+	address, err := SchemaFor[structuredAddress]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !address.Strict {
+		t.Error("expected a schema with only required fields to be strict")
+	}
+
+	for _, tc := range []struct {
+		schema func() (*ResponseSchema, error)
+		value  map[string]any
+	}{
+		{SchemaFor[[]structuredAddress], map[string]any{"type": "array", "items": address.Schema}},
+		{SchemaFor[map[string]int], map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "integer"}}},
+		{SchemaFor[string], map[string]any{"type": "string"}},
+	} {
+		schema, err := tc.schema()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]any{
+			"type":                 "object",
+			"properties":           map[string]any{"value": tc.value},
+			"required":             []string{"value"},
+			"additionalProperties": false,
+		}
+		if !schema.Wrapped || !reflect.DeepEqual(schema.Schema, want) {
+			t.Errorf("expected the schema to be wrapped in an object, got %+v", schema)
+		}
+	}
+
+	type node struct {
+		Children []node `json:"children"`
+	}
+	if _, err := SchemaFor[node](); err == nil || !strings.Contains(err.Error(), "recursive type") {
+		t.Errorf("expected a recursive type error, got %v", err)
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	t.Parallel()
+	schema, err := SchemaFor[structuredPerson]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		value any
+		want  []string
+	}{
+		{
+			map[string]any{"name": "Ada", "age": 36.0, "address": map[string]any{"city": "London"}, "born": "1815-12-10T00:00:00Z"},
+			nil,
+		},
+		{
+			map[string]any{"name": "Ada", "age": 36.5, "address": nil, "born": "", "extra": true},
+			[]string{
+				"$.address: expected object, got null",
+				"$.age: expected integer, got number",
+				`$: unexpected property "extra"`,
+			},
+		},
+		{
+			map[string]any{"tags": []any{"a", 1.0}},
+			[]string{
+				`$: missing required property "name"`,
+				`$: missing required property "age"`,
+				`$: missing required property "address"`,
+				`$: missing required property "born"`,
+				"$.tags[1]: expected string, got number",
+			},
+		},
+		{"Ada", []string{"$: expected object, got string"}},
+	}
+	for _, tt := range tests {
+		if got := ValidateJSON(schema.Schema, tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ValidateJSON(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+
+	enum := map[string]any{"type": []any{"string", "null"}, "enum": []any{"red", "green", nil}}
+	if got := ValidateJSON(enum, nil); got != nil {
+		t.Errorf("expected null to be allowed, got %q", got)
+	}
+	if got := ValidateJSON(enum, "blue"); len(got) != 1 || !strings.Contains(got[0], "is not one of") {
+		t.Errorf("expected an enum problem, got %q", got)
+	}
+}
+
+// structuredModel responds with content, recording the messages and options
+// of the last call.
+type structuredModel struct {
+	silentModel
+	capabilities ModelCapabilities
+	content      string
+	messages     []MessageContent
+	opts         CallOptions
+}
+
+func (m *structuredModel) GenerateContent(_ context.Context, messages []MessageContent, options ...CallOption) (*ContentResponse, error) { //nolint:lll
+	m.messages = messages
+	m.opts = CallOptions{}
+	for _, opt := range options {
+		opt(&m.opts)
+	}
+	return &ContentResponse{Choices: []*ContentChoice{{Content: m.content}}}, nil
+}
+
+func (m *structuredModel) Capabilities() ModelCapabilities {
+	return m.capabilities
+}
+
+func TestGenerateStructured(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	messages := []MessageContent{TextParts(ChatMessageTypeHuman, "Who is Ada?")}
+
+	native := &structuredModel{
+		capabilities: ModelCapabilities{StructuredOutput: true},
+		content:      `{"city": "London"}`,
+	}
+	address, err := GenerateStructured[structuredAddress](ctx, native, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.City != "London" {
+		t.Errorf("unexpected result %+v", address)
+	}
+	if native.opts.ResponseSchema == nil || native.opts.ResponseSchema.Name != "structuredAddress" {
+		t.Errorf("expected the schema to be passed to the model, got %+v", native.opts.ResponseSchema)
+	}
+	if len(native.messages[0].Parts) != 1 {
+		t.Errorf("expected no instructions for a model with structured output, got %v", native.messages)
+	}
+
+	prompted := &structuredModel{
+		capabilities: ModelCapabilities{JSONMode: true},
+		content:      "```json\n{\"city\": \"Paris\"}\n```",
+	}
+	address, err = GenerateStructured[structuredAddress](ctx, prompted, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.City != "Paris" {
+		t.Errorf("unexpected result %+v", address)
+	}
+	if len(prompted.messages[0].Parts) != 2 || !prompted.opts.JSONMode {
+		t.Errorf("expected schema instructions and JSON mode, got %v, %v", prompted.messages, prompted.opts.JSONMode)
+	}
+	if len(messages[0].Parts) != 1 {
+		t.Error("expected the messages of the caller to be left unchanged")
+	}
+
+	wrapped := &structuredModel{
+		capabilities: ModelCapabilities{StructuredOutput: true},
+		content:      `{"value": [{"city": "London"}, {"city": "Paris"}]}`,
+	}
+	addresses, err := GenerateStructured[[]structuredAddress](ctx, wrapped, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(addresses, []structuredAddress{{City: "London"}, {City: "Paris"}}) {
+		t.Errorf("expected the value to be unwrapped, got %+v", addresses)
+	}
+	wrapped.content = `{"value": {"london": 1}}`
+	counts, err := GenerateStructured[map[string]int](ctx, wrapped, messages)
+	if err != nil || !reflect.DeepEqual(counts, map[string]int{"london": 1}) {
+		t.Errorf("expected the value to be unwrapped, got %v, %v", counts, err)
+	}
+	wrapped.content = `{"value": "London"}`
+	city, err := GenerateStructured[string](ctx, wrapped, messages)
+	if err != nil || city != "London" {
+		t.Errorf("expected the value to be unwrapped, got %q, %v", city, err)
+	}
+	wrapped.content = `["London"]`
+	if _, err = GenerateStructured[[]string](ctx, wrapped, messages); !errors.Is(err, ErrInvalidStructuredOutput) {
+		t.Errorf("expected an unwrapped value to be rejected, got %v", err)
+	}
+
+	invalid := &structuredModel{content: `{"city": 42}`}
+	_, err = GenerateStructured[structuredAddress](ctx, invalid, messages)
+	var outputErr *StructuredOutputError
+	if !errors.As(err, &outputErr) || !errors.Is(err, ErrInvalidStructuredOutput) {
+		t.Fatalf("expected a StructuredOutputError, got %v", err)
+	}
+	if !reflect.DeepEqual(outputErr.Problems, []string{"$.city: expected string, got number"}) {
+		t.Errorf("unexpected problems %q", outputErr.Problems)
+	}
+
+	invalid.content = "London"
+	if _, err = GenerateStructured[structuredAddress](ctx, invalid, messages); !errors.Is(err, ErrInvalidStructuredOutput) {
+		t.Errorf("expected invalid JSON to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package llms
+
+// Usage is the number of tokens consumed by a GenerateContent call, as
+// reported by the provider, e.g. for cost tracking.
+type Usage struct {
+	// PromptTokens is the number of tokens of the input, including the
+	// cached ones.
+	PromptTokens int `json:"prompt_tokens"`
+	// CompletionTokens is the number of generated tokens.
+	CompletionTokens int `json:"completion_tokens"`
+	// TotalTokens is the number of prompt and completion tokens.
+	TotalTokens int `json:"total_tokens"`
+	// CachedTokens is the number of prompt tokens read from the prompt cache
+	// of the provider, which are billed at a discount.
+	CachedTokens int `json:"cached_tokens,omitempty"`
+	// ReasoningTokens is the number of completion tokens the model reasoned
+	// with before its answer, for the models reporting it.
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+}
+
+// Add returns the sum of u and v.
+func (u Usage) Add(v Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + v.PromptTokens,
+		CompletionTokens: u.CompletionTokens + v.CompletionTokens,
+		TotalTokens:      u.TotalTokens + v.TotalTokens,
+		CachedTokens:     u.CachedTokens + v.CachedTokens,
+		ReasoningTokens:  u.ReasoningTokens + v.ReasoningTokens,
+	}
+}
+
+// NewUsage returns the usage of a call with the given number of prompt and
+// completion tokens.
+func NewUsage(promptTokens, completionTokens int) Usage {
+	return Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// nolint:gochecknoglobals
+var (
+	promptTokenKeys     = []string{"PromptTokens", "InputTokens", "input_tokens", "prompt_tokens"}
+	completionTokenKeys = []string{"CompletionTokens", "OutputTokens", "output_tokens", "completion_tokens"}
+	totalTokenKeys      = []string{"TotalTokens", "total_tokens"}
+	reasoningTokenKeys  = []string{"ReasoningTokens", "reasoning_tokens"}
+)
+
+// ResponseUsage returns the usage of resp, read from the generation info of
+// its first choice when the model doesn't set ContentResponse.Usage.
+func ResponseUsage(resp *ContentResponse) Usage {
+	if resp == nil {
+		return Usage{}
+	}
+	if resp.Usage != (Usage{}) || len(resp.Choices) == 0 || resp.Choices[0] == nil {
+		return resp.Usage
+	}
+	info := resp.Choices[0].GenerationInfo
+	usage := Usage{
+		PromptTokens:     tokenCount(info, promptTokenKeys),
+		CompletionTokens: tokenCount(info, completionTokenKeys),
+		TotalTokens:      tokenCount(info, totalTokenKeys),
+		ReasoningTokens:  tokenCount(info, reasoningTokenKeys),
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	return usage
+}
+
+// tokenCount returns the first token count of info under one of keys.
+func tokenCount(info map[string]any, keys []string) int {
+	for _, key := range keys {
+		switch n := info[key].(type) {
+		case int:
+			return n
+		case int32:
+			return int(n)
+		case int64:
+			return int(n)
+		case float64:
+			return int(n)
+		}
+	}
+	return 0
+}
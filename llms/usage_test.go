@@ -0,0 +1,42 @@
+package llms
+
+import "testing"
+
+func TestResponseUsage(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		resp *ContentResponse
+		want Usage
+	}{
+		{"nil", nil, Usage{}},
+		{"usage", &ContentResponse{Usage: NewUsage(3, 4)}, Usage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7}},
+		{
+			"openai generation info",
+			&ContentResponse{Choices: []*ContentChoice{{GenerationInfo: map[string]any{
+				"PromptTokens": 3, "CompletionTokens": 4, "TotalTokens": 7,
+			}}}},
+			Usage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7},
+		},
+		{
+			"reasoning generation info",
+			&ContentResponse{Choices: []*ContentChoice{{GenerationInfo: map[string]any{
+				"PromptTokens": 3, "CompletionTokens": 40, "TotalTokens": 43, "ReasoningTokens": 36,
+			}}}},
+			Usage{PromptTokens: 3, CompletionTokens: 40, TotalTokens: 43, ReasoningTokens: 36},
+		},
+		{
+			"gemini generation info",
+			&ContentResponse{Choices: []*ContentChoice{{GenerationInfo: map[string]any{
+				"input_tokens": int32(3), "output_tokens": int32(4),
+			}}}},
+			Usage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7},
+		},
+		{"no usage", &ContentResponse{Choices: []*ContentChoice{{Content: "hi"}}}, Usage{}},
+	}
+	for _, tt := range tests {
+		if got := ResponseUsage(tt.resp); got != tt.want {
+			t.Errorf("%s: ResponseUsage() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -40,6 +40,14 @@ func (wx *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConte
 		return nil, err
 	}
 
+	opts := getDefaultCallOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+	if err := opts.RateLimiter.Wait(ctx, messages, *opts); err != nil {
+		return nil, err
+	}
+
 	result, err := wx.client.GenerateText(
 		wx.modelID,
 		prompt,
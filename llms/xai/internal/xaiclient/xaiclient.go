@@ -0,0 +1,147 @@
+package xaiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	DefaultBaseURL = "https://api.x.ai/v1"
+
+	defaultModel = "grok-3"
+)
+
+// Client is a client for the xAI API.
+type Client struct {
+	token   string
+	Model   string
+	baseURL string
+
+	httpClient Doer
+}
+
+// Option is an option for the xAI client.
+type Option func(*Client) error
+
+// Doer performs a HTTP request.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// WithHTTPClient allows setting a custom HTTP client.
+func WithHTTPClient(client Doer) Option {
+	return func(c *Client) error {
+		c.httpClient = client
+		return nil
+	}
+}
+
+// New returns a new xAI client.
+func New(token string, model string, baseURL string, opts ...Option) (*Client, error) {
+	c := &Client{
+		token:      token,
+		Model:      model,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	if c.Model == "" {
+		c.Model = defaultModel
+	}
+	if c.baseURL == "" {
+		c.baseURL = DefaultBaseURL
+	}
+
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// CreateChat creates a chat completion, streamed if the request has a
+// streaming function.
+func (c *Client) CreateChat(ctx context.Context, r *ChatRequest) (*ChatResponse, error) {
+	if r.Model == "" {
+		r.Model = c.Model
+	}
+	return c.createChat(ctx, r)
+}
+
+func (c *Client) do(ctx context.Context, path string, payload any) (*http.Response, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payloadBytes))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, errorFromResponse(resp)
+	}
+	return resp, nil
+}
+
+// maxErrorBodySize is the size of the body of the error responses read for
+// their message.
+const maxErrorBodySize = 1 << 16
+
+func errorFromResponse(r *http.Response) error {
+	msg := fmt.Sprintf("API returned unexpected status code: %d", r.StatusCode)
+
+	// No need to check the error here: if it fails, we'll just return the
+	// status code.
+	body, _ := io.ReadAll(io.LimitReader(r.Body, maxErrorBodySize))
+	if detail := errorDetail(body); detail != "" {
+		return fmt.Errorf("%s: %s", msg, detail) // nolint:goerr113
+	}
+	return errors.New(msg) // nolint:goerr113
+}
+
+// errorDetail returns the message of an error response body, which xAI
+// returns as an error string along with a code, or the body itself when it
+// isn't JSON.
+func errorDetail(body []byte) string {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return ""
+	}
+	var resp struct {
+		Code  string          `json:"code"`
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return string(body)
+	}
+	var errObj struct {
+		Message string `json:"message"`
+	}
+	var s string
+	switch {
+	case json.Unmarshal(resp.Error, &s) == nil && s != "":
+		if resp.Code != "" {
+			return resp.Code + ": " + s
+		}
+		return s
+	case json.Unmarshal(resp.Error, &errObj) == nil && errObj.Message != "":
+		return errObj.Message
+	}
+	return resp.Code
+}
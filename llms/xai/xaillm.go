@@ -0,0 +1,321 @@
+package xai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/xai/internal/xaiclient"
+)
+
+var (
+	ErrEmptyResponse          = errors.New("no response")
+	ErrMissingToken           = errors.New("missing the xAI API key, set it in the XAI_API_KEY environment variable")
+	ErrUnsupportedMessageType = errors.New("unsupported message type")
+	ErrInvalidContentType     = errors.New("invalid content type")
+)
+
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// LLM is a client for the chat models of xAI, the Grok models.
+type LLM struct {
+	CallbacksHandler callbacks.Handler
+	client           *xaiclient.Client
+}
+
+var _ llms.Model = (*LLM)(nil)
+
+// New returns a new xAI LLM.
+func New(opts ...Option) (*LLM, error) {
+	options := &options{
+		token:      os.Getenv(tokenEnvVarName),
+		baseURL:    xaiclient.DefaultBaseURL,
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if len(options.token) == 0 {
+		return nil, ErrMissingToken
+	}
+
+	c, err := xaiclient.New(options.token, options.model, options.baseURL,
+		xaiclient.WithHTTPClient(options.httpClient),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("xai: failed to create client: %w", err)
+	}
+	return &LLM{
+		CallbacksHandler: options.callbackHandler,
+		client:           c,
+	}, nil
+}
+
+// Call requests a completion for the given prompt.
+func (o *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, o, prompt, options...)
+}
+
+// GenerateContent implements the Model interface.
+func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleLLMGenerateContentStart(ctx, messages)
+	}
+
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	if err := opts.RateLimiter.Wait(ctx, messages, opts); err != nil {
+		return nil, err
+	}
+
+	chatMessages, err := processMessages(messages)
+	if err != nil {
+		return nil, fmt.Errorf("xai: %w", err)
+	}
+	req := &xaiclient.ChatRequest{
+		Model:             opts.Model,
+		Messages:          chatMessages,
+		Temperature:       opts.Temperature,
+		TopP:              opts.TopP,
+		MaxTokens:         opts.MaxTokens,
+		N:                 opts.N,
+		Seed:              opts.Seed,
+		StopWords:         opts.StopWords,
+		FrequencyPenalty:  opts.FrequencyPenalty,
+		PresencePenalty:   opts.PresencePenalty,
+		Tools:             toolsToTools(opts),
+		ParallelToolCalls: opts.ParallelToolCalls,
+		StreamingFunc:     opts.StreamingFunc,
+
+		ReasoningEffort: string(opts.ReasoningEffort),
+		Logprobs:        opts.Logprobs,
+		TopLogprobs:     opts.TopLogprobs,
+
+		StreamingToolCallFunc:  opts.StreamingToolCallFunc,
+		StreamingReasoningFunc: opts.StreamingReasoningFunc,
+	}
+	if err := setToolChoice(req, opts.ToolChoice); err != nil {
+		return nil, fmt.Errorf("xai: %w", err)
+	}
+	switch {
+	case opts.ResponseSchema != nil:
+		req.ResponseFormat = &xaiclient.ResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &xaiclient.ResponseJSONSchema{
+				Name:   opts.ResponseSchema.Name,
+				Schema: opts.ResponseSchema.Schema,
+				Strict: opts.ResponseSchema.Strict,
+			},
+		}
+	case opts.JSONMode:
+		req.ResponseFormat = &xaiclient.ResponseFormat{Type: "json_object"}
+	}
+
+	result, err := o.client.CreateChat(ctx, req)
+	if err != nil {
+		if o.CallbacksHandler != nil {
+			o.CallbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, fmt.Errorf("xai: failed to create chat: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	resp := convertResponse(result)
+	if o.CallbacksHandler != nil {
+		o.CallbacksHandler.HandleLLMGenerateContentEnd(ctx, resp)
+	}
+	return resp, nil
+}
+
+func processMessages(messages []llms.MessageContent) ([]xaiclient.ChatMessage, error) {
+	chatMessages := make([]xaiclient.ChatMessage, 0, len(messages))
+	for _, mc := range messages {
+		var role string
+		switch mc.Role {
+		case llms.ChatMessageTypeSystem:
+			role = RoleSystem
+		case llms.ChatMessageTypeHuman, llms.ChatMessageTypeGeneric:
+			role = RoleUser
+		case llms.ChatMessageTypeAI:
+			role = RoleAssistant
+		case llms.ChatMessageTypeTool:
+			// Each result of the parallel tool calls is a message of its own.
+			for _, part := range mc.Parts {
+				p, ok := part.(llms.ToolCallResponse)
+				if !ok {
+					return nil, fmt.Errorf("%w for tool message: %T", ErrInvalidContentType, part)
+				}
+				chatMessages = append(chatMessages, xaiclient.ChatMessage{
+					Role:       RoleTool,
+					ToolCallID: p.ToolCallID,
+					Content:    p.Content,
+				})
+			}
+			continue
+		case llms.ChatMessageTypeFunction:
+			fallthrough
+		default:
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedMessageType, mc.Role)
+		}
+
+		msg := xaiclient.ChatMessage{Role: role}
+		var text strings.Builder
+		for _, part := range mc.Parts {
+			switch p := part.(type) {
+			case llms.TextContent:
+				text.WriteString(p.Text)
+			case llms.ToolCall:
+				if role != RoleAssistant || p.FunctionCall == nil {
+					return nil, fmt.Errorf("%w for %s message: tool call", ErrInvalidContentType, role)
+				}
+				msg.ToolCalls = append(msg.ToolCalls, xaiclient.ToolCall{
+					ID:   p.ID,
+					Type: "function",
+					Function: xaiclient.FunctionCall{
+						Name:      p.FunctionCall.Name,
+						Arguments: p.FunctionCall.Arguments,
+					},
+				})
+			case llms.ReasoningContent:
+				// The reasoning of previous turns isn't sent back.
+			default:
+				return nil, fmt.Errorf("%w for %s message: %T", ErrInvalidContentType, role, part)
+			}
+		}
+		msg.Content = text.String()
+		chatMessages = append(chatMessages, msg)
+	}
+	return chatMessages, nil
+}
+
+// toolsToTools returns the tools of the call, including the deprecated
+// functions.
+func toolsToTools(opts llms.CallOptions) []xaiclient.Tool {
+	tools := make([]xaiclient.Tool, 0, len(opts.Tools)+len(opts.Functions))
+	for _, fn := range opts.Functions {
+		tools = append(tools, xaiclient.Tool{
+			Type: "function",
+			Function: xaiclient.FunctionDefinition{
+				Name:        fn.Name,
+				Description: fn.Description,
+				Parameters:  fn.Parameters,
+			},
+		})
+	}
+	for _, tool := range opts.Tools {
+		if tool.Function == nil {
+			continue
+		}
+		tools = append(tools, xaiclient.Tool{
+			Type: "function",
+			Function: xaiclient.FunctionDefinition{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				Parameters:  tool.Function.Parameters,
+			},
+		})
+	}
+	return tools
+}
+
+func setToolChoice(req *xaiclient.ChatRequest, toolChoice any) error {
+	mode, function, err := llms.ResolveToolChoice(toolChoice)
+	if err != nil {
+		return err
+	}
+	if len(req.Tools) == 0 {
+		return nil
+	}
+	switch mode {
+	case "":
+	case llms.ToolChoiceAuto, llms.ToolChoiceNone, llms.ToolChoiceRequired:
+		req.ToolChoice = string(mode)
+	case llms.ToolChoiceFunction:
+		req.ToolChoice = xaiclient.ToolChoice{
+			Type:     "function",
+			Function: xaiclient.ToolChoiceFunction{Name: function},
+		}
+	}
+	return nil
+}
+
+func convertResponse(result *xaiclient.ChatResponse) *llms.ContentResponse {
+	choices := make([]*llms.ContentChoice, len(result.Choices))
+	for i, c := range result.Choices {
+		choice := &llms.ContentChoice{
+			Content:    c.Message.Content,
+			StopReason: c.FinishReason,
+			GenerationInfo: map[string]any{
+				"CompletionTokens": result.Usage.CompletionTokens,
+				"PromptTokens":     result.Usage.PromptTokens,
+				"TotalTokens":      result.Usage.TotalTokens,
+				"ReasoningTokens":  result.Usage.CompletionTokensDetails.ReasoningTokens,
+			},
+		}
+		if c.Message.ReasoningContent != "" {
+			choice.Reasoning = []llms.ReasoningContent{{Text: c.Message.ReasoningContent}}
+		}
+		if c.Logprobs != nil {
+			choice.Logprobs = convertLogprobs(c.Logprobs.Content)
+		}
+		for _, tc := range c.Message.ToolCalls {
+			choice.ToolCalls = append(choice.ToolCalls, llms.ToolCall{
+				ID:   tc.ID,
+				Type: tc.Type,
+				FunctionCall: &llms.FunctionCall{
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				},
+			})
+		}
+		if len(choice.ToolCalls) > 0 {
+			choice.FuncCall = choice.ToolCalls[0].FunctionCall
+		}
+		choices[i] = choice
+	}
+	resp := &llms.ContentResponse{
+		Choices: choices,
+		Usage:   llms.NewUsage(result.Usage.PromptTokens, result.Usage.CompletionTokens),
+	}
+	resp.Usage.CachedTokens = result.Usage.PromptTokensDetails.CachedTokens
+	resp.Usage.ReasoningTokens = result.Usage.CompletionTokensDetails.ReasoningTokens
+	return resp
+}
+
+// convertLogprobs converts the log probabilities of the tokens of a choice.
+func convertLogprobs(logprobs []xaiclient.TokenLogprob) []llms.TokenLogprob {
+	converted := make([]llms.TokenLogprob, 0, len(logprobs))
+	for _, lp := range logprobs {
+		logprob := llms.TokenLogprob{Token: lp.Token, Logprob: lp.Logprob, Bytes: lp.Bytes}
+		if len(lp.TopLogprobs) > 0 {
+			logprob.TopLogprobs = convertLogprobs(lp.TopLogprobs)
+		}
+		converted = append(converted, logprob)
+	}
+	return converted
+}
+
+// Capabilities implements the llms.CapabilityReporter interface, reporting
+// the capabilities of the model.
+func (o *LLM) Capabilities() llms.ModelCapabilities {
+	if capabilities, ok := llms.LookupModelCapabilities(o.client.Model); ok {
+		return capabilities
+	}
+	return llms.ModelCapabilities{ToolCalling: true, JSONMode: true, Streaming: true}
+}
@@ -0,0 +1,66 @@
+package xai
+
+import (
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms/xai/internal/xaiclient"
+)
+
+const (
+	tokenEnvVarName = "XAI_API_KEY" //nolint:gosec
+)
+
+// Models of the xAI API.
+const (
+	ModelGrok4     = "grok-4"
+	ModelGrok3     = "grok-3"
+	ModelGrok3Mini = "grok-3-mini"
+)
+
+type options struct {
+	token           string
+	model           string
+	baseURL         string
+	httpClient      xaiclient.Doer
+	callbackHandler callbacks.Handler
+}
+
+// Option is an option for the xAI LLM.
+type Option func(*options)
+
+// WithToken passes the xAI API key to the client. If not set, the key is
+// read from the XAI_API_KEY environment variable.
+func WithToken(token string) Option {
+	return func(opts *options) {
+		opts.token = token
+	}
+}
+
+// WithModel sets the default model of the calls, ModelGrok3 if not set.
+func WithModel(model string) Option {
+	return func(opts *options) {
+		opts.model = model
+	}
+}
+
+// WithBaseURL passes the xAI base URL to the client.
+// If not set, the default base URL is used.
+func WithBaseURL(baseURL string) Option {
+	return func(opts *options) {
+		opts.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient allows setting a custom HTTP client. If not set, the default value
+// is http.DefaultClient.
+func WithHTTPClient(client xaiclient.Doer) Option {
+	return func(opts *options) {
+		opts.httpClient = client
+	}
+}
+
+// WithCallback allows setting a custom Callback Handler.
+func WithCallback(callbackHandler callbacks.Handler) Option {
+	return func(opts *options) {
+		opts.callbackHandler = callbackHandler
+	}
+}
@@ -0,0 +1,194 @@
+package xai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func newTestLLM(t *testing.T, handler http.HandlerFunc) *LLM {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	llm, err := New(WithToken("test-token"), WithBaseURL(server.URL))
+	require.NoError(t, err)
+	return llm
+}
+
+func TestGenerateContentToolCalls(t *testing.T) {
+	t.Parallel()
+	var request map[string]any
+	llm := newTestLLM(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(body, &request))
+		_, _ = io.WriteString(w, `{
+			"id": "1", "model": "grok-3",
+			"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {
+				"role": "assistant", "content": "",
+				"tool_calls": [
+					{"id": "c1", "type": "function", "function": {"name": "weather", "arguments": "{\"city\":\"Paris\"}"}},
+					{"id": "c2", "type": "function", "function": {"name": "weather", "arguments": "{\"city\":\"Rome\"}"}}
+				]
+			}}],
+			"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15, "prompt_tokens_details": {"cached_tokens": 4}}
+		}`)
+	})
+
+	messages := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "You are a weather bot."),
+		llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Paris and Rome?"),
+	}
+	tools := []llms.Tool{{
+		Type: "function",
+		Function: &llms.FunctionDefinition{
+			Name:       "weather",
+			Parameters: map[string]any{"type": "object"},
+		},
+	}}
+	resp, err := llm.GenerateContent(context.Background(), messages,
+		llms.WithTools(tools), llms.WithToolChoice(llms.FunctionToolChoice("weather")))
+	require.NoError(t, err)
+
+	assert.Equal(t, "grok-3", request["model"])
+	assert.Equal(t, map[string]any{"type": "function", "function": map[string]any{"name": "weather"}}, request["tool_choice"])
+	require.Len(t, resp.Choices, 1)
+	require.Len(t, resp.Choices[0].ToolCalls, 2)
+	assert.Equal(t, "c2", resp.Choices[0].ToolCalls[1].ID)
+	assert.Equal(t, `{"city":"Paris"}`, resp.Choices[0].FuncCall.Arguments)
+	assert.Equal(t, llms.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, CachedTokens: 4}, resp.Usage)
+}
+
+func TestGenerateContentJSONMode(t *testing.T) {
+	t.Parallel()
+	var request map[string]any
+	llm := newTestLLM(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_, _ = io.WriteString(w, `{"choices": [{"message": {"role": "assistant", "content": "{\"ok\":true}"}, "finish_reason": "stop"}]}`)
+	})
+
+	resp, err := llm.GenerateContent(context.Background(),
+		[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Answer in JSON.")},
+		llms.WithJSONMode())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"type": "json_object"}, request["response_format"])
+	assert.Equal(t, `{"ok":true}`, resp.Choices[0].Content)
+}
+
+func TestGenerateContentReasoning(t *testing.T) {
+	t.Parallel()
+	var request map[string]any
+	llm := newTestLLM(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_, _ = io.WriteString(w, `{
+			"choices": [{"message": {"role": "assistant", "content": "4", "reasoning_content": "2 plus 2 is 4."}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 8, "completion_tokens": 21, "total_tokens": 29, "completion_tokens_details": {"reasoning_tokens": 20}}
+		}`)
+	})
+
+	resp, err := llm.GenerateContent(context.Background(),
+		[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "What is 2+2?")},
+		llms.WithModel(ModelGrok3Mini), llms.WithReasoningEffort(llms.ReasoningEffortHigh))
+	require.NoError(t, err)
+	assert.Equal(t, "high", request["reasoning_effort"])
+	assert.Equal(t, "4", resp.Choices[0].Content)
+	assert.Equal(t, []llms.ReasoningContent{{Text: "2 plus 2 is 4."}}, resp.Choices[0].Reasoning)
+	assert.Equal(t, 20, resp.Usage.ReasoningTokens)
+}
+
+func TestGenerateContentLogprobs(t *testing.T) {
+	t.Parallel()
+	var request map[string]any
+	llm := newTestLLM(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_, _ = io.WriteString(w, `{
+			"choices": [{"message": {"role": "assistant", "content": "Yes"}, "finish_reason": "stop", "logprobs": {"content": [
+				{"token": "Yes", "logprob": -0.1, "bytes": [89, 101, 115], "top_logprobs": [
+					{"token": "Yes", "logprob": -0.1, "bytes": [89, 101, 115]},
+					{"token": "No", "logprob": -2.4, "bytes": [78, 111]}
+				]}
+			]}}]
+		}`)
+	})
+
+	resp, err := llm.GenerateContent(context.Background(),
+		[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Is the sky blue?")},
+		llms.WithLogprobs(2))
+	require.NoError(t, err)
+	assert.Equal(t, true, request["logprobs"])
+	assert.InDelta(t, 2, request["top_logprobs"], 0)
+	assert.Equal(t, []llms.TokenLogprob{{
+		Token: "Yes", Logprob: -0.1, Bytes: []byte("Yes"),
+		TopLogprobs: []llms.TokenLogprob{
+			{Token: "Yes", Logprob: -0.1, Bytes: []byte("Yes")},
+			{Token: "No", Logprob: -2.4, Bytes: []byte("No")},
+		},
+	}}, resp.Choices[0].Logprobs)
+}
+
+func TestGenerateContentStreaming(t *testing.T) {
+	t.Parallel()
+	var request map[string]any
+	llm := newTestLLM(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"choices":[{"index":0,"delta":{"role":"assistant","content":"Let me "}}]}
+
+data: {"choices":[{"index":0,"delta":{"content":"check."}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"id":"c1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"},"index":0}]}}]}
+
+data: {"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}
+
+data: [DONE]
+`)
+	})
+
+	var text string
+	var deltas []llms.ToolCallDelta
+	resp, err := llm.GenerateContent(context.Background(),
+		[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Paris?")},
+		llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+			text += string(chunk)
+			return nil
+		}),
+		llms.WithStreamingToolCallFunc(func(_ context.Context, delta llms.ToolCallDelta) error {
+			deltas = append(deltas, delta)
+			return nil
+		}))
+	require.NoError(t, err)
+
+	assert.Equal(t, true, request["stream"])
+	assert.Equal(t, "Let me check.", text)
+	assert.Equal(t, []llms.ToolCallDelta{
+		{Index: 0, ID: "c1", Name: "weather", ArgumentsDelta: `{"city":"Paris"}`},
+	}, deltas)
+	choice := resp.Choices[0]
+	assert.Equal(t, "Let me check.", choice.Content)
+	assert.Equal(t, "tool_calls", choice.StopReason)
+	require.Len(t, choice.ToolCalls, 1)
+	assert.Equal(t, "weather", choice.ToolCalls[0].FunctionCall.Name)
+	assert.Equal(t, llms.NewUsage(10, 5), resp.Usage)
+}
+
+func TestGenerateContentError(t *testing.T) {
+	t.Parallel()
+	llm := newTestLLM(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"code":"Client specified an invalid argument","error":"Incorrect API key provided"}`)
+	})
+
+	_, err := llm.Call(context.Background(), "Hello")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+	assert.Contains(t, err.Error(), "Incorrect API key provided")
+}
@@ -2,10 +2,11 @@ package alloydb
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/tmc/langchaingo/util/alloydbutil"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -14,10 +15,26 @@ import (
 )
 
 type ChatMessageHistory struct {
-	engine     alloydbutil.PostgresEngine
-	sessionID  string
-	tableName  string
-	schemaName string
+	engine      alloydbutil.PostgresEngine
+	sessionID   string
+	tableName   string
+	schemaName  string
+	idGenerator alloydbutil.IDGenerator
+	// hasMetadata is whether the table has the created_at and metadata
+	// columns, which tables created before they were added lack.
+	hasMetadata bool
+	sessionLock bool
+	retention   RetentionPolicy
+	// hasCompressedData is whether the table has the compressed_data
+	// column, which tables created before it was added lack.
+	hasCompressedData bool
+	// compressor, if not nil, compresses the messages of at least
+	// compressionMinSize bytes.
+	compressor         alloydbutil.Compressor
+	compressionMinSize int
+	// readOnly refuses the mutating operations and reads the messages in
+	// read-only transactions.
+	readOnly bool
 }
 
 var _ schema.ChatMessageHistory = &ChatMessageHistory{}
@@ -46,23 +63,38 @@ func NewChatMessageHistory(ctx context.Context,
 		sessionID: sessionID,
 	}
 	cmh = applyChatMessageHistoryOptions(cmh, opts...)
+	switch cmh.idGenerator.(type) {
+	case alloydbutil.CallerProvided, *alloydbutil.CallerProvided:
+		return ChatMessageHistory{}, errors.New("the CallerProvided ID generator can't be used, messages carry no id")
+	}
 
 	err = cmh.validateTable(ctx)
 	if err != nil {
 		return ChatMessageHistory{}, fmt.Errorf("error validating table '%s' in schema '%s': %w", tableName, cmh.schemaName, err)
 	}
+	if cmh.retention.MaxAge > 0 && !cmh.hasMetadata {
+		return ChatMessageHistory{}, fmt.Errorf("retention policy with a maximum age: %w", ErrNoMetadataColumns)
+	}
+	if cmh.compressor != nil && !cmh.hasCompressedData {
+		return ChatMessageHistory{}, fmt.Errorf("compression: %w", ErrNoCompressedDataColumn)
+	}
 	return cmh, nil
 }
 
+// table returns the quoted, schema qualified name of the table of the
+// history.
+func (c *ChatMessageHistory) table() string {
+	return alloydbutil.QuoteIdentifier(c.schemaName, c.tableName)
+}
+
 // validateTable validates if a table with a specific schema exist and it
 // contains the required columns.
 func (c *ChatMessageHistory) validateTable(ctx context.Context) error {
-	tableExistsQuery := fmt.Sprintf(`SELECT EXISTS (
+	tableExistsQuery := `SELECT EXISTS (
 		SELECT FROM information_schema.tables 
-		WHERE table_schema = '%s' AND table_name = '%s');`,
-		c.schemaName, c.tableName)
+		WHERE table_schema = $1 AND table_name = $2);`
 	var exists bool
-	err := c.engine.Pool.QueryRow(ctx, tableExistsQuery).Scan(&exists)
+	err := c.engine.Pool.QueryRow(ctx, tableExistsQuery, c.schemaName, c.tableName).Scan(&exists)
 	if err != nil {
 		return fmt.Errorf("error validating the existence of table '%s' in schema '%s': %w", c.tableName, c.schemaName, err)
 	}
@@ -81,12 +113,12 @@ func (c *ChatMessageHistory) validateTable(ctx context.Context) error {
 	columns := make(map[string]string)
 
 	// Get the columns from the table
-	columnsQuery := fmt.Sprintf(`
+	columnsQuery := `
     	SELECT column_name, data_type
     	FROM information_schema.columns
-   	 	WHERE table_schema = '%s' AND table_name = '%s';`, c.schemaName, c.tableName)
+   	 	WHERE table_schema = $1 AND table_name = $2;`
 
-	rows, err := c.engine.Pool.Query(ctx, columnsQuery)
+	rows, err := c.engine.Pool.Query(ctx, columnsQuery, c.schemaName, c.tableName)
 	if err != nil {
 		return fmt.Errorf("error fetching columns from table '%s' in schema '%s': %w", c.tableName, c.schemaName, err)
 	}
@@ -100,6 +132,13 @@ func (c *ChatMessageHistory) validateTable(ctx context.Context) error {
 		columns[columnName] = dataType
 	}
 
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error fetching columns from table '%s' in schema '%s': %w", c.tableName, c.schemaName, err)
+	}
+	_, hasCreatedAt := columns["created_at"]
+	c.hasMetadata = hasCreatedAt && columns["metadata"] == "jsonb"
+	c.hasCompressedData = columns["compressed_data"] == "bytea"
+
 	// Validate column names and types
 	for reqColumn, expectedType := range requiredColumns {
 		actualType, found := columns[reqColumn]
@@ -116,134 +155,399 @@ func (c *ChatMessageHistory) validateTable(ctx context.Context) error {
 
 // addMessage adds a new message into the ChatMessageHistory for a given
 // session.
-func (c *ChatMessageHistory) addMessage(ctx context.Context, content string, messageType llms.ChatMessageType) error {
-	data, err := json.Marshal(content)
+func (c *ChatMessageHistory) addMessage(ctx context.Context, message llms.ChatMessage) error {
+	if err := c.checkWritable("add message"); err != nil {
+		return err
+	}
+	data, err := marshalMessage(message)
 	if err != nil {
-		return fmt.Errorf("failed to serialize content to JSON: %w", err)
+		return err
+	}
+	args, err := c.insertMessageArgs(data, message.GetType())
+	if err != nil {
+		return err
 	}
-	query := fmt.Sprintf(`INSERT INTO %q.%q (session_id, data, type) VALUES ($1, $2, $3)`,
-		c.schemaName, c.tableName)
 
-	_, err = c.engine.Pool.Exec(ctx, query, c.sessionID, data, messageType)
+	err = c.appendMessages(ctx, func(db execer) error {
+		_, err := db.Exec(ctx, c.insertMessageQuery(), args...)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to add message to database: %w", err)
 	}
 	return nil
 }
 
+// insertMessageQuery returns the statement used to insert a single message.
+func (c *ChatMessageHistory) insertMessageQuery() string {
+	return c.insertQuery(c.messageColumns())
+}
+
+// insertQuery returns the statement inserting a message with the values of
+// columns, passed as parameters in order. A NULL creation time defaults to
+// the current time.
+func (c *ChatMessageHistory) insertQuery(columns []string) string {
+	values := make([]string, len(columns))
+	for i, column := range columns {
+		values[i] = fmt.Sprintf("$%d", i+1)
+		if column == "created_at" {
+			values[i] = fmt.Sprintf("COALESCE($%d, NOW())", i+1)
+		}
+	}
+	return fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`,
+		c.table(), strings.Join(columns, ", "), strings.Join(values, ", "))
+}
+
+// messageColumns returns the columns of the arguments of insertMessageArgs.
+func (c *ChatMessageHistory) messageColumns() []string {
+	columns := []string{"session_id", "data", "type"}
+	if c.idGenerator != nil {
+		columns = append([]string{"message_id"}, columns...)
+	}
+	if c.hasCompressedData {
+		columns = append(columns, "compressed_data")
+	}
+	return columns
+}
+
+// insertMessageArgs returns the arguments of insertMessageQuery, generating
+// a message id when an IDGenerator is configured.
+func (c *ChatMessageHistory) insertMessageArgs(data []byte, messageType llms.ChatMessageType) ([]any, error) {
+	data, compressed, err := c.compress(data)
+	if err != nil {
+		return nil, err
+	}
+	args := []any{c.sessionID, data, messageType}
+	if c.hasCompressedData {
+		args = append(args, compressed)
+	}
+	if c.idGenerator == nil {
+		return args, nil
+	}
+	id, err := c.idGenerator.NewID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate message id: %w", err)
+	}
+	return append([]any{id}, args...), nil
+}
+
 // AddMessage adds a message to the ChatMessageHistory.
 func (c *ChatMessageHistory) AddMessage(ctx context.Context, message llms.ChatMessage) error {
-	return c.addMessage(ctx, message.GetContent(), message.GetType())
+	return c.addMessage(ctx, message)
 }
 
 // AddAIMessage adds an AI-generated message to the ChatMessageHistory.
 func (c *ChatMessageHistory) AddAIMessage(ctx context.Context, content string) error {
-	return c.addMessage(ctx, content, llms.ChatMessageTypeAI)
+	return c.addMessage(ctx, llms.AIChatMessage{Content: content})
 }
 
 // AddUserMessage adds a user-generated message to the ChatMessageHistory.
 func (c *ChatMessageHistory) AddUserMessage(ctx context.Context, content string) error {
-	return c.addMessage(ctx, content, llms.ChatMessageTypeHuman)
+	return c.addMessage(ctx, llms.HumanChatMessage{Content: content})
 }
 
 // Clear removes all messages associated with a session from the
 // ChatMessageHistory.
 func (c *ChatMessageHistory) Clear(ctx context.Context) error {
-	query := fmt.Sprintf(`DELETE FROM %q.%q WHERE session_id = $1`,
-		c.schemaName, c.tableName)
+	if err := c.checkWritable("clear session"); err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`DELETE FROM %s WHERE session_id = $1`, c.table())
 
-	_, err := c.engine.Pool.Exec(ctx, query, c.sessionID)
+	err := c.write(ctx, func(db execer) error {
+		_, err := db.Exec(ctx, query, c.sessionID)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to clear session %s: %w", c.sessionID, err)
 	}
-	return err
+	return nil
 }
 
 // AddMessages adds multiple messages to the ChatMessageHistory for a given
-// session.
+// session, in a single round trip. Either all or none of the messages are
+// added.
 func (c *ChatMessageHistory) AddMessages(ctx context.Context, messages []llms.ChatMessage) error {
+	if err := c.checkWritable("add messages"); err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	b, err := c.insertMessagesBatch(messages)
+	if err != nil {
+		return err
+	}
+	err = c.appendMessages(ctx, func(db execer) error {
+		return db.SendBatch(ctx, b).Close()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add messages to database: %w", err)
+	}
+	return nil
+}
+
+// AddMessageContents adds messages with all their parts, e.g. images and
+// tool calls, to the ChatMessageHistory for a given session, in a single
+// round trip. They are read back with all their parts by MessageContents.
+func (c *ChatMessageHistory) AddMessageContents(ctx context.Context, contents []llms.MessageContent) error {
+	if err := c.checkWritable("add messages"); err != nil {
+		return err
+	}
+	if len(contents) == 0 {
+		return nil
+	}
 	b := &pgx.Batch{}
-	query := fmt.Sprintf(`INSERT INTO %q.%q (session_id, data, type) VALUES ($1, $2, $3)`,
-		c.schemaName, c.tableName)
+	query := c.insertMessageQuery()
+	for _, content := range contents {
+		data, err := marshalMessageContent(content)
+		if err != nil {
+			return err
+		}
+		args, err := c.insertMessageArgs(data, content.Role)
+		if err != nil {
+			return err
+		}
+		b.Queue(query, args...)
+	}
+	err := c.appendMessages(ctx, func(db execer) error {
+		return db.SendBatch(ctx, b).Close()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add messages to database: %w", err)
+	}
+	return nil
+}
+
+// MessageContents retrieves the messages associated with a session from the
+// ChatMessageHistory with all their parts, in insertion order, e.g. to replay
+// an agent transcript. It takes the options of ListMessages.
+func (c *ChatMessageHistory) MessageContents(ctx context.Context, opts ...ListMessagesOption) ([]llms.MessageContent, error) {
+	stored, err := c.ListMessages(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	contents := make([]llms.MessageContent, len(stored))
+	for i, message := range stored {
+		contents[i] = message.Content
+	}
+	return contents, nil
+}
+
+// insertMessagesBatch returns a batch inserting messages in order.
+func (c *ChatMessageHistory) insertMessagesBatch(messages []llms.ChatMessage) (*pgx.Batch, error) {
+	b := &pgx.Batch{}
+	query := c.insertMessageQuery()
 
 	for _, message := range messages {
-		data, err := json.Marshal(message.GetContent())
+		data, err := marshalMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		args, err := c.insertMessageArgs(data, message.GetType())
 		if err != nil {
-			return fmt.Errorf("failed to serialize content to JSON: %w", err)
+			return nil, err
 		}
-		b.Queue(query, c.sessionID, data, message.GetType())
+		b.Queue(query, args...)
 	}
-	return c.engine.Pool.SendBatch(ctx, b).Close()
+	return b, nil
 }
 
 // Messages retrieves all messages associated with a session from the
-// ChatMessageHistory.
+// ChatMessageHistory, in insertion order. They are read from the engine's
+// read pool instance, if any.
 func (c *ChatMessageHistory) Messages(ctx context.Context) ([]llms.ChatMessage, error) {
-	query := fmt.Sprintf(
-		`SELECT id, session_id, data, type, timestamp FROM %q.%q WHERE session_id = $1 ORDER BY id`,
-		c.schemaName, c.tableName,
-	)
+	stored, err := c.ListMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]llms.ChatMessage, len(stored))
+	for i, message := range stored {
+		messages[i] = message.Message
+	}
+	return messages, nil
+}
+
+// ListMessages retrieves the messages associated with a session from the
+// ChatMessageHistory, in insertion order, along with their ids. With
+// WithLimit or WithTokenBudget, only the most recent messages are returned,
+// and WithBeforeID pages back through older messages. They are read from the
+// engine's read pool instance, if any.
+func (c *ChatMessageHistory) ListMessages(ctx context.Context, opts ...ListMessagesOption) ([]StoredMessage, error) {
+	o := applyListMessagesOptions(opts...)
+	var messages []StoredMessage
+	err := c.read(ctx, func(ctx context.Context, db querier) error {
+		var err error
+		messages, err = c.readMessages(ctx, db, o)
+		return err
+	})
+	return messages, err
+}
 
-	rows, err := c.engine.Pool.Query(ctx, query, c.sessionID)
+// readMessages reads the messages of the session with db.
+func (c *ChatMessageHistory) readMessages(ctx context.Context, db querier, o listMessagesOptions) ([]StoredMessage, error) {
+	conditions := "session_id = $1"
+	args := []any{c.sessionID}
+	if o.beforeID > 0 {
+		args = append(args, o.beforeID)
+		conditions += fmt.Sprintf(" AND id < $%d", len(args))
+	}
+	limit := ""
+	if o.limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", o.limit)
+	}
+
+	// The most recent messages are selected, then put back in order.
+	columns := c.selectColumns()
+	var query string
+	switch {
+	case o.maxTokens > 0 && o.tokenCounter != nil:
+		query = fmt.Sprintf(`SELECT %s FROM %s WHERE %s ORDER BY id DESC%s`,
+			columns, c.table(), conditions, limit)
+		return c.readMessagesWithinBudget(ctx, db, o, query, args)
+	case o.maxTokens > 0:
+		// Without token counter, the tokens are approximated in SQL.
+		args = append(args, o.maxTokens)
+		query = fmt.Sprintf(`SELECT %s FROM (SELECT %s, SUM(%s) OVER (ORDER BY id DESC) AS tokens FROM %s WHERE %s ORDER BY id DESC%s) AS recent WHERE tokens <= $%d ORDER BY id`,
+			columns, columns, c.approximateTokensSQL(), c.table(), conditions, limit, len(args))
+	case o.limit > 0:
+		query = fmt.Sprintf(`SELECT %s FROM (SELECT %s FROM %s WHERE %s ORDER BY id DESC%s) AS recent ORDER BY id`,
+			columns, columns, c.table(), conditions, limit)
+	default:
+		query = fmt.Sprintf(`SELECT %s FROM %s WHERE %s ORDER BY id`,
+			columns, c.table(), conditions)
+	}
+
+	var messages []StoredMessage
+	err := c.scanMessages(ctx, db, query, args, func(message StoredMessage) bool {
+		messages = append(messages, message)
+		return true
+	})
+	return messages, err
+}
+
+// readMessagesWithinBudget reads the most recent messages returned by query,
+// newest first, until the token budget of o is spent, and returns them in
+// order.
+func (c *ChatMessageHistory) readMessagesWithinBudget(ctx context.Context,
+	db querier,
+	o listMessagesOptions,
+	query string,
+	args []any,
+) ([]StoredMessage, error) {
+	var messages []StoredMessage
+	tokens := 0
+	err := c.scanMessages(ctx, db, query, args, func(message StoredMessage) bool {
+		tokens += o.tokenCounter(message.Message.GetContent())
+		if tokens > o.maxTokens {
+			return false
+		}
+		messages = append(messages, message)
+		return true
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve messages: %w", err)
+		return nil, err
+	}
+	slices.Reverse(messages)
+	return messages, nil
+}
+
+// selectColumns returns the columns of the messages read by scanMessages.
+func (c *ChatMessageHistory) selectColumns() string {
+	columns := "id, data, type"
+	if c.hasMetadata {
+		columns += ", created_at, metadata"
+	}
+	if c.hasCompressedData {
+		columns += ", compressed_data"
+	}
+	return columns
+}
+
+// scanMessages runs query, which selects the columns of selectColumns, and
+// passes the messages it returns to fn, until fn
+// returns false.
+func (c *ChatMessageHistory) scanMessages(ctx context.Context,
+	db querier,
+	query string,
+	args []any,
+	fn func(message StoredMessage) bool,
+) error {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve messages: %w", err)
 	}
 	defer rows.Close()
 
-	var messages []llms.ChatMessage
+	var (
+		id                int
+		data, messageType string
+		createdAt         time.Time
+		metadata          map[string]any
+		compressed        []byte
+	)
+	dest := []any{&id, &data, &messageType}
+	if c.hasMetadata {
+		dest = append(dest, &createdAt, &metadata)
+	}
+	if c.hasCompressedData {
+		dest = append(dest, &compressed)
+	}
 	for rows.Next() {
-		var id int
-		var sessionID, data, messageType string
-		var timestamp time.Time
-		if err := rows.Scan(&id, &sessionID, &data, &messageType, &timestamp); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+		metadata = nil
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
 		}
-
-		// Variable to hold the deserialized content
-		var content string
-
-		// Unmarshal the JSON data into the content variable
-		err := json.Unmarshal([]byte(data), &content)
+		message, err := c.readStoredMessage(id, llms.ChatMessageType(messageType), []byte(data), compressed)
 		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+			return err
 		}
-		switch messageType {
-		case string(llms.ChatMessageTypeAI):
-			messages = append(messages, llms.AIChatMessage{Content: content})
-		case string(llms.ChatMessageTypeHuman):
-			messages = append(messages, llms.HumanChatMessage{Content: content})
-		case string(llms.ChatMessageTypeSystem):
-			messages = append(messages, llms.SystemChatMessage{Content: content})
-		default:
-			return nil, fmt.Errorf("unsupported message type: %s", messageType)
+		message.CreatedAt, message.Metadata = createdAt, metadata
+		if !fn(message) {
+			return nil
 		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate over rows: %w", err)
+		return fmt.Errorf("failed to iterate over rows: %w", err)
 	}
-
-	return messages, nil
+	return nil
 }
 
-// SetMessages clears the current messages from the ChatMessageHistory for a
-// given session and then adds new messages to it.
+// SetMessages replaces the messages of the ChatMessageHistory for a given
+// session with new messages, atomically: readers see either the previous or
+// the new messages.
 func (c *ChatMessageHistory) SetMessages(ctx context.Context, messages []llms.ChatMessage) error {
-	err := c.Clear(ctx)
+	if err := c.checkWritable("set messages"); err != nil {
+		return err
+	}
+	b, err := c.insertMessagesBatch(messages)
 	if err != nil {
 		return err
 	}
+	clearQuery := fmt.Sprintf(`DELETE FROM %s WHERE session_id = $1`, c.table())
 
-	b := &pgx.Batch{}
-	query := fmt.Sprintf(`INSERT INTO %q.%q (session_id, data, type) VALUES ($1, $2, $3)`,
-		c.schemaName, c.tableName)
-
-	for _, message := range messages {
-		data, err := json.Marshal(message.GetContent())
-		if err != nil {
-			return fmt.Errorf("failed to serialize content to JSON: %w", err)
+	err = c.engine.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := c.lockSession(ctx, tx); err != nil {
+			return err
 		}
-		b.Queue(query, c.sessionID, data, message.GetType())
+		if _, err := tx.Exec(ctx, clearQuery, c.sessionID); err != nil {
+			return fmt.Errorf("failed to clear session %s: %w", c.sessionID, err)
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+		if err := tx.SendBatch(ctx, b).Close(); err != nil {
+			return fmt.Errorf("failed to add messages to database: %w", err)
+		}
+		if !c.retention.EnforceOnWrite {
+			return nil
+		}
+		_, err := c.prune(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set messages: %w", err)
 	}
-	return c.engine.Pool.SendBatch(ctx, b).Close()
+	return nil
 }
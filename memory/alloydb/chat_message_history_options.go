@@ -1,5 +1,7 @@
 package alloydb
 
+import "github.com/tmc/langchaingo/util/alloydbutil"
+
 const (
 	defaultSchemaName = "public"
 )
@@ -15,6 +17,15 @@ func WithSchemaName(schemaName string) ChatMessageHistoryStoresOption {
 	}
 }
 
+// WithIDGenerator sets the generator used to fill the message_id column. The
+// table must be created with alloydbutil.WithMessageIDGenerator. Messages
+// carry no id, so alloydbutil.CallerProvided isn't supported.
+func WithIDGenerator(idGenerator alloydbutil.IDGenerator) ChatMessageHistoryStoresOption {
+	return func(c *ChatMessageHistory) {
+		c.idGenerator = idGenerator
+	}
+}
+
 // applyChatMessageHistoryOptions applies the given options to the
 // ChatMessageHistory.
 func applyChatMessageHistoryOptions(cmh ChatMessageHistory, opts ...ChatMessageHistoryStoresOption) ChatMessageHistory {
@@ -26,3 +37,64 @@ func applyChatMessageHistoryOptions(cmh ChatMessageHistory, opts ...ChatMessageH
 	}
 	return cmh
 }
+
+// ListMessagesOption is a function for listing the messages of a chat message
+// history with other than the default values.
+type ListMessagesOption func(o *listMessagesOptions)
+
+type listMessagesOptions struct {
+	limit        int
+	beforeID     int
+	maxTokens    int
+	tokenCounter TokenCounter
+}
+
+// WithLimit sets the maximum number of messages to list, the most recent
+// ones. By default all the messages are listed.
+func WithLimit(limit int) ListMessagesOption {
+	return func(o *listMessagesOptions) {
+		o.limit = limit
+	}
+}
+
+// WithBeforeID only lists the messages older than the message with the given
+// id, such as the first message of the previous page.
+func WithBeforeID(id int) ListMessagesOption {
+	return func(o *listMessagesOptions) {
+		o.beforeID = id
+	}
+}
+
+// TokenCounter returns the number of tokens of a text, e.g. for the
+// tokenizer of the model the messages are sent to.
+type TokenCounter func(text string) int
+
+// approximateTokensSQL returns the SQL expression approximating the number
+// of tokens of a message, about 4 characters per token, used by
+// WithTokenBudget without token counter. The compressed messages count the
+// bytes of their compressed data.
+func (c *ChatMessageHistory) approximateTokensSQL() string {
+	if c.hasCompressedData {
+		return "(char_length(data::text) + COALESCE(octet_length(compressed_data), 0) + 3) / 4"
+	}
+	return "(char_length(data::text) + 3) / 4"
+}
+
+// WithTokenBudget only lists the most recent messages whose contents add up
+// to at most maxTokens tokens, counted by counter. With a nil counter, the
+// tokens are approximated in SQL as a quarter of the length of the stored
+// messages, so that only the messages within the budget are read.
+func WithTokenBudget(maxTokens int, counter TokenCounter) ListMessagesOption {
+	return func(o *listMessagesOptions) {
+		o.maxTokens = maxTokens
+		o.tokenCounter = counter
+	}
+}
+
+func applyListMessagesOptions(opts ...ListMessagesOption) listMessagesOptions {
+	var o listMessagesOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -2,11 +2,14 @@ package alloydb_test
 
 import (
 	"context"
+	"fmt"
 	"github.com/tmc/langchaingo/util/alloydbutil"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/memory/alloydb"
 )
@@ -67,7 +70,17 @@ func setEngine(ctx context.Context, t *testing.T) (alloydbutil.PostgresEngine, e
 		alloydbutil.WithAlloyDBInstance(projectID, region, cluster, instance),
 	)
 
-	return *pgEngine, err
+	return pgEngine, err
+}
+
+func TestCallerProvidedIDGenerator(t *testing.T) {
+	t.Parallel()
+	engine := alloydbutil.PostgresEngine{Pool: new(pgxpool.Pool)}
+	_, err := alloydb.NewChatMessageHistory(context.Background(), engine, "items", "session",
+		alloydb.WithIDGenerator(alloydbutil.CallerProvided{}))
+	if err == nil || !strings.Contains(err.Error(), "CallerProvided") {
+		t.Errorf("expected the CallerProvided generator to be rejected, got %v", err)
+	}
 }
 
 func TestValidateTable(t *testing.T) {
@@ -139,3 +152,537 @@ func TestValidateTable(t *testing.T) {
 		})
 	}
 }
+
+// summarizer is an llms.Model returning a fixed summary.
+type summarizer struct{}
+
+func (summarizer) GenerateContent(_ context.Context, _ []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) {
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "summary"}}}, nil
+}
+
+func (summarizer) Call(_ context.Context, _ string, _ ...llms.CallOption) (string, error) {
+	return "summary", nil
+}
+
+func TestCompact(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := setEngine(ctx, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if err := engine.InitChatHistoryTable(ctx, "compacted_items"); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.InitChatHistoryArchiveTable(ctx, "compacted_items_archive"); err != nil {
+		t.Fatal(err)
+	}
+	chatMsgHistory, err := alloydb.NewChatMessageHistory(ctx, engine, "compacted_items", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chatMsgHistory.Clear(ctx) //nolint:errcheck
+	for i := 0; i < 5; i++ {
+		if err := chatMsgHistory.AddUserMessage(ctx, "user message"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	compacted, err := chatMsgHistory.Compact(ctx, summarizer{}, alloydb.WithKeepRecent(2), alloydb.WithMinMessages(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compacted != 3 {
+		t.Fatalf("expected 3 compacted messages, got %d", compacted)
+	}
+	messages, err := chatMsgHistory.Messages(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 || messages[0].GetType() != llms.ChatMessageTypeSystem || messages[0].GetContent() != "summary" {
+		t.Errorf("expected the summary followed by 2 messages, got %v", messages)
+	}
+}
+
+func TestSummaryBuffer(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := setEngine(ctx, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if err := engine.InitChatHistoryTable(ctx, "summarized_items"); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.InitChatHistoryArchiveTable(ctx, "summarized_items_archive"); err != nil {
+		t.Fatal(err)
+	}
+	chatMsgHistory, err := alloydb.NewChatMessageHistory(ctx, engine, "summarized_items", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chatMsgHistory.Clear(ctx) //nolint:errcheck
+	buffer := alloydb.NewSummaryBuffer(summarizer{}, &chatMsgHistory)
+	buffer.CompactionOptions = []alloydb.CompactionOption{alloydb.WithKeepRecent(2), alloydb.WithMinMessages(2)}
+
+	for i := 0; i < 3; i++ {
+		err := buffer.SaveContext(ctx, map[string]any{"input": "question"}, map[string]any{"output": "answer"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	vars, err := buffer.LoadMemoryVariables(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "system: summary\nHuman: question\nAI: answer"; vars["history"] != want {
+		t.Errorf("expected %q, got %q", want, vars["history"])
+	}
+}
+
+func TestListMessages(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := setEngine(ctx, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if err := engine.InitChatHistoryTable(ctx, "listed_items"); err != nil {
+		t.Fatal(err)
+	}
+	chatMsgHistory, err := alloydb.NewChatMessageHistory(ctx, engine, "listed_items", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chatMsgHistory.Clear(ctx) //nolint:errcheck
+	err = chatMsgHistory.AddMessages(ctx, []llms.ChatMessage{
+		llms.SystemChatMessage{Content: "system message"},
+		llms.HumanChatMessage{Content: "user message"},
+		llms.AIChatMessage{Content: "AI message"},
+		llms.ToolChatMessage{ID: "call", Content: "tool message"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := chatMsgHistory.ListMessages(ctx, alloydb.WithLimit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].Message.GetContent() != "AI message" || page[1].Message != (llms.ToolChatMessage{ID: "call", Content: "tool message"}) {
+		t.Fatalf("expected the 2 most recent messages, got %v", page)
+	}
+	page, err = chatMsgHistory.ListMessages(ctx, alloydb.WithLimit(2), alloydb.WithBeforeID(page[0].ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].Message.GetType() != llms.ChatMessageTypeSystem || page[1].Message.GetType() != llms.ChatMessageTypeHuman {
+		t.Errorf("expected the 2 oldest messages, got %v", page)
+	}
+}
+
+func TestSearchMessages(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := setEngine(ctx, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if err := engine.InitChatHistoryTable(ctx, "searched_items"); err != nil {
+		t.Fatal(err)
+	}
+	chatMsgHistory, err := alloydb.NewChatMessageHistory(ctx, engine, "searched_items", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chatMsgHistory.Clear(ctx) //nolint:errcheck
+	start := time.Now().Add(-time.Minute)
+	if err := chatMsgHistory.AddUserMessage(ctx, "without metadata"); err != nil {
+		t.Fatal(err)
+	}
+	err = chatMsgHistory.AddMessageWithMetadata(ctx, llms.HumanChatMessage{Content: "question"},
+		map[string]any{"user_id": "u1", "trace_id": "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = chatMsgHistory.AddMessageWithMetadata(ctx, llms.AIChatMessage{Content: "answer"},
+		map[string]any{"user_id": "u1", "model": "gemini"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := chatMsgHistory.SearchMessages(ctx, alloydb.MessageFilter{
+		Metadata: map[string]any{"user_id": "u1"},
+		After:    start,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0].Message.GetContent() != "question" || found[1].Metadata["model"] != "gemini" {
+		t.Fatalf("expected the messages of the user, got %v", found)
+	}
+	if found[0].CreatedAt.Before(start) {
+		t.Errorf("expected the creation time to be set, got %v", found[0].CreatedAt)
+	}
+	found, err = chatMsgHistory.SearchMessages(ctx, alloydb.MessageFilter{Types: []llms.ChatMessageType{llms.ChatMessageTypeAI}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Message.GetContent() != "answer" {
+		t.Errorf("expected the AI message, got %v", found)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := setEngine(ctx, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if err := engine.InitChatHistoryTable(ctx, "fts_items", alloydbutil.WithFullTextSearch("english")); err != nil {
+		t.Fatal(err)
+	}
+	first, err := alloydb.NewChatMessageHistory(ctx, engine, "fts_items", "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Clear(ctx) //nolint:errcheck
+	second, err := alloydb.NewChatMessageHistory(ctx, engine, "fts_items", "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Clear(ctx) //nolint:errcheck
+	if err := first.AddUserMessage(ctx, "How do I size the connection pool?"); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.AddAIMessage(ctx, "Start with the number of cores."); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.AddUserMessage(ctx, "Our pools keep running out of connections."); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := first.Search(ctx, "connection pools")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].SessionID != "first" || results[0].Message.GetType() != llms.ChatMessageTypeHuman {
+		t.Fatalf("expected the message of the session, got %v", results)
+	}
+	results, err = first.Search(ctx, "connection pools", alloydb.WithAllSessions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected the messages of both sessions, got %v", results)
+	}
+}
+
+func TestSessionLock(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := setEngine(ctx, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if err := engine.InitChatHistoryTable(ctx, "locked_items"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replicas appending batches of messages to the same session.
+	const replicas, batches, batchSize = 4, 5, 10
+	errs := make(chan error, replicas)
+	for r := 0; r < replicas; r++ {
+		go func() {
+			chatMsgHistory, err := alloydb.NewChatMessageHistory(ctx, engine, "locked_items", "session", alloydb.WithSessionLock())
+			if err != nil {
+				errs <- err
+				return
+			}
+			for b := 0; b < batches; b++ {
+				messages := make([]llms.ChatMessage, batchSize)
+				for i := range messages {
+					messages[i] = llms.HumanChatMessage{Content: fmt.Sprintf("%d-%d", r, b)}
+				}
+				if err := chatMsgHistory.AddMessages(ctx, messages); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for r := 0; r < replicas; r++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chatMsgHistory, err := alloydb.NewChatMessageHistory(ctx, engine, "locked_items", "session", alloydb.WithSessionLock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chatMsgHistory.Clear(ctx) //nolint:errcheck
+	messages, err := chatMsgHistory.Messages(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != replicas*batches*batchSize {
+		t.Fatalf("expected %d messages, got %d", replicas*batches*batchSize, len(messages))
+	}
+	for i := 0; i < len(messages); i += batchSize {
+		for _, message := range messages[i : i+batchSize] {
+			if message.GetContent() != messages[i].GetContent() {
+				t.Fatalf("expected the messages of batch %s to be contiguous, got %s", messages[i].GetContent(), message.GetContent())
+			}
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := setEngine(ctx, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if err := engine.InitChatHistoryTable(ctx, "pruned_items"); err != nil {
+		t.Fatal(err)
+	}
+	chatMsgHistory, err := alloydb.NewChatMessageHistory(ctx, engine, "pruned_items", "session",
+		alloydb.WithRetentionPolicy(alloydb.RetentionPolicy{MaxMessages: 3, EnforceOnWrite: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chatMsgHistory.Clear(ctx) //nolint:errcheck
+	for _, content := range []string{"1", "2", "3", "4", "5"} {
+		if err := chatMsgHistory.AddUserMessage(ctx, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	messages, err := chatMsgHistory.Messages(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 || messages[0].GetContent() != "3" {
+		t.Fatalf("expected the 3 most recent messages, got %v", messages)
+	}
+
+	aged, err := alloydb.NewChatMessageHistory(ctx, engine, "pruned_items", "session",
+		alloydb.WithRetentionPolicy(alloydb.RetentionPolicy{MaxAge: time.Nanosecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pruned, err := aged.Prune(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 3 {
+		t.Errorf("expected the 3 messages to expire, got %d", pruned)
+	}
+}
+
+func TestExportImportSession(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := setEngine(ctx, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if err := engine.InitChatHistoryTable(ctx, "exported_items"); err != nil {
+		t.Fatal(err)
+	}
+	source, err := alloydb.NewChatMessageHistory(ctx, engine, "exported_items", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Clear(ctx) //nolint:errcheck
+	if err := source.AddUserMessage(ctx, "question"); err != nil {
+		t.Fatal(err)
+	}
+	err = source.AddMessageWithMetadata(ctx, llms.AIChatMessage{Content: "answer"}, map[string]any{"model": "gemini"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	exported, err := source.ExportSession(ctx, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported != 2 || strings.Count(buf.String(), "\n") != 2 {
+		t.Fatalf("expected 2 lines, got %d: %s", exported, buf.String())
+	}
+
+	target, err := alloydb.NewChatMessageHistory(ctx, engine, "exported_items", "target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Clear(ctx) //nolint:errcheck
+	imported, err := target.ImportSession(ctx, strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 2 {
+		t.Errorf("expected 2 imported messages, got %d", imported)
+	}
+	messages, err := target.SearchMessages(ctx, alloydb.MessageFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 || messages[0].Message.GetContent() != "question" || messages[1].Metadata["model"] != "gemini" {
+		t.Errorf("expected the messages of the source session, got %v", messages)
+	}
+
+	if _, err := target.ImportSession(ctx, strings.NewReader(`{"type":"ai","data":"ok"}`+"\n"+`not json`)); err == nil {
+		t.Error("expected an invalid line error")
+	}
+	if messages, _ := target.Messages(ctx); len(messages) != 2 {
+		t.Errorf("expected a failed import to add no messages, got %d messages", len(messages))
+	}
+}
+
+func TestSetMessages(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := setEngine(ctx, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if err := engine.InitChatHistoryTable(ctx, "set_items"); err != nil {
+		t.Fatal(err)
+	}
+	chatMsgHistory, err := alloydb.NewChatMessageHistory(ctx, engine, "set_items", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chatMsgHistory.Clear(ctx) //nolint:errcheck
+	if err := chatMsgHistory.AddUserMessage(ctx, "previous message"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = chatMsgHistory.SetMessages(ctx, []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "user message"},
+		llms.AIChatMessage{Content: "AI message"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages, err := chatMsgHistory.Messages(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 || messages[0].GetContent() != "user message" || messages[1].GetContent() != "AI message" {
+		t.Errorf("expected the set messages, got %v", messages)
+	}
+}
+
+func TestSessionManager(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := setEngine(ctx, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if err := engine.InitChatHistoryTable(ctx, "session_items"); err != nil {
+		t.Fatal(err)
+	}
+	for _, sessionID := range []string{"first", "second"} {
+		chatMsgHistory, err := alloydb.NewChatMessageHistory(ctx, engine, "session_items", sessionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := chatMsgHistory.AddUserMessage(ctx, "user message"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sessions, err := alloydb.NewSessionManager(engine, "session_items")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := sessions.ListSessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != "second" || list[0].MessageCount != 1 {
+		t.Errorf("expected the most recent session first, got %v", list)
+	}
+	deleted, err := sessions.DeleteSession(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected 1 deleted message, got %d", deleted)
+	}
+	if exists, err := sessions.SessionExists(ctx, "first"); err != nil || exists {
+		t.Errorf("expected the deleted session not to exist, got %v, %v", exists, err)
+	}
+	if _, err := sessions.DeleteSession(ctx, "second"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListMessagesWithTokenBudget(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := setEngine(ctx, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	if err := engine.InitChatHistoryTable(ctx, "budget_items"); err != nil {
+		t.Fatal(err)
+	}
+	chatMsgHistory, err := alloydb.NewChatMessageHistory(ctx, engine, "budget_items", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chatMsgHistory.Clear(ctx) //nolint:errcheck
+	err = chatMsgHistory.AddMessages(ctx, []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "a long user message"},
+		llms.AIChatMessage{Content: "AI message"},
+		llms.HumanChatMessage{Content: "user message"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	words := func(text string) int { return len(strings.Fields(text)) }
+	messages, err := chatMsgHistory.ListMessages(ctx, alloydb.WithTokenBudget(5, words))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 || messages[0].Message.GetContent() != "AI message" {
+		t.Errorf("expected the 2 most recent messages, got %v", messages)
+	}
+	messages, err = chatMsgHistory.ListMessages(ctx, alloydb.WithTokenBudget(5, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].Message.GetContent() != "user message" {
+		t.Errorf("expected the most recent message, got %v", messages)
+	}
+}
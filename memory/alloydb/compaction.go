@@ -0,0 +1,195 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+const (
+	defaultKeepRecent  = 20
+	defaultMinMessages = 50
+	archiveTableSuffix = "_archive"
+
+	defaultSummaryPrompt = `Progressively summarize the conversation below, including the summary of its earlier part if any, keeping the facts, preferences and decisions worth remembering in later conversations.`
+)
+
+// ErrHistoryChanged is returned by Compact when the messages to compact were
+// changed by another client while being summarized. The compaction can be
+// retried.
+var ErrHistoryChanged = errors.New("chat history changed during compaction")
+
+// CompactionOption is a function for compacting a chat message history with
+// other than the default values.
+type CompactionOption func(o *compactionOptions)
+
+type compactionOptions struct {
+	archiveTable string
+	keepRecent   int
+	minMessages  int
+	prompt       string
+}
+
+// WithArchiveTable sets the table the compacted messages are moved to. It
+// defaults to the chat history table name followed by "_archive", and must be
+// created with alloydbutil.PostgresEngine.InitChatHistoryArchiveTable.
+func WithArchiveTable(tableName string) CompactionOption {
+	return func(o *compactionOptions) {
+		o.archiveTable = tableName
+	}
+}
+
+// WithKeepRecent sets the number of most recent messages left as they are.
+// It defaults to 20.
+func WithKeepRecent(n int) CompactionOption {
+	return func(o *compactionOptions) {
+		o.keepRecent = n
+	}
+}
+
+// WithMinMessages sets the minimum number of older messages worth replacing
+// by a summary. It defaults to 50.
+func WithMinMessages(n int) CompactionOption {
+	return func(o *compactionOptions) {
+		o.minMessages = n
+	}
+}
+
+// WithSummaryPrompt sets the instructions the messages to summarize are
+// appended to.
+func WithSummaryPrompt(prompt string) CompactionOption {
+	return func(o *compactionOptions) {
+		o.prompt = prompt
+	}
+}
+
+func applyCompactionOptions(tableName string, opts ...CompactionOption) compactionOptions {
+	o := compactionOptions{
+		archiveTable: tableName + archiveTableSuffix,
+		keepRecent:   defaultKeepRecent,
+		minMessages:  defaultMinMessages,
+		prompt:       defaultSummaryPrompt,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Compact replaces the messages of the session older than the most recent
+// ones by a system message summarizing them, generated by llm, and returns
+// the number of replaced messages. The summary takes the place of the last
+// replaced message, and the replaced messages are moved to the archive table.
+// A previous summary is part of the messages summarized by the next
+// compaction, so that a long-lived session keeps a single summary followed by
+// its recent messages.
+func (c *ChatMessageHistory) Compact(ctx context.Context, llm llms.Model, opts ...CompactionOption) (int, error) {
+	if err := c.checkWritable("compact session"); err != nil {
+		return 0, err
+	}
+	o := applyCompactionOptions(c.tableName, opts...)
+
+	columns := "id, data, type"
+	if c.hasCompressedData {
+		columns += ", compressed_data"
+	}
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE session_id = $1 ORDER BY id`, columns, c.table())
+	rows, err := c.engine.Pool.Query(ctx, query, c.sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve messages: %w", err)
+	}
+	var (
+		ids               []int
+		lines             []string
+		id                int
+		data, messageType string
+		compressed        []byte
+	)
+	dest := []any{&id, &data, &messageType}
+	if c.hasCompressedData {
+		dest = append(dest, &compressed)
+	}
+	_, err = pgx.ForEachRow(rows, dest, func() error {
+		message, err := c.readStoredMessage(id, llms.ChatMessageType(messageType), []byte(data), compressed)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		lines = append(lines, fmt.Sprintf("%s: %s", messageType, message.Message.GetContent()))
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read messages: %w", err)
+	}
+
+	n := len(ids) - o.keepRecent
+	if n <= 0 || n < o.minMessages {
+		return 0, nil
+	}
+	summary, err := llms.GenerateFromSinglePrompt(ctx, llm, o.prompt+"\n\n"+strings.Join(lines[:n], "\n"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to summarize messages: %w", err)
+	}
+	summaryData, err := marshalMessage(llms.SystemChatMessage{Content: summary})
+	if err != nil {
+		return 0, err
+	}
+	summaryArgs, err := c.insertMessageArgs(summaryData, llms.ChatMessageTypeSystem)
+	if err != nil {
+		return 0, err
+	}
+	lastID := ids[n-1]
+
+	err = c.engine.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := c.lockSession(ctx, tx); err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, c.archiveMessagesQuery(o.archiveTable), c.sessionID, lastID)
+		if err != nil {
+			return fmt.Errorf("failed to archive messages: %w", err)
+		}
+		if tag.RowsAffected() != int64(n) {
+			return ErrHistoryChanged
+		}
+		deleteStmt := fmt.Sprintf(`DELETE FROM %s WHERE session_id = $1 AND id <= $2`, c.table())
+		if _, err := tx.Exec(ctx, deleteStmt, c.sessionID, lastID); err != nil {
+			return fmt.Errorf("failed to delete archived messages: %w", err)
+		}
+		if _, err := tx.Exec(ctx, c.insertSummaryQuery(), append([]any{lastID}, summaryArgs...)...); err != nil {
+			return fmt.Errorf("failed to add summary: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// archiveMessagesQuery returns the statement copying the messages of a
+// session up to an id to archiveTable.
+func (c *ChatMessageHistory) archiveMessagesQuery(archiveTable string) string {
+	columns := "id, session_id, data, type"
+	if c.idGenerator != nil {
+		columns = "id, message_id, session_id, data, type"
+	}
+	if c.hasMetadata {
+		columns += ", created_at, metadata"
+	}
+	if c.hasCompressedData {
+		columns += ", compressed_data"
+	}
+	return fmt.Sprintf(`INSERT INTO %s (%s, summary_id) SELECT %s, $2 FROM %s WHERE session_id = $1 AND id <= $2`,
+		alloydbutil.QuoteIdentifier(c.schemaName, archiveTable), columns, columns, c.table())
+}
+
+// insertSummaryQuery returns the statement inserting a summary with the id
+// of the last message it replaces.
+func (c *ChatMessageHistory) insertSummaryQuery() string {
+	return c.insertQuery(append([]string{"id"}, c.messageColumns()...))
+}
@@ -0,0 +1,108 @@
+package alloydb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+// compressedMessageVersion is the version of the data column format of the
+// compressed messages: a JSON object of the version and the name of the
+// compression, the compressed data column of the message being stored in
+// the compressed_data column.
+const compressedMessageVersion = 3
+
+// ErrNoCompressedDataColumn is returned when compressing the messages of a
+// table created without the compressed_data column.
+var ErrNoCompressedDataColumn = errors.New("chat history table has no compressed_data column")
+
+// errCompressedMessage is returned by newStoredMessage for compressed
+// messages, which must be decompressed first by uncompressed.
+var errCompressedMessage = errors.New("message is compressed")
+
+// compressedMessage is the data column of a compressed message.
+type compressedMessage struct {
+	Version     int    `json:"version"`
+	Compression string `json:"compression"`
+}
+
+// WithCompression compresses the data of the messages of at least minSize
+// bytes with compressor, e.g. verbose agent traces, and decompresses them
+// when they are read. The compressed messages are stored in the
+// compressed_data column of the table. The histories reading the messages
+// must have the same compressor. The compressed messages are not matched by
+// Search.
+func WithCompression(compressor alloydbutil.Compressor, minSize int) ChatMessageHistoryStoresOption {
+	return func(c *ChatMessageHistory) {
+		c.compressor = compressor
+		c.compressionMinSize = minSize
+	}
+}
+
+// compress returns the data and compressed_data columns of a message,
+// compressed if it's large enough.
+func (c *ChatMessageHistory) compress(data []byte) ([]byte, []byte, error) {
+	if c.compressor == nil || len(data) < c.compressionMinSize {
+		return data, nil, nil
+	}
+	compressed, err := c.compressor.Compress(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compress message: %w", err)
+	}
+	data, err = json.Marshal(compressedMessage{
+		Version:     compressedMessageVersion,
+		Compression: c.compressor.Name(),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to serialize compressed message to JSON: %w", err)
+	}
+	return data, compressed, nil
+}
+
+// uncompressed returns the data column of a message, decompressed from its
+// compressed_data column if it's compressed.
+func (c *ChatMessageHistory) uncompressed(data, compressed []byte) ([]byte, error) {
+	if len(data) == 0 || data[0] != '{' {
+		return data, nil
+	}
+	var versioned struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &versioned); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+	if versioned.Version != compressedMessageVersion {
+		return data, nil
+	}
+	var v compressedMessage
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+	if c.compressor == nil || c.compressor.Name() != v.Compression {
+		return nil, fmt.Errorf("message compressed with %s: no such compressor", v.Compression)
+	}
+	if compressed == nil {
+		return nil, errors.New("compressed message without compressed data")
+	}
+	data, err := c.compressor.Decompress(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress message: %w", err)
+	}
+	return data, nil
+}
+
+// readStoredMessage deserializes the data column of the message id of type
+// messageType, decompressing its compressed_data column if needed.
+func (c *ChatMessageHistory) readStoredMessage(id int,
+	messageType llms.ChatMessageType,
+	data, compressed []byte,
+) (StoredMessage, error) {
+	data, err := c.uncompressed(data, compressed)
+	if err != nil {
+		return StoredMessage{ID: id}, err
+	}
+	return newStoredMessage(id, messageType, data)
+}
@@ -0,0 +1,298 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+const (
+	defaultEntitiesKey          = "entities"
+	defaultEntityRecentMessages = 6
+	noEntities                  = "NONE"
+
+	defaultEntityExtractionPrompt = `You are reading the transcript of a conversation between an AI and a human. Extract all the proper nouns, such as the names of people, places, organizations and products, from the last line of the conversation.
+
+The conversation history is provided for coreferences only, e.g. "What do you know about him" where "him" is defined in a previous line: ignore the nouns mentioned there that are not referenced in the last line.
+
+Return the output as a single comma-separated list, or NONE if there is nothing of note to return, e.g. when the human is just issuing a greeting.`
+
+	defaultEntitySummaryPrompt = `You are helping a human keep track of facts about the people, places and concepts relevant to them. Update the summary of the entity below with the facts about it relayed in the last line of the conversation. Write a single sentence when there is no existing summary, and return the existing summary unchanged when there is no new information worth remembering long-term about the entity. Only return the summary.`
+)
+
+// EntityMemory is a memory of the conversation, stored in a chat history
+// table, along with summaries of the entities it mentions, stored in a table
+// created with alloydbutil.PostgresEngine.InitEntityMemoryTable. Every turn,
+// the entities of the input are extracted by LLM and their summaries are
+// loaded into the memory variables, and then updated by LLM with the facts
+// of the turn once it is saved.
+type EntityMemory struct {
+	memory.ConversationBuffer
+	LLM llms.Model
+	// EntitiesKey is the key of the summaries of the entities in the memory
+	// variables. It defaults to "entities".
+	EntitiesKey string
+	// RecentMessages is the number of the most recent messages given to the
+	// LLM along with the last turn, to resolve coreferences. It defaults
+	// to 6.
+	RecentMessages int
+	// ExtractionPrompt and SummaryPrompt are the instructions for extracting
+	// the entities of the input and updating their summaries.
+	ExtractionPrompt string
+	SummaryPrompt    string
+
+	history   *ChatMessageHistory
+	tableName string
+	// entities are the entities of the input of the last
+	// LoadMemoryVariables, whose summaries SaveContext updates.
+	entities []string
+}
+
+// Statically assert that EntityMemory implement the memory interface.
+var _ schema.Memory = &EntityMemory{}
+
+// NewEntityMemory creates an EntityMemory storing the conversation in
+// history and the summaries of its entities in tableName, in the schema of
+// history, extracted and summarized by llm. The chat history option of
+// options is ignored.
+func NewEntityMemory(llm llms.Model,
+	history *ChatMessageHistory,
+	tableName string,
+	options ...memory.ConversationBufferOption,
+) (*EntityMemory, error) {
+	if llm == nil {
+		return nil, errors.New("missing entity memory LLM")
+	}
+	if tableName == "" {
+		return nil, errors.New("missing entity memory table name")
+	}
+	options = append(options, memory.WithChatHistory(history))
+	return &EntityMemory{
+		ConversationBuffer: *memory.NewConversationBuffer(options...),
+		LLM:                llm,
+		EntitiesKey:        defaultEntitiesKey,
+		RecentMessages:     defaultEntityRecentMessages,
+		ExtractionPrompt:   defaultEntityExtractionPrompt,
+		SummaryPrompt:      defaultEntitySummaryPrompt,
+		history:            history,
+		tableName:          tableName,
+	}, nil
+}
+
+// MemoryVariables returns the memory key of the conversation and the key of
+// table returns the quoted, schema qualified name of the table of the
+// entities.
+func (m *EntityMemory) table() string {
+	return alloydbutil.QuoteIdentifier(m.history.schemaName, m.tableName)
+}
+
+// the summaries of the entities.
+func (m *EntityMemory) MemoryVariables(ctx context.Context) []string {
+	return append(m.ConversationBuffer.MemoryVariables(ctx), m.EntitiesKey)
+}
+
+// LoadMemoryVariables returns the conversation, as ConversationBuffer does,
+// and the summaries of the entities of the input as "entity: summary" lines.
+func (m *EntityMemory) LoadMemoryVariables(ctx context.Context, inputs map[string]any) (map[string]any, error) {
+	variables, err := m.ConversationBuffer.LoadMemoryVariables(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	input, err := memory.GetInputValue(inputs, m.InputKey)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := m.recentMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.entities, err = m.extractEntities(ctx, recent, input)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := m.summaries(ctx, m.entities)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0, len(summaries))
+	for _, entity := range m.entities {
+		if summary, ok := summaries[entity]; ok {
+			lines = append(lines, fmt.Sprintf("%s: %s", entity, summary))
+		}
+	}
+	variables[m.EntitiesKey] = strings.Join(lines, "\n")
+	return variables, nil
+}
+
+// SaveContext saves the turn as ConversationBuffer does, then updates the
+// summaries of the entities of its input. The entities are the ones
+// extracted by the previous LoadMemoryVariables, or extracted from the input
+// when it wasn't called.
+func (m *EntityMemory) SaveContext(ctx context.Context, inputs map[string]any, outputs map[string]any) error {
+	input, err := memory.GetInputValue(inputs, m.InputKey)
+	if err != nil {
+		return err
+	}
+	output, err := memory.GetInputValue(outputs, m.OutputKey)
+	if err != nil {
+		return err
+	}
+	recent, err := m.recentMessages(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.ConversationBuffer.SaveContext(ctx, inputs, outputs); err != nil {
+		return err
+	}
+
+	entities := m.entities
+	m.entities = nil
+	if entities == nil {
+		if entities, err = m.extractEntities(ctx, recent, input); err != nil {
+			return err
+		}
+	}
+	if len(entities) == 0 {
+		return nil
+	}
+	summaries, err := m.summaries(ctx, entities)
+	if err != nil {
+		return err
+	}
+	lastTurn := fmt.Sprintf("%s: %s\n%s: %s", m.HumanPrefix, input, m.AIPrefix, output)
+	updated := make(map[string]string, len(entities))
+	for _, entity := range entities {
+		prompt := fmt.Sprintf("%s\n\nConversation history:\n%s\n\nEntity: %s\n\nExisting summary:\n%s\n\nLast line of the conversation:\n%s",
+			m.SummaryPrompt, recent, entity, summaries[entity], lastTurn)
+		summary, err := llms.GenerateFromSinglePrompt(ctx, m.LLM, prompt)
+		if err != nil {
+			return fmt.Errorf("failed to summarize entity %s: %w", entity, err)
+		}
+		updated[entity] = strings.TrimSpace(summary)
+	}
+	return m.saveSummaries(ctx, updated)
+}
+
+// Clear deletes the messages of the session and the summaries of its
+// entities.
+func (m *EntityMemory) Clear(ctx context.Context) error {
+	if err := m.ConversationBuffer.Clear(ctx); err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`DELETE FROM %s WHERE session_id = $1`, m.table())
+	if _, err := m.history.engine.Pool.Exec(ctx, query, m.history.sessionID); err != nil {
+		return fmt.Errorf("failed to delete entities: %w", err)
+	}
+	m.entities = nil
+	return nil
+}
+
+// Entities returns the summaries of all the entities of the session, by
+// entity.
+func (m *EntityMemory) Entities(ctx context.Context) (map[string]string, error) {
+	query := fmt.Sprintf(`SELECT entity, summary FROM %s WHERE session_id = $1`, m.table())
+	return m.querySummaries(ctx, query, m.history.sessionID)
+}
+
+// summaries returns the existing summaries of entities.
+func (m *EntityMemory) summaries(ctx context.Context, entities []string) (map[string]string, error) {
+	if len(entities) == 0 {
+		return map[string]string{}, nil
+	}
+	query := fmt.Sprintf(`SELECT entity, summary FROM %s WHERE session_id = $1 AND entity = ANY($2)`,
+		m.table())
+	return m.querySummaries(ctx, query, m.history.sessionID, entities)
+}
+
+// querySummaries runs query, which selects entities and their summaries, on
+// the primary instance, as the summaries are updated every turn.
+func (m *EntityMemory) querySummaries(ctx context.Context, query string, args ...any) (map[string]string, error) {
+	rows, err := m.history.engine.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entities: %w", err)
+	}
+	summaries := make(map[string]string)
+	var entity, summary string
+	_, err = pgx.ForEachRow(rows, []any{&entity, &summary}, func() error {
+		summaries[entity] = summary
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entities: %w", err)
+	}
+	return summaries, nil
+}
+
+// saveSummaries inserts or replaces the summaries of entities in a single
+// transaction.
+func (m *EntityMemory) saveSummaries(ctx context.Context, summaries map[string]string) error {
+	query := fmt.Sprintf(`INSERT INTO %s (session_id, entity, summary) VALUES ($1, $2, $3)
+ON CONFLICT (session_id, entity) DO UPDATE SET summary = EXCLUDED.summary, updated_at = NOW()`,
+		m.table())
+	b := &pgx.Batch{}
+	for entity, summary := range summaries {
+		b.Queue(query, m.history.sessionID, entity, summary)
+	}
+	err := m.history.engine.WithTx(ctx, func(tx pgx.Tx) error {
+		return tx.SendBatch(ctx, b).Close()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save entities: %w", err)
+	}
+	return nil
+}
+
+// recentMessages returns the most recent messages of the session as lines
+// of text.
+func (m *EntityMemory) recentMessages(ctx context.Context) (string, error) {
+	if m.RecentMessages <= 0 {
+		return "", nil
+	}
+	stored, err := m.history.ListMessages(ctx, WithLimit(m.RecentMessages))
+	if err != nil {
+		return "", err
+	}
+	messages := make([]llms.ChatMessage, len(stored))
+	for i, message := range stored {
+		messages[i] = message.Message
+	}
+	return llms.GetBufferString(messages, m.HumanPrefix, m.AIPrefix)
+}
+
+// extractEntities returns the entities of input extracted by the LLM.
+func (m *EntityMemory) extractEntities(ctx context.Context, recent, input string) ([]string, error) {
+	prompt := fmt.Sprintf("%s\n\nConversation history:\n%s\n\nLast line of the conversation:\n%s: %s",
+		m.ExtractionPrompt, recent, m.HumanPrefix, input)
+	output, err := llms.GenerateFromSinglePrompt(ctx, m.LLM, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract entities: %w", err)
+	}
+	return parseEntities(output), nil
+}
+
+// parseEntities parses the comma-separated list of entities returned by the
+// LLM, without duplicates.
+func parseEntities(output string) []string {
+	output = strings.TrimSpace(output)
+	entities := []string{}
+	if strings.EqualFold(output, noEntities) {
+		return entities
+	}
+	seen := make(map[string]bool)
+	for _, entity := range strings.Split(output, ",") {
+		entity = strings.Trim(strings.TrimSpace(entity), `."'`)
+		if entity == "" || strings.EqualFold(entity, noEntities) || seen[entity] {
+			continue
+		}
+		seen[entity] = true
+		entities = append(entities, entity)
+	}
+	return entities
+}
@@ -0,0 +1,185 @@
+package alloydb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// importBatchSize is the number of messages inserted per round trip by
+// ImportSession.
+const importBatchSize = 500
+
+// exportedMessage is a line of a session exported by ExportSession.
+type exportedMessage struct {
+	Type llms.ChatMessageType `json:"type"`
+	// Content is the text of the message, for the consumers of the export
+	// that don't deserialize Data, e.g. to build fine-tuning datasets.
+	Content string `json:"content"`
+	// Data is the data column of the message, as stored but uncompressed.
+	Data      json.RawMessage `json:"data"`
+	CreatedAt *time.Time      `json:"created_at,omitempty"`
+	Metadata  map[string]any  `json:"metadata,omitempty"`
+}
+
+// ExportSession writes the messages of the session to w as JSON Lines, in
+// insertion order, and returns their number. Each line is a JSON object of
+// the type, text content and stored data of a message, along with its
+// creation time and metadata when the table has them. The messages are
+// streamed from the primary instance rather than read at once.
+func (c *ChatMessageHistory) ExportSession(ctx context.Context, w io.Writer) (int, error) {
+	columns := "data, type"
+	if c.hasMetadata {
+		columns += ", created_at, metadata"
+	}
+	if c.hasCompressedData {
+		columns += ", compressed_data"
+	}
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE session_id = $1 ORDER BY id`,
+		columns, c.table())
+	var (
+		line              exportedMessage
+		data, messageType string
+		createdAt         time.Time
+		compressed        []byte
+	)
+	dest := []any{&data, &messageType}
+	if c.hasMetadata {
+		dest = append(dest, &createdAt, &line.Metadata)
+	}
+	if c.hasCompressedData {
+		dest = append(dest, &compressed)
+	}
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	n := 0
+	err := c.query(ctx, c.engine.Pool, func(ctx context.Context, db querier) error {
+		rows, err := db.Query(ctx, query, c.sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve messages: %w", err)
+		}
+		_, err = pgx.ForEachRow(rows, dest, func() error {
+			line.Type = llms.ChatMessageType(messageType)
+			uncompressed, err := c.uncompressed([]byte(data), compressed)
+			if err != nil {
+				return err
+			}
+			message, err := newStoredMessage(0, line.Type, uncompressed)
+			if err != nil {
+				return err
+			}
+			line.Content, line.Data = message.Message.GetContent(), json.RawMessage(uncompressed)
+			if c.hasMetadata {
+				line.CreatedAt = &createdAt
+			}
+			if err := encoder.Encode(line); err != nil {
+				return fmt.Errorf("failed to write message: %w", err)
+			}
+			line.Metadata = nil
+			n++
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return n, fmt.Errorf("failed to export session %s: %w", c.sessionID, err)
+	}
+	if err := bw.Flush(); err != nil {
+		return n, fmt.Errorf("failed to export session %s: %w", c.sessionID, err)
+	}
+	return n, nil
+}
+
+// ImportSession adds the messages of a session exported by ExportSession,
+// read from r, to the session of the ChatMessageHistory, e.g. to restore a
+// backup or to migrate a session between environments, and returns their
+// number. Either all or none of the messages are added. The creation time
+// and metadata of the messages are kept when the table has the columns.
+func (c *ChatMessageHistory) ImportSession(ctx context.Context, r io.Reader) (int, error) {
+	if err := c.checkWritable("import session"); err != nil {
+		return 0, err
+	}
+	query := c.importMessageQuery()
+	decoder := json.NewDecoder(r)
+	n := 0
+	err := c.engine.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := c.lockSession(ctx, tx); err != nil {
+			return err
+		}
+		b := &pgx.Batch{}
+		for {
+			var line exportedMessage
+			err := decoder.Decode(&line)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read message %d: %w", n+1, err)
+			}
+			if _, err := newStoredMessage(0, line.Type, line.Data); err != nil {
+				return fmt.Errorf("invalid message %d: %w", n+1, err)
+			}
+			args, err := c.importMessageArgs(line)
+			if err != nil {
+				return err
+			}
+			b.Queue(query, args...)
+			n++
+			if b.Len() == importBatchSize {
+				if err := tx.SendBatch(ctx, b).Close(); err != nil {
+					return err
+				}
+				b = &pgx.Batch{}
+			}
+		}
+		if b.Len() > 0 {
+			if err := tx.SendBatch(ctx, b).Close(); err != nil {
+				return err
+			}
+		}
+		if !c.retention.EnforceOnWrite {
+			return nil
+		}
+		_, err := c.prune(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to import session %s: %w", c.sessionID, err)
+	}
+	return n, nil
+}
+
+// importMessageQuery returns the statement inserting an imported message.
+func (c *ChatMessageHistory) importMessageQuery() string {
+	if !c.hasMetadata {
+		return c.insertMessageQuery()
+	}
+	return c.insertQuery(append(c.messageColumns(), "created_at", "metadata"))
+}
+
+// importMessageArgs returns the arguments of importMessageQuery for line.
+func (c *ChatMessageHistory) importMessageArgs(line exportedMessage) ([]any, error) {
+	args, err := c.insertMessageArgs([]byte(line.Data), line.Type)
+	if err != nil {
+		return nil, err
+	}
+	if !c.hasMetadata {
+		return args, nil
+	}
+	metadata := line.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize metadata to JSON: %w", err)
+	}
+	return append(args, line.CreatedAt, metadataJSON), nil
+}
@@ -0,0 +1,54 @@
+package alloydb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// execer runs the write statements of a ChatMessageHistory, on the pool or in
+// a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// WithSessionLock serializes the writes to the session of the
+// ChatMessageHistory, e.g. from several replicas of a service, with a
+// transaction level advisory lock of the session. The messages of concurrent
+// appends are then not interleaved, and a write doesn't interleave with a
+// compaction or a replacement of the messages of the session.
+func WithSessionLock() ChatMessageHistoryStoresOption {
+	return func(c *ChatMessageHistory) {
+		c.sessionLock = true
+	}
+}
+
+// write runs fn on the pool, or in a transaction holding the advisory lock
+// of the session with WithSessionLock.
+func (c *ChatMessageHistory) write(ctx context.Context, fn func(db execer) error) error {
+	if !c.sessionLock {
+		return fn(c.engine.Pool)
+	}
+	return c.engine.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := c.lockSession(ctx, tx); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// lockSession takes the advisory lock of the session in tx with
+// WithSessionLock, released when tx ends. The lock is keyed by the table and
+// the session.
+func (c *ChatMessageHistory) lockSession(ctx context.Context, tx pgx.Tx) error {
+	if !c.sessionLock {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`, c.table(), c.sessionID); err != nil {
+		return fmt.Errorf("failed to lock session %s: %w", c.sessionID, err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package alloydb
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// messageContentVersion is the version of the data column format of the
+// messages added as llms.MessageContent: a JSON object of the version and
+// the message content with all its parts. The messages added as
+// llms.ChatMessage are stored without version, as a JSON string of their
+// content or a JSON object of their fields.
+const messageContentVersion = 2
+
+// StoredMessage is a message of a ChatMessageHistory along with its id, which
+// increases in insertion order.
+type StoredMessage struct {
+	ID int
+	// Message is the message, without the image and binary parts of its
+	// content.
+	Message llms.ChatMessage
+	// Content is the message with all its parts.
+	Content llms.MessageContent
+	// CreatedAt is the time the message was added, and Metadata the metadata
+	// it was added with. They are zero for tables created without the
+	// created_at and metadata columns.
+	CreatedAt time.Time
+	Metadata  map[string]any
+}
+
+// storedMessageContent is the data column of a message added as
+// llms.MessageContent.
+type storedMessageContent struct {
+	Version int                 `json:"version"`
+	Content llms.MessageContent `json:"content"`
+}
+
+// newStoredMessage deserializes the data column of the message id of type
+// messageType.
+func newStoredMessage(id int, messageType llms.ChatMessageType, data []byte) (StoredMessage, error) {
+	stored := StoredMessage{ID: id}
+	var versioned struct {
+		Version int `json:"version"`
+	}
+	if len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &versioned); err != nil {
+			return stored, fmt.Errorf("failed to unmarshal data: %w", err)
+		}
+	}
+	switch {
+	case versioned.Version == 0:
+		message, err := unmarshalMessage(messageType, data)
+		if err != nil {
+			return stored, err
+		}
+		stored.Message, stored.Content = message, messageToContent(message)
+	case versioned.Version == messageContentVersion:
+		var v storedMessageContent
+		if err := json.Unmarshal(data, &v); err != nil {
+			return stored, fmt.Errorf("failed to unmarshal data: %w", err)
+		}
+		message, err := contentToMessage(v.Content)
+		if err != nil {
+			return stored, err
+		}
+		stored.Message, stored.Content = message, v.Content
+	case versioned.Version == compressedMessageVersion:
+		return stored, errCompressedMessage
+	default:
+		return stored, fmt.Errorf("unsupported message format version %d", versioned.Version)
+	}
+	return stored, nil
+}
+
+// marshalMessageContent serializes content to the data column.
+func marshalMessageContent(content llms.MessageContent) ([]byte, error) {
+	data, err := json.Marshal(storedMessageContent{Version: messageContentVersion, Content: content})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize content to JSON: %w", err)
+	}
+	return data, nil
+}
+
+// messageToContent converts message to an llms.MessageContent.
+func messageToContent(message llms.ChatMessage) llms.MessageContent {
+	content := llms.MessageContent{Role: message.GetType()}
+	switch m := message.(type) {
+	case llms.ToolChatMessage:
+		content.Parts = append(content.Parts, llms.ToolCallResponse{ToolCallID: m.ID, Content: m.Content})
+		return content
+	case llms.FunctionChatMessage:
+		content.Parts = append(content.Parts, llms.ToolCallResponse{Name: m.Name, Content: m.Content})
+		return content
+	}
+	if text := message.GetContent(); text != "" {
+		content.Parts = append(content.Parts, llms.TextPart(text))
+	}
+	if m, ok := message.(llms.AIChatMessage); ok {
+		for _, toolCall := range m.ToolCalls {
+			content.Parts = append(content.Parts, toolCall)
+		}
+		if m.FunctionCall != nil {
+			content.Parts = append(content.Parts, llms.ToolCall{Type: "function", FunctionCall: m.FunctionCall})
+		}
+	}
+	return content
+}
+
+// contentToMessage converts content to an llms.ChatMessage of its text, tool
+// call and tool response parts.
+func contentToMessage(content llms.MessageContent) (llms.ChatMessage, error) {
+	var (
+		text      string
+		toolCalls []llms.ToolCall
+		response  llms.ToolCallResponse
+	)
+	for _, part := range content.Parts {
+		switch p := part.(type) {
+		case llms.TextContent:
+			text += p.Text
+		case llms.ToolCall:
+			toolCalls = append(toolCalls, p)
+		case llms.ToolCallResponse:
+			response = p
+			text += p.Content
+		}
+	}
+	switch content.Role {
+	case llms.ChatMessageTypeAI:
+		return llms.AIChatMessage{Content: text, ToolCalls: toolCalls}, nil
+	case llms.ChatMessageTypeHuman:
+		return llms.HumanChatMessage{Content: text}, nil
+	case llms.ChatMessageTypeSystem:
+		return llms.SystemChatMessage{Content: text}, nil
+	case llms.ChatMessageTypeGeneric:
+		return llms.GenericChatMessage{Content: text}, nil
+	case llms.ChatMessageTypeFunction:
+		return llms.FunctionChatMessage{Name: response.Name, Content: text}, nil
+	case llms.ChatMessageTypeTool:
+		return llms.ToolChatMessage{ID: response.ToolCallID, Content: text}, nil
+	}
+	return nil, fmt.Errorf("unsupported message type: %s", content.Role)
+}
+
+// marshalMessage serializes message to the data column. Messages whose
+// content is all they hold are stored as a JSON string, the others as a JSON
+// object of their fields.
+func marshalMessage(message llms.ChatMessage) ([]byte, error) {
+	var v any = message.GetContent()
+	switch m := message.(type) {
+	case llms.AIChatMessage:
+		if m.FunctionCall != nil || len(m.ToolCalls) > 0 {
+			v = m
+		}
+	case llms.GenericChatMessage, llms.FunctionChatMessage, llms.ToolChatMessage:
+		v = m
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize content to JSON: %w", err)
+	}
+	return data, nil
+}
+
+// unmarshalMessage deserializes the data column of a message of type
+// messageType.
+func unmarshalMessage(messageType llms.ChatMessageType, data []byte) (llms.ChatMessage, error) {
+	if len(data) > 0 && data[0] == '{' {
+		return unmarshalMessageObject(messageType, data)
+	}
+	var content string
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+	switch messageType {
+	case llms.ChatMessageTypeAI:
+		return llms.AIChatMessage{Content: content}, nil
+	case llms.ChatMessageTypeHuman:
+		return llms.HumanChatMessage{Content: content}, nil
+	case llms.ChatMessageTypeSystem:
+		return llms.SystemChatMessage{Content: content}, nil
+	case llms.ChatMessageTypeGeneric:
+		return llms.GenericChatMessage{Content: content}, nil
+	case llms.ChatMessageTypeFunction:
+		return llms.FunctionChatMessage{Content: content}, nil
+	case llms.ChatMessageTypeTool:
+		return llms.ToolChatMessage{Content: content}, nil
+	}
+	return nil, fmt.Errorf("unsupported message type: %s", messageType)
+}
+
+// unmarshalMessageObject deserializes a message stored as a JSON object.
+func unmarshalMessageObject(messageType llms.ChatMessageType, data []byte) (llms.ChatMessage, error) {
+	switch messageType { // nolint:exhaustive
+	case llms.ChatMessageTypeAI:
+		return unmarshalJSON[llms.AIChatMessage](data)
+	case llms.ChatMessageTypeGeneric:
+		return unmarshalJSON[llms.GenericChatMessage](data)
+	case llms.ChatMessageTypeFunction:
+		return unmarshalJSON[llms.FunctionChatMessage](data)
+	case llms.ChatMessageTypeTool:
+		return unmarshalJSON[llms.ToolChatMessage](data)
+	}
+	return nil, fmt.Errorf("unsupported message type: %s", messageType)
+}
+
+func unmarshalJSON[T llms.ChatMessage](data []byte) (llms.ChatMessage, error) {
+	var message T
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+	return message, nil
+}
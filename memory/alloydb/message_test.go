@@ -0,0 +1,388 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/fake"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	t.Parallel()
+	messages := []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "hello"},
+		llms.SystemChatMessage{Content: "be brief"},
+		llms.AIChatMessage{Content: "hi"},
+		llms.AIChatMessage{ToolCalls: []llms.ToolCall{{
+			ID:           "call-1",
+			Type:         "function",
+			FunctionCall: &llms.FunctionCall{Name: "search", Arguments: `{"q":"go"}`},
+		}}},
+		llms.ToolChatMessage{ID: "call-1", Content: "results"},
+		llms.GenericChatMessage{Content: "note", Role: "reviewer", Name: "alice"},
+		llms.FunctionChatMessage{Name: "search", Content: "results"},
+	}
+	for _, message := range messages {
+		data, err := marshalMessage(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := unmarshalMessage(message.GetType(), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, message) {
+			t.Errorf("expected %#v, got %#v", message, got)
+		}
+	}
+}
+
+func TestUnmarshalMessageUnsupportedType(t *testing.T) {
+	t.Parallel()
+	if _, err := unmarshalMessage("unknown", []byte(`"content"`)); err == nil {
+		t.Error("expected an error for an unsupported message type")
+	}
+}
+
+func TestMessageContentRoundTrip(t *testing.T) {
+	t.Parallel()
+	toolCall := llms.ToolCall{
+		ID:           "call-1",
+		Type:         "function",
+		FunctionCall: &llms.FunctionCall{Name: "describe", Arguments: `{"detail":"high"}`},
+	}
+	contents := []llms.MessageContent{
+		{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{
+			llms.TextPart("what is in these images?"),
+			llms.ImageURLPart("https://example.com/cat.png"),
+			llms.BinaryPart("image/png", []byte{0x89, 'P', 'N', 'G'}),
+		}},
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{toolCall}},
+		{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+			llms.ToolCallResponse{ToolCallID: "call-1", Name: "describe", Content: "a cat"},
+		}},
+	}
+	wantMessages := []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "what is in these images?"},
+		llms.AIChatMessage{ToolCalls: []llms.ToolCall{toolCall}},
+		llms.ToolChatMessage{ID: "call-1", Content: "a cat"},
+	}
+	for i, content := range contents {
+		data, err := marshalMessageContent(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := newStoredMessage(i, content.Role, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Content, content) {
+			t.Errorf("expected content %#v, got %#v", content, got.Content)
+		}
+		if !reflect.DeepEqual(got.Message, wantMessages[i]) {
+			t.Errorf("expected message %#v, got %#v", wantMessages[i], got.Message)
+		}
+	}
+}
+
+func TestStoredMessageWithoutVersion(t *testing.T) {
+	t.Parallel()
+	got, err := newStoredMessage(1, llms.ChatMessageTypeTool, []byte(`{"tool_call_id":"call-1","content":"a cat"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+		llms.ToolCallResponse{ToolCallID: "call-1", Content: "a cat"},
+	}}
+	if !reflect.DeepEqual(got.Content, want) {
+		t.Errorf("expected content %#v, got %#v", want, got.Content)
+	}
+
+	if _, err := newStoredMessage(1, llms.ChatMessageTypeHuman, []byte(`{"version":3,"content":{}}`)); err == nil {
+		t.Error("expected an error for an unsupported format version")
+	}
+}
+
+func TestSearchMessagesQuery(t *testing.T) {
+	t.Parallel()
+	c := ChatMessageHistory{schemaName: "public", tableName: "items", sessionID: "session", hasMetadata: true}
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query, args, err := c.searchMessagesQuery(MessageFilter{
+		Metadata: map[string]any{"user_id": "u1"},
+		Types:    []llms.ChatMessageType{llms.ChatMessageTypeHuman},
+		After:    after,
+		Limit:    10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SELECT id, data, type, created_at, metadata FROM "public"."items" WHERE session_id = $1 AND metadata @> $2::jsonb AND type = ANY($3) AND created_at >= $4 ORDER BY id LIMIT 10`
+	if query != want {
+		t.Errorf("expected %s, got %s", want, query)
+	}
+	wantArgs := []any{"session", []byte(`{"user_id":"u1"}`), []string{"human"}, after}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("expected arguments %v, got %v", wantArgs, args)
+	}
+
+	query, args, err = c.searchMessagesQuery(MessageFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(query, "WHERE session_id = $1 ORDER BY id") || len(args) != 1 {
+		t.Errorf("expected all the messages of the session, got %s with %v", query, args)
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	t.Parallel()
+	c := ChatMessageHistory{schemaName: "public", tableName: "items", sessionID: "session"}
+	stmt, args, err := c.searchQuery("connection pool", applySearchOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SELECT session_id, id, data, type, ts_rank(jsonb_to_tsvector('english'::regconfig, data, '["string"]'), websearch_to_tsquery('english'::regconfig, $1)) AS rank FROM "public"."items" WHERE jsonb_to_tsvector('english'::regconfig, data, '["string"]') @@ websearch_to_tsquery('english'::regconfig, $1) AND session_id = $2 ORDER BY rank DESC, id DESC LIMIT 10`
+	if stmt != want {
+		t.Errorf("expected %s, got %s", want, stmt)
+	}
+	if !reflect.DeepEqual(args, []any{"connection pool", "session"}) {
+		t.Errorf("unexpected arguments %v", args)
+	}
+
+	stmt, args, err = c.searchQuery("pool", applySearchOptions(WithAllSessions(), WithTextSearchConfig("simple")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stmt, "session_id = ") || !strings.Contains(stmt, "'simple'::regconfig") || len(args) != 1 {
+		t.Errorf("expected a search of all sessions with the simple configuration, got %s", stmt)
+	}
+
+	if _, _, err := c.searchQuery("pool", applySearchOptions(WithTextSearchConfig("english'; DROP TABLE items; --"))); err == nil {
+		t.Error("expected an invalid text search configuration error")
+	}
+}
+
+func TestPruneQuery(t *testing.T) {
+	t.Parallel()
+	c := ChatMessageHistory{schemaName: "public", tableName: "items", sessionID: "session"}
+	if query, _ := c.pruneQuery(); query != "" {
+		t.Errorf("expected no statement without retention policy, got %s", query)
+	}
+
+	c.retention = RetentionPolicy{MaxMessages: 100, MaxAge: 30 * 24 * time.Hour}
+	query, args := c.pruneQuery()
+	want := `DELETE FROM "public"."items" WHERE session_id = $1 AND (id <= (SELECT id FROM "public"."items" WHERE session_id = $1 ORDER BY id DESC OFFSET 100 LIMIT 1) OR created_at < NOW() - $2 * INTERVAL '1 second')`
+	if query != want {
+		t.Errorf("expected %s, got %s", want, query)
+	}
+	if !reflect.DeepEqual(args, []any{"session", float64(30 * 24 * 60 * 60)}) {
+		t.Errorf("unexpected arguments %v", args)
+	}
+}
+
+// stripIdentifiers removes the quoted identifiers of query, failing on an
+// unterminated one.
+func stripIdentifiers(t *testing.T, query string) string {
+	t.Helper()
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(query); i++ {
+		switch {
+		case query[i] != '"':
+			if !quoted {
+				b.WriteByte(query[i])
+			}
+		case quoted && i+1 < len(query) && query[i+1] == '"':
+			i++
+		default:
+			quoted = !quoted
+		}
+	}
+	if quoted {
+		t.Fatalf("unterminated identifier in %s", query)
+	}
+	return b.String()
+}
+
+func FuzzQueries(f *testing.F) {
+	f.Add("public", "items")
+	f.Add(`pub"lic`, `"; DROP TABLE x; --`)
+	f.Fuzz(func(t *testing.T, schemaName, tableName string) {
+		queries := func(schemaName, tableName string) []string {
+			c := ChatMessageHistory{
+				schemaName:        schemaName,
+				tableName:         tableName,
+				sessionID:         "session",
+				hasMetadata:       true,
+				hasCompressedData: true,
+				retention:         RetentionPolicy{MaxMessages: 100},
+			}
+			searchMessages, _, err := c.searchMessagesQuery(MessageFilter{Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			search, _, err := c.searchQuery("query", applySearchOptions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			prune, _ := c.pruneQuery()
+			return []string{
+				c.insertMessageQuery(), c.importMessageQuery(), c.insertSummaryQuery(),
+				c.archiveMessagesQuery(tableName + archiveTableSuffix), searchMessages, search, prune,
+			}
+		}
+		want := queries("s", "t")
+		for i, query := range queries(schemaName, tableName) {
+			if stripIdentifiers(t, query) != stripIdentifiers(t, want[i]) {
+				t.Errorf("identifiers escaped the statement %s", query)
+			}
+		}
+	})
+}
+
+func TestImportMessageQuery(t *testing.T) {
+	t.Parallel()
+	c := ChatMessageHistory{schemaName: "public", tableName: "items", sessionID: "session", hasMetadata: true}
+	want := `INSERT INTO "public"."items" (session_id, data, type, created_at, metadata) VALUES ($1, $2, $3, COALESCE($4, NOW()), $5)`
+	if got := c.importMessageQuery(); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	args, err := c.importMessageArgs(exportedMessage{Type: llms.ChatMessageTypeHuman, Data: []byte(`"hello"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 5 || args[3].(*time.Time) != nil || string(args[4].([]byte)) != "{}" {
+		t.Errorf("unexpected arguments %v", args)
+	}
+
+	c.hasCompressedData = true
+	want = `INSERT INTO "public"."items" (session_id, data, type, compressed_data, created_at, metadata) VALUES ($1, $2, $3, $4, COALESCE($5, NOW()), $6)`
+	if got := c.importMessageQuery(); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	args, err = c.importMessageArgs(exportedMessage{Type: llms.ChatMessageTypeHuman, Data: []byte(`"hello"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 6 || args[3].([]byte) != nil {
+		t.Errorf("unexpected arguments %v", args)
+	}
+
+	c.hasMetadata, c.hasCompressedData = false, false
+	if got := c.importMessageQuery(); got != c.insertMessageQuery() {
+		t.Errorf("expected the insert statement of messages without metadata, got %s", got)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	t.Parallel()
+	compressor, err := alloydbutil.NewZstdCompressor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ChatMessageHistory{compressor: compressor, compressionMinSize: 64}
+
+	small := []byte(`"hello"`)
+	data, compressed, err := c.compress(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(small) || compressed != nil {
+		t.Errorf("expected small messages to be stored uncompressed, got %s, %x", data, compressed)
+	}
+
+	large, err := marshalMessageContent(llms.TextParts(llms.ChatMessageTypeAI, strings.Repeat("trace ", 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, compressed, err = c.compress(large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compressed) == 0 || len(data) > 64 {
+		t.Errorf("expected the compressed message to be stored in the compressed data, got %s", data)
+	}
+	if _, err := newStoredMessage(1, llms.ChatMessageTypeAI, data); err == nil {
+		t.Fatal("expected an error reading a compressed message without decompressing it")
+	}
+	stored, err := c.readStoredMessage(1, llms.ChatMessageTypeAI, data, compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stored.Message.GetContent(); got != strings.Repeat("trace ", 100) {
+		t.Errorf("unexpected content %q", got)
+	}
+
+	if _, err := (&ChatMessageHistory{}).readStoredMessage(1, llms.ChatMessageTypeAI, data, compressed); err == nil {
+		t.Error("expected an error reading a compressed message without compressor")
+	}
+	if _, err := c.readStoredMessage(1, llms.ChatMessageTypeAI, data, nil); err == nil {
+		t.Error("expected an error reading a compressed message without compressed data")
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+	c := &ChatMessageHistory{}
+	WithReadOnly()(c)
+	ctx := context.Background()
+
+	err := c.AddUserMessage(ctx, "hello")
+	var readOnlyErr *alloydbutil.ReadOnlyError
+	if !errors.As(err, &readOnlyErr) || readOnlyErr.Operation != "add message" {
+		t.Errorf("expected a ReadOnlyError for add message, got %v", err)
+	}
+	for name, write := range map[string]func() error{
+		"Clear":       func() error { return c.Clear(ctx) },
+		"SetMessages": func() error { return c.SetMessages(ctx, nil) },
+		"Prune":       func() error { _, err := c.Prune(ctx); return err },
+		"Import": func() error {
+			_, err := c.ImportSession(ctx, strings.NewReader(""))
+			return err
+		},
+	} {
+		if err := write(); !errors.Is(err, alloydbutil.ErrReadOnly) {
+			t.Errorf("%s: expected ErrReadOnly, got %v", name, err)
+		}
+	}
+}
+
+func TestParseEntities(t *testing.T) {
+	t.Parallel()
+	tests := map[string][]string{
+		"NONE":                       {},
+		" none.\n":                   {},
+		"Alice, Paris , Alice, NONE": {"Alice", "Paris"},
+		`"AlloyDB", Google Cloud.`:   {"AlloyDB", "Google Cloud"},
+	}
+	for output, want := range tests {
+		if got := parseEntities(output); !slices.Equal(got, want) {
+			t.Errorf("parseEntities(%q): expected %v, got %v", output, want, got)
+		}
+	}
+}
+
+func TestNewEntityMemory(t *testing.T) {
+	t.Parallel()
+	llm := fake.NewFakeLLM(nil)
+	if _, err := NewEntityMemory(nil, &ChatMessageHistory{}, "entities"); err == nil {
+		t.Error("expected an error without LLM")
+	}
+	if _, err := NewEntityMemory(llm, &ChatMessageHistory{}, ""); err == nil {
+		t.Error("expected an error without table name")
+	}
+	m, err := NewEntityMemory(llm, &ChatMessageHistory{}, "entities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.MemoryVariables(context.Background()), []string{"history", "entities"}; !slices.Equal(got, want) {
+		t.Errorf("expected memory variables %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,138 @@
+package alloydb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrNoMetadataColumns is returned when adding or searching messages by
+// metadata in a table created without the created_at and metadata columns.
+var ErrNoMetadataColumns = errors.New("chat history table has no created_at and metadata columns")
+
+// MessageFilter selects the messages returned by SearchMessages. The zero
+// value matches all the messages of the session.
+type MessageFilter struct {
+	// Metadata only matches the messages whose metadata contains these keys
+	// and values, e.g. {"user_id": "u1"}.
+	Metadata map[string]any
+	// Types only matches the messages of these types.
+	Types []llms.ChatMessageType
+	// After, if not zero, only matches the messages created at or after it.
+	After time.Time
+	// Before, if not zero, only matches the messages created before it.
+	Before time.Time
+	// Limit is the maximum number of messages returned, the oldest ones. By
+	// default all the matching messages are returned.
+	Limit int
+}
+
+// AddMessageWithMetadata adds a message to the ChatMessageHistory along with
+// its metadata, e.g. the user id, trace id or model of the message.
+func (c *ChatMessageHistory) AddMessageWithMetadata(ctx context.Context, message llms.ChatMessage, metadata map[string]any) error {
+	if err := c.checkWritable("add message"); err != nil {
+		return err
+	}
+	if !c.hasMetadata {
+		return ErrNoMetadataColumns
+	}
+	data, err := marshalMessage(message)
+	if err != nil {
+		return err
+	}
+	args, err := c.insertMessageArgs(data, message.GetType())
+	if err != nil {
+		return err
+	}
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to serialize metadata to JSON: %w", err)
+	}
+
+	err = c.appendMessages(ctx, func(db execer) error {
+		_, err := db.Exec(ctx, c.insertMessageWithMetadataQuery(), append(args, metadataJSON)...)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add message to database: %w", err)
+	}
+	return nil
+}
+
+// insertMessageWithMetadataQuery returns the statement used to insert a
+// single message with its metadata.
+func (c *ChatMessageHistory) insertMessageWithMetadataQuery() string {
+	return c.insertQuery(append(c.messageColumns(), "metadata"))
+}
+
+// SearchMessages retrieves the messages associated with a session from the
+// ChatMessageHistory that match filter, in insertion order, along with their
+// creation time and metadata. They are read from the engine's read pool
+// instance, if any.
+func (c *ChatMessageHistory) SearchMessages(ctx context.Context, filter MessageFilter) ([]StoredMessage, error) {
+	if !c.hasMetadata {
+		return nil, ErrNoMetadataColumns
+	}
+	query, args, err := c.searchMessagesQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	var messages []StoredMessage
+	err = c.read(ctx, func(ctx context.Context, db querier) error {
+		messages = nil
+		return c.scanMessages(ctx, db, query, args, func(message StoredMessage) bool {
+			messages = append(messages, message)
+			return true
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	return messages, nil
+}
+
+// searchMessagesQuery returns the statement selecting the messages of the
+// session matching filter, and its arguments.
+func (c *ChatMessageHistory) searchMessagesQuery(filter MessageFilter) (string, []any, error) {
+	conditions := []string{"session_id = $1"}
+	args := []any{c.sessionID}
+	if len(filter.Metadata) > 0 {
+		metadataJSON, err := json.Marshal(filter.Metadata)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to serialize metadata filter to JSON: %w", err)
+		}
+		args = append(args, metadataJSON)
+		conditions = append(conditions, fmt.Sprintf("metadata @> $%d::jsonb", len(args)))
+	}
+	if len(filter.Types) > 0 {
+		types := make([]string, len(filter.Types))
+		for i, messageType := range filter.Types {
+			types[i] = string(messageType)
+		}
+		args = append(args, types)
+		conditions = append(conditions, fmt.Sprintf("type = ANY($%d)", len(args)))
+	}
+	if !filter.After.IsZero() {
+		args = append(args, filter.After)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !filter.Before.IsZero() {
+		args = append(args, filter.Before)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	limit := ""
+	if filter.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s ORDER BY id%s`,
+		c.selectColumns(), c.table(), strings.Join(conditions, " AND "), limit)
+	return query, args, nil
+}
@@ -0,0 +1,52 @@
+package alloydb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+// querier runs the read statements of a ChatMessageHistory, on a pool or in a
+// transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// WithReadOnly puts the ChatMessageHistory in read-only mode, e.g. to serve
+// sessions from a replica or to analyze production sessions from a staging
+// job. Its mutating operations return an alloydbutil.ReadOnlyError, and the
+// messages are read in read-only transactions.
+func WithReadOnly() ChatMessageHistoryStoresOption {
+	return func(c *ChatMessageHistory) {
+		c.readOnly = true
+	}
+}
+
+// checkWritable returns an alloydbutil.ReadOnlyError for the mutating
+// operation op in read-only mode.
+func (c *ChatMessageHistory) checkWritable(op string) error {
+	if c.readOnly {
+		return &alloydbutil.ReadOnlyError{Operation: op}
+	}
+	return nil
+}
+
+// read calls fn with the engine's read pool instance, if any, as query does.
+func (c *ChatMessageHistory) read(ctx context.Context, fn func(ctx context.Context, db querier) error) error {
+	return c.engine.Read(ctx, func(ctx context.Context, pool *pgxpool.Pool) error {
+		return c.query(ctx, pool, fn)
+	})
+}
+
+// query calls fn with pool, or with a read-only transaction on pool in
+// read-only mode.
+func (c *ChatMessageHistory) query(ctx context.Context, pool *pgxpool.Pool, fn func(ctx context.Context, db querier) error) error {
+	if !c.readOnly {
+		return fn(ctx, pool)
+	}
+	return alloydbutil.ReadOnlyTx(ctx, pool, func(tx pgx.Tx) error {
+		return fn(ctx, tx)
+	})
+}
@@ -0,0 +1,102 @@
+package alloydb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RetentionPolicy limits the messages kept in the session of a
+// ChatMessageHistory. The zero value keeps all the messages.
+type RetentionPolicy struct {
+	// MaxMessages, if positive, is the number of most recent messages kept.
+	MaxMessages int
+	// MaxAge, if positive, is the age after which messages are deleted. It
+	// requires the created_at column.
+	MaxAge time.Duration
+	// EnforceOnWrite prunes the session after every write adding messages.
+	// Otherwise the session is only pruned by Prune.
+	EnforceOnWrite bool
+}
+
+// WithRetentionPolicy sets the retention policy of the session of the
+// ChatMessageHistory, enforced by Prune.
+func WithRetentionPolicy(policy RetentionPolicy) ChatMessageHistoryStoresOption {
+	return func(c *ChatMessageHistory) {
+		c.retention = policy
+	}
+}
+
+// Prune deletes the messages of the session beyond the retention policy and
+// returns their number. The messages archived by compactions are kept.
+func (c *ChatMessageHistory) Prune(ctx context.Context) (int64, error) {
+	if err := c.checkWritable("prune session"); err != nil {
+		return 0, err
+	}
+	var pruned int64
+	err := c.write(ctx, func(db execer) error {
+		var err error
+		pruned, err = c.prune(ctx, db)
+		return err
+	})
+	return pruned, err
+}
+
+// appendMessages runs fn, which adds messages to the session. When the
+// retention policy is enforced on write, the session is pruned in the same
+// transaction.
+func (c *ChatMessageHistory) appendMessages(ctx context.Context, fn func(db execer) error) error {
+	if !c.retention.EnforceOnWrite {
+		return c.write(ctx, fn)
+	}
+	return c.engine.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := c.lockSession(ctx, tx); err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		_, err := c.prune(ctx, tx)
+		return err
+	})
+}
+
+// prune deletes the messages of the session beyond the retention policy
+// with db.
+func (c *ChatMessageHistory) prune(ctx context.Context, db execer) (int64, error) {
+	query, args := c.pruneQuery()
+	if query == "" {
+		return 0, nil
+	}
+	tag, err := db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune session %s: %w", c.sessionID, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// pruneQuery returns the statement deleting the messages of the session
+// beyond the retention policy and its arguments, or an empty statement
+// without policy.
+func (c *ChatMessageHistory) pruneQuery() (string, []any) {
+	var conditions []string
+	args := []any{c.sessionID}
+	if c.retention.MaxMessages > 0 {
+		// The messages up to the most recent one beyond the limit.
+		conditions = append(conditions, fmt.Sprintf(
+			"id <= (SELECT id FROM %s WHERE session_id = $1 ORDER BY id DESC OFFSET %d LIMIT 1)",
+			c.table(), c.retention.MaxMessages))
+	}
+	if c.retention.MaxAge > 0 {
+		args = append(args, c.retention.MaxAge.Seconds())
+		conditions = append(conditions, fmt.Sprintf("created_at < NOW() - $%d * INTERVAL '1 second'", len(args)))
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf(`DELETE FROM %s WHERE session_id = $1 AND (%s)`,
+		c.table(), strings.Join(conditions, " OR ")), args
+}
@@ -0,0 +1,166 @@
+package alloydb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+const (
+	defaultSearchLimit      = 10
+	defaultTextSearchConfig = "english"
+)
+
+// textSearchConfigRegexp matches the names of text search configurations,
+// which are inlined in the search query to match the index expression.
+var textSearchConfigRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// SearchResult is a message matching a full-text search, along with its
+// session and the relevance of the message to the search.
+type SearchResult struct {
+	StoredMessage
+	SessionID string
+	Rank      float32
+}
+
+// SearchOption is a function for searching the messages of a chat message
+// history with other than the default values.
+type SearchOption func(o *searchOptions)
+
+type searchOptions struct {
+	allSessions bool
+	limit       int
+	config      string
+}
+
+// WithAllSessions searches the messages of all the sessions of the table
+// instead of those of the session of the history.
+func WithAllSessions() SearchOption {
+	return func(o *searchOptions) {
+		o.allSessions = true
+	}
+}
+
+// WithSearchLimit sets the maximum number of messages returned. It defaults
+// to 10.
+func WithSearchLimit(limit int) SearchOption {
+	return func(o *searchOptions) {
+		o.limit = limit
+	}
+}
+
+// WithTextSearchConfig sets the text search configuration of the search. It
+// defaults to "english" and must match the configuration of the index
+// created with alloydbutil.WithFullTextSearch.
+func WithTextSearchConfig(config string) SearchOption {
+	return func(o *searchOptions) {
+		o.config = config
+	}
+}
+
+func applySearchOptions(opts ...SearchOption) searchOptions {
+	o := searchOptions{
+		limit:  defaultSearchLimit,
+		config: defaultTextSearchConfig,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Search returns the messages whose text matches query, the most relevant
+// first. The query is in the web search syntax, e.g. `"connection pool" -redis`.
+// Only the messages of the session of the history are searched, unless
+// WithAllSessions is set. The text of the messages is indexed for the searches
+// by alloydbutil.WithFullTextSearch; without index, the searches scan the
+// table. They are read from the engine's read pool instance, if any.
+func (c *ChatMessageHistory) Search(ctx context.Context, query string, opts ...SearchOption) ([]SearchResult, error) {
+	o := applySearchOptions(opts...)
+	stmt, args, err := c.searchQuery(query, o)
+	if err != nil {
+		return nil, err
+	}
+	var results []SearchResult
+	err = c.read(ctx, func(ctx context.Context, db querier) error {
+		var err error
+		results, err = c.scanSearchResults(ctx, db, stmt, args)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	return results, nil
+}
+
+// searchQuery returns the statement of a full-text search, and its
+// arguments.
+func (c *ChatMessageHistory) searchQuery(query string, o searchOptions) (string, []any, error) {
+	if !textSearchConfigRegexp.MatchString(o.config) {
+		return "", nil, fmt.Errorf("invalid text search configuration %q", o.config)
+	}
+	// The text search vector must match the expression of the index.
+	vector := fmt.Sprintf(`jsonb_to_tsvector('%s'::regconfig, data, '["string"]')`, o.config)
+	tsQuery := fmt.Sprintf(`websearch_to_tsquery('%s'::regconfig, $1)`, o.config)
+	args := []any{query}
+	conditions := fmt.Sprintf("%s @@ %s", vector, tsQuery)
+	if !o.allSessions {
+		args = append(args, c.sessionID)
+		conditions += fmt.Sprintf(" AND session_id = $%d", len(args))
+	}
+	limit := ""
+	if o.limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", o.limit)
+	}
+	stmt := fmt.Sprintf(`SELECT session_id, %s, ts_rank(%s, %s) AS rank FROM %s WHERE %s ORDER BY rank DESC, id DESC%s`,
+		c.selectColumns(), vector, tsQuery, c.table(), conditions, limit)
+	return stmt, args, nil
+}
+
+// scanSearchResults runs the statement of a full-text search and returns
+// its results.
+func (c *ChatMessageHistory) scanSearchResults(ctx context.Context, db querier, stmt string, args []any) ([]SearchResult, error) {
+	rows, err := db.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve messages: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		results           []SearchResult
+		sessionID         string
+		id                int
+		data, messageType string
+		createdAt         time.Time
+		metadata          map[string]any
+		compressed        []byte
+		rank              float32
+	)
+	dest := []any{&sessionID, &id, &data, &messageType}
+	if c.hasMetadata {
+		dest = append(dest, &createdAt, &metadata)
+	}
+	if c.hasCompressedData {
+		dest = append(dest, &compressed)
+	}
+	dest = append(dest, &rank)
+	for rows.Next() {
+		metadata = nil
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		message, err := c.readStoredMessage(id, llms.ChatMessageType(messageType), []byte(data), compressed)
+		if err != nil {
+			return nil, err
+		}
+		message.CreatedAt, message.Metadata = createdAt, metadata
+		results = append(results, SearchResult{StoredMessage: message, SessionID: sessionID, Rank: rank})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over rows: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,102 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+// SessionInfo describes a session of a chat history table.
+type SessionInfo struct {
+	ID string
+	// MessageCount is the number of messages of the session.
+	MessageCount int
+	// LastMessageID is the id of the most recent message of the session.
+	LastMessageID int
+}
+
+// SessionManager manages the sessions of a chat history table, e.g. to list
+// the conversations of an application or apply a retention policy.
+type SessionManager struct {
+	engine     alloydbutil.PostgresEngine
+	tableName  string
+	schemaName string
+}
+
+// NewSessionManager creates a SessionManager of the sessions stored in
+// tableName. Only the schema name option applies.
+func NewSessionManager(engine alloydbutil.PostgresEngine, tableName string, opts ...ChatMessageHistoryStoresOption) (SessionManager, error) {
+	if engine.Pool == nil {
+		return SessionManager{}, errors.New("alloyDB engine must be provided")
+	}
+	if tableName == "" {
+		return SessionManager{}, errors.New("table name must be provided")
+	}
+	cmh := applyChatMessageHistoryOptions(ChatMessageHistory{}, opts...)
+	return SessionManager{
+		engine:     engine,
+		tableName:  tableName,
+		schemaName: cmh.schemaName,
+	}, nil
+}
+
+// table returns the quoted, schema qualified name of the table of the
+// sessions.
+func (m *SessionManager) table() string {
+	return alloydbutil.QuoteIdentifier(m.schemaName, m.tableName)
+}
+
+// ListSessions returns the sessions with messages, the most recently active
+// first. They are read from the engine's read pool instance, if any.
+func (m *SessionManager) ListSessions(ctx context.Context) ([]SessionInfo, error) {
+	query := fmt.Sprintf(
+		`SELECT session_id, COUNT(*), MAX(id) FROM %s GROUP BY session_id ORDER BY MAX(id) DESC`,
+		m.table(),
+	)
+	var sessions []SessionInfo
+	err := m.engine.Read(ctx, func(ctx context.Context, pool *pgxpool.Pool) error {
+		rows, err := pool.Query(ctx, query)
+		if err != nil {
+			return err
+		}
+		sessions = nil
+		var session SessionInfo
+		_, err = pgx.ForEachRow(rows, []any{&session.ID, &session.MessageCount, &session.LastMessageID}, func() error {
+			sessions = append(sessions, session)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// SessionExists returns whether the session has messages.
+func (m *SessionManager) SessionExists(ctx context.Context, sessionID string) (bool, error) {
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE session_id = $1)`, m.table())
+	var exists bool
+	err := m.engine.Read(ctx, func(ctx context.Context, pool *pgxpool.Pool) error {
+		return pool.QueryRow(ctx, query, sessionID).Scan(&exists)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check session %s: %w", sessionID, err)
+	}
+	return exists, nil
+}
+
+// DeleteSession deletes the messages of the session and returns their
+// number. The messages archived by compactions are kept.
+func (m *SessionManager) DeleteSession(ctx context.Context, sessionID string) (int64, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE session_id = $1`, m.table())
+	tag, err := m.engine.Pool.Exec(ctx, query, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete session %s: %w", sessionID, err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,55 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// SummaryBuffer is a memory of a rolling summary of the conversation
+// followed by its most recent messages, both stored in a chat history table,
+// so that conversations longer than the context window of the model persist
+// across process restarts. Once enough messages pile up, the older ones are
+// summarized by LLM into a system message along with the previous summary,
+// as ChatMessageHistory.Compact does.
+type SummaryBuffer struct {
+	memory.ConversationBuffer
+	LLM llms.Model
+	// CompactionOptions are the options of the compactions of the history,
+	// e.g. WithKeepRecent to set the length of the tail of recent messages.
+	CompactionOptions []CompactionOption
+
+	history *ChatMessageHistory
+}
+
+// Statically assert that SummaryBuffer implement the memory interface.
+var _ schema.Memory = &SummaryBuffer{}
+
+// NewSummaryBuffer creates a SummaryBuffer storing the conversation in
+// history, summarized by llm. The chat history option of options is
+// ignored.
+func NewSummaryBuffer(llm llms.Model, history *ChatMessageHistory, options ...memory.ConversationBufferOption) *SummaryBuffer {
+	options = append(options, memory.WithChatHistory(history))
+	return &SummaryBuffer{
+		ConversationBuffer: *memory.NewConversationBuffer(options...),
+		LLM:                llm,
+		history:            history,
+	}
+}
+
+// SaveContext uses ConversationBuffer method for saving context, then
+// summarizes the older messages if needed.
+func (sb *SummaryBuffer) SaveContext(ctx context.Context, inputValues map[string]any, outputValues map[string]any) error {
+	if err := sb.ConversationBuffer.SaveContext(ctx, inputValues, outputValues); err != nil {
+		return err
+	}
+	_, err := sb.history.Compact(ctx, sb.LLM, sb.CompactionOptions...)
+	// A concurrent write changed the history; the next save compacts it.
+	if errors.Is(err, ErrHistoryChanged) {
+		return nil
+	}
+	return err
+}
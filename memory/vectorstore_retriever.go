@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// defaultVectorStoreNumDocuments is the default number of past exchanges
+// retrieved by VectorStoreRetrieverMemory.
+const defaultVectorStoreNumDocuments = 4
+
+// VectorStoreRetrieverMemory is a memory that stores each exchange of the
+// conversation as a document of a vector store, and retrieves the exchanges
+// most relevant to the input instead of the most recent ones.
+type VectorStoreRetrieverMemory struct {
+	VectorStore vectorstores.VectorStore
+	// NumDocuments is the number of exchanges retrieved.
+	NumDocuments int
+	// SearchOptions are the options of the searches, e.g. a filter on the
+	// session id set in Metadata.
+	SearchOptions []vectorstores.Option
+	// Metadata is the metadata of the stored exchanges.
+	Metadata map[string]any
+
+	// ReturnDocuments returns the retrieved documents instead of their
+	// contents joined, set with WithReturnMessages.
+	ReturnDocuments bool
+	InputKey        string
+	OutputKey       string
+	HumanPrefix     string
+	AIPrefix        string
+	MemoryKey       string
+}
+
+// Statically assert that VectorStoreRetrieverMemory implement the memory interface.
+var _ schema.Memory = &VectorStoreRetrieverMemory{}
+
+// NewVectorStoreRetrieverMemory is a function for creating a new vector store
+// retriever memory retrieving numDocuments exchanges, 4 if it isn't positive.
+// The options set the keys and prefixes as for ConversationBuffer, and the
+// chat history option is ignored.
+func NewVectorStoreRetrieverMemory(
+	vectorStore vectorstores.VectorStore,
+	numDocuments int,
+	options ...ConversationBufferOption,
+) *VectorStoreRetrieverMemory {
+	if numDocuments <= 0 {
+		numDocuments = defaultVectorStoreNumDocuments
+	}
+	b := applyBufferOptions(options...)
+	return &VectorStoreRetrieverMemory{
+		VectorStore:     vectorStore,
+		NumDocuments:    numDocuments,
+		ReturnDocuments: b.ReturnMessages,
+		InputKey:        b.InputKey,
+		OutputKey:       b.OutputKey,
+		HumanPrefix:     b.HumanPrefix,
+		AIPrefix:        b.AIPrefix,
+		MemoryKey:       b.MemoryKey,
+	}
+}
+
+// MemoryVariables gets the memory key of the retrieved exchanges.
+func (m *VectorStoreRetrieverMemory) MemoryVariables(context.Context) []string {
+	return []string{m.MemoryKey}
+}
+
+// LoadMemoryVariables returns the exchanges most relevant to the input,
+// joined by new lines or as documents if ReturnDocuments is set.
+func (m *VectorStoreRetrieverMemory) LoadMemoryVariables(
+	ctx context.Context, inputs map[string]any,
+) (map[string]any, error) {
+	query, err := GetInputValue(inputs, m.InputKey)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := m.VectorStore.SimilaritySearch(ctx, query, m.NumDocuments, m.SearchOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve exchanges: %w", err)
+	}
+
+	if m.ReturnDocuments {
+		return map[string]any{
+			m.MemoryKey: docs,
+		}, nil
+	}
+
+	contents := make([]string, len(docs))
+	for i, doc := range docs {
+		contents[i] = doc.PageContent
+	}
+	return map[string]any{
+		m.MemoryKey: strings.Join(contents, "\n"),
+	}, nil
+}
+
+// SaveContext adds the exchange of the input and output values to the vector
+// store, as a document of a human and an AI line.
+func (m *VectorStoreRetrieverMemory) SaveContext(
+	ctx context.Context,
+	inputValues map[string]any,
+	outputValues map[string]any,
+) error {
+	input, err := GetInputValue(inputValues, m.InputKey)
+	if err != nil {
+		return err
+	}
+	output, err := GetInputValue(outputValues, m.OutputKey)
+	if err != nil {
+		return err
+	}
+	metadata := maps.Clone(m.Metadata)
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	doc := schema.Document{
+		PageContent: fmt.Sprintf("%s: %s\n%s: %s", m.HumanPrefix, input, m.AIPrefix, output),
+		Metadata:    metadata,
+	}
+	if _, err := m.VectorStore.AddDocuments(ctx, []schema.Document{doc}); err != nil {
+		return fmt.Errorf("failed to save exchange: %w", err)
+	}
+	return nil
+}
+
+// Clear does nothing, as vector stores can't delete documents in general.
+func (m *VectorStoreRetrieverMemory) Clear(context.Context) error {
+	return nil
+}
+
+func (m *VectorStoreRetrieverMemory) GetMemoryKey(context.Context) string {
+	return m.MemoryKey
+}
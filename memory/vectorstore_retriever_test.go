@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// wordVectorStore returns the documents sharing a word with the query, in
+// insertion order.
+type wordVectorStore struct {
+	docs []schema.Document
+}
+
+func (s *wordVectorStore) AddDocuments(_ context.Context, docs []schema.Document, _ ...vectorstores.Option) ([]string, error) { // nolint: lll
+	s.docs = append(s.docs, docs...)
+	return make([]string, len(docs)), nil
+}
+
+func (s *wordVectorStore) SimilaritySearch(_ context.Context, query string, numDocuments int, _ ...vectorstores.Option) ([]schema.Document, error) { // nolint: lll
+	var docs []schema.Document
+	for _, doc := range s.docs {
+		for _, word := range strings.Fields(query) {
+			if strings.Contains(doc.PageContent, word) && len(docs) < numDocuments {
+				docs = append(docs, doc)
+				break
+			}
+		}
+	}
+	return docs, nil
+}
+
+func TestVectorStoreRetrieverMemory(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := &wordVectorStore{}
+	m := NewVectorStoreRetrieverMemory(store, 1, WithInputKey("input"))
+	m.Metadata = map[string]any{"session_id": "1"}
+	require.Equal(t, []string{"history"}, m.MemoryVariables(ctx))
+
+	err := m.SaveContext(ctx, map[string]any{"input": "my favorite sport is soccer"}, map[string]any{"text": "noted"})
+	require.NoError(t, err)
+	err = m.SaveContext(ctx, map[string]any{"input": "I live in Paris"}, map[string]any{"text": "nice"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"session_id": "1"}, store.docs[0].Metadata)
+
+	result, err := m.LoadMemoryVariables(ctx, map[string]any{"input": "Paris weather", "other": "value"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"history": "Human: I live in Paris\nAI: nice"}, result)
+
+	m.ReturnDocuments = true
+	result, err = m.LoadMemoryVariables(ctx, map[string]any{"input": "soccer"})
+	require.NoError(t, err)
+	require.Equal(t, []schema.Document{store.docs[0]}, result["history"])
+
+	require.NoError(t, m.Clear(ctx))
+}
+
+func TestVectorStoreRetrieverMemoryDefaults(t *testing.T) {
+	t.Parallel()
+	m := NewVectorStoreRetrieverMemory(&wordVectorStore{}, 0, WithReturnMessages(true), WithMemoryKey("relevant"))
+	require.Equal(t, defaultVectorStoreNumDocuments, m.NumDocuments)
+	require.True(t, m.ReturnDocuments)
+	require.Equal(t, "relevant", m.GetMemoryKey(context.Background()))
+
+	_, err := m.LoadMemoryVariables(context.Background(), map[string]any{"a": "1", "b": "2"})
+	require.ErrorIs(t, err, ErrInvalidInputValues)
+}
@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+var (
+	// ErrMetadataNotFound is returned by GetMeta when the document has no
+	// metadata value for the key.
+	ErrMetadataNotFound = errors.New("metadata key not found")
+	// ErrMetadataType is returned by GetMeta when the metadata value can't be
+	// converted to the requested type.
+	ErrMetadataType = errors.New("metadata value has the wrong type")
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// GetMeta returns the metadata value of doc for key as a T.
+//
+// Besides values already of type T, it converts the values produced by
+// decoding metadata from JSON, as vector stores returning JSONB metadata do:
+// numbers, decoded as float64 or json.Number, convert to any integer or float
+// type they fit in without loss, RFC 3339 strings convert to time.Time and
+// []any converts element by element to a slice type.
+func GetMeta[T any](doc Document, key string) (T, error) {
+	var result T
+	value, ok := doc.Metadata[key]
+	if !ok {
+		return result, fmt.Errorf("%w: %q", ErrMetadataNotFound, key)
+	}
+	if err := convertMeta(value, reflect.ValueOf(&result).Elem()); err != nil {
+		var zero T
+		return zero, fmt.Errorf("metadata %q: %w", key, err)
+	}
+	return result, nil
+}
+
+// convertMeta sets target to value, converting it as described by GetMeta.
+func convertMeta(value any, target reflect.Value) error {
+	if value == nil {
+		switch target.Kind() { // nolint:exhaustive
+		case reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
+			return nil
+		default:
+			return fmt.Errorf("%w: cannot convert null to %s", ErrMetadataType, target.Type())
+		}
+	}
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(target.Type()) {
+		target.Set(v)
+		return nil
+	}
+	if number, ok := value.(json.Number); ok {
+		if i, err := number.Int64(); err == nil {
+			return convertMeta(i, target)
+		}
+		f, err := number.Float64()
+		if err != nil {
+			return fmt.Errorf("%w: invalid number %q", ErrMetadataType, number)
+		}
+		return convertMeta(f, target)
+	}
+
+	mismatch := fmt.Errorf("%w: cannot convert %T to %s", ErrMetadataType, value, target.Type())
+	switch {
+	case target.Type() == timeType:
+		s, ok := value.(string)
+		if !ok {
+			return mismatch
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrMetadataType, err)
+		}
+		target.Set(reflect.ValueOf(t))
+		return nil
+	case isInt(target.Kind()):
+		i, ok := toInt64(v)
+		if !ok || target.OverflowInt(i) {
+			return mismatch
+		}
+		target.SetInt(i)
+		return nil
+	case isUint(target.Kind()):
+		i, ok := toInt64(v)
+		if !ok || i < 0 || target.OverflowUint(uint64(i)) {
+			return mismatch
+		}
+		target.SetUint(uint64(i))
+		return nil
+	case target.Kind() == reflect.Float32 || target.Kind() == reflect.Float64:
+		f, ok := toFloat64(v)
+		if !ok || target.OverflowFloat(f) {
+			return mismatch
+		}
+		target.SetFloat(f)
+		return nil
+	case target.Kind() == reflect.Slice && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array):
+		slice := reflect.MakeSlice(target.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if err := convertMeta(v.Index(i).Interface(), slice.Index(i)); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		target.Set(slice)
+		return nil
+	}
+	return mismatch
+}
+
+func isInt(k reflect.Kind) bool {
+	return k == reflect.Int || k == reflect.Int8 || k == reflect.Int16 || k == reflect.Int32 || k == reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+	return k == reflect.Uint || k == reflect.Uint8 || k == reflect.Uint16 || k == reflect.Uint32 || k == reflect.Uint64
+}
+
+// toInt64 returns the value of a number without a fractional part as an
+// int64.
+func toInt64(v reflect.Value) (int64, bool) {
+	switch {
+	case isInt(v.Kind()):
+		return v.Int(), true
+	case isUint(v.Kind()):
+		u := v.Uint()
+		return int64(u), u <= math.MaxInt64
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		f := v.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(f), true
+	}
+	return 0, false
+}
+
+// toFloat64 returns the value of a number as a float64.
+func toFloat64(v reflect.Value) (float64, bool) {
+	switch {
+	case isInt(v.Kind()):
+		return float64(v.Int()), true
+	case isUint(v.Kind()):
+		return float64(v.Uint()), true
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
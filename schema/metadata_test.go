@@ -0,0 +1,79 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMeta(t *testing.T) {
+	t.Parallel()
+	var decoded map[string]any
+	err := json.Unmarshal([]byte(`{
+		"population": 8336817,
+		"area": 783.8,
+		"city": "New York",
+		"founded": "1624-01-01T00:00:00Z",
+		"zip_codes": [10001, 10002],
+		"capital": null
+	}`), &decoded)
+	require.NoError(t, err)
+	doc := Document{Metadata: decoded}
+
+	population, err := GetMeta[int](doc, "population")
+	require.NoError(t, err)
+	require.Equal(t, 8336817, population)
+
+	area, err := GetMeta[float32](doc, "area")
+	require.NoError(t, err)
+	require.InDelta(t, 783.8, area, 0.001)
+
+	city, err := GetMeta[string](doc, "city")
+	require.NoError(t, err)
+	require.Equal(t, "New York", city)
+
+	founded, err := GetMeta[time.Time](doc, "founded")
+	require.NoError(t, err)
+	require.Equal(t, 1624, founded.Year())
+
+	zipCodes, err := GetMeta[[]uint32](doc, "zip_codes")
+	require.NoError(t, err)
+	require.Equal(t, []uint32{10001, 10002}, zipCodes)
+
+	capital, err := GetMeta[*string](doc, "capital")
+	require.NoError(t, err)
+	require.Nil(t, capital)
+
+	_, err = GetMeta[int](doc, "area")
+	require.ErrorIs(t, err, ErrMetadataType)
+
+	_, err = GetMeta[int8](doc, "population")
+	require.ErrorIs(t, err, ErrMetadataType)
+
+	_, err = GetMeta[bool](doc, "city")
+	require.ErrorIs(t, err, ErrMetadataType)
+
+	_, err = GetMeta[int](doc, "capital")
+	require.ErrorIs(t, err, ErrMetadataType)
+
+	_, err = GetMeta[int](doc, "mayor")
+	require.ErrorIs(t, err, ErrMetadataNotFound)
+}
+
+func TestGetMetaJSONNumber(t *testing.T) {
+	t.Parallel()
+	doc := Document{Metadata: map[string]any{"id": json.Number("9007199254740993"), "score": json.Number("0.5")}}
+
+	id, err := GetMeta[int64](doc, "id")
+	require.NoError(t, err)
+	require.Equal(t, int64(9007199254740993), id)
+
+	score, err := GetMeta[float64](doc, "score")
+	require.NoError(t, err)
+	require.InDelta(t, 0.5, score, 0)
+
+	_, err = GetMeta[int](doc, "score")
+	require.ErrorIs(t, err, ErrMetadataType)
+}
@@ -0,0 +1,259 @@
+// Package alloydb provides a sqldatabase.Engine for AlloyDB, for the SQL
+// database chain to answer questions over the tables of the database storing
+// the vectors:
+//
+//	engine, err := alloydb.NewAlloyDB(pgEngine)
+//	...
+//	db, err := sqldatabase.NewSQLDatabase(engine, nil)
+//	...
+//	chain := chains.NewSQLDatabaseChain(llm, 10, db)
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tmc/langchaingo/tools/sqldatabase"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+const (
+	// Dialect is the dialect of the AlloyDB engine, used by the SQL database
+	// chain to generate queries.
+	Dialect = "postgresql"
+
+	defaultSchemaName     = "public"
+	defaultMaxValueLength = 100
+)
+
+var _ sqldatabase.Engine = &AlloyDB{}
+
+// AlloyDB is a sqldatabase.Engine running the queries through the pools of
+// an alloydbutil.PostgresEngine, e.g. the one of a vector store, so that
+// the SQL database chain can answer questions over the same database.
+type AlloyDB struct {
+	engine         alloydbutil.PostgresEngine
+	schemaName     string
+	allowWrites    bool
+	maxValueLength int
+}
+
+// Option is a function for creating a new AlloyDB engine with other than the
+// default values.
+type Option func(a *AlloyDB)
+
+// WithSchemaName sets the schema of the tables. It defaults to "public", the
+// tables of other schemas are named schema.table.
+func WithSchemaName(schemaName string) Option {
+	return func(a *AlloyDB) {
+		a.schemaName = schemaName
+	}
+}
+
+// WithWrites runs the queries in read-write transactions on the primary
+// instance. By default, the queries, generated by an LLM, run in read-only
+// transactions on the read pool instance, if any.
+func WithWrites() Option {
+	return func(a *AlloyDB) {
+		a.allowWrites = true
+	}
+}
+
+// WithMaxValueLength sets the number of characters after which the values
+// of the query results are truncated, so that e.g. embeddings don't fill the
+// prompts with their sample rows. It defaults to 100, 0 disables truncation.
+func WithMaxValueLength(maxValueLength int) Option {
+	return func(a *AlloyDB) {
+		a.maxValueLength = maxValueLength
+	}
+}
+
+// NewAlloyDB creates a new AlloyDB engine. The engine isn't closed by Close,
+// as it is usually shared with the vector stores and chat histories.
+func NewAlloyDB(engine alloydbutil.PostgresEngine, opts ...Option) (*AlloyDB, error) {
+	a := &AlloyDB{
+		engine:         engine,
+		schemaName:     defaultSchemaName,
+		maxValueLength: defaultMaxValueLength,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.engine.Pool == nil {
+		return nil, errors.New("missing engine")
+	}
+	if a.schemaName == "" {
+		return nil, errors.New("missing schema name")
+	}
+	if a.maxValueLength < 0 {
+		return nil, fmt.Errorf("negative max value length %d", a.maxValueLength)
+	}
+	return a, nil
+}
+
+// Dialect returns the dialect of the AlloyDB engine.
+func (a *AlloyDB) Dialect() string {
+	return Dialect
+}
+
+// Query executes query and returns the column names and the results as
+// strings, NULL values being empty.
+func (a *AlloyDB) Query(ctx context.Context, query string, args ...any) ([]string, [][]string, error) {
+	var (
+		cols    []string
+		results [][]string
+	)
+	err := a.run(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		cols = cols[:0]
+		for _, field := range rows.FieldDescriptions() {
+			cols = append(cols, field.Name)
+		}
+		results = make([][]string, 0)
+		for rows.Next() {
+			values, err := rows.Values()
+			if err != nil {
+				return err
+			}
+			row := make([]string, len(values))
+			for i, value := range values {
+				row[i] = a.formatValue(value)
+			}
+			results = append(results, row)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	return cols, results, nil
+}
+
+// TableNames returns the names of the tables of the schema.
+func (a *AlloyDB) TableNames(ctx context.Context) ([]string, error) {
+	_, results, err := a.Query(ctx,
+		`SELECT table_name FROM information_schema.tables WHERE table_schema = $1 AND table_type = 'BASE TABLE' ORDER BY table_name`,
+		a.schemaName)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(results))
+	for _, row := range results {
+		if a.schemaName == defaultSchemaName {
+			names = append(names, row[0])
+			continue
+		}
+		names = append(names, a.schemaName+"."+row[0])
+	}
+	return names, nil
+}
+
+// TableInfo returns the CREATE TABLE statement of table, with the types of
+// its columns and its primary key.
+func (a *AlloyDB) TableInfo(ctx context.Context, table string) (string, error) {
+	schemaName, tableName := a.splitTableName(table)
+	qualified := fmt.Sprintf("%q.%q", schemaName, tableName)
+	_, columns, err := a.Query(ctx, `SELECT a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull
+		FROM pg_attribute a
+		WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, qualified)
+	if err != nil {
+		return "", err
+	}
+	if len(columns) == 0 {
+		return "", fmt.Errorf("%w: %s", sqldatabase.ErrTableNotFound, table)
+	}
+	_, primaryKey, err := a.Query(ctx, `SELECT a.attname
+		FROM pg_index i JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = to_regclass($1) AND i.indisprimary
+		ORDER BY array_position(i.indkey, a.attnum)`, qualified)
+	if err != nil {
+		return "", err
+	}
+	return createTableStatement(qualified, columns, primaryKey), nil
+}
+
+// Close does nothing, the engine is closed by its owner.
+func (a *AlloyDB) Close() error {
+	return nil
+}
+
+// run runs fn in a read-only transaction on the read pool, or in a
+// read-write transaction on the primary instance when writes are allowed.
+func (a *AlloyDB) run(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	if a.allowWrites {
+		return a.engine.WithTx(ctx, fn)
+	}
+	return a.engine.Read(ctx, func(ctx context.Context, pool *pgxpool.Pool) error {
+		return alloydbutil.ReadOnlyTx(ctx, pool, fn)
+	})
+}
+
+// splitTableName returns the schema and the name of a table returned by
+// TableNames.
+func (a *AlloyDB) splitTableName(table string) (string, string) {
+	if schemaName, tableName, ok := strings.Cut(table, "."); ok {
+		return schemaName, tableName
+	}
+	return a.schemaName, table
+}
+
+// formatValue formats a value of the query results, truncated to
+// maxValueLength characters.
+func (a *AlloyDB) formatValue(value any) string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case time.Time:
+		s = v.Format(time.RFC3339Nano)
+	default:
+		s = fmt.Sprint(v)
+	}
+	if a.maxValueLength == 0 {
+		return s
+	}
+	if runes := []rune(s); len(runes) > a.maxValueLength {
+		return string(runes[:a.maxValueLength]) + "..."
+	}
+	return s
+}
+
+// createTableStatement returns the CREATE TABLE statement of a table with
+// the given rows of name, type and not null columns, and the given primary
+// key rows.
+func createTableStatement(qualified string, columns, primaryKey [][]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE %s (", qualified)
+	for i, column := range columns {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "\n\t%q %s", column[0], column[1])
+		if column[2] == "true" {
+			b.WriteString(" NOT NULL")
+		}
+	}
+	if len(primaryKey) > 0 {
+		names := make([]string, len(primaryKey))
+		for i, row := range primaryKey {
+			names[i] = fmt.Sprintf("%q", row[0])
+		}
+		fmt.Fprintf(&b, ",\n\tPRIMARY KEY (%s)", strings.Join(names, ", "))
+	}
+	b.WriteString("\n)")
+	return b.String()
+}
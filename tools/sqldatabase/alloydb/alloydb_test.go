@@ -0,0 +1,50 @@
+package alloydb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+func TestNewAlloyDBValidation(t *testing.T) {
+	t.Parallel()
+	_, err := NewAlloyDB(alloydbutil.PostgresEngine{})
+	require.ErrorContains(t, err, "missing engine")
+}
+
+func TestFormatValue(t *testing.T) {
+	t.Parallel()
+	a := &AlloyDB{maxValueLength: 5}
+	require.Equal(t, "", a.formatValue(nil))
+	require.Equal(t, "abc", a.formatValue("abc"))
+	require.Equal(t, "abc", a.formatValue([]byte("abc")))
+	require.Equal(t, "42", a.formatValue(42))
+	require.Equal(t, "ééééé...", a.formatValue(strings.Repeat("é", 6)))
+	a.maxValueLength = 0
+	require.Equal(t, "2024-01-02T03:04:05Z", a.formatValue(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+}
+
+func TestSplitTableName(t *testing.T) {
+	t.Parallel()
+	a := &AlloyDB{schemaName: "analytics"}
+	schemaName, tableName := a.splitTableName("events")
+	require.Equal(t, []string{"analytics", "events"}, []string{schemaName, tableName})
+	schemaName, tableName = a.splitTableName("public.users")
+	require.Equal(t, []string{"public", "users"}, []string{schemaName, tableName})
+}
+
+func TestCreateTableStatement(t *testing.T) {
+	t.Parallel()
+	statement := createTableStatement(`"public"."docs"`,
+		[][]string{{"id", "uuid", "true"}, {"content", "text", "false"}, {"embedding", "vector(768)", "false"}},
+		[][]string{{"id"}})
+	require.Equal(t, `CREATE TABLE "public"."docs" (
+	"id" uuid NOT NULL,
+	"content" text,
+	"embedding" vector(768),
+	PRIMARY KEY ("id")
+)`, statement)
+}
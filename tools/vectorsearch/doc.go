@@ -0,0 +1,4 @@
+// Package vectorsearch contains an implementation of the tool interface
+// searching the documents of a vector store, so that agents can decide when
+// to search a knowledge base.
+package vectorsearch
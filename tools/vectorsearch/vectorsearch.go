@@ -0,0 +1,190 @@
+package vectorsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/tools"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+const (
+	_defaultNumDocuments = 4
+	_noDocumentsResult   = "No documents found."
+)
+
+var (
+	// ErrInvalidName is returned by New when the name of the tool can't be
+	// the name of a function called by an LLM.
+	ErrInvalidName = errors.New("tool name must be 1 to 64 letters, digits, underscores or dashes")
+	// ErrMissingDescription is returned by New when the description of the
+	// tool is empty.
+	ErrMissingDescription = errors.New("missing tool description")
+
+	nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`) //nolint:gochecknoglobals
+)
+
+// Tool is an implementation of the tool interface that searches the
+// documents of a vector store similar to its input, and returns them as
+// numbered snippets followed by their source metadata.
+type Tool struct {
+	CallbacksHandler callbacks.Handler
+	retriever        vectorstores.Retriever
+	name             string
+	description      string
+	metadataKeys     []string
+}
+
+var _ tools.Tool = Tool{}
+
+// Option is a function for creating a new Tool with other than the default
+// values.
+type Option func(o *options)
+
+type options struct {
+	numDocuments  int
+	filters       any
+	metadataKeys  []string
+	searchOptions []vectorstores.Option
+}
+
+// WithNumDocuments sets the number of documents returned by a search. It
+// defaults to 4.
+func WithNumDocuments(numDocuments int) Option {
+	return func(o *options) {
+		o.numDocuments = numDocuments
+	}
+}
+
+// WithFilters sets the filters of the searches, in the format of the vector
+// store, e.g. a SQL condition for the AlloyDB vector store.
+func WithFilters(filters any) Option {
+	return func(o *options) {
+		o.filters = filters
+	}
+}
+
+// WithMetadataKeys sets the metadata keys of the documents included in the
+// result, e.g. "source" and "page". All the metadata is included by default.
+func WithMetadataKeys(keys ...string) Option {
+	return func(o *options) {
+		o.metadataKeys = keys
+	}
+}
+
+// WithSearchOptions sets other options of the searches, e.g. a score
+// threshold.
+func WithSearchOptions(opts ...vectorstores.Option) Option {
+	return func(o *options) {
+		o.searchOptions = opts
+	}
+}
+
+// New creates a new tool searching store. The name and the description tell
+// the agent when to use the tool, e.g. "search_product_docs" and "Searches
+// the product documentation. Input should be a search query.".
+func New(store vectorstores.VectorStore, name, description string, opts ...Option) (Tool, error) {
+	o := options{numDocuments: _defaultNumDocuments}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if store == nil {
+		return Tool{}, errors.New("missing vector store")
+	}
+	if !nameRegexp.MatchString(name) {
+		return Tool{}, fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	if strings.TrimSpace(description) == "" {
+		return Tool{}, ErrMissingDescription
+	}
+	if o.numDocuments <= 0 {
+		return Tool{}, fmt.Errorf("invalid number of documents %d", o.numDocuments)
+	}
+
+	searchOptions := o.searchOptions
+	if o.filters != nil {
+		searchOptions = append([]vectorstores.Option{vectorstores.WithFilters(o.filters)}, searchOptions...)
+	}
+	return Tool{
+		retriever:    vectorstores.ToRetriever(store, o.numDocuments, searchOptions...),
+		name:         name,
+		description:  description,
+		metadataKeys: o.metadataKeys,
+	}, nil
+}
+
+// Name returns the name of the tool.
+func (t Tool) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool.
+func (t Tool) Description() string {
+	return t.description
+}
+
+// Call searches the documents similar to input.
+func (t Tool) Call(ctx context.Context, input string) (string, error) {
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolStart(ctx, input)
+	}
+
+	docs, err := t.retriever.GetRelevantDocuments(ctx, input)
+	if err != nil {
+		if t.CallbacksHandler != nil {
+			t.CallbacksHandler.HandleToolError(ctx, err)
+		}
+		return "", err
+	}
+	result := t.format(docs)
+
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolEnd(ctx, result)
+	}
+
+	return result, nil
+}
+
+// format returns the documents as numbered snippets, each followed by its
+// metadata.
+func (t Tool) format(docs []schema.Document) string {
+	if len(docs) == 0 {
+		return _noDocumentsResult
+	}
+	var b strings.Builder
+	for i, doc := range docs {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "[%d] %s", i+1, strings.TrimSpace(doc.PageContent))
+		if source := t.source(doc.Metadata); source != "" {
+			fmt.Fprintf(&b, "\nSource: %s", source)
+		}
+	}
+	return b.String()
+}
+
+// source returns the metadata of a document as key=value pairs.
+func (t Tool) source(metadata map[string]any) string {
+	keys := t.metadataKeys
+	if keys == nil {
+		keys = make([]string, 0, len(metadata))
+		for key := range metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if value, ok := metadata[key]; ok {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", key, value))
+		}
+	}
+	return strings.Join(pairs, ", ")
+}
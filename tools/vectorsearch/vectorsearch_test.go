@@ -0,0 +1,90 @@
+package vectorsearch
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+type fakeStore struct {
+	docs         []schema.Document
+	err          error
+	numDocuments int
+	options      vectorstores.Options
+}
+
+func (s *fakeStore) AddDocuments(context.Context, []schema.Document, ...vectorstores.Option) ([]string, error) {
+	return nil, nil
+}
+
+func (s *fakeStore) SimilaritySearch(_ context.Context, _ string, numDocuments int, options ...vectorstores.Option) ([]schema.Document, error) { // nolint: lll
+	s.numDocuments = numDocuments
+	for _, opt := range options {
+		opt(&s.options)
+	}
+	return s.docs, s.err
+}
+
+func TestToolCall(t *testing.T) {
+	t.Parallel()
+	store := &fakeStore{docs: []schema.Document{
+		{PageContent: " AlloyDB is a database.\n", Metadata: map[string]any{"source": "a.md", "page": 2}},
+		{PageContent: "No metadata."},
+	}}
+	tool, err := New(store, "search_docs", "Searches the docs.",
+		WithNumDocuments(2), WithFilters("topic = 'db'"), WithSearchOptions(vectorstores.WithScoreThreshold(0.5)))
+	require.NoError(t, err)
+	require.Equal(t, "search_docs", tool.Name())
+	require.Equal(t, "Searches the docs.", tool.Description())
+
+	result, err := tool.Call(context.Background(), "what is alloydb?")
+	require.NoError(t, err)
+	require.Equal(t, "[1] AlloyDB is a database.\nSource: page=2, source=a.md\n\n[2] No metadata.", result)
+	require.Equal(t, 2, store.numDocuments)
+	require.Equal(t, "topic = 'db'", store.options.Filters)
+	require.InDelta(t, 0.5, store.options.ScoreThreshold, 1e-6)
+}
+
+func TestToolCallMetadataKeys(t *testing.T) {
+	t.Parallel()
+	store := &fakeStore{docs: []schema.Document{
+		{PageContent: "content", Metadata: map[string]any{"source": "a.md", "page": 2, "hash": "x"}},
+	}}
+	tool, err := New(store, "search", "Searches.", WithMetadataKeys("source", "title"))
+	require.NoError(t, err)
+	result, err := tool.Call(context.Background(), "query")
+	require.NoError(t, err)
+	require.Equal(t, "[1] content\nSource: source=a.md", result)
+	require.Equal(t, _defaultNumDocuments, store.numDocuments)
+}
+
+func TestToolCallNoDocuments(t *testing.T) {
+	t.Parallel()
+	tool, err := New(&fakeStore{}, "search", "Searches.")
+	require.NoError(t, err)
+	result, err := tool.Call(context.Background(), "query")
+	require.NoError(t, err)
+	require.Equal(t, _noDocumentsResult, result)
+
+	errSearch := errors.New("search failed")
+	tool, err = New(&fakeStore{err: errSearch}, "search", "Searches.")
+	require.NoError(t, err)
+	_, err = tool.Call(context.Background(), "query")
+	require.ErrorIs(t, err, errSearch)
+}
+
+func TestNewValidation(t *testing.T) {
+	t.Parallel()
+	_, err := New(&fakeStore{}, "search docs", "Searches.")
+	require.ErrorIs(t, err, ErrInvalidName)
+	_, err = New(&fakeStore{}, "search", " ")
+	require.ErrorIs(t, err, ErrMissingDescription)
+	_, err = New(&fakeStore{}, "search", "Searches.", WithNumDocuments(0))
+	require.Error(t, err)
+	_, err = New(nil, "search", "Searches.")
+	require.Error(t, err)
+}
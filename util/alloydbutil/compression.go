@@ -0,0 +1,53 @@
+package alloydbutil
+
+import (
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressor compresses the payloads stored by the chat message histories
+// and document stores, e.g. verbose agent traces or large documents. The
+// compressed payloads are stored in BYTEA columns.
+type Compressor interface {
+	// Name identifies the compression of the payloads it compressed, e.g.
+	// "zstd".
+	Name() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// zstdCompressor is a Compressor using Zstandard.
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+// NewZstdCompressor returns a Compressor using Zstandard, safe for concurrent
+// use.
+func NewZstdCompressor() (Compressor, error) {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd decoder: %w", err)
+	}
+	return zstdCompressor{encoder: encoder, decoder: decoder}, nil
+}
+
+// Name implements Compressor.
+func (zstdCompressor) Name() string {
+	return "zstd"
+}
+
+// Compress implements Compressor.
+func (z zstdCompressor) Compress(data []byte) ([]byte, error) {
+	return z.encoder.EncodeAll(data, nil), nil
+}
+
+// Decompress implements Compressor.
+func (z zstdCompressor) Decompress(data []byte) ([]byte, error) {
+	return z.decoder.DecodeAll(data, nil)
+}
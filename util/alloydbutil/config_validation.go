@@ -0,0 +1,270 @@
+package alloydbutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ConfigError is a configuration problem along with a hint on how to fix it.
+// Validation functions return all the problems found, joined with
+// errors.Join; use errors.As to inspect the first one.
+type ConfigError struct {
+	// Field is the option or field with the problem.
+	Field string
+	// Problem describes what is wrong.
+	Problem string
+	// Hint describes how to fix it.
+	Hint string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", e.Field, e.Problem, e.Hint)
+}
+
+// ValidateOptions checks the engine options without connecting, returning
+// all the configuration problems found.
+func ValidateOptions(opts ...Option) error {
+	_, err := applyClientOptions(opts...)
+	return err
+}
+
+// validate returns the problems of the engine configuration, joined.
+func (c engineConfig) validate() error {
+	var problems []error
+	if c.connPool == nil {
+		primary := alloyDBInstance{projectID: c.projectID, region: c.region, cluster: c.cluster, instance: c.instance}
+		if missing := primary.missingFields(); len(missing) > 0 {
+			problems = append(problems, &ConfigError{
+				Field:   "WithAlloyDBInstance",
+				Problem: fmt.Sprintf("missing connection fields: %s", strings.Join(missing, ", ")),
+				Hint:    "provide all of the project ID, region, cluster and instance, or an existing pool with WithPool",
+			})
+		}
+		if c.database == "" {
+			problems = append(problems, &ConfigError{
+				Field:   "WithDatabase",
+				Problem: "missing database name",
+				Hint:    `set the database to connect to, e.g. WithDatabase("postgres")`,
+			})
+		}
+	}
+	if c.readInstance != nil {
+		if missing := c.readInstance.missingFields(); len(missing) > 0 {
+			problems = append(problems, &ConfigError{
+				Field:   "WithReadInstance",
+				Problem: fmt.Sprintf("missing connection fields: %s", strings.Join(missing, ", ")),
+				Hint:    "provide all of the project ID, region, cluster and read pool instance",
+			})
+		}
+		if c.connPool != nil {
+			problems = append(problems, &ConfigError{
+				Field:   "WithReadInstance",
+				Problem: "read instance set with an existing pool",
+				Hint:    "connect with WithAlloyDBInstance instead of WithPool to use a read instance",
+			})
+		}
+	}
+	problems = append(problems, c.validateFailover()...)
+	problems = append(problems, c.validateTLS()...)
+	if c.ipType != "PUBLIC" && c.ipType != "PRIVATE" {
+		problems = append(problems, &ConfigError{
+			Field:   "WithIPType",
+			Problem: fmt.Sprintf("invalid IP type %q", c.ipType),
+			Hint:    `use "PUBLIC" or "PRIVATE"`,
+		})
+	}
+	switch {
+	case c.iamAccountEmail != "" && (c.user != "" || c.password != ""):
+		problems = append(problems, &ConfigError{
+			Field:   "WithIAMAccountEmail",
+			Problem: "both IAM and built-in database authentication are configured",
+			Hint:    "use either WithIAMAccountEmail or WithUser and WithPassword",
+		})
+	case c.user != "" && c.password == "":
+		problems = append(problems, &ConfigError{
+			Field:   "WithPassword",
+			Problem: "user set without a password",
+			Hint:    "set the password with WithPassword, or use WithIAMAccountEmail for IAM authentication",
+		})
+	case c.user == "" && c.password != "":
+		problems = append(problems, &ConfigError{
+			Field:   "WithUser",
+			Problem: "password set without a user",
+			Hint:    "set the database user with WithUser",
+		})
+	}
+	if c.retryPolicy.MaxAttempts > 1 && c.retryPolicy.InitialBackoff <= 0 {
+		problems = append(problems, &ConfigError{
+			Field:   "WithRetryPolicy",
+			Problem: "retries enabled without a backoff",
+			Hint:    "set InitialBackoff, or start from DefaultRetryPolicy()",
+		})
+	}
+	return errors.Join(problems...)
+}
+
+// validateFailover returns the problems of the secondary instance and the
+// failover policy.
+func (c engineConfig) validateFailover() []error {
+	var problems []error
+	if c.secondaryInstance == nil {
+		if c.failoverPolicy != nil {
+			problems = append(problems, &ConfigError{
+				Field:   "WithFailoverPolicy",
+				Problem: "failover policy set without a secondary instance",
+				Hint:    "set the instance to fail over to with WithSecondaryInstance",
+			})
+		}
+		return problems
+	}
+	if missing := c.secondaryInstance.missingFields(); len(missing) > 0 {
+		problems = append(problems, &ConfigError{
+			Field:   "WithSecondaryInstance",
+			Problem: fmt.Sprintf("missing connection fields: %s", strings.Join(missing, ", ")),
+			Hint:    "provide all of the project ID, region, cluster and instance of the secondary cluster",
+		})
+	}
+	if c.connPool != nil {
+		problems = append(problems, &ConfigError{
+			Field:   "WithSecondaryInstance",
+			Problem: "secondary instance set with an existing pool",
+			Hint:    "connect with WithAlloyDBInstance instead of WithPool to fail over to a secondary instance",
+		})
+	}
+	if c.cluster != "" && c.secondaryInstance.cluster == c.cluster && c.secondaryInstance.region == c.region {
+		problems = append(problems, &ConfigError{
+			Field:   "WithSecondaryInstance",
+			Problem: "secondary instance is in the primary cluster",
+			Hint:    "use an instance of a secondary cluster, or WithReadInstance for a read pool instance",
+		})
+	}
+	return problems
+}
+
+// validateTLS returns the problems of the sslmode, certificates and TLS
+// configuration.
+func (c engineConfig) validateTLS() []error {
+	var problems []error
+	switch c.sslMode {
+	case "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		problems = append(problems, &ConfigError{
+			Field:   "WithSSLMode",
+			Problem: fmt.Sprintf("invalid sslmode %q", c.sslMode),
+			Hint:    `use "disable", "allow", "prefer", "require", "verify-ca" or "verify-full"`,
+		})
+	}
+	if c.sslMode == "disable" && (c.tlsConfig != nil || c.sslRootCert != "" || c.sslCert != "" || c.sslKey != "") {
+		problems = append(problems, &ConfigError{
+			Field:   "WithSSLMode",
+			Problem: "TLS is configured with sslmode disable",
+			Hint:    `use sslmode "require" or stricter, or remove the TLS options`,
+		})
+	}
+	if c.sslMode == "verify-full" && c.tlsConfig == nil {
+		problems = append(problems, &ConfigError{
+			Field:   "WithSSLMode",
+			Problem: "sslmode verify-full requires the server name",
+			Hint:    "set the server name with WithTLSConfig, or use verify-ca",
+		})
+	}
+	if (c.sslCert == "") != (c.sslKey == "") {
+		problems = append(problems, &ConfigError{
+			Field:   "WithSSLCertificates",
+			Problem: "client certificate set without its key, or the reverse",
+			Hint:    "set both the client certificate and key paths",
+		})
+	}
+	return problems
+}
+
+// missingFields returns the names of the unset fields of the instance.
+func (i alloyDBInstance) missingFields() []string {
+	missing := []string{}
+	for _, field := range []struct{ name, value string }{
+		{"project ID", i.projectID},
+		{"region", i.region},
+		{"cluster", i.cluster},
+		{"instance", i.instance},
+	} {
+		if field.value == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	return missing
+}
+
+// Validate returns all the problems of the options, without applying the
+// defaults.
+func (o VectorstoreTableOptions) Validate() error {
+	var problems []error
+	if o.TableName == "" {
+		problems = append(problems, &ConfigError{
+			Field:   "TableName",
+			Problem: "missing table name",
+			Hint:    "set the name of the table storing the documents",
+		})
+	}
+	if o.VectorSize <= 0 {
+		problems = append(problems, &ConfigError{
+			Field:   "VectorSize",
+			Problem: fmt.Sprintf("invalid vector size %d", o.VectorSize),
+			Hint:    "set it to the dimension of the embeddings, e.g. 768 for text-embedding-005",
+		})
+	}
+	if o.IDColumn.DataType != "" {
+		if err := validateDataType(o.IDColumn.DataType); err != nil {
+			problems = append(problems, &ConfigError{
+				Field:   "IDColumn",
+				Problem: err.Error(),
+				Hint:    "use a plain type name such as UUID or TEXT",
+			})
+		}
+	}
+	for _, column := range o.MetadataColumns {
+		if column.Name == "" {
+			problems = append(problems, &ConfigError{
+				Field:   "MetadataColumns",
+				Problem: "column without a name",
+				Hint:    "name every metadata column",
+			})
+		}
+		if err := validateDataType(column.DataType); err != nil {
+			problems = append(problems, &ConfigError{
+				Field:   fmt.Sprintf("MetadataColumns[%q]", column.Name),
+				Problem: err.Error(),
+				Hint:    "use a plain type name such as TEXT, INT or VARCHAR(64)",
+			})
+		}
+	}
+	if o.EmbeddingType != "" && !o.EmbeddingType.Valid() {
+		problems = append(problems, &ConfigError{
+			Field:   "EmbeddingType",
+			Problem: fmt.Sprintf("unsupported embedding type %q", o.EmbeddingType),
+			Hint:    "use EmbeddingTypeVector, EmbeddingTypeHalfVec, EmbeddingTypeBit or EmbeddingTypeSparseVec",
+		})
+	}
+	embeddingColumns := map[string]bool{o.EmbeddingColumn: true}
+	if o.EmbeddingColumn == "" {
+		embeddingColumns["embedding"] = true
+	}
+	for _, column := range o.AdditionalEmbeddingColumns {
+		if column == "" || embeddingColumns[column] {
+			problems = append(problems, &ConfigError{
+				Field:   "AdditionalEmbeddingColumns",
+				Problem: fmt.Sprintf("empty or duplicate embedding column name %q", column),
+				Hint:    "give every embedding column a distinct name",
+			})
+		}
+		embeddingColumns[column] = true
+	}
+	if o.AddMissingColumns && !o.IfNotExists {
+		problems = append(problems, &ConfigError{
+			Field:   "AddMissingColumns",
+			Problem: "columns can only be added to an existing table kept with IfNotExists",
+			Hint:    "set IfNotExists as well",
+		})
+	}
+	return errors.Join(problems...)
+}
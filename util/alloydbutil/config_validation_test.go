@@ -0,0 +1,174 @@
+package alloydbutil
+
+import (
+	"crypto/tls"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateOptions(t *testing.T) {
+	t.Parallel()
+	err := ValidateOptions(
+		WithAlloyDBInstance("project", "", "cluster", ""),
+		WithIPType("INTERNAL"),
+		WithUser("user"),
+		WithIAMAccountEmail("sa@project.iam.gserviceaccount.com"),
+		WithReadInstance("project", "region", "cluster", ""),
+	)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		"missing connection fields: region, instance",
+		"missing database name",
+		`invalid IP type "INTERNAL"`,
+		"both IAM and built-in database authentication are configured",
+		"WithReadInstance: missing connection fields: instance",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %q", want, err)
+		}
+	}
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Hint == "" {
+		t.Errorf("expected a ConfigError with a hint, got %v", err)
+	}
+
+	err = ValidateOptions(
+		WithAlloyDBInstance("project", "region", "cluster", "instance"),
+		WithDatabase("postgres"),
+		WithUser("user"),
+		WithPassword("password"),
+		WithReadInstance("project", "region", "cluster", "read-pool"),
+	)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateFailoverOptions(t *testing.T) {
+	t.Parallel()
+	base := []Option{
+		WithAlloyDBInstance("project", "us-central1", "cluster", "instance"),
+		WithDatabase("postgres"),
+		WithUser("user"),
+		WithPassword("password"),
+	}
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "secondary instance",
+			opts: []Option{WithSecondaryInstance("project", "us-east1", "secondary", "instance"), WithFailoverPolicy(FailoverPolicy{})},
+		},
+		{
+			name: "missing fields",
+			opts: []Option{WithSecondaryInstance("project", "us-east1", "", "instance")},
+			want: "WithSecondaryInstance: missing connection fields: cluster",
+		},
+		{
+			name: "primary cluster",
+			opts: []Option{WithSecondaryInstance("project", "us-central1", "cluster", "other")},
+			want: "secondary instance is in the primary cluster",
+		},
+		{
+			name: "policy without secondary instance",
+			opts: []Option{WithFailoverPolicy(FailoverPolicy{})},
+			want: "failover policy set without a secondary instance",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateOptions(append(append([]Option{}, base...), tt.opts...)...)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error to contain %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidateTLSOptions(t *testing.T) {
+	t.Parallel()
+	base := []Option{
+		WithAlloyDBInstance("project", "region", "cluster", "instance"),
+		WithDatabase("postgres"),
+		WithUser("user"),
+		WithPassword("password"),
+	}
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "invalid sslmode", opts: []Option{WithSSLMode("on")}, want: `invalid sslmode "on"`},
+		{
+			name: "TLS with sslmode disable",
+			opts: []Option{WithSSLMode("disable"), WithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12})},
+			want: "TLS is configured with sslmode disable",
+		},
+		{name: "verify-full without server name", opts: []Option{WithSSLMode("verify-full")}, want: "requires the server name"},
+		{
+			name: "client certificate without key",
+			opts: []Option{WithSSLCertificates("", "client.pem", "")},
+			want: "client certificate set without its key",
+		},
+		{
+			name: "valid",
+			opts: []Option{WithSSLMode("verify-ca"), WithSSLCertificates("ca.pem", "client.pem", "client.key")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateOptions(append(append([]Option{}, base...), tt.opts...)...)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error to contain %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestVectorstoreTableOptionsValidate(t *testing.T) {
+	t.Parallel()
+	err := VectorstoreTableOptions{
+		MetadataColumns:            []Column{{Name: "area", DataType: "int; DROP TABLE items"}},
+		AddMissingColumns:          true,
+		EmbeddingType:              "float8[]",
+		AdditionalEmbeddingColumns: []string{"title_embedding", "embedding"},
+	}.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		"TableName: missing table name",
+		"VectorSize: invalid vector size 0",
+		`MetadataColumns["area"]: invalid data type`,
+		"AddMissingColumns",
+		`unsupported embedding type "float8[]"`,
+		`duplicate embedding column name "embedding"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %q", want, err)
+		}
+	}
+
+	if err := (VectorstoreTableOptions{TableName: "items", VectorSize: 768}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
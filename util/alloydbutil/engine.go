@@ -5,9 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strings"
+	"sync"
 
 	"cloud.google.com/go/alloydbconn"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/metric"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/oauth2/v2"
 	"google.golang.org/api/option"
@@ -17,6 +22,26 @@ type EmailRetriever func(context.Context) (string, error)
 
 type PostgresEngine struct {
 	Pool *pgxpool.Pool
+
+	// readPool connects to the read pool instance set with
+	// WithReadInstance, if any.
+	readPool    *pgxpool.Pool
+	retryPolicy RetryPolicy
+	// faults fails the attempts of Retry and Read, if set with
+	// WithQueryFaults.
+	faults              *faultInjector
+	usingIAMAuth        bool
+	metricsRegistration metric.Registration
+
+	// primaryURI is the URI of the primary instance the pool was created
+	// for.
+	primaryURI string
+	// failover switches the pool between the primary instance and the
+	// secondary instance set with WithSecondaryInstance, if any.
+	failover *failover
+	// probePools check the health of the primary and secondary instances
+	// for failover.
+	probePools []*pgxpool.Pool
 }
 
 type Column struct {
@@ -32,31 +57,119 @@ func NewPostgresEngine(ctx context.Context, opts ...Option) (PostgresEngine, err
 	if err != nil {
 		return PostgresEngine{}, err
 	}
-	if cfg.connPool == nil {
-		user, usingIAMAuth, err := getUser(ctx, cfg)
-		if err != nil {
-			return PostgresEngine{}, fmt.Errorf("error assigning user. Err: %w", err)
+	// The engine closes its pools on errors, but not the one of WithPool.
+	ownsPool := cfg.connPool == nil
+	if ownsPool {
+		usingIAMAuth := cfg.password == ""
+		if cfg.lazyConnect && usingIAMAuth && cfg.iamAccountEmail == "" {
+			// The IAM principal is retrieved when the first connection is made.
+			cfg.resolveUser = lazyUser(cfg.emailRetreiver)
+		} else {
+			user, iamAuth, err := getUser(ctx, cfg)
+			if err != nil {
+				return PostgresEngine{}, fmt.Errorf("error assigning user. Err: %w", err)
+			}
+			if iamAuth {
+				cfg.user = user
+			}
+			usingIAMAuth = iamAuth
 		}
-		if usingIAMAuth {
-			cfg.user = user
+		pgEngine.usingIAMAuth = usingIAMAuth
+		primary := alloyDBInstance{projectID: cfg.projectID, region: cfg.region, cluster: cfg.cluster, instance: cfg.instance}
+		pgEngine.primaryURI = primary.uri()
+		var target dialTarget = primary
+		if cfg.secondaryInstance != nil {
+			var policy FailoverPolicy
+			if cfg.failoverPolicy != nil {
+				policy = *cfg.failoverPolicy
+			}
+			pgEngine.failover = newFailover(primary, *cfg.secondaryInstance, policy)
+			target = pgEngine.failover
 		}
-		cfg.connPool, err = createPool(ctx, cfg, usingIAMAuth)
+		cfg.connPool, err = createPool(ctx, cfg, target, usingIAMAuth)
 		if err != nil {
 			return PostgresEngine{}, err
 		}
+		if err := pgEngine.createSecondaryPools(ctx, cfg, usingIAMAuth); err != nil {
+			cfg.connPool.Close()
+			pgEngine.Close()
+			return PostgresEngine{}, err
+		}
+		if !cfg.lazyConnect {
+			err := cfg.connPool.Ping(ctx)
+			if err != nil && pgEngine.failover != nil && pgEngine.failover.trySwitch(ctx, secondaryInstance) == nil {
+				err = cfg.connPool.Ping(ctx)
+			}
+			if err != nil {
+				cfg.connPool.Close()
+				pgEngine.Close()
+				return PostgresEngine{}, fmt.Errorf("failed to connect to AlloyDB instance: %w", err)
+			}
+		}
+		if pgEngine.failover != nil {
+			pgEngine.failover.reset = cfg.connPool.Reset
+			pgEngine.failover.start()
+		}
 	}
 	pgEngine.Pool = cfg.connPool
+	pgEngine.retryPolicy = cfg.retryPolicy
+	if cfg.queryFaults != nil && cfg.queryFaults.ErrorRate > 0 {
+		pgEngine.faults = newFaultInjector(*cfg.queryFaults)
+	}
+	if cfg.meterProvider != nil {
+		pgEngine.metricsRegistration, err = registerPoolMetrics(cfg.meterProvider, pgEngine.Pool)
+		if err != nil {
+			if ownsPool {
+				pgEngine.Close()
+			}
+			return PostgresEngine{}, fmt.Errorf("failed to register pool metrics: %w", err)
+		}
+	}
 	return *pgEngine, nil
 }
 
-// createPool creates a connection pool to the PostgreSQL database.
-func createPool(ctx context.Context, cfg engineConfig, usingIAMAuth bool) (*pgxpool.Pool, error) {
+// createSecondaryPools creates the pools to the read pool and secondary
+// instances, if any.
+func (p *PostgresEngine) createSecondaryPools(ctx context.Context, cfg engineConfig, usingIAMAuth bool) error {
+	if cfg.readInstance != nil {
+		readPool, err := createPool(ctx, cfg, *cfg.readInstance, usingIAMAuth)
+		if err != nil {
+			return fmt.Errorf("failed to create read pool: %w", err)
+		}
+		p.readPool = readPool
+	}
+	if p.failover == nil {
+		return nil
+	}
+	for _, instance := range p.failover.instances {
+		probePool, err := createPool(ctx, cfg, instance, usingIAMAuth)
+		if err != nil {
+			return fmt.Errorf("failed to create health check pool: %w", err)
+		}
+		p.probePools = append(p.probePools, probePool)
+	}
+	p.failover.probe = func(ctx context.Context, instance int) error {
+		return p.probePools[instance].Ping(ctx)
+	}
+	return nil
+}
+
+// dialTarget is the AlloyDB instance dialed by the connections of a pool.
+type dialTarget interface {
+	uri() string
+}
+
+// createPool creates a connection pool to the PostgreSQL database of an
+// AlloyDB instance. The instance is looked up on every new connection.
+func createPool(ctx context.Context, cfg engineConfig, instance dialTarget, usingIAMAuth bool) (*pgxpool.Pool, error) {
 	dialeropts := []alloydbconn.Option{}
-	dsn := fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable", cfg.user, cfg.password, cfg.database)
 	if usingIAMAuth {
 		dialeropts = append(dialeropts, alloydbconn.WithIAMAuthN())
-		dsn = fmt.Sprintf("user=%s dbname=%s sslmode=disable", cfg.user, cfg.database)
 	}
+	if cfg.lazyConnect {
+		dialeropts = append(dialeropts, alloydbconn.WithLazyRefresh())
+	}
+	dsn := connectionString(cfg, usingIAMAuth)
 	d, err := alloydbconn.NewDialer(ctx, dialeropts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize connection: %w", err)
@@ -66,12 +179,39 @@ func createPool(ctx context.Context, cfg engineConfig, usingIAMAuth bool) (*pgxp
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse connection config: %w", err)
 	}
-	instanceURI := fmt.Sprintf("projects/%s/locations/%s/clusters/%s/instances/%s", cfg.projectID, cfg.region, cfg.cluster, cfg.instance)
+	if cfg.resolveUser != nil {
+		config.BeforeConnect = func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+			user, err := cfg.resolveUser(ctx)
+			if err != nil {
+				return err
+			}
+			connConfig.User = user
+			return nil
+		}
+	}
+	if cfg.tlsConfig != nil {
+		config.ConnConfig.TLSConfig = cfg.tlsConfig.Clone()
+		config.ConnConfig.Fallbacks = nil
+	}
 	config.ConnConfig.DialFunc = func(ctx context.Context, _ string, _ string) (net.Conn, error) {
 		if cfg.ipType == "PRIVATE" {
-			return d.Dial(ctx, instanceURI, alloydbconn.WithPrivateIP())
+			return d.Dial(ctx, instance.uri(), alloydbconn.WithPrivateIP())
+		}
+		return d.Dial(ctx, instance.uri(), alloydbconn.WithPublicIP())
+	}
+	var tracers []pgx.QueryTracer
+	if cfg.queryFaults != nil && cfg.queryFaults.Latency > 0 {
+		tracers = append(tracers, faultTracer{latency: cfg.queryFaults.Latency})
+	}
+	if cfg.tracerProvider != nil || cfg.meterProvider != nil {
+		tracer, err := newOTelTracer(cfg.tracerProvider, cfg.meterProvider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create telemetry tracer: %w", err)
 		}
-		return d.Dial(ctx, instanceURI, alloydbconn.WithPublicIP())
+		tracers = append(tracers, tracer)
+	}
+	if len(tracers) > 0 {
+		config.ConnConfig.Tracer = multitracer.New(tracers...)
 	}
 	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
@@ -80,12 +220,84 @@ func createPool(ctx context.Context, cfg engineConfig, usingIAMAuth bool) (*pgxp
 	return pool, nil
 }
 
+// connectionString returns the DSN of the connections made through the
+// AlloyDB connector.
+func connectionString(cfg engineConfig, usingIAMAuth bool) string {
+	dsn := fmt.Sprintf("user=%s password=%s dbname=%s", cfg.user, cfg.password, cfg.database)
+	if usingIAMAuth {
+		dsn = fmt.Sprintf("user=%s dbname=%s", cfg.user, cfg.database)
+	}
+	sslMode := cfg.sslMode
+	if sslMode == "" {
+		sslMode = "disable"
+		if cfg.tlsConfig != nil || cfg.sslCert != "" || cfg.sslRootCert != "" {
+			sslMode = "require"
+		}
+	}
+	dsn += " sslmode=" + sslMode
+	if sslMode == "disable" {
+		return dsn
+	}
+	// The connector dials the instance regardless of the host, which is set so
+	// that pgx doesn't default to a Unix socket, over which it doesn't use TLS.
+	dsn += " host=localhost"
+	for _, param := range []struct{ name, value string }{
+		{"sslrootcert", cfg.sslRootCert},
+		{"sslcert", cfg.sslCert},
+		{"sslkey", cfg.sslKey},
+	} {
+		if param.value != "" {
+			dsn += fmt.Sprintf(" %s='%s'", param.name, strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(param.value))
+		}
+	}
+	return dsn
+}
+
+// WithTx runs fn in a transaction on the pool, committing it when fn
+// succeeds and rolling it back otherwise.
+func (p *PostgresEngine) WithTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// execIdempotent executes a statement that can safely be repeated, retrying
+// transient errors.
+func (p *PostgresEngine) execIdempotent(ctx context.Context, query string, args ...any) error {
+	return p.Retry(ctx, func(ctx context.Context) error {
+		_, err := p.Pool.Exec(ctx, query, args...)
+		return err
+	})
+}
+
 // Close closes the connection.
 func (p *PostgresEngine) Close() {
+	if p.metricsRegistration != nil {
+		_ = p.metricsRegistration.Unregister()
+	}
 	if p.Pool != nil {
 		// Close the connection pool.
 		p.Pool.Close()
 	}
+	if p.failover != nil {
+		p.failover.close()
+	}
+	if p.readPool != nil {
+		p.readPool.Close()
+	}
+	for _, probePool := range p.probePools {
+		probePool.Close()
+	}
 }
 
 // getUser retrieves the username, a flag indicating if IAM authentication
@@ -112,6 +324,28 @@ func getUser(ctx context.Context, config engineConfig) (string, bool, error) {
 	return "", false, errors.New("unable to retrieve a valid username")
 }
 
+// lazyUser returns a function retrieving the IAM principal with retrieve on
+// its first successful call and returning it on the following ones.
+func lazyUser(retrieve EmailRetriever) func(context.Context) (string, error) {
+	var (
+		mu   sync.Mutex
+		user string
+	)
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if user != "" {
+			return user, nil
+		}
+		email, err := retrieve(ctx)
+		if err != nil {
+			return "", fmt.Errorf("unable to retrieve service account email: %w", err)
+		}
+		user = email
+		return user, nil
+	}
+}
+
 // getServiceAccountEmail retrieves the IAM principal email with users account.
 func getServiceAccountEmail(ctx context.Context) (string, error) {
 	scopes := []string{"https://www.googleapis.com/auth/userinfo.email"}
@@ -142,11 +376,8 @@ func getServiceAccountEmail(ctx context.Context) (string, error) {
 // validateVectorstoreTableOptions initializes the options struct with the default values for
 // the InitVectorstoreTable function.
 func validateVectorstoreTableOptions(opts *VectorstoreTableOptions) error {
-	if opts.TableName == "" {
-		return fmt.Errorf("missing table name in options")
-	}
-	if opts.VectorSize == 0 {
-		return fmt.Errorf("missing vector size in options")
+	if err := opts.Validate(); err != nil {
+		return err
 	}
 
 	if opts.SchemaName == "" {
@@ -165,12 +396,24 @@ func validateVectorstoreTableOptions(opts *VectorstoreTableOptions) error {
 		opts.MetadataJSONColumn = "langchain_metadata"
 	}
 
+	if opts.EmbeddingType == "" {
+		opts.EmbeddingType = EmbeddingTypeVector
+	}
+
 	if opts.IDColumn.Name == "" {
 		opts.IDColumn.Name = "langchain_id"
 	}
 
 	if opts.IDColumn.DataType == "" {
 		opts.IDColumn.DataType = "UUID"
+		if opts.IDGenerator != nil {
+			opts.IDColumn.DataType = opts.IDGenerator.DataType()
+		}
+	}
+
+	// The generator's data type isn't covered by Validate.
+	if err := validateDataType(opts.IDColumn.DataType); err != nil {
+		return &ConfigError{Field: "IDGenerator", Problem: err.Error(), Hint: "return a plain type name from DataType"}
 	}
 
 	return nil
@@ -183,66 +426,395 @@ func (p *PostgresEngine) InitVectorstoreTable(ctx context.Context, opts Vectorst
 		return fmt.Errorf("failed to validate vectorstore table options: %w", err)
 	}
 
+	init := func(ctx context.Context) error {
+		return p.WithTx(ctx, func(tx pgx.Tx) error {
+			return initVectorstoreTable(ctx, tx, opts)
+		})
+	}
+	// Creating the table is only idempotent if an existing one is kept or
+	// dropped first.
+	if opts.IfNotExists || opts.OverwriteExisting {
+		return p.Retry(ctx, init)
+	}
+	return init(ctx)
+}
+
+// initVectorstoreTable runs the statements of InitVectorstoreTable in tx.
+func initVectorstoreTable(ctx context.Context, tx pgx.Tx, opts VectorstoreTableOptions) error {
 	// Ensure the vector extension exists
-	_, err = p.Pool.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS vector")
+	_, err := tx.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS vector")
 	if err != nil {
 		return fmt.Errorf("failed to create extension: %w", err)
 	}
 
 	// Drop table if exists and overwrite flag is true
 	if opts.OverwriteExisting {
-		_, err = p.Pool.Exec(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS "%s"."%s"`, opts.SchemaName, opts.TableName))
+		_, err = tx.Exec(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s`, QuoteIdentifier(opts.SchemaName, opts.TableName)))
 		if err != nil {
 			return fmt.Errorf("failed to drop table: %w", err)
 		}
 	}
 
-	// Build the SQL query that creates the table
-	query := fmt.Sprintf(`CREATE TABLE "%s"."%s" (
-		"%s" %s PRIMARY KEY,
-		"%s" TEXT NOT NULL,
-		"%s" vector(%d) NOT NULL`, opts.SchemaName, opts.TableName, opts.IDColumn.Name, opts.IDColumn.DataType, opts.ContentColumnName, opts.EmbeddingColumn, opts.VectorSize)
+	// Execute the query to create the table
+	_, err = tx.Exec(ctx, createVectorstoreTableQuery(opts))
+	if err != nil {
+		return fmt.Errorf("failed to create table: %w", err)
+	}
 
-	// Add metadata columns  to the query string if provided
-	for _, column := range opts.MetadataColumns {
-		nullable := ""
-		if !column.Nullable {
-			nullable = "NOT NULL"
+	// Add the columns declared since the table was created
+	if opts.AddMissingColumns {
+		for _, query := range addVectorstoreColumnsQueries(opts) {
+			if _, err = tx.Exec(ctx, query); err != nil {
+				return fmt.Errorf("failed to add column: %w", err)
+			}
+		}
+	}
+
+	// Index the expiry of the documents to purge the expired ones
+	if opts.ExpiresAtColumn != "" {
+		_, err = tx.Exec(ctx, createExpiresAtIndexQuery(opts))
+		if err != nil {
+			return fmt.Errorf("failed to create expiry index: %w", err)
 		}
-		query += fmt.Sprintf(`, "%s" %s %s`, column.Name, column.DataType, nullable)
+	}
+	return nil
+}
+
+// createVectorstoreTableQuery builds the CREATE TABLE statement for opts.
+func createVectorstoreTableQuery(opts VectorstoreTableOptions) string {
+	table := newCreateTable(opts.SchemaName, opts.TableName).
+		Column(opts.IDColumn.Name, opts.IDColumn.DataType, "PRIMARY KEY").
+		Column(opts.ContentColumnName, "TEXT", "NOT NULL").
+		Column(opts.EmbeddingColumn, fmt.Sprintf("%s(%d)", opts.EmbeddingType, opts.VectorSize), "NOT NULL")
+	for _, column := range opts.AdditionalEmbeddingColumns {
+		table.Column(column, fmt.Sprintf("%s(%d)", opts.EmbeddingType, opts.VectorSize))
+	}
+	if opts.IfNotExists {
+		table.IfNotExists()
+	}
+
+	// Add metadata columns to the query string if provided
+	for _, column := range opts.MetadataColumns {
+		table.Column(column.Name, column.DataType, nullability(column)...)
 	}
 
 	// Add JSON metadata column to the query string if storeMetadata is true
 	if opts.StoreMetadata {
-		query += fmt.Sprintf(`, "%s" JSON`, opts.MetadataJSONColumn)
+		table.Column(opts.MetadataJSONColumn, "JSON")
+	}
+	if opts.EmbeddingHashColumn != "" {
+		table.Column(opts.EmbeddingHashColumn, "TEXT")
+	}
+	if opts.ExpiresAtColumn != "" {
+		table.Column(opts.ExpiresAtColumn, "TIMESTAMPTZ")
 	}
-	// Close the query string
-	query += ");"
+	if opts.ArchiveKeyColumn != "" {
+		table.Column(opts.ArchiveKeyColumn, "TEXT")
+	}
+	return table.String()
+}
 
-	// Execute the query to create the table
-	_, err = p.Pool.Exec(ctx, query)
-	if err != nil {
-		return fmt.Errorf("failed to create table: %w", err)
+// createExpiresAtIndexQuery builds the statement indexing the expiry column
+// of opts.
+func createExpiresAtIndexQuery(opts VectorstoreTableOptions) string {
+	return fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (%s);`,
+		QuoteIdentifier(opts.TableName+"_"+opts.ExpiresAtColumn+"_idx"),
+		QuoteIdentifier(opts.SchemaName, opts.TableName), QuoteIdentifier(opts.ExpiresAtColumn))
+}
+
+// addVectorstoreColumnsQueries builds the statements adding the additional
+// embedding and metadata columns of opts to an existing table, leaving present
+// columns untouched. The columns are added as nullable: the rows already in the
+// table have no value for them, so NOT NULL would fail on any non-empty table.
+func addVectorstoreColumnsQueries(opts VectorstoreTableOptions) []string {
+	columns := make([]string, 0, len(opts.AdditionalEmbeddingColumns)+len(opts.MetadataColumns)+4)
+	for _, column := range opts.AdditionalEmbeddingColumns {
+		columns = append(columns, columnDefinition(column, fmt.Sprintf("%s(%d)", opts.EmbeddingType, opts.VectorSize)))
+	}
+	for _, column := range opts.MetadataColumns {
+		columns = append(columns, columnDefinition(column.Name, column.DataType))
+	}
+	if opts.StoreMetadata {
+		columns = append(columns, columnDefinition(opts.MetadataJSONColumn, "JSON"))
+	}
+	if opts.EmbeddingHashColumn != "" {
+		columns = append(columns, columnDefinition(opts.EmbeddingHashColumn, "TEXT"))
+	}
+	if opts.ExpiresAtColumn != "" {
+		columns = append(columns, columnDefinition(opts.ExpiresAtColumn, "TIMESTAMPTZ"))
+	}
+	if opts.ArchiveKeyColumn != "" {
+		columns = append(columns, columnDefinition(opts.ArchiveKeyColumn, "TEXT"))
 	}
 
-	return nil
+	queries := make([]string, 0, len(columns))
+	for _, column := range columns {
+		queries = append(queries, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s;`,
+			QuoteIdentifier(opts.SchemaName, opts.TableName), column))
+	}
+	return queries
+}
+
+// nullability returns the constraints of a metadata column.
+func nullability(column Column) []string {
+	if column.Nullable {
+		return nil
+	}
+	return []string{"NOT NULL"}
 }
 
-// initChatHistoryTable creates a table to store chat history.
+// InitChatHistoryTable creates a table to store chat history. The messages
+// are stored along with their creation time and metadata, and the compressed
+// messages in the compressed_data column.
 func (p *PostgresEngine) InitChatHistoryTable(ctx context.Context, tableName string, opts ...OptionInitChatHistoryTable) error {
 	cfg := applyChatMessageHistoryOptions(opts...)
 
-	createTableQuery := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "%s"."%s" (
-		id SERIAL PRIMARY KEY,
-		session_id TEXT NOT NULL,
-		data JSONB NOT NULL,
-		type TEXT NOT NULL
-	);`, cfg.schemaName, tableName)
+	createTableQuery, err := createChatHistoryTableQuery(cfg, tableName)
+	if err != nil {
+		return err
+	}
 
 	// Execute the query
-	_, err := p.Pool.Exec(ctx, createTableQuery)
+	err = p.execIdempotent(ctx, createTableQuery)
 	if err != nil {
 		return fmt.Errorf("failed to execute query: %w", err)
 	}
+
+	if cfg.textSearchConfig != "" {
+		createIndexQuery, err := createChatHistoryTextSearchIndexQuery(cfg, tableName)
+		if err != nil {
+			return err
+		}
+		if err := p.execIdempotent(ctx, createIndexQuery); err != nil {
+			return fmt.Errorf("failed to create full-text search index: %w", err)
+		}
+	}
+	return nil
+}
+
+// createChatHistoryTextSearchIndexQuery builds the statement indexing the
+// text search vector of the string values of the data column of a chat
+// history table. The expression must match the one of the searches.
+func createChatHistoryTextSearchIndexQuery(cfg InitChatHistoryTableOptions, tableName string) (string, error) {
+	if !textSearchConfigRegexp.MatchString(cfg.textSearchConfig) {
+		return "", fmt.Errorf("invalid text search configuration %q", cfg.textSearchConfig)
+	}
+	return fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING GIN (jsonb_to_tsvector('%s'::regconfig, data, '["string"]'));`,
+		QuoteIdentifier(tableName+"_data_fts_idx"), QuoteIdentifier(cfg.schemaName, tableName), cfg.textSearchConfig), nil
+}
+
+// createChatHistoryTableQuery builds the CREATE TABLE statement of a chat
+// history table.
+func createChatHistoryTableQuery(cfg InitChatHistoryTableOptions, tableName string) (string, error) {
+	table := newCreateTable(cfg.schemaName, tableName).IfNotExists().
+		Column("id", "SERIAL", "PRIMARY KEY")
+	if cfg.messageIDGenerator != nil {
+		dataType := cfg.messageIDGenerator.DataType()
+		if err := validateDataType(dataType); err != nil {
+			return "", fmt.Errorf("failed to validate message id column: %w", err)
+		}
+		table.Column("message_id", dataType, "NOT NULL", "UNIQUE")
+	}
+	return table.
+		Column("session_id", "TEXT", "NOT NULL").
+		Column("data", "JSONB", "NOT NULL").
+		Column("type", "TEXT", "NOT NULL").
+		Column("created_at", "TIMESTAMPTZ", "NOT NULL", "DEFAULT NOW()").
+		Column("metadata", "JSONB", "NOT NULL", "DEFAULT '{}'").
+		Column("compressed_data", "BYTEA").
+		String(), nil
+}
+
+// InitChatHistoryArchiveTable creates a table to preserve the messages of a
+// chat history table replaced by summaries when the history is compacted. The
+// options must match those the chat history table was created with.
+func (p *PostgresEngine) InitChatHistoryArchiveTable(ctx context.Context, tableName string, opts ...OptionInitChatHistoryTable) error {
+	cfg := applyChatMessageHistoryOptions(opts...)
+
+	createTableQuery, err := createChatHistoryArchiveTableQuery(cfg, tableName)
+	if err != nil {
+		return err
+	}
+	if err := p.execIdempotent(ctx, createTableQuery); err != nil {
+		return fmt.Errorf("failed to create archive table: %w", err)
+	}
+
+	createIndexQuery := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (session_id, summary_id);`,
+		QuoteIdentifier(tableName+"_session_id_idx"), QuoteIdentifier(cfg.schemaName, tableName))
+	if err := p.execIdempotent(ctx, createIndexQuery); err != nil {
+		return fmt.Errorf("failed to create archive table index: %w", err)
+	}
+	return nil
+}
+
+// createChatHistoryArchiveTableQuery builds the CREATE TABLE statement of a
+// chat history archive table. The archived messages keep their id, and
+// summary_id is the id of the summary that replaced them.
+func createChatHistoryArchiveTableQuery(cfg InitChatHistoryTableOptions, tableName string) (string, error) {
+	table := newCreateTable(cfg.schemaName, tableName).IfNotExists().
+		Column("id", "INTEGER", "PRIMARY KEY")
+	if cfg.messageIDGenerator != nil {
+		dataType := cfg.messageIDGenerator.DataType()
+		if err := validateDataType(dataType); err != nil {
+			return "", fmt.Errorf("failed to validate message id column: %w", err)
+		}
+		table.Column("message_id", dataType, "NOT NULL")
+	}
+	return table.
+		Column("session_id", "TEXT", "NOT NULL").
+		Column("data", "JSONB", "NOT NULL").
+		Column("type", "TEXT", "NOT NULL").
+		Column("created_at", "TIMESTAMPTZ", "NOT NULL", "DEFAULT NOW()").
+		Column("metadata", "JSONB", "NOT NULL", "DEFAULT '{}'").
+		Column("compressed_data", "BYTEA").
+		Column("summary_id", "INTEGER", "NOT NULL").
+		Column("archived_at", "TIMESTAMPTZ", "NOT NULL", "DEFAULT NOW()").
+		String(), nil
+}
+
+// InitDocumentAuditTable creates a table to record the documents returned by
+// vector store retrievals, indexed by retrieval time to support retention.
+func (p *PostgresEngine) InitDocumentAuditTable(ctx context.Context, opts DocumentAuditTableOptions) error {
+	if opts.TableName == "" {
+		return fmt.Errorf("missing table name in options")
+	}
+	if opts.SchemaName == "" {
+		opts.SchemaName = defaultSchemaName
+	}
+
+	createTableQuery := newCreateTable(opts.SchemaName, opts.TableName).IfNotExists().
+		Column("id", "BIGSERIAL", "PRIMARY KEY").
+		Column("retrieved_at", "TIMESTAMPTZ", "NOT NULL", "DEFAULT NOW()").
+		Column("session_id", "TEXT").
+		Column("user_id", "TEXT").
+		Column("query", "TEXT", "NOT NULL").
+		Column("document_id", "TEXT", "NOT NULL").
+		Column("rank", "INT", "NOT NULL").
+		Column("distance", "REAL").
+		String()
+	if err := p.execIdempotent(ctx, createTableQuery); err != nil {
+		return fmt.Errorf("failed to create audit table: %w", err)
+	}
+
+	createIndexQuery := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (retrieved_at);`,
+		QuoteIdentifier(opts.TableName+"_retrieved_at_idx"), QuoteIdentifier(opts.SchemaName, opts.TableName))
+	if err := p.execIdempotent(ctx, createIndexQuery); err != nil {
+		return fmt.Errorf("failed to create audit table index: %w", err)
+	}
+	return nil
+}
+
+// InitDocstoreTable creates a table to store full documents by key, e.g. the
+// parent documents of the chunks indexed in a vector store. The compressed
+// contents are stored in the compressed_content column.
+func (p *PostgresEngine) InitDocstoreTable(ctx context.Context, opts DocstoreTableOptions) error {
+	if opts.TableName == "" {
+		return fmt.Errorf("missing table name in options")
+	}
+	if opts.SchemaName == "" {
+		opts.SchemaName = defaultSchemaName
+	}
+	if err := p.execIdempotent(ctx, createDocstoreTableQuery(opts)); err != nil {
+		return fmt.Errorf("failed to create docstore table: %w", err)
+	}
+	return nil
+}
+
+// createDocstoreTableQuery builds the CREATE TABLE statement of a docstore
+// table.
+func createDocstoreTableQuery(opts DocstoreTableOptions) string {
+	return newCreateTable(opts.SchemaName, opts.TableName).IfNotExists().
+		Column("key", "TEXT", "PRIMARY KEY").
+		Column("content", "TEXT", "NOT NULL").
+		Column("metadata", "JSONB", "NOT NULL", "DEFAULT '{}'").
+		Column("updated_at", "TIMESTAMPTZ", "NOT NULL", "DEFAULT NOW()").
+		Column("compressed_content", "BYTEA").
+		String()
+}
+
+// InitRecordManagerTable creates a table to record the documents indexed in
+// vector stores, keyed by namespace and document hash, e.g. for
+// indexes.Index.
+func (p *PostgresEngine) InitRecordManagerTable(ctx context.Context, opts RecordManagerTableOptions) error {
+	if opts.TableName == "" {
+		return fmt.Errorf("missing table name in options")
+	}
+	if opts.SchemaName == "" {
+		opts.SchemaName = defaultSchemaName
+	}
+	if err := p.execIdempotent(ctx, createRecordManagerTableQuery(opts)); err != nil {
+		return fmt.Errorf("failed to create record manager table: %w", err)
+	}
+	createIndexQuery := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (namespace, source_id, updated_at);`,
+		QuoteIdentifier(opts.TableName+"_source_id_idx"), QuoteIdentifier(opts.SchemaName, opts.TableName))
+	if err := p.execIdempotent(ctx, createIndexQuery); err != nil {
+		return fmt.Errorf("failed to create record manager table index: %w", err)
+	}
 	return nil
 }
+
+// createRecordManagerTableQuery builds the CREATE TABLE statement of a record
+// manager table.
+func createRecordManagerTableQuery(opts RecordManagerTableOptions) string {
+	return newCreateTable(opts.SchemaName, opts.TableName).IfNotExists().
+		Column("namespace", "TEXT", "NOT NULL").
+		Column("key", "TEXT", "NOT NULL").
+		Column("source_id", "TEXT", "NOT NULL", "DEFAULT ''").
+		Column("document_id", "TEXT", "NOT NULL").
+		Column("updated_at", "TIMESTAMPTZ", "NOT NULL", "DEFAULT NOW()").
+		PrimaryKey("namespace", "key").
+		String()
+}
+
+// InitEntityMemoryTable creates a table to store the summaries of the
+// entities mentioned in conversations, keyed by session and entity.
+func (p *PostgresEngine) InitEntityMemoryTable(ctx context.Context, opts EntityMemoryTableOptions) error {
+	if opts.TableName == "" {
+		return fmt.Errorf("missing table name in options")
+	}
+	if opts.SchemaName == "" {
+		opts.SchemaName = defaultSchemaName
+	}
+	if err := p.execIdempotent(ctx, createEntityMemoryTableQuery(opts)); err != nil {
+		return fmt.Errorf("failed to create entity memory table: %w", err)
+	}
+	return nil
+}
+
+// createEntityMemoryTableQuery builds the CREATE TABLE statement of an
+// entity memory table.
+func createEntityMemoryTableQuery(opts EntityMemoryTableOptions) string {
+	return newCreateTable(opts.SchemaName, opts.TableName).IfNotExists().
+		Column("session_id", "TEXT", "NOT NULL").
+		Column("entity", "TEXT", "NOT NULL").
+		Column("summary", "TEXT", "NOT NULL").
+		Column("updated_at", "TIMESTAMPTZ", "NOT NULL", "DEFAULT NOW()").
+		PrimaryKey("session_id", "entity").
+		String()
+}
+
+// InitEmbeddingCacheTable creates a table to cache the vectors of embedded
+// texts, keyed by a hash of the texts.
+func (p *PostgresEngine) InitEmbeddingCacheTable(ctx context.Context, opts EmbeddingCacheTableOptions) error {
+	if opts.TableName == "" {
+		return fmt.Errorf("missing table name in options")
+	}
+	if opts.SchemaName == "" {
+		opts.SchemaName = defaultSchemaName
+	}
+	if err := p.execIdempotent(ctx, createEmbeddingCacheTableQuery(opts)); err != nil {
+		return fmt.Errorf("failed to create embedding cache table: %w", err)
+	}
+	return nil
+}
+
+// createEmbeddingCacheTableQuery builds the CREATE TABLE statement of an
+// embedding cache table. The vectors are stored as arrays, so that the table
+// doesn't need the vector extension and holds vectors of any dimension.
+func createEmbeddingCacheTableQuery(opts EmbeddingCacheTableOptions) string {
+	return newCreateTable(opts.SchemaName, opts.TableName).IfNotExists().
+		Column("key", "TEXT", "PRIMARY KEY").
+		Column("embedding", "REAL[]", "NOT NULL").
+		Column("created_at", "TIMESTAMPTZ", "NOT NULL", "DEFAULT NOW()").
+		String()
+}
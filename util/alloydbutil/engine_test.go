@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/jackc/pgx/v5"
 )
 
 func getEnvVariables(t *testing.T) (string, string, string, string, string, string, string) {
@@ -80,7 +84,7 @@ func TestNewPostgresEngine(t *testing.T) {
 				WithDatabase(database),
 				WithAlloyDBInstance("", region, cluster, instance),
 			},
-			err: "missing connection: provide a connection pool or connection fields",
+			err: "invalid engine configuration:\nWithAlloyDBInstance: missing connection fields: project ID (provide all of the project ID, region, cluster and instance, or an existing pool with WithPool)",
 		},
 		{
 			desc: "Error in engine creation with missing projectId",
@@ -90,7 +94,7 @@ func TestNewPostgresEngine(t *testing.T) {
 				WithDatabase(database),
 				WithAlloyDBInstance(projectID, region, "", instance),
 			},
-			err: "missing connection: provide a connection pool or connection fields",
+			err: "invalid engine configuration:\nWithAlloyDBInstance: missing connection fields: cluster (provide all of the project ID, region, cluster and instance, or an existing pool with WithPool)",
 		},
 	}
 
@@ -99,13 +103,11 @@ func TestNewPostgresEngine(t *testing.T) {
 			t.Parallel()
 			_, err := NewPostgresEngine(ctx, tc.in...)
 
-			if err == nil && tc.err != "" {
+			switch {
+			case err == nil && tc.err != "":
 				t.Fatalf("unexpected error: got %q, want %q", err, tc.err)
-			} else {
-				errStr := err.Error()
-				if errStr != tc.err {
-					t.Fatalf("unexpected error: got %q, want %q", errStr, tc.err)
-				}
+			case err != nil && err.Error() != tc.err:
+				t.Fatalf("unexpected error: got %q, want %q", err.Error(), tc.err)
 			}
 		})
 	}
@@ -191,3 +193,184 @@ func TestGetUser(t *testing.T) {
 		})
 	}
 }
+
+func TestVectorstoreTableQueries(t *testing.T) {
+	t.Parallel()
+	opts := VectorstoreTableOptions{
+		TableName:     "items",
+		VectorSize:    3,
+		StoreMetadata: true,
+		IfNotExists:   true,
+		MetadataColumns: []Column{
+			{Name: "area", DataType: "int", Nullable: true},
+			{Name: "name", DataType: "text"},
+		},
+		EmbeddingHashColumn:        "content_hash",
+		AdditionalEmbeddingColumns: []string{"title_embedding"},
+		ExpiresAtColumn:            "expires_at",
+		ArchiveKeyColumn:           "archive_key",
+	}
+	if err := validateVectorstoreTableOptions(&opts); err != nil {
+		t.Fatal(err)
+	}
+
+	create := createVectorstoreTableQuery(opts)
+	if !strings.HasPrefix(create, `CREATE TABLE IF NOT EXISTS "public"."items"`) {
+		t.Errorf("expected CREATE TABLE IF NOT EXISTS, got %s", create)
+	}
+	if !strings.Contains(create, `"embedding" vector(3) NOT NULL, "title_embedding" vector(3), `) {
+		t.Errorf("unexpected embedding columns in %s", create)
+	}
+	if !strings.Contains(create, `"area" int, "name" text NOT NULL, "langchain_metadata" JSON, "content_hash" TEXT, "expires_at" TIMESTAMPTZ, "archive_key" TEXT);`) {
+		t.Errorf("unexpected metadata columns in %s", create)
+	}
+
+	want := []string{
+		`ALTER TABLE "public"."items" ADD COLUMN IF NOT EXISTS "title_embedding" vector(3);`,
+		`ALTER TABLE "public"."items" ADD COLUMN IF NOT EXISTS "area" int;`,
+		`ALTER TABLE "public"."items" ADD COLUMN IF NOT EXISTS "name" text;`,
+		`ALTER TABLE "public"."items" ADD COLUMN IF NOT EXISTS "langchain_metadata" JSON;`,
+		`ALTER TABLE "public"."items" ADD COLUMN IF NOT EXISTS "content_hash" TEXT;`,
+		`ALTER TABLE "public"."items" ADD COLUMN IF NOT EXISTS "expires_at" TIMESTAMPTZ;`,
+		`ALTER TABLE "public"."items" ADD COLUMN IF NOT EXISTS "archive_key" TEXT;`,
+	}
+	if got := addVectorstoreColumnsQueries(opts); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	wantIndex := `CREATE INDEX IF NOT EXISTS "items_expires_at_idx" ON "public"."items" ("expires_at");`
+	if got := createExpiresAtIndexQuery(opts); got != wantIndex {
+		t.Errorf("expected %s, got %s", wantIndex, got)
+	}
+}
+
+func TestWithTxRollback(t *testing.T) {
+	t.Parallel()
+	username, password, database, projectID, region, instance, cluster := getEnvVariables(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := NewPostgresEngine(ctx,
+		WithUser(username),
+		WithPassword(password),
+		WithDatabase(database),
+		WithAlloyDBInstance(projectID, region, cluster, instance),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(engine.Close)
+
+	errAbort := errors.New("abort")
+	err = engine.WithTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `CREATE TABLE "with_tx_rollback" (id INT)`); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected abort error, got %v", err)
+	}
+
+	var exists bool
+	err = engine.Pool.QueryRow(ctx, `SELECT to_regclass('"with_tx_rollback"') IS NOT NULL`).Scan(&exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("expected table creation to be rolled back")
+	}
+}
+
+func TestConnectionString(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		opts         []Option
+		usingIAMAuth bool
+		want         string
+	}{
+		{
+			name: "default",
+			opts: []Option{WithUser("user"), WithPassword("password"), WithDatabase("db")},
+			want: "user=user password=password dbname=db sslmode=disable",
+		},
+		{
+			name:         "IAM authentication",
+			opts:         []Option{WithUser("sa@project.iam"), WithDatabase("db"), WithSSLMode("require")},
+			usingIAMAuth: true,
+			want:         "user=sa@project.iam dbname=db sslmode=require host=localhost",
+		},
+		{
+			name: "certificates",
+			opts: []Option{
+				WithUser("user"), WithPassword("password"), WithDatabase("db"),
+				WithSSLCertificates("/certs/ca.pem", "/certs/o'brien.pem", "/certs/client.key"),
+			},
+			want: `user=user password=password dbname=db sslmode=require host=localhost sslrootcert='/certs/ca.pem' sslcert='/certs/o\'brien.pem' sslkey='/certs/client.key'`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := engineConfig{}
+			for _, opt := range tt.opts {
+				opt(&cfg)
+			}
+			if got := connectionString(cfg, tt.usingIAMAuth); got != tt.want {
+				t.Errorf("connectionString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealth(t *testing.T) {
+	t.Parallel()
+	username, password, database, projectID, region, instance, cluster := getEnvVariables(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	engine, err := NewPostgresEngine(ctx,
+		WithUser(username),
+		WithPassword(password),
+		WithDatabase(database),
+		WithAlloyDBInstance(projectID, region, cluster, instance),
+		WithLazyConnect(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(engine.Close)
+
+	status, err := engine.Health(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Reachable || status.IAMAuth || status.ReadInstanceReachable {
+		t.Errorf("unexpected health status %+v", status)
+	}
+}
+
+func TestLazyUser(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	errUnavailable := errors.New("metadata server unavailable")
+	user := lazyUser(func(context.Context) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", errUnavailable
+		}
+		return "sa@project.iam", nil
+	})
+
+	if _, err := user(context.Background()); !errors.Is(err, errUnavailable) {
+		t.Fatalf("expected the retrieval error, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := user(context.Background())
+		if err != nil || got != "sa@project.iam" {
+			t.Fatalf("expected the retrieved user, got %q, %v", got, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected the user to be retrieved until it succeeds, got %d calls", calls)
+	}
+}
@@ -0,0 +1,208 @@
+package alloydbutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	primaryInstance   = 0
+	secondaryInstance = 1
+)
+
+// FailoverPolicy configures the failover of the engine's pool to the
+// secondary instance set with WithSecondaryInstance. Unset fields take their
+// default value.
+type FailoverPolicy struct {
+	// HealthCheckInterval is the time between health checks. It defaults to
+	// 10s.
+	HealthCheckInterval time.Duration
+	// HealthCheckTimeout bounds every health check. It defaults to 5s.
+	HealthCheckTimeout time.Duration
+	// FailureThreshold is the number of consecutive failed health checks of
+	// the active instance after which the pool fails over to the other one,
+	// if it is healthy. It defaults to 3.
+	FailureThreshold int
+	// SwitchbackAfter is how long the primary instance must be healthy again
+	// before the pool switches back to it. It defaults to 5m. A negative
+	// value disables automatic switchback; call Switchback instead.
+	SwitchbackAfter time.Duration
+	// OnSwitch, if not nil, is called with the URI of the instance the pool
+	// switched to.
+	OnSwitch func(instanceURI string)
+}
+
+// withDefaults returns the policy with its unset fields set to their default.
+func (f FailoverPolicy) withDefaults() FailoverPolicy {
+	if f.HealthCheckInterval <= 0 {
+		f.HealthCheckInterval = 10 * time.Second
+	}
+	if f.HealthCheckTimeout <= 0 {
+		f.HealthCheckTimeout = 5 * time.Second
+	}
+	if f.FailureThreshold <= 0 {
+		f.FailureThreshold = 3
+	}
+	if f.SwitchbackAfter == 0 {
+		f.SwitchbackAfter = 5 * time.Minute
+	}
+	return f
+}
+
+// failover points the engine's pool at the primary or the secondary instance,
+// switching between them according to its policy.
+type failover struct {
+	instances [2]alloyDBInstance
+	policy    FailoverPolicy
+	// probe checks that an instance answers, independently of the pool.
+	probe func(ctx context.Context, instance int) error
+	// reset closes the connections of the pool, which reconnects to the
+	// active instance.
+	reset func()
+
+	active atomic.Int32
+
+	mu           sync.Mutex
+	failures     int
+	healthySince time.Time
+
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+func newFailover(primary, secondary alloyDBInstance, policy FailoverPolicy) *failover {
+	return &failover{
+		instances: [2]alloyDBInstance{primary, secondary},
+		policy:    policy.withDefaults(),
+	}
+}
+
+// uri returns the URI of the active instance, which new connections of the
+// pool dial.
+func (f *failover) uri() string {
+	return f.instances[f.active.Load()].uri()
+}
+
+// check runs a health check of instance.
+func (f *failover) check(ctx context.Context, instance int) error {
+	ctx, cancel := context.WithTimeout(ctx, f.policy.HealthCheckTimeout)
+	defer cancel()
+	return f.probe(ctx, instance)
+}
+
+// switchTo makes instance the active one. f.mu must be held.
+func (f *failover) switchTo(instance int) {
+	f.failures = 0
+	f.healthySince = time.Time{}
+	if f.active.Swap(int32(instance)) == int32(instance) {
+		return
+	}
+	if f.reset != nil {
+		f.reset()
+	}
+	if f.policy.OnSwitch != nil {
+		f.policy.OnSwitch(f.instances[instance].uri())
+	}
+}
+
+// trySwitch makes instance the active one if it is healthy.
+func (f *failover) trySwitch(ctx context.Context, instance int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check(ctx, instance); err != nil {
+		return err
+	}
+	f.switchTo(instance)
+	return nil
+}
+
+// step runs the health checks due at now, failing over from an unhealthy
+// active instance and switching back to a recovered primary instance.
+func (f *failover) step(ctx context.Context, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	active := int(f.active.Load())
+	if f.check(ctx, active) == nil {
+		f.failures = 0
+	} else {
+		f.failures++
+	}
+	if f.failures >= f.policy.FailureThreshold {
+		// When both instances are unhealthy the pool stays where it is.
+		if f.check(ctx, 1-active) == nil {
+			f.switchTo(1 - active)
+			return
+		}
+	}
+
+	if active != secondaryInstance || f.policy.SwitchbackAfter < 0 {
+		return
+	}
+	if f.check(ctx, primaryInstance) != nil {
+		f.healthySince = time.Time{}
+		return
+	}
+	if f.healthySince.IsZero() {
+		f.healthySince = now
+	}
+	if now.Sub(f.healthySince) >= f.policy.SwitchbackAfter {
+		f.switchTo(primaryInstance)
+	}
+}
+
+// start runs the health checks in the background until close is called.
+func (f *failover) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	f.stop = cancel
+	f.done = make(chan struct{})
+	go func() {
+		defer close(f.done)
+		ticker := time.NewTicker(f.policy.HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				f.step(ctx, now)
+			}
+		}
+	}()
+}
+
+// close stops the health checks.
+func (f *failover) close() {
+	if f.stop != nil {
+		f.stop()
+		<-f.done
+	}
+}
+
+// ActiveInstance returns the URI of the instance the engine's pool connects
+// to: the primary instance, or the secondary instance set with
+// WithSecondaryInstance after a failover. It is empty for engines created
+// with WithPool.
+func (p *PostgresEngine) ActiveInstance() string {
+	if p.failover == nil {
+		return p.primaryURI
+	}
+	return p.failover.uri()
+}
+
+// Switchback switches the engine's pool back to the primary instance after a
+// failover, if the primary instance is healthy. It is the way back when the
+// failover policy disables automatic switchback.
+func (p *PostgresEngine) Switchback(ctx context.Context) error {
+	if p.failover == nil {
+		return errors.New("no secondary instance is set")
+	}
+	if err := p.failover.trySwitch(ctx, primaryInstance); err != nil {
+		return fmt.Errorf("primary instance is unhealthy: %w", err)
+	}
+	return nil
+}
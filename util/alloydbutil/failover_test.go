@@ -0,0 +1,112 @@
+package alloydbutil
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeInstances is the health of the primary and secondary instances.
+type fakeInstances struct {
+	healthy [2]bool
+}
+
+func (f *fakeInstances) probe(_ context.Context, instance int) error {
+	if !f.healthy[instance] {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func newTestFailover(instances *fakeInstances, policy FailoverPolicy) (*failover, *[]string) {
+	var switches []string
+	policy.OnSwitch = func(uri string) { switches = append(switches, uri) }
+	f := newFailover(
+		alloyDBInstance{projectID: "project", region: "us-central1", cluster: "primary", instance: "instance"},
+		alloyDBInstance{projectID: "project", region: "us-east1", cluster: "secondary", instance: "instance"},
+		policy,
+	)
+	f.probe = instances.probe
+	return f, &switches
+}
+
+func TestFailover(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	instances := &fakeInstances{healthy: [2]bool{true, true}}
+	f, switches := newTestFailover(instances, FailoverPolicy{FailureThreshold: 2, SwitchbackAfter: time.Minute})
+	now := time.Now()
+
+	f.step(ctx, now)
+	instances.healthy[primaryInstance] = false
+	f.step(ctx, now)
+	if strings.Contains(f.uri(), "secondary") {
+		t.Fatal("expected no failover before the failure threshold")
+	}
+	f.step(ctx, now)
+	if !strings.Contains(f.uri(), "clusters/secondary") {
+		t.Fatalf("expected a failover to the secondary instance, got %s", f.uri())
+	}
+
+	// The primary instance must stay healthy for SwitchbackAfter.
+	instances.healthy[primaryInstance] = true
+	f.step(ctx, now)
+	instances.healthy[primaryInstance] = false
+	f.step(ctx, now.Add(30*time.Second))
+	instances.healthy[primaryInstance] = true
+	f.step(ctx, now.Add(time.Minute))
+	if !strings.Contains(f.uri(), "clusters/secondary") {
+		t.Fatal("expected no switchback while the primary instance is flapping")
+	}
+	f.step(ctx, now.Add(2*time.Minute))
+	if !strings.Contains(f.uri(), "clusters/primary") {
+		t.Fatalf("expected a switchback to the primary instance, got %s", f.uri())
+	}
+
+	if len(*switches) != 2 {
+		t.Errorf("expected 2 switches, got %v", *switches)
+	}
+}
+
+func TestFailoverStaysWhenBothInstancesAreUnhealthy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	instances := &fakeInstances{}
+	f, switches := newTestFailover(instances, FailoverPolicy{FailureThreshold: 1})
+	for i := 0; i < 3; i++ {
+		f.step(ctx, time.Now())
+	}
+	if len(*switches) != 0 {
+		t.Errorf("expected no switch, got %v", *switches)
+	}
+}
+
+func TestFailoverManualSwitchback(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	instances := &fakeInstances{healthy: [2]bool{false, true}}
+	f, _ := newTestFailover(instances, FailoverPolicy{FailureThreshold: 1, SwitchbackAfter: -1})
+	engine := PostgresEngine{failover: f}
+
+	f.step(ctx, time.Now())
+	if f.active.Load() != secondaryInstance {
+		t.Fatal("expected a failover to the secondary instance")
+	}
+	if err := engine.Switchback(ctx); err == nil {
+		t.Error("expected an error switching back to an unhealthy instance")
+	}
+
+	instances.healthy[primaryInstance] = true
+	f.step(ctx, time.Now().Add(time.Hour))
+	if !strings.Contains(engine.ActiveInstance(), "clusters/secondary") {
+		t.Fatal("expected no automatic switchback")
+	}
+	if err := engine.Switchback(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(engine.ActiveInstance(), "clusters/primary") {
+		t.Errorf("expected a switchback to the primary instance, got %s", engine.ActiveInstance())
+	}
+}
@@ -0,0 +1,83 @@
+package alloydbutil
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInjectedFault is the default cause of the failures injected with
+// WithQueryFaults.
+var ErrInjectedFault = errors.New("injected query fault")
+
+// QueryFaults configures the faults injected into database queries, to
+// verify retry and fallback behavior in tests and staging environments.
+type QueryFaults struct {
+	// Latency delays every query of the connection pools created by the
+	// engine.
+	Latency time.Duration
+	// ErrorRate is the fraction (0-1) of the attempts of PostgresEngine.Retry
+	// and PostgresEngine.Read that fail with a FaultError before running.
+	ErrorRate float64
+	// Err is the cause of the failures, ErrInjectedFault if not set.
+	Err error
+}
+
+// FaultError is the error of an attempt failed by WithQueryFaults. It is
+// transient, so PostgresEngine.Retry retries it, and PostgresEngine.Read falls
+// back to the primary instance on it as if the read pool were unreachable.
+type FaultError struct {
+	Err error
+}
+
+func (e *FaultError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FaultError) Unwrap() error {
+	return e.Err
+}
+
+// faultInjector fails attempts at the error rate of QueryFaults.
+type faultInjector struct {
+	faults QueryFaults
+	random func() float64
+}
+
+func newFaultInjector(faults QueryFaults) *faultInjector {
+	if faults.Err == nil {
+		faults.Err = ErrInjectedFault
+	}
+	return &faultInjector{faults: faults, random: rand.Float64} //nolint:gosec
+}
+
+// inject returns a FaultError for the attempts chosen to fail. It is safe to
+// call on a nil injector.
+func (f *faultInjector) inject() error {
+	if f == nil || f.random() >= f.faults.ErrorRate {
+		return nil
+	}
+	return &FaultError{Err: f.faults.Err}
+}
+
+// faultTracer is a pgx.QueryTracer delaying queries by QueryFaults.Latency.
+type faultTracer struct {
+	latency time.Duration
+}
+
+var _ pgx.QueryTracer = faultTracer{}
+
+func (f faultTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	if f.latency > 0 {
+		select {
+		case <-time.After(f.latency):
+		case <-ctx.Done():
+		}
+	}
+	return ctx
+}
+
+func (faultTracer) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
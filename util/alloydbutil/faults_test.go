@@ -0,0 +1,96 @@
+package alloydbutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sequence returns a random source returning values in order.
+func sequence(values ...float64) func() float64 {
+	return func() float64 {
+		value := values[0]
+		values = values[1:]
+		return value
+	}
+}
+
+func TestFaultInjector(t *testing.T) {
+	t.Parallel()
+	errUnavailable := errors.New("unavailable")
+	faults := newFaultInjector(QueryFaults{ErrorRate: 0.5, Err: errUnavailable})
+	faults.random = sequence(0.4, 0.6)
+
+	err := faults.inject()
+	if !errors.Is(err, errUnavailable) || !IsTransientError(err) {
+		t.Errorf("expected transient injected error, got %v", err)
+	}
+	if err := faults.inject(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (*faultInjector)(nil).inject(); err != nil {
+		t.Errorf("unexpected error without faults: %v", err)
+	}
+}
+
+func TestRetryInjectedFaults(t *testing.T) {
+	t.Parallel()
+	faults := newFaultInjector(QueryFaults{ErrorRate: 0.5})
+	faults.random = sequence(0.1, 0.2, 0.9)
+	engine := PostgresEngine{
+		retryPolicy: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		faults:      faults,
+	}
+
+	calls := 0
+	err := engine.Retry(context.Background(), func(context.Context) error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected success after retrying injected faults, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call after 2 injected faults, got %d", calls)
+	}
+
+	faults.random = sequence(0.1, 0.1, 0.1)
+	err = engine.Retry(context.Background(), func(context.Context) error { return nil })
+	if !errors.Is(err, ErrInjectedFault) {
+		t.Errorf("expected injected fault after max attempts, got %v", err)
+	}
+}
+
+func TestReadInjectedFaults(t *testing.T) {
+	t.Parallel()
+	faults := newFaultInjector(QueryFaults{ErrorRate: 0.5})
+	faults.random = sequence(0.1, 0.9)
+	primary, read := new(pgxpool.Pool), new(pgxpool.Pool)
+	engine := PostgresEngine{Pool: primary, readPool: read, faults: faults}
+
+	var used []*pgxpool.Pool
+	err := engine.Read(context.Background(), func(_ context.Context, pool *pgxpool.Pool) error {
+		used = append(used, pool)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(used) != 1 || used[0] != primary {
+		t.Errorf("expected fallback to the primary pool after an injected fault, got %d calls", len(used))
+	}
+}
+
+func TestFaultTracerLatency(t *testing.T) {
+	t.Parallel()
+	tracer := faultTracer{latency: 20 * time.Millisecond}
+	start := time.Now()
+	tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{})
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected query delayed by 20ms, got %s", elapsed)
+	}
+}
@@ -0,0 +1,67 @@
+package alloydbutil
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// HealthStatus describes the state of the engine's connection to AlloyDB.
+type HealthStatus struct {
+	// Reachable reports whether the primary instance answered.
+	Reachable bool
+	// Latency is the round trip time to the primary instance.
+	Latency time.Duration
+	// VectorExtension reports whether the pgvector extension is installed.
+	VectorExtension bool
+	// ScaNNExtension reports whether the alloydb_scann extension is
+	// installed.
+	ScaNNExtension bool
+	// IAMAuth reports whether IAM database authentication is used.
+	IAMAuth bool
+	// ReadInstanceReachable reports whether the read pool instance set with
+	// WithReadInstance answered. It is false without one.
+	ReadInstanceReachable bool
+	// FailedOver reports whether the pool connects to the secondary
+	// instance set with WithSecondaryInstance instead of the primary one.
+	FailedOver bool
+}
+
+// Ping checks that the primary instance is reachable, connecting to it if
+// needed.
+func (p *PostgresEngine) Ping(ctx context.Context) error {
+	if err := p.Pool.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to ping AlloyDB instance: %w", err)
+	}
+	return nil
+}
+
+// Health checks the instances of the engine and the extensions installed in
+// the database. The returned status is filled in as far as the checks got
+// when an error is returned.
+func (p *PostgresEngine) Health(ctx context.Context) (HealthStatus, error) {
+	status := HealthStatus{IAMAuth: p.usingIAMAuth}
+	if p.failover != nil {
+		status.FailedOver = p.failover.active.Load() == secondaryInstance
+	}
+
+	start := time.Now()
+	if err := p.Ping(ctx); err != nil {
+		return status, err
+	}
+	status.Latency = time.Since(start)
+	status.Reachable = true
+
+	if p.readPool != nil {
+		status.ReadInstanceReachable = p.readPool.Ping(ctx) == nil
+	}
+
+	const query = `SELECT
+		EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'vector'),
+		EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'alloydb_scann')`
+	err := p.Pool.QueryRow(ctx, query).Scan(&status.VectorExtension, &status.ScaNNExtension)
+	if err != nil {
+		return status, fmt.Errorf("failed to check installed extensions: %w", err)
+	}
+	return status, nil
+}
@@ -0,0 +1,117 @@
+package alloydbutil
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrMissingID is returned by the CallerProvided generator, which never
+// generates identifiers on its own.
+var ErrMissingID = errors.New("missing caller provided id")
+
+// crockfordAlphabet is the base32 alphabet used to encode ULIDs.
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// IDGenerator generates identifiers for vectorstore rows and chat messages.
+type IDGenerator interface {
+	// NewID returns a new identifier.
+	NewID() (string, error)
+	// DataType returns the column type used to store the identifiers.
+	DataType() string
+}
+
+// UUIDv4 generates random UUIDs. It is the default generator.
+type UUIDv4 struct{}
+
+func (UUIDv4) NewID() (string, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate uuid v4: %w", err)
+	}
+	return id.String(), nil
+}
+
+func (UUIDv4) DataType() string {
+	return "UUID"
+}
+
+// UUIDv7 generates time-ordered UUIDs, which keep B-tree inserts local for
+// insert-heavy workloads.
+type UUIDv7 struct{}
+
+func (UUIDv7) NewID() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate uuid v7: %w", err)
+	}
+	return id.String(), nil
+}
+
+func (UUIDv7) DataType() string {
+	return "UUID"
+}
+
+// ULID generates lexicographically sortable identifiers encoded as 26
+// character Crockford base32 strings.
+type ULID struct{}
+
+func (ULID) NewID() (string, error) {
+	return newULID(time.Now())
+}
+
+func (ULID) DataType() string {
+	return "CHAR(26)"
+}
+
+// CallerProvided expects every row to carry its own identifier and fails
+// instead of generating one. It can't be used for chat messages, which carry
+// no identifier.
+type CallerProvided struct {
+	// ColumnType is the column type used to store the identifiers. Defaults
+	// to TEXT.
+	ColumnType string
+}
+
+func (CallerProvided) NewID() (string, error) {
+	return "", ErrMissingID
+}
+
+func (c CallerProvided) DataType() string {
+	if c.ColumnType == "" {
+		return "TEXT"
+	}
+	return c.ColumnType
+}
+
+// newULID builds a ULID from the millisecond timestamp of t followed by 80
+// random bits.
+func newULID(t time.Time) (string, error) {
+	var b [16]byte
+	ms := uint64(t.UnixMilli())
+	for i := 0; i < 6; i++ {
+		b[i] = byte(ms >> (8 * (5 - i)))
+	}
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", fmt.Errorf("failed to generate ulid entropy: %w", err)
+	}
+
+	// 128 bits are encoded as 26 characters of 5 bits each, the first
+	// character carrying two leading zero bits.
+	out := make([]byte, 26)
+	for i := range out {
+		var v byte
+		for j := 0; j < 5; j++ {
+			bit := i*5 + j - 2
+			v <<= 1
+			if bit >= 0 && b[bit/8]&(0x80>>(bit%8)) != 0 {
+				v |= 1
+			}
+		}
+		out[i] = crockfordAlphabet[v]
+	}
+	return string(out), nil
+}
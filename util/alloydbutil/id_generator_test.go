@@ -0,0 +1,104 @@
+package alloydbutil
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestIDGenerators(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		generator IDGenerator
+		dataType  string
+		validate  func(t *testing.T, id string)
+	}{
+		{
+			name:      "UUIDv4",
+			generator: UUIDv4{},
+			dataType:  "UUID",
+			validate: func(t *testing.T, id string) {
+				t.Helper()
+				if v := uuid.MustParse(id).Version(); v != 4 {
+					t.Errorf("expected version 4, got %d", v)
+				}
+			},
+		},
+		{
+			name:      "UUIDv7",
+			generator: UUIDv7{},
+			dataType:  "UUID",
+			validate: func(t *testing.T, id string) {
+				t.Helper()
+				if v := uuid.MustParse(id).Version(); v != 7 {
+					t.Errorf("expected version 7, got %d", v)
+				}
+			},
+		},
+		{
+			name:      "ULID",
+			generator: ULID{},
+			dataType:  "CHAR(26)",
+			validate: func(t *testing.T, id string) {
+				t.Helper()
+				if len(id) != 26 {
+					t.Errorf("expected 26 characters, got %d", len(id))
+				}
+				for _, r := range id {
+					if !strings.ContainsRune(crockfordAlphabet, r) {
+						t.Errorf("unexpected character %q in %s", r, id)
+					}
+				}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if tc.generator.DataType() != tc.dataType {
+				t.Errorf("expected data type %s, got %s", tc.dataType, tc.generator.DataType())
+			}
+			id, err := tc.generator.NewID()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.validate(t, id)
+		})
+	}
+}
+
+func TestULIDIsTimeOrdered(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	first, err := newULID(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := newULID(now.Add(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first[:10] >= second[:10] {
+		t.Errorf("expected %s to sort before %s", first, second)
+	}
+}
+
+func TestCallerProvided(t *testing.T) {
+	t.Parallel()
+	_, err := CallerProvided{}.NewID()
+	if !errors.Is(err, ErrMissingID) {
+		t.Errorf("expected ErrMissingID, got %v", err)
+	}
+	if got := (CallerProvided{}).DataType(); got != "TEXT" {
+		t.Errorf("expected TEXT, got %s", got)
+	}
+	if got := (CallerProvided{ColumnType: "BIGINT"}).DataType(); got != "BIGINT" {
+		t.Errorf("expected BIGINT, got %s", got)
+	}
+}
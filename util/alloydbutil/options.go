@@ -1,9 +1,13 @@
 package alloydbutil
 
 import (
-	"errors"
+	"context"
+	"crypto/tls"
+	"fmt"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -25,6 +29,36 @@ type engineConfig struct {
 	ipType          string
 	iamAccountEmail string
 	emailRetreiver  EmailRetriever
+	queryFaults     *QueryFaults
+	retryPolicy     RetryPolicy
+	tracerProvider  trace.TracerProvider
+	meterProvider   metric.MeterProvider
+	readInstance    *alloyDBInstance
+	sslMode         string
+	tlsConfig       *tls.Config
+	sslRootCert     string
+	sslCert         string
+	sslKey          string
+	lazyConnect     bool
+	// resolveUser, when set, retrieves the user when connecting.
+	resolveUser func(context.Context) (string, error)
+	// secondaryInstance and failoverPolicy configure the failover set
+	// with WithSecondaryInstance.
+	secondaryInstance *alloyDBInstance
+	failoverPolicy    *FailoverPolicy
+}
+
+// alloyDBInstance identifies an AlloyDB instance.
+type alloyDBInstance struct {
+	projectID string
+	region    string
+	cluster   string
+	instance  string
+}
+
+// uri returns the instance URI used by the AlloyDB connector.
+func (i alloyDBInstance) uri() string {
+	return fmt.Sprintf("projects/%s/locations/%s/clusters/%s/instances/%s", i.projectID, i.region, i.cluster, i.instance)
 }
 
 // VectorstoreTableOptions is used with the InitVectorstoreTable to use the required and default fields.
@@ -39,6 +73,95 @@ type VectorstoreTableOptions struct {
 	MetadataColumns    []Column
 	OverwriteExisting  bool
 	StoreMetadata      bool
+	// IfNotExists keeps an existing table instead of failing to create it.
+	IfNotExists bool
+	// AddMissingColumns, used with IfNotExists, adds the metadata columns
+	// not yet present in an existing table. They are added as nullable, the
+	// existing rows having no value for them.
+	AddMissingColumns bool
+	// IDGenerator determines the ID column type when IDColumn.DataType is
+	// not set.
+	IDGenerator IDGenerator
+	// EmbeddingHashColumn, when set, adds a column holding the hash of the
+	// content each embedding was computed from, used to find embeddings left
+	// stale by content edits.
+	EmbeddingHashColumn string
+	// EmbeddingType is the type of the embedding column, EmbeddingTypeVector
+	// by default.
+	EmbeddingType EmbeddingType
+	// AdditionalEmbeddingColumns adds nullable embedding columns of the same
+	// type and size as EmbeddingColumn, holding other representations of the
+	// documents, e.g. the embeddings of their titles.
+	AdditionalEmbeddingColumns []string
+	// ExpiresAtColumn, when set, adds an indexed column holding the time
+	// each document expires at, after which it is no longer returned by
+	// searches and is deleted by PurgeExpired. Documents without an expiry
+	// are kept.
+	ExpiresAtColumn string
+	// ArchiveKeyColumn, when set, adds a column holding the cold storage key
+	// of the content of each archived document, whose content column is
+	// emptied.
+	ArchiveKeyColumn string
+}
+
+// EmbeddingType is a pgvector column type storing embeddings.
+type EmbeddingType string
+
+const (
+	// EmbeddingTypeVector stores single precision vectors.
+	EmbeddingTypeVector EmbeddingType = "vector"
+	// EmbeddingTypeHalfVec stores half precision vectors, halving the
+	// storage of high dimensional embeddings with little recall loss.
+	EmbeddingTypeHalfVec EmbeddingType = "halfvec"
+	// EmbeddingTypeBit stores binary quantized vectors, searched with the
+	// Hamming or Jaccard distance.
+	EmbeddingTypeBit EmbeddingType = "bit"
+	// EmbeddingTypeSparseVec stores sparse vectors.
+	EmbeddingTypeSparseVec EmbeddingType = "sparsevec"
+)
+
+// Valid reports whether t is a supported embedding type.
+func (t EmbeddingType) Valid() bool {
+	switch t {
+	case EmbeddingTypeVector, EmbeddingTypeHalfVec, EmbeddingTypeBit, EmbeddingTypeSparseVec:
+		return true
+	}
+	return false
+}
+
+// DocumentAuditTableOptions is used with InitDocumentAuditTable to create the
+// table recording which documents were returned by each retrieval.
+type DocumentAuditTableOptions struct {
+	TableName  string
+	SchemaName string
+}
+
+// DocstoreTableOptions is used with InitDocstoreTable to create the table of
+// a document store, keeping full documents by key.
+type DocstoreTableOptions struct {
+	TableName  string
+	SchemaName string
+}
+
+// RecordManagerTableOptions is used with InitRecordManagerTable to create the
+// table recording the documents indexed in vector stores.
+type RecordManagerTableOptions struct {
+	TableName  string
+	SchemaName string
+}
+
+// EntityMemoryTableOptions is used with InitEntityMemoryTable to create the
+// table of the summaries of the entities of conversations.
+type EntityMemoryTableOptions struct {
+	TableName  string
+	SchemaName string
+}
+
+// EmbeddingCacheTableOptions is used with InitEmbeddingCacheTable to create
+// the table caching the vectors of embedded texts.
+type EmbeddingCacheTableOptions struct {
+	TableName  string
+	SchemaName string
 }
 
 // WithAlloyDBInstance sets the project, region, cluster, and instance fields.
@@ -51,6 +174,47 @@ func WithAlloyDBInstance(projectID, region, cluster, instance string) Option {
 	}
 }
 
+// WithReadInstance routes reads, such as similarity searches and chat
+// history retrieval, to an AlloyDB read pool instance of the cluster, using
+// the same database and credentials as the primary instance. Reads fall back
+// to the primary instance when the read pool instance is unreachable.
+func WithReadInstance(projectID, region, cluster, instance string) Option {
+	return func(p *engineConfig) {
+		p.readInstance = &alloyDBInstance{
+			projectID: projectID,
+			region:    region,
+			cluster:   cluster,
+			instance:  instance,
+		}
+	}
+}
+
+// WithSecondaryInstance sets an instance of a secondary cluster, typically
+// in another region, that the engine's pool fails over to when the primary
+// instance becomes unhealthy, using the same database and credentials. The
+// health of both instances is checked in the background, and the pool
+// switches back to the primary instance once it recovers, as configured with
+// WithFailoverPolicy. Transactions and queries in flight on the failing
+// instance fail, and must be retried by the caller.
+func WithSecondaryInstance(projectID, region, cluster, instance string) Option {
+	return func(p *engineConfig) {
+		p.secondaryInstance = &alloyDBInstance{
+			projectID: projectID,
+			region:    region,
+			cluster:   cluster,
+			instance:  instance,
+		}
+	}
+}
+
+// WithFailoverPolicy configures the health checks, failover and switchback of
+// the secondary instance set with WithSecondaryInstance.
+func WithFailoverPolicy(policy FailoverPolicy) Option {
+	return func(p *engineConfig) {
+		p.failoverPolicy = &policy
+	}
+}
+
 // WithPool sets the Port field.
 func WithPool(pool *pgxpool.Pool) Option {
 	return func(p *engineConfig) {
@@ -93,6 +257,83 @@ func WithIAMAccountEmail(email string) Option {
 	}
 }
 
+// WithSSLMode sets the sslmode of the PostgreSQL connections: "disable",
+// "allow", "prefer", "require", "verify-ca" or "verify-full". The AlloyDB
+// connector always encrypts the connection to the instance; this adds TLS to
+// the PostgreSQL protocol, as required by some organization policies. It
+// defaults to "disable", or "require" when certificates or a TLS config are
+// set.
+func WithSSLMode(sslMode string) Option {
+	return func(p *engineConfig) {
+		p.sslMode = sslMode
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the PostgreSQL connections,
+// taking precedence over the one derived from the sslmode and certificates.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(p *engineConfig) {
+		p.tlsConfig = tlsConfig
+	}
+}
+
+// WithSSLCertificates sets the paths of the root CA certificate verifying the
+// server, and of the client certificate and key. Any of them can be empty.
+func WithSSLCertificates(rootCert, cert, key string) Option {
+	return func(p *engineConfig) {
+		p.sslRootCert = rootCert
+		p.sslCert = cert
+		p.sslKey = key
+	}
+}
+
+// WithLazyConnect creates the engine without connecting to the instance,
+// deferring the connection, and the retrieval of the IAM principal from the
+// environment, to the first query. Without it NewPostgresEngine checks that
+// the instance is reachable. It suits serverless environments, where the
+// connector also refreshes its certificates on demand instead of in the
+// background.
+func WithLazyConnect() Option {
+	return func(p *engineConfig) {
+		p.lazyConnect = true
+	}
+}
+
+// WithQueryFaults injects latency into the queries of the connection pools
+// created by the engine, and errors into the attempts of Retry and Read. The
+// latency has no effect with WithPool.
+func WithQueryFaults(faults QueryFaults) Option {
+	return func(p *engineConfig) {
+		p.queryFaults = &faults
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry transient errors on reads and
+// idempotent writes. Retries are disabled by default.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(p *engineConfig) {
+		p.retryPolicy = policy
+	}
+}
+
+// WithTracerProvider enables OpenTelemetry spans for the queries and batches
+// of the connection pool created by the engine. It has no effect with
+// WithPool; configure the pool's tracer instead.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(p *engineConfig) {
+		p.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider enables OpenTelemetry metrics for the engine's pool:
+// connection counts and acquisitions, and, for pools created by the engine,
+// query and connection acquisition durations.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(p *engineConfig) {
+		p.meterProvider = mp
+	}
+}
+
 func applyClientOptions(opts ...Option) (engineConfig, error) {
 	cfg := &engineConfig{
 		emailRetreiver: getServiceAccountEmail,
@@ -101,8 +342,8 @@ func applyClientOptions(opts ...Option) (engineConfig, error) {
 	for _, opt := range opts {
 		opt(cfg)
 	}
-	if cfg.connPool == nil && cfg.projectID == "" && cfg.region == "" && cfg.cluster == "" && cfg.instance == "" {
-		return engineConfig{}, errors.New("missing connection: provide a connection pool or connection fields")
+	if err := cfg.validate(); err != nil {
+		return engineConfig{}, fmt.Errorf("invalid engine configuration:\n%w", err)
 	}
 
 	return *cfg, nil
@@ -113,7 +354,9 @@ type OptionInitChatHistoryTable func(*InitChatHistoryTableOptions)
 
 // Option type for defining options.
 type InitChatHistoryTableOptions struct {
-	schemaName string
+	schemaName         string
+	messageIDGenerator IDGenerator
+	textSearchConfig   string
 }
 
 // WithSchemaName sets a custom schema name.
@@ -123,6 +366,23 @@ func WithSchemaName(schemaName string) OptionInitChatHistoryTable {
 	}
 }
 
+// WithMessageIDGenerator adds a message_id column matching the generator's
+// data type to the chat history table.
+func WithMessageIDGenerator(generator IDGenerator) OptionInitChatHistoryTable {
+	return func(i *InitChatHistoryTableOptions) {
+		i.messageIDGenerator = generator
+	}
+}
+
+// WithFullTextSearch indexes the text of the messages for full-text search
+// with the given text search configuration, e.g. "english", which the
+// searches must use to benefit from the index.
+func WithFullTextSearch(config string) OptionInitChatHistoryTable {
+	return func(i *InitChatHistoryTableOptions) {
+		i.textSearchConfig = config
+	}
+}
+
 // applyChatMessageHistoryOptions applies the given options to the
 // ChatMessageHistory.
 func applyChatMessageHistoryOptions(opts ...OptionInitChatHistoryTable) InitChatHistoryTableOptions {
@@ -0,0 +1,36 @@
+package alloydbutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrReadOnly is matched by the ReadOnlyError of the operations refused by
+// the vector stores and chat histories in read-only mode.
+var ErrReadOnly = errors.New("read-only mode")
+
+// ReadOnlyError is returned by the mutating operations of the vector stores
+// and chat histories in read-only mode.
+type ReadOnlyError struct {
+	// Operation is the refused operation, e.g. "add documents".
+	Operation string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("cannot %s: %s", e.Operation, ErrReadOnly)
+}
+
+// Is reports whether target is ErrReadOnly.
+func (e *ReadOnlyError) Is(target error) bool {
+	return target == ErrReadOnly
+}
+
+// ReadOnlyTx runs fn in a read-only transaction on pool, in which Postgres
+// rejects any write.
+func ReadOnlyTx(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
+	return pgx.BeginTxFunc(ctx, pool, pgx.TxOptions{AccessMode: pgx.ReadOnly}, fn)
+}
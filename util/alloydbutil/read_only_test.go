@@ -0,0 +1,18 @@
+package alloydbutil
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestReadOnlyError(t *testing.T) {
+	t.Parallel()
+	err := fmt.Errorf("failed: %w", &ReadOnlyError{Operation: "add documents"})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected %v to match ErrReadOnly", err)
+	}
+	if want := "failed: cannot add documents: read-only mode"; err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+}
@@ -0,0 +1,50 @@
+package alloydbutil
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Read calls fn with the pool of the read pool instance set with
+// WithReadInstance, or with the primary pool when there is none. If the read
+// pool instance is unreachable fn is called again with the primary pool, so
+// fn must be a read that is safe to repeat.
+func (p *PostgresEngine) Read(ctx context.Context, fn func(ctx context.Context, pool *pgxpool.Pool) error) error {
+	read := func(pool *pgxpool.Pool) error {
+		if err := p.faults.inject(); err != nil {
+			return err
+		}
+		return fn(ctx, pool)
+	}
+	if p.readPool == nil {
+		return read(p.Pool)
+	}
+	err := read(p.readPool)
+	if err == nil || ctx.Err() != nil || !isUnreachable(err) {
+		return err
+	}
+	return read(p.Pool)
+}
+
+// isUnreachable reports whether err means the instance couldn't be connected
+// to or is shutting down, or is a fault injected with WithQueryFaults.
+func isUnreachable(err error) bool {
+	var faultErr *FaultError
+	if errors.As(err, &faultErr) {
+		return true
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || // connection_exception
+			strings.HasPrefix(pgErr.Code, "57P") // operator_intervention
+	}
+	return false
+}
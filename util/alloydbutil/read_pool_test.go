@@ -0,0 +1,69 @@
+package alloydbutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestReadFallsBackToPrimary(t *testing.T) {
+	t.Parallel()
+	primary, read := new(pgxpool.Pool), new(pgxpool.Pool)
+	engine := PostgresEngine{Pool: primary, readPool: read}
+
+	tests := []struct {
+		name    string
+		readErr error
+		want    []*pgxpool.Pool
+	}{
+		{name: "read pool available", want: []*pgxpool.Pool{read}},
+		{name: "read pool unreachable", readErr: &pgconn.PgError{Code: "08006"}, want: []*pgxpool.Pool{read, primary}},
+		{name: "read pool shutting down", readErr: &pgconn.PgError{Code: "57P01"}, want: []*pgxpool.Pool{read, primary}},
+		{name: "query error", readErr: &pgconn.PgError{Code: "42P01"}, want: []*pgxpool.Pool{read}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var used []*pgxpool.Pool
+			err := engine.Read(context.Background(), func(_ context.Context, pool *pgxpool.Pool) error {
+				used = append(used, pool)
+				if pool == read {
+					return tt.readErr
+				}
+				return nil
+			})
+			if len(used) != len(tt.want) {
+				t.Fatalf("expected %d calls, got %d", len(tt.want), len(used))
+			}
+			for i := range used {
+				if used[i] != tt.want[i] {
+					t.Errorf("call %d used the wrong pool", i)
+				}
+			}
+			if len(tt.want) == 1 && !errors.Is(err, tt.readErr) {
+				t.Errorf("expected error %v, got %v", tt.readErr, err)
+			}
+			if len(tt.want) == 2 && err != nil {
+				t.Errorf("unexpected error after falling back: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadWithoutReadPool(t *testing.T) {
+	t.Parallel()
+	primary := new(pgxpool.Pool)
+	engine := PostgresEngine{Pool: primary}
+	err := engine.Read(context.Background(), func(_ context.Context, pool *pgxpool.Pool) error {
+		if pool != primary {
+			t.Error("expected the primary pool")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
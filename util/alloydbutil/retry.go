@@ -0,0 +1,113 @@
+package alloydbutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RetryPolicy configures the retries of transient errors on reads and
+// idempotent writes made through PostgresEngine.Retry.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first
+	// one. Values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the upper bound of the delay before the first retry.
+	// It doubles with every retry, up to MaxBackoff, and the actual delay is
+	// chosen at random below it.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy returns a policy making up to 3 attempts with backoffs
+// between 100ms and 2s.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+	}
+}
+
+// backoff returns the jittered delay before retry number attempt, starting
+// at 0.
+func (r RetryPolicy) backoff(attempt int, random func() float64) time.Duration {
+	ceiling := r.InitialBackoff
+	for i := 0; i < attempt && (r.MaxBackoff <= 0 || ceiling < r.MaxBackoff); i++ {
+		ceiling *= 2
+	}
+	if r.MaxBackoff > 0 && ceiling > r.MaxBackoff {
+		ceiling = r.MaxBackoff
+	}
+	return time.Duration(random() * float64(ceiling))
+}
+
+// Retry calls fn until it succeeds, returns a non transient error, the
+// attempts of the engine's retry policy are exhausted or ctx is done. fn must
+// be safe to repeat, e.g. a read or an idempotent write.
+func (p *PostgresEngine) Retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	random := rand.Float64 //nolint:gosec
+	for attempt := 1; ; attempt++ {
+		err := p.faults.inject()
+		if err == nil {
+			err = fn(ctx)
+		}
+		if err == nil || attempt >= p.retryPolicy.MaxAttempts || !p.isRetryable(err) {
+			return err
+		}
+		timer := time.NewTimer(p.retryPolicy.backoff(attempt-1, random))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryable reports whether err is transient. With IAM authentication,
+// authentication failures are retried too, as they can be caused by a token
+// being refreshed.
+func (p *PostgresEngine) isRetryable(err error) bool {
+	if IsTransientError(err) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return p.usingIAMAuth && errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "28")
+}
+
+// IsTransientError reports whether err is a serialization failure, a
+// deadlock, a connection failure or a server shutdown, after which the
+// operation can be retried.
+func IsTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var faultErr *FaultError
+	if errors.As(err, &faultErr) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "40001", // serialization_failure
+			pgErr.Code == "40P01",                // deadlock_detected
+			pgErr.Code == "53300",                // too_many_connections
+			strings.HasPrefix(pgErr.Code, "08"),  // connection_exception
+			strings.HasPrefix(pgErr.Code, "57P"): // operator_intervention
+			return true
+		}
+		return false
+	}
+	return pgconn.SafeToRetry(err) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,132 @@
+package alloydbutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsTransientError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &pgconn.PgError{Code: "40001"}, want: true},
+		{err: fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: "40P01"}), want: true},
+		{err: &pgconn.PgError{Code: "08006"}, want: true},
+		{err: &pgconn.PgError{Code: "57P01"}, want: true},
+		{err: &pgconn.PgError{Code: "23505"}, want: false},
+		{err: &pgconn.PgError{Code: "28P01"}, want: false},
+		{err: syscall.ECONNRESET, want: true},
+		{err: context.DeadlineExceeded, want: false},
+		{err: errors.New("boom"), want: false},
+	}
+	for _, tc := range tests {
+		if got := IsTransientError(tc.err); got != tc.want {
+			t.Errorf("IsTransientError(%v) = %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	transient := &pgconn.PgError{Code: "40001"}
+
+	tests := []struct {
+		name         string
+		engine       PostgresEngine
+		errs         []error
+		wantAttempts int
+		wantErr      error
+	}{
+		{
+			name:         "succeeds after transient errors",
+			engine:       PostgresEngine{retryPolicy: policy},
+			errs:         []error{transient, transient, nil},
+			wantAttempts: 3,
+		},
+		{
+			name:         "gives up after max attempts",
+			engine:       PostgresEngine{retryPolicy: policy},
+			errs:         []error{transient, transient, transient, nil},
+			wantAttempts: 3,
+			wantErr:      transient,
+		},
+		{
+			name:         "does not retry permanent errors",
+			engine:       PostgresEngine{retryPolicy: policy},
+			errs:         []error{&pgconn.PgError{Code: "23505"}, nil},
+			wantAttempts: 1,
+			wantErr:      &pgconn.PgError{Code: "23505"},
+		},
+		{
+			name:         "retries authentication failures with IAM",
+			engine:       PostgresEngine{retryPolicy: policy, usingIAMAuth: true},
+			errs:         []error{&pgconn.PgError{Code: "28P01"}, nil},
+			wantAttempts: 2,
+		},
+		{
+			name:         "disabled by default",
+			engine:       PostgresEngine{},
+			errs:         []error{transient, nil},
+			wantAttempts: 1,
+			wantErr:      transient,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			attempts := 0
+			err := tc.engine.Retry(context.Background(), func(context.Context) error {
+				err := tc.errs[attempts]
+				attempts++
+				return err
+			})
+			if attempts != tc.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tc.wantAttempts, attempts)
+			}
+			var pgErr *pgconn.PgError
+			if tc.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tc.wantErr != nil && (!errors.As(err, &pgErr) || pgErr.Code != tc.wantErr.(*pgconn.PgError).Code) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRetryContextCancelled(t *testing.T) {
+	t.Parallel()
+	engine := PostgresEngine{retryPolicy: RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := engine.Retry(ctx, func(context.Context) error {
+		return syscall.ECONNRESET
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, syscall.ECONNRESET) {
+		t.Errorf("expected deadline exceeded and last error, got %v", err)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	maxRandom := func() float64 { return 1 }
+	for attempt, want := range []time.Duration{
+		100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond,
+		800 * time.Millisecond, time.Second, time.Second,
+	} {
+		if got := policy.backoff(attempt, maxRandom); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
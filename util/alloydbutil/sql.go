@@ -0,0 +1,82 @@
+package alloydbutil
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// dataTypeRegexp matches the data types accepted in column definitions,
+// e.g. "TEXT", "vector(768)", "character varying(64)" or "int[]".
+var dataTypeRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_ ]*(\([0-9, ]+\))?(\[\])*$`)
+
+// textSearchConfigRegexp matches the names of text search configurations,
+// e.g. "english" or "pg_catalog.simple", which are inlined in the index
+// expression.
+var textSearchConfigRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// QuoteIdentifier quotes a possibly schema qualified identifier, e.g.
+// QuoteIdentifier("public", "items") returns "public"."items". The stores
+// and memories quote the names of their tables and columns with it, so that
+// any table created by the Init*Table methods can be queried.
+func QuoteIdentifier(parts ...string) string {
+	return pgx.Identifier(parts).Sanitize()
+}
+
+// validateDataType returns an error if dataType is not a plain type name,
+// as data types can't be quoted like identifiers.
+func validateDataType(dataType string) error {
+	if !dataTypeRegexp.MatchString(dataType) {
+		return fmt.Errorf("invalid data type %q", dataType)
+	}
+	return nil
+}
+
+// createTableBuilder builds CREATE TABLE statements with quoted identifiers.
+type createTableBuilder struct {
+	table       string
+	ifNotExists bool
+	columns     []string
+}
+
+func newCreateTable(schemaName, tableName string) *createTableBuilder {
+	return &createTableBuilder{table: QuoteIdentifier(schemaName, tableName)}
+}
+
+// IfNotExists makes the statement keep an existing table.
+func (b *createTableBuilder) IfNotExists() *createTableBuilder {
+	b.ifNotExists = true
+	return b
+}
+
+// Column adds a column with the given data type and constraints.
+func (b *createTableBuilder) Column(name, dataType string, constraints ...string) *createTableBuilder {
+	b.columns = append(b.columns, columnDefinition(name, dataType, constraints...))
+	return b
+}
+
+// PrimaryKey adds a primary key constraint on columns, e.g. a composite key.
+func (b *createTableBuilder) PrimaryKey(columns ...string) *createTableBuilder {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = QuoteIdentifier(column)
+	}
+	b.columns = append(b.columns, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(quoted, ", ")))
+	return b
+}
+
+// String returns the CREATE TABLE statement.
+func (b *createTableBuilder) String() string {
+	ifNotExists := ""
+	if b.ifNotExists {
+		ifNotExists = "IF NOT EXISTS "
+	}
+	return fmt.Sprintf("CREATE TABLE %s%s (%s);", ifNotExists, b.table, strings.Join(b.columns, ", "))
+}
+
+// columnDefinition returns a column definition with a quoted name.
+func columnDefinition(name, dataType string, constraints ...string) string {
+	return strings.Join(append([]string{QuoteIdentifier(name), dataType}, constraints...), " ")
+}
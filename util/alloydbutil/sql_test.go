@@ -0,0 +1,200 @@
+package alloydbutil
+
+import (
+	"strings"
+	"testing"
+)
+
+// stripIdentifiers removes the quoted identifiers of query, failing on an
+// unterminated one.
+func stripIdentifiers(t *testing.T, query string) string {
+	t.Helper()
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(query); i++ {
+		switch {
+		case query[i] != '"':
+			if !quoted {
+				b.WriteByte(query[i])
+			}
+		case quoted && i+1 < len(query) && query[i+1] == '"':
+			i++
+		default:
+			quoted = !quoted
+		}
+	}
+	if quoted {
+		t.Fatalf("unterminated identifier in %s", query)
+	}
+	return b.String()
+}
+
+func FuzzCreateVectorstoreTableQuery(f *testing.F) {
+	f.Add("items", "public", "content", "area")
+	f.Add(`it"ems`, `pub"; DROP TABLE x; --`, "con\x00tent", `"`)
+	f.Fuzz(func(t *testing.T, tableName, schemaName, contentColumn, metadataColumn string) {
+		opts := VectorstoreTableOptions{
+			TableName:         tableName,
+			SchemaName:        schemaName,
+			ContentColumnName: contentColumn,
+			VectorSize:        3,
+			StoreMetadata:     true,
+			MetadataColumns:   []Column{{Name: metadataColumn, DataType: "text"}},
+		}
+		if err := validateVectorstoreTableOptions(&opts); err != nil {
+			t.Skip()
+		}
+		queries := append([]string{createVectorstoreTableQuery(opts)}, addVectorstoreColumnsQueries(opts)...)
+		for _, query := range queries {
+			stripped := stripIdentifiers(t, query)
+			if strings.Count(stripped, ";") != 1 || !strings.HasSuffix(stripped, ";") {
+				t.Errorf("identifiers escaped the statement %s", query)
+			}
+		}
+	})
+}
+
+func FuzzCreateChatHistoryTableQuery(f *testing.F) {
+	f.Add("messages", "public")
+	f.Add(`mes"sages`, `"; DROP TABLE x; --`)
+	f.Fuzz(func(t *testing.T, tableName, schemaName string) {
+		cfg := InitChatHistoryTableOptions{schemaName: schemaName}
+		for _, createQuery := range []func(InitChatHistoryTableOptions, string) (string, error){
+			createChatHistoryTableQuery, createChatHistoryArchiveTableQuery,
+		} {
+			query, err := createQuery(cfg, tableName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stripped := stripIdentifiers(t, query)
+			if strings.Count(stripped, ";") != 1 || !strings.HasSuffix(stripped, ";") {
+				t.Errorf("identifiers escaped the statement %s", query)
+			}
+		}
+	})
+}
+
+func FuzzCreateDocstoreTableQuery(f *testing.F) {
+	f.Add("documents", "public")
+	f.Add(`docu"ments`, `"; DROP TABLE x; --`)
+	f.Fuzz(func(t *testing.T, tableName, schemaName string) {
+		query := createDocstoreTableQuery(DocstoreTableOptions{TableName: tableName, SchemaName: schemaName})
+		stripped := stripIdentifiers(t, query)
+		if strings.Count(stripped, ";") != 1 || !strings.HasSuffix(stripped, ";") {
+			t.Errorf("identifiers escaped the statement %s", query)
+		}
+	})
+}
+
+func FuzzCreateRecordManagerTableQuery(f *testing.F) {
+	f.Add("records", "public")
+	f.Add(`rec"ords`, `"; DROP TABLE x; --`)
+	f.Fuzz(func(t *testing.T, tableName, schemaName string) {
+		query := createRecordManagerTableQuery(RecordManagerTableOptions{TableName: tableName, SchemaName: schemaName})
+		stripped := stripIdentifiers(t, query)
+		if strings.Count(stripped, ";") != 1 || !strings.HasSuffix(stripped, ";") {
+			t.Errorf("identifiers escaped the statement %s", query)
+		}
+	})
+}
+
+func TestCreateRecordManagerTableQuery(t *testing.T) {
+	t.Parallel()
+	got := createRecordManagerTableQuery(RecordManagerTableOptions{TableName: "records", SchemaName: "public"})
+	want := `CREATE TABLE IF NOT EXISTS "public"."records" ("namespace" TEXT NOT NULL, "key" TEXT NOT NULL, "source_id" TEXT NOT NULL DEFAULT '', "document_id" TEXT NOT NULL, "updated_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(), PRIMARY KEY ("namespace", "key"));`
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func FuzzCreateEntityMemoryTableQuery(f *testing.F) {
+	f.Add("entities", "public")
+	f.Add(`ent"ities`, `"; DROP TABLE x; --`)
+	f.Fuzz(func(t *testing.T, tableName, schemaName string) {
+		query := createEntityMemoryTableQuery(EntityMemoryTableOptions{TableName: tableName, SchemaName: schemaName})
+		stripped := stripIdentifiers(t, query)
+		if strings.Count(stripped, ";") != 1 || !strings.HasSuffix(stripped, ";") {
+			t.Errorf("identifiers escaped the statement %s", query)
+		}
+	})
+}
+
+func TestCreateEntityMemoryTableQuery(t *testing.T) {
+	t.Parallel()
+	got := createEntityMemoryTableQuery(EntityMemoryTableOptions{TableName: "entities", SchemaName: "public"})
+	want := `CREATE TABLE IF NOT EXISTS "public"."entities" ("session_id" TEXT NOT NULL, "entity" TEXT NOT NULL, "summary" TEXT NOT NULL, "updated_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(), PRIMARY KEY ("session_id", "entity"));`
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		parts []string
+		want  string
+	}{
+		{parts: []string{"public", "items"}, want: `"public"."items"`},
+		{parts: []string{`it"ems`}, want: `"it""ems"`},
+		{parts: []string{"a;b"}, want: `"a;b"`},
+	}
+	for _, tc := range tests {
+		if got := QuoteIdentifier(tc.parts...); got != tc.want {
+			t.Errorf("expected %s, got %s", tc.want, got)
+		}
+	}
+}
+
+func TestValidateDataType(t *testing.T) {
+	t.Parallel()
+	for _, dataType := range []string{"TEXT", "vector(768)", "character varying(64)", "numeric(10, 2)", "int[]"} {
+		if err := validateDataType(dataType); err != nil {
+			t.Errorf("unexpected error for %q: %v", dataType, err)
+		}
+	}
+	for _, dataType := range []string{"", "int; DROP TABLE items", "text DEFAULT 'x'", "int)--"} {
+		if err := validateDataType(dataType); err == nil {
+			t.Errorf("expected error for %q", dataType)
+		}
+	}
+}
+
+func TestCreateChatHistoryTextSearchIndexQuery(t *testing.T) {
+	t.Parallel()
+	cfg := InitChatHistoryTableOptions{schemaName: "public", textSearchConfig: "english"}
+	query, err := createChatHistoryTextSearchIndexQuery(cfg, "messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `CREATE INDEX IF NOT EXISTS "messages_data_fts_idx" ON "public"."messages" USING GIN (jsonb_to_tsvector('english'::regconfig, data, '["string"]'));`
+	if query != want {
+		t.Errorf("expected %s, got %s", want, query)
+	}
+	for _, config := range []string{"", "english'::regconfig, data)); DROP TABLE x; --", "pg_catalog.english.x"} {
+		cfg.textSearchConfig = config
+		if _, err := createChatHistoryTextSearchIndexQuery(cfg, "messages"); err == nil {
+			t.Errorf("expected error for %q", config)
+		}
+	}
+}
+
+func FuzzCreateEmbeddingCacheTableQuery(f *testing.F) {
+	f.Add("embeddings", "public")
+	f.Add(`embed"dings`, `"; DROP TABLE x; --`)
+	f.Fuzz(func(t *testing.T, tableName, schemaName string) {
+		query := createEmbeddingCacheTableQuery(EmbeddingCacheTableOptions{TableName: tableName, SchemaName: schemaName})
+		stripped := stripIdentifiers(t, query)
+		if strings.Count(stripped, ";") != 1 || !strings.HasSuffix(stripped, ";") {
+			t.Errorf("identifiers escaped the statement %s", query)
+		}
+	})
+}
+
+func TestCreateEmbeddingCacheTableQuery(t *testing.T) {
+	t.Parallel()
+	got := createEmbeddingCacheTableQuery(EmbeddingCacheTableOptions{TableName: "embeddings", SchemaName: "public"})
+	want := `CREATE TABLE IF NOT EXISTS "public"."embeddings" ("key" TEXT PRIMARY KEY, "embedding" REAL[] NOT NULL, "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW());`
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,246 @@
+package alloydbutil
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// structTag is the key of the struct tags read by StructColumns and
+// StructMetadata.
+const structTag = "alloydb"
+
+// ErrNotStruct is returned by StructColumns and StructMetadata for values
+// that are neither a struct nor a pointer to a struct.
+var ErrNotStruct = errors.New("not a struct")
+
+// nolint:gochecknoglobals
+var timeType = reflect.TypeOf(time.Time{})
+
+// structField is an exported field of a struct mapped to a metadata column.
+type structField struct {
+	index  []int
+	column Column
+}
+
+// StructColumns returns the metadata columns of the exported fields of v, a
+// struct or a pointer to a struct, so that typed corpora don't have to
+// maintain their Column definitions by hand. The fields of embedded structs
+// are included as if they were fields of v.
+//
+// A column is named after the snake case name of its field, e.g. "user_id"
+// for UserID, and typed after the Go type of the field: TEXT for strings,
+// BOOLEAN, SMALLINT, INTEGER, BIGINT, REAL or DOUBLE PRECISION for numbers,
+// TIMESTAMPTZ for time.Time, BYTEA for []byte, arrays for slices of those
+// types and JSONB for the other maps, slices and structs. The columns are
+// nullable. The field tag "alloydb" overrides the defaults, e.g.
+//
+//	Area    int    `alloydb:"area_km2,notnull"`
+//	Country string `alloydb:",type=VARCHAR(2)"`
+//	Notes   string `alloydb:"-"`
+func StructColumns(v any) ([]Column, error) {
+	fields, err := structFields(v)
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]Column, len(fields))
+	for i, field := range fields {
+		columns[i] = field.column
+	}
+	return columns, nil
+}
+
+// StructMetadata returns the metadata of a document from v, a struct or a
+// pointer to a struct, keyed by the columns returned by StructColumns for
+// its type. Nil pointers, maps and slices are left out, so that their
+// columns are NULL.
+func StructMetadata(v any) (map[string]any, error) {
+	fields, err := structFields(v)
+	if err != nil {
+		return nil, err
+	}
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if !value.IsValid() {
+		return nil, fmt.Errorf("%w: nil %T", ErrNotStruct, v)
+	}
+	metadata := make(map[string]any, len(fields))
+	for _, field := range fields {
+		fieldValue, ok := fieldByIndex(value, field.index)
+		if !ok {
+			continue
+		}
+		switch fieldValue.Kind() { // nolint:exhaustive
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			if fieldValue.IsNil() {
+				continue
+			}
+		}
+		metadata[field.column.Name] = reflect.Indirect(fieldValue).Interface()
+	}
+	return metadata, nil
+}
+
+// ColumnNames returns the names of columns, e.g. to set the metadata
+// columns of a vectorstore to those returned by StructColumns.
+func ColumnNames(columns []Column) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// structFields returns the fields of v mapped to metadata columns.
+func structFields(v any) ([]structField, error) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T", ErrNotStruct, v)
+	}
+	fields, err := appendStructFields(nil, t, nil)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if seen[field.column.Name] {
+			return nil, fmt.Errorf("duplicate column %q in %s", field.column.Name, t)
+		}
+		seen[field.column.Name] = true
+	}
+	return fields, nil
+}
+
+// appendStructFields appends the fields of the struct type t, whose index in
+// the outermost struct starts with index, to fields.
+func appendStructFields(fields []structField, t reflect.Type, index []int) ([]structField, error) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get(structTag)
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		name, options, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && embedded != timeType {
+				var err error
+				fields, err = appendStructFields(fields, embedded, fieldIndex)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		column := Column{Name: name, Nullable: true}
+		if column.Name == "" {
+			column.Name = snakeCase(f.Name)
+		}
+		for _, option := range strings.Split(options, ",") {
+			switch {
+			case option == "":
+			case option == "notnull":
+				column.Nullable = false
+			case strings.HasPrefix(option, "type="):
+				column.DataType = strings.TrimPrefix(option, "type=")
+			default:
+				return nil, fmt.Errorf("field %s: unknown tag option %q", f.Name, option)
+			}
+		}
+		if column.DataType == "" {
+			dataType, err := dataTypeOf(f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			column.DataType = dataType
+		}
+		if err := validateDataType(column.DataType); err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		fields = append(fields, structField{index: fieldIndex, column: column})
+	}
+	return fields, nil
+}
+
+// dataTypeOf returns the data type of the columns of the fields of type t.
+func dataTypeOf(t reflect.Type) (string, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return "TIMESTAMPTZ", nil
+	}
+	switch t.Kind() { // nolint:exhaustive
+	case reflect.String:
+		return "TEXT", nil
+	case reflect.Bool:
+		return "BOOLEAN", nil
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "SMALLINT", nil
+	case reflect.Int32, reflect.Uint16:
+		return "INTEGER", nil
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return "BIGINT", nil
+	case reflect.Uint, reflect.Uint64:
+		return "NUMERIC(20)", nil
+	case reflect.Float32:
+		return "REAL", nil
+	case reflect.Float64:
+		return "DOUBLE PRECISION", nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "BYTEA", nil
+		}
+		elem, err := dataTypeOf(t.Elem())
+		if err != nil || elem == "JSONB" || strings.HasSuffix(elem, "[]") {
+			return "JSONB", nil // nolint:nilerr
+		}
+		return elem + "[]", nil
+	case reflect.Map, reflect.Struct, reflect.Interface:
+		return "JSONB", nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// fieldByIndex returns the field of v at index, and false if it's in a nil
+// embedded struct.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// snakeCase converts a Go field name to snake case, e.g. "UserID" to
+// "user_id" and "HTTPStatus" to "http_status".
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
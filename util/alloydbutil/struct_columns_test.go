@@ -0,0 +1,110 @@
+package alloydbutil
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Audited struct {
+	CreatedAt time.Time
+	Author    *string
+}
+
+type city struct {
+	Audited
+	Name       string `alloydb:",notnull"`
+	Country    string `alloydb:",type=VARCHAR(2)"`
+	Area       int    `alloydb:"area_km2"`
+	Population int64
+	Density    float64
+	HTTPStatus int32
+	Tags       []string
+	Attributes map[string]any
+	Thumbnail  []byte
+	Notes      string `alloydb:"-"`
+	internal   string
+}
+
+func TestStructColumns(t *testing.T) {
+	t.Parallel()
+	columns, err := StructColumns(&city{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Column{
+		{Name: "created_at", DataType: "TIMESTAMPTZ", Nullable: true},
+		{Name: "author", DataType: "TEXT", Nullable: true},
+		{Name: "name", DataType: "TEXT"},
+		{Name: "country", DataType: "VARCHAR(2)", Nullable: true},
+		{Name: "area_km2", DataType: "BIGINT", Nullable: true},
+		{Name: "population", DataType: "BIGINT", Nullable: true},
+		{Name: "density", DataType: "DOUBLE PRECISION", Nullable: true},
+		{Name: "http_status", DataType: "INTEGER", Nullable: true},
+		{Name: "tags", DataType: "TEXT[]", Nullable: true},
+		{Name: "attributes", DataType: "JSONB", Nullable: true},
+		{Name: "thumbnail", DataType: "BYTEA", Nullable: true},
+	}
+	if !reflect.DeepEqual(columns, want) {
+		t.Errorf("expected %v, got %v", want, columns)
+	}
+	if names := ColumnNames(columns[:3]); !reflect.DeepEqual(names, []string{"created_at", "author", "name"}) {
+		t.Errorf("unexpected column names %v", names)
+	}
+}
+
+func TestStructColumnsErrors(t *testing.T) {
+	t.Parallel()
+	if _, err := StructColumns("city"); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("expected ErrNotStruct, got %v", err)
+	}
+	if _, err := StructColumns(struct {
+		Area int `alloydb:",type=int; DROP TABLE items"`
+	}{}); err == nil {
+		t.Error("expected an error for an invalid data type")
+	}
+	if _, err := StructColumns(struct {
+		Area     int
+		AreaCopy int `alloydb:"area"`
+	}{}); err == nil {
+		t.Error("expected an error for duplicate columns")
+	}
+	if _, err := StructColumns(struct {
+		Done chan bool
+	}{}); err == nil {
+		t.Error("expected an error for an unsupported type")
+	}
+}
+
+func TestStructMetadata(t *testing.T) {
+	t.Parallel()
+	createdAt := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	metadata, err := StructMetadata(city{
+		Audited: Audited{CreatedAt: createdAt},
+		Name:    "Tokyo",
+		Area:    2194,
+		Tags:    []string{"capital"},
+		Notes:   "skipped",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"created_at":  createdAt,
+		"name":        "Tokyo",
+		"country":     "",
+		"area_km2":    2194,
+		"population":  int64(0),
+		"density":     float64(0),
+		"http_status": int32(0),
+		"tags":        []string{"capital"},
+	}
+	if !reflect.DeepEqual(metadata, want) {
+		t.Errorf("expected %v, got %v", want, metadata)
+	}
+
+	if _, err := StructMetadata((*city)(nil)); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("expected ErrNotStruct, got %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package alloydbutil
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dataTypeAliases maps type names accepted in column definitions to the
+// names reported by Postgres' format_type.
+var dataTypeAliases = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"serial":      "integer",
+	"int2":        "smallint",
+	"int8":        "bigint",
+	"bigserial":   "bigint",
+	"float":       "double precision",
+	"float8":      "double precision",
+	"float4":      "real",
+	"bool":        "boolean",
+	"varchar":     "character varying",
+	"char":        "character",
+	"decimal":     "numeric",
+	"timestamptz": "timestamp with time zone",
+	"timestamp":   "timestamp without time zone",
+	"timetz":      "time with time zone",
+	"time":        "time without time zone",
+}
+
+var typeModifierRegexp = regexp.MustCompile(`^([a-z0-9 _]+?)\s*(\(.*\))?$`)
+
+// ColumnDiff describes a column whose definition is incompatible with the
+// expected one.
+type ColumnDiff struct {
+	Name     string
+	Expected Column
+	Actual   Column
+}
+
+// VectorstoreTableDiff is the structured difference between the expected
+// vectorstore table and the one found in the database. An empty diff means
+// the table can be used with the given options.
+type VectorstoreTableDiff struct {
+	// TableMissing is set when the table does not exist.
+	TableMissing bool
+	// MissingColumns are expected columns not present in the table.
+	MissingColumns []Column
+	// MismatchedColumns are columns with an incompatible type or nullability.
+	MismatchedColumns []ColumnDiff
+	// ExpectedVectorSize and ActualVectorSize are the dimensions of the
+	// embedding column.
+	ExpectedVectorSize int
+	ActualVectorSize   int
+}
+
+// IsEmpty reports whether the table matches the expected definition.
+func (d VectorstoreTableDiff) IsEmpty() bool {
+	return !d.TableMissing &&
+		len(d.MissingColumns) == 0 &&
+		len(d.MismatchedColumns) == 0 &&
+		d.ExpectedVectorSize == d.ActualVectorSize
+}
+
+// String returns a human readable summary of the differences.
+func (d VectorstoreTableDiff) String() string {
+	if d.TableMissing {
+		return "table does not exist"
+	}
+	problems := []string{}
+	for _, c := range d.MissingColumns {
+		problems = append(problems, fmt.Sprintf("missing column %q of type %s", c.Name, c.DataType))
+	}
+	for _, c := range d.MismatchedColumns {
+		problems = append(problems, fmt.Sprintf("column %q is %s (nullable=%t), expected %s (nullable=%t)",
+			c.Name, c.Actual.DataType, c.Actual.Nullable, c.Expected.DataType, c.Expected.Nullable))
+	}
+	if d.ExpectedVectorSize != d.ActualVectorSize {
+		problems = append(problems, fmt.Sprintf("embedding column has %d dimensions, expected %d",
+			d.ActualVectorSize, d.ExpectedVectorSize))
+	}
+	return strings.Join(problems, "; ")
+}
+
+// ValidateVectorstoreTable checks that the table described by opts exists and
+// has the id, content, embedding and metadata columns with compatible types
+// and vector dimension, returning the differences found.
+func (p *PostgresEngine) ValidateVectorstoreTable(ctx context.Context, opts VectorstoreTableOptions) (VectorstoreTableDiff, error) {
+	if err := validateVectorstoreTableOptions(&opts); err != nil {
+		return VectorstoreTableDiff{}, fmt.Errorf("failed to validate vectorstore table options: %w", err)
+	}
+
+	query := `SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull
+		FROM pg_attribute a
+		WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped`
+	var actual map[string]Column
+	err := p.Retry(ctx, func(ctx context.Context) error {
+		var err error
+		actual, err = p.fetchColumns(ctx, query, QuoteIdentifier(opts.SchemaName, opts.TableName))
+		return err
+	})
+	if err != nil {
+		return VectorstoreTableDiff{}, fmt.Errorf("failed to fetch columns of table %q: %w", opts.TableName, err)
+	}
+	return diffVectorstoreTable(opts, actual), nil
+}
+
+// fetchColumns returns the columns returned by query, by name.
+func (p *PostgresEngine) fetchColumns(ctx context.Context, query string, args ...any) (map[string]Column, error) {
+	rows, err := p.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := map[string]Column{}
+	for rows.Next() {
+		var c Column
+		if err := rows.Scan(&c.Name, &c.DataType, &c.Nullable); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		columns[c.Name] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over columns: %w", err)
+	}
+	return columns, nil
+}
+
+// diffVectorstoreTable compares the columns of an existing table with the
+// ones InitVectorstoreTable would create for opts.
+func diffVectorstoreTable(opts VectorstoreTableOptions, actual map[string]Column) VectorstoreTableDiff {
+	diff := VectorstoreTableDiff{ExpectedVectorSize: opts.VectorSize}
+	if len(actual) == 0 {
+		diff.TableMissing = true
+		return diff
+	}
+
+	expected := []Column{
+		{Name: opts.IDColumn.Name, DataType: opts.IDColumn.DataType},
+		{Name: opts.ContentColumnName, DataType: "text"},
+	}
+	expected = append(expected, opts.MetadataColumns...)
+	if opts.StoreMetadata {
+		expected = append(expected, Column{Name: opts.MetadataJSONColumn, DataType: "json", Nullable: true})
+	}
+	if opts.EmbeddingHashColumn != "" {
+		expected = append(expected, Column{Name: opts.EmbeddingHashColumn, DataType: "text", Nullable: true})
+	}
+	if opts.ExpiresAtColumn != "" {
+		expected = append(expected, Column{Name: opts.ExpiresAtColumn, DataType: "timestamp with time zone", Nullable: true})
+	}
+	if opts.ArchiveKeyColumn != "" {
+		expected = append(expected, Column{Name: opts.ArchiveKeyColumn, DataType: "text", Nullable: true})
+	}
+	for _, column := range opts.AdditionalEmbeddingColumns {
+		dataType := fmt.Sprintf("%s(%d)", opts.EmbeddingType, opts.VectorSize)
+		expected = append(expected, Column{Name: column, DataType: dataType, Nullable: true})
+	}
+
+	for _, want := range expected {
+		got, ok := actual[want.Name]
+		if !ok {
+			diff.MissingColumns = append(diff.MissingColumns, want)
+			continue
+		}
+		// A NOT NULL column breaks inserts of documents lacking the value.
+		if !compatibleDataTypes(want.DataType, got.DataType) || (want.Nullable && !got.Nullable) {
+			diff.MismatchedColumns = append(diff.MismatchedColumns, ColumnDiff{Name: want.Name, Expected: want, Actual: got})
+		}
+	}
+
+	embedding := Column{Name: opts.EmbeddingColumn, DataType: fmt.Sprintf("%s(%d)", opts.EmbeddingType, opts.VectorSize)}
+	got, ok := actual[opts.EmbeddingColumn]
+	switch {
+	case !ok:
+		diff.MissingColumns = append(diff.MissingColumns, embedding)
+	case baseDataType(got.DataType) != string(opts.EmbeddingType):
+		diff.MismatchedColumns = append(diff.MismatchedColumns, ColumnDiff{Name: embedding.Name, Expected: embedding, Actual: got})
+	default:
+		diff.ActualVectorSize = typeModifier(got.DataType)
+	}
+	return diff
+}
+
+// compatibleDataTypes reports whether a column of type actual can store
+// values declared as expected. Type modifiers are only compared when the
+// expected type declares them.
+func compatibleDataTypes(expected, actual string) bool {
+	wantBase, gotBase := baseDataType(expected), baseDataType(actual)
+	if wantBase == "json" || wantBase == "jsonb" {
+		return gotBase == "json" || gotBase == "jsonb"
+	}
+	if wantBase != gotBase {
+		return false
+	}
+	wantMod := typeModifier(expected)
+	return wantMod == 0 || wantMod == typeModifier(actual)
+}
+
+// baseDataType returns the canonical type name without modifiers.
+func baseDataType(dataType string) string {
+	m := typeModifierRegexp.FindStringSubmatch(strings.ToLower(strings.TrimSpace(dataType)))
+	if m == nil {
+		return strings.ToLower(dataType)
+	}
+	if alias, ok := dataTypeAliases[m[1]]; ok {
+		return alias
+	}
+	return m[1]
+}
+
+// typeModifier returns the first numeric type modifier, e.g. 768 for
+// vector(768), or 0 when there is none.
+func typeModifier(dataType string) int {
+	m := typeModifierRegexp.FindStringSubmatch(strings.ToLower(strings.TrimSpace(dataType)))
+	if m == nil || m[2] == "" {
+		return 0
+	}
+	first := strings.SplitN(strings.Trim(m[2], "()"), ",", 2)[0]
+	n, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,132 @@
+package alloydbutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffVectorstoreTable(t *testing.T) {
+	t.Parallel()
+	opts := VectorstoreTableOptions{
+		TableName:     "items",
+		VectorSize:    768,
+		StoreMetadata: true,
+		MetadataColumns: []Column{
+			{Name: "area", DataType: "int", Nullable: true},
+			{Name: "name", DataType: "varchar(64)"},
+		},
+	}
+	if err := validateVectorstoreTableOptions(&opts); err != nil {
+		t.Fatal(err)
+	}
+	matching := map[string]Column{
+		"langchain_id":       {Name: "langchain_id", DataType: "uuid"},
+		"content":            {Name: "content", DataType: "text"},
+		"embedding":          {Name: "embedding", DataType: "vector(768)"},
+		"area":               {Name: "area", DataType: "integer", Nullable: true},
+		"name":               {Name: "name", DataType: "character varying(64)"},
+		"langchain_metadata": {Name: "langchain_metadata", DataType: "jsonb", Nullable: true},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(map[string]Column)
+		want   VectorstoreTableDiff
+	}{
+		{
+			name:   "matching table",
+			modify: func(map[string]Column) {},
+			want:   VectorstoreTableDiff{ExpectedVectorSize: 768, ActualVectorSize: 768},
+		},
+		{
+			name: "missing table",
+			modify: func(c map[string]Column) {
+				for k := range c {
+					delete(c, k)
+				}
+			},
+			want: VectorstoreTableDiff{TableMissing: true, ExpectedVectorSize: 768},
+		},
+		{
+			name: "missing metadata column",
+			modify: func(c map[string]Column) {
+				delete(c, "area")
+			},
+			want: VectorstoreTableDiff{
+				MissingColumns:     []Column{{Name: "area", DataType: "int", Nullable: true}},
+				ExpectedVectorSize: 768,
+				ActualVectorSize:   768,
+			},
+		},
+		{
+			name: "wrong dimension",
+			modify: func(c map[string]Column) {
+				c["embedding"] = Column{Name: "embedding", DataType: "vector(1536)"}
+			},
+			want: VectorstoreTableDiff{ExpectedVectorSize: 768, ActualVectorSize: 1536},
+		},
+		{
+			name: "incompatible type and nullability",
+			modify: func(c map[string]Column) {
+				c["area"] = Column{Name: "area", DataType: "integer"}
+				c["content"] = Column{Name: "content", DataType: "bytea"}
+			},
+			want: VectorstoreTableDiff{
+				MismatchedColumns: []ColumnDiff{
+					{
+						Name:     "content",
+						Expected: Column{Name: "content", DataType: "text"},
+						Actual:   Column{Name: "content", DataType: "bytea"},
+					},
+					{
+						Name:     "area",
+						Expected: Column{Name: "area", DataType: "int", Nullable: true},
+						Actual:   Column{Name: "area", DataType: "integer"},
+					},
+				},
+				ExpectedVectorSize: 768,
+				ActualVectorSize:   768,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			actual := map[string]Column{}
+			for k, v := range matching {
+				actual[k] = v
+			}
+			tc.modify(actual)
+			got := diffVectorstoreTable(opts, actual)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %+v, got %+v", tc.want, got)
+			}
+			if got.IsEmpty() != (tc.name == "matching table") {
+				t.Errorf("unexpected IsEmpty result for %s: %s", tc.name, got)
+			}
+		})
+	}
+}
+
+func TestDiffVectorstoreTableEmbeddingType(t *testing.T) {
+	t.Parallel()
+	opts := VectorstoreTableOptions{TableName: "items", VectorSize: 768, EmbeddingType: EmbeddingTypeHalfVec}
+	if err := validateVectorstoreTableOptions(&opts); err != nil {
+		t.Fatal(err)
+	}
+	actual := map[string]Column{
+		"langchain_id": {Name: "langchain_id", DataType: "uuid"},
+		"content":      {Name: "content", DataType: "text"},
+		"embedding":    {Name: "embedding", DataType: "halfvec(768)"},
+	}
+	if diff := diffVectorstoreTable(opts, actual); !diff.IsEmpty() {
+		t.Errorf("expected no differences, got %s", diff)
+	}
+
+	actual["embedding"] = Column{Name: "embedding", DataType: "vector(768)"}
+	diff := diffVectorstoreTable(opts, actual)
+	if len(diff.MismatchedColumns) != 1 || diff.MismatchedColumns[0].Expected.DataType != "halfvec(768)" {
+		t.Errorf("expected the embedding column type to mismatch, got %s", diff)
+	}
+}
@@ -0,0 +1,185 @@
+package alloydbutil
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans and metrics of this package.
+const instrumentationName = "github.com/tmc/langchaingo/util/alloydbutil"
+
+// otelTracer is a pgx tracer emitting OpenTelemetry spans and metrics for the
+// queries, batches and connection acquisitions of a pool. tracer and the
+// instruments are nil when the corresponding provider isn't set.
+type otelTracer struct {
+	tracer          trace.Tracer
+	queryDuration   metric.Float64Histogram
+	acquireDuration metric.Float64Histogram
+}
+
+var (
+	_ pgx.QueryTracer       = &otelTracer{}
+	_ pgx.BatchTracer       = &otelTracer{}
+	_ pgxpool.AcquireTracer = &otelTracer{}
+)
+
+type startTimeKey struct{}
+
+func newOTelTracer(tp trace.TracerProvider, mp metric.MeterProvider) (*otelTracer, error) {
+	t := &otelTracer{}
+	if tp != nil {
+		t.tracer = tp.Tracer(instrumentationName)
+	}
+	if mp == nil {
+		return t, nil
+	}
+	meter := mp.Meter(instrumentationName)
+	var err error
+	t.queryDuration, err = meter.Float64Histogram("alloydb.query.duration",
+		metric.WithDescription("Duration of the queries and batches."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	t.acquireDuration, err = meter.Float64Histogram("alloydb.pool.acquire.duration",
+		metric.WithDescription("Time waited to acquire a connection from the pool."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// start starts a span and records the start time in the returned context.
+func (t *otelTracer) start(ctx context.Context, name string, attrs ...attribute.KeyValue) context.Context {
+	ctx = context.WithValue(ctx, startTimeKey{}, time.Now())
+	if t.tracer == nil {
+		return ctx
+	}
+	ctx, _ = t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append([]attribute.KeyValue{attribute.String("db.system", "postgresql")}, attrs...)...),
+	)
+	return ctx
+}
+
+// end ends the span started by start and records the elapsed time in
+// histogram.
+func (t *otelTracer) end(ctx context.Context, histogram metric.Float64Histogram, err error, attrs ...attribute.KeyValue) {
+	if t.tracer != nil {
+		span := trace.SpanFromContext(ctx)
+		span.SetAttributes(attrs...)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+	start, ok := ctx.Value(startTimeKey{}).(time.Time)
+	if histogram == nil || !ok {
+		return
+	}
+	histogram.Record(ctx, time.Since(start).Seconds(),
+		metric.WithAttributes(append(attrs, attribute.Bool("error", err != nil))...))
+}
+
+func (t *otelTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation := queryOperation(data.SQL)
+	ctx = t.start(ctx, operation, attribute.String("db.statement", data.SQL))
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+func (t *otelTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	if t.tracer != nil {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	t.end(ctx, t.queryDuration, data.Err, attribute.String("db.operation", operation))
+}
+
+func (t *otelTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceBatchStartData) context.Context {
+	return t.start(ctx, "BATCH")
+}
+
+func (t *otelTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	if t.tracer == nil {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("db.statement", data.SQL),
+		attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()),
+	}
+	if data.Err != nil {
+		attrs = append(attrs, attribute.String("error", data.Err.Error()))
+	}
+	trace.SpanFromContext(ctx).AddEvent("query", trace.WithAttributes(attrs...))
+}
+
+func (t *otelTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	t.end(ctx, t.queryDuration, data.Err, attribute.String("db.operation", "BATCH"))
+}
+
+func (t *otelTracer) TraceAcquireStart(ctx context.Context, _ *pgxpool.Pool, _ pgxpool.TraceAcquireStartData) context.Context {
+	return context.WithValue(ctx, acquireStartKey{}, time.Now())
+}
+
+func (t *otelTracer) TraceAcquireEnd(ctx context.Context, _ *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	start, ok := ctx.Value(acquireStartKey{}).(time.Time)
+	if t.acquireDuration == nil || !ok {
+		return
+	}
+	t.acquireDuration.Record(ctx, time.Since(start).Seconds(),
+		metric.WithAttributes(attribute.Bool("error", data.Err != nil)))
+}
+
+type (
+	operationKey    struct{}
+	acquireStartKey struct{}
+)
+
+// queryOperation returns the SQL command of a statement, e.g. SELECT.
+func queryOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(strings.TrimRight(fields[0], ";"))
+}
+
+// registerPoolMetrics reports the connection counts and acquisition
+// statistics of pool.
+func registerPoolMetrics(mp metric.MeterProvider, pool *pgxpool.Pool) (metric.Registration, error) {
+	meter := mp.Meter(instrumentationName)
+	connections, err := meter.Int64ObservableGauge("alloydb.pool.connections",
+		metric.WithDescription("Connections of the pool by state."))
+	if err != nil {
+		return nil, err
+	}
+	maxConnections, err := meter.Int64ObservableGauge("alloydb.pool.connections.max",
+		metric.WithDescription("Maximum size of the pool."))
+	if err != nil {
+		return nil, err
+	}
+	acquires, err := meter.Int64ObservableCounter("alloydb.pool.acquires",
+		metric.WithDescription("Connection acquisitions, by whether they had to wait for a connection."))
+	if err != nil {
+		return nil, err
+	}
+	return meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		stat := pool.Stat()
+		o.ObserveInt64(connections, int64(stat.IdleConns()), metric.WithAttributes(attribute.String("state", "idle")))
+		o.ObserveInt64(connections, int64(stat.AcquiredConns()), metric.WithAttributes(attribute.String("state", "acquired")))
+		o.ObserveInt64(connections, int64(stat.ConstructingConns()),
+			metric.WithAttributes(attribute.String("state", "constructing")))
+		o.ObserveInt64(maxConnections, int64(stat.MaxConns()))
+		o.ObserveInt64(acquires, stat.AcquireCount()-stat.EmptyAcquireCount(), metric.WithAttributes(attribute.Bool("waited", false)))
+		o.ObserveInt64(acquires, stat.EmptyAcquireCount(), metric.WithAttributes(attribute.Bool("waited", true)))
+		return nil
+	}, connections, maxConnections, acquires)
+}
@@ -0,0 +1,132 @@
+package alloydbutil
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type recordingSpan struct {
+	noop.Span
+	name   string
+	attrs  map[attribute.Key]any
+	status codes.Code
+	ended  bool
+}
+
+func (s *recordingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, a := range kv {
+		s.attrs[a.Key] = a.Value.AsInterface()
+	}
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, _ string) { s.status = code }
+
+func (s *recordingSpan) End(...trace.SpanEndOption) { s.ended = true }
+
+type recordingTracer struct {
+	noop.Tracer
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &recordingSpan{name: name, attrs: map[attribute.Key]any{}}
+	cfg := trace.NewSpanStartConfig(opts...)
+	span.SetAttributes(cfg.Attributes()...)
+	t.spans = append(t.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type recordingTracerProvider struct {
+	noop.TracerProvider
+	tracer *recordingTracer
+}
+
+func (p recordingTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer { return p.tracer }
+
+func TestOTelTracerQuerySpans(t *testing.T) {
+	t.Parallel()
+	recorder := &recordingTracer{}
+	tracer, err := newOTelTracer(recordingTracerProvider{tracer: recorder}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sql := `SELECT content FROM "public"."items" ORDER BY distance LIMIT $1`
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: sql})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "insert into items values ($1)"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("duplicate key")})
+
+	if len(recorder.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(recorder.spans))
+	}
+	selectSpan, insertSpan := recorder.spans[0], recorder.spans[1]
+	if selectSpan.name != "SELECT" || selectSpan.attrs["db.statement"] != sql || selectSpan.attrs["db.operation"] != "SELECT" {
+		t.Errorf("unexpected select span %+v", selectSpan)
+	}
+	if !selectSpan.ended || selectSpan.status != codes.Unset {
+		t.Errorf("expected select span to end successfully, got %+v", selectSpan)
+	}
+	if insertSpan.name != "INSERT" || insertSpan.status != codes.Error || !insertSpan.ended {
+		t.Errorf("expected failed insert span, got %+v", insertSpan)
+	}
+}
+
+func TestQueryOperation(t *testing.T) {
+	t.Parallel()
+	for sql, want := range map[string]string{
+		"SELECT 1":                           "SELECT",
+		"\n\t  delete from items where id=1": "DELETE",
+		"COMMIT;":                            "COMMIT",
+		"":                                   "QUERY",
+	} {
+		if got := queryOperation(sql); got != want {
+			t.Errorf("queryOperation(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+// failingMeterProvider provides meters failing to register callbacks.
+type failingMeterProvider struct {
+	metricnoop.MeterProvider
+}
+
+func (failingMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter { return failingMeter{} }
+
+type failingMeter struct {
+	metricnoop.Meter
+}
+
+func (failingMeter) RegisterCallback(metric.Callback, ...metric.Observable) (metric.Registration, error) {
+	return nil, errors.New("registration failed")
+}
+
+func TestPoolMetricsRegistrationError(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, "postgres://user@localhost:1/db?connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	_, err = NewPostgresEngine(ctx, WithPool(pool), WithMeterProvider(failingMeterProvider{}))
+	if err == nil || !strings.Contains(err.Error(), "registration failed") {
+		t.Fatalf("expected the registration error, got %v", err)
+	}
+	// The pool of WithPool belongs to the caller, and is left open.
+	if _, err := pool.Acquire(ctx); err == nil || strings.Contains(err.Error(), "closed pool") {
+		t.Errorf("expected the pool to be open, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+// ErrColdStorageNotSet is returned when archiving documents in a VectorStore
+// without cold storage.
+var ErrColdStorageNotSet = errors.New("cold storage is not set")
+
+// ColdStorage stores the content of archived documents outside of the
+// database, e.g. in a Cloud Storage bucket, to reduce the database storage of
+// large collections of rarely retrieved documents.
+type ColdStorage interface {
+	// Put stores content under key, replacing any previous content.
+	Put(ctx context.Context, key, content string) error
+	// Get returns the content stored under key.
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// archiveKey returns the cold storage key of the content of the document id.
+func (vs *VectorStore) archiveKey(id string) string {
+	return path.Join(vs.schemaName, vs.tableName, id)
+}
+
+// ArchiveDocuments moves the content of the documents with the given IDs to
+// cold storage, keeping their embeddings and metadata in the database, and
+// returns the number of archived documents. Archived documents are still
+// returned by searches, their content being read back from cold storage.
+// Documents already archived, or edited while being archived, are skipped.
+func (vs *VectorStore) ArchiveDocuments(ctx context.Context, ids []string) (int, error) {
+	if err := vs.checkWritable("archive documents"); err != nil {
+		return 0, err
+	}
+	if vs.coldStorage == nil || vs.archiveKeyColumn == "" {
+		return 0, ErrColdStorageNotSet
+	}
+	idColumn := alloydbutil.QuoteIdentifier(vs.idColumn)
+	contentColumn := alloydbutil.QuoteIdentifier(vs.contentColumn)
+	archiveKeyColumn := alloydbutil.QuoteIdentifier(vs.archiveKeyColumn)
+	selectStmt := fmt.Sprintf(`SELECT %s::text, %s FROM %s WHERE %s::text = ANY($1) AND %s IS NULL`,
+		idColumn, contentColumn, vs.table(), idColumn, archiveKeyColumn)
+	updateStmt := fmt.Sprintf(`UPDATE %s SET %s = '', %s = $1 WHERE %s::text = $2 AND %s IS NULL AND %s = $3`,
+		vs.table(), contentColumn, archiveKeyColumn, idColumn, archiveKeyColumn, contentColumn)
+
+	var found, contents []string
+	err := vs.engine.Retry(ctx, func(ctx context.Context) error {
+		found, contents = nil, nil
+		rows, err := vs.engine.Pool.Query(ctx, selectStmt, ids)
+		if err != nil {
+			return err
+		}
+		var id, content string
+		_, err = pgx.ForEachRow(rows, []any{&id, &content}, func() error {
+			found = append(found, id)
+			contents = append(contents, content)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find documents to archive: %w", err)
+	}
+
+	// The content is only removed from the database once it is stored.
+	b := &pgx.Batch{}
+	for i, id := range found {
+		key := vs.archiveKey(id)
+		if err := vs.coldStorage.Put(ctx, key, contents[i]); err != nil {
+			return 0, fmt.Errorf("failed to store the content of document %s: %w", id, err)
+		}
+		b.Queue(updateStmt, key, id, contents[i])
+	}
+	archived := 0
+	err = vs.engine.WithTx(ctx, func(tx pgx.Tx) error {
+		return vs.withSQLHooks(ctx, tx, OperationArchiveDocuments, func() error {
+			results := tx.SendBatch(ctx, b)
+			defer results.Close()
+			archived = 0
+			for range found {
+				tag, err := results.Exec()
+				if err != nil {
+					return err
+				}
+				archived += int(tag.RowsAffected())
+			}
+			return results.Close()
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive documents: %w", err)
+	}
+	return archived, nil
+}
+
+// rehydrate reads the content of the archived documents of results back from
+// cold storage, concurrently.
+func (vs *VectorStore) rehydrate(ctx context.Context, results []SearchDocument) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for i := range results {
+		if results[i].ArchiveKey == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			content, err := vs.coldStorage.Get(ctx, results[i].ArchiveKey)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("failed to read archived document %s: %w", results[i].ID, err))
+				mu.Unlock()
+				return
+			}
+			results[i].Content = content
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package alloydb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+// recordAccess writes one audit row per document returned by a search, ranked
+// after the documents skipped or returned by the previous pages. The rows are
+// written in a single implicit transaction.
+func (vs *VectorStore) recordAccess(ctx context.Context, query string, so searchOptions, accessed []SearchDocument) error {
+	if len(accessed) == 0 {
+		return nil
+	}
+	stmt := fmt.Sprintf(`INSERT INTO %s (session_id, user_id, query, document_id, rank, distance)
+		VALUES (NULLIF($1, ''), NULLIF($2, ''), $3, $4, $5, $6)`, alloydbutil.QuoteIdentifier(vs.schemaName, vs.auditTable))
+
+	b := &pgx.Batch{}
+	rankOffset := so.rankOffset()
+	for i, doc := range accessed {
+		b.Queue(stmt, so.auditSessionID, so.auditUserID, query, doc.ID, rankOffset+i+1, doc.Distance)
+	}
+	if err := vs.engine.Pool.SendBatch(ctx, b).Close(); err != nil {
+		return fmt.Errorf("failed to record document access: %w", err)
+	}
+	return nil
+}
+
+// PurgeAuditLog deletes audit log entries older than retention and returns
+// the number of deleted entries. Callers are expected to run it periodically
+// to enforce their retention policy.
+func (vs *VectorStore) PurgeAuditLog(ctx context.Context, retention time.Duration) (int64, error) {
+	if err := vs.checkWritable("purge audit log"); err != nil {
+		return 0, err
+	}
+	if vs.auditTable == "" {
+		return 0, fmt.Errorf("audit log is not enabled")
+	}
+	stmt := fmt.Sprintf(`DELETE FROM %s WHERE retrieved_at < $1`, alloydbutil.QuoteIdentifier(vs.schemaName, vs.auditTable))
+	cutoff := time.Now().Add(-retention)
+	var deleted int64
+	err := vs.engine.Retry(ctx, func(ctx context.Context) error {
+		tag, err := vs.engine.Pool.Exec(ctx, stmt, cutoff)
+		deleted = tag.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge audit log: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,65 @@
+package alloydb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+// CheckEmbeddingDimensions returns an error wrapping
+// embeddings.ErrDimensionMismatch if the vector size declared by an embedding
+// column of the table isn't the dimension of the embeddings of the embedder,
+// which it embeds a probe text to find unless the embedder knows it. Calling
+// it after NewVectorStore makes a misconfigured store fail before its first
+// insert. The columns without a declared size are not checked.
+func (vs *VectorStore) CheckEmbeddingDimensions(ctx context.Context) error {
+	columns := []string{vs.embeddingColumn}
+	for _, additional := range vs.additionalEmbeddings {
+		columns = append(columns, additional.column)
+	}
+	query := `SELECT attname::text, atttypmod FROM pg_attribute
+		WHERE attrelid = to_regclass($1) AND attname = ANY($2) AND NOT attisdropped`
+	sizes := map[string]int{}
+	err := vs.engine.Retry(ctx, func(ctx context.Context) error {
+		rows, err := vs.engine.Pool.Query(ctx, query, vs.table(), columns)
+		if err != nil {
+			return err
+		}
+		sizes = map[string]int{}
+		var (
+			column string
+			size   int
+		)
+		_, err = pgx.ForEachRow(rows, []any{&column, &size}, func() error {
+			sizes[column] = size
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get the vector sizes of the embedding columns: %w", err)
+	}
+
+	dimensions := 0
+	for _, column := range columns {
+		size, ok := sizes[column]
+		if !ok {
+			return fmt.Errorf("embedding column %q not found in table %q.%q", column, vs.schemaName, vs.tableName)
+		}
+		if size <= 0 {
+			continue
+		}
+		if dimensions == 0 {
+			if dimensions, err = embeddings.Dimensions(ctx, vs.embedder); err != nil {
+				return err
+			}
+		}
+		if size != dimensions {
+			return fmt.Errorf("%w: the embedder returns %d-dimensional embeddings, but column %q has %d dimensions",
+				embeddings.ErrDimensionMismatch, dimensions, column, size)
+		}
+	}
+	return nil
+}
@@ -67,6 +67,44 @@ func (InnerProduct) similaritySearchFunction() string {
 	return "inner_product"
 }
 
+// Hamming is the Hamming distance between bit embeddings.
+type Hamming struct{}
+
+func (Hamming) String() string {
+	return "hamming"
+}
+
+func (Hamming) operator() string {
+	return "<~>"
+}
+
+func (Hamming) searchFunction() string {
+	return "bit_hamming_ops"
+}
+
+func (Hamming) similaritySearchFunction() string {
+	return "hamming_distance"
+}
+
+// Jaccard is the Jaccard distance between bit embeddings.
+type Jaccard struct{}
+
+func (Jaccard) String() string {
+	return "jaccard"
+}
+
+func (Jaccard) operator() string {
+	return "<%>"
+}
+
+func (Jaccard) searchFunction() string {
+	return "bit_jaccard_ops"
+}
+
+func (Jaccard) similaritySearchFunction() string {
+	return "jaccard_distance"
+}
+
 // HNSWOptions holds the configuration for the hnsw index.
 type HNSWOptions struct {
 	M              int
@@ -0,0 +1,191 @@
+package alloydb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+// ErrMismatchedKeys is returned by DocStore.Set when the numbers of keys and
+// documents differ.
+var ErrMismatchedKeys = errors.New("number of keys and documents differ")
+
+// DocStore stores full documents by key in a table created with
+// alloydbutil.InitDocstoreTable.
+type DocStore struct {
+	engine     alloydbutil.PostgresEngine
+	tableName  string
+	schemaName string
+	// compressor, if not nil, compresses the contents of at least
+	// compressionMinSize bytes.
+	compressor         alloydbutil.Compressor
+	compressionMinSize int
+}
+
+// DocStoreOption is a function for creating a new DocStore with other than
+// the default values.
+type DocStoreOption func(ds *DocStore)
+
+// WithDocStoreSchemaName sets the schema of the table of the DocStore. It
+// defaults to "public".
+func WithDocStoreSchemaName(schemaName string) DocStoreOption {
+	return func(ds *DocStore) {
+		ds.schemaName = schemaName
+	}
+}
+
+// WithDocStoreCompression compresses the contents of the documents of at
+// least minSize bytes with compressor, e.g. large parent documents, and
+// decompresses them when they are read. The compressed contents are stored
+// in the compressed_content column of the table. The stores reading the
+// documents must have the same compressor.
+func WithDocStoreCompression(compressor alloydbutil.Compressor, minSize int) DocStoreOption {
+	return func(ds *DocStore) {
+		ds.compressor = compressor
+		ds.compressionMinSize = minSize
+	}
+}
+
+// NewDocStore creates a new DocStore storing its documents in tableName.
+func NewDocStore(engine alloydbutil.PostgresEngine, tableName string, opts ...DocStoreOption) (DocStore, error) {
+	ds := DocStore{
+		engine:     engine,
+		tableName:  tableName,
+		schemaName: defaultSchemaName,
+	}
+	for _, opt := range opts {
+		opt(&ds)
+	}
+	if ds.engine.Pool == nil {
+		return DocStore{}, errors.New("missing docstore engine")
+	}
+	if ds.tableName == "" {
+		return DocStore{}, errors.New("missing docstore table name")
+	}
+	return ds, nil
+}
+
+// table returns the quoted, schema qualified name of the table of the store.
+func (ds DocStore) table() string {
+	return alloydbutil.QuoteIdentifier(ds.schemaName, ds.tableName)
+}
+
+// Set stores docs under keys, replacing the documents already stored under
+// the same keys. Either all or none of the documents are stored.
+func (ds DocStore) Set(ctx context.Context, keys []string, docs []schema.Document) error {
+	if len(keys) != len(docs) {
+		return fmt.Errorf("%w: %d keys, %d documents", ErrMismatchedKeys, len(keys), len(docs))
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (key, content, metadata, compressed_content) VALUES ($1, $2, $3, $4)
+ON CONFLICT (key) DO UPDATE SET content = EXCLUDED.content, metadata = EXCLUDED.metadata,
+compressed_content = EXCLUDED.compressed_content, updated_at = NOW()`,
+		ds.table())
+	b := &pgx.Batch{}
+	for i, doc := range docs {
+		metadata := doc.Metadata
+		if metadata == nil {
+			metadata = map[string]any{}
+		}
+		metadataJSON, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to transform metadata to json: %w", err)
+		}
+		content, compressed, err := ds.compress(doc.PageContent)
+		if err != nil {
+			return err
+		}
+		b.Queue(query, keys[i], content, metadataJSON, compressed)
+	}
+	err := ds.engine.WithTx(ctx, func(tx pgx.Tx) error {
+		return tx.SendBatch(ctx, b).Close()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store documents: %w", err)
+	}
+	return nil
+}
+
+// Get returns the documents stored under keys, in the order of the keys.
+// The keys without document are skipped. The documents are read from the
+// engine's read pool instance, if any.
+func (ds DocStore) Get(ctx context.Context, keys []string) ([]schema.Document, error) {
+	query := fmt.Sprintf(`SELECT key, content, metadata, compressed_content FROM %s WHERE key = ANY($1)`, ds.table())
+	found := make(map[string]schema.Document, len(keys))
+	err := ds.engine.Read(ctx, func(ctx context.Context, pool *pgxpool.Pool) error {
+		rows, err := pool.Query(ctx, query, keys)
+		if err != nil {
+			return err
+		}
+		var (
+			key        string
+			doc        schema.Document
+			metadata   map[string]any
+			compressed []byte
+		)
+		_, err = pgx.ForEachRow(rows, []any{&key, &doc.PageContent, &metadata, &compressed}, func() error {
+			if compressed != nil {
+				content, err := ds.decompress(compressed)
+				if err != nil {
+					return fmt.Errorf("document %s: %w", key, err)
+				}
+				doc.PageContent = content
+			}
+			doc.Metadata = metadata
+			found[key] = doc
+			metadata = nil
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve documents: %w", err)
+	}
+	docs := make([]schema.Document, 0, len(found))
+	for _, key := range keys {
+		if doc, ok := found[key]; ok {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// compress returns the content and compressed_content columns of a
+// document, compressed if it's large enough.
+func (ds DocStore) compress(content string) (string, []byte, error) {
+	if ds.compressor == nil || len(content) < ds.compressionMinSize {
+		return content, nil, nil
+	}
+	compressed, err := ds.compressor.Compress([]byte(content))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to compress document: %w", err)
+	}
+	return "", compressed, nil
+}
+
+// decompress returns the content of a document from its compressed_content
+// column.
+func (ds DocStore) decompress(compressed []byte) (string, error) {
+	if ds.compressor == nil {
+		return "", errors.New("document is compressed: no compressor")
+	}
+	content, err := ds.compressor.Decompress(compressed)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress document: %w", err)
+	}
+	return string(content), nil
+}
+
+// Delete deletes the documents stored under keys.
+func (ds DocStore) Delete(ctx context.Context, keys []string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE key = ANY($1)`, ds.table())
+	if _, err := ds.engine.Pool.Exec(ctx, query, keys); err != nil {
+		return fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package alloydb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/tmc/langchaingo/schema"
+)
+
+// additionalEmbedding is an additional embedding column along with the
+// metadata key of the text it embeds.
+type additionalEmbedding struct {
+	column      string
+	metadataKey string
+}
+
+// embedAdditionalColumns embeds the texts of the additional embedding columns
+// of docs, returning for every column the values to insert for each
+// document: the formatted embedding, or nil for documents without the text.
+func (vs *VectorStore) embedAdditionalColumns(ctx context.Context, docs []schema.Document) ([][]any, error) {
+	values := make([][]any, len(vs.additionalEmbeddings))
+	for c, additional := range vs.additionalEmbeddings {
+		values[c] = make([]any, len(docs))
+		var texts []string
+		var indexes []int
+		for i, doc := range docs {
+			if text, ok := doc.Metadata[additional.metadataKey].(string); ok && text != "" {
+				texts = append(texts, text)
+				indexes = append(indexes, i)
+			}
+		}
+		if len(texts) == 0 {
+			continue
+		}
+		embeddings, err := vs.embedder.EmbedDocuments(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed %s: %w", additional.column, err)
+		}
+		if len(embeddings) != len(texts) {
+			return nil, fmt.Errorf("embedder returned %d embeddings for %d %s texts", len(embeddings), len(texts), additional.column)
+		}
+		for j, i := range indexes {
+			values[c][i] = formatEmbedding(vs.embeddingType, embeddings[j])
+		}
+	}
+	return values, nil
+}
+
+// searchEmbeddingColumn returns the embedding column a search targets, the
+// main one unless another one is set with WithSearchEmbeddingColumn.
+func (vs *VectorStore) searchEmbeddingColumn(so searchOptions) (string, error) {
+	if so.embeddingColumn == "" || so.embeddingColumn == vs.embeddingColumn {
+		return vs.embeddingColumn, nil
+	}
+	if !slices.ContainsFunc(vs.additionalEmbeddings, func(a additionalEmbedding) bool {
+		return a.column == so.embeddingColumn
+	}) {
+		return "", fmt.Errorf("unknown embedding column %q", so.embeddingColumn)
+	}
+	return so.embeddingColumn, nil
+}
@@ -0,0 +1,62 @@
+package alloydb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pgvector/pgvector-go"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+// formatEmbedding returns the text representation of embedding as a value of
+// the embedding column type. Bit embeddings are binary quantized, setting the
+// bits of the positive dimensions.
+func formatEmbedding(embeddingType alloydbutil.EmbeddingType, embedding []float32) string {
+	switch embeddingType {
+	case alloydbutil.EmbeddingTypeBit:
+		var b strings.Builder
+		for _, v := range embedding {
+			if v > 0 {
+				b.WriteByte('1')
+			} else {
+				b.WriteByte('0')
+			}
+		}
+		return b.String()
+	case alloydbutil.EmbeddingTypeSparseVec:
+		elements := []string{}
+		for i, v := range embedding {
+			if v != 0 {
+				elements = append(elements, fmt.Sprintf("%d:%s", i+1, strconv.FormatFloat(float64(v), 'g', -1, 32)))
+			}
+		}
+		return fmt.Sprintf("{%s}/%d", strings.Join(elements, ","), len(embedding))
+	case alloydbutil.EmbeddingTypeVector, alloydbutil.EmbeddingTypeHalfVec:
+	}
+	return pgvector.NewVector(embedding).String()
+}
+
+// embeddingArraySQL returns the SQL expression converting an embedding
+// column of the given type to a real[], bit embeddings having one 0 or 1
+// element per bit.
+func embeddingArraySQL(embeddingType alloydbutil.EmbeddingType, column string) string {
+	switch embeddingType {
+	case alloydbutil.EmbeddingTypeBit:
+		return fmt.Sprintf("string_to_array(%s::text, NULL)::real[]", column)
+	case alloydbutil.EmbeddingTypeSparseVec:
+		return fmt.Sprintf("%s::vector::real[]", column)
+	case alloydbutil.EmbeddingTypeVector, alloydbutil.EmbeddingTypeHalfVec:
+	}
+	return fmt.Sprintf("%s::real[]", column)
+}
+
+// operatorClass returns the operator class of the indexes of an embedding
+// column of the given type searched with strategy.
+func operatorClass(embeddingType alloydbutil.EmbeddingType, strategy distanceStrategy) string {
+	opClass := strategy.searchFunction()
+	if embeddingType == alloydbutil.EmbeddingTypeHalfVec || embeddingType == alloydbutil.EmbeddingTypeSparseVec {
+		return strings.Replace(opClass, "vector_", string(embeddingType)+"_", 1)
+	}
+	return opClass
+}
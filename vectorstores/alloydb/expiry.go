@@ -0,0 +1,92 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+// ErrExpiryNotTracked is returned when purging or adding expiring documents
+// in a VectorStore without an expiry column.
+var ErrExpiryNotTracked = errors.New("expires at column is not set")
+
+// expiresAt returns the expiry time of the documents added with so, or the
+// zero time when they don't expire.
+func (vs *VectorStore) expiresAt(so searchOptions) (time.Time, error) {
+	ttl := vs.defaultTTL
+	if so.ttl != 0 {
+		ttl = so.ttl
+	}
+	if ttl <= 0 {
+		return time.Time{}, nil
+	}
+	if vs.expiresAtColumn == "" {
+		return time.Time{}, ErrExpiryNotTracked
+	}
+	return time.Now().Add(ttl), nil
+}
+
+// notExpiredCondition returns the condition matching the rows that haven't
+// expired.
+func (vs *VectorStore) notExpiredCondition() string {
+	column := alloydbutil.QuoteIdentifier(vs.expiresAtColumn)
+	return fmt.Sprintf("(%s IS NULL OR %s > NOW())", column, column)
+}
+
+// PurgeExpired deletes the expired documents and returns their number.
+func (vs *VectorStore) PurgeExpired(ctx context.Context) (int64, error) {
+	if err := vs.checkWritable("purge expired documents"); err != nil {
+		return 0, err
+	}
+	if vs.expiresAtColumn == "" {
+		return 0, ErrExpiryNotTracked
+	}
+	stmt := fmt.Sprintf(`DELETE FROM %s WHERE %s <= NOW()`, vs.table(), alloydbutil.QuoteIdentifier(vs.expiresAtColumn))
+
+	var deleted int64
+	err := vs.engine.Retry(ctx, func(ctx context.Context) error {
+		tag, err := vs.engine.Pool.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+		deleted = tag.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired documents: %w", err)
+	}
+	return deleted, nil
+}
+
+// StartExpiryJanitor calls PurgeExpired every interval in the background,
+// until ctx is done or the returned stop function is called. onError, if not
+// nil, is called with the errors of the purges; the janitor keeps running
+// after them. stop waits for a running purge to return.
+func (vs *VectorStore) StartExpiryJanitor(ctx context.Context, interval time.Duration, onError func(error)) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := vs.PurgeExpired(ctx); err != nil && ctx.Err() == nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
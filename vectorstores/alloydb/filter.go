@@ -0,0 +1,33 @@
+package alloydb
+
+import (
+	"fmt"
+
+	"github.com/tmc/langchaingo/util/alloydbutil"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/internal/pgfilter"
+)
+
+// filterCondition returns the condition of filters, a SQL condition or a
+// vectorstores.Filter on the metadata json column, along with args followed
+// by the arguments of the condition.
+func (vs *VectorStore) filterCondition(filters any, args []any) (string, []any, error) {
+	switch f := filters.(type) {
+	case string:
+		return fmt.Sprintf("(%s)", f), args, nil
+	case vectorstores.Filter:
+		if vs.metadataJSONColumn == "" {
+			return "", nil, fmt.Errorf("%w: a vectorstores.Filter needs the metadata json column", ErrInvalidFilters)
+		}
+		if err := f.Validate(); err != nil {
+			return "", nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		// The filters use the jsonb operators.
+		condition, args, err := pgfilter.Condition(f, alloydbutil.QuoteIdentifier(vs.metadataJSONColumn)+"::jsonb", args)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		return condition, args, nil
+	}
+	return "", nil, fmt.Errorf("%w, got %T", ErrInvalidFilters, filters)
+}
@@ -0,0 +1,49 @@
+package alloydb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Operation is a vector store operation running in a transaction, around
+// which SQL hooks run.
+type Operation string
+
+const (
+	// OperationAddDocuments inserts the documents of AddDocuments.
+	OperationAddDocuments Operation = "add_documents"
+	// OperationSearch runs the query of a similarity search.
+	OperationSearch Operation = "search"
+	// OperationArchiveDocuments removes the content of archived documents.
+	OperationArchiveDocuments Operation = "archive_documents"
+	// OperationRefreshEmbeddings updates a batch of refreshed embeddings.
+	OperationRefreshEmbeddings Operation = "refresh_embeddings"
+	// OperationDeleteDocuments deletes the documents of DeleteDocuments.
+	OperationDeleteDocuments Operation = "delete_documents"
+)
+
+// SQLHook runs statements in the transaction of a vector store operation,
+// e.g. SET LOCAL app.current_tenant for row level security policies, or SET
+// LOCAL work_mem. An error aborts the operation.
+type SQLHook func(ctx context.Context, tx pgx.Tx, op Operation) error
+
+// withSQLHooks runs fn in tx, between the pre and post SQL hooks of the
+// vector store.
+func (vs *VectorStore) withSQLHooks(ctx context.Context, tx pgx.Tx, op Operation, fn func() error) error {
+	for _, hook := range vs.preSQLHooks {
+		if err := hook(ctx, tx, op); err != nil {
+			return fmt.Errorf("failed to run pre %s SQL hook: %w", op, err)
+		}
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	for _, hook := range vs.postSQLHooks {
+		if err := hook(ctx, tx, op); err != nil {
+			return fmt.Errorf("failed to run post %s SQL hook: %w", op, err)
+		}
+	}
+	return nil
+}
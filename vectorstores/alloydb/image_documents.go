@@ -0,0 +1,66 @@
+package alloydb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// imageURI returns the URI of the image a document stands for, if any.
+func (vs *VectorStore) imageURI(doc schema.Document) (string, bool) {
+	if vs.imageURIMetadataKey == "" {
+		return "", false
+	}
+	uri, ok := doc.Metadata[vs.imageURIMetadataKey].(string)
+	return uri, ok && uri != ""
+}
+
+// embedDocuments embeds the content of the text documents, and the image of
+// the image documents, returning an embedding for each document.
+func (vs *VectorStore) embedDocuments(ctx context.Context, embedder embeddings.Embedder, docs []schema.Document) ([][]float32, error) { //nolint:lll
+	var texts, uris []string
+	var textIndexes, imageIndexes []int
+	for i, doc := range docs {
+		if uri, ok := vs.imageURI(doc); ok {
+			uris = append(uris, uri)
+			imageIndexes = append(imageIndexes, i)
+			continue
+		}
+		texts = append(texts, doc.PageContent)
+		textIndexes = append(textIndexes, i)
+	}
+	if len(uris) == 0 {
+		return embedder.EmbedDocuments(ctx, texts)
+	}
+
+	imageEmbedder, ok := embedder.(embeddings.ImageURIEmbedder)
+	if !ok {
+		return nil, fmt.Errorf("embedder %T can't embed images", embedder)
+	}
+	vectors := make([][]float32, len(docs))
+	if len(texts) > 0 {
+		textVectors, err := embedder.EmbedDocuments(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		if len(textVectors) != len(texts) {
+			return nil, fmt.Errorf("got %d embeddings for %d text documents", len(textVectors), len(texts))
+		}
+		for j, i := range textIndexes {
+			vectors[i] = textVectors[j]
+		}
+	}
+	imageVectors, err := imageEmbedder.EmbedImageURIs(ctx, uris)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed images: %w", err)
+	}
+	if len(imageVectors) != len(uris) {
+		return nil, fmt.Errorf("got %d embeddings for %d image documents", len(imageVectors), len(uris))
+	}
+	for j, i := range imageIndexes {
+		vectors[i] = imageVectors[j]
+	}
+	return vectors, nil
+}
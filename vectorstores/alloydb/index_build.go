@@ -0,0 +1,103 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// indexProgressInterval is how often the progress of an index build is
+// polled.
+const indexProgressInterval = time.Second
+
+// IndexBuildProgress is the progress of an index build, as reported by
+// pg_stat_progress_create_index.
+type IndexBuildProgress struct {
+	// Phase is the current phase of the build, e.g. "building index".
+	Phase       string
+	BlocksTotal int64
+	BlocksDone  int64
+	TuplesTotal int64
+	TuplesDone  int64
+}
+
+// Fraction returns the completed fraction of the current phase, between 0
+// and 1, or 0 when the phase doesn't report its size.
+func (p IndexBuildProgress) Fraction() float64 {
+	switch {
+	case p.BlocksTotal > 0:
+		return float64(p.BlocksDone) / float64(p.BlocksTotal)
+	case p.TuplesTotal > 0:
+		return float64(p.TuplesDone) / float64(p.TuplesTotal)
+	}
+	return 0
+}
+
+// ApplyVectorIndexConcurrently creates index like ApplyVectorIndex with
+// CREATE INDEX CONCURRENTLY, which doesn't block writes to the table, calling
+// progress, if not nil, with the progress of the build every second until it
+// is done. A failed or canceled build leaves an invalid index behind; drop it
+// with DropVectorIndex before retrying.
+func (vs *VectorStore) ApplyVectorIndexConcurrently(ctx context.Context,
+	index BaseIndex,
+	name string,
+	progress func(IndexBuildProgress),
+) error {
+	if err := vs.checkWritable("apply vector index"); err != nil {
+		return err
+	}
+	if index.indexType == "exactnearestneighbor" {
+		return errors.New("exact nearest neighbor search doesn't use an index")
+	}
+	if err := vs.createIndexExtension(ctx, index); err != nil {
+		return err
+	}
+	stmt := vs.createIndexStatement(index, name, true)
+
+	// The build runs on its own connection, whose backend is the one to
+	// look up in pg_stat_progress_create_index.
+	conn, err := vs.engine.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+	pid := conn.Conn().PgConn().PID()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.Exec(ctx, stmt)
+		done <- err
+	}()
+
+	ticker := time.NewTicker(indexProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				return fmt.Errorf("failed to execute creation of index: %w", err)
+			}
+			return nil
+		case <-ticker.C:
+			if progress == nil {
+				continue
+			}
+			// Progress is best effort, the build's outcome is what matters.
+			if p, err := vs.indexBuildProgress(ctx, pid); err == nil {
+				progress(p)
+			}
+		}
+	}
+}
+
+// indexBuildProgress returns the progress of the index build run by the
+// backend pid.
+func (vs *VectorStore) indexBuildProgress(ctx context.Context, pid uint32) (IndexBuildProgress, error) {
+	const query = `SELECT phase, blocks_total, blocks_done, tuples_total, tuples_done
+		FROM pg_stat_progress_create_index WHERE pid = $1`
+	var p IndexBuildProgress
+	err := vs.engine.Pool.QueryRow(ctx, query, int64(pid)).
+		Scan(&p.Phase, &p.BlocksTotal, &p.BlocksDone, &p.TuplesTotal, &p.TuplesDone)
+	return p, err
+}
@@ -0,0 +1,98 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+	"github.com/tmc/langchaingo/vectorstores/migrate"
+)
+
+var _ migrate.Source = &VectorStore{}
+
+// ListEmbeddedDocuments returns at most limit documents with an id following
+// cursor, ordered by id, along with their embeddings and the cursor of the
+// next documents, empty after the last ones. Expired documents are skipped
+// and the content of archived documents is read back from cold storage. It
+// lets the store be the source of migrate.Copy.
+func (vs *VectorStore) ListEmbeddedDocuments(ctx context.Context,
+	cursor string,
+	limit int,
+) ([]migrate.Record, string, error) {
+	if limit <= 0 {
+		return nil, "", errors.New("limit must be positive")
+	}
+	metadataColumn := "'{}'::json"
+	if vs.metadataJSONColumn != "" {
+		metadataColumn = alloydbutil.QuoteIdentifier(vs.metadataJSONColumn)
+	}
+	idColumn := alloydbutil.QuoteIdentifier(vs.idColumn)
+	columns := []string{
+		idColumn + "::text", alloydbutil.QuoteIdentifier(vs.contentColumn), metadataColumn,
+		embeddingArraySQL(vs.embeddingType, alloydbutil.QuoteIdentifier(vs.embeddingColumn)),
+	}
+	if vs.archiveKeyColumn != "" {
+		columns = append(columns, fmt.Sprintf("COALESCE(%s, '')", alloydbutil.QuoteIdentifier(vs.archiveKeyColumn)))
+	}
+	conditions := []string{idColumn + "::text > $1"}
+	if vs.expiresAtColumn != "" {
+		conditions = append(conditions, vs.notExpiredCondition())
+	}
+	stmt := fmt.Sprintf(`SELECT %s FROM %s WHERE %s ORDER BY %s::text LIMIT $2`,
+		strings.Join(columns, ", "), vs.table(), strings.Join(conditions, " AND "), idColumn)
+
+	var results []SearchDocument
+	err := vs.engine.Retry(ctx, func(ctx context.Context) error {
+		results = nil
+		return vs.engine.Read(ctx, func(ctx context.Context, pool *pgxpool.Pool) error {
+			tx, err := vs.beginSearch(ctx, pool)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = tx.Rollback(ctx) }()
+			rows, err := tx.Query(ctx, stmt, cursor, limit)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				doc := SearchDocument{}
+				dest := []any{&doc.ID, &doc.Content, &doc.LangchainMetadata, &doc.Embedding}
+				if vs.archiveKeyColumn != "" {
+					dest = append(dest, &doc.ArchiveKey)
+				}
+				if err := rows.Scan(dest...); err != nil {
+					return err
+				}
+				results = append(results, doc)
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			return tx.Commit(ctx)
+		})
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list documents: %w", err)
+	}
+	if err := vs.rehydrate(ctx, results); err != nil {
+		return nil, "", err
+	}
+
+	records := make([]migrate.Record, len(results))
+	for i, result := range results {
+		doc, err := searchDocumentToDocument(result, searchOptions{})
+		if err != nil {
+			return nil, "", err
+		}
+		records[i] = migrate.Record{ID: result.ID, Document: doc, Embedding: result.Embedding}
+	}
+	next := ""
+	if len(results) == limit {
+		next = results[len(results)-1].ID
+	}
+	return records, next, nil
+}
@@ -0,0 +1,133 @@
+package alloydb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// ErrInvalidPageToken is returned by SimilaritySearchPage when the page token
+// wasn't returned by a previous call.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// pageCursor is the position of the end of a page of search results: the
+// order key of its last document, the IDs of the documents returned so far
+// with that key, and the rank of its last document, counting the documents
+// skipped by WithOffset.
+type pageCursor struct {
+	Key  float32  `json:"k"`
+	IDs  []string `json:"ids"`
+	Rank int      `json:"r"`
+}
+
+func encodePageToken(cursor pageCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodePageToken(token string) (pageCursor, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: %w", ErrInvalidPageToken, err)
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("%w: %w", ErrInvalidPageToken, err)
+	}
+	return cursor, nil
+}
+
+// orderKey returns the value of the search ORDER BY expression for a
+// document at distance from the query. The inner product operator returns
+// the negated inner product.
+func (vs *VectorStore) orderKey(distance float32) float32 {
+	if _, ok := vs.distanceStrategy.(InnerProduct); ok {
+		return -distance
+	}
+	return distance
+}
+
+// nextPageCursor returns the position of the end of the page of results of a
+// search with so, which continues the page ending at so.after, if not nil.
+func (vs *VectorStore) nextPageCursor(results []SearchDocument, so searchOptions) pageCursor {
+	cursor := pageCursor{
+		Key:  vs.orderKey(results[len(results)-1].Distance),
+		Rank: so.rankOffset() + len(results),
+	}
+	if so.after != nil && so.after.Key == cursor.Key {
+		cursor.IDs = append(cursor.IDs, so.after.IDs...)
+	}
+	for _, result := range results {
+		if vs.orderKey(result.Distance) == cursor.Key {
+			cursor.IDs = append(cursor.IDs, result.ID)
+		}
+	}
+	return cursor
+}
+
+// SimilaritySearchPage returns a page of at most pageSize documents nearest
+// to query, starting after the page pageToken was returned with, or at the
+// nearest document when pageToken is empty, along with the token of the next
+// page, empty after the last one. Later pages must be requested with the
+// same query and options; WithOffset only applies to the first page. The
+// documents of the previous pages are skipped by the database instead of
+// being read again, but approximate indexes, e.g. HNSW with its ef_search
+// limit, may return fewer documents on deep pages.
+func (vs *VectorStore) SimilaritySearchPage(ctx context.Context,
+	query string,
+	pageSize int,
+	pageToken string,
+	options ...vectorstores.Option,
+) ([]schema.Document, string, error) {
+	if pageSize <= 0 {
+		pageSize = vs.k
+	}
+	if pageToken != "" {
+		cursor, err := decodePageToken(pageToken)
+		if err != nil {
+			return nil, "", err
+		}
+		options = append(options, withSearchOptions(func(so *searchOptions) {
+			so.after = &cursor
+			so.offset = 0
+		}))
+	}
+	opts := applyOpts(options...)
+	so := getSearchOptions(opts)
+	stmt, args, err := vs.searchQuery(ctx, query, pageSize, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var results []SearchDocument
+	err = vs.executeSQLQuery(ctx, query, stmt, so, func(result SearchDocument) error {
+		results = append(results, result)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to execute sql query: %w", err)
+	}
+	if err := vs.rehydrate(ctx, results); err != nil {
+		return nil, "", err
+	}
+	documents, err := vs.processResultsToDocuments(results, so)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to process Results to Documents with Scores: %w", err)
+	}
+	if len(results) < pageSize {
+		return documents, "", nil
+	}
+	nextPageToken, err := encodePageToken(vs.nextPageCursor(results, so))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode page token: %w", err)
+	}
+	return documents, nextPageToken, nil
+}
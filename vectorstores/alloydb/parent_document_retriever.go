@@ -0,0 +1,156 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/textsplitter"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+const (
+	defaultParentIDKey   = "doc_id"
+	defaultNumChildDocs  = 20
+	defaultNumParentDocs = 4
+)
+
+// ParentDocumentRetriever indexes small chunks of documents in a vector
+// store, which embed more precisely, but retrieves the larger documents they
+// were split from, which give more context, from a DocStore.
+type ParentDocumentRetriever struct {
+	CallbacksHandler callbacks.Handler
+	VectorStore      vectorstores.VectorStore
+	DocStore         DocStore
+	// ChildSplitter splits the parent documents into the chunks indexed in
+	// the vector store.
+	ChildSplitter textsplitter.TextSplitter
+	// ParentSplitter, if not nil, splits the added documents into the parent
+	// documents. Otherwise the added documents are the parent documents.
+	ParentSplitter textsplitter.TextSplitter
+	// IDKey is the metadata key of the chunks set to the key of their parent
+	// document in the DocStore. It defaults to "doc_id".
+	IDKey string
+	// NumChildDocuments is the number of chunks searched for, 20 by default,
+	// and NumDocuments the maximum number of parent documents returned, 4 by
+	// default.
+	NumChildDocuments int
+	NumDocuments      int
+	// SearchOptions are the options of the searches of the chunks.
+	SearchOptions []vectorstores.Option
+}
+
+var _ schema.Retriever = ParentDocumentRetriever{}
+
+// NewParentDocumentRetriever creates a new ParentDocumentRetriever indexing
+// the chunks split by childSplitter in vectorStore, and the parent documents
+// in docStore.
+func NewParentDocumentRetriever(
+	vectorStore vectorstores.VectorStore,
+	docStore DocStore,
+	childSplitter textsplitter.TextSplitter,
+) ParentDocumentRetriever {
+	return ParentDocumentRetriever{
+		VectorStore:       vectorStore,
+		DocStore:          docStore,
+		ChildSplitter:     childSplitter,
+		IDKey:             defaultParentIDKey,
+		NumChildDocuments: defaultNumChildDocs,
+		NumDocuments:      defaultNumParentDocs,
+	}
+}
+
+// AddDocuments stores the parent documents of docs in the DocStore and
+// indexes their chunks in the vector store, and returns the keys of the
+// parent documents. The parent documents are deleted if indexing the chunks
+// fails.
+func (r ParentDocumentRetriever) AddDocuments(ctx context.Context, docs []schema.Document) ([]string, error) {
+	parents := docs
+	if r.ParentSplitter != nil {
+		var err error
+		parents, err = textsplitter.SplitDocuments(r.ParentSplitter, docs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split parent documents: %w", err)
+		}
+	}
+
+	keys := make([]string, len(parents))
+	var children []schema.Document
+	for i, parent := range parents {
+		key, err := alloydbutil.UUIDv4{}.NewID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key for document %d: %w", i, err)
+		}
+		keys[i] = key
+		chunks, err := r.ChildSplitter.SplitText(parent.PageContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split document %d: %w", i, err)
+		}
+		for _, chunk := range chunks {
+			metadata := maps.Clone(parent.Metadata)
+			if metadata == nil {
+				metadata = map[string]any{}
+			}
+			metadata[r.idKey()] = key
+			children = append(children, schema.Document{PageContent: chunk, Metadata: metadata})
+		}
+	}
+
+	if err := r.DocStore.Set(ctx, keys, parents); err != nil {
+		return nil, err
+	}
+	if _, err := r.VectorStore.AddDocuments(ctx, children); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to add chunks: %w", err), r.DocStore.Delete(ctx, keys))
+	}
+	return keys, nil
+}
+
+// GetRelevantDocuments returns the parent documents of the chunks most
+// similar to query, the parent of the most similar chunk first.
+func (r ParentDocumentRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]schema.Document, error) {
+	if r.CallbacksHandler != nil {
+		r.CallbacksHandler.HandleRetrieverStart(ctx, query)
+	}
+
+	numChildren := r.NumChildDocuments
+	if numChildren <= 0 {
+		numChildren = defaultNumChildDocs
+	}
+	children, err := r.VectorStore.SimilaritySearch(ctx, query, numChildren, r.SearchOptions...)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	seen := make(map[string]bool)
+	for _, child := range children {
+		key, ok := child.Metadata[r.idKey()].(string)
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	docs, err := r.DocStore.Get(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	if r.NumDocuments > 0 && len(docs) > r.NumDocuments {
+		docs = docs[:r.NumDocuments]
+	}
+
+	if r.CallbacksHandler != nil {
+		r.CallbacksHandler.HandleRetrieverEnd(ctx, query, docs)
+	}
+	return docs, nil
+}
+
+func (r ParentDocumentRetriever) idKey() string {
+	if r.IDKey == "" {
+		return defaultParentIDKey
+	}
+	return r.IDKey
+}
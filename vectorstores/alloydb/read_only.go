@@ -0,0 +1,27 @@
+package alloydb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+// checkWritable returns an alloydbutil.ReadOnlyError for the mutating
+// operation op in read-only mode.
+func (vs *VectorStore) checkWritable(op string) error {
+	if vs.readOnly {
+		return &alloydbutil.ReadOnlyError{Operation: op}
+	}
+	return nil
+}
+
+// beginSearch begins the transaction of a search on pool, read-only in
+// read-only mode.
+func (vs *VectorStore) beginSearch(ctx context.Context, pool *pgxpool.Pool) (pgx.Tx, error) {
+	if vs.readOnly {
+		return pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	}
+	return pool.Begin(ctx)
+}
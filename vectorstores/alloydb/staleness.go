@@ -0,0 +1,161 @@
+package alloydb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+)
+
+// ErrEmbeddingHashNotTracked is returned when looking for stale embeddings in
+// a VectorStore without an embedding hash column.
+var ErrEmbeddingHashNotTracked = errors.New("embedding hash column is not set")
+
+// defaultRefreshBatchSize is the number of stale documents re-embedded at
+// once by RefreshStaleEmbeddings.
+const defaultRefreshBatchSize = 100
+
+// contentHash returns the hash of content stored in the embedding hash
+// column. It matches the contentHashSQL expression.
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// contentHashSQL returns the SQL expression hashing the content column like
+// contentHash.
+func (vs *VectorStore) contentHashSQL() string {
+	return fmt.Sprintf("encode(sha256(convert_to(%s, 'UTF8')), 'hex')", alloydbutil.QuoteIdentifier(vs.contentColumn))
+}
+
+// staleCondition returns the condition matching the rows whose content
+// changed since their embedding was computed. The content of archived rows
+// is in cold storage, so they are never stale.
+func (vs *VectorStore) staleCondition() string {
+	condition := fmt.Sprintf("%s IS DISTINCT FROM %s", alloydbutil.QuoteIdentifier(vs.embeddingHashColumn), vs.contentHashSQL())
+	if vs.archiveKeyColumn != "" {
+		condition += fmt.Sprintf(" AND %s IS NULL", alloydbutil.QuoteIdentifier(vs.archiveKeyColumn))
+	}
+	return condition
+}
+
+// FindStaleEmbeddings returns the IDs of the documents whose content was
+// edited after their embedding was computed, including the ones added before
+// the embedding hash column was set.
+func (vs *VectorStore) FindStaleEmbeddings(ctx context.Context) ([]string, error) {
+	if vs.embeddingHashColumn == "" {
+		return nil, ErrEmbeddingHashNotTracked
+	}
+	query := fmt.Sprintf(`SELECT %s::text FROM %s WHERE %s`,
+		alloydbutil.QuoteIdentifier(vs.idColumn), vs.table(), vs.staleCondition())
+
+	var ids []string
+	err := vs.engine.Retry(ctx, func(ctx context.Context) error {
+		rows, err := vs.engine.Pool.Query(ctx, query)
+		if err != nil {
+			return err
+		}
+		ids, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stale embeddings: %w", err)
+	}
+	return ids, nil
+}
+
+// RefreshStaleEmbeddings re-embeds the documents found by
+// FindStaleEmbeddings, batchSize documents at a time (100 when not
+// positive), and returns the number of refreshed documents. Callers are
+// expected to run it periodically, or after editing content. Documents
+// edited again while being re-embedded are left stale for the next run.
+func (vs *VectorStore) RefreshStaleEmbeddings(ctx context.Context, batchSize int) (int, error) {
+	if err := vs.checkWritable("refresh stale embeddings"); err != nil {
+		return 0, err
+	}
+	if vs.embeddingHashColumn == "" {
+		return 0, ErrEmbeddingHashNotTracked
+	}
+	if batchSize <= 0 {
+		batchSize = defaultRefreshBatchSize
+	}
+	idColumn := alloydbutil.QuoteIdentifier(vs.idColumn)
+	selectStmt := fmt.Sprintf(`SELECT %s::text, %s FROM %s WHERE %s LIMIT $1`,
+		idColumn, alloydbutil.QuoteIdentifier(vs.contentColumn), vs.table(), vs.staleCondition())
+	updateStmt := fmt.Sprintf(`UPDATE %s SET %s = $1, %s = $2 WHERE %s::text = $3 AND %s = $2`, vs.table(),
+		alloydbutil.QuoteIdentifier(vs.embeddingColumn), alloydbutil.QuoteIdentifier(vs.embeddingHashColumn), idColumn,
+		vs.contentHashSQL())
+
+	refreshed := 0
+	for {
+		var ids, contents []string
+		err := vs.engine.Retry(ctx, func(ctx context.Context) error {
+			ids, contents = nil, nil
+			rows, err := vs.engine.Pool.Query(ctx, selectStmt, batchSize)
+			if err != nil {
+				return err
+			}
+			var id, content string
+			_, err = pgx.ForEachRow(rows, []any{&id, &content}, func() error {
+				ids = append(ids, id)
+				contents = append(contents, content)
+				return nil
+			})
+			return err
+		})
+		if err != nil {
+			return refreshed, fmt.Errorf("failed to find stale embeddings: %w", err)
+		}
+		if len(ids) == 0 {
+			return refreshed, nil
+		}
+
+		var embeddings [][]float32
+		err = withStageTimeout(ctx, vs.embeddingTimeout, ErrEmbeddingTimeout, func(ctx context.Context) error {
+			embeddings, err = vs.embedder.EmbedDocuments(ctx, contents)
+			return err
+		})
+		if err != nil {
+			return refreshed, fmt.Errorf("failed embed documents: %w", err)
+		}
+		if len(embeddings) != len(contents) {
+			return refreshed, fmt.Errorf("embedder returned %d embeddings for %d documents", len(embeddings), len(contents))
+		}
+
+		b := &pgx.Batch{}
+		for i := range ids {
+			b.Queue(updateStmt, formatEmbedding(vs.embeddingType, embeddings[i]), contentHash(contents[i]), ids[i])
+		}
+		updated := 0
+		err = withStageTimeout(ctx, vs.writeTimeout, ErrWriteTimeout, func(ctx context.Context) error {
+			return vs.engine.WithTx(ctx, func(tx pgx.Tx) error {
+				return vs.withSQLHooks(ctx, tx, OperationRefreshEmbeddings, func() error {
+					results := tx.SendBatch(ctx, b)
+					defer results.Close()
+					updated = 0
+					for range ids {
+						tag, err := results.Exec()
+						if err != nil {
+							return err
+						}
+						updated += int(tag.RowsAffected())
+					}
+					return results.Close()
+				})
+			})
+		})
+		if err != nil {
+			return refreshed, fmt.Errorf("failed to update embeddings: %w", err)
+		}
+		refreshed += updated
+		// Stop when every row of the batch was edited concurrently, rather
+		// than racing the editor, or when there are no more stale rows.
+		if updated == 0 || len(ids) < batchSize {
+			return refreshed, nil
+		}
+	}
+}
@@ -3,13 +3,15 @@ package alloydb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/pgvector/pgvector-go"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/indexes"
 	"github.com/tmc/langchaingo/schema"
 	"github.com/tmc/langchaingo/util/alloydbutil"
 	"github.com/tmc/langchaingo/vectorstores"
@@ -19,6 +21,23 @@ const (
 	defaultIndexNameSuffix = "langchainvectorindex"
 )
 
+// ErrStopIteration can be returned from a SimilaritySearchIter callback to
+// stop the search early without error.
+var ErrStopIteration = errors.New("stop iteration")
+
+var (
+	// ErrEmbeddingTimeout is returned by AddDocuments when embedding the
+	// documents exceeds its deadline.
+	ErrEmbeddingTimeout = errors.New("embedding documents timed out")
+	// ErrWriteTimeout is returned by AddDocuments when inserting the
+	// documents exceeds its deadline.
+	ErrWriteTimeout = errors.New("writing documents timed out")
+	// ErrInvalidFilters is returned when the filters of a search are neither
+	// a SQL condition string nor a vectorstores.Filter, or when a
+	// vectorstores.Filter is used without a metadata json column.
+	ErrInvalidFilters = errors.New("filters must be a SQL condition or a vectorstores.Filter")
+)
+
 type VectorStore struct {
 	engine             alloydbutil.PostgresEngine
 	embedder           embeddings.Embedder
@@ -31,9 +50,37 @@ type VectorStore struct {
 	metadataColumns    []string
 	k                  int
 	distanceStrategy   distanceStrategy
+	idGenerator        alloydbutil.IDGenerator
+	auditTable         string
+	embeddingTimeout   time.Duration
+	writeTimeout       time.Duration
+	// embeddingHashColumn holds the hash of the content each embedding was
+	// computed from, when set.
+	embeddingHashColumn string
+	embeddingType       alloydbutil.EmbeddingType
+	// additionalEmbeddings are the embedding columns besides embeddingColumn.
+	additionalEmbeddings []additionalEmbedding
+	// expiresAtColumn holds the time each document expires at, when set.
+	expiresAtColumn string
+	defaultTTL      time.Duration
+	// coldStorage holds the content of the archived documents, whose key is
+	// in archiveKeyColumn.
+	coldStorage      ColdStorage
+	archiveKeyColumn string
+	// preSQLHooks and postSQLHooks run in the transaction of every
+	// operation, before and after its statements.
+	preSQLHooks  []SQLHook
+	postSQLHooks []SQLHook
+	// readOnly refuses the mutating operations and runs the searches in
+	// read-only transactions.
+	readOnly bool
+	// imageURIMetadataKey is the metadata key of the URI of the image
+	// embedded instead of the content of image documents.
+	imageURIMetadataKey string
 }
 
 type BaseIndex struct {
+	column           string
 	name             string
 	indexType        string
 	options          Index
@@ -42,12 +89,32 @@ type BaseIndex struct {
 }
 
 type SearchDocument struct {
+	ID                string
 	Content           string
 	LangchainMetadata string
 	Distance          float32
+	// Embedding is the searched embedding of the document, only read with
+	// WithReturnEmbeddings.
+	Embedding []float32
+	// ArchiveKey is the cold storage key of the content of an archived
+	// document.
+	ArchiveKey string
 }
 
-var _ vectorstores.VectorStore = &VectorStore{}
+const (
+	// EmbeddingMetadataKey is the document metadata key set to the searched
+	// embedding of the document, as a []float32, with WithReturnEmbeddings.
+	EmbeddingMetadataKey = "embedding"
+	// DistanceMetadataKey is the document metadata key set to the distance
+	// between the query and the document computed by the distance strategy,
+	// as a float32, with WithReturnDistance.
+	DistanceMetadataKey = "distance"
+)
+
+var (
+	_ vectorstores.VectorStore = &VectorStore{}
+	_ indexes.VectorStore      = &VectorStore{}
+)
 
 // NewVectorStore creates a new VectorStore with options.
 func NewVectorStore(engine alloydbutil.PostgresEngine,
@@ -62,14 +129,47 @@ func NewVectorStore(engine alloydbutil.PostgresEngine,
 	return vs, nil
 }
 
-// AddDocuments adds documents to the Postgres collection, and returns the ids
-// of the added documents.
-func (vs *VectorStore) AddDocuments(ctx context.Context, docs []schema.Document, _ ...vectorstores.Option) ([]string, error) {
+// table returns the quoted, schema qualified name of the table of the store.
+func (vs *VectorStore) table() string {
+	return alloydbutil.QuoteIdentifier(vs.schemaName, vs.tableName)
+}
+
+// AddDocuments adds documents to the Postgres collection in a single
+// transaction, and returns the ids of the added documents. The documents are
+// embedded with the embedder of the options, if any, instead of the embedder
+// of the store. With WithImageURIMetadataKey, the image of image documents is
+// embedded instead of their content.
+func (vs *VectorStore) AddDocuments(ctx context.Context, docs []schema.Document, options ...vectorstores.Option) ([]string, error) {
+	if err := vs.checkWritable("add documents"); err != nil {
+		return nil, err
+	}
+	opts := applyOpts(options...)
+	expiresAt, err := vs.expiresAt(getSearchOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	embedder := vs.embedder
+	if opts.Embedder != nil {
+		embedder = opts.Embedder
+	}
 	texts := make([]string, 0, len(docs))
 	for _, doc := range docs {
 		texts = append(texts, doc.PageContent)
 	}
-	embeddings, err := vs.embedder.EmbedDocuments(ctx, texts)
+	var embeddings [][]float32
+	var additionalEmbeddings [][]any
+	err = withStageTimeout(ctx, vs.embeddingTimeout, ErrEmbeddingTimeout, func(ctx context.Context) error {
+		var err error
+		embeddings, err = vs.embedDocuments(ctx, embedder, docs)
+		if err != nil {
+			return err
+		}
+		if len(embeddings) != len(texts) {
+			return fmt.Errorf("got %d embeddings for %d documents", len(embeddings), len(texts))
+		}
+		additionalEmbeddings, err = vs.embedAdditionalColumns(ctx, docs)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed embed documents: %w", err)
 	}
@@ -78,9 +178,13 @@ func (vs *VectorStore) AddDocuments(ctx context.Context, docs []schema.Document,
 	for i, doc := range docs {
 		if val, ok := doc.Metadata["id"].(string); ok {
 			ids[i] = val
-		} else {
-			ids[i] = uuid.New().String()
+			continue
+		}
+		id, err := vs.idGenerator.NewID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate id for document %d: %w", i, err)
 		}
+		ids[i] = id
 	}
 	// If no metadata provided, initialize with empty maps
 	metadatas := make([]map[string]any, len(docs))
@@ -96,20 +200,29 @@ func (vs *VectorStore) AddDocuments(ctx context.Context, docs []schema.Document,
 	for i := range texts {
 		id := ids[i]
 		content := texts[i]
-		embedding := pgvector.NewVector(embeddings[i]).String()
+		embedding := formatEmbedding(vs.embeddingType, embeddings[i])
 		metadata := metadatas[i]
 		// Construct metadata column names if present
 		metadataColNames := ""
-		if len(vs.metadataColumns) > 0 {
-			metadataColNames = ", " + strings.Join(vs.metadataColumns, ", ")
+		for _, metadataColumn := range vs.metadataColumns {
+			metadataColNames += ", " + alloydbutil.QuoteIdentifier(metadataColumn)
 		}
 
 		if vs.metadataJSONColumn != "" {
-			metadataColNames += ", " + vs.metadataJSONColumn
+			metadataColNames += ", " + alloydbutil.QuoteIdentifier(vs.metadataJSONColumn)
+		}
+		if vs.embeddingHashColumn != "" {
+			metadataColNames += ", " + alloydbutil.QuoteIdentifier(vs.embeddingHashColumn)
+		}
+		for _, additional := range vs.additionalEmbeddings {
+			metadataColNames += ", " + alloydbutil.QuoteIdentifier(additional.column)
+		}
+		if !expiresAt.IsZero() {
+			metadataColNames += ", " + alloydbutil.QuoteIdentifier(vs.expiresAtColumn)
 		}
 
-		insertStmt := fmt.Sprintf(`INSERT INTO %q.%q (%s, %s, %s%s)`,
-			vs.schemaName, vs.tableName, vs.idColumn, vs.contentColumn, vs.embeddingColumn, metadataColNames)
+		insertStmt := fmt.Sprintf(`INSERT INTO %s (%s, %s, %s%s)`, vs.table(), alloydbutil.QuoteIdentifier(vs.idColumn),
+			alloydbutil.QuoteIdentifier(vs.contentColumn), alloydbutil.QuoteIdentifier(vs.embeddingColumn), metadataColNames)
 		valuesStmt := "VALUES ($1, $2, $3"
 		values := []any{id, content, embedding}
 
@@ -131,110 +244,479 @@ func (vs *VectorStore) AddDocuments(ctx context.Context, docs []schema.Document,
 			}
 			values = append(values, metadataJSON)
 		}
+		if vs.embeddingHashColumn != "" {
+			valuesStmt += fmt.Sprintf(", $%d", len(values)+1)
+			values = append(values, contentHash(content))
+		}
+		for c := range vs.additionalEmbeddings {
+			valuesStmt += fmt.Sprintf(", $%d", len(values)+1)
+			values = append(values, additionalEmbeddings[c][i])
+		}
+		if !expiresAt.IsZero() {
+			valuesStmt += fmt.Sprintf(", $%d", len(values)+1)
+			values = append(values, expiresAt)
+		}
 		valuesStmt += ")"
 		query := insertStmt + valuesStmt
 		b.Queue(query, values...)
 
 	}
 
-	batchResults := vs.engine.Pool.SendBatch(ctx, b)
-	if err := batchResults.Close(); err != nil {
-		return nil, fmt.Errorf("failed to execute batch: %w", err)
+	// Insert all the documents or none of them.
+	err = withStageTimeout(ctx, vs.writeTimeout, ErrWriteTimeout, func(ctx context.Context) error {
+		return vs.engine.WithTx(ctx, func(tx pgx.Tx) error {
+			return vs.withSQLHooks(ctx, tx, OperationAddDocuments, func() error {
+				if err := tx.SendBatch(ctx, b).Close(); err != nil {
+					return fmt.Errorf("failed to execute batch: %w", err)
+				}
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return ids, nil
 }
 
+// withStageTimeout runs a stage of AddDocuments with an optional timeout,
+// wrapping its error with stageErr when the stage deadline, or the one of
+// ctx, is exceeded.
+func withStageTimeout(ctx context.Context, timeout time.Duration, stageErr error, fn func(context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := fn(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", stageErr, err)
+	}
+	return err
+}
+
+// DeleteDocuments deletes the documents with the given IDs in a single
+// transaction, and returns the number of deleted documents. The content of
+// archived documents is left in cold storage.
+func (vs *VectorStore) DeleteDocuments(ctx context.Context, ids []string) (int, error) {
+	if err := vs.checkWritable("delete documents"); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	stmt := fmt.Sprintf(`DELETE FROM %s WHERE %s::text = ANY($1)`, vs.table(), alloydbutil.QuoteIdentifier(vs.idColumn))
+	deleted := 0
+	err := vs.engine.WithTx(ctx, func(tx pgx.Tx) error {
+		return vs.withSQLHooks(ctx, tx, OperationDeleteDocuments, func() error {
+			tag, err := tx.Exec(ctx, stmt, ids)
+			if err != nil {
+				return err
+			}
+			deleted = int(tag.RowsAffected())
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return deleted, nil
+}
+
 // SimilaritySearch performs a similarity search on the database using the
-// query vector.
-func (vs *VectorStore) SimilaritySearch(ctx context.Context, query string, _ int, options ...vectorstores.Option) ([]schema.Document, error) {
+// query vector. numDocuments limits the number of documents, the store's k
+// is used when it is not positive. The filters are a SQL condition on the
+// columns of the table, or a vectorstores.Filter on the metadata json column.
+func (vs *VectorStore) SimilaritySearch(ctx context.Context, query string, numDocuments int, options ...vectorstores.Option) ([]schema.Document, error) {
+	if numDocuments <= 0 {
+		numDocuments = vs.k
+	}
+	opts := applyOpts(options...)
+	stmt, args, err := vs.searchQuery(ctx, query, numDocuments, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []SearchDocument
+	err = vs.executeSQLQuery(ctx, query, stmt, getSearchOptions(opts), func(result SearchDocument) error {
+		results = append(results, result)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute sql query: %w", err)
+	}
+	if err := vs.rehydrate(ctx, results); err != nil {
+		return nil, err
+	}
+	documents, err := vs.processResultsToDocuments(results, getSearchOptions(opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to process Results to Documents with Scores: %w", err)
+	}
+	return documents, nil
+}
+
+// SimilaritySearchIter performs a similarity search like SimilaritySearch,
+// but streams the matching documents to fn as rows are read instead of
+// materializing the whole result set. numDocuments limits the number of
+// rows, the store's k is used when it is not positive. Returning
+// ErrStopIteration from fn stops the search without error, any other error
+// stops it and is returned.
+func (vs *VectorStore) SimilaritySearchIter(ctx context.Context,
+	query string,
+	numDocuments int,
+	fn func(schema.Document) error,
+	options ...vectorstores.Option,
+) error {
+	if numDocuments <= 0 {
+		numDocuments = vs.k
+	}
 	opts := applyOpts(options...)
-	var documents []schema.Document
+	stmt, args, err := vs.searchQuery(ctx, query, numDocuments, opts)
+	if err != nil {
+		return err
+	}
+
+	so := getSearchOptions(opts)
+	err = vs.executeSQLQuery(ctx, query, stmt, so, func(result SearchDocument) error {
+		results := []SearchDocument{result}
+		if err := vs.rehydrate(ctx, results); err != nil {
+			return err
+		}
+		doc, err := searchDocumentToDocument(results[0], so)
+		if err != nil {
+			return err
+		}
+		return fn(doc)
+	}, args...)
+	if err != nil && !errors.Is(err, ErrStopIteration) {
+		return err
+	}
+	return nil
+}
+
+// SearchResult is a document streamed by SimilaritySearchStream, or the
+// error that ended the stream.
+type SearchResult struct {
+	Document schema.Document
+	Err      error
+}
+
+// SimilaritySearchStream performs a similarity search like
+// SimilaritySearchIter, sending the matching documents one at a time over the
+// returned channel, which is closed once the search is done. A failed search
+// sends a last result with Err set. Rows are only read as fast as the channel
+// is received from, so exports of whole collections run in constant memory,
+// but the search transaction stays open until the channel is drained or ctx
+// is canceled.
+func (vs *VectorStore) SimilaritySearchStream(ctx context.Context,
+	query string,
+	numDocuments int,
+	options ...vectorstores.Option,
+) <-chan SearchResult {
+	results := make(chan SearchResult)
+	go func() {
+		defer close(results)
+		err := vs.SimilaritySearchIter(ctx, query, numDocuments, func(doc schema.Document) error {
+			select {
+			case results <- SearchResult{Document: doc}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}, options...)
+		if err != nil {
+			select {
+			case results <- SearchResult{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return results
+}
+
+// searchQuery embeds the query and builds the similarity search statement
+// along with its arguments.
+func (vs *VectorStore) searchQuery(ctx context.Context, query string, limit int, opts vectorstores.Options) (string, []any, error) {
+	so := getSearchOptions(opts)
+	column, err := vs.searchEmbeddingColumn(so)
+	if err != nil {
+		return "", nil, err
+	}
+	embeddingColumn := alloydbutil.QuoteIdentifier(column)
 	embedding, err := vs.embedder.EmbedQuery(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed embed query: %w", err)
+		return "", nil, fmt.Errorf("failed embed query: %w", err)
 	}
 	operator := vs.distanceStrategy.operator()
 	searchFunction := vs.distanceStrategy.similaritySearchFunction()
 
 	columns := []string{}
-	columns = append(columns, vs.contentColumn)
+	columns = append(columns, alloydbutil.QuoteIdentifier(vs.idColumn)+"::text", alloydbutil.QuoteIdentifier(vs.contentColumn))
 	if vs.metadataJSONColumn != "" {
-		columns = append(columns, vs.metadataJSONColumn)
+		columns = append(columns, alloydbutil.QuoteIdentifier(vs.metadataJSONColumn))
+	} else {
+		columns = append(columns, "'{}'::json")
 	}
 	columnNames := strings.Join(columns, `, `)
-	whereClause := ""
+	var conditions []string
+	if column != vs.embeddingColumn {
+		// Additional embedding columns are nullable.
+		conditions = append(conditions, fmt.Sprintf("%s IS NOT NULL", embeddingColumn))
+	}
+	if vs.expiresAtColumn != "" {
+		conditions = append(conditions, vs.notExpiredCondition())
+	}
+	args := []any{formatEmbedding(vs.embeddingType, embedding), limit}
+	if so.after != nil {
+		// The rows following the previous page are the ones further from the
+		// query, or as far but not returned yet.
+		orderKey := fmt.Sprintf("(%s %s $1::%s)::real", embeddingColumn, operator, vs.embeddingType)
+		conditions = append(conditions, fmt.Sprintf("%s >= $3::real AND NOT (%s = $3::real AND %s::text = ANY($4))",
+			orderKey, orderKey, alloydbutil.QuoteIdentifier(vs.idColumn)))
+		args = append(args, so.after.Key, so.after.IDs)
+	}
 	if opts.Filters != nil {
-		whereClause = fmt.Sprintf("WHERE %s", opts.Filters)
+		var condition string
+		if condition, args, err = vs.filterCondition(opts.Filters, args); err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	extraColumns := ""
+	if so.returnEmbeddings {
+		extraColumns = ", " + embeddingArraySQL(vs.embeddingType, embeddingColumn)
+	}
+	if vs.archiveKeyColumn != "" {
+		extraColumns += fmt.Sprintf(", COALESCE(%s, '')", alloydbutil.QuoteIdentifier(vs.archiveKeyColumn))
+	}
+	offset := ""
+	if so.offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", so.offset)
 	}
 	stmt := fmt.Sprintf(`
-        SELECT %s, %s(%s, '%s') AS distance FROM "%s"."%s" %s ORDER BY %s %s '%s' LIMIT $1::int;`,
-		columnNames, searchFunction, vs.embeddingColumn, vector.String(), vs.schemaName, vs.tableName, whereClause, vs.embeddingColumn, operator, vector.String())
+        SELECT %s, %s(%s, $1::%s) AS distance%s FROM %s %s ORDER BY %s %s $1::%s LIMIT $2::int%s;`,
+		columnNames, searchFunction, embeddingColumn, vs.embeddingType, extraColumns, vs.table(),
+		whereClause, embeddingColumn, operator, vs.embeddingType, offset)
+
+	return stmt, args, nil
+}
+
+// partialResultsError stops the retries of a search whose results were
+// already passed to the caller. It deliberately doesn't unwrap, so the
+// transient cause isn't seen by the retry policy.
+type partialResultsError struct {
+	err error
+}
+
+func (e partialResultsError) Error() string {
+	return e.err.Error()
+}
+
+// executeSQLQuery runs a search statement, retrying transient errors with the
+// engine's retry policy until fn has been called. Searches run on the
+// engine's read pool instance, if any, unless the audit log must be written.
+// When the audit log is enabled, the rows passed to fn are recorded however
+// the search ended, including when fn stopped it.
+func (vs *VectorStore) executeSQLQuery(ctx context.Context,
+	query string,
+	stmt string,
+	so searchOptions,
+	fn func(SearchDocument) error,
+	args ...any,
+) error {
+	delivered := false
+	var accessed []SearchDocument
+	deliver := func(doc SearchDocument) error {
+		delivered = true
+		if vs.auditTable != "" {
+			accessed = append(accessed, SearchDocument{ID: doc.ID, Distance: doc.Distance})
+		}
+		return fn(doc)
+	}
+	err := vs.engine.Retry(ctx, func(ctx context.Context) error {
+		search := func(ctx context.Context, pool *pgxpool.Pool) error {
+			return vs.executeSearch(ctx, pool, stmt, so, deliver, args...)
+		}
+		var err error
+		if vs.auditTable != "" {
+			err = search(ctx, vs.engine.Pool)
+		} else {
+			err = vs.engine.Read(ctx, search)
+		}
+		if err != nil && delivered {
+			return partialResultsError{err: err}
+		}
+		return err
+	})
+	var partial partialResultsError
+	if errors.As(err, &partial) {
+		err = partial.err
+	}
+
+	// The search transaction is rolled back when fn stops the search, so the
+	// access is recorded on its own, even if ctx was canceled meanwhile.
+	auditErr := vs.recordAccess(context.WithoutCancel(ctx), query, so, accessed)
+	switch {
+	case auditErr == nil:
+		return err
+	case err == nil || errors.Is(err, ErrStopIteration):
+		return auditErr
+	default:
+		return errors.Join(err, auditErr)
+	}
+}
 
-	results, err := vs.executeSQLQuery(ctx, stmt)
+// executeSearch runs a search statement inside a transaction, applying the
+// session parameters of the search options first, and calls fn for every
+// row as it is read.
+func (vs *VectorStore) executeSearch(ctx context.Context,
+	pool *pgxpool.Pool,
+	stmt string,
+	so searchOptions,
+	fn func(SearchDocument) error,
+	args ...any,
+) error {
+	tx, err := vs.beginSearch(ctx, pool)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute sql query: %w", err)
+		return fmt.Errorf("failed to begin search transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for name, value := range so.sessionParameters {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL %s = %s", name, value)); err != nil {
+			return fmt.Errorf("failed to set %s: %w", name, err)
+		}
 	}
-	documents, err = vs.processResultsToDocuments(results)
+
+	err = vs.withSQLHooks(ctx, tx, OperationSearch, func() error {
+		return vs.querySearch(ctx, tx, stmt, so, fn, args...)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to process Results to Documents with Scores: %w", err)
+		return err
 	}
-	return documents, nil
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit search transaction: %w", err)
+	}
+	return nil
 }
 
-func (vs *VectorStore) executeSQLQuery(ctx context.Context, stmt string) ([]SearchDocument, error) {
-	rows, err := vs.engine.Pool.Query(ctx, stmt, vs.k)
+// querySearch runs a search statement in tx and calls fn for every row as it
+// is read.
+func (vs *VectorStore) querySearch(ctx context.Context,
+	tx pgx.Tx,
+	stmt string,
+	so searchOptions,
+	fn func(SearchDocument) error,
+	args ...any,
+) error {
+	rows, err := tx.Query(ctx, stmt, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute similar search query: %w", err)
+		return fmt.Errorf("failed to execute similar search query: %w", err)
 	}
 	defer rows.Close()
 
-	var results []SearchDocument
 	for rows.Next() {
 		doc := SearchDocument{}
 
-		err = rows.Scan(&doc.Content, &doc.LangchainMetadata, &doc.Distance)
+		dest := []any{&doc.ID, &doc.Content, &doc.LangchainMetadata, &doc.Distance}
+		if so.returnEmbeddings {
+			dest = append(dest, &doc.Embedding)
+		}
+		if vs.archiveKeyColumn != "" {
+			dest = append(dest, &doc.ArchiveKey)
+		}
+		err = rows.Scan(dest...)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan result: %w", err)
+			return fmt.Errorf("failed to scan result: %w", err)
+		}
+		if err := fn(doc); err != nil {
+			return err
 		}
-		results = append(results, doc)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows iteration error: %w", err)
+		return fmt.Errorf("rows iteration error: %w", err)
 	}
-	return results, nil
+	return nil
 }
 
-func (*VectorStore) processResultsToDocuments(results []SearchDocument) ([]schema.Document, error) {
+func (*VectorStore) processResultsToDocuments(results []SearchDocument, so searchOptions) ([]schema.Document, error) {
 	documents := make([]schema.Document, 0, len(results))
 	for _, result := range results {
-		mapMetadata := map[string]any{}
-		err := json.Unmarshal([]byte(result.LangchainMetadata), &mapMetadata)
+		doc, err := searchDocumentToDocument(result, so)
 		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal langchain metadata: %w", err)
-		}
-		doc := schema.Document{
-			PageContent: result.Content,
-			Metadata:    mapMetadata,
-			Score:       result.Distance,
+			return nil, err
 		}
 		documents = append(documents, doc)
 	}
 	return documents, nil
 }
 
+// searchDocumentToDocument converts a search result row into a Document,
+// adding the embedding and distance to its metadata when requested by so.
+func searchDocumentToDocument(result SearchDocument, so searchOptions) (schema.Document, error) {
+	mapMetadata := map[string]any{}
+	err := json.Unmarshal([]byte(result.LangchainMetadata), &mapMetadata)
+	if err != nil {
+		return schema.Document{}, fmt.Errorf("failed to unmarshal langchain metadata: %w", err)
+	}
+	if so.returnEmbeddings {
+		mapMetadata[EmbeddingMetadataKey] = result.Embedding
+	}
+	if so.returnDistance {
+		mapMetadata[DistanceMetadataKey] = result.Distance
+	}
+	return schema.Document{
+		PageContent: result.Content,
+		Metadata:    mapMetadata,
+		Score:       result.Distance,
+	}, nil
+}
+
 // ApplyVectorIndex creates an index in the table of the embeddings.
 func (vs *VectorStore) ApplyVectorIndex(ctx context.Context, index BaseIndex, name string, concurrently, overwrite bool) error {
+	if err := vs.checkWritable("apply vector index"); err != nil {
+		return err
+	}
 	if index.indexType == "exactnearestneighbor" {
 		return vs.DropVectorIndex(ctx, name, overwrite)
 	}
-	function := index.distanceStrategy.searchFunction()
+	if err := vs.createIndexExtension(ctx, index); err != nil {
+		return err
+	}
+	stmt := vs.createIndexStatement(index, name, concurrently)
+
+	_, err := vs.engine.Pool.Exec(ctx, stmt)
+	if err != nil {
+		return fmt.Errorf("failed to execute creation of index: %w", err)
+	}
+	_, err = vs.engine.Pool.Exec(ctx, "COMMIT")
+	if err != nil {
+		return fmt.Errorf("failed to commit index: %w", err)
+	}
+	return nil
+}
+
+// createIndexExtension creates the extension providing the index type, if
+// any.
+func (vs *VectorStore) createIndexExtension(ctx context.Context, index BaseIndex) error {
 	if index.indexType == "ScaNN" {
 		_, err := vs.engine.Pool.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS alloydb_scann")
 		if err != nil {
 			return fmt.Errorf("failed to create alloydb scann extension: %w", err)
 		}
 	}
+	return nil
+}
+
+// createIndexStatement builds the statement creating index, named name or
+// after the table when name is empty.
+func (vs *VectorStore) createIndexStatement(index BaseIndex, name string, concurrently bool) string {
+	function := operatorClass(vs.embeddingType, index.distanceStrategy)
 	filter := ""
 	if len(index.partialIndexes) > 0 {
 		filter = fmt.Sprintf("WHERE %s", index.partialIndexes)
@@ -242,9 +724,16 @@ func (vs *VectorStore) ApplyVectorIndex(ctx context.Context, index BaseIndex, na
 	optsString := index.indexOptions()
 	params := fmt.Sprintf("WITH %s", optsString)
 
+	column := vs.embeddingColumn
+	if index.column != "" {
+		column = index.column
+	}
 	if name == "" {
 		if index.name == "" {
 			index.name = vs.tableName + defaultIndexNameSuffix
+			if column != vs.embeddingColumn {
+				index.name = vs.tableName + column + defaultIndexNameSuffix
+			}
 		}
 		name = index.name
 	}
@@ -254,26 +743,20 @@ func (vs *VectorStore) ApplyVectorIndex(ctx context.Context, index BaseIndex, na
 		concurrentlyStr = "CONCURRENTLY"
 	}
 
-	stmt := fmt.Sprintf("CREATE INDEX %s %s ON %s.%s USING %s (%s %s) %s %s",
-		concurrentlyStr, name, vs.schemaName, vs.tableName, index.indexType, vs.embeddingColumn, function, params, filter)
-
-	_, err := vs.engine.Pool.Exec(ctx, stmt)
-	if err != nil {
-		return fmt.Errorf("failed to execute creation of index: %w", err)
-	}
-	_, err = vs.engine.Pool.Exec(ctx, "COMMIT")
-	if err != nil {
-		return fmt.Errorf("failed to commit index: %w", err)
-	}
-	return nil
+	return fmt.Sprintf("CREATE INDEX %s %s ON %s USING %s (%s %s) %s %s",
+		concurrentlyStr, alloydbutil.QuoteIdentifier(name), vs.table(), index.indexType, alloydbutil.QuoteIdentifier(column),
+		function, params, filter)
 }
 
 // ReIndex re-indexes the VectorStore.
 func (vs *VectorStore) ReIndex(ctx context.Context, indexName string) error {
+	if err := vs.checkWritable("reindex"); err != nil {
+		return err
+	}
 	if indexName == "" {
 		indexName = vs.tableName + defaultIndexNameSuffix
 	}
-	query := fmt.Sprintf("REINDEX INDEX %s;", indexName)
+	query := fmt.Sprintf("REINDEX INDEX %s;", alloydbutil.QuoteIdentifier(vs.schemaName, indexName))
 	_, err := vs.engine.Pool.Exec(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to reindex: %w", err)
@@ -284,6 +767,9 @@ func (vs *VectorStore) ReIndex(ctx context.Context, indexName string) error {
 
 // DropVectorIndex drops the vector index from the VectorStore.
 func (vs *VectorStore) DropVectorIndex(ctx context.Context, indexName string, overwrite bool) error {
+	if err := vs.checkWritable("drop vector index"); err != nil {
+		return err
+	}
 	// Overwrite allows dangerous operations like a Drop query.
 	if !overwrite {
 		return nil
@@ -291,7 +777,7 @@ func (vs *VectorStore) DropVectorIndex(ctx context.Context, indexName string, ov
 	if indexName == "" {
 		indexName = vs.tableName + defaultIndexNameSuffix
 	}
-	query := fmt.Sprintf("DROP INDEX IF EXISTS %s;", indexName)
+	query := fmt.Sprintf("DROP INDEX IF EXISTS %s;", alloydbutil.QuoteIdentifier(vs.schemaName, indexName))
 	_, err := vs.engine.Pool.Exec(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to drop vector index: %w", err)
@@ -305,10 +791,9 @@ func (vs *VectorStore) IsValidIndex(ctx context.Context, indexName string) (bool
 	if indexName == "" {
 		indexName = vs.tableName + defaultIndexNameSuffix
 	}
-	query := fmt.Sprintf("SELECT tablename, indexname  FROM pg_indexes WHERE tablename = '%s' AND schemaname = '%s' AND indexname = '%s';",
-		vs.tableName, vs.schemaName, indexName)
+	query := "SELECT tablename, indexname  FROM pg_indexes WHERE tablename = $1 AND schemaname = $2 AND indexname = $3;"
 	var tablename, indexnameFromDB string
-	err := vs.engine.Pool.QueryRow(ctx, query).Scan(&tablename, &indexnameFromDB)
+	err := vs.engine.Pool.QueryRow(ctx, query, vs.tableName, vs.schemaName, indexName).Scan(&tablename, &indexnameFromDB)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if index exists: %w", err)
 	}
@@ -316,6 +801,13 @@ func (vs *VectorStore) IsValidIndex(ctx context.Context, indexName string) (bool
 	return indexnameFromDB == indexName, nil
 }
 
+// OnColumn returns a copy of the index built on the given additional
+// embedding column instead of the main one.
+func (index BaseIndex) OnColumn(column string) BaseIndex {
+	index.column = column
+	return index
+}
+
 func (*VectorStore) NewBaseIndex(indexName, indexType string, strategy distanceStrategy, partialIndexes []string, opts Index) BaseIndex {
 	return BaseIndex{
 		name:             indexName,
@@ -2,9 +2,12 @@ package alloydb
 
 import (
 	"errors"
-	"github.com/tmc/langchaingo/util/alloydbutil"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/util/alloydbutil"
 	"github.com/tmc/langchaingo/vectorstores"
 )
 
@@ -63,7 +66,8 @@ func WithMetadataColumns(metadataColumns []string) VectorStoreOption {
 	}
 }
 
-// WithK sets the number of Documents to return from the VectorStore.
+// WithK sets the number of Documents to return from the VectorStore when
+// searches don't specify a positive number of documents.
 func WithK(k int) VectorStoreOption {
 	return func(v *VectorStore) {
 		v.k = k
@@ -77,6 +81,140 @@ func WithDistanceStrategy(distanceStrategy distanceStrategy) VectorStoreOption {
 	}
 }
 
+// WithIDGenerator sets the generator used for documents without an "id"
+// metadata value.
+func WithIDGenerator(idGenerator alloydbutil.IDGenerator) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.idGenerator = idGenerator
+	}
+}
+
+// WithAuditTable enables the document access audit log, recording the
+// documents returned by every search into auditTable. The table is created
+// with alloydbutil.InitDocumentAuditTable in the VectorStore's schema.
+func WithAuditTable(auditTable string) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.auditTable = auditTable
+	}
+}
+
+// WithEmbeddingTimeout sets the deadline for embedding the documents of
+// each AddDocuments call.
+func WithEmbeddingTimeout(timeout time.Duration) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.embeddingTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets the deadline for inserting the documents of each
+// AddDocuments call.
+func WithWriteTimeout(timeout time.Duration) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.writeTimeout = timeout
+	}
+}
+
+// WithEmbeddingHashColumn sets the column, created with
+// VectorstoreTableOptions.EmbeddingHashColumn, holding the hash of the
+// content each embedding was computed from. It enables FindStaleEmbeddings
+// and RefreshStaleEmbeddings.
+func WithEmbeddingHashColumn(embeddingHashColumn string) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.embeddingHashColumn = embeddingHashColumn
+	}
+}
+
+// WithEmbeddingType sets the type of the embedding column, created with
+// VectorstoreTableOptions.EmbeddingType. Bit embeddings must be searched with
+// the Hamming or Jaccard distance.
+func WithEmbeddingType(embeddingType alloydbutil.EmbeddingType) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.embeddingType = embeddingType
+	}
+}
+
+// WithAdditionalEmbeddingColumn stores the embedding of the metadataKey
+// metadata value of every added document in column, created with
+// VectorstoreTableOptions.AdditionalEmbeddingColumns. The column is left NULL
+// for documents without a string value. Search it with
+// WithSearchEmbeddingColumn.
+func WithAdditionalEmbeddingColumn(column, metadataKey string) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.additionalEmbeddings = append(v.additionalEmbeddings, additionalEmbedding{column: column, metadataKey: metadataKey})
+	}
+}
+
+// WithExpiresAtColumn sets the column, created with
+// VectorstoreTableOptions.ExpiresAtColumn, holding the time each document
+// expires at. Expired documents are excluded from searches, and deleted by
+// PurgeExpired. Documents only expire when added with a TTL, set with
+// WithDefaultTTL or WithTTL.
+func WithExpiresAtColumn(expiresAtColumn string) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.expiresAtColumn = expiresAtColumn
+	}
+}
+
+// WithDefaultTTL sets the time to live of the documents added without
+// WithTTL. By default they don't expire.
+func WithDefaultTTL(ttl time.Duration) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.defaultTTL = ttl
+	}
+}
+
+// WithColdStorage enables ArchiveDocuments, which moves the content of
+// documents to storage, keeping its key in archiveKeyColumn, created with
+// VectorstoreTableOptions.ArchiveKeyColumn. The content of the archived
+// documents returned by searches is read back from storage.
+func WithColdStorage(storage ColdStorage, archiveKeyColumn string) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.coldStorage = storage
+		v.archiveKeyColumn = archiveKeyColumn
+	}
+}
+
+// WithPreSQLHook adds a hook run at the start of the transaction of every
+// operation, e.g. to SET LOCAL app.current_tenant for row level security or
+// to raise work_mem for the searches. Hooks run in the order they are added.
+func WithPreSQLHook(hook SQLHook) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.preSQLHooks = append(v.preSQLHooks, hook)
+	}
+}
+
+// WithPostSQLHook adds a hook run at the end of the transaction of every
+// operation, before it is committed. Hooks run in the order they are added.
+func WithPostSQLHook(hook SQLHook) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.postSQLHooks = append(v.postSQLHooks, hook)
+	}
+}
+
+// WithReadOnly puts the VectorStore in read-only mode, e.g. to serve from a
+// replica or to guarantee that a job can't mutate production tables. Its
+// mutating operations return an alloydbutil.ReadOnlyError, and the searches
+// run in read-only transactions. It can't be combined with WithAuditTable,
+// as the audit log is written by the searches.
+func WithReadOnly() VectorStoreOption {
+	return func(v *VectorStore) {
+		v.readOnly = true
+	}
+}
+
+// WithImageURIMetadataKey indexes image documents, whose metadataKey
+// metadata value is the URI of an image, such as a gs:// Cloud Storage URI.
+// The image is embedded instead of the content of the document, which is
+// still stored, e.g. as a caption. The embedder must implement
+// embeddings.ImageURIEmbedder and embed texts in the same embedding space as
+// images, like the Vertex AI multimodal embedder, so that images are
+// searched by text queries.
+func WithImageURIMetadataKey(metadataKey string) VectorStoreOption {
+	return func(v *VectorStore) {
+		v.imageURIMetadataKey = metadataKey
+	}
+}
+
 // applyAlloyDBVectorStoreOptions applies the given VectorStore options to the
 // VectorStore with an alloydb Engine.
 func applyAlloyDBVectorStoreOptions(engine alloydbutil.PostgresEngine,
@@ -84,16 +222,6 @@ func applyAlloyDBVectorStoreOptions(engine alloydbutil.PostgresEngine,
 	tableName string,
 	opts ...VectorStoreOption,
 ) (VectorStore, error) {
-	// Check for required values.
-	if engine.Pool == nil {
-		return VectorStore{}, errors.New("missing vector store engine")
-	}
-	if embedder == nil {
-		return VectorStore{}, errors.New("missing vector store embeder")
-	}
-	if tableName == "" {
-		return VectorStore{}, errors.New("missing vector store table name")
-	}
 	defaultDistanceStrategy := CosineDistance{}
 
 	vs := &VectorStore{
@@ -108,14 +236,154 @@ func applyAlloyDBVectorStoreOptions(engine alloydbutil.PostgresEngine,
 		k:                  defaultK,
 		distanceStrategy:   defaultDistanceStrategy,
 		metadataColumns:    []string{},
+		idGenerator:        alloydbutil.UUIDv4{},
+		embeddingType:      alloydbutil.EmbeddingTypeVector,
 	}
 	for _, opt := range opts {
 		opt(vs)
 	}
+	if err := vs.validate(); err != nil {
+		return VectorStore{}, fmt.Errorf("invalid vector store configuration:\n%w", err)
+	}
 
 	return *vs, nil
 }
 
+// validate returns all the configuration problems of the VectorStore.
+func (vs *VectorStore) validate() error {
+	var problems []error
+	if vs.engine.Pool == nil {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "engine",
+			Problem: "missing vector store engine",
+			Hint:    "create one with alloydbutil.NewPostgresEngine",
+		})
+	}
+	if vs.embedder == nil {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "embedder",
+			Problem: "missing vector store embedder",
+			Hint:    "create one with embeddings.NewEmbedder",
+		})
+	}
+	if vs.tableName == "" {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "tableName",
+			Problem: "missing vector store table name",
+			Hint:    "use the table created with InitVectorstoreTable",
+		})
+	}
+	for _, column := range []struct{ option, name string }{
+		{"WithSchemaName", vs.schemaName},
+		{"WithIDColumn", vs.idColumn},
+		{"WithContentColumn", vs.contentColumn},
+		{"WithEmbeddingColumn", vs.embeddingColumn},
+	} {
+		if column.name == "" {
+			problems = append(problems, &alloydbutil.ConfigError{
+				Field:   column.option,
+				Problem: "empty name",
+				Hint:    "omit the option to use the default name",
+			})
+		}
+	}
+	if vs.k <= 0 {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "WithK",
+			Problem: fmt.Sprintf("invalid number of results %d", vs.k),
+			Hint:    "use a positive number of documents to return",
+		})
+	}
+	if vs.distanceStrategy == nil {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "WithDistanceStrategy",
+			Problem: "missing distance strategy",
+			Hint:    "use CosineDistance{}, Euclidean{} or InnerProduct{}",
+		})
+	}
+	if vs.idGenerator == nil {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "WithIDGenerator",
+			Problem: "missing ID generator",
+			Hint:    "omit the option to generate UUIDv4 IDs",
+		})
+	}
+	if !vs.embeddingType.Valid() {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "WithEmbeddingType",
+			Problem: fmt.Sprintf("unsupported embedding type %q", vs.embeddingType),
+			Hint:    "use the type the table was created with",
+		})
+	}
+	if vs.distanceStrategy != nil && vs.embeddingType.Valid() {
+		_, bitDistance := vs.distanceStrategy.(Hamming)
+		if _, ok := vs.distanceStrategy.(Jaccard); ok {
+			bitDistance = true
+		}
+		if bitDistance != (vs.embeddingType == alloydbutil.EmbeddingTypeBit) {
+			problems = append(problems, &alloydbutil.ConfigError{
+				Field:   "WithDistanceStrategy",
+				Problem: fmt.Sprintf("%s is not supported by %s embeddings", vs.distanceStrategy, vs.embeddingType),
+				Hint:    "search bit embeddings with Hamming{} or Jaccard{}, and other embeddings with CosineDistance{}, Euclidean{} or InnerProduct{}",
+			})
+		}
+	}
+	for _, additional := range vs.additionalEmbeddings {
+		if additional.column == "" || additional.column == vs.embeddingColumn || additional.metadataKey == "" {
+			problems = append(problems, &alloydbutil.ConfigError{
+				Field:   "WithAdditionalEmbeddingColumn",
+				Problem: fmt.Sprintf("invalid additional embedding column %q for metadata key %q", additional.column, additional.metadataKey),
+				Hint:    "name a column other than the main embedding column and the metadata key it embeds",
+			})
+		}
+	}
+	if (vs.coldStorage == nil) != (vs.archiveKeyColumn == "") {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "WithColdStorage",
+			Problem: "cold storage set without an archive key column, or the reverse",
+			Hint:    "set both the storage and the column created with VectorstoreTableOptions.ArchiveKeyColumn",
+		})
+	}
+	if vs.defaultTTL < 0 {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "WithDefaultTTL",
+			Problem: "negative time to live",
+			Hint:    "use a positive TTL, or 0 for documents that don't expire",
+		})
+	}
+	if vs.defaultTTL > 0 && vs.expiresAtColumn == "" {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "WithDefaultTTL",
+			Problem: "time to live set without an expiry column",
+			Hint:    "set the column created with VectorstoreTableOptions.ExpiresAtColumn with WithExpiresAtColumn",
+		})
+	}
+	if vs.readOnly && vs.auditTable != "" {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "WithReadOnly",
+			Problem: "read-only mode with the audit log, which the searches write",
+			Hint:    "omit WithAuditTable in read-only mode",
+		})
+	}
+	if vs.imageURIMetadataKey != "" && vs.embedder != nil {
+		if _, ok := vs.embedder.(embeddings.ImageURIEmbedder); !ok {
+			problems = append(problems, &alloydbutil.ConfigError{
+				Field:   "WithImageURIMetadataKey",
+				Problem: fmt.Sprintf("embedder %T can't embed images", vs.embedder),
+				Hint:    "use a multimodal embedder implementing embeddings.ImageURIEmbedder",
+			})
+		}
+	}
+	if vs.embeddingTimeout < 0 || vs.writeTimeout < 0 {
+		problems = append(problems, &alloydbutil.ConfigError{
+			Field:   "WithEmbeddingTimeout/WithWriteTimeout",
+			Problem: "negative timeout",
+			Hint:    "use a positive timeout, or 0 for none",
+		})
+	}
+	return errors.Join(problems...)
+}
+
 func applyOpts(options ...vectorstores.Option) vectorstores.Options {
 	opts := vectorstores.Options{}
 	for _, opt := range options {
@@ -123,3 +391,155 @@ func applyOpts(options ...vectorstores.Option) vectorstores.Options {
 	}
 	return opts
 }
+
+// searchOptionsKey is the vectorstores.Options.Extra key holding the AlloyDB
+// specific searchOptions.
+type searchOptionsKey struct{}
+
+// searchOptions holds the AlloyDB specific options of a single search.
+type searchOptions struct {
+	// sessionParameters are applied with SET LOCAL inside the search
+	// transaction.
+	sessionParameters map[string]string
+	// auditSessionID and auditUserID identify who a search was performed
+	// for in the audit log.
+	auditSessionID string
+	auditUserID    string
+	// embeddingColumn is the additional embedding column searched instead
+	// of the main one.
+	embeddingColumn string
+	// ttl is the time to live of the documents added by AddDocuments.
+	ttl time.Duration
+	// returnEmbeddings and returnDistance add the embedding and distance of
+	// the returned documents to their metadata.
+	returnEmbeddings bool
+	returnDistance   bool
+	// offset is the number of nearest documents skipped.
+	offset int
+	// after is the position of the end of the previous page of a search
+	// continued with a page token.
+	after *pageCursor
+}
+
+// rankOffset returns the number of documents ranked before the first
+// result of the search, skipped by WithOffset or returned by the previous
+// pages.
+func (so searchOptions) rankOffset() int {
+	if so.after != nil {
+		return so.after.Rank
+	}
+	return so.offset
+}
+
+// withSearchOptions returns a vectorstores.Option that modifies the AlloyDB
+// specific search options.
+func withSearchOptions(fn func(*searchOptions)) vectorstores.Option {
+	return func(o *vectorstores.Options) {
+		if o.Extra == nil {
+			o.Extra = map[any]any{}
+		}
+		so, ok := o.Extra[searchOptionsKey{}].(*searchOptions)
+		if !ok {
+			so = &searchOptions{}
+			o.Extra[searchOptionsKey{}] = so
+		}
+		fn(so)
+	}
+}
+
+// getSearchOptions returns the AlloyDB specific search options set in opts.
+func getSearchOptions(opts vectorstores.Options) searchOptions {
+	if so, ok := opts.Extra[searchOptionsKey{}].(*searchOptions); ok {
+		return *so
+	}
+	return searchOptions{}
+}
+
+// withSessionParameter sets a session parameter for the duration of the
+// search transaction.
+func withSessionParameter(name, value string) vectorstores.Option {
+	return withSearchOptions(func(so *searchOptions) {
+		if so.sessionParameters == nil {
+			so.sessionParameters = map[string]string{}
+		}
+		so.sessionParameters[name] = value
+	})
+}
+
+// WithEFSearch sets hnsw.ef_search for a single search. Higher values
+// improve recall of HNSW indexes at the cost of latency.
+func WithEFSearch(efSearch int) vectorstores.Option {
+	return withSessionParameter("hnsw.ef_search", strconv.Itoa(efSearch))
+}
+
+// WithIVFProbes sets the number of lists probed by ivf indexes, created with
+// IVFOptions, for a single search.
+func WithIVFProbes(probes int) vectorstores.Option {
+	return withSessionParameter("ivf.probes", strconv.Itoa(probes))
+}
+
+// WithIVFFlatProbes sets the number of lists probed by ivfflat indexes,
+// created with IVFFlatOptions, for a single search.
+func WithIVFFlatProbes(probes int) vectorstores.Option {
+	return withSessionParameter("ivfflat.probes", strconv.Itoa(probes))
+}
+
+// WithScaNNLeavesToSearch sets the number of leaves searched by ScaNN
+// indexes for a single search.
+func WithScaNNLeavesToSearch(leaves int) vectorstores.Option {
+	return withSessionParameter("scann.num_leaves_to_search", strconv.Itoa(leaves))
+}
+
+// WithAuditPrincipal records sessionID and userID as the recipients of the
+// documents returned by a search when the audit log is enabled.
+func WithAuditPrincipal(sessionID, userID string) vectorstores.Option {
+	return withSearchOptions(func(so *searchOptions) {
+		so.auditSessionID = sessionID
+		so.auditUserID = userID
+	})
+}
+
+// WithSearchEmbeddingColumn searches the given additional embedding column,
+// set with WithAdditionalEmbeddingColumn, instead of the main one. Documents
+// without an embedding in the column are not returned.
+func WithSearchEmbeddingColumn(column string) vectorstores.Option {
+	return withSearchOptions(func(so *searchOptions) {
+		so.embeddingColumn = column
+	})
+}
+
+// WithTTL sets the time to live of the documents added by an AddDocuments
+// call, overriding the one set with WithDefaultTTL. The vector store must
+// have an expiry column, set with WithExpiresAtColumn.
+func WithTTL(ttl time.Duration) vectorstores.Option {
+	return withSearchOptions(func(so *searchOptions) {
+		so.ttl = ttl
+	})
+}
+
+// WithReturnEmbeddings sets the searched embedding of every returned document
+// in its metadata, under EmbeddingMetadataKey, e.g. to rerank the documents or
+// debug the recall of an index.
+func WithReturnEmbeddings() vectorstores.Option {
+	return withSearchOptions(func(so *searchOptions) {
+		so.returnEmbeddings = true
+	})
+}
+
+// WithReturnDistance sets the distance between the query and every returned
+// document in its metadata, under DistanceMetadataKey, so it is kept when the
+// document score is replaced, e.g. by a reranker.
+func WithReturnDistance() vectorstores.Option {
+	return withSearchOptions(func(so *searchOptions) {
+		so.returnDistance = true
+	})
+}
+
+// WithOffset skips the offset nearest documents, to page through the
+// neighbors of a query. The skipped documents are still read by the
+// database, so deep pages are better read with SimilaritySearchPage.
+func WithOffset(offset int) vectorstores.Option {
+	return withSearchOptions(func(so *searchOptions) {
+		so.offset = offset
+	})
+}
@@ -0,0 +1,732 @@
+package alloydb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/util/alloydbutil"
+
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+func TestSearchSessionParameters(t *testing.T) {
+	t.Parallel()
+	opts := applyOpts(
+		vectorstores.WithScoreThreshold(0.5),
+		WithEFSearch(100),
+		WithIVFProbes(10),
+		WithIVFFlatProbes(20),
+		WithScaNNLeavesToSearch(5),
+	)
+	got := getSearchOptions(opts).sessionParameters
+	want := map[string]string{
+		"hnsw.ef_search":             "100",
+		"ivf.probes":                 "10",
+		"ivfflat.probes":             "20",
+		"scann.num_leaves_to_search": "5",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if opts.ScoreThreshold != 0.5 {
+		t.Errorf("expected generic options to be preserved, got %v", opts.ScoreThreshold)
+	}
+}
+
+func TestSearchOptionsDefault(t *testing.T) {
+	t.Parallel()
+	if so := getSearchOptions(applyOpts()); so.sessionParameters != nil {
+		t.Errorf("expected no session parameters, got %v", so.sessionParameters)
+	}
+}
+
+func TestAuditPrincipal(t *testing.T) {
+	t.Parallel()
+	so := getSearchOptions(applyOpts(WithAuditPrincipal("session", "user"), WithEFSearch(40)))
+	if so.auditSessionID != "session" || so.auditUserID != "user" {
+		t.Errorf("unexpected audit principal %q/%q", so.auditSessionID, so.auditUserID)
+	}
+	if so.sessionParameters["hnsw.ef_search"] != "40" {
+		t.Errorf("expected search options to be merged, got %v", so.sessionParameters)
+	}
+}
+
+// blockingEmbedder blocks until its context is done.
+type blockingEmbedder struct{}
+
+func (blockingEmbedder) EmbedDocuments(ctx context.Context, _ []string) ([][]float32, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingEmbedder) EmbedQuery(ctx context.Context, _ string) ([]float32, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestAddDocumentsEmbeddingTimeout(t *testing.T) {
+	t.Parallel()
+	vs := VectorStore{embedder: blockingEmbedder{}}
+	WithEmbeddingTimeout(10 * time.Millisecond)(&vs)
+
+	_, err := vs.AddDocuments(context.Background(), []schema.Document{{PageContent: "doc"}})
+	if !errors.Is(err, ErrEmbeddingTimeout) {
+		t.Errorf("expected embedding timeout, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+// failingEmbedder fails to embed with err.
+type failingEmbedder struct{ err error }
+
+func (e failingEmbedder) EmbedDocuments(context.Context, []string) ([][]float32, error) {
+	return nil, e.err
+}
+
+func (e failingEmbedder) EmbedQuery(context.Context, string) ([]float32, error) {
+	return nil, e.err
+}
+
+func TestSimilaritySearchStreamError(t *testing.T) {
+	t.Parallel()
+	errEmbed := errors.New("embedding failed")
+	vs := VectorStore{embedder: failingEmbedder{err: errEmbed}, k: defaultK}
+
+	var results []SearchResult
+	for result := range vs.SimilaritySearchStream(context.Background(), "query", 1000) {
+		results = append(results, result)
+	}
+	if len(results) != 1 || !errors.Is(results[0].Err, errEmbed) {
+		t.Fatalf("expected a single error result, got %+v", results)
+	}
+}
+
+func TestSimilaritySearchStreamCanceled(t *testing.T) {
+	t.Parallel()
+	vs := VectorStore{embedder: blockingEmbedder{}, k: defaultK}
+	ctx, cancel := context.WithCancel(context.Background())
+	results := vs.SimilaritySearchStream(ctx, "query", 1000)
+	cancel()
+	// The stream is closed once the canceled search returns.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to be closed")
+	case _, ok := <-results:
+		for ok {
+			_, ok = <-results
+		}
+	}
+}
+
+func TestStaleEmbeddingsNotTracked(t *testing.T) {
+	t.Parallel()
+	vs := VectorStore{}
+	if _, err := vs.FindStaleEmbeddings(context.Background()); !errors.Is(err, ErrEmbeddingHashNotTracked) {
+		t.Errorf("expected ErrEmbeddingHashNotTracked, got %v", err)
+	}
+	if _, err := vs.RefreshStaleEmbeddings(context.Background(), 0); !errors.Is(err, ErrEmbeddingHashNotTracked) {
+		t.Errorf("expected ErrEmbeddingHashNotTracked, got %v", err)
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	t.Parallel()
+	// SELECT encode(sha256(convert_to('Tokyo', 'UTF8')), 'hex');
+	want := "ec2d191680171ca9603105aa2be38a46228a8e1c75e3ef0383c7ebbd1287110a"
+	if got := contentHash("Tokyo"); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	vs := VectorStore{contentColumn: "content", embeddingHashColumn: "content_hash"}
+	if got := vs.staleCondition(); got != `"content_hash" IS DISTINCT FROM encode(sha256(convert_to("content", 'UTF8')), 'hex')` {
+		t.Errorf("unexpected stale condition %q", got)
+	}
+}
+
+func TestFormatEmbedding(t *testing.T) {
+	t.Parallel()
+	embedding := []float32{0.5, 0, -1.25, 0}
+	for embeddingType, want := range map[alloydbutil.EmbeddingType]string{
+		alloydbutil.EmbeddingTypeBit:       "1000",
+		alloydbutil.EmbeddingTypeSparseVec: "{1:0.5,3:-1.25}/4",
+	} {
+		if got := formatEmbedding(embeddingType, embedding); got != want {
+			t.Errorf("formatEmbedding(%s) = %q, want %q", embeddingType, got, want)
+		}
+	}
+	for embeddingType, want := range map[alloydbutil.EmbeddingType]string{
+		alloydbutil.EmbeddingTypeVector:    "vector_cosine_ops",
+		alloydbutil.EmbeddingTypeHalfVec:   "halfvec_cosine_ops",
+		alloydbutil.EmbeddingTypeSparseVec: "sparsevec_cosine_ops",
+	} {
+		if got := operatorClass(embeddingType, CosineDistance{}); got != want {
+			t.Errorf("operatorClass(%s) = %q, want %q", embeddingType, got, want)
+		}
+	}
+	if got := operatorClass(alloydbutil.EmbeddingTypeBit, Hamming{}); got != "bit_hamming_ops" {
+		t.Errorf("unexpected bit operator class %q", got)
+	}
+}
+
+// lengthEmbedder embeds texts as their length.
+type lengthEmbedder struct{}
+
+func (e lengthEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i], _ = e.EmbedQuery(ctx, text)
+	}
+	return embeddings, nil
+}
+
+func (lengthEmbedder) EmbedQuery(_ context.Context, text string) ([]float32, error) {
+	return []float32{float32(len(text))}, nil
+}
+
+func TestAdditionalEmbeddingColumns(t *testing.T) {
+	t.Parallel()
+	vs := VectorStore{
+		embedder:         lengthEmbedder{},
+		embeddingColumn:  "embedding",
+		embeddingType:    alloydbutil.EmbeddingTypeVector,
+		distanceStrategy: CosineDistance{},
+		schemaName:       "public",
+		tableName:        "items",
+	}
+	WithAdditionalEmbeddingColumn("title_embedding", "title")(&vs)
+
+	values, err := vs.embedAdditionalColumns(context.Background(), []schema.Document{
+		{PageContent: "a", Metadata: map[string]any{"title": "abc"}},
+		{PageContent: "b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 || values[0][0] == nil || values[0][1] != nil {
+		t.Errorf("expected an embedding for the titled document only, got %v", values)
+	}
+
+	stmt, _, err := vs.searchQuery(context.Background(), "query", 4, applyOpts(WithSearchEmbeddingColumn("title_embedding")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stmt, `WHERE "title_embedding" IS NOT NULL ORDER BY "title_embedding" <=>`) {
+		t.Errorf("expected the search to target title_embedding, got %s", stmt)
+	}
+
+	_, _, err = vs.searchQuery(context.Background(), "query", 4, applyOpts(WithSearchEmbeddingColumn("body_embedding")))
+	if err == nil || !strings.Contains(err.Error(), `unknown embedding column "body_embedding"`) {
+		t.Errorf("expected an unknown column error, got %v", err)
+	}
+}
+
+func TestCreateIndexStatement(t *testing.T) {
+	t.Parallel()
+	vs := VectorStore{
+		schemaName:      "public",
+		tableName:       "items",
+		embeddingColumn: "embedding",
+		embeddingType:   alloydbutil.EmbeddingTypeHalfVec,
+	}
+	index := vs.NewBaseIndex("", "hnsw", Euclidean{}, nil, HNSWOptions{M: 16, EfConstruction: 64})
+
+	stmt := vs.createIndexStatement(index.OnColumn("title_embedding"), "", true)
+	want := `CREATE INDEX CONCURRENTLY "itemstitle_embeddinglangchainvectorindex" ON "public"."items" ` +
+		`USING hnsw ("title_embedding" halfvec_l2_ops) WITH (m = 16, ef_construction = 64) `
+	if stmt != want {
+		t.Errorf("expected %q, got %q", want, stmt)
+	}
+}
+
+func TestIndexBuildProgressFraction(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		progress IndexBuildProgress
+		want     float64
+	}{
+		{IndexBuildProgress{BlocksTotal: 200, BlocksDone: 50, TuplesTotal: 10, TuplesDone: 10}, 0.25},
+		{IndexBuildProgress{TuplesTotal: 10, TuplesDone: 5}, 0.5},
+		{IndexBuildProgress{Phase: "initializing"}, 0},
+	} {
+		if got := tt.progress.Fraction(); got != tt.want {
+			t.Errorf("%+v: expected %v, got %v", tt.progress, tt.want, got)
+		}
+	}
+}
+
+func TestNewVectorStoreValidation(t *testing.T) {
+	t.Parallel()
+	_, err := NewVectorStore(alloydbutil.PostgresEngine{}, nil, "", WithK(0), WithIDColumn(""),
+		WithEmbeddingType(alloydbutil.EmbeddingTypeBit), WithIDGenerator(nil))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		"missing vector store engine",
+		"missing vector store embedder",
+		"missing vector store table name",
+		"WithIDColumn: empty name",
+		"WithK: invalid number of results 0",
+		"WithIDGenerator: missing ID generator",
+		"cosineDistance is not supported by bit embeddings",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %q", want, err)
+		}
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	t.Parallel()
+	vs := VectorStore{
+		embedder:         lengthEmbedder{},
+		embeddingColumn:  "embedding",
+		embeddingType:    alloydbutil.EmbeddingTypeVector,
+		distanceStrategy: CosineDistance{},
+		schemaName:       "public",
+		tableName:        "items",
+	}
+	if _, err := vs.AddDocuments(context.Background(), []schema.Document{{PageContent: "a"}}, WithTTL(time.Hour)); !errors.Is(err, ErrExpiryNotTracked) {
+		t.Errorf("expected ErrExpiryNotTracked, got %v", err)
+	}
+	if _, err := vs.PurgeExpired(context.Background()); !errors.Is(err, ErrExpiryNotTracked) {
+		t.Errorf("expected ErrExpiryNotTracked, got %v", err)
+	}
+
+	WithExpiresAtColumn("expires_at")(&vs)
+	WithDefaultTTL(time.Hour)(&vs)
+	expiresAt, err := vs.expiresAt(getSearchOptions(applyOpts(WithTTL(time.Minute))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if until := time.Until(expiresAt); until <= 0 || until > time.Minute {
+		t.Errorf("expected the TTL of the call to override the default one, got %s", until)
+	}
+	expiresAt, err = vs.expiresAt(searchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if until := time.Until(expiresAt); until <= time.Minute || until > time.Hour {
+		t.Errorf("expected the default TTL, got %s", until)
+	}
+
+	stmt, _, err := vs.searchQuery(context.Background(), "query", 4, applyOpts(vectorstores.WithFilters("area > 1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stmt, `WHERE ("expires_at" IS NULL OR "expires_at" > NOW()) AND (area > 1) ORDER BY`) {
+		t.Errorf("expected the search to exclude expired documents, got %s", stmt)
+	}
+}
+
+func TestReturnEmbeddingsAndDistance(t *testing.T) {
+	t.Parallel()
+	vs := VectorStore{
+		embedder:         lengthEmbedder{},
+		embeddingColumn:  "embedding",
+		embeddingType:    alloydbutil.EmbeddingTypeBit,
+		distanceStrategy: Hamming{},
+		schemaName:       "public",
+		tableName:        "items",
+	}
+	opts := applyOpts(WithReturnEmbeddings(), WithReturnDistance())
+	stmt, _, err := vs.searchQuery(context.Background(), "query", 4, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stmt, `AS distance, string_to_array("embedding"::text, NULL)::real[] FROM`) {
+		t.Errorf("expected the search to select the embedding, got %s", stmt)
+	}
+
+	doc, err := searchDocumentToDocument(SearchDocument{
+		LangchainMetadata: `{"area": 1}`,
+		Distance:          0.5,
+		Embedding:         []float32{1, 0, 1},
+	}, getSearchOptions(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"area": float64(1), EmbeddingMetadataKey: []float32{1, 0, 1}, DistanceMetadataKey: float32(0.5)}
+	if !reflect.DeepEqual(doc.Metadata, want) {
+		t.Errorf("expected metadata %v, got %v", want, doc.Metadata)
+	}
+}
+
+// mapStorage is a ColdStorage keeping the content in memory.
+type mapStorage map[string]string
+
+func (s mapStorage) Put(_ context.Context, key, content string) error {
+	s[key] = content
+	return nil
+}
+
+func (s mapStorage) Get(_ context.Context, key string) (string, error) {
+	content, ok := s[key]
+	if !ok {
+		return "", errors.New("object not found")
+	}
+	return content, nil
+}
+
+func TestColdStorage(t *testing.T) {
+	t.Parallel()
+	if _, err := (&VectorStore{}).ArchiveDocuments(context.Background(), []string{"a"}); !errors.Is(err, ErrColdStorageNotSet) {
+		t.Errorf("expected ErrColdStorageNotSet, got %v", err)
+	}
+
+	storage := mapStorage{"public/items/a": "archived content"}
+	vs := VectorStore{
+		embedder:            lengthEmbedder{},
+		contentColumn:       "content",
+		embeddingColumn:     "embedding",
+		embeddingHashColumn: "content_hash",
+		embeddingType:       alloydbutil.EmbeddingTypeVector,
+		distanceStrategy:    CosineDistance{},
+		schemaName:          "public",
+		tableName:           "items",
+	}
+	WithColdStorage(storage, "archive_key")(&vs)
+	if got := vs.archiveKey("a"); got != "public/items/a" {
+		t.Errorf("unexpected archive key %q", got)
+	}
+	if got := vs.staleCondition(); !strings.HasSuffix(got, ` AND "archive_key" IS NULL`) {
+		t.Errorf("expected archived documents not to be stale, got %q", got)
+	}
+	stmt, _, err := vs.searchQuery(context.Background(), "query", 4, vectorstores.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stmt, `AS distance, COALESCE("archive_key", '') FROM`) {
+		t.Errorf("expected the search to select the archive key, got %s", stmt)
+	}
+
+	results := []SearchDocument{
+		{ID: "a", ArchiveKey: "public/items/a"},
+		{ID: "b", Content: "hot content"},
+	}
+	if err := vs.rehydrate(context.Background(), results); err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Content != "archived content" || results[1].Content != "hot content" {
+		t.Errorf("unexpected rehydrated content %q and %q", results[0].Content, results[1].Content)
+	}
+	err = vs.rehydrate(context.Background(), []SearchDocument{{ID: "c", ArchiveKey: "public/items/c"}})
+	if err == nil || !strings.Contains(err.Error(), "failed to read archived document c") {
+		t.Errorf("expected a rehydration error, got %v", err)
+	}
+}
+
+func TestSimilaritySearchPagination(t *testing.T) {
+	t.Parallel()
+	vs := VectorStore{
+		embedder:         lengthEmbedder{},
+		idColumn:         "langchain_id",
+		embeddingColumn:  "embedding",
+		embeddingType:    alloydbutil.EmbeddingTypeVector,
+		distanceStrategy: InnerProduct{},
+		schemaName:       "public",
+		tableName:        "items",
+	}
+
+	stmt, _, err := vs.searchQuery(context.Background(), "query", 4, applyOpts(WithOffset(8)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stmt, "LIMIT $2::int OFFSET 8;") {
+		t.Errorf("expected an offset, got %s", stmt)
+	}
+
+	first := vs.nextPageCursor([]SearchDocument{{ID: "a", Distance: 0.5}}, searchOptions{offset: 8})
+	if first.Rank != 9 {
+		t.Errorf("expected the first page to be ranked after the offset, got %+v", first)
+	}
+
+	previous := searchOptions{after: &pageCursor{Key: -0.5, IDs: []string{"a"}, Rank: 9}}
+	if previous.rankOffset() != 9 {
+		t.Errorf("expected the next page to be ranked after the previous one, got %d", previous.rankOffset())
+	}
+	cursor := vs.nextPageCursor([]SearchDocument{
+		{ID: "b", Distance: 0.5},
+		{ID: "c", Distance: 0.5},
+	}, previous)
+	if cursor.Key != -0.5 || !reflect.DeepEqual(cursor.IDs, []string{"a", "b", "c"}) || cursor.Rank != 11 {
+		t.Errorf("expected the documents tied with the previous page to be skipped, got %+v", cursor)
+	}
+	cursor = vs.nextPageCursor([]SearchDocument{
+		{ID: "d", Distance: 0.5},
+		{ID: "e", Distance: 0.25},
+	}, previous)
+	if cursor.Key != -0.25 || !reflect.DeepEqual(cursor.IDs, []string{"e"}) {
+		t.Errorf("unexpected cursor %+v", cursor)
+	}
+
+	token, err := encodePageToken(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodePageToken(token)
+	if err != nil || !reflect.DeepEqual(decoded, cursor) {
+		t.Errorf("expected the token to decode to %+v, got %+v, %v", cursor, decoded, err)
+	}
+	if _, err := decodePageToken("not a token"); !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("expected ErrInvalidPageToken, got %v", err)
+	}
+
+	stmt, args, err := vs.searchQuery(context.Background(), "query", 4, applyOpts(withSearchOptions(func(so *searchOptions) {
+		so.after = &cursor
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `WHERE ("embedding" <#> $1::vector)::real >= $3::real AND NOT (("embedding" <#> $1::vector)::real = $3::real AND "langchain_id"::text = ANY($4)) ORDER BY`
+	if !strings.Contains(stmt, want) || len(args) != 4 {
+		t.Errorf("expected the search to continue after the cursor, got %s with %d arguments", stmt, len(args))
+	}
+}
+
+func TestSimilaritySearchFilter(t *testing.T) {
+	t.Parallel()
+	vs := VectorStore{
+		embedder:         lengthEmbedder{},
+		idColumn:         "langchain_id",
+		embeddingColumn:  "embedding",
+		embeddingType:    alloydbutil.EmbeddingTypeVector,
+		distanceStrategy: CosineDistance{},
+		schemaName:       "public",
+		tableName:        "items",
+	}
+	filter := vectorstores.And(vectorstores.Eq("kind", "cat"), vectorstores.Gte("legs", 2))
+	if _, _, err := vs.searchQuery(context.Background(), "query", 4, applyOpts(vectorstores.WithFilters(filter))); !errors.Is(err, ErrInvalidFilters) {
+		t.Errorf("expected ErrInvalidFilters without a metadata json column, got %v", err)
+	}
+
+	vs.metadataJSONColumn = "langchain_metadata"
+	stmt, args, err := vs.searchQuery(context.Background(), "query", 4, applyOpts(vectorstores.WithFilters(filter)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `WHERE (COALESCE(("langchain_metadata"::jsonb -> $3::text) = $4::jsonb, false) AND ` +
+		`jsonb_path_exists("langchain_metadata"::jsonb, $5::jsonpath, $6::jsonb, true)) ORDER BY`
+	if !strings.Contains(stmt, want) {
+		t.Errorf("expected the filter condition, got %s", stmt)
+	}
+	wantArgs := []any{"kind", `"cat"`, `strict $."legs" ? (@ >= $v)`, `{"v":2}`}
+	if !reflect.DeepEqual(args[2:], wantArgs) {
+		t.Errorf("expected the arguments %v, got %v", wantArgs, args[2:])
+	}
+
+	for _, filters := range []any{map[string]any{"kind": "cat"}, vectorstores.Not(vectorstores.Filter{})} {
+		if _, _, err := vs.searchQuery(context.Background(), "query", 4, applyOpts(vectorstores.WithFilters(filters))); !errors.Is(err, ErrInvalidFilters) {
+			t.Errorf("expected ErrInvalidFilters for %v, got %v", filters, err)
+		}
+	}
+}
+
+func TestSQLHooks(t *testing.T) {
+	t.Parallel()
+	var calls []string
+	hook := func(name string, err error) SQLHook {
+		return func(_ context.Context, _ pgx.Tx, op Operation) error {
+			calls = append(calls, name+":"+string(op))
+			return err
+		}
+	}
+	vs := &VectorStore{}
+	WithPreSQLHook(hook("pre1", nil))(vs)
+	WithPreSQLHook(hook("pre2", nil))(vs)
+	WithPostSQLHook(hook("post", nil))(vs)
+
+	err := vs.withSQLHooks(context.Background(), nil, OperationSearch, func() error {
+		calls = append(calls, "run")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"pre1:search", "pre2:search", "run", "post:search"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected %v, got %v", want, calls)
+	}
+
+	calls = nil
+	errHook := errors.New("permission denied")
+	WithPreSQLHook(hook("failing", errHook))(vs)
+	err = vs.withSQLHooks(context.Background(), nil, OperationAddDocuments, func() error {
+		calls = append(calls, "run")
+		return nil
+	})
+	if !errors.Is(err, errHook) {
+		t.Errorf("expected the hook error, got %v", err)
+	}
+	want = []string{"pre1:add_documents", "pre2:add_documents", "failing:add_documents"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected the operation to be aborted, got %v", calls)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+	vs := VectorStore{embedder: failingEmbedder{err: errors.New("unexpected embedding")}}
+	WithReadOnly()(&vs)
+
+	_, err := vs.AddDocuments(context.Background(), []schema.Document{{PageContent: "doc"}})
+	if !errors.Is(err, alloydbutil.ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	var readOnlyErr *alloydbutil.ReadOnlyError
+	if !errors.As(err, &readOnlyErr) || readOnlyErr.Operation != "add documents" {
+		t.Errorf("expected a ReadOnlyError for add documents, got %v", err)
+	}
+	if _, err := vs.PurgeExpired(context.Background()); !errors.Is(err, alloydbutil.ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if _, err := vs.DeleteDocuments(context.Background(), []string{"id"}); !errors.Is(err, alloydbutil.ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if err := vs.DropVectorIndex(context.Background(), "index", true); !errors.Is(err, alloydbutil.ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+
+	vs.auditTable = "audit"
+	if err := vs.validate(); err == nil || !strings.Contains(err.Error(), "WithReadOnly") {
+		t.Errorf("expected read-only mode with the audit log to be rejected, got %v", err)
+	}
+}
+
+// imageEmbedder embeds texts as their length, and image URIs as their length
+// plus 100.
+type imageEmbedder struct{ lengthEmbedder }
+
+func (imageEmbedder) EmbedImageURIs(_ context.Context, uris []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(uris))
+	for i, uri := range uris {
+		embeddings[i] = []float32{float32(100 + len(uri))}
+	}
+	return embeddings, nil
+}
+
+func TestImageDocuments(t *testing.T) {
+	t.Parallel()
+	vs := VectorStore{embedder: imageEmbedder{}}
+	WithImageURIMetadataKey("image_uri")(&vs)
+
+	docs := []schema.Document{
+		{PageContent: "text"},
+		{PageContent: "a cat", Metadata: map[string]any{"image_uri": "gs://bucket/cat.png"}},
+		{PageContent: "no image", Metadata: map[string]any{"image_uri": ""}},
+	}
+	embeddings, err := vs.embedDocuments(context.Background(), vs.embedder, docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]float32{{4}, {119}, {8}}
+	if !reflect.DeepEqual(embeddings, want) {
+		t.Errorf("expected the image of the image document to be embedded, got %v", embeddings)
+	}
+
+	_, err = vs.embedDocuments(context.Background(), lengthEmbedder{}, docs)
+	if err == nil || !strings.Contains(err.Error(), "can't embed images") {
+		t.Errorf("expected a text embedder to be rejected, got %v", err)
+	}
+	vs.embedder = lengthEmbedder{}
+	if err := vs.validate(); err == nil || !strings.Contains(err.Error(), "WithImageURIMetadataKey") {
+		t.Errorf("expected a text embedder to be rejected, got %v", err)
+	}
+}
+
+// stripIdentifiers removes the quoted identifiers of query, failing on an
+// unterminated one.
+func stripIdentifiers(t *testing.T, query string) string {
+	t.Helper()
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(query); i++ {
+		switch {
+		case query[i] != '"':
+			if !quoted {
+				b.WriteByte(query[i])
+			}
+		case quoted && i+1 < len(query) && query[i+1] == '"':
+			i++
+		default:
+			quoted = !quoted
+		}
+	}
+	if quoted {
+		t.Fatalf("unterminated identifier in %s", query)
+	}
+	return b.String()
+}
+
+func TestDocStoreCompression(t *testing.T) {
+	t.Parallel()
+	compressor, err := alloydbutil.NewZstdCompressor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := DocStore{}
+	WithDocStoreCompression(compressor, 64)(&ds)
+
+	content, compressed, err := ds.compress("hello")
+	if err != nil || content != "hello" || compressed != nil {
+		t.Errorf("expected small documents to be stored uncompressed, got %q, %x, %v", content, compressed, err)
+	}
+
+	large := strings.Repeat("parent document ", 100)
+	content, compressed, err = ds.compress(large)
+	if err != nil || content != "" || len(compressed) == 0 || len(compressed) >= len(large) {
+		t.Fatalf("expected large documents to be stored compressed, got %q, %d bytes, %v", content, len(compressed), err)
+	}
+	if content, err = ds.decompress(compressed); err != nil || content != large {
+		t.Errorf("expected the decompressed document, got %q, %v", content, err)
+	}
+	if _, err := (DocStore{}).decompress(compressed); err == nil {
+		t.Error("expected an error reading a compressed document without compressor")
+	}
+}
+
+func FuzzQueries(f *testing.F) {
+	f.Add("public", "items", "langchain_id", "embedding")
+	f.Add(`pub"lic`, `"; DROP TABLE x; --`, `id" = id; --`, `a"b`)
+	f.Fuzz(func(t *testing.T, schemaName, tableName, idColumn, column string) {
+		if column == "" {
+			t.Skip("the optional columns are only used when set")
+		}
+		queries := func(schemaName, tableName, idColumn, column string) []string {
+			vs := VectorStore{
+				embedder:            lengthEmbedder{},
+				schemaName:          schemaName,
+				tableName:           tableName,
+				idColumn:            idColumn,
+				contentColumn:       column,
+				metadataJSONColumn:  column,
+				embeddingColumn:     column,
+				embeddingHashColumn: column,
+				expiresAtColumn:     column,
+				archiveKeyColumn:    column,
+				embeddingType:       alloydbutil.EmbeddingTypeVector,
+				distanceStrategy:    CosineDistance{},
+			}
+			stmt, _, err := vs.searchQuery(context.Background(), "query", 4, applyOpts(
+				WithReturnEmbeddings(), vectorstores.WithFilters(vectorstores.Eq("kind", "cat"))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			index := vs.NewBaseIndex("", "hnsw", CosineDistance{}, nil, HNSWOptions{M: 16, EfConstruction: 64})
+			return []string{stmt, vs.staleCondition(), vs.createIndexStatement(index, "", false)}
+		}
+		want := queries("s", "t", "i", "c")
+		for i, query := range queries(schemaName, tableName, idColumn, column) {
+			if stripIdentifiers(t, query) != stripIdentifiers(t, want[i]) {
+				t.Errorf("identifiers escaped the statement %s", query)
+			}
+		}
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/tmc/langchaingo/embeddings"
 	"github.com/tmc/langchaingo/llms/ollama"
 	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/textsplitter"
 	"github.com/tmc/langchaingo/util/alloydbutil"
 	"github.com/tmc/langchaingo/vectorstores/alloydb"
 )
@@ -96,13 +97,13 @@ func setVectorStore(t *testing.T) (alloydb.VectorStore, func() error, error) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	vs, err := alloydb.NewVectorStore(ctx, pgEngine, e, table)
+	vs, err := alloydb.NewVectorStore(pgEngine, e, table)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	cleanUpTableFn := func() error {
-		_, err := pgEngine.Pool.Exec(ctx, fmt.Sprintf("DROP TABLE IF EXISTS `%s`", table))
+		_, err := pgEngine.Pool.Exec(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %q", table))
 		return err
 	}
 	return vs, cleanUpTableFn, nil
@@ -110,8 +111,10 @@ func setVectorStore(t *testing.T) (alloydb.VectorStore, func() error, error) {
 
 func TestPingToDB(t *testing.T) {
 	t.Parallel()
-	engine := setEngine(t)
-
+	engine, err := setEngine(t)
+	if err != nil {
+		t.Fatal(err)
+	}
 	defer engine.Close()
 
 	if err := engine.Pool.Ping(context.Background()); err != nil {
@@ -127,7 +130,7 @@ func TestApplyVectorIndexAndDropIndex(t *testing.T) {
 	}
 	ctx := context.Background()
 	idx := vs.NewBaseIndex("testindex", "hnsw", alloydb.CosineDistance{}, []string{}, alloydb.HNSWOptions{})
-	err := vs.ApplyVectorIndex(ctx, idx, "testindex", false, false)
+	err = vs.ApplyVectorIndex(ctx, idx, "testindex", false, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -149,7 +152,7 @@ func TestIsValidIndex(t *testing.T) {
 	}
 	ctx := context.Background()
 	idx := vs.NewBaseIndex("testindex", "hnsw", alloydb.CosineDistance{}, []string{}, alloydb.HNSWOptions{})
-	err := vs.ApplyVectorIndex(ctx, idx, "testindex", false, false)
+	err = vs.ApplyVectorIndex(ctx, idx, "testindex", false, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -176,7 +179,7 @@ func TestAddDocuments(t *testing.T) {
 	}
 	ctx := context.Background()
 
-	_, err := vs.AddDocuments(ctx, []schema.Document{
+	_, err = vs.AddDocuments(ctx, []schema.Document{
 		{
 			PageContent: "Tokyo",
 			Metadata: map[string]any{
@@ -235,3 +238,56 @@ func TestAddDocuments(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestParentDocumentRetriever(t *testing.T) {
+	t.Parallel()
+	vs, cleanUpTableFn, err := setVectorStore(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgEngine, err := setEngine(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	_, _, _, _, _, _, _, table := getEnvVariables(t)
+	docstoreTable := table + "_docstore"
+	if err := pgEngine.InitDocstoreTable(ctx, alloydbutil.DocstoreTableOptions{TableName: docstoreTable}); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := alloydb.NewDocStore(pgEngine, docstoreTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retriever := alloydb.NewParentDocumentRetriever(&vs, ds,
+		textsplitter.NewRecursiveCharacter(textsplitter.WithChunkSize(40), textsplitter.WithChunkOverlap(0)))
+	keys, err := retriever.AddDocuments(ctx, []schema.Document{
+		{PageContent: "Tokyo is the capital of Japan. It has a population of 38 million people."},
+		{PageContent: "Paris is the capital of France. It has a population of 11 million people."},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(keys))
+	}
+	docs, err := retriever.GetRelevantDocuments(ctx, "capital of Japan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) == 0 || docs[0].PageContent != "Tokyo is the capital of Japan. It has a population of 38 million people." {
+		t.Errorf("expected the parent document of Tokyo first, got %v", docs)
+	}
+
+	if err := ds.Delete(ctx, keys); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pgEngine.Pool.Exec(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %q", docstoreTable)); err != nil {
+		t.Fatal(err)
+	}
+	err = cleanUpTableFn()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
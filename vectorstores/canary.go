@@ -0,0 +1,135 @@
+package vectorstores
+
+import (
+	"context"
+	"errors"
+	"log"
+	"maps"
+	"math/rand"
+	"time"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// CanaryArmMetadataKey is the document metadata key CanaryRetriever sets to
+// the arm that served the document.
+const CanaryArmMetadataKey = "canary_arm"
+
+// CanaryArm identifies which configuration served a retrieval.
+type CanaryArm string
+
+const (
+	// CanaryArmBaseline is the current production configuration.
+	CanaryArmBaseline CanaryArm = "baseline"
+	// CanaryArmExperiment is the experimental configuration.
+	CanaryArmExperiment CanaryArm = "experiment"
+)
+
+type canaryArmKey struct{}
+
+// CanaryArmFromContext returns the arm serving the retrieval of ctx, set by
+// CanaryRetriever in the context passed to its callbacks handler and to the
+// retriever of the arm, if any.
+func CanaryArmFromContext(ctx context.Context) CanaryArm {
+	arm, _ := ctx.Value(canaryArmKey{}).(CanaryArm)
+	return arm
+}
+
+// ErrInvalidCanaryPercentage is returned when the experiment percentage is
+// not between 0 and 100.
+var ErrInvalidCanaryPercentage = errors.New("canary percentage must be between 0 and 100")
+
+// CanaryOutcome is the result of a single retrieval served by a
+// CanaryRetriever.
+type CanaryOutcome struct {
+	Arm       CanaryArm
+	Query     string
+	Documents []schema.Document
+	Latency   time.Duration
+	Err       error
+}
+
+// CanaryRecorder records the outcomes of canary retrievals for online
+// comparison of the two arms. Recording errors are logged and don't fail the
+// retrieval.
+type CanaryRecorder interface {
+	RecordCanaryOutcome(ctx context.Context, outcome CanaryOutcome) error
+}
+
+// CanaryRetriever routes a percentage of retrievals to an experimental
+// retriever, e.g. one backed by a new index, embedding model or chunking,
+// and the rest to the baseline retriever.
+type CanaryRetriever struct {
+	CallbacksHandler callbacks.Handler
+	// Recorder, when set, receives the outcome of every retrieval.
+	Recorder CanaryRecorder
+
+	baseline   schema.Retriever
+	experiment schema.Retriever
+	percentage float64
+	random     func() float64
+}
+
+var _ schema.Retriever = &CanaryRetriever{}
+
+// NewCanaryRetriever creates a CanaryRetriever sending percentage (0-100) of
+// the retrievals to experiment.
+func NewCanaryRetriever(baseline, experiment schema.Retriever, percentage float64) (*CanaryRetriever, error) {
+	if percentage < 0 || percentage > 100 {
+		return nil, ErrInvalidCanaryPercentage
+	}
+	return &CanaryRetriever{
+		baseline:   baseline,
+		experiment: experiment,
+		percentage: percentage,
+		random:     rand.Float64, //nolint:gosec
+	}, nil
+}
+
+// GetRelevantDocuments returns documents from the arm selected for this
+// retrieval, tagging copies of the documents with the arm in their metadata.
+// The arm is also set in the context passed to the callbacks handler, see
+// CanaryArmFromContext.
+func (r *CanaryRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]schema.Document, error) {
+	arm, retriever := CanaryArmBaseline, r.baseline
+	if r.random()*100 < r.percentage {
+		arm, retriever = CanaryArmExperiment, r.experiment
+	}
+	ctx = context.WithValue(ctx, canaryArmKey{}, arm)
+
+	if r.CallbacksHandler != nil {
+		r.CallbacksHandler.HandleRetrieverStart(ctx, query)
+	}
+
+	start := time.Now()
+	docs, err := retriever.GetRelevantDocuments(ctx, query)
+	latency := time.Since(start)
+
+	// The documents may be shared by the retriever, e.g. from a cache.
+	tagged := make([]schema.Document, len(docs))
+	for i, doc := range docs {
+		doc.Metadata = maps.Clone(doc.Metadata)
+		if doc.Metadata == nil {
+			doc.Metadata = map[string]any{}
+		}
+		doc.Metadata[CanaryArmMetadataKey] = string(arm)
+		tagged[i] = doc
+	}
+	docs = tagged
+
+	if r.Recorder != nil {
+		outcome := CanaryOutcome{Arm: arm, Query: query, Documents: docs, Latency: latency, Err: err}
+		if recErr := r.Recorder.RecordCanaryOutcome(ctx, outcome); recErr != nil {
+			log.Printf("canary: failed to record the outcome of the %s arm: %v", arm, recErr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r.CallbacksHandler != nil {
+		r.CallbacksHandler.HandleRetrieverEnd(ctx, query, docs)
+	}
+	return docs, nil
+}
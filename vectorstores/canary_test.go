@@ -0,0 +1,112 @@
+package vectorstores
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/schema"
+)
+
+type staticRetriever struct {
+	docs []schema.Document
+	err  error
+}
+
+func (r staticRetriever) GetRelevantDocuments(context.Context, string) ([]schema.Document, error) {
+	docs := make([]schema.Document, len(r.docs))
+	copy(docs, r.docs)
+	return docs, r.err
+}
+
+type outcomeRecorder struct {
+	outcomes []CanaryOutcome
+	err      error
+}
+
+func (r *outcomeRecorder) RecordCanaryOutcome(_ context.Context, outcome CanaryOutcome) error {
+	r.outcomes = append(r.outcomes, outcome)
+	return r.err
+}
+
+// armHandler records the canary arm of the retrievals it is notified of.
+type armHandler struct {
+	callbacks.SimpleHandler
+	arms []CanaryArm
+}
+
+func (h *armHandler) HandleRetrieverEnd(ctx context.Context, _ string, _ []schema.Document) {
+	h.arms = append(h.arms, CanaryArmFromContext(ctx))
+}
+
+func TestCanaryRetrieverRouting(t *testing.T) {
+	t.Parallel()
+	baseline := staticRetriever{docs: []schema.Document{{PageContent: "baseline"}}}
+	experiment := staticRetriever{docs: []schema.Document{{PageContent: "experiment"}}}
+
+	r, err := NewCanaryRetriever(baseline, experiment, 25)
+	require.NoError(t, err)
+	recorder := &outcomeRecorder{}
+	r.Recorder = recorder
+
+	for _, tc := range []struct {
+		random  float64
+		arm     CanaryArm
+		content string
+	}{
+		{random: 0.1, arm: CanaryArmExperiment, content: "experiment"},
+		{random: 0.25, arm: CanaryArmBaseline, content: "baseline"},
+		{random: 0.9, arm: CanaryArmBaseline, content: "baseline"},
+	} {
+		random := tc.random
+		r.random = func() float64 { return random }
+		docs, err := r.GetRelevantDocuments(context.Background(), "query")
+		require.NoError(t, err)
+		require.Len(t, docs, 1)
+		require.Equal(t, tc.content, docs[0].PageContent)
+		require.Equal(t, string(tc.arm), docs[0].Metadata[CanaryArmMetadataKey])
+	}
+
+	require.Len(t, recorder.outcomes, 3)
+	require.Equal(t, CanaryArmExperiment, recorder.outcomes[0].Arm)
+	require.Equal(t, "query", recorder.outcomes[0].Query)
+}
+
+func TestCanaryRetrieverRecordsErrors(t *testing.T) {
+	t.Parallel()
+	errSearch := errors.New("search failed")
+	r, err := NewCanaryRetriever(staticRetriever{}, staticRetriever{err: errSearch}, 100)
+	require.NoError(t, err)
+	recorder := &outcomeRecorder{}
+	r.Recorder = recorder
+
+	_, err = r.GetRelevantDocuments(context.Background(), "query")
+	require.ErrorIs(t, err, errSearch)
+	require.Len(t, recorder.outcomes, 1)
+	require.ErrorIs(t, recorder.outcomes[0].Err, errSearch)
+}
+
+func TestCanaryRetrieverTagging(t *testing.T) {
+	t.Parallel()
+	shared := []schema.Document{{PageContent: "experiment", Metadata: map[string]any{"source": "a"}}}
+	r, err := NewCanaryRetriever(staticRetriever{}, staticRetriever{docs: shared}, 100)
+	require.NoError(t, err)
+	handler := &armHandler{}
+	r.CallbacksHandler = handler
+	r.Recorder = &outcomeRecorder{err: errors.New("recorder unavailable")}
+
+	docs, err := r.GetRelevantDocuments(context.Background(), "query")
+	require.NoError(t, err, "recorder errors must not fail the retrieval")
+	require.Len(t, docs, 1)
+	require.Equal(t, map[string]any{"source": "a", CanaryArmMetadataKey: "experiment"}, docs[0].Metadata)
+	require.Equal(t, map[string]any{"source": "a"}, shared[0].Metadata)
+	require.Equal(t, []CanaryArm{CanaryArmExperiment}, handler.arms)
+}
+
+func TestNewCanaryRetrieverInvalidPercentage(t *testing.T) {
+	t.Parallel()
+	_, err := NewCanaryRetriever(staticRetriever{}, staticRetriever{}, 101)
+	require.ErrorIs(t, err, ErrInvalidCanaryPercentage)
+}
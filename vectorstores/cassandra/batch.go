@@ -0,0 +1,122 @@
+package cassandra
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// tokenRing maps the tokens of the ring to the nodes owning them.
+type tokenRing struct {
+	// tokens are the sorted tokens of the nodes, and hosts the ids of the
+	// nodes of the tokens.
+	tokens []int64
+	hosts  []string
+}
+
+// owner returns the node owning token t, the node of the first token of the
+// ring greater than or equal to t, wrapping around.
+func (r *tokenRing) owner(t int64) string {
+	i := sort.Search(len(r.tokens), func(i int) bool { return r.tokens[i] >= t })
+	if i == len(r.tokens) {
+		i = 0
+	}
+	return r.hosts[i]
+}
+
+// ringCache loads the token ring of the cluster once.
+type ringCache struct {
+	mu     sync.Mutex
+	loaded bool
+	ring   *tokenRing
+}
+
+// get returns the token ring of the cluster, or nil when it can't be read
+// from the system tables, e.g. because of their permissions.
+func (c *ringCache) get(ctx context.Context, session Session) *tokenRing {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.loaded {
+		c.ring = loadRing(ctx, session)
+		c.loaded = true
+	}
+	return c.ring
+}
+
+func loadRing(ctx context.Context, session Session) *tokenRing {
+	type entry struct {
+		token int64
+		host  string
+	}
+	var entries []entry
+	for _, table := range []string{"system.local", "system.peers"} {
+		rows := session.Query(ctx, "SELECT host_id, tokens FROM "+table)
+		var host string
+		var tokens []string
+		for rows.Scan(&host, &tokens) {
+			for _, t := range tokens {
+				token, err := strconv.ParseInt(t, 10, 64)
+				if err != nil {
+					_ = rows.Close()
+					return nil
+				}
+				entries = append(entries, entry{token: token, host: host})
+			}
+			tokens = nil
+		}
+		if err := rows.Close(); err != nil {
+			return nil
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].token < entries[j].token })
+	ring := &tokenRing{tokens: make([]int64, len(entries)), hosts: make([]string, len(entries))}
+	for i, e := range entries {
+		ring.tokens[i], ring.hosts[i] = e.token, e.host
+	}
+	return ring
+}
+
+// batches splits the rows of ids into batches of at most batchSize rows
+// owned by the same node, so that the driver routes each batch to a replica
+// of all its rows. The rows are grouped by token order alone when the ring
+// is unknown. It returns the indexes of the ids of each batch.
+func (s Store) batches(ctx context.Context, ids []string) [][]int {
+	order := make([]int, len(ids))
+	tokens := make([]int64, len(ids))
+	for i, id := range ids {
+		order[i] = i
+		tokens[i] = token([]byte(id))
+	}
+	sort.SliceStable(order, func(i, j int) bool { return tokens[order[i]] < tokens[order[j]] })
+
+	var groups [][]int
+	if ring := s.ring.get(ctx, s.session); ring != nil {
+		byOwner := map[string]int{}
+		for _, i := range order {
+			owner := ring.owner(tokens[i])
+			g, ok := byOwner[owner]
+			if !ok {
+				g = len(groups)
+				byOwner[owner] = g
+				groups = append(groups, nil)
+			}
+			groups[g] = append(groups[g], i)
+		}
+	} else {
+		groups = [][]int{order}
+	}
+
+	var batches [][]int
+	for _, group := range groups {
+		for len(group) > 0 {
+			n := min(len(group), s.batchSize)
+			batches = append(batches, group[:n])
+			group = group[n:]
+		}
+	}
+	return batches
+}
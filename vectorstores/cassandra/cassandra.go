@@ -0,0 +1,400 @@
+package cassandra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/indexes"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/migrate"
+)
+
+var (
+	// ErrMissingSession is returned by New when the session isn't set.
+	ErrMissingSession = errors.New("missing session")
+	// ErrMissingEmbedder is returned by New when the embedder isn't set.
+	ErrMissingEmbedder = errors.New("missing embedder")
+	// ErrMissingKeyspace is returned by New when the keyspace isn't set.
+	ErrMissingKeyspace = errors.New("missing keyspace")
+	// ErrInvalidFilters is returned when the filters of a search are neither
+	// a map[string]any of scalar metadata values nor a vectorstores.Filter
+	// of equalities.
+	ErrInvalidFilters = errors.New(
+		"filters must be a map[string]any of scalar metadata values or a vectorstores.Filter of equalities")
+)
+
+// identifierRegexp matches the unquoted CQL identifiers accepted as keyspace
+// and table names.
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Session runs the CQL statements of the store. Sessions of drivers are
+// adapted in a few lines, see the package documentation.
+type Session interface {
+	// Exec runs a statement.
+	Exec(ctx context.Context, stmt string, values ...any) error
+	// ExecBatch runs stmt with each of the values in an unlogged batch.
+	ExecBatch(ctx context.Context, stmt string, values [][]any) error
+	// Query runs a statement and returns its rows.
+	Query(ctx context.Context, stmt string, values ...any) Rows
+}
+
+// Rows are the rows returned by a query. *gocql.Iter implements Rows.
+type Rows interface {
+	// Scan copies the columns of the next row into dest, and reports whether
+	// there was a next row.
+	Scan(dest ...any) bool
+	// Close closes the rows and returns the error of the query, if any.
+	Close() error
+}
+
+// Store is a vector store keeping the documents in a Cassandra 5 or Astra
+// DB table, with their embeddings in a vector column searched through a
+// storage attached index.
+type Store struct {
+	session    Session
+	embedder   embeddings.Embedder
+	keyspace   string
+	table      string
+	dimensions int
+	similarity Similarity
+	batchSize  int
+	ring       *ringCache
+}
+
+var (
+	_ vectorstores.VectorStore = Store{}
+	_ indexes.VectorStore      = Store{}
+	_ migrate.Source           = Store{}
+)
+
+// New creates a new Store with options.
+func New(opts ...Option) (Store, error) {
+	s := Store{
+		table:      DefaultTable,
+		similarity: Cosine,
+		batchSize:  _defaultBatchSize,
+		ring:       &ringCache{},
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	switch {
+	case s.session == nil:
+		return Store{}, ErrMissingSession
+	case s.embedder == nil:
+		return Store{}, ErrMissingEmbedder
+	case s.keyspace == "":
+		return Store{}, ErrMissingKeyspace
+	}
+	for _, name := range []string{s.keyspace, s.table} {
+		if !identifierRegexp.MatchString(name) {
+			return Store{}, fmt.Errorf("invalid keyspace or table name %q", name)
+		}
+	}
+	if s.similarity != Cosine && s.similarity != DotProduct && s.similarity != Euclidean {
+		return Store{}, fmt.Errorf("invalid similarity %q", s.similarity)
+	}
+	if s.batchSize <= 0 {
+		return Store{}, fmt.Errorf("invalid batch size %d", s.batchSize)
+	}
+	return s, nil
+}
+
+// AddDocuments embeds and inserts documents, and returns their ids.
+// Documents with a string "id" metadata keep it as their id, replacing the
+// document with the same id. The rows are written in unlogged batches of
+// rows owned by the same node, see WithBatchSize.
+func (s Store) AddDocuments(ctx context.Context,
+	docs []schema.Document,
+	options ...vectorstores.Option,
+) ([]string, error) {
+	opts := getOptions(options...)
+	if opts.Deduplicater != nil {
+		filtered := make([]schema.Document, 0, len(docs))
+		for _, doc := range docs {
+			if !opts.Deduplicater(ctx, doc) {
+				filtered = append(filtered, doc)
+			}
+		}
+		docs = filtered
+	}
+	if len(docs) == 0 {
+		return []string{}, nil
+	}
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.PageContent
+	}
+	vectors, err := s.embedderOf(opts).EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed documents: %w", err)
+	}
+	if len(vectors) != len(docs) {
+		return nil, fmt.Errorf("got %d embeddings for %d documents", len(vectors), len(docs))
+	}
+
+	ids := make([]string, len(docs))
+	values := make([][]any, len(docs))
+	for i, doc := range docs {
+		id, ok := doc.Metadata["id"].(string)
+		if !ok || id == "" {
+			id = uuid.New().String()
+		}
+		ids[i] = id
+		metadata := doc.Metadata
+		if metadata == nil {
+			metadata = map[string]any{}
+		}
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		values[i] = []any{id, doc.PageContent, string(data), indexedMetadata(metadata), vectors[i]}
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %s (id, content, metadata, metadata_s, embedding) VALUES (?, ?, ?, ?, ?)`,
+		s.qualifiedTable())
+	for _, batch := range s.batches(ctx, ids) {
+		batchValues := make([][]any, len(batch))
+		for i, j := range batch {
+			batchValues[i] = values[j]
+		}
+		if err := s.session.ExecBatch(ctx, stmt, batchValues); err != nil {
+			return nil, fmt.Errorf("failed to insert documents: %w", err)
+		}
+	}
+	return ids, nil
+}
+
+// indexedMetadata returns the scalar metadata values as strings, for the
+// filters of the searches.
+func indexedMetadata(metadata map[string]any) map[string]string {
+	indexed := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		if s, ok := scalarString(value); ok {
+			indexed[key] = s
+		}
+	}
+	return indexed
+}
+
+func scalarString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// DeleteDocuments deletes the documents with the given ids, and returns the
+// number of documents that existed.
+func (s Store) DeleteDocuments(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	rows := s.session.Query(ctx, fmt.Sprintf(`SELECT id FROM %s WHERE id IN ?`, s.qualifiedTable()), ids)
+	existing := 0
+	var id string
+	for rows.Scan(&id) {
+		existing++
+	}
+	if err := rows.Close(); err != nil {
+		return 0, fmt.Errorf("failed to find documents: %w", err)
+	}
+
+	stmt := fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, s.qualifiedTable())
+	for _, batch := range s.batches(ctx, ids) {
+		values := make([][]any, len(batch))
+		for i, j := range batch {
+			values[i] = []any{ids[j]}
+		}
+		if err := s.session.ExecBatch(ctx, stmt, values); err != nil {
+			return 0, fmt.Errorf("failed to delete documents: %w", err)
+		}
+	}
+	return existing, nil
+}
+
+// SimilaritySearch returns the numDocuments documents with the embeddings
+// most similar to the embedding of query, matching the filters and scoring
+// at least the score threshold. The filters are a map of scalar metadata
+// values the documents must be equal to, or a vectorstores.Filter which is an
+// Eq or an And of Eq. The scores are the similarity functions of Cassandra,
+// in [0, 1].
+func (s Store) SimilaritySearch(ctx context.Context,
+	query string,
+	numDocuments int,
+	options ...vectorstores.Option,
+) ([]schema.Document, error) {
+	opts := getOptions(options...)
+	conditions, args, err := filterConditions(opts.Filters)
+	if err != nil {
+		return nil, err
+	}
+	vector, err := s.embedderOf(opts).EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	args = append([]any{vector}, args...)
+	args = append(args, vector, numDocuments)
+	rows := s.session.Query(ctx, s.searchQuery(conditions), args...)
+	docs := make([]schema.Document, 0, numDocuments)
+	var content, metadata string
+	var score float32
+	for rows.Scan(&content, &metadata, &score) {
+		if opts.ScoreThreshold != 0 && score < opts.ScoreThreshold {
+			continue
+		}
+		doc := schema.Document{PageContent: content, Score: score}
+		if err := json.Unmarshal([]byte(metadata), &doc.Metadata); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("failed to search documents: %w", err)
+	}
+	return docs, nil
+}
+
+// searchQuery returns the search statement, whose arguments are the query
+// vector, the arguments of the conditions, the query vector again and the
+// number of documents.
+func (s Store) searchQuery(conditions []string) string {
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	return fmt.Sprintf(`SELECT content, metadata, similarity_%s(embedding, ?) FROM %s%s ORDER BY embedding ANN OF ? LIMIT ?`,
+		s.similarity, s.qualifiedTable(), where)
+}
+
+// filterConditions returns the conditions of filters on the indexed
+// metadata, and their arguments. The metadata entries are indexed for
+// equality only, so a vectorstores.Filter must be an Eq or an And of Eq.
+func filterConditions(filters any) ([]string, []any, error) {
+	var equalities []vectorstores.Filter
+	switch f := filters.(type) {
+	case nil:
+		return nil, nil, nil
+	case map[string]any:
+		keys := make([]string, 0, len(f))
+		for key := range f {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			equalities = append(equalities, vectorstores.Eq(key, f[key]))
+		}
+	case vectorstores.Filter:
+		if err := f.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		var err error
+		if equalities, err = filterEqualities(f, nil); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("%w, got %T", ErrInvalidFilters, filters)
+	}
+	conditions := make([]string, len(equalities))
+	args := make([]any, len(equalities))
+	for i, eq := range equalities {
+		value, ok := scalarString(eq.Value)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w, got %T for %q", ErrInvalidFilters, eq.Value, eq.Key)
+		}
+		conditions[i] = fmt.Sprintf("metadata_s[%s] = ?", quoteLiteral(eq.Key))
+		args[i] = value
+	}
+	return conditions, args, nil
+}
+
+// filterEqualities appends the Eq filters of f, an Eq or an And of Eq, to
+// equalities.
+func filterEqualities(f vectorstores.Filter, equalities []vectorstores.Filter) ([]vectorstores.Filter, error) {
+	switch f.Op { //nolint:exhaustive
+	case vectorstores.FilterEq:
+		return append(equalities, f), nil
+	case vectorstores.FilterAnd:
+		for _, filter := range f.Filters {
+			var err error
+			if equalities, err = filterEqualities(filter, equalities); err != nil {
+				return nil, err
+			}
+		}
+		return equalities, nil
+	}
+	return nil, fmt.Errorf("%w: %s filters aren't supported, the metadata are only indexed for equality", ErrInvalidFilters, f.Op)
+}
+
+// ListEmbeddedDocuments returns at most limit documents with their
+// embeddings in token order, after the document with the id cursor, and the
+// cursor of the next page, empty after the last page.
+func (s Store) ListEmbeddedDocuments(ctx context.Context, cursor string, limit int) ([]migrate.Record, string, error) {
+	stmt := fmt.Sprintf(`SELECT id, content, metadata, embedding FROM %s`, s.qualifiedTable())
+	args := []any{}
+	if cursor != "" {
+		stmt += ` WHERE token(id) > token(?)`
+		args = append(args, cursor)
+	}
+	stmt += ` LIMIT ?`
+	args = append(args, limit)
+
+	rows := s.session.Query(ctx, stmt, args...)
+	records := make([]migrate.Record, 0, limit)
+	var id, content, metadata string
+	var embedding []float32
+	for rows.Scan(&id, &content, &metadata, &embedding) {
+		record := migrate.Record{ID: id, Document: schema.Document{PageContent: content}, Embedding: embedding}
+		if err := json.Unmarshal([]byte(metadata), &record.Document.Metadata); err != nil {
+			_ = rows.Close()
+			return nil, "", fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+		records = append(records, record)
+		embedding = nil
+	}
+	if err := rows.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to list documents: %w", err)
+	}
+	next := ""
+	if len(records) == limit && limit > 0 {
+		next = records[len(records)-1].ID
+	}
+	return records, next, nil
+}
+
+func (s Store) qualifiedTable() string {
+	return s.keyspace + "." + s.table
+}
+
+func (s Store) embedderOf(opts vectorstores.Options) embeddings.Embedder {
+	if opts.Embedder != nil {
+		return opts.Embedder
+	}
+	return s.embedder
+}
+
+func getOptions(options ...vectorstores.Option) vectorstores.Options {
+	opts := vectorstores.Options{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
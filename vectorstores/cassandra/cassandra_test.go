@@ -0,0 +1,240 @@
+package cassandra
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/vstest"
+)
+
+// statement is a statement run by the recording session.
+type statement struct {
+	stmt   string
+	values [][]any
+}
+
+// recorder records the statements run on it, and answers the queries
+// starting with the prefixes of rows with their rows.
+type recorder struct {
+	mu         sync.Mutex
+	statements []statement
+	rows       map[string][][]any
+	err        error
+}
+
+func (r *recorder) record(stmt string, values ...[]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, statement{stmt: stmt, values: values})
+}
+
+func (r *recorder) Exec(_ context.Context, stmt string, values ...any) error {
+	r.record(stmt, values)
+	return r.err
+}
+
+func (r *recorder) ExecBatch(_ context.Context, stmt string, values [][]any) error {
+	r.record(stmt, values...)
+	return r.err
+}
+
+func (r *recorder) Query(_ context.Context, stmt string, values ...any) Rows {
+	r.record(stmt, values)
+	for prefix, rows := range r.rows {
+		if strings.HasPrefix(stmt, prefix) {
+			return &recordedRows{rows: rows, err: r.err}
+		}
+	}
+	return &recordedRows{err: r.err}
+}
+
+type recordedRows struct {
+	rows [][]any
+	err  error
+}
+
+func (r *recordedRows) Scan(dest ...any) bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	for i, value := range r.rows[0] {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+	}
+	r.rows = r.rows[1:]
+	return true
+}
+
+func (r *recordedRows) Close() error { return r.err }
+
+func newTestStore(t *testing.T, opts ...Option) (Store, *recorder) {
+	t.Helper()
+	r := &recorder{rows: map[string][][]any{}}
+	opts = append([]Option{WithSession(r), WithEmbedder(vstest.Embedder{}), WithKeyspace("ks")}, opts...)
+	s, err := New(opts...)
+	require.NoError(t, err)
+	return s, r
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	_, err := New(WithEmbedder(vstest.Embedder{}), WithKeyspace("ks"))
+	require.ErrorIs(t, err, ErrMissingSession)
+	_, err = New(WithSession(&recorder{}), WithKeyspace("ks"))
+	require.ErrorIs(t, err, ErrMissingEmbedder)
+	_, err = New(WithSession(&recorder{}), WithEmbedder(vstest.Embedder{}))
+	require.ErrorIs(t, err, ErrMissingKeyspace)
+	_, err = New(WithSession(&recorder{}), WithEmbedder(vstest.Embedder{}), WithKeyspace("ks"), WithTable("a-b"))
+	require.Error(t, err)
+	_, err = New(WithSession(&recorder{}), WithEmbedder(vstest.Embedder{}), WithKeyspace("ks"), WithSimilarity("l1"))
+	require.Error(t, err)
+}
+
+func TestToken(t *testing.T) {
+	t.Parallel()
+	// The tokens of Cassandra's Murmur3Partitioner.
+	require.Equal(t, int64(-3758069500696749310), token([]byte("hello")))
+	require.Equal(t, int64(3760413751763713166), token([]byte("hello, world")))
+}
+
+func TestInit(t *testing.T) {
+	t.Parallel()
+	s, r := newTestStore(t, WithVectorDimensions(2), WithSimilarity(DotProduct))
+	ctx := context.Background()
+	require.NoError(t, s.InitKeyspace(ctx, KeyspaceOptions{}))
+	require.NoError(t, s.InitKeyspace(ctx, KeyspaceOptions{DataCenters: map[string]int{"dc2": 1, "dc1": 3}}))
+	require.NoError(t, s.InitTable(ctx, TableOptions{OverwriteExisting: true, IfNotExists: true}))
+
+	stmts := make([]string, len(r.statements))
+	for i, st := range r.statements {
+		stmts[i] = st.stmt
+	}
+	require.Equal(t, []string{
+		`CREATE KEYSPACE IF NOT EXISTS ks WITH replication = {'class': 'SimpleStrategy', 'replication_factor': 1}`,
+		`CREATE KEYSPACE IF NOT EXISTS ks WITH replication = {'class': 'NetworkTopologyStrategy', 'dc1': 3, 'dc2': 1}`,
+		`DROP TABLE IF EXISTS ks.langchaingo_documents`,
+		`CREATE TABLE IF NOT EXISTS ks.langchaingo_documents (
+	id text PRIMARY KEY,
+	content text,
+	metadata text,
+	metadata_s map<text, text>,
+	embedding vector<float, 2>
+)`,
+		`CREATE CUSTOM INDEX IF NOT EXISTS langchaingo_documents_embedding_idx ON ks.langchaingo_documents (embedding) USING 'StorageAttachedIndex' WITH OPTIONS = {'similarity_function': 'dot_product'}`, // nolint: lll
+		`CREATE CUSTOM INDEX IF NOT EXISTS langchaingo_documents_metadata_idx ON ks.langchaingo_documents (entries(metadata_s)) USING 'StorageAttachedIndex'`,                                              // nolint: lll
+	}, stmts)
+
+	s, _ = newTestStore(t)
+	require.Error(t, s.InitTable(ctx, TableOptions{}))
+}
+
+func TestAddDocuments(t *testing.T) {
+	t.Parallel()
+	s, r := newTestStore(t)
+	ids, err := s.AddDocuments(context.Background(), []schema.Document{
+		{PageContent: "cat dog", Metadata: map[string]any{"id": "hello", "n": 1, "tags": []string{"a"}}},
+		{PageContent: "dog"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "hello", ids[0])
+
+	// Without the ring, the rows are written in a single batch.
+	batch := r.statements[len(r.statements)-1]
+	require.Equal(t, `INSERT INTO ks.langchaingo_documents (id, content, metadata, metadata_s, embedding) VALUES (?, ?, ?, ?, ?)`, batch.stmt) // nolint: lll
+	require.Len(t, batch.values, 2)
+	require.Contains(t, batch.values, []any{
+		"hello", "cat dog", `{"id":"hello","n":1,"tags":["a"]}`,
+		map[string]string{"id": "hello", "n": "1"}, []float32{0.70710677, 0.70710677, 0, 0},
+	})
+}
+
+func TestBatches(t *testing.T) {
+	t.Parallel()
+	s, r := newTestStore(t, WithBatchSize(2))
+	// "hello" and "hello, world" have the tokens -3758069500696749310 and
+	// 3760413751763713166: node a owns the first one, node b the second.
+	r.rows["SELECT host_id, tokens FROM system.local"] = [][]any{{"a", []string{"-1000"}}}
+	r.rows["SELECT host_id, tokens FROM system.peers"] = [][]any{{"b", []string{"5000000000000000000"}}}
+
+	ids := []string{"hello, world", "hello", "hello, world", "hello, world"}
+	batches := s.batches(context.Background(), ids)
+	require.Equal(t, [][]int{{1}, {0, 2}, {3}}, batches)
+
+	// The ring is read once.
+	s.batches(context.Background(), ids)
+	require.Len(t, r.statements, 2)
+}
+
+func TestDeleteDocuments(t *testing.T) {
+	t.Parallel()
+	s, r := newTestStore(t)
+	r.rows["SELECT id FROM"] = [][]any{{"a"}}
+	deleted, err := s.DeleteDocuments(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+	batch := r.statements[len(r.statements)-1]
+	require.Equal(t, `DELETE FROM ks.langchaingo_documents WHERE id = ?`, batch.stmt)
+	require.ElementsMatch(t, [][]any{{"a"}, {"b"}}, batch.values)
+
+	r.err = errors.New("unavailable")
+	_, err = s.DeleteDocuments(context.Background(), []string{"a"})
+	require.Error(t, err)
+}
+
+func TestSimilaritySearch(t *testing.T) {
+	t.Parallel()
+	s, r := newTestStore(t, WithSimilarity(Euclidean))
+	r.rows["SELECT content"] = [][]any{
+		{"cat", `{"kind":"pet"}`, float32(0.9)},
+		{"dog", `{}`, float32(0.2)},
+	}
+	docs, err := s.SimilaritySearch(context.Background(), "cat", 2,
+		vectorstores.WithFilters(map[string]any{"kind": "pet", "it's": true}), vectorstores.WithScoreThreshold(0.5))
+	require.NoError(t, err)
+	require.Equal(t, []schema.Document{{PageContent: "cat", Metadata: map[string]any{"kind": "pet"}, Score: 0.9}}, docs)
+
+	search := r.statements[0]
+	require.Equal(t, `SELECT content, metadata, similarity_euclidean(embedding, ?) FROM ks.langchaingo_documents WHERE metadata_s['it''s'] = ? AND metadata_s['kind'] = ? ORDER BY embedding ANN OF ? LIMIT ?`, search.stmt) // nolint: lll
+	require.Equal(t, [][]any{{[]float32{1, 0, 0, 0}, "true", "pet", []float32{1, 0, 0, 0}, 2}}, search.values)
+
+	_, err = s.SimilaritySearch(context.Background(), "cat", 2, vectorstores.WithFilters(map[string]any{"tags": []any{"a"}}))
+	require.ErrorIs(t, err, ErrInvalidFilters)
+
+	_, err = s.SimilaritySearch(context.Background(), "cat", 2, vectorstores.WithFilters(
+		vectorstores.And(vectorstores.Eq("kind", "pet"), vectorstores.And(vectorstores.Eq("legs", 4)))))
+	require.NoError(t, err)
+	search = r.statements[len(r.statements)-1]
+	require.Contains(t, search.stmt, `WHERE metadata_s['kind'] = ? AND metadata_s['legs'] = ? ORDER BY`)
+	require.Equal(t, [][]any{{[]float32{1, 0, 0, 0}, "pet", "4", []float32{1, 0, 0, 0}, 2}}, search.values)
+
+	for _, filter := range []vectorstores.Filter{
+		vectorstores.Or(vectorstores.Eq("kind", "pet")),
+		vectorstores.And(vectorstores.Eq("kind", "pet"), vectorstores.Gt("legs", 2)),
+		vectorstores.Not(vectorstores.Filter{}),
+	} {
+		_, err = s.SimilaritySearch(context.Background(), "cat", 2, vectorstores.WithFilters(filter))
+		require.ErrorIs(t, err, ErrInvalidFilters, filter)
+	}
+}
+
+func TestListEmbeddedDocuments(t *testing.T) {
+	t.Parallel()
+	s, r := newTestStore(t)
+	r.rows["SELECT id, content"] = [][]any{
+		{"a", "cat", `{}`, []float32{1, 0}},
+		{"b", "dog", `{"n":1}`, []float32{0, 1}},
+	}
+	records, next, err := s.ListEmbeddedDocuments(context.Background(), "x", 2)
+	require.NoError(t, err)
+	require.Equal(t, "b", next)
+	require.Len(t, records, 2)
+	require.Equal(t, []float32{0, 1}, records[1].Embedding)
+	require.Equal(t, map[string]any{"n": 1.0}, records[1].Document.Metadata)
+	require.Equal(t, `SELECT id, content, metadata, embedding FROM ks.langchaingo_documents WHERE token(id) > token(?) LIMIT ?`, r.statements[0].stmt) // nolint: lll
+}
@@ -0,0 +1,29 @@
+// Package cassandra contains an implementation of the VectorStore interface
+// for Apache Cassandra 5 and DataStax Astra DB, storing the embeddings in a
+// vector column searched through a storage attached index (SAI).
+//
+// The store runs its statements through a Session, so that the module
+// doesn't depend on a driver. A gocql session is adapted with:
+//
+//	type session struct{ *gocql.Session }
+//
+//	func (s session) Exec(ctx context.Context, stmt string, values ...any) error {
+//		return s.Session.Query(stmt, values...).WithContext(ctx).Exec()
+//	}
+//
+//	func (s session) ExecBatch(ctx context.Context, stmt string, values [][]any) error {
+//		batch := s.Session.NewBatch(gocql.UnloggedBatch).WithContext(ctx)
+//		for _, v := range values {
+//			batch.Query(stmt, v...)
+//		}
+//		return s.Session.ExecuteBatch(batch)
+//	}
+//
+//	func (s session) Query(ctx context.Context, stmt string, values ...any) cassandra.Rows {
+//		return s.Session.Query(stmt, values...).WithContext(ctx).Iter()
+//	}
+//
+// Writes are token aware: the rows are grouped by the node owning their
+// partition, read from the system tables, into unlogged batches that a
+// token aware driver sends to a replica of all their rows.
+package cassandra
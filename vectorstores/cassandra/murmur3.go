@@ -0,0 +1,85 @@
+package cassandra
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// token returns the token of a partition key with the Murmur3Partitioner,
+// the default partitioner of Cassandra and the partitioner of Astra DB. It is
+// the first half of the x64 128 bit MurmurHash3 of the key, with the sign
+// extension of the tail bytes of Cassandra's implementation.
+func token(key []byte) int64 {
+	const (
+		c1 = 0x87c37b91114253d5
+		c2 = 0x4cf5ad432745937f
+	)
+	var h1, h2 uint64
+	nBlocks := len(key) / 16
+	for i := 0; i < nBlocks; i++ {
+		k1 := binary.LittleEndian.Uint64(key[i*16:])
+		k2 := binary.LittleEndian.Uint64(key[i*16+8:])
+
+		k1 *= c1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		k2 *= c2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	tail := key[nBlocks*16:]
+	signed := func(i int) uint64 { return uint64(int64(int8(tail[i]))) }
+	var k1, k2 uint64
+	for i := len(tail) - 1; i >= 8; i-- {
+		k2 ^= signed(i) << (8 * (i - 8))
+	}
+	if len(tail) > 8 {
+		k2 *= c2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+	}
+	for i := min(len(tail), 8) - 1; i >= 0; i-- {
+		k1 ^= signed(i) << (8 * i)
+	}
+	if len(tail) > 0 {
+		k1 *= c1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+	}
+
+	h1 ^= uint64(len(key))
+	h2 ^= uint64(len(key))
+	h1 += h2
+	h2 += h1
+	h1 = fmix(h1)
+	h2 = fmix(h2)
+	h1 += h2
+
+	// The minimum token is reserved by the partitioner.
+	if t := int64(h1); t != math.MinInt64 {
+		return t
+	}
+	return math.MaxInt64
+}
+
+func fmix(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
@@ -0,0 +1,77 @@
+package cassandra
+
+import (
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+const (
+	// DefaultTable is the default table of the documents.
+	DefaultTable = "langchaingo_documents"
+
+	_defaultBatchSize = 20
+)
+
+// Similarity is the similarity function of the vector index.
+type Similarity string
+
+const (
+	Cosine     Similarity = "cosine"
+	DotProduct Similarity = "dot_product"
+	Euclidean  Similarity = "euclidean"
+)
+
+// Option is a function for configuring the Store.
+type Option func(s *Store)
+
+// WithSession sets the session running the CQL statements. It must be set.
+func WithSession(session Session) Option {
+	return func(s *Store) {
+		s.session = session
+	}
+}
+
+// WithEmbedder sets the embedder of the documents and queries. It must be
+// set.
+func WithEmbedder(embedder embeddings.Embedder) Option {
+	return func(s *Store) {
+		s.embedder = embedder
+	}
+}
+
+// WithKeyspace sets the keyspace of the table. It must be set.
+func WithKeyspace(keyspace string) Option {
+	return func(s *Store) {
+		s.keyspace = keyspace
+	}
+}
+
+// WithTable sets the table of the documents. It defaults to DefaultTable.
+func WithTable(table string) Option {
+	return func(s *Store) {
+		s.table = table
+	}
+}
+
+// WithVectorDimensions sets the dimension of the embeddings, used by
+// InitTable. It must be set to create the table.
+func WithVectorDimensions(dimensions int) Option {
+	return func(s *Store) {
+		s.dimensions = dimensions
+	}
+}
+
+// WithSimilarity sets the similarity function of the vector index and of
+// the scores of the documents. It defaults to Cosine.
+func WithSimilarity(similarity Similarity) Option {
+	return func(s *Store) {
+		s.similarity = similarity
+	}
+}
+
+// WithBatchSize sets the maximum number of rows of the unlogged batches of
+// AddDocuments and DeleteDocuments. It defaults to 20.
+func WithBatchSize(batchSize int) Option {
+	return func(s *Store) {
+		s.batchSize = batchSize
+	}
+}
@@ -0,0 +1,102 @@
+package cassandra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// KeyspaceOptions is used with InitKeyspace.
+type KeyspaceOptions struct {
+	// ReplicationFactor is the replication factor of the SimpleStrategy. It
+	// defaults to 1, and is ignored when DataCenters is set.
+	ReplicationFactor int
+	// DataCenters maps the data centers to their replication factors of the
+	// NetworkTopologyStrategy.
+	DataCenters map[string]int
+}
+
+// InitKeyspace creates the keyspace of the store if it doesn't exist. Astra
+// DB keyspaces are created with its API instead.
+func (s Store) InitKeyspace(ctx context.Context, opts KeyspaceOptions) error {
+	if err := s.session.Exec(ctx, createKeyspaceQuery(s.keyspace, opts)); err != nil {
+		return fmt.Errorf("failed to create keyspace: %w", err)
+	}
+	return nil
+}
+
+func createKeyspaceQuery(keyspace string, opts KeyspaceOptions) string {
+	replication := ""
+	if len(opts.DataCenters) > 0 {
+		replication = "'class': 'NetworkTopologyStrategy'"
+		for _, dc := range sortedKeys(opts.DataCenters) {
+			replication += fmt.Sprintf(", %s: %d", quoteLiteral(dc), opts.DataCenters[dc])
+		}
+	} else {
+		factor := opts.ReplicationFactor
+		if factor <= 0 {
+			factor = 1
+		}
+		replication = fmt.Sprintf("'class': 'SimpleStrategy', 'replication_factor': %d", factor)
+	}
+	return fmt.Sprintf(`CREATE KEYSPACE IF NOT EXISTS %s WITH replication = {%s}`, keyspace, replication)
+}
+
+// TableOptions is used with InitTable.
+type TableOptions struct {
+	// OverwriteExisting drops the existing table before creating it.
+	OverwriteExisting bool
+	// IfNotExists keeps an existing table instead of failing to create it.
+	IfNotExists bool
+}
+
+// InitTable creates the table of the store, with the storage attached
+// indexes of the embeddings, with the similarity function of the store, and
+// of the metadata entries the searches are filtered on. The vector
+// dimensions must be set.
+func (s Store) InitTable(ctx context.Context, opts TableOptions) error {
+	if s.dimensions <= 0 {
+		return errors.New("missing vector dimensions")
+	}
+	if opts.OverwriteExisting {
+		if err := s.session.Exec(ctx, "DROP TABLE IF EXISTS "+s.qualifiedTable()); err != nil {
+			return fmt.Errorf("failed to drop table: %w", err)
+		}
+	}
+	for _, stmt := range s.createTableQueries(opts.IfNotExists) {
+		if err := s.session.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create table: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s Store) createTableQueries(ifNotExists bool) []string {
+	exists := ""
+	if ifNotExists {
+		exists = "IF NOT EXISTS "
+	}
+	return []string{
+		fmt.Sprintf(`CREATE TABLE %s%s (
+	id text PRIMARY KEY,
+	content text,
+	metadata text,
+	metadata_s map<text, text>,
+	embedding vector<float, %d>
+)`, exists, s.qualifiedTable(), s.dimensions),
+		fmt.Sprintf(`CREATE CUSTOM INDEX %s%s_embedding_idx ON %s (embedding) USING 'StorageAttachedIndex' WITH OPTIONS = {'similarity_function': '%s'}`, // nolint: lll
+			exists, s.table, s.qualifiedTable(), s.similarity),
+		fmt.Sprintf(`CREATE CUSTOM INDEX %s%s_metadata_idx ON %s (entries(metadata_s)) USING 'StorageAttachedIndex'`,
+			exists, s.table, s.qualifiedTable()),
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,562 @@
+package columnar
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/indexes"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+const (
+	segmentExt     = ".seg"
+	tombstonesFile = "tombstones"
+)
+
+var (
+	// ErrMissingEmbedder is returned by Open when the embedder isn't set.
+	ErrMissingEmbedder = errors.New("missing embedder")
+	// ErrInvalidFilters is returned when the filters of a search are neither
+	// a map[string]any of metadata values nor a vectorstores.Filter.
+	ErrInvalidFilters = errors.New("filters must be a map[string]any of metadata values or a vectorstores.Filter")
+	// ErrDimensionMismatch is returned when the embeddings of added documents
+	// don't have the dimension of the stored embeddings.
+	ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+)
+
+// location is the location of a row, in the segment of sequence number seq.
+type location struct {
+	seq, row int
+}
+
+// Store is an embedded vector store persisting the documents in a
+// directory of columnar segment files.
+type Store struct {
+	mu       sync.RWMutex
+	dir      string
+	embedder embeddings.Embedder
+	distance Distance
+
+	segments   []*segment
+	nextSeq    int
+	dimensions int
+	// locations are the locations of the live rows of the ids, and deleted
+	// the locations of the deleted or replaced rows.
+	locations  map[string]location
+	deleted    map[location]bool
+	tombstones *os.File
+}
+
+var (
+	_ vectorstores.VectorStore = &Store{}
+	_ indexes.VectorStore      = &Store{}
+)
+
+// Open opens the store of the directory dir, creating it if it doesn't
+// exist. The store must be closed after use, and a directory must not be
+// opened by several stores at once.
+func Open(dir string, opts ...Option) (*Store, error) {
+	s := &Store{
+		dir:       dir,
+		locations: map[string]location{},
+		deleted:   map[location]bool{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.embedder == nil {
+		return nil, ErrMissingEmbedder
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	if err := s.load(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load opens the segments and the tombstones of the directory.
+func (s *Store) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read store directory: %w", err)
+	}
+	var seqs []int
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), segmentExt)
+		if !ok {
+			continue
+		}
+		if seq, err := strconv.Atoi(name); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Ints(seqs)
+
+	s.tombstones, err = os.OpenFile(filepath.Join(s.dir, tombstonesFile), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open tombstones: %w", err)
+	}
+	scanner := bufio.NewScanner(s.tombstones)
+	for scanner.Scan() {
+		var loc location
+		if _, err := fmt.Sscanf(scanner.Text(), "%d %d", &loc.seq, &loc.row); err != nil {
+			return fmt.Errorf("failed to read tombstones: %w", err)
+		}
+		s.deleted[loc] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read tombstones: %w", err)
+	}
+
+	for _, seq := range seqs {
+		seg, err := openSegment(s.segmentPath(seq), seq)
+		if err != nil {
+			return err
+		}
+		s.segments = append(s.segments, seg)
+		s.nextSeq = seq + 1
+		if s.dimensions == 0 {
+			s.dimensions = seg.dimensions
+		}
+		ids, err := seg.column(idsColumn)
+		if err != nil {
+			return err
+		}
+		for row, id := range ids {
+			loc := location{seq: seq, row: row}
+			if s.deleted[loc] {
+				continue
+			}
+			// A later row of an id replaces the earlier ones, whose tombstones
+			// may not have been written.
+			if old, ok := s.locations[string(id)]; ok {
+				s.deleted[old] = true
+			}
+			s.locations[string(id)] = loc
+		}
+	}
+	return nil
+}
+
+// Close closes the files of the store.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errs := make([]error, 0, len(s.segments)+1)
+	for _, seg := range s.segments {
+		errs = append(errs, seg.file.Close())
+	}
+	s.segments = nil
+	if s.tombstones != nil {
+		errs = append(errs, s.tombstones.Close())
+		s.tombstones = nil
+	}
+	return errors.Join(errs...)
+}
+
+// AddDocuments embeds documents and writes them to a new segment, and
+// returns their ids. Documents with a string "id" metadata keep it as their
+// id, replacing the stored document with the same id.
+func (s *Store) AddDocuments(ctx context.Context,
+	docs []schema.Document,
+	options ...vectorstores.Option,
+) ([]string, error) {
+	opts := getOptions(options...)
+	if opts.Deduplicater != nil {
+		filtered := make([]schema.Document, 0, len(docs))
+		for _, doc := range docs {
+			if !opts.Deduplicater(ctx, doc) {
+				filtered = append(filtered, doc)
+			}
+		}
+		docs = filtered
+	}
+	if len(docs) == 0 {
+		return []string{}, nil
+	}
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.PageContent
+	}
+	vectors, err := s.embedderOf(opts).EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed documents: %w", err)
+	}
+	if len(vectors) != len(docs) {
+		return nil, fmt.Errorf("got %d embeddings for %d documents", len(vectors), len(docs))
+	}
+
+	ids := make([]string, len(docs))
+	var columns [3][][]byte
+	for i, doc := range docs {
+		id, ok := doc.Metadata["id"].(string)
+		if !ok || id == "" {
+			id = uuid.New().String()
+		}
+		ids[i] = id
+		metadata := doc.Metadata
+		if metadata == nil {
+			metadata = map[string]any{}
+		}
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		columns[idsColumn] = append(columns[idsColumn], []byte(id))
+		columns[metadataColumn] = append(columns[metadataColumn], data)
+		columns[contentsColumn] = append(columns[contentsColumn], []byte(doc.PageContent))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dimensions := s.dimensions
+	if dimensions == 0 {
+		dimensions = len(vectors[0])
+	}
+	for _, vector := range vectors {
+		if len(vector) != dimensions {
+			return nil, fmt.Errorf("%w: got %d, want %d", ErrDimensionMismatch, len(vector), dimensions)
+		}
+	}
+
+	seq := s.nextSeq
+	if err := writeSegment(s.segmentPath(seq), dimensions, vectors, columns); err != nil {
+		return nil, fmt.Errorf("failed to write segment: %w", err)
+	}
+	seg, err := openSegment(s.segmentPath(seq), seq)
+	if err != nil {
+		return nil, err
+	}
+	s.segments = append(s.segments, seg)
+	s.nextSeq++
+	s.dimensions = dimensions
+
+	var replaced []location
+	for row, id := range ids {
+		if old, ok := s.locations[id]; ok {
+			replaced = append(replaced, old)
+		}
+		s.locations[id] = location{seq: seq, row: row}
+	}
+	if err := s.deleteLocations(replaced); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// deleteLocations marks rows deleted, and persists their tombstones.
+func (s *Store) deleteLocations(locs []location) error {
+	if len(locs) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	for _, loc := range locs {
+		s.deleted[loc] = true
+		fmt.Fprintf(&b, "%d %d\n", loc.seq, loc.row)
+	}
+	if _, err := s.tombstones.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write tombstones: %w", err)
+	}
+	if err := s.tombstones.Sync(); err != nil {
+		return fmt.Errorf("failed to write tombstones: %w", err)
+	}
+	return nil
+}
+
+// DeleteDocuments deletes the documents with the given ids, and returns the
+// number of deleted documents. Their rows are removed from the segment files
+// by Compact.
+func (s *Store) DeleteDocuments(_ context.Context, ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var locs []location
+	for _, id := range ids {
+		if loc, ok := s.locations[id]; ok {
+			locs = append(locs, loc)
+			delete(s.locations, id)
+		}
+	}
+	if err := s.deleteLocations(locs); err != nil {
+		return 0, err
+	}
+	return len(locs), nil
+}
+
+// SimilaritySearch returns the numDocuments documents most similar to query,
+// matching the filters and scoring at least the score threshold. The filters
+// are a map of metadata values the documents must be equal to. Searches
+// read the vectors of the segments, their metadata when filtering, and the
+// contents of the returned documents only.
+func (s *Store) SimilaritySearch(ctx context.Context,
+	query string,
+	numDocuments int,
+	options ...vectorstores.Option,
+) ([]schema.Document, error) {
+	if numDocuments <= 0 {
+		return []schema.Document{}, nil
+	}
+	opts := getOptions(options...)
+	match, err := filterFunc(opts.Filters)
+	if err != nil {
+		return nil, err
+	}
+	vector, err := s.embedderOf(opts).EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.dimensions != 0 && len(vector) != s.dimensions {
+		return nil, fmt.Errorf("%w: got %d, want %d", ErrDimensionMismatch, len(vector), s.dimensions)
+	}
+
+	best := &candidates{}
+	for _, seg := range s.segments {
+		vectors, err := seg.vectors()
+		if err != nil {
+			return nil, err
+		}
+		var metadata [][]byte
+		if match != nil {
+			if metadata, err = seg.column(metadataColumn); err != nil {
+				return nil, err
+			}
+		}
+		for row, v := range vectors {
+			if s.deleted[location{seq: seg.seq, row: row}] {
+				continue
+			}
+			score := s.score(vector, v)
+			if opts.ScoreThreshold != 0 && score < opts.ScoreThreshold {
+				continue
+			}
+			if best.Len() == numDocuments && score <= (*best)[0].score {
+				continue
+			}
+			if match != nil {
+				var m map[string]any
+				if err := json.Unmarshal(metadata[row], &m); err != nil || !match(m) {
+					continue
+				}
+			}
+			heap.Push(best, candidate{score: score, seg: seg, row: row})
+			if best.Len() > numDocuments {
+				heap.Pop(best)
+			}
+		}
+	}
+
+	docs := make([]schema.Document, best.Len())
+	for i := len(docs) - 1; i >= 0; i-- {
+		c := heap.Pop(best).(candidate) //nolint:forcetypeassert
+		doc, err := c.seg.document(c.row)
+		if err != nil {
+			return nil, err
+		}
+		doc.Score = c.score
+		docs[i] = doc
+	}
+	return docs, nil
+}
+
+// document reads the content and metadata of a row.
+func (s *segment) document(row int) (schema.Document, error) {
+	content, err := s.value(contentsColumn, row)
+	if err != nil {
+		return schema.Document{}, err
+	}
+	metadata, err := s.value(metadataColumn, row)
+	if err != nil {
+		return schema.Document{}, err
+	}
+	doc := schema.Document{PageContent: string(content)}
+	if err := json.Unmarshal(metadata, &doc.Metadata); err != nil {
+		return schema.Document{}, fmt.Errorf("%w: %w", ErrCorruptSegment, err)
+	}
+	return doc, nil
+}
+
+// Compact rewrites the live rows of the segments into a single segment,
+// removing the rows of the deleted and replaced documents.
+func (s *Store) Compact(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var vectors [][]float32
+	var columns [3][][]byte
+	for _, seg := range s.segments {
+		segVectors, err := seg.vectors()
+		if err != nil {
+			return err
+		}
+		var segColumns [3][][]byte
+		for column := range segColumns {
+			if segColumns[column], err = seg.column(column); err != nil {
+				return err
+			}
+		}
+		for row := range segVectors {
+			if s.deleted[location{seq: seg.seq, row: row}] {
+				continue
+			}
+			vectors = append(vectors, segVectors[row])
+			for column := range columns {
+				columns[column] = append(columns[column], segColumns[column][row])
+			}
+		}
+	}
+
+	var segments []*segment
+	locations := map[string]location{}
+	if len(vectors) > 0 {
+		seq := s.nextSeq
+		if err := writeSegment(s.segmentPath(seq), s.dimensions, vectors, columns); err != nil {
+			return fmt.Errorf("failed to write segment: %w", err)
+		}
+		seg, err := openSegment(s.segmentPath(seq), seq)
+		if err != nil {
+			return err
+		}
+		segments = []*segment{seg}
+		s.nextSeq++
+		for row, id := range columns[idsColumn] {
+			locations[string(id)] = location{seq: seq, row: row}
+		}
+	} else {
+		s.dimensions = 0
+	}
+	old := s.segments
+	s.segments, s.locations, s.deleted = segments, locations, map[location]bool{}
+
+	// The new segment replaces the old ones if they aren't removed.
+	for _, seg := range old {
+		seg.file.Close()
+		if err := os.Remove(s.segmentPath(seg.seq)); err != nil {
+			return fmt.Errorf("failed to remove segment: %w", err)
+		}
+	}
+	if err := s.tombstones.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate tombstones: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of stored documents.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.locations)
+}
+
+func (s *Store) segmentPath(seq int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%08d%s", seq, segmentExt))
+}
+
+func (s *Store) embedderOf(opts vectorstores.Options) embeddings.Embedder {
+	if opts.Embedder != nil {
+		return opts.Embedder
+	}
+	return s.embedder
+}
+
+// score returns the score of the document embedding b for the query
+// embedding a, higher for more similar documents.
+func (s *Store) score(a, b []float32) float32 {
+	var dot, normA, normB, sum float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+		sum += (x - y) * (x - y)
+	}
+	if s.distance == L2 {
+		return float32(1 / (1 + math.Sqrt(sum)))
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
+
+// filterFunc returns the function matching the metadata of filters, nil
+// when there are no filters. The values are compared to the metadata as
+// decoded from JSON.
+func filterFunc(filters any) (func(map[string]any) bool, error) {
+	switch f := filters.(type) {
+	case nil:
+		return nil, nil
+	case vectorstores.Filter:
+		if err := f.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		return f.Match, nil
+	case map[string]any:
+		data, err := json.Marshal(f)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		var want map[string]any
+		if err := json.Unmarshal(data, &want); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		return func(metadata map[string]any) bool {
+			for key, value := range want {
+				if v, ok := metadata[key]; !ok || !reflect.DeepEqual(v, value) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	}
+	return nil, fmt.Errorf("%w, got %T", ErrInvalidFilters, filters)
+}
+
+// candidate is a row of a search result.
+type candidate struct {
+	score float32
+	seg   *segment
+	row   int
+}
+
+// candidates is a min-heap of the best candidates of a search.
+type candidates []candidate
+
+func (c candidates) Len() int           { return len(c) }
+func (c candidates) Less(i, j int) bool { return c[i].score < c[j].score }
+func (c candidates) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c *candidates) Push(x any)        { *c = append(*c, x.(candidate)) } //nolint:forcetypeassert
+
+func (c *candidates) Pop() any {
+	old := *c
+	x := old[len(old)-1]
+	*c = old[:len(old)-1]
+	return x
+}
+
+func getOptions(options ...vectorstores.Option) vectorstores.Options {
+	opts := vectorstores.Options{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
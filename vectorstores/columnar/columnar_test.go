@@ -0,0 +1,181 @@
+package columnar
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/vstest"
+)
+
+func openTestStore(t *testing.T, dir string, opts ...Option) *Store {
+	t.Helper()
+	s, err := Open(dir, append([]Option{WithEmbedder(vstest.Embedder{})}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+	_, err := Open(t.TempDir())
+	require.ErrorIs(t, err, ErrMissingEmbedder)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000.seg"), []byte("garbage"), 0o600))
+	_, err = Open(dir, WithEmbedder(vstest.Embedder{}))
+	require.ErrorIs(t, err, ErrCorruptSegment)
+}
+
+func TestAddAndSearch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := openTestStore(t, dir)
+
+	ids, err := s.AddDocuments(ctx, []schema.Document{
+		{PageContent: "cat", Metadata: map[string]any{"kind": "pet", "legs": 4}},
+		{PageContent: "cat dog", Metadata: map[string]any{"kind": "pet", "legs": 4}},
+		{PageContent: "fish", Metadata: map[string]any{"kind": "food"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, ids, 3)
+	_, err = s.AddDocuments(ctx, []schema.Document{
+		{PageContent: "dog", Metadata: map[string]any{"id": "d", "kind": "pet"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, s.Len())
+
+	docs, err := s.SimilaritySearch(ctx, "cat", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat", "cat dog"}, vstest.Contents(docs))
+	require.InDelta(t, 1, docs[0].Score, 1e-6)
+	require.Equal(t, map[string]any{"kind": "pet", "legs": 4.0}, docs[0].Metadata)
+
+	docs, err = s.SimilaritySearch(ctx, "dog", 4, vectorstores.WithFilters(map[string]any{"legs": 4}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat dog", "cat"}, vstest.Contents(docs))
+
+	docs, err = s.SimilaritySearch(ctx, "dog", 4,
+		vectorstores.WithFilters(vectorstores.And(vectorstores.Eq("kind", "pet"), vectorstores.Lt("legs", 4.5))))
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat dog", "cat"}, vstest.Contents(docs))
+
+	docs, err = s.SimilaritySearch(ctx, "dog", 4, vectorstores.WithScoreThreshold(0.5))
+	require.NoError(t, err)
+	require.Equal(t, []string{"dog", "cat dog"}, vstest.Contents(docs))
+
+	_, err = s.SimilaritySearch(ctx, "dog", 4, vectorstores.WithFilters("kind = 'pet'"))
+	require.ErrorIs(t, err, ErrInvalidFilters)
+
+	// The documents are persisted.
+	require.NoError(t, s.Close())
+	s = openTestStore(t, dir, WithDistance(L2))
+	require.Equal(t, 4, s.Len())
+	docs, err = s.SimilaritySearch(ctx, "fish", 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"fish"}, vstest.Contents(docs))
+	require.InDelta(t, 1, docs[0].Score, 1e-6)
+}
+
+func TestDimensionMismatch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := openTestStore(t, t.TempDir())
+	_, err := s.AddDocuments(ctx, []schema.Document{{PageContent: "cat"}})
+	require.NoError(t, err)
+	_, err = s.AddDocuments(ctx, []schema.Document{{PageContent: "x"}},
+		vectorstores.WithEmbedder(embedderFunc(func() []float32 { return []float32{1, 2} })))
+	require.ErrorIs(t, err, ErrDimensionMismatch)
+}
+
+type embedderFunc func() []float32
+
+func (f embedderFunc) EmbedDocuments(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = f()
+	}
+	return vectors, nil
+}
+
+func (f embedderFunc) EmbedQuery(context.Context, string) ([]float32, error) { return f(), nil }
+
+func TestDeleteReplaceAndCompact(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := openTestStore(t, dir)
+
+	_, err := s.AddDocuments(ctx, []schema.Document{
+		{PageContent: "cat", Metadata: map[string]any{"id": "a"}},
+		{PageContent: "dog", Metadata: map[string]any{"id": "b"}},
+		{PageContent: "fish", Metadata: map[string]any{"id": "c"}},
+	})
+	require.NoError(t, err)
+	// Replace a, and delete b.
+	_, err = s.AddDocuments(ctx, []schema.Document{{PageContent: "cat cat", Metadata: map[string]any{"id": "a"}}})
+	require.NoError(t, err)
+	deleted, err := s.DeleteDocuments(ctx, []string{"b", "missing"})
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+
+	check := func(s *Store) {
+		t.Helper()
+		require.Equal(t, 2, s.Len())
+		docs, err := s.SimilaritySearch(ctx, "cat dog fish", 10)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"cat cat", "fish"}, vstest.Contents(docs))
+	}
+	check(s)
+
+	// The tombstones are persisted.
+	require.NoError(t, s.Close())
+	s = openTestStore(t, dir)
+	check(s)
+
+	require.NoError(t, s.Compact(ctx))
+	check(s)
+	segments, err := filepath.Glob(filepath.Join(dir, "*.seg"))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	info, err := os.Stat(filepath.Join(dir, tombstonesFile))
+	require.NoError(t, err)
+	require.Zero(t, info.Size())
+
+	require.NoError(t, s.Close())
+	s = openTestStore(t, dir)
+	check(s)
+
+	_, err = s.DeleteDocuments(ctx, []string{"a", "c"})
+	require.NoError(t, err)
+	require.NoError(t, s.Compact(ctx))
+	require.Zero(t, s.Len())
+	segments, err = filepath.Glob(filepath.Join(dir, "*.seg"))
+	require.NoError(t, err)
+	require.Empty(t, segments)
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+	for name, distance := range map[string]Distance{"Cosine": Cosine, "L2": L2} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			vstest.Run(t, vstest.Config{
+				NewStore: func(t *testing.T, embedder embeddings.Embedder) vectorstores.VectorStore {
+					t.Helper()
+					s, err := Open(t.TempDir(), WithEmbedder(embedder), WithDistance(distance))
+					require.NoError(t, err)
+					t.Cleanup(func() { s.Close() })
+					return s
+				},
+				Filters: true,
+			})
+		})
+	}
+}
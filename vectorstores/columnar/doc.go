@@ -0,0 +1,12 @@
+// Package columnar contains an implementation of the VectorStore interface
+// embedded in the process, persisting the documents in a directory of
+// columnar segment files, so that large local corpora can be searched
+// without a server.
+//
+// Each AddDocuments call writes a segment holding the vectors, ids,
+// metadata and contents of its documents in separate columns. Searches scan
+// the vectors column of every segment, read the metadata column only when
+// filtering, and read the contents of the returned documents only.
+// Deletions and replacements are recorded as tombstones until Compact
+// rewrites the live documents into a single segment.
+package columnar
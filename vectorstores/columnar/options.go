@@ -0,0 +1,36 @@
+package columnar
+
+import (
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+// Distance is the distance function of the searches.
+type Distance int
+
+const (
+	// Cosine ranks the documents by cosine similarity, the score of a
+	// document being its cosine similarity to the query.
+	Cosine Distance = iota
+	// L2 ranks the documents by euclidean distance, the score of a document
+	// being 1 / (1 + distance) so that closer documents score higher.
+	L2
+)
+
+// Option is a function for configuring the Store.
+type Option func(s *Store)
+
+// WithEmbedder sets the embedder of the documents and queries. It must be
+// set.
+func WithEmbedder(embedder embeddings.Embedder) Option {
+	return func(s *Store) {
+		s.embedder = embedder
+	}
+}
+
+// WithDistance sets the distance function of the searches. It defaults to
+// Cosine.
+func WithDistance(distance Distance) Option {
+	return func(s *Store) {
+		s.distance = distance
+	}
+}
@@ -0,0 +1,182 @@
+package columnar
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// A segment file holds the rows of an AddDocuments call, column by column,
+// so that searches only read the vectors, and the metadata when filtering.
+// Integers are little endian:
+//
+//	magic [8]byte
+//	dimensions, rows uint32
+//	offsets of the ids, metadata and contents columns uint64
+//	vectors column: rows * dimensions float32
+//	ids, metadata and contents columns: rows + 1 uint64 offsets of the
+//	values, relative to the end of the offsets, then the values
+const (
+	segmentMagic      = "LCGOCOL1"
+	segmentHeaderSize = 8 + 2*4 + 3*8
+)
+
+// The variable length columns of a segment.
+const (
+	idsColumn = iota
+	metadataColumn
+	contentsColumn
+)
+
+// ErrCorruptSegment is returned when a segment file can't be read.
+var ErrCorruptSegment = errors.New("corrupt segment file")
+
+// segment is an open segment file.
+type segment struct {
+	seq        int
+	file       *os.File
+	dimensions int
+	rows       int
+	columns    [3]int64
+}
+
+// writeSegment writes the rows of a segment to path, through a temporary
+// file renamed once synced so that a segment is never partially written.
+func writeSegment(path string, dimensions int, vectors [][]float32, columns [3][][]byte) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	rows := len(vectors)
+	header := make([]byte, segmentHeaderSize)
+	copy(header, segmentMagic)
+	binary.LittleEndian.PutUint32(header[8:], uint32(dimensions))
+	binary.LittleEndian.PutUint32(header[12:], uint32(rows))
+	offset := int64(segmentHeaderSize + 4*rows*dimensions)
+	for i, column := range columns {
+		binary.LittleEndian.PutUint64(header[16+8*i:], uint64(offset))
+		offset += int64(8 * (rows + 1))
+		for _, value := range column {
+			offset += int64(len(value))
+		}
+	}
+
+	buf := make([]byte, 0, offset)
+	buf = append(buf, header...)
+	for _, vector := range vectors {
+		for _, v := range vector {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+		}
+	}
+	for _, column := range columns {
+		var valueOffset uint64
+		buf = binary.LittleEndian.AppendUint64(buf, 0)
+		for _, value := range column {
+			valueOffset += uint64(len(value))
+			buf = binary.LittleEndian.AppendUint64(buf, valueOffset)
+		}
+		for _, value := range column {
+			buf = append(buf, value...)
+		}
+	}
+
+	if _, err := f.Write(buf); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// openSegment opens the segment file path.
+func openSegment(path string, seq int) (*segment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, segmentHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil || string(header[:8]) != segmentMagic {
+		f.Close()
+		return nil, fmt.Errorf("%w: %s", ErrCorruptSegment, path)
+	}
+	s := &segment{
+		seq:        seq,
+		file:       f,
+		dimensions: int(binary.LittleEndian.Uint32(header[8:])),
+		rows:       int(binary.LittleEndian.Uint32(header[12:])),
+	}
+	for i := range s.columns {
+		s.columns[i] = int64(binary.LittleEndian.Uint64(header[16+8*i:]))
+	}
+	return s, nil
+}
+
+// vectors reads the vectors column.
+func (s *segment) vectors() ([][]float32, error) {
+	buf := make([]byte, 4*s.rows*s.dimensions)
+	if _, err := s.file.ReadAt(buf, segmentHeaderSize); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorruptSegment, err)
+	}
+	vectors := make([][]float32, s.rows)
+	for i := range vectors {
+		vectors[i] = make([]float32, s.dimensions)
+		for j := range vectors[i] {
+			vectors[i][j] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*(i*s.dimensions+j):]))
+		}
+	}
+	return vectors, nil
+}
+
+// column reads all the values of a variable length column.
+func (s *segment) column(column int) ([][]byte, error) {
+	offsets, err := s.offsets(column, 0, s.rows)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, offsets[s.rows])
+	if _, err := s.file.ReadAt(data, s.columns[column]+int64(8*(s.rows+1))); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorruptSegment, err)
+	}
+	values := make([][]byte, s.rows)
+	for i := range values {
+		values[i] = data[offsets[i]:offsets[i+1]]
+	}
+	return values, nil
+}
+
+// value reads the value of a row of a variable length column.
+func (s *segment) value(column, row int) ([]byte, error) {
+	offsets, err := s.offsets(column, row, row+1)
+	if err != nil {
+		return nil, err
+	}
+	value := make([]byte, offsets[1]-offsets[0])
+	if _, err := s.file.ReadAt(value, s.columns[column]+int64(8*(s.rows+1))+int64(offsets[0])); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorruptSegment, err)
+	}
+	return value, nil
+}
+
+// offsets reads the offsets of the values of the rows from to to, included.
+func (s *segment) offsets(column, from, to int) ([]uint64, error) {
+	buf := make([]byte, 8*(to-from+1))
+	if _, err := s.file.ReadAt(buf, s.columns[column]+int64(8*from)); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorruptSegment, err)
+	}
+	offsets := make([]uint64, to-from+1)
+	for i := range offsets {
+		offsets[i] = binary.LittleEndian.Uint64(buf[8*i:])
+	}
+	return offsets, nil
+}
@@ -7,6 +7,8 @@ The main components of this package are:
 - VectorStore interface: a common interface for saving and querying vector embeddings of documents.
 - Options: a set of options for similarity search and document addition.
 - Retriever: a retriever for vector stores that implements the schema.Retriever interface.
+- Filter: a backend agnostic metadata filter, passed to WithFilters, that the stores supporting it
+translate to their own query language.
 
 The package provides a flexible way to handle different types of vector stores
 by using the VectorStore interface as an abstraction.
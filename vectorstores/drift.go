@@ -0,0 +1,217 @@
+package vectorstores
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// ErrNoDriftProbes is returned when checking for drift before the probes are
+// set or captured.
+var ErrNoDriftProbes = errors.New("no drift probes")
+
+// DriftProbe is a probe query along with its embedding and neighbors at the
+// time the embedding space was known to be good.
+type DriftProbe struct {
+	Query     string    `json:"query"`
+	Embedding []float32 `json:"embedding"`
+	// Neighbors are the keys of the documents returned for the query.
+	Neighbors []string `json:"neighbors"`
+}
+
+// DriftReport is the result of a drift check.
+type DriftReport struct {
+	CheckedAt time.Time
+	// NeighborOverlap is the mean fraction of the neighbors of the probes
+	// still returned for their query.
+	NeighborOverlap float64
+	// EmbeddingSimilarity is the mean cosine similarity of the probe
+	// embeddings to their stored embeddings.
+	EmbeddingSimilarity float64
+	// Drifted is whether either measure is below its threshold.
+	Drifted bool
+}
+
+// DriftMonitor detects the silent changes of the embedding space, e.g. a
+// provider-side update of the query embedding model, that degrade retrieval
+// from documents embedded with the previous model. It periodically embeds a
+// fixed set of probe queries and compares their embeddings and neighbors with
+// the ones captured when retrieval was known to be good.
+type DriftMonitor struct {
+	// Probes are the probe queries, either captured with CaptureProbes or
+	// loaded from a previous capture.
+	Probes []DriftProbe
+	// MinNeighborOverlap is the neighbor overlap below which the embedding
+	// space drifted. It defaults to 0.8.
+	MinNeighborOverlap float64
+	// MinEmbeddingSimilarity is the embedding similarity below which the
+	// embedding space drifted. It defaults to 0.99.
+	MinEmbeddingSimilarity float64
+	// DocumentKey identifies the neighbors of the probes. It defaults to the
+	// content of the documents.
+	DocumentKey func(doc schema.Document) string
+	// SearchOptions are the options of the searches of the probes.
+	SearchOptions []Option
+	// OnDrift, if not nil, is called with the reports of the checks that
+	// detected a drift.
+	OnDrift func(ctx context.Context, report DriftReport)
+
+	store    VectorStore
+	embedder embeddings.Embedder
+}
+
+// NewDriftMonitor creates a DriftMonitor of the searches of store, whose
+// queries are embedded with embedder.
+func NewDriftMonitor(store VectorStore, embedder embeddings.Embedder) *DriftMonitor {
+	return &DriftMonitor{
+		MinNeighborOverlap:     0.8,
+		MinEmbeddingSimilarity: 0.99,
+		DocumentKey:            func(doc schema.Document) string { return doc.PageContent },
+		store:                  store,
+		embedder:               embedder,
+	}
+}
+
+// CaptureProbes sets the probes of the monitor to the given queries with
+// their current embeddings and k nearest neighbors, and returns them to be
+// stored.
+func (m *DriftMonitor) CaptureProbes(ctx context.Context, queries []string, k int) ([]DriftProbe, error) {
+	vectors, err := m.embedder.EmbedDocuments(ctx, queries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed probes: %w", err)
+	}
+	probes := make([]DriftProbe, len(queries))
+	for i, query := range queries {
+		neighbors, err := m.neighbors(ctx, query, k)
+		if err != nil {
+			return nil, err
+		}
+		probes[i] = DriftProbe{Query: query, Embedding: vectors[i], Neighbors: neighbors}
+	}
+	m.Probes = probes
+	return probes, nil
+}
+
+// Check embeds the probes and searches their neighbors, and reports how far
+// they are from the stored ones.
+func (m *DriftMonitor) Check(ctx context.Context) (DriftReport, error) {
+	if len(m.Probes) == 0 {
+		return DriftReport{}, ErrNoDriftProbes
+	}
+	queries := make([]string, len(m.Probes))
+	for i, probe := range m.Probes {
+		queries[i] = probe.Query
+	}
+	vectors, err := m.embedder.EmbedDocuments(ctx, queries)
+	if err != nil {
+		return DriftReport{}, fmt.Errorf("failed to embed probes: %w", err)
+	}
+
+	var overlap, similarity float64
+	for i, probe := range m.Probes {
+		neighbors, err := m.neighbors(ctx, probe.Query, len(probe.Neighbors))
+		if err != nil {
+			return DriftReport{}, err
+		}
+		overlap += neighborOverlap(probe.Neighbors, neighbors)
+		similarity += cosineSimilarity(probe.Embedding, vectors[i])
+	}
+	report := DriftReport{
+		CheckedAt:           time.Now(),
+		NeighborOverlap:     overlap / float64(len(m.Probes)),
+		EmbeddingSimilarity: similarity / float64(len(m.Probes)),
+	}
+	report.Drifted = report.NeighborOverlap < m.MinNeighborOverlap ||
+		report.EmbeddingSimilarity < m.MinEmbeddingSimilarity
+	if report.Drifted && m.OnDrift != nil {
+		m.OnDrift(ctx, report)
+	}
+	return report, nil
+}
+
+// Start calls Check every interval in the background, until ctx is done or
+// the returned stop function is called. onError, if not nil, is called with
+// the errors of the checks; the monitor keeps running after them.
+func (m *DriftMonitor) Start(ctx context.Context, interval time.Duration, onError func(error)) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := m.Check(ctx); err != nil && ctx.Err() == nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// neighbors returns the keys of the k nearest documents to query.
+func (m *DriftMonitor) neighbors(ctx context.Context, query string, k int) ([]string, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	docs, err := m.store.SimilaritySearch(ctx, query, k, m.SearchOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search probe %q: %w", query, err)
+	}
+	keys := make([]string, len(docs))
+	for i, doc := range docs {
+		keys[i] = m.DocumentKey(doc)
+	}
+	return keys, nil
+}
+
+// neighborOverlap returns the fraction of want found in got, 1 when want is
+// empty.
+func neighborOverlap(want, got []string) float64 {
+	if len(want) == 0 {
+		return 1
+	}
+	found := make(map[string]bool, len(got))
+	for _, key := range got {
+		found[key] = true
+	}
+	n := 0
+	for _, key := range want {
+		if found[key] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(want))
+}
+
+// cosineSimilarity returns the cosine similarity of a and b, 0 when their
+// dimensions differ.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,78 @@
+package vectorstores
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// probeStore returns the documents of the current model for every query.
+type probeStore struct {
+	docs []schema.Document
+}
+
+func (s *probeStore) AddDocuments(context.Context, []schema.Document, ...Option) ([]string, error) {
+	return nil, nil
+}
+
+func (s *probeStore) SimilaritySearch(_ context.Context, _ string, k int, _ ...Option) ([]schema.Document, error) {
+	return s.docs[:min(k, len(s.docs))], nil
+}
+
+// probeEmbedder embeds every text to vector.
+type probeEmbedder struct {
+	vector []float32
+}
+
+func (e *probeEmbedder) EmbedDocuments(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = e.vector
+	}
+	return vectors, nil
+}
+
+func (e *probeEmbedder) EmbedQuery(_ context.Context, _ string) ([]float32, error) {
+	return e.vector, nil
+}
+
+func TestDriftMonitor(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := &probeStore{docs: documents("a", "b", "c", "d")}
+	embedder := &probeEmbedder{vector: []float32{1, 0}}
+	var drifts []DriftReport
+	m := NewDriftMonitor(store, embedder)
+	m.OnDrift = func(_ context.Context, report DriftReport) { drifts = append(drifts, report) }
+
+	_, err := m.Check(ctx)
+	require.ErrorIs(t, err, ErrNoDriftProbes)
+
+	probes, err := m.CaptureProbes(ctx, []string{"first", "second"}, 4)
+	require.NoError(t, err)
+	require.Len(t, probes, 2)
+	require.Equal(t, []string{"a", "b", "c", "d"}, probes[0].Neighbors)
+
+	report, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, report.Drifted)
+	require.InDelta(t, 1, report.NeighborOverlap, 1e-9)
+	require.InDelta(t, 1, report.EmbeddingSimilarity, 1e-9)
+
+	// The provider updated the model: the neighbors and embeddings shift.
+	store.docs = documents("a", "b", "e", "f")
+	embedder.vector = []float32{1, 1}
+	report, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, report.Drifted)
+	require.InDelta(t, 0.5, report.NeighborOverlap, 1e-9)
+	require.InDelta(t, 0.7071, report.EmbeddingSimilarity, 1e-4)
+	require.Len(t, drifts, 1)
+}
+
+func TestCosineSimilarityOfDifferentDimensions(t *testing.T) {
+	t.Parallel()
+	require.Zero(t, cosineSimilarity([]float32{1, 0}, []float32{1, 0, 0}))
+}
@@ -0,0 +1,9 @@
+// Package duckdb contains an implementation of the VectorStore interface
+// backed by DuckDB, so that analytical pipelines can search the embeddings
+// of corpora ingested from parquet files in-process.
+//
+// The store uses a database opened by the caller with a DuckDB driver, e.g.
+// github.com/marcboeker/go-duckdb, and the fixed-size ARRAY functions of
+// DuckDB 1.1 or later. The HNSW index of CreateHNSWIndex requires the vss
+// extension.
+package duckdb
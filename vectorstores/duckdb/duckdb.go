@@ -0,0 +1,370 @@
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/indexes"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/migrate"
+)
+
+var (
+	// ErrMissingDB is returned by New when the database isn't set.
+	ErrMissingDB = errors.New("missing database")
+	// ErrMissingEmbedder is returned by New when the embedder isn't set.
+	ErrMissingEmbedder = errors.New("missing embedder")
+	// ErrInvalidFilters is returned when the filters of a search are neither
+	// a map[string]any of metadata values nor a vectorstores.Filter.
+	ErrInvalidFilters = errors.New("filters must be a map[string]any of metadata values or a vectorstores.Filter")
+
+	identifierRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`) //nolint:gochecknoglobals
+)
+
+// Store is a vector store keeping the documents in a DuckDB table, along
+// with their metadata as JSON and their embeddings as a FLOAT array column.
+type Store struct {
+	db         *sql.DB
+	embedder   embeddings.Embedder
+	dimensions int
+	tableName  string
+	collection string
+	distance   Distance
+}
+
+var (
+	_ vectorstores.VectorStore = Store{}
+	_ indexes.VectorStore      = Store{}
+	_ migrate.Source           = Store{}
+)
+
+// New creates a new Store with options, creating its table if it doesn't
+// exist.
+func New(ctx context.Context, opts ...Option) (Store, error) {
+	s := Store{
+		tableName:  DefaultTableName,
+		collection: DefaultCollection,
+		distance:   Cosine,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.db == nil {
+		return Store{}, ErrMissingDB
+	}
+	if s.embedder == nil {
+		return Store{}, ErrMissingEmbedder
+	}
+	if s.dimensions <= 0 {
+		return Store{}, fmt.Errorf("invalid vector dimensions %d", s.dimensions)
+	}
+	if !identifierRegexp.MatchString(s.tableName) {
+		return Store{}, fmt.Errorf("invalid table name %q", s.tableName)
+	}
+	if _, ok := distanceFunctions[s.distance]; !ok {
+		return Store{}, fmt.Errorf("invalid distance %q", s.distance)
+	}
+	if _, err := s.db.ExecContext(ctx, s.createTableQuery()); err != nil {
+		return Store{}, fmt.Errorf("failed to create table: %w", err)
+	}
+	return s, nil
+}
+
+// distanceFunctions are the array functions computing the distances.
+var distanceFunctions = map[Distance]string{ //nolint:gochecknoglobals
+	Cosine:       "array_cosine_distance",
+	L2:           "array_distance",
+	InnerProduct: "array_negative_inner_product",
+}
+
+func (s Store) createTableQuery() string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR PRIMARY KEY,
+	collection VARCHAR NOT NULL,
+	content VARCHAR NOT NULL,
+	metadata JSON NOT NULL,
+	embedding FLOAT[%d] NOT NULL
+)`, s.tableName, s.dimensions)
+}
+
+// CreateHNSWIndex creates an HNSW index of the embeddings with the metric of
+// the distance function, loading the vss extension. Indexes of databases
+// stored in files require the hnsw_enable_experimental_persistence setting.
+func (s Store) CreateHNSWIndex(ctx context.Context) error {
+	stmts := []string{
+		"INSTALL vss",
+		"LOAD vss",
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_embedding_hnsw ON %s USING HNSW (embedding) WITH (metric = '%s')`,
+			s.tableName, s.tableName, s.distance),
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create HNSW index: %w", err)
+		}
+	}
+	return nil
+}
+
+// AddDocuments embeds and adds documents to the collection, or the name space
+// of the options, in a single transaction and returns their ids. Documents
+// with a string "id" metadata keep it as their id, replacing the stored
+// document with the same id.
+func (s Store) AddDocuments(ctx context.Context,
+	docs []schema.Document,
+	options ...vectorstores.Option,
+) ([]string, error) {
+	opts := getOptions(options...)
+	if opts.Deduplicater != nil {
+		filtered := make([]schema.Document, 0, len(docs))
+		for _, doc := range docs {
+			if !opts.Deduplicater(ctx, doc) {
+				filtered = append(filtered, doc)
+			}
+		}
+		docs = filtered
+	}
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.PageContent
+	}
+	vectors, err := s.embedderOf(opts).EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed documents: %w", err)
+	}
+	if len(vectors) != len(docs) {
+		return nil, fmt.Errorf("got %d embeddings for %d documents", len(vectors), len(docs))
+	}
+
+	query := fmt.Sprintf(`INSERT OR REPLACE INTO %s (id, collection, content, metadata, embedding)
+VALUES (?, ?, ?, ?::JSON, ?::FLOAT[%d])`, s.tableName, s.dimensions)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		if len(vectors[i]) != s.dimensions {
+			return nil, fmt.Errorf("got an embedding of dimension %d, want %d", len(vectors[i]), s.dimensions)
+		}
+		id, ok := doc.Metadata["id"].(string)
+		if !ok || id == "" {
+			id = uuid.New().String()
+		}
+		ids[i] = id
+		metadata, err := marshalMetadata(doc.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		embedding, err := json.Marshal(vectors[i])
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.ExecContext(ctx, query, id, s.collectionOf(opts), doc.PageContent, metadata, string(embedding))
+		if err != nil {
+			return nil, fmt.Errorf("failed to add document %s: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit documents: %w", err)
+	}
+	return ids, nil
+}
+
+// SimilaritySearch returns the numDocuments documents of the collection, or
+// the name space of the options, most similar to query, matching the filters
+// and scoring at least the score threshold. The filters are a map of metadata
+// values the documents must be equal to, or a vectorstores.Filter.
+func (s Store) SimilaritySearch(ctx context.Context,
+	query string,
+	numDocuments int,
+	options ...vectorstores.Option,
+) ([]schema.Document, error) {
+	opts := getOptions(options...)
+	// The query embedding and the collection are the first two arguments.
+	conditions, filterArgs, err := filterConditions(opts.Filters, 3)
+	if err != nil {
+		return nil, err
+	}
+	vector, err := s.embedderOf(opts).EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	embedding, err := json.Marshal(vector)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]any{string(embedding), s.collectionOf(opts)}, filterArgs...)
+	if opts.ScoreThreshold != 0 {
+		args = append(args, s.maxDistance(opts.ScoreThreshold))
+	}
+	args = append(args, numDocuments)
+	rows, err := s.db.QueryContext(ctx, s.searchQuery(conditions, len(filterArgs), opts.ScoreThreshold != 0), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search documents: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []schema.Document
+	for rows.Next() {
+		var content, metadata string
+		var distance float64
+		if err := rows.Scan(&content, &metadata, &distance); err != nil {
+			return nil, err
+		}
+		doc := schema.Document{PageContent: content, Score: s.score(distance)}
+		if err := json.Unmarshal([]byte(metadata), &doc.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+// searchQuery returns the similarity search statement of the documents
+// matching conditions, whose arguments are the query embedding, the
+// collection, the arguments of conditions, the maximum distance when
+// threshold is set, and the number of documents.
+func (s Store) searchQuery(conditions []string, numArgs int, threshold bool) string {
+	distance := fmt.Sprintf("%s(embedding, $1::FLOAT[%d])", distanceFunctions[s.distance], s.dimensions)
+	conditions = append([]string{"collection = $2"}, conditions...)
+	next := 3 + numArgs
+	if threshold {
+		conditions = append(conditions, fmt.Sprintf("%s <= $%d", distance, next))
+		next++
+	}
+	return fmt.Sprintf(`SELECT content, metadata::VARCHAR, %s AS distance
+FROM %s WHERE %s ORDER BY distance LIMIT $%d`, distance, s.tableName, strings.Join(conditions, " AND "), next)
+}
+
+// DeleteDocuments deletes the documents with the given ids, and returns the
+// number of deleted documents.
+func (s Store) DeleteDocuments(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	stmt := fmt.Sprintf(`DELETE FROM %s WHERE id IN (%s)`, s.tableName, placeholders(len(ids)))
+	result, err := s.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete documents: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+// ListEmbeddedDocuments returns at most limit documents of the collection
+// with an id following cursor, ordered by id, along with their embeddings and
+// the cursor of the next documents, empty after the last ones. It lets the
+// store be the source of migrate.Copy.
+func (s Store) ListEmbeddedDocuments(ctx context.Context, cursor string, limit int) ([]migrate.Record, string, error) {
+	if limit <= 0 {
+		return nil, "", errors.New("limit must be positive")
+	}
+	stmt := fmt.Sprintf(`SELECT id, content, metadata::VARCHAR, to_json(embedding)::VARCHAR FROM %s
+WHERE collection = ? AND id > ? ORDER BY id LIMIT ?`, s.tableName)
+	rows, err := s.db.QueryContext(ctx, stmt, s.collection, cursor, limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list documents: %w", err)
+	}
+	defer rows.Close()
+
+	var records []migrate.Record
+	for rows.Next() {
+		var record migrate.Record
+		var metadata, embedding string
+		if err := rows.Scan(&record.ID, &record.Document.PageContent, &metadata, &embedding); err != nil {
+			return nil, "", err
+		}
+		if err := json.Unmarshal([]byte(metadata), &record.Document.Metadata); err != nil {
+			return nil, "", fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+		if err := json.Unmarshal([]byte(embedding), &record.Embedding); err != nil {
+			return nil, "", fmt.Errorf("failed to unmarshal embedding: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(records) == limit {
+		next = records[len(records)-1].ID
+	}
+	return records, next, nil
+}
+
+func (s Store) embedderOf(opts vectorstores.Options) embeddings.Embedder {
+	if opts.Embedder != nil {
+		return opts.Embedder
+	}
+	return s.embedder
+}
+
+func (s Store) collectionOf(opts vectorstores.Options) string {
+	if opts.NameSpace != "" {
+		return opts.NameSpace
+	}
+	return s.collection
+}
+
+// score returns the score of a document at distance from the query, higher
+// for more similar documents.
+func (s Store) score(distance float64) float32 {
+	switch s.distance {
+	case L2:
+		return float32(1 / (1 + distance))
+	case InnerProduct:
+		return float32(-distance)
+	case Cosine:
+	}
+	return float32(1 - distance)
+}
+
+// maxDistance returns the distance of the documents scoring scoreThreshold.
+func (s Store) maxDistance(scoreThreshold float32) float64 {
+	switch s.distance {
+	case L2:
+		return 1/float64(scoreThreshold) - 1
+	case InnerProduct:
+		return -float64(scoreThreshold)
+	case Cosine:
+	}
+	return 1 - float64(scoreThreshold)
+}
+
+func marshalMetadata(metadata map[string]any) (string, error) {
+	if metadata == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	return string(b), nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func getOptions(options ...vectorstores.Option) vectorstores.Options {
+	opts := vectorstores.Options{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
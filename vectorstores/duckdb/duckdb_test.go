@@ -0,0 +1,243 @@
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/vstest"
+)
+
+// statement is a statement run by the recording driver.
+type statement struct {
+	query string
+	args  []any
+}
+
+// recorder records the statements run on its database, and answers the
+// queries with rows.
+type recorder struct {
+	mu         sync.Mutex
+	statements []statement
+	rows       [][]driver.Value
+}
+
+func (r *recorder) record(query string, args []driver.NamedValue) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	r.statements = append(r.statements, statement{query: query, args: values})
+}
+
+type recordingConn struct {
+	r *recorder
+}
+
+func (c recordingConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c recordingConn) Close() error                        { return nil }
+func (c recordingConn) Begin() (driver.Tx, error)           { return c, nil }
+func (c recordingConn) Commit() error                       { return nil }
+func (c recordingConn) Rollback() error                     { return nil }
+
+func (c recordingConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) { // nolint: lll
+	c.r.record(query, args)
+	return driver.RowsAffected(3), nil
+}
+
+func (c recordingConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) { // nolint: lll
+	c.r.record(query, args)
+	return &recordingRows{rows: c.r.rows}, nil
+}
+
+type recordingRows struct {
+	rows [][]driver.Value
+}
+
+func (r *recordingRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *recordingRows) Close() error { return nil }
+
+func (r *recordingRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type recordingConnector struct {
+	r *recorder
+}
+
+func (c recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return recordingConn(c), nil
+}
+func (c recordingConnector) Driver() driver.Driver { return nil }
+
+func newTestStore(t *testing.T, opts ...Option) (Store, *recorder) {
+	t.Helper()
+	r := &recorder{}
+	db := sql.OpenDB(recordingConnector{r: r})
+	t.Cleanup(func() { db.Close() })
+	opts = append([]Option{WithDB(db), WithEmbedder(vstest.Embedder{}), WithVectorDimensions(4)}, opts...)
+	s, err := New(context.Background(), opts...)
+	require.NoError(t, err)
+	return s, r
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	_, r := newTestStore(t, WithTableName("docs"))
+	require.Equal(t, `CREATE TABLE IF NOT EXISTS docs (
+	id VARCHAR PRIMARY KEY,
+	collection VARCHAR NOT NULL,
+	content VARCHAR NOT NULL,
+	metadata JSON NOT NULL,
+	embedding FLOAT[4] NOT NULL
+)`, r.statements[0].query)
+
+	ctx := context.Background()
+	_, err := New(ctx, WithEmbedder(vstest.Embedder{}), WithVectorDimensions(4))
+	require.ErrorIs(t, err, ErrMissingDB)
+	db := sql.OpenDB(recordingConnector{r: &recorder{}})
+	defer db.Close()
+	_, err = New(ctx, WithDB(db), WithVectorDimensions(4))
+	require.ErrorIs(t, err, ErrMissingEmbedder)
+	_, err = New(ctx, WithDB(db), WithEmbedder(vstest.Embedder{}))
+	require.Error(t, err)
+	_, err = New(ctx, WithDB(db), WithEmbedder(vstest.Embedder{}), WithVectorDimensions(4), WithTableName("a-b"))
+	require.Error(t, err)
+	_, err = New(ctx, WithDB(db), WithEmbedder(vstest.Embedder{}), WithVectorDimensions(4), WithDistance("dot"))
+	require.Error(t, err)
+}
+
+func TestAddDocuments(t *testing.T) {
+	t.Parallel()
+	s, r := newTestStore(t)
+	ids, err := s.AddDocuments(context.Background(), []schema.Document{
+		{PageContent: "cat dog", Metadata: map[string]any{"id": "a", "n": 1}},
+		{PageContent: "dog"},
+	}, vectorstores.WithNameSpace("pets"))
+	require.NoError(t, err)
+	require.Equal(t, "a", ids[0])
+	require.Len(t, r.statements, 3)
+	require.Contains(t, r.statements[1].query, "INSERT OR REPLACE INTO langchaingo_documents")
+	require.Equal(t, []any{"a", "pets", "cat dog", `{"id":"a","n":1}`, "[0.70710677,0.70710677,0,0]"}, r.statements[1].args)
+	require.Equal(t, []any{ids[1], "pets", "dog", "{}", "[0,1,0,0]"}, r.statements[2].args)
+
+	_, err = s.AddDocuments(context.Background(), []schema.Document{{PageContent: "x"}},
+		vectorstores.WithEmbedder(embedderFunc(func() []float32 { return []float32{1, 2, 3} })))
+	require.Error(t, err)
+}
+
+type embedderFunc func() []float32
+
+func (f embedderFunc) EmbedDocuments(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = f()
+	}
+	return vectors, nil
+}
+
+func (f embedderFunc) EmbedQuery(context.Context, string) ([]float32, error) { return f(), nil }
+
+func TestSimilaritySearch(t *testing.T) {
+	t.Parallel()
+	s, r := newTestStore(t, WithDistance(L2))
+	r.rows = [][]driver.Value{{"cat", `{"n":1}`, 0.5}}
+	docs, err := s.SimilaritySearch(context.Background(), "cat", 3,
+		vectorstores.WithFilters(map[string]any{"n": 1, "kind": "pet"}), vectorstores.WithScoreThreshold(0.5))
+	require.NoError(t, err)
+	require.Equal(t, []schema.Document{{PageContent: "cat", Metadata: map[string]any{"n": 1.0}, Score: 1 / 1.5}}, docs)
+
+	search := r.statements[1]
+	require.Equal(t, `SELECT content, metadata::VARCHAR, array_distance(embedding, $1::FLOAT[4]) AS distance
+FROM langchaingo_documents WHERE collection = $2 AND json_extract(metadata, $3) = $4::JSON AND json_extract(metadata, $5) = $6::JSON AND array_distance(embedding, $1::FLOAT[4]) <= $7 ORDER BY distance LIMIT $8`, search.query) // nolint: lll
+	require.Equal(t, []any{"[1,0,0,0]", DefaultCollection, `$."kind"`, `"pet"`, `$."n"`, "1", 1.0, int64(3)}, search.args)
+
+	_, err = s.SimilaritySearch(context.Background(), "cat", 3, vectorstores.WithFilters("n = 1"))
+	require.ErrorIs(t, err, ErrInvalidFilters)
+}
+
+func TestSimilaritySearchFilter(t *testing.T) {
+	t.Parallel()
+	s, r := newTestStore(t)
+	_, err := s.SimilaritySearch(context.Background(), "cat", 3, vectorstores.WithFilters(vectorstores.And(
+		vectorstores.Gte("n", 1),
+		vectorstores.Ne("kind", "pet"),
+		vectorstores.Or(vectorstores.Exists("x"), vectorstores.Lt("tags", []any{"a"})),
+	)))
+	require.NoError(t, err)
+
+	search := r.statements[1]
+	require.Contains(t, search.query, `WHERE collection = $2 AND (`+
+		`COALESCE(json_type(metadata, $3) IN ('BIGINT', 'UBIGINT', 'DOUBLE') AND TRY_CAST(json_extract(metadata, $3) AS DOUBLE) >= $4, false) AND `+
+		`(NOT COALESCE(json_type(metadata, $5) = 'VARCHAR' AND json_extract_string(metadata, $5) = $6, false)) AND `+
+		`((json_type(metadata, $7) IS NOT NULL) OR false)) ORDER BY distance LIMIT $8`)
+	require.Equal(t, []any{"[1,0,0,0]", DefaultCollection, `$."n"`, 1.0, `$."kind"`, "pet", `$."x"`, int64(3)}, search.args)
+
+	_, err = s.SimilaritySearch(context.Background(), "cat", 3, vectorstores.WithFilters(vectorstores.Eq(`a"b`, 1)))
+	require.ErrorIs(t, err, ErrInvalidFilters)
+	_, err = s.SimilaritySearch(context.Background(), "cat", 3, vectorstores.WithFilters(vectorstores.Filter{Op: "like"}))
+	require.ErrorIs(t, err, vectorstores.ErrInvalidFilter)
+}
+
+func TestScores(t *testing.T) {
+	t.Parallel()
+	for _, distance := range []Distance{Cosine, L2, InnerProduct} {
+		s := Store{distance: distance}
+		for _, threshold := range []float32{0.2, 0.5, 0.9} {
+			require.InDelta(t, threshold, s.score(s.maxDistance(threshold)), 1e-6, distance)
+		}
+	}
+}
+
+func TestLoadParquet(t *testing.T) {
+	t.Parallel()
+	s, r := newTestStore(t)
+	loaded, err := s.LoadParquet(context.Background(), ParquetSource{
+		Path:            "corpus/*.parquet",
+		IDColumn:        "doc_id",
+		ContentColumn:   "text",
+		MetadataColumns: []string{"source", `it's`},
+		EmbeddingColumn: "vector",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, loaded)
+	require.Equal(t, `INSERT OR REPLACE INTO langchaingo_documents (id, collection, content, metadata, embedding)
+SELECT CAST("doc_id" AS VARCHAR), $1, CAST("text" AS VARCHAR), json_object('source', "source", 'it''s', "it's"), CAST("vector" AS FLOAT[4]) FROM read_parquet($2)`, r.statements[1].query) // nolint: lll
+	require.Equal(t, []any{DefaultCollection, "corpus/*.parquet"}, r.statements[1].args)
+
+	s, r = newTestStore(t)
+	r.rows = [][]driver.Value{{"1", "cat", "{}"}, {"", "dog", `{"source":"b"}`}, {"3", "cat dog", "{}"}}
+	loaded, err = s.LoadParquet(context.Background(), ParquetSource{
+		Path: "corpus.parquet", ContentColumn: "text", MetadataColumns: []string{"source"}, BatchSize: 2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, loaded)
+	require.Equal(t, `SELECT '', CAST("text" AS VARCHAR), CAST(json_object('source', "source") AS VARCHAR) FROM read_parquet($1)`, r.statements[1].query) // nolint: lll
+	// The documents are added in two batches of two and one documents.
+	require.Len(t, r.statements, 5)
+	require.Equal(t, []any{"1", DefaultCollection, "cat", `{"id":"1"}`, "[1,0,0,0]"}, r.statements[2].args)
+	require.Equal(t, `{"source":"b"}`, r.statements[3].args[3])
+	require.Equal(t, "3", r.statements[4].args[0])
+
+	_, err = s.LoadParquet(context.Background(), ParquetSource{Path: "corpus.parquet"})
+	require.Error(t, err)
+}
@@ -0,0 +1,152 @@
+package duckdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// filterConditions returns the SQL conditions matching the documents of
+// filters, a map of metadata values or a vectorstores.Filter, along with
+// their arguments numbered from firstParam.
+func filterConditions(filters any, firstParam int) ([]string, []any, error) {
+	switch f := filters.(type) {
+	case nil:
+		return nil, nil, nil
+	case map[string]any:
+		keys := make([]string, 0, len(f))
+		for key := range f {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		conditions := make([]string, len(keys))
+		args := make([]any, 0, 2*len(keys))
+		for i, key := range keys {
+			path, err := jsonPath(key)
+			if err != nil {
+				return nil, nil, err
+			}
+			value, err := json.Marshal(f[key])
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+			}
+			conditions[i] = fmt.Sprintf("json_extract(metadata, $%d) = $%d::JSON", firstParam+2*i, firstParam+2*i+1)
+			args = append(args, path, string(value))
+		}
+		return conditions, args, nil
+	case vectorstores.Filter:
+		if err := f.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		condition, args, err := filterSQL(f, firstParam, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []string{condition}, args, nil
+	}
+	return nil, nil, fmt.Errorf("%w, got %T", ErrInvalidFilters, filters)
+}
+
+// filterSQL translates f to a condition on the json metadata column, along
+// with args followed by the arguments of the condition, numbered from
+// firstParam. The comparisons check the json type of the metadata value, so
+// that numbers are compared by value and the values of different kinds don't
+// match.
+func filterSQL(f vectorstores.Filter, firstParam int, args []any) (string, []any, error) {
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", firstParam+len(args)-1)
+	}
+	switch f.Op {
+	case vectorstores.FilterAnd, vectorstores.FilterOr:
+		if len(f.Filters) == 0 {
+			return fmt.Sprint(f.Op == vectorstores.FilterAnd), args, nil
+		}
+		conditions := make([]string, len(f.Filters))
+		for i, filter := range f.Filters {
+			var err error
+			if conditions[i], args, err = filterSQL(filter, firstParam, args); err != nil {
+				return "", nil, err
+			}
+		}
+		return "(" + strings.Join(conditions, " "+strings.ToUpper(string(f.Op))+" ") + ")", args, nil
+	case vectorstores.FilterNot:
+		condition, args, err := filterSQL(f.Filters[0], firstParam, args)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(NOT " + condition + ")", args, nil
+	case vectorstores.FilterIn:
+		values, _ := f.Value.([]any)
+		filters := make([]vectorstores.Filter, len(values))
+		for i, value := range values {
+			filters[i] = vectorstores.Eq(f.Key, value)
+		}
+		return filterSQL(vectorstores.Or(filters...), firstParam, args)
+	}
+
+	path, err := jsonPath(f.Key)
+	if err != nil {
+		return "", nil, err
+	}
+	if f.Op == vectorstores.FilterExists {
+		return fmt.Sprintf("(json_type(metadata, %s) IS NOT NULL)", arg(path)), args, nil
+	}
+	// The value is decoded from its json encoding, as the metadata are.
+	encoded, err := json.Marshal(f.Value)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+	}
+	var value any
+	if err := json.Unmarshal(encoded, &value); err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+	}
+
+	op := sqlOps[f.Op]
+	if f.Op == vectorstores.FilterNe {
+		op = "="
+	}
+	var condition string
+	switch v := value.(type) {
+	case float64:
+		p := arg(path)
+		condition = fmt.Sprintf("json_type(metadata, %s) IN ('BIGINT', 'UBIGINT', 'DOUBLE') AND TRY_CAST(json_extract(metadata, %s) AS DOUBLE) %s %s",
+			p, p, op, arg(v))
+	case string:
+		p := arg(path)
+		condition = fmt.Sprintf("json_type(metadata, %s) = 'VARCHAR' AND json_extract_string(metadata, %s) %s %s", p, p, op, arg(v))
+	default:
+		if op != "=" {
+			// The other values are only compared for equality.
+			return "false", args, nil
+		}
+		condition = fmt.Sprintf("json_extract(metadata, %s) = %s::JSON", arg(path), arg(string(encoded)))
+	}
+	// The condition is null when the document doesn't have the key.
+	condition = "COALESCE(" + condition + ", false)"
+	if f.Op == vectorstores.FilterNe {
+		condition = "(NOT " + condition + ")"
+	}
+	return condition, args, nil
+}
+
+// sqlOps are the SQL operators of the comparisons.
+var sqlOps = map[vectorstores.FilterOp]string{ //nolint:gochecknoglobals
+	vectorstores.FilterEq:  "=",
+	vectorstores.FilterGt:  ">",
+	vectorstores.FilterGte: ">=",
+	vectorstores.FilterLt:  "<",
+	vectorstores.FilterLte: "<=",
+}
+
+// jsonPath returns the json path of a metadata key, which can't contain
+// quotes or backslashes.
+func jsonPath(key string) (string, error) {
+	if strings.ContainsAny(key, `"\`) {
+		return "", fmt.Errorf("%w: invalid key %q", ErrInvalidFilters, key)
+	}
+	return fmt.Sprintf(`$."%s"`, key), nil
+}
@@ -0,0 +1,153 @@
+package duckdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+const _defaultParquetBatchSize = 100
+
+// ParquetSource describes the documents of parquet files loaded with
+// LoadParquet.
+type ParquetSource struct {
+	// Path is the path, glob or URL of the parquet files, as accepted by
+	// read_parquet.
+	Path string
+	// ContentColumn is the column of the content of the documents. It must
+	// be set.
+	ContentColumn string
+	// IDColumn is the column of the ids of the documents. Ids are generated
+	// when it isn't set.
+	IDColumn string
+	// MetadataColumns are the columns stored in the metadata of the
+	// documents, under their names.
+	MetadataColumns []string
+	// EmbeddingColumn is the column of the embeddings of the documents, if
+	// they are already embedded. The documents are embedded by the embedder
+	// of the store when it isn't set.
+	EmbeddingColumn string
+	// BatchSize is the number of documents embedded at once when there is
+	// no embedding column. It defaults to 100.
+	BatchSize int
+}
+
+// LoadParquet adds the documents of parquet files to the collection, or the
+// name space of the options, and returns their number. Documents already
+// embedded are copied by a single statement without leaving DuckDB;
+// otherwise they are read and added in batches, with the options, which
+// requires the database to allow a second connection for the additions.
+func (s Store) LoadParquet(ctx context.Context, src ParquetSource, options ...vectorstores.Option) (int, error) {
+	if src.Path == "" || src.ContentColumn == "" {
+		return 0, errors.New("missing parquet path or content column")
+	}
+	if src.EmbeddingColumn != "" {
+		result, err := s.db.ExecContext(ctx, s.insertParquetQuery(src), s.collectionOf(getOptions(options...)), src.Path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load parquet documents: %w", err)
+		}
+		loaded, err := result.RowsAffected()
+		return int(loaded), err
+	}
+
+	batchSize := src.BatchSize
+	if batchSize <= 0 {
+		batchSize = _defaultParquetBatchSize
+	}
+	rows, err := s.db.QueryContext(ctx, selectParquetQuery(src), src.Path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read parquet documents: %w", err)
+	}
+	defer rows.Close()
+
+	loaded := 0
+	batch := make([]schema.Document, 0, batchSize)
+	add := func() error {
+		if _, err := s.AddDocuments(ctx, batch, options...); err != nil {
+			return err
+		}
+		loaded += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for rows.Next() {
+		var id, content, metadata string
+		if err := rows.Scan(&id, &content, &metadata); err != nil {
+			return loaded, err
+		}
+		doc := schema.Document{PageContent: content}
+		if err := json.Unmarshal([]byte(metadata), &doc.Metadata); err != nil {
+			return loaded, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+		if id != "" {
+			doc.Metadata["id"] = id
+		}
+		batch = append(batch, doc)
+		if len(batch) == batchSize {
+			if err := add(); err != nil {
+				return loaded, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return loaded, err
+	}
+	if len(batch) > 0 {
+		if err := add(); err != nil {
+			return loaded, err
+		}
+	}
+	return loaded, nil
+}
+
+// insertParquetQuery returns the statement copying the embedded documents of
+// src, whose arguments are the collection and the path.
+func (s Store) insertParquetQuery(src ParquetSource) string {
+	return fmt.Sprintf(`INSERT OR REPLACE INTO %s (id, collection, content, metadata, embedding)
+SELECT %s, $1, CAST(%s AS VARCHAR), %s, CAST(%s AS FLOAT[%d]) FROM read_parquet($2)`,
+		s.tableName, parquetID(src), quoteIdentifier(src.ContentColumn), parquetMetadata(src),
+		quoteIdentifier(src.EmbeddingColumn), s.dimensions)
+}
+
+// selectParquetQuery returns the statement reading the id, content and
+// metadata of the documents of src, whose argument is the path.
+func selectParquetQuery(src ParquetSource) string {
+	id := "''"
+	if src.IDColumn != "" {
+		id = fmt.Sprintf("CAST(%s AS VARCHAR)", quoteIdentifier(src.IDColumn))
+	}
+	return fmt.Sprintf(`SELECT %s, CAST(%s AS VARCHAR), CAST(%s AS VARCHAR) FROM read_parquet($1)`,
+		id, quoteIdentifier(src.ContentColumn), parquetMetadata(src))
+}
+
+func parquetID(src ParquetSource) string {
+	if src.IDColumn == "" {
+		return "CAST(uuid() AS VARCHAR)"
+	}
+	return fmt.Sprintf("CAST(%s AS VARCHAR)", quoteIdentifier(src.IDColumn))
+}
+
+// parquetMetadata returns the JSON object of the metadata columns of src.
+func parquetMetadata(src ParquetSource) string {
+	if len(src.MetadataColumns) == 0 {
+		return "'{}'::JSON"
+	}
+	pairs := make([]string, len(src.MetadataColumns))
+	for i, column := range src.MetadataColumns {
+		pairs[i] = fmt.Sprintf("%s, %s", quoteLiteral(column), quoteIdentifier(column))
+	}
+	return fmt.Sprintf("json_object(%s)", strings.Join(pairs, ", "))
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,8 @@
+// Package elasticsearch contains an implementation of the VectorStore
+// interface for Elasticsearch 8 and OpenSearch 2, using their REST APIs.
+//
+// Searches are dense kNN searches of the embeddings, BM25 searches of the
+// contents, or hybrid searches fusing both with reciprocal rank fusion
+// (RRF), computed by the store so that they don't depend on the license or
+// the search pipelines of the cluster.
+package elasticsearch
@@ -0,0 +1,517 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/indexes"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+const (
+	contentField   = "content"
+	metadataField  = "metadata"
+	embeddingField = "embedding"
+)
+
+var (
+	// ErrMissingURL is returned by New when the URL of the cluster isn't set.
+	ErrMissingURL = errors.New("missing cluster URL")
+	// ErrMissingEmbedder is returned by New when the embedder isn't set.
+	ErrMissingEmbedder = errors.New("missing embedder")
+	// ErrInvalidFilters is returned when the filters of a search are neither
+	// a map[string]any of metadata values, a vectorstores.Filter nor a []any
+	// of query clauses.
+	ErrInvalidFilters = errors.New(
+		"filters must be a map[string]any of metadata values, a vectorstores.Filter or a []any of query clauses")
+)
+
+// ResponseError is the error response of the cluster.
+type ResponseError struct {
+	StatusCode int
+	// Type is the type of the error, e.g. "resource_already_exists_exception".
+	Type   string
+	Reason string
+}
+
+func (e *ResponseError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("elasticsearch: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("elasticsearch: status %d: %s: %s", e.StatusCode, e.Type, e.Reason)
+}
+
+// Store is a vector store keeping the documents in an Elasticsearch or
+// OpenSearch index, with their content, metadata and embedding fields.
+type Store struct {
+	url           string
+	index         string
+	client        *http.Client
+	authorization string
+	username      string
+	password      string
+	embedder      embeddings.Embedder
+	engine        Engine
+	dimensions    int
+	similarity    Similarity
+	refresh       bool
+}
+
+var (
+	_ vectorstores.VectorStore = Store{}
+	_ indexes.VectorStore      = Store{}
+)
+
+// New creates a new Store with options.
+func New(opts ...Option) (Store, error) {
+	s := Store{
+		index:      DefaultIndex,
+		client:     http.DefaultClient,
+		similarity: Cosine,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.url == "" {
+		return Store{}, ErrMissingURL
+	}
+	if s.embedder == nil {
+		return Store{}, ErrMissingEmbedder
+	}
+	if s.engine != Elasticsearch && s.engine != OpenSearch {
+		return Store{}, fmt.Errorf("invalid engine %d", s.engine)
+	}
+	if _, ok := openSearchSpaceTypes[s.similarity]; !ok {
+		return Store{}, fmt.Errorf("invalid similarity %q", s.similarity)
+	}
+	s.url = strings.TrimSuffix(s.url, "/")
+	return s, nil
+}
+
+// AddDocuments embeds and indexes documents with a single bulk request, and
+// returns their ids. Documents with a string "id" metadata keep it as their
+// id, replacing the indexed document with the same id.
+func (s Store) AddDocuments(ctx context.Context,
+	docs []schema.Document,
+	options ...vectorstores.Option,
+) ([]string, error) {
+	opts := getOptions(options...)
+	if opts.Deduplicater != nil {
+		filtered := make([]schema.Document, 0, len(docs))
+		for _, doc := range docs {
+			if !opts.Deduplicater(ctx, doc) {
+				filtered = append(filtered, doc)
+			}
+		}
+		docs = filtered
+	}
+	if len(docs) == 0 {
+		return []string{}, nil
+	}
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.PageContent
+	}
+	vectors, err := s.embedderOf(opts).EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed documents: %w", err)
+	}
+	if len(vectors) != len(docs) {
+		return nil, fmt.Errorf("got %d embeddings for %d documents", len(vectors), len(docs))
+	}
+
+	ids := make([]string, len(docs))
+	lines := make([]any, 0, 2*len(docs))
+	for i, doc := range docs {
+		id, ok := doc.Metadata["id"].(string)
+		if !ok || id == "" {
+			id = uuid.New().String()
+		}
+		ids[i] = id
+		metadata := doc.Metadata
+		if metadata == nil {
+			metadata = map[string]any{}
+		}
+		lines = append(lines,
+			map[string]any{"index": map[string]any{"_index": s.index, "_id": id}},
+			map[string]any{contentField: doc.PageContent, metadataField: metadata, embeddingField: vectors[i]},
+		)
+	}
+	if _, err := s.bulk(ctx, lines); err != nil {
+		return nil, fmt.Errorf("failed to index documents: %w", err)
+	}
+	return ids, nil
+}
+
+// DeleteDocuments deletes the documents with the given ids with a single bulk
+// request, and returns the number of deleted documents.
+func (s Store) DeleteDocuments(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	lines := make([]any, len(ids))
+	for i, id := range ids {
+		lines[i] = map[string]any{"delete": map[string]any{"_index": s.index, "_id": id}}
+	}
+	items, err := s.bulk(ctx, lines)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete documents: %w", err)
+	}
+	deleted := 0
+	for _, item := range items {
+		if item.Result == "deleted" {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// SimilaritySearch returns the numDocuments documents most relevant to query
+// with the search mode of the options, KNN by default, matching the filters
+// and scoring at least the score threshold. The filters are either a map of
+// metadata values the documents must be equal to, or one of the values when
+// it is a []any, or a []any of query clauses of the cluster.
+func (s Store) SimilaritySearch(ctx context.Context,
+	query string,
+	numDocuments int,
+	options ...vectorstores.Option,
+) ([]schema.Document, error) {
+	opts := getOptions(options...)
+	so := getSearchOptions(opts)
+	filters, err := filterClauses(opts.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []hit
+	switch so.mode {
+	case KNN:
+		results, err = s.knnSearch(ctx, query, numDocuments, so, filters, opts)
+	case BM25:
+		results, err = s.bm25Search(ctx, query, numDocuments, filters)
+	case Hybrid:
+		window := max(so.windowSize, numDocuments)
+		var dense, lexical []hit
+		if dense, err = s.knnSearch(ctx, query, window, so, filters, opts); err != nil {
+			return nil, err
+		}
+		if lexical, err = s.bm25Search(ctx, query, window, filters); err != nil {
+			return nil, err
+		}
+		results = reciprocalRankFusion(so.rankConstant, dense, lexical)
+		results = results[:min(numDocuments, len(results))]
+	default:
+		return nil, fmt.Errorf("invalid search mode %d", so.mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]schema.Document, 0, len(results))
+	for _, result := range results {
+		if opts.ScoreThreshold != 0 && result.Score < opts.ScoreThreshold {
+			continue
+		}
+		docs = append(docs, schema.Document{
+			PageContent: result.Source.Content,
+			Metadata:    result.Source.Metadata,
+			Score:       result.Score,
+		})
+	}
+	return docs, nil
+}
+
+// hit is a document returned by a search.
+type hit struct {
+	ID     string  `json:"_id"`
+	Score  float32 `json:"_score"`
+	Source struct {
+		Content  string         `json:"content"`
+		Metadata map[string]any `json:"metadata"`
+	} `json:"_source"`
+}
+
+// knnSearch returns the k documents with the embeddings nearest to the
+// embedding of query.
+func (s Store) knnSearch(ctx context.Context,
+	query string,
+	k int,
+	so searchOptions,
+	filters []any,
+	opts vectorstores.Options,
+) ([]hit, error) {
+	vector, err := s.embedderOf(opts).EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	return s.search(ctx, s.knnQuery(vector, k, max(so.numCandidates, k), filters))
+}
+
+// knnQuery returns the body of a kNN search in the syntax of the engine.
+func (s Store) knnQuery(vector []float32, k, numCandidates int, filters []any) map[string]any {
+	body := map[string]any{
+		"size":    k,
+		"_source": []string{contentField, metadataField},
+	}
+	if s.engine == OpenSearch {
+		knn := map[string]any{"vector": vector, "k": k}
+		if len(filters) > 0 {
+			knn["filter"] = map[string]any{"bool": map[string]any{"filter": filters}}
+		}
+		body["query"] = map[string]any{"knn": map[string]any{embeddingField: knn}}
+		return body
+	}
+	knn := map[string]any{
+		"field":          embeddingField,
+		"query_vector":   vector,
+		"k":              k,
+		"num_candidates": numCandidates,
+	}
+	if len(filters) > 0 {
+		knn["filter"] = filters
+	}
+	body["knn"] = knn
+	return body
+}
+
+// bm25Search returns the k documents whose content best matches query.
+func (s Store) bm25Search(ctx context.Context, query string, k int, filters []any) ([]hit, error) {
+	boolQuery := map[string]any{
+		"must": map[string]any{"match": map[string]any{contentField: query}},
+	}
+	if len(filters) > 0 {
+		boolQuery["filter"] = filters
+	}
+	return s.search(ctx, map[string]any{
+		"size":    k,
+		"_source": []string{contentField, metadataField},
+		"query":   map[string]any{"bool": boolQuery},
+	})
+}
+
+func (s Store) search(ctx context.Context, body map[string]any) ([]hit, error) {
+	var response struct {
+		Hits struct {
+			Hits []hit `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/"+url.PathEscape(s.index)+"/_search", body, &response); err != nil {
+		return nil, fmt.Errorf("failed to search documents: %w", err)
+	}
+	return response.Hits.Hits, nil
+}
+
+// reciprocalRankFusion fuses ranked lists of documents, scoring every
+// document with the sum of 1 / (rankConstant + rank) over the lists it is
+// in, ranks starting at 1.
+func reciprocalRankFusion(rankConstant int, lists ...[]hit) []hit {
+	scores := map[string]float32{}
+	var fused []hit
+	for _, list := range lists {
+		for i, h := range list {
+			if _, ok := scores[h.ID]; !ok {
+				fused = append(fused, h)
+			}
+			scores[h.ID] += 1 / float32(rankConstant+i+1)
+		}
+	}
+	for i := range fused {
+		fused[i].Score = scores[fused[i].ID]
+	}
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].Score > fused[j].Score
+	})
+	return fused
+}
+
+// filterClauses returns the query clauses of filters.
+func filterClauses(filters any) ([]any, error) {
+	switch f := filters.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return f, nil
+	case map[string]any:
+		keys := make([]string, 0, len(f))
+		for key := range f {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		clauses := make([]any, len(keys))
+		for i, key := range keys {
+			field := metadataField + "." + key
+			if values, ok := f[key].([]any); ok {
+				clauses[i] = map[string]any{"terms": map[string]any{field: values}}
+				continue
+			}
+			clauses[i] = map[string]any{"term": map[string]any{field: f[key]}}
+		}
+		return clauses, nil
+	case vectorstores.Filter:
+		if err := f.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		return []any{filterQuery(f)}, nil
+	}
+	return nil, fmt.Errorf("%w, got %T", ErrInvalidFilters, filters)
+}
+
+// filterQuery translates f to the query DSL. Equality is a term query, so
+// text metadata must be mapped as keyword, as InitIndex does.
+func filterQuery(f vectorstores.Filter) map[string]any {
+	field := metadataField + "." + f.Key
+	switch f.Op {
+	case vectorstores.FilterAnd, vectorstores.FilterOr, vectorstores.FilterNot:
+		clauses := make([]any, len(f.Filters))
+		for i, filter := range f.Filters {
+			clauses[i] = filterQuery(filter)
+		}
+		switch f.Op { //nolint:exhaustive
+		case vectorstores.FilterAnd:
+			return map[string]any{"bool": map[string]any{"filter": clauses}}
+		case vectorstores.FilterOr:
+			return map[string]any{"bool": map[string]any{"should": clauses, "minimum_should_match": 1}}
+		}
+		return map[string]any{"bool": map[string]any{"must_not": clauses}}
+	case vectorstores.FilterEq:
+		return map[string]any{"term": map[string]any{field: f.Value}}
+	case vectorstores.FilterNe:
+		return filterQuery(vectorstores.Not(vectorstores.Eq(f.Key, f.Value)))
+	case vectorstores.FilterIn:
+		return map[string]any{"terms": map[string]any{field: f.Value}}
+	case vectorstores.FilterExists:
+		return map[string]any{"exists": map[string]any{"field": field}}
+	}
+	return map[string]any{"range": map[string]any{field: map[string]any{string(f.Op): f.Value}}}
+}
+
+// bulkItem is the result of an action of a bulk request.
+type bulkItem struct {
+	ID     string `json:"_id"`
+	Status int    `json:"status"`
+	Result string `json:"result"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// bulk sends the lines of a bulk request, and returns the results of its
+// actions or the error of the first failed one.
+func (s Store) bulk(ctx context.Context, lines []any) ([]bulkItem, error) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, line := range lines {
+		if err := encoder.Encode(line); err != nil {
+			return nil, err
+		}
+	}
+	path := "/_bulk"
+	if s.refresh {
+		path += "?refresh=wait_for"
+	}
+	var response struct {
+		Errors bool                  `json:"errors"`
+		Items  []map[string]bulkItem `json:"items"`
+	}
+	if err := s.do(ctx, http.MethodPost, path, body.Bytes(), &response); err != nil {
+		return nil, err
+	}
+	items := make([]bulkItem, 0, len(response.Items))
+	for _, actions := range response.Items {
+		for _, item := range actions {
+			// Deleting a missing document has a "not_found" result, not an
+			// error.
+			if item.Error != nil {
+				return nil, fmt.Errorf("document %s: %w", item.ID,
+					&ResponseError{StatusCode: item.Status, Type: item.Error.Type, Reason: item.Error.Reason})
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// do sends a request with a JSON body, or the NDJSON body of a bulk request
+// when it is a []byte, and decodes the JSON response into out.
+func (s Store) do(ctx context.Context, method, path string, body any, out any) error {
+	var reader io.Reader
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+		contentType = "application/x-ndjson"
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.url+path, reader)
+	if err != nil {
+		return err
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if s.authorization != "" {
+		req.Header.Set("Authorization", s.authorization)
+	} else if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respErr := &ResponseError{StatusCode: resp.StatusCode}
+		var errorBody struct {
+			Error struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &errorBody) == nil {
+			respErr.Type, respErr.Reason = errorBody.Error.Type, errorBody.Error.Reason
+		}
+		return respErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (s Store) embedderOf(opts vectorstores.Options) embeddings.Embedder {
+	if opts.Embedder != nil {
+		return opts.Embedder
+	}
+	return s.embedder
+}
+
+func getOptions(options ...vectorstores.Option) vectorstores.Options {
+	opts := vectorstores.Options{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
@@ -0,0 +1,312 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/vstest"
+)
+
+// request is a request received by the test cluster.
+type request struct {
+	method, path, body string
+	header             http.Header
+}
+
+// cluster records the requests it receives, and answers them with the
+// responses of their paths, or an empty object.
+type cluster struct {
+	mu        sync.Mutex
+	requests  []request
+	responses map[string][]string
+	status    int
+}
+
+func (c *cluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, request{method: r.Method, path: r.URL.RequestURI(), body: string(body), header: r.Header})
+	if c.status != 0 {
+		w.WriteHeader(c.status)
+		_, _ = io.WriteString(w, `{"error":{"type":"resource_already_exists_exception","reason":"exists"}}`)
+		return
+	}
+	response := "{}"
+	if responses := c.responses[r.URL.Path]; len(responses) > 0 {
+		response, c.responses[r.URL.Path] = responses[0], responses[1:]
+	}
+	_, _ = io.WriteString(w, response)
+}
+
+func newTestStore(t *testing.T, opts ...Option) (Store, *cluster) {
+	t.Helper()
+	c := &cluster{responses: map[string][]string{}}
+	server := httptest.NewServer(c)
+	t.Cleanup(server.Close)
+	opts = append([]Option{WithURL(server.URL + "/"), WithEmbedder(vstest.Embedder{}), WithVectorDimensions(4)}, opts...)
+	s, err := New(opts...)
+	require.NoError(t, err)
+	return s, c
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	_, err := New(WithEmbedder(vstest.Embedder{}))
+	require.ErrorIs(t, err, ErrMissingURL)
+	_, err = New(WithURL("http://localhost:9200"))
+	require.ErrorIs(t, err, ErrMissingEmbedder)
+	_, err = New(WithURL("http://localhost:9200"), WithEmbedder(vstest.Embedder{}), WithSimilarity("hamming"))
+	require.Error(t, err)
+}
+
+func TestInitIndex(t *testing.T) {
+	t.Parallel()
+	s, c := newTestStore(t, WithIndex("docs"), WithSimilarity(DotProduct), WithAPIKey("key"))
+	require.NoError(t, s.InitIndex(context.Background(), IndexOptions{Shards: 2, Analyzer: "english"}))
+	require.Equal(t, http.MethodPut, c.requests[0].method)
+	require.Equal(t, "/docs", c.requests[0].path)
+	require.Equal(t, "ApiKey key", c.requests[0].header.Get("Authorization"))
+	require.JSONEq(t, `{
+		"settings": {"index": {"number_of_shards": 2}},
+		"mappings": {
+			"dynamic_templates": [{"metadata_strings": {
+				"path_match": "metadata.*", "match_mapping_type": "string", "mapping": {"type": "keyword"}
+			}}],
+			"properties": {
+				"content": {"type": "text", "analyzer": "english"},
+				"metadata": {"type": "object", "dynamic": true},
+				"embedding": {"type": "dense_vector", "dims": 4, "index": true, "similarity": "dot_product"}
+			}
+		}
+	}`, c.requests[0].body)
+
+	c.status = http.StatusBadRequest
+	require.Error(t, s.InitIndex(context.Background(), IndexOptions{}))
+	require.NoError(t, s.InitIndex(context.Background(), IndexOptions{IfNotExists: true}))
+}
+
+func TestInitIndexTemplateOpenSearch(t *testing.T) {
+	t.Parallel()
+	s, c := newTestStore(t, WithEngine(OpenSearch), WithBasicAuth("admin", "secret"))
+	require.NoError(t, s.InitIndexTemplate(context.Background(), "docs", []string{"docs-*"},
+		IndexOptions{OverwriteExisting: true}))
+	require.Len(t, c.requests, 1)
+	require.Equal(t, "/_index_template/docs", c.requests[0].path)
+	user, password, ok := (&http.Request{Header: c.requests[0].header}).BasicAuth()
+	require.True(t, ok)
+	require.Equal(t, "admin", user)
+	require.Equal(t, "secret", password)
+
+	var body struct {
+		IndexPatterns []string `json:"index_patterns"`
+		Template      struct {
+			Settings map[string]map[string]any `json:"settings"`
+			Mappings struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"mappings"`
+		} `json:"template"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(c.requests[0].body), &body))
+	require.Equal(t, []string{"docs-*"}, body.IndexPatterns)
+	require.Equal(t, map[string]any{"knn": true}, body.Template.Settings["index"])
+	require.Equal(t, map[string]any{
+		"type":      "knn_vector",
+		"dimension": 4.0,
+		"method":    map[string]any{"name": "hnsw", "engine": "lucene", "space_type": "cosinesimil"},
+	}, body.Template.Mappings.Properties["embedding"])
+
+	require.Error(t, s.InitIndexTemplate(context.Background(), "docs", nil, IndexOptions{}))
+}
+
+func TestAddDocuments(t *testing.T) {
+	t.Parallel()
+	s, c := newTestStore(t, WithRefresh(true))
+	c.responses["/_bulk"] = []string{
+		`{"errors":false,"items":[{"index":{"_id":"a","status":201}},{"index":{"_id":"b","status":201}}]}`,
+		`{"errors":true,"items":[{"index":{"_id":"c","status":400,"error":{"type":"mapper_parsing_exception","reason":"bad"}}}]}`, // nolint: lll
+	}
+	ids, err := s.AddDocuments(context.Background(), []schema.Document{
+		{PageContent: "cat dog", Metadata: map[string]any{"id": "a", "kind": "pet"}},
+		{PageContent: "dog"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "a", ids[0])
+	require.Equal(t, "/_bulk?refresh=wait_for", c.requests[0].path)
+	require.Equal(t, "application/x-ndjson", c.requests[0].header.Get("Content-Type"))
+	lines := strings.Split(strings.TrimSuffix(c.requests[0].body, "\n"), "\n")
+	require.Len(t, lines, 4)
+	require.JSONEq(t, `{"index":{"_index":"langchaingo","_id":"a"}}`, lines[0])
+	require.JSONEq(t, `{"content":"cat dog","metadata":{"id":"a","kind":"pet"},"embedding":[0.70710677,0.70710677,0,0]}`, lines[1])
+	require.JSONEq(t, `{"index":{"_index":"langchaingo","_id":"`+ids[1]+`"}}`, lines[2])
+	require.JSONEq(t, `{"content":"dog","metadata":{},"embedding":[0,1,0,0]}`, lines[3])
+
+	_, err = s.AddDocuments(context.Background(), []schema.Document{{PageContent: "cat"}})
+	var respErr *ResponseError
+	require.ErrorAs(t, err, &respErr)
+	require.Equal(t, "mapper_parsing_exception", respErr.Type)
+}
+
+func TestDeleteDocuments(t *testing.T) {
+	t.Parallel()
+	s, c := newTestStore(t)
+	c.responses["/_bulk"] = []string{
+		`{"items":[{"delete":{"_id":"a","status":200,"result":"deleted"}},{"delete":{"_id":"b","status":404,"result":"not_found"}}]}`, // nolint: lll
+	}
+	deleted, err := s.DeleteDocuments(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+	require.Equal(t, `{"delete":{"_id":"a","_index":"langchaingo"}}
+{"delete":{"_id":"b","_index":"langchaingo"}}
+`, c.requests[0].body)
+}
+
+func TestSimilaritySearch(t *testing.T) {
+	t.Parallel()
+	s, c := newTestStore(t)
+	c.responses["/langchaingo/_search"] = []string{
+		`{"hits":{"hits":[{"_id":"a","_score":0.9,"_source":{"content":"cat","metadata":{"kind":"pet"}}},
+			{"_id":"b","_score":0.4,"_source":{"content":"dog","metadata":{}}}]}}`,
+	}
+	docs, err := s.SimilaritySearch(context.Background(), "cat", 2,
+		vectorstores.WithFilters(map[string]any{"kind": "pet", "age": []any{1, 2}}),
+		vectorstores.WithScoreThreshold(0.5))
+	require.NoError(t, err)
+	require.Equal(t, []schema.Document{{PageContent: "cat", Metadata: map[string]any{"kind": "pet"}, Score: 0.9}}, docs)
+	require.JSONEq(t, `{
+		"size": 2,
+		"_source": ["content", "metadata"],
+		"knn": {
+			"field": "embedding", "query_vector": [1, 0, 0, 0], "k": 2, "num_candidates": 100,
+			"filter": [{"terms": {"metadata.age": [1, 2]}}, {"term": {"metadata.kind": "pet"}}]
+		}
+	}`, c.requests[0].body)
+
+	_, err = s.SimilaritySearch(context.Background(), "cat", 2, vectorstores.WithFilters("kind:pet"))
+	require.ErrorIs(t, err, ErrInvalidFilters)
+}
+
+func TestFilterQuery(t *testing.T) {
+	t.Parallel()
+	f := vectorstores.And(
+		vectorstores.Eq("kind", "pet"),
+		vectorstores.Or(vectorstores.Gte("legs", 4), vectorstores.Exists("indoor")),
+		vectorstores.In("color", "red", "blue"),
+		vectorstores.Ne("n", 1),
+	)
+	clauses, err := filterClauses(f)
+	require.NoError(t, err)
+	data, err := json.Marshal(clauses)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"bool": {"filter": [
+		{"term": {"metadata.kind": "pet"}},
+		{"bool": {"should": [
+			{"range": {"metadata.legs": {"gte": 4}}},
+			{"exists": {"field": "metadata.indoor"}}
+		], "minimum_should_match": 1}},
+		{"terms": {"metadata.color": ["red", "blue"]}},
+		{"bool": {"must_not": [{"term": {"metadata.n": 1}}]}}
+	]}}]`, string(data))
+
+	_, err = filterClauses(vectorstores.Filter{Op: "like"})
+	require.ErrorIs(t, err, ErrInvalidFilters)
+}
+
+func TestSimilaritySearchOpenSearch(t *testing.T) {
+	t.Parallel()
+	s, c := newTestStore(t, WithEngine(OpenSearch))
+	_, err := s.SimilaritySearch(context.Background(), "dog", 3,
+		vectorstores.WithFilters([]any{map[string]any{"range": map[string]any{"metadata.age": map[string]any{"gte": 2}}}}))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"size": 3,
+		"_source": ["content", "metadata"],
+		"query": {"knn": {"embedding": {
+			"vector": [0, 1, 0, 0], "k": 3,
+			"filter": {"bool": {"filter": [{"range": {"metadata.age": {"gte": 2}}}]}}
+		}}}
+	}`, c.requests[0].body)
+}
+
+func TestSimilaritySearchBM25(t *testing.T) {
+	t.Parallel()
+	s, c := newTestStore(t)
+	_, err := s.SimilaritySearch(context.Background(), "cat", 2, WithSearchMode(BM25),
+		vectorstores.WithFilters(map[string]any{"kind": "pet"}))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"size": 2,
+		"_source": ["content", "metadata"],
+		"query": {"bool": {"must": {"match": {"content": "cat"}}, "filter": [{"term": {"metadata.kind": "pet"}}]}}
+	}`, c.requests[0].body)
+}
+
+func TestSimilaritySearchHybrid(t *testing.T) {
+	t.Parallel()
+	s, c := newTestStore(t)
+	c.responses["/langchaingo/_search"] = []string{
+		`{"hits":{"hits":[{"_id":"a","_source":{"content":"a"}},{"_id":"b","_source":{"content":"b"}}]}}`,
+		`{"hits":{"hits":[{"_id":"c","_source":{"content":"c"}},{"_id":"b","_source":{"content":"b"}}]}}`,
+	}
+	docs, err := s.SimilaritySearch(context.Background(), "cat", 2,
+		WithSearchMode(Hybrid), WithRRF(1, 4), WithNumCandidates(10))
+	require.NoError(t, err)
+	require.Len(t, c.requests, 2)
+	require.Contains(t, c.requests[0].body, `"k":4`)
+	require.Contains(t, c.requests[1].body, `"size":4`)
+	// b is ranked second by both searches: 1/3 + 1/3 beats 1/2.
+	require.Equal(t, []schema.Document{
+		{PageContent: "b", Score: 2.0 / 3},
+		{PageContent: "a", Score: 0.5},
+	}, docs)
+}
+
+func TestReciprocalRankFusion(t *testing.T) {
+	t.Parallel()
+	fused := reciprocalRankFusion(60,
+		[]hit{{ID: "a"}, {ID: "b"}, {ID: "c"}},
+		[]hit{{ID: "c"}, {ID: "d"}},
+	)
+	ids := make([]string, len(fused))
+	for i, h := range fused {
+		ids[i] = h.ID
+	}
+	require.Equal(t, []string{"c", "a", "b", "d"}, ids)
+	require.InDelta(t, 1.0/63+1.0/61, fused[0].Score, 1e-6)
+}
+
+// TestConformance runs the conformance suite against the cluster at
+// ELASTICSEARCH_URL, as the test cluster of the other tests only answers
+// canned responses.
+func TestConformance(t *testing.T) {
+	t.Parallel()
+	clusterURL := os.Getenv("ELASTICSEARCH_URL")
+	if clusterURL == "" {
+		t.Skip("ELASTICSEARCH_URL environment variable not set")
+	}
+	vstest.Run(t, vstest.Config{
+		NewStore: func(t *testing.T, embedder embeddings.Embedder) vectorstores.VectorStore {
+			t.Helper()
+			ctx := context.Background()
+			s, err := New(WithURL(clusterURL), WithEmbedder(embedder), WithVectorDimensions(len(vstest.Vocabulary)),
+				WithIndex("vstest-"+uuid.New().String()), WithRefresh(true))
+			require.NoError(t, err)
+			require.NoError(t, s.InitIndex(ctx, IndexOptions{}))
+			t.Cleanup(func() { _ = s.DeleteIndex(ctx) })
+			return s
+		},
+		Filters: true,
+	})
+}
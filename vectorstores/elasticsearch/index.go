@@ -0,0 +1,146 @@
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// openSearchSpaceTypes maps the similarities to the space types of
+// OpenSearch.
+var openSearchSpaceTypes = map[Similarity]string{ // nolint: gochecknoglobals
+	Cosine:     "cosinesimil",
+	DotProduct: "innerproduct",
+	L2Norm:     "l2",
+}
+
+// IndexOptions is used with InitIndex and InitIndexTemplate.
+type IndexOptions struct {
+	// Shards and Replicas are the numbers of primary shards and replicas of
+	// the index. The defaults of the cluster are used when they are zero.
+	Shards   int
+	Replicas int
+	// Analyzer is the analyzer of the contents, used by BM25 searches. The
+	// default analyzer of the cluster is used when it isn't set.
+	Analyzer string
+	// OverwriteExisting deletes the existing index before creating it.
+	OverwriteExisting bool
+	// IfNotExists keeps an existing index instead of failing to create it.
+	IfNotExists bool
+}
+
+// InitIndex creates the index of the store, mapping the contents as text,
+// the string metadata as keywords so that they can be filtered on, and the
+// embeddings as vectors of the dimension and similarity of the store.
+func (s Store) InitIndex(ctx context.Context, opts IndexOptions) error {
+	body, err := s.indexBody(opts)
+	if err != nil {
+		return err
+	}
+	if opts.OverwriteExisting {
+		if err := s.DeleteIndex(ctx); err != nil {
+			return err
+		}
+	}
+	err = s.do(ctx, http.MethodPut, "/"+url.PathEscape(s.index), body, nil)
+	var respErr *ResponseError
+	if opts.IfNotExists && errors.As(err, &respErr) && respErr.Type == "resource_already_exists_exception" {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	return nil
+}
+
+// InitIndexTemplate creates or replaces the index template name, applying
+// the settings and mappings of InitIndex to the indices created with names
+// matching the patterns, e.g. the indices of time based collections.
+// OverwriteExisting and IfNotExists are ignored.
+func (s Store) InitIndexTemplate(ctx context.Context, name string, patterns []string, opts IndexOptions) error {
+	if len(patterns) == 0 {
+		return errors.New("missing index patterns")
+	}
+	template, err := s.indexBody(opts)
+	if err != nil {
+		return err
+	}
+	body := map[string]any{
+		"index_patterns": patterns,
+		"template":       template,
+	}
+	if err := s.do(ctx, http.MethodPut, "/_index_template/"+url.PathEscape(name), body, nil); err != nil {
+		return fmt.Errorf("failed to create index template: %w", err)
+	}
+	return nil
+}
+
+// DeleteIndex deletes the index of the store, if it exists.
+func (s Store) DeleteIndex(ctx context.Context) error {
+	err := s.do(ctx, http.MethodDelete, "/"+url.PathEscape(s.index), nil, nil)
+	var respErr *ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete index: %w", err)
+	}
+	return nil
+}
+
+// indexBody returns the settings and mappings of the index.
+func (s Store) indexBody(opts IndexOptions) (map[string]any, error) {
+	if s.dimensions <= 0 {
+		return nil, errors.New("missing vector dimensions")
+	}
+	settings := map[string]any{}
+	if opts.Shards > 0 {
+		settings["number_of_shards"] = opts.Shards
+	}
+	if opts.Replicas > 0 {
+		settings["number_of_replicas"] = opts.Replicas
+	}
+
+	content := map[string]any{"type": "text"}
+	if opts.Analyzer != "" {
+		content["analyzer"] = opts.Analyzer
+	}
+	embedding := map[string]any{
+		"type":       "dense_vector",
+		"dims":       s.dimensions,
+		"index":      true,
+		"similarity": string(s.similarity),
+	}
+	if s.engine == OpenSearch {
+		settings["knn"] = true
+		embedding = map[string]any{
+			"type":      "knn_vector",
+			"dimension": s.dimensions,
+			"method": map[string]any{
+				"name":       "hnsw",
+				"engine":     "lucene",
+				"space_type": openSearchSpaceTypes[s.similarity],
+			},
+		}
+	}
+
+	return map[string]any{
+		"settings": map[string]any{"index": settings},
+		"mappings": map[string]any{
+			"dynamic_templates": []any{
+				map[string]any{"metadata_strings": map[string]any{
+					"path_match":         metadataField + ".*",
+					"match_mapping_type": "string",
+					"mapping":            map[string]any{"type": "keyword"},
+				}},
+			},
+			"properties": map[string]any{
+				contentField:   content,
+				metadataField:  map[string]any{"type": "object", "dynamic": true},
+				embeddingField: embedding,
+			},
+		},
+	}, nil
+}
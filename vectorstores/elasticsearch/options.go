@@ -0,0 +1,204 @@
+package elasticsearch
+
+import (
+	"net/http"
+
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+const (
+	// DefaultIndex is the default index of the documents.
+	DefaultIndex = "langchaingo"
+
+	_defaultRRFRankConstant = 60
+	_defaultNumCandidates   = 100
+)
+
+// Engine is the search engine of the cluster.
+type Engine int
+
+const (
+	// Elasticsearch is Elasticsearch 8, storing the embeddings as
+	// dense_vector fields.
+	Elasticsearch Engine = iota
+	// OpenSearch is OpenSearch 2, storing the embeddings as knn_vector
+	// fields.
+	OpenSearch
+)
+
+// Similarity is the similarity of the embeddings, in the terms of
+// Elasticsearch. It is translated to the space type of OpenSearch.
+type Similarity string
+
+const (
+	Cosine     Similarity = "cosine"
+	DotProduct Similarity = "dot_product"
+	L2Norm     Similarity = "l2_norm"
+)
+
+// Option is a function for configuring the Store.
+type Option func(s *Store)
+
+// WithURL sets the URL of the cluster, e.g. "https://localhost:9200". It
+// must be set.
+func WithURL(url string) Option {
+	return func(s *Store) {
+		s.url = url
+	}
+}
+
+// WithIndex sets the index of the documents. It defaults to DefaultIndex.
+func WithIndex(index string) Option {
+	return func(s *Store) {
+		s.index = index
+	}
+}
+
+// WithEmbedder sets the embedder of the documents and queries. It must be
+// set.
+func WithEmbedder(embedder embeddings.Embedder) Option {
+	return func(s *Store) {
+		s.embedder = embedder
+	}
+}
+
+// WithEngine sets the search engine of the cluster. It defaults to
+// Elasticsearch.
+func WithEngine(engine Engine) Option {
+	return func(s *Store) {
+		s.engine = engine
+	}
+}
+
+// WithHTTPClient sets the HTTP client of the requests, e.g. with the TLS
+// configuration of the cluster. It defaults to http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Store) {
+		s.client = client
+	}
+}
+
+// WithAPIKey authenticates the requests with an Elasticsearch API key.
+func WithAPIKey(apiKey string) Option {
+	return func(s *Store) {
+		s.authorization = "ApiKey " + apiKey
+	}
+}
+
+// WithBasicAuth authenticates the requests with a user name and password.
+func WithBasicAuth(username, password string) Option {
+	return func(s *Store) {
+		s.username, s.password = username, password
+	}
+}
+
+// WithVectorDimensions sets the dimension of the embeddings, used by
+// InitIndex. It must be set to create the index.
+func WithVectorDimensions(dimensions int) Option {
+	return func(s *Store) {
+		s.dimensions = dimensions
+	}
+}
+
+// WithSimilarity sets the similarity of the embeddings, used by InitIndex.
+// It defaults to Cosine.
+func WithSimilarity(similarity Similarity) Option {
+	return func(s *Store) {
+		s.similarity = similarity
+	}
+}
+
+// WithRefresh makes the additions and deletions wait for the index to be
+// refreshed, so that they are visible to the following searches.
+func WithRefresh(refresh bool) Option {
+	return func(s *Store) {
+		s.refresh = refresh
+	}
+}
+
+// SearchMode is the kind of search of SimilaritySearch.
+type SearchMode int
+
+const (
+	// KNN searches the documents with the embeddings nearest to the
+	// embedding of the query.
+	KNN SearchMode = iota
+	// BM25 searches the documents whose content matches the query.
+	BM25
+	// Hybrid fuses the results of the KNN and BM25 searches with reciprocal
+	// rank fusion.
+	Hybrid
+)
+
+// searchOptionsKey is the vectorstores.Options.Extra key holding the
+// searchOptions of this package.
+type searchOptionsKey struct{}
+
+// searchOptions holds the options of a single search.
+type searchOptions struct {
+	mode SearchMode
+	// numCandidates is the number of candidates of each shard of kNN
+	// searches.
+	numCandidates int
+	// rankConstant and windowSize are the parameters of the reciprocal rank
+	// fusion of hybrid searches.
+	rankConstant int
+	windowSize   int
+}
+
+// withSearchOptions returns a vectorstores.Option that modifies the search
+// options of this package.
+func withSearchOptions(fn func(*searchOptions)) vectorstores.Option {
+	return func(o *vectorstores.Options) {
+		if o.Extra == nil {
+			o.Extra = map[any]any{}
+		}
+		so, ok := o.Extra[searchOptionsKey{}].(*searchOptions)
+		if !ok {
+			so = &searchOptions{}
+			o.Extra[searchOptionsKey{}] = so
+		}
+		fn(so)
+	}
+}
+
+func getSearchOptions(opts vectorstores.Options) searchOptions {
+	so := searchOptions{}
+	if o, ok := opts.Extra[searchOptionsKey{}].(*searchOptions); ok {
+		so = *o
+	}
+	if so.numCandidates <= 0 {
+		so.numCandidates = _defaultNumCandidates
+	}
+	if so.rankConstant <= 0 {
+		so.rankConstant = _defaultRRFRankConstant
+	}
+	return so
+}
+
+// WithSearchMode sets the kind of search. It defaults to KNN.
+func WithSearchMode(mode SearchMode) vectorstores.Option {
+	return withSearchOptions(func(so *searchOptions) {
+		so.mode = mode
+	})
+}
+
+// WithNumCandidates sets the number of candidates considered by each shard
+// in kNN searches, trading speed for accuracy. It defaults to 100, or the
+// number of documents when it is greater.
+func WithNumCandidates(numCandidates int) vectorstores.Option {
+	return withSearchOptions(func(so *searchOptions) {
+		so.numCandidates = numCandidates
+	})
+}
+
+// WithRRF sets the parameters of the reciprocal rank fusion of hybrid
+// searches: the rank constant, 60 by default, and the number of documents
+// of each search fused, the number of documents by default.
+func WithRRF(rankConstant, windowSize int) vectorstores.Option {
+	return withSearchOptions(func(so *searchOptions) {
+		so.rankConstant = rankConstant
+		so.windowSize = windowSize
+	})
+}
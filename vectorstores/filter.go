@@ -0,0 +1,257 @@
+package vectorstores
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrInvalidFilter is returned when a Filter is malformed.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// FilterOp is the operator of a Filter.
+type FilterOp string
+
+const (
+	// FilterEq matches the documents whose metadata value equals the value.
+	FilterEq FilterOp = "eq"
+	// FilterNe matches the documents whose metadata value doesn't equal the
+	// value, including the documents without the key.
+	FilterNe FilterOp = "ne"
+	// FilterGt matches the documents whose metadata value is greater than
+	// the value.
+	FilterGt FilterOp = "gt"
+	// FilterGte matches the documents whose metadata value is greater than
+	// or equal to the value.
+	FilterGte FilterOp = "gte"
+	// FilterLt matches the documents whose metadata value is less than the
+	// value.
+	FilterLt FilterOp = "lt"
+	// FilterLte matches the documents whose metadata value is less than or
+	// equal to the value.
+	FilterLte FilterOp = "lte"
+	// FilterIn matches the documents whose metadata value equals one of the
+	// values.
+	FilterIn FilterOp = "in"
+	// FilterExists matches the documents with the metadata key.
+	FilterExists FilterOp = "exists"
+	// FilterAnd matches the documents matching all the filters.
+	FilterAnd FilterOp = "and"
+	// FilterOr matches the documents matching any of the filters.
+	FilterOr FilterOp = "or"
+	// FilterNot matches the documents not matching the filter.
+	FilterNot FilterOp = "not"
+)
+
+// Filter is a backend agnostic filter on the metadata of documents, built
+// with Eq, Ne, Gt, Gte, Lt, Lte, In, Exists, And, Or and Not. It can be
+// passed to WithFilters in place of the backend specific filters of the
+// stores supporting it, which translate it to their own query language.
+//
+// Numbers are compared by value whatever their Go type, strings in
+// lexicographic order, and the other values for equality only: ordering
+// comparisons between values of different kinds don't match.
+type Filter struct {
+	Op FilterOp `json:"op"`
+	// Key is the metadata key of the comparisons, In and Exists.
+	Key string `json:"key,omitempty"`
+	// Value is the value of the comparisons, and the []any of values of In.
+	Value any `json:"value,omitempty"`
+	// Filters are the operands of And, Or and Not.
+	Filters []Filter `json:"filters,omitempty"`
+}
+
+// Eq returns a Filter matching the documents whose metadata value for key
+// equals value.
+func Eq(key string, value any) Filter { return Filter{Op: FilterEq, Key: key, Value: value} }
+
+// Ne returns a Filter matching the documents whose metadata value for key
+// doesn't equal value, or which don't have the key.
+func Ne(key string, value any) Filter { return Filter{Op: FilterNe, Key: key, Value: value} }
+
+// Gt returns a Filter matching the documents whose metadata value for key is
+// greater than value.
+func Gt(key string, value any) Filter { return Filter{Op: FilterGt, Key: key, Value: value} }
+
+// Gte returns a Filter matching the documents whose metadata value for key
+// is greater than or equal to value.
+func Gte(key string, value any) Filter { return Filter{Op: FilterGte, Key: key, Value: value} }
+
+// Lt returns a Filter matching the documents whose metadata value for key is
+// less than value.
+func Lt(key string, value any) Filter { return Filter{Op: FilterLt, Key: key, Value: value} }
+
+// Lte returns a Filter matching the documents whose metadata value for key
+// is less than or equal to value.
+func Lte(key string, value any) Filter { return Filter{Op: FilterLte, Key: key, Value: value} }
+
+// In returns a Filter matching the documents whose metadata value for key
+// equals one of values.
+func In(key string, values ...any) Filter { return Filter{Op: FilterIn, Key: key, Value: values} }
+
+// Exists returns a Filter matching the documents with the metadata key.
+func Exists(key string) Filter { return Filter{Op: FilterExists, Key: key} }
+
+// And returns a Filter matching the documents matching all filters, all the
+// documents when there are none.
+func And(filters ...Filter) Filter { return Filter{Op: FilterAnd, Filters: filters} }
+
+// Or returns a Filter matching the documents matching any of filters, none
+// when there are none.
+func Or(filters ...Filter) Filter { return Filter{Op: FilterOr, Filters: filters} }
+
+// Not returns a Filter matching the documents not matching filter.
+func Not(filter Filter) Filter { return Filter{Op: FilterNot, Filters: []Filter{filter}} }
+
+// Validate returns an error wrapping ErrInvalidFilter if f is malformed.
+func (f Filter) Validate() error {
+	switch f.Op {
+	case FilterEq, FilterNe, FilterGt, FilterGte, FilterLt, FilterLte, FilterExists:
+		if f.Key == "" {
+			return fmt.Errorf("%w: %s without a key", ErrInvalidFilter, f.Op)
+		}
+	case FilterIn:
+		if f.Key == "" {
+			return fmt.Errorf("%w: %s without a key", ErrInvalidFilter, f.Op)
+		}
+		if _, ok := f.Value.([]any); !ok {
+			return fmt.Errorf("%w: %s values must be a []any, got %T", ErrInvalidFilter, f.Op, f.Value)
+		}
+	case FilterNot:
+		if len(f.Filters) != 1 {
+			return fmt.Errorf("%w: %s takes one filter, got %d", ErrInvalidFilter, f.Op, len(f.Filters))
+		}
+	case FilterAnd, FilterOr:
+	default:
+		return fmt.Errorf("%w: unknown operator %q", ErrInvalidFilter, f.Op)
+	}
+	for _, filter := range f.Filters {
+		if err := filter.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match reports whether metadata matches f, which must be valid.
+func (f Filter) Match(metadata map[string]any) bool {
+	switch f.Op {
+	case FilterAnd:
+		for _, filter := range f.Filters {
+			if !filter.Match(metadata) {
+				return false
+			}
+		}
+		return true
+	case FilterOr:
+		for _, filter := range f.Filters {
+			if filter.Match(metadata) {
+				return true
+			}
+		}
+		return false
+	case FilterNot:
+		return !f.Filters[0].Match(metadata)
+	case FilterNe:
+		return !Eq(f.Key, f.Value).Match(metadata)
+	}
+
+	value, ok := metadata[f.Key]
+	if !ok {
+		return false
+	}
+	switch f.Op {
+	case FilterExists:
+		return true
+	case FilterEq:
+		return filterValuesEqual(value, f.Value)
+	case FilterIn:
+		values, _ := f.Value.([]any)
+		for _, v := range values {
+			if filterValuesEqual(value, v) {
+				return true
+			}
+		}
+		return false
+	}
+	c, ok := compareFilterValues(value, f.Value)
+	if !ok {
+		return false
+	}
+	switch f.Op {
+	case FilterGt:
+		return c > 0
+	case FilterGte:
+		return c >= 0
+	case FilterLt:
+		return c < 0
+	case FilterLte:
+		return c <= 0
+	}
+	return false
+}
+
+// filterValuesEqual reports whether a and b are equal, numbers being
+// compared by value and the other values by their JSON encoding if they
+// aren't deeply equal, e.g. a []string and the []any of a decoded document.
+func filterValuesEqual(a, b any) bool {
+	if c, ok := compareFilterValues(a, b); ok {
+		return c == 0
+	}
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// compareFilterValues compares a and b if they are both numbers or both
+// strings.
+func compareFilterValues(a, b any) (int, bool) {
+	if x, ok := filterNumber(a); ok {
+		y, ok := filterNumber(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	x, okA := a.(string)
+	y, okB := b.(string)
+	if !okA || !okB {
+		return 0, false
+	}
+	switch {
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	}
+	return 0, true
+}
+
+// filterNumber returns v as a float64 if it is a number.
+func filterNumber(v any) (float64, bool) {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() { //nolint:exhaustive
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
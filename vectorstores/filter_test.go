@@ -0,0 +1,88 @@
+package vectorstores
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterMatch(t *testing.T) {
+	t.Parallel()
+	metadata := map[string]any{"kind": "pet", "legs": 4, "weight": 3.5, "tags": []any{"a", "b"}, "indoor": true}
+
+	tests := []struct {
+		filter Filter
+		match  bool
+	}{
+		{Eq("kind", "pet"), true},
+		{Eq("kind", "food"), false},
+		{Eq("legs", 4.0), true},
+		{Eq("legs", "4"), false},
+		{Eq("tags", []string{"a", "b"}), true},
+		{Eq("missing", nil), false},
+		{Ne("kind", "food"), true},
+		{Ne("missing", 1), true},
+		{Gt("legs", 3), true},
+		{Gt("legs", 4), false},
+		{Gte("legs", int64(4)), true},
+		{Lt("weight", 4), true},
+		{Lte("weight", 3), false},
+		{Gt("kind", "food"), true},
+		{Gt("kind", 1), false},
+		{Lt("missing", 1), false},
+		{In("kind", "food", "pet"), true},
+		{In("legs", 2, 8), false},
+		{In("kind"), false},
+		{Exists("indoor"), true},
+		{Exists("missing"), false},
+		{And(), true},
+		{And(Eq("kind", "pet"), Gt("legs", 2)), true},
+		{And(Eq("kind", "pet"), Gt("legs", 4)), false},
+		{Or(), false},
+		{Or(Eq("kind", "food"), Eq("indoor", true)), true},
+		{Not(Exists("missing")), true},
+		{Not(Or(Eq("kind", "pet"), Exists("missing"))), false},
+	}
+	for _, tt := range tests {
+		require.NoError(t, tt.filter.Validate())
+		require.Equalf(t, tt.match, tt.filter.Match(metadata), "%+v", tt.filter)
+	}
+
+	// Metadata decoded from JSON matches the same way.
+	var decoded map[string]any
+	data, err := json.Marshal(metadata)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	for _, tt := range tests {
+		require.Equalf(t, tt.match, tt.filter.Match(decoded), "%+v", tt.filter)
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	t.Parallel()
+	for _, f := range []Filter{
+		{Op: "like", Key: "kind"},
+		Eq("", "pet"),
+		{Op: FilterIn, Key: "kind", Value: "pet"},
+		{Op: FilterNot},
+		And(Eq("kind", "pet"), Exists("")),
+	} {
+		require.ErrorIs(t, f.Validate(), ErrInvalidFilter)
+	}
+}
+
+func TestFilterJSON(t *testing.T) {
+	t.Parallel()
+	f := And(Eq("kind", "pet"), Not(In("legs", 2.0, 4.0)))
+	data, err := json.Marshal(f)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"op": "and", "filters": [
+		{"op": "eq", "key": "kind", "value": "pet"},
+		{"op": "not", "filters": [{"op": "in", "key": "legs", "value": [2, 4]}]}
+	]}`, string(data))
+
+	var decoded Filter
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, f, decoded)
+}
@@ -0,0 +1,4 @@
+// Package inmemory contains an implementation of the VectorStore interface
+// keeping the documents and their embeddings in memory, for unit tests of
+// chains and retrievers and for prototypes, without a database.
+package inmemory
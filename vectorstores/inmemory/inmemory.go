@@ -0,0 +1,378 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/indexes"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/migrate"
+)
+
+const _mmrFetchFactor = 4
+
+var (
+	// ErrMissingEmbedder is returned by New when the embedder isn't set.
+	ErrMissingEmbedder = errors.New("missing embedder")
+	// ErrInvalidFilters is returned when the filters of a search are neither
+	// a map[string]any, a vectorstores.Filter nor a func(schema.Document) bool.
+	ErrInvalidFilters = errors.New("filters must be a map[string]any, a vectorstores.Filter or a func(schema.Document) bool")
+	// ErrDimensionMismatch is returned when adding a document whose embedding
+	// doesn't have the dimension of the stored ones.
+	ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+)
+
+// document is a stored document along with its embedding.
+type document struct {
+	id        string
+	nameSpace string
+	doc       schema.Document
+	embedding []float32
+}
+
+// Store is a vector store keeping the documents in memory. It is safe for
+// concurrent use.
+type Store struct {
+	embedder embeddings.Embedder
+	distance Distance
+
+	mu sync.RWMutex
+	// docs are the documents in insertion order, indexed by id in ids.
+	docs []document
+	ids  map[string]int
+}
+
+var (
+	_ vectorstores.VectorStore = &Store{}
+	_ indexes.VectorStore      = &Store{}
+	_ migrate.Source           = &Store{}
+)
+
+// New creates a new empty Store with options.
+func New(opts ...Option) (*Store, error) {
+	s := &Store{ids: map[string]int{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.embedder == nil {
+		return nil, ErrMissingEmbedder
+	}
+	if s.distance != Cosine && s.distance != L2 {
+		return nil, fmt.Errorf("invalid distance %d", s.distance)
+	}
+	return s, nil
+}
+
+// AddDocuments embeds and adds documents to the name space of the options,
+// and returns their ids. Documents with a string "id" metadata keep it as
+// their id, replacing the stored document with the same id.
+func (s *Store) AddDocuments(ctx context.Context,
+	docs []schema.Document,
+	options ...vectorstores.Option,
+) ([]string, error) {
+	opts := getOptions(options...)
+	if opts.Deduplicater != nil {
+		docs = slices.DeleteFunc(slices.Clone(docs), func(doc schema.Document) bool {
+			return opts.Deduplicater(ctx, doc)
+		})
+	}
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.PageContent
+	}
+	vectors, err := s.embedderOf(opts).EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed documents: %w", err)
+	}
+	if len(vectors) != len(docs) {
+		return nil, fmt.Errorf("got %d embeddings for %d documents", len(vectors), len(docs))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dimension := 0
+	if len(s.docs) > 0 {
+		dimension = len(s.docs[0].embedding)
+	}
+	for _, vector := range vectors {
+		if dimension == 0 {
+			dimension = len(vector)
+		}
+		if len(vector) != dimension {
+			return nil, fmt.Errorf("%w: got %d, want %d", ErrDimensionMismatch, len(vector), dimension)
+		}
+	}
+
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		id, ok := doc.Metadata["id"].(string)
+		if !ok || id == "" {
+			id = uuid.New().String()
+		}
+		ids[i] = id
+		doc.Metadata = maps.Clone(doc.Metadata)
+		stored := document{id: id, nameSpace: opts.NameSpace, doc: doc, embedding: slices.Clone(vectors[i])}
+		if j, ok := s.ids[id]; ok {
+			s.docs[j] = stored
+			continue
+		}
+		s.ids[id] = len(s.docs)
+		s.docs = append(s.docs, stored)
+	}
+	return ids, nil
+}
+
+// SimilaritySearch returns the numDocuments documents of the name space of
+// the options most similar to query, matching the filters and scoring at
+// least the score threshold. The filters are either a map of metadata values
+// the documents must be equal to, or a function returning whether a document
+// matches. WithMMR reranks the documents by maximal marginal relevance.
+func (s *Store) SimilaritySearch(ctx context.Context,
+	query string,
+	numDocuments int,
+	options ...vectorstores.Option,
+) ([]schema.Document, error) {
+	opts := getOptions(options...)
+	match, err := filterFunc(opts.Filters)
+	if err != nil {
+		return nil, err
+	}
+	embedding, err := s.embedderOf(opts).EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	so := getSearchOptions(opts)
+	limit := numDocuments
+	if so.mmr {
+		limit = max(so.fetchK, _mmrFetchFactor*numDocuments)
+	}
+
+	type candidate struct {
+		doc       schema.Document
+		embedding []float32
+	}
+	var candidates []candidate
+	s.mu.RLock()
+	for _, stored := range s.docs {
+		if stored.nameSpace != opts.NameSpace || !match(stored.doc) {
+			continue
+		}
+		score := s.score(embedding, stored.embedding)
+		if score < opts.ScoreThreshold {
+			continue
+		}
+		doc := stored.doc
+		doc.Metadata = maps.Clone(doc.Metadata)
+		doc.Score = score
+		candidates = append(candidates, candidate{doc: doc, embedding: stored.embedding})
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].doc.Score > candidates[j].doc.Score
+	})
+	candidates = candidates[:min(limit, len(candidates))]
+
+	docs := make([]schema.Document, len(candidates))
+	vectors := make([][]float32, len(candidates))
+	for i, c := range candidates {
+		docs[i] = c.doc
+		vectors[i] = c.embedding
+	}
+	if so.mmr {
+		return maximalMarginalRelevance(docs, vectors, numDocuments, so.lambda), nil
+	}
+	return docs, nil
+}
+
+// DeleteDocuments deletes the documents with the given ids, and returns the
+// number of deleted documents.
+func (s *Store) DeleteDocuments(_ context.Context, ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if _, ok := s.ids[id]; ok {
+			deleted[id] = true
+		}
+	}
+	if len(deleted) == 0 {
+		return 0, nil
+	}
+	s.docs = slices.DeleteFunc(s.docs, func(stored document) bool {
+		return deleted[stored.id]
+	})
+	s.ids = make(map[string]int, len(s.docs))
+	for i, stored := range s.docs {
+		s.ids[stored.id] = i
+	}
+	return len(deleted), nil
+}
+
+// ListEmbeddedDocuments returns at most limit documents with an id following
+// cursor, ordered by id, along with their embeddings and the cursor of the
+// next documents, empty after the last ones. It lets the store be the source
+// of migrate.Copy.
+func (s *Store) ListEmbeddedDocuments(_ context.Context, cursor string, limit int) ([]migrate.Record, string, error) {
+	if limit <= 0 {
+		return nil, "", errors.New("limit must be positive")
+	}
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.ids))
+	for id := range s.ids {
+		if id > cursor {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	ids = ids[:min(limit, len(ids))]
+	records := make([]migrate.Record, len(ids))
+	for i, id := range ids {
+		stored := s.docs[s.ids[id]]
+		doc := stored.doc
+		doc.Metadata = maps.Clone(doc.Metadata)
+		records[i] = migrate.Record{ID: id, Document: doc, Embedding: slices.Clone(stored.embedding)}
+	}
+	s.mu.RUnlock()
+
+	next := ""
+	if len(ids) == limit {
+		next = ids[len(ids)-1]
+	}
+	return records, next, nil
+}
+
+// Len returns the number of stored documents, of all the name spaces.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.docs)
+}
+
+func (s *Store) embedderOf(opts vectorstores.Options) embeddings.Embedder {
+	if opts.Embedder != nil {
+		return opts.Embedder
+	}
+	return s.embedder
+}
+
+// score returns the score of the document embedding b for the query
+// embedding a, higher for more similar documents.
+func (s *Store) score(a, b []float32) float32 {
+	if s.distance == L2 {
+		return float32(1 / (1 + euclideanDistance(a, b)))
+	}
+	return float32(cosineSimilarity(a, b))
+}
+
+// filterFunc returns the function matching the documents of filters.
+func filterFunc(filters any) (func(schema.Document) bool, error) {
+	switch f := filters.(type) {
+	case nil:
+		return func(schema.Document) bool { return true }, nil
+	case func(schema.Document) bool:
+		return f, nil
+	case vectorstores.Filter:
+		if err := f.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		return func(doc schema.Document) bool { return f.Match(doc.Metadata) }, nil
+	case map[string]any:
+		return func(doc schema.Document) bool {
+			for key, value := range f {
+				if v, ok := doc.Metadata[key]; !ok || !reflect.DeepEqual(v, value) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	}
+	return nil, fmt.Errorf("%w, got %T", ErrInvalidFilters, filters)
+}
+
+// maximalMarginalRelevance returns the numDocuments documents of docs, sorted
+// by relevance, maximizing their marginal relevance: their relevance weighted
+// by lambda minus their greatest similarity to the documents already
+// selected, weighted by 1 - lambda.
+func maximalMarginalRelevance(docs []schema.Document,
+	vectors [][]float32,
+	numDocuments int,
+	lambda float32,
+) []schema.Document {
+	selected := make([]schema.Document, 0, min(numDocuments, len(docs)))
+	var selectedVectors [][]float32
+	used := make([]bool, len(docs))
+	for len(selected) < cap(selected) {
+		best, bestScore := -1, float32(math.Inf(-1))
+		for i := range docs {
+			if used[i] {
+				continue
+			}
+			redundancy := float32(math.Inf(-1))
+			if len(selectedVectors) == 0 {
+				redundancy = 0
+			}
+			for _, v := range selectedVectors {
+				redundancy = max(redundancy, float32(cosineSimilarity(vectors[i], v)))
+			}
+			score := lambda*docs[i].Score - (1-lambda)*redundancy
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		used[best] = true
+		selected = append(selected, docs[best])
+		selectedVectors = append(selectedVectors, vectors[best])
+	}
+	return selected
+}
+
+// cosineSimilarity returns the cosine similarity of a and b, 0 when their
+// dimensions differ.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// euclideanDistance returns the euclidean distance of a and b, +Inf when
+// their dimensions differ.
+func euclideanDistance(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return math.Inf(1)
+	}
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+func getOptions(options ...vectorstores.Option) vectorstores.Options {
+	opts := vectorstores.Options{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
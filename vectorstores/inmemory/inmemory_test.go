@@ -0,0 +1,165 @@
+package inmemory
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/migrate"
+	"github.com/tmc/langchaingo/vectorstores/vstest"
+)
+
+func newTestStore(t *testing.T, opts ...Option) *Store {
+	t.Helper()
+	opts = append([]Option{WithEmbedder(vstest.Embedder{})}, opts...)
+	s, err := New(opts...)
+	require.NoError(t, err)
+	_, err = s.AddDocuments(context.Background(), []schema.Document{
+		{PageContent: "cat cat", Metadata: map[string]any{"id": "1", "kind": "cat"}},
+		{PageContent: "cat dog", Metadata: map[string]any{"id": "2", "kind": "mixed"}},
+		{PageContent: "dog dog", Metadata: map[string]any{"id": "3", "kind": "dog"}},
+		{PageContent: "fish", Metadata: map[string]any{"id": "4", "kind": "fish"}},
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func TestSimilaritySearch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	docs, err := s.SimilaritySearch(ctx, "cat", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat cat", "cat dog"}, vstest.Contents(docs))
+	require.InDelta(t, 1, docs[0].Score, 1e-6)
+	require.InDelta(t, 0.7071, docs[1].Score, 1e-4)
+
+	docs, err = s.SimilaritySearch(ctx, "cat", 4, vectorstores.WithScoreThreshold(0.5))
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat cat", "cat dog"}, vstest.Contents(docs))
+
+	docs, err = s.SimilaritySearch(ctx, "cat", 4, vectorstores.WithFilters(map[string]any{"kind": "dog"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"dog dog"}, vstest.Contents(docs))
+
+	docs, err = s.SimilaritySearch(ctx, "cat", 4, vectorstores.WithFilters(func(doc schema.Document) bool {
+		return strings.HasPrefix(doc.PageContent, "fish")
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"fish"}, vstest.Contents(docs))
+
+	_, err = s.SimilaritySearch(ctx, "cat", 4, vectorstores.WithFilters("kind = 'dog'"))
+	require.ErrorIs(t, err, ErrInvalidFilters)
+
+	// The returned metadata doesn't alias the stored one.
+	docs[0].Metadata["kind"] = "changed"
+	docs, err = s.SimilaritySearch(ctx, "fish", 1)
+	require.NoError(t, err)
+	require.Equal(t, "fish", docs[0].Metadata["kind"])
+}
+
+func TestSimilaritySearchL2(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t, WithDistance(L2))
+	docs, err := s.SimilaritySearch(context.Background(), "cat cat", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat cat", "cat dog"}, vstest.Contents(docs))
+	require.InDelta(t, 1, docs[0].Score, 1e-6)
+	require.InDelta(t, 1/(1+0.7654), docs[1].Score, 1e-4)
+}
+
+func TestAddDocumentsReplaceAndNameSpaces(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	ids, err := s.AddDocuments(ctx, []schema.Document{
+		{PageContent: "fish fish", Metadata: map[string]any{"id": "4"}},
+		{PageContent: "dog"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "4", ids[0])
+	require.NotEmpty(t, ids[1])
+	require.Equal(t, 5, s.Len())
+
+	_, err = s.AddDocuments(ctx, []schema.Document{{PageContent: "cat"}}, vectorstores.WithNameSpace("other"))
+	require.NoError(t, err)
+	docs, err := s.SimilaritySearch(ctx, "cat", 4, vectorstores.WithNameSpace("other"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat"}, vstest.Contents(docs))
+
+	embedder, err := embeddings.NewEmbedder(embeddings.EmbedderClientFunc(
+		func(_ context.Context, texts []string) ([][]float32, error) { return [][]float32{{1}}, nil }))
+	require.NoError(t, err)
+	_, err = s.AddDocuments(ctx, []schema.Document{{PageContent: "x"}}, vectorstores.WithEmbedder(embedder))
+	require.ErrorIs(t, err, ErrDimensionMismatch)
+
+	_, err = s.AddDocuments(ctx, []schema.Document{{PageContent: "cat"}}, vectorstores.WithDeduplicater(
+		func(context.Context, schema.Document) bool { return true }))
+	require.NoError(t, err)
+	require.Equal(t, 6, s.Len())
+}
+
+func TestDeleteDocuments(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := newTestStore(t)
+	deleted, err := s.DeleteDocuments(ctx, []string{"1", "3", "missing"})
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+	docs, err := s.SimilaritySearch(ctx, "cat dog fish", 4)
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat dog", "fish"}, vstest.Contents(docs))
+
+	_, err = s.AddDocuments(ctx, []schema.Document{{PageContent: "fish fish", Metadata: map[string]any{"id": "4"}}})
+	require.NoError(t, err)
+	require.Equal(t, 2, s.Len())
+}
+
+func TestCopyBetweenStores(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	src := newTestStore(t)
+	dst, err := New(WithEmbedder(vstest.Embedder{}))
+	require.NoError(t, err)
+	result, err := migrate.Copy(ctx, src, dst, migrate.WithBatchSize(3), migrate.WithIDMetadataKey("id"))
+	require.NoError(t, err)
+	require.Equal(t, 4, result.Copied)
+
+	// The copied embeddings are searched with the embedder of the source.
+	docs, err := dst.SimilaritySearch(ctx, "dog", 1, vectorstores.WithEmbedder(src.embedder))
+	require.NoError(t, err)
+	require.Equal(t, []string{"dog dog"}, vstest.Contents(docs))
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	_, err := New()
+	require.ErrorIs(t, err, ErrMissingEmbedder)
+	_, err = New(WithEmbedder(vstest.Embedder{}), WithDistance(Distance(7)))
+	require.Error(t, err)
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+	for name, distance := range map[string]Distance{"Cosine": Cosine, "L2": L2} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			vstest.Run(t, vstest.Config{
+				NewStore: func(t *testing.T, embedder embeddings.Embedder) vectorstores.VectorStore {
+					t.Helper()
+					s, err := New(WithEmbedder(embedder), WithDistance(distance))
+					require.NoError(t, err)
+					return s
+				},
+				Filters: true,
+				MMR:     WithMMR,
+			})
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package inmemory
+
+import (
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// Distance is the distance function of the searches.
+type Distance int
+
+const (
+	// Cosine ranks the documents by cosine similarity, the score of a
+	// document being its cosine similarity to the query.
+	Cosine Distance = iota
+	// L2 ranks the documents by euclidean distance, the score of a document
+	// being 1 / (1 + distance) so that closer documents score higher.
+	L2
+)
+
+// Option is a function for configuring the Store.
+type Option func(s *Store)
+
+// WithEmbedder sets the embedder of the documents and queries. It must be
+// set.
+func WithEmbedder(embedder embeddings.Embedder) Option {
+	return func(s *Store) {
+		s.embedder = embedder
+	}
+}
+
+// WithDistance sets the distance function of the searches. It defaults to
+// Cosine.
+func WithDistance(distance Distance) Option {
+	return func(s *Store) {
+		s.distance = distance
+	}
+}
+
+// searchOptionsKey is the vectorstores.Options.Extra key holding the
+// in-memory specific searchOptions.
+type searchOptionsKey struct{}
+
+// searchOptions holds the in-memory specific options of a single search.
+type searchOptions struct {
+	// mmr reranks the fetchK nearest documents by maximal marginal relevance.
+	mmr    bool
+	fetchK int
+	lambda float32
+}
+
+// WithMMR returns a search option reranking the fetchK documents nearest to
+// the query by maximal marginal relevance, to return relevant documents that
+// are diverse. lambda, between 0 and 1, weights relevance against diversity:
+// 1 ranks by relevance only, 0 by diversity only. fetchK defaults to 4 times
+// the number of documents when it is lower.
+func WithMMR(fetchK int, lambda float32) vectorstores.Option {
+	return func(o *vectorstores.Options) {
+		if o.Extra == nil {
+			o.Extra = map[any]any{}
+		}
+		o.Extra[searchOptionsKey{}] = &searchOptions{mmr: true, fetchK: fetchK, lambda: lambda}
+	}
+}
+
+// getSearchOptions returns the in-memory specific search options set in
+// opts.
+func getSearchOptions(opts vectorstores.Options) searchOptions {
+	if so, ok := opts.Extra[searchOptionsKey{}].(*searchOptions); ok {
+		return *so
+	}
+	return searchOptions{}
+}
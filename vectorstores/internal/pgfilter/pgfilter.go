@@ -0,0 +1,82 @@
+// Package pgfilter translates vectorstores.Filter to the conditions of the
+// PostgreSQL vector stores, on their jsonb metadata column.
+package pgfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// jsonPathOps are the jsonpath operators of the comparisons.
+var jsonPathOps = map[vectorstores.FilterOp]string{ //nolint:gochecknoglobals
+	vectorstores.FilterGt:  ">",
+	vectorstores.FilterGte: ">=",
+	vectorstores.FilterLt:  "<",
+	vectorstores.FilterLte: "<=",
+}
+
+// Condition translates f, a valid filter, to a condition on the jsonb
+// column, along with args followed by the arguments of the condition.
+// Equality uses jsonb equality, so that numbers are compared by value, and
+// the ordering comparisons a strict jsonpath, which doesn't match values of
+// another type.
+func Condition(f vectorstores.Filter, column string, args []any) (string, []any, error) {
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	switch f.Op {
+	case vectorstores.FilterAnd, vectorstores.FilterOr:
+		if len(f.Filters) == 0 {
+			return fmt.Sprint(f.Op == vectorstores.FilterAnd), args, nil
+		}
+		conditions := make([]string, len(f.Filters))
+		for i, filter := range f.Filters {
+			var err error
+			if conditions[i], args, err = Condition(filter, column, args); err != nil {
+				return "", nil, err
+			}
+		}
+		return "(" + strings.Join(conditions, " "+strings.ToUpper(string(f.Op))+" ") + ")", args, nil
+	case vectorstores.FilterNot:
+		condition, args, err := Condition(f.Filters[0], column, args)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(NOT " + condition + ")", args, nil
+	case vectorstores.FilterExists:
+		return fmt.Sprintf("(%s ? %s::text)", column, arg(f.Key)), args, nil
+	case vectorstores.FilterEq, vectorstores.FilterNe:
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		condition := fmt.Sprintf("COALESCE((%s -> %s::text) = %s::jsonb, false)", column, arg(f.Key), arg(string(value)))
+		if f.Op == vectorstores.FilterNe {
+			condition = "(NOT " + condition + ")"
+		}
+		return condition, args, nil
+	case vectorstores.FilterIn:
+		values, _ := f.Value.([]any)
+		filters := make([]vectorstores.Filter, len(values))
+		for i, value := range values {
+			filters[i] = vectorstores.Eq(f.Key, value)
+		}
+		return Condition(vectorstores.Or(filters...), column, args)
+	}
+
+	vars, err := json.Marshal(map[string]any{"v": f.Value})
+	if err != nil {
+		return "", nil, err
+	}
+	path := fmt.Sprintf("strict $.%s ? (@ %s $v)", jsonPathKey(f.Key), jsonPathOps[f.Op])
+	return fmt.Sprintf("jsonb_path_exists(%s, %s::jsonpath, %s::jsonb, true)", column, arg(path), arg(string(vars))), args, nil
+}
+
+// jsonPathKey quotes key as a jsonpath member accessor.
+func jsonPathKey(key string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(key) + `"`
+}
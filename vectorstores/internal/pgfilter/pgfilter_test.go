@@ -0,0 +1,43 @@
+package pgfilter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+func TestCondition(t *testing.T) {
+	t.Parallel()
+	f := vectorstores.And(
+		vectorstores.Eq("kind", "pet"),
+		vectorstores.Or(vectorstores.Gte("legs", 4), vectorstores.Not(vectorstores.Exists(`a"b`))),
+		vectorstores.In("color", "red", "blue"),
+		vectorstores.Ne("n", 1),
+		vectorstores.Or(),
+	)
+	condition, args, err := Condition(f, "cmetadata::jsonb", []any{"query"})
+	require.NoError(t, err)
+	require.Equal(t,
+		`(COALESCE((cmetadata::jsonb -> $2::text) = $3::jsonb, false) AND `+
+			`(jsonb_path_exists(cmetadata::jsonb, $4::jsonpath, $5::jsonb, true) OR (NOT (cmetadata::jsonb ? $6::text))) AND `+
+			`(COALESCE((cmetadata::jsonb -> $7::text) = $8::jsonb, false) OR COALESCE((cmetadata::jsonb -> $9::text) = $10::jsonb, false)) AND `+ //nolint:lll
+			`(NOT COALESCE((cmetadata::jsonb -> $11::text) = $12::jsonb, false)) AND `+
+			`false)`,
+		condition)
+	require.Equal(t, []any{
+		"query",
+		"kind", `"pet"`,
+		`strict $."legs" ? (@ >= $v)`, `{"v":4}`, `a"b`,
+		"color", `"red"`, "color", `"blue"`,
+		"n", "1",
+	}, args)
+
+	_, _, err = Condition(vectorstores.Eq("kind", func() {}), "cmetadata::jsonb", nil)
+	require.Error(t, err)
+}
+
+func TestJSONPathKey(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, `"a\"b\\c"`, jsonPathKey(`a"b\c`))
+}
@@ -0,0 +1,4 @@
+// Package migrate copies the documents of a vector store, along with their
+// embeddings, into another vector store, e.g. from pgvector to AlloyDB, so
+// switching backends doesn't require embedding the documents again.
+package migrate
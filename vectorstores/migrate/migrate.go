@@ -0,0 +1,187 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+const _defaultBatchSize = 100
+
+// ErrQueryEmbedding is returned when the embedder passed to the destination
+// store is asked to embed a query, as it only holds the embeddings of the
+// copied documents.
+var ErrQueryEmbedding = errors.New("copied embeddings can't embed queries")
+
+// Record is a document of a source store along with its embedding.
+type Record struct {
+	// ID is the id of the document in the source store.
+	ID        string
+	Document  schema.Document
+	Embedding []float32
+}
+
+// Source is a vector store whose documents can be listed with their
+// embeddings.
+type Source interface {
+	// ListEmbeddedDocuments returns at most limit documents following cursor,
+	// in a stable order, and the cursor of the next ones, empty after the
+	// last documents. The empty cursor lists the first documents.
+	ListEmbeddedDocuments(ctx context.Context, cursor string, limit int) ([]Record, string, error)
+}
+
+// Progress is the progress of a copy, reported after every batch.
+type Progress struct {
+	// Copied is the number of documents copied so far by the call to Copy.
+	Copied int
+	// Cursor is the cursor following the copied documents, from which an
+	// interrupted copy can be resumed with WithCursor.
+	Cursor string
+}
+
+// Result is the result of a copy.
+type Result struct {
+	// Copied is the number of copied documents.
+	Copied int
+	// Cursor is the cursor following the last copied batch. When Copy
+	// returns an error, the copy can be resumed from it with WithCursor.
+	Cursor string
+}
+
+// Option is a function for configuring a copy.
+type Option func(o *options)
+
+type options struct {
+	batchSize     int
+	progress      func(Progress)
+	cursor        string
+	idMetadataKey string
+	addOptions    []vectorstores.Option
+}
+
+// WithBatchSize sets the number of documents read from the source and added
+// to the destination at once. It defaults to 100.
+func WithBatchSize(batchSize int) Option {
+	return func(o *options) {
+		o.batchSize = batchSize
+	}
+}
+
+// WithProgress sets a function called after every copied batch.
+func WithProgress(fn func(Progress)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// WithCursor resumes a copy from the cursor of its last progress or result.
+func WithCursor(cursor string) Option {
+	return func(o *options) {
+		o.cursor = cursor
+	}
+}
+
+// WithIDMetadataKey sets the metadata key of the documents set to their id in
+// the source store, so that destinations reading the ids from the metadata,
+// e.g. AlloyDB with "id", keep them. Resuming an interrupted copy then
+// replaces the documents of a batch partially added instead of duplicating
+// them, for destinations replacing documents with the same id.
+func WithIDMetadataKey(key string) Option {
+	return func(o *options) {
+		o.idMetadataKey = key
+	}
+}
+
+// WithAddOptions sets other options of the additions to the destination,
+// e.g. a name space.
+func WithAddOptions(opts ...vectorstores.Option) Option {
+	return func(o *options) {
+		o.addOptions = opts
+	}
+}
+
+// Copy copies the documents of src into dst in batches, along with their
+// embeddings. The destination must embed the documents with the embedder of
+// the add options, as vectorstores.WithEmbedder sets it to return the
+// embeddings of the source.
+func Copy(ctx context.Context, src Source, dst vectorstores.VectorStore, opts ...Option) (Result, error) {
+	o := options{batchSize: _defaultBatchSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if src == nil || dst == nil {
+		return Result{}, errors.New("missing source or destination store")
+	}
+	if o.batchSize <= 0 {
+		return Result{}, fmt.Errorf("invalid batch size %d", o.batchSize)
+	}
+
+	result := Result{Cursor: o.cursor}
+	for {
+		records, next, err := src.ListEmbeddedDocuments(ctx, result.Cursor, o.batchSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to list documents: %w", err)
+		}
+		if len(records) > 0 {
+			if err := addRecords(ctx, dst, records, o); err != nil {
+				return result, err
+			}
+		}
+		result.Copied += len(records)
+		result.Cursor = next
+		if o.progress != nil {
+			o.progress(Progress{Copied: result.Copied, Cursor: result.Cursor})
+		}
+		if next == "" {
+			return result, nil
+		}
+	}
+}
+
+// addRecords adds the documents of records to dst with their embeddings.
+func addRecords(ctx context.Context, dst vectorstores.VectorStore, records []Record, o options) error {
+	docs := make([]schema.Document, len(records))
+	vectors := make([][]float32, len(records))
+	for i, record := range records {
+		doc := record.Document
+		if o.idMetadataKey != "" {
+			doc.Metadata = maps.Clone(doc.Metadata)
+			if doc.Metadata == nil {
+				doc.Metadata = map[string]any{}
+			}
+			doc.Metadata[o.idMetadataKey] = record.ID
+		}
+		docs[i] = doc
+		vectors[i] = record.Embedding
+	}
+	addOptions := append([]vectorstores.Option{}, o.addOptions...)
+	addOptions = append(addOptions, vectorstores.WithEmbedder(copiedEmbeddings{vectors: vectors}))
+	if _, err := dst.AddDocuments(ctx, docs, addOptions...); err != nil {
+		return fmt.Errorf("failed to add documents: %w", err)
+	}
+	return nil
+}
+
+// copiedEmbeddings is an embedder returning the embeddings of the copied
+// documents, in order.
+type copiedEmbeddings struct {
+	vectors [][]float32
+}
+
+var _ embeddings.Embedder = copiedEmbeddings{}
+
+func (e copiedEmbeddings) EmbedDocuments(_ context.Context, texts []string) ([][]float32, error) {
+	if len(texts) != len(e.vectors) {
+		return nil, fmt.Errorf("asked for %d embeddings, copied %d", len(texts), len(e.vectors))
+	}
+	return e.vectors, nil
+}
+
+func (e copiedEmbeddings) EmbedQuery(context.Context, string) ([]float32, error) {
+	return nil, ErrQueryEmbedding
+}
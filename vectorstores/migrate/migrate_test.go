@@ -0,0 +1,148 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// sliceSource lists records using their index as the cursor.
+type sliceSource struct {
+	records []Record
+	err     error
+	// failAt is the cursor whose listing fails with err.
+	failAt string
+}
+
+func (s *sliceSource) ListEmbeddedDocuments(_ context.Context, cursor string, limit int) ([]Record, string, error) {
+	if s.err != nil && cursor == s.failAt {
+		return nil, "", s.err
+	}
+	start := 0
+	if cursor != "" {
+		start, _ = strconv.Atoi(cursor)
+	}
+	end := min(start+limit, len(s.records))
+	next := ""
+	if end < len(s.records) {
+		next = strconv.Itoa(end)
+	}
+	return s.records[start:end], next, nil
+}
+
+// embeddingStore stores the documents with the embeddings of the embedder of
+// the add options.
+type embeddingStore struct {
+	docs       []schema.Document
+	embeddings [][]float32
+	batches    int
+	nameSpace  string
+}
+
+func (s *embeddingStore) AddDocuments(ctx context.Context, docs []schema.Document, options ...vectorstores.Option) ([]string, error) { // nolint: lll
+	opts := vectorstores.Options{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.PageContent
+	}
+	vectors, err := opts.Embedder.EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	s.docs = append(s.docs, docs...)
+	s.embeddings = append(s.embeddings, vectors...)
+	s.batches++
+	s.nameSpace = opts.NameSpace
+	return make([]string, len(docs)), nil
+}
+
+func (s *embeddingStore) SimilaritySearch(context.Context, string, int, ...vectorstores.Option) ([]schema.Document, error) { // nolint: lll
+	return nil, nil
+}
+
+func testRecords(n int) []Record {
+	records := make([]Record, n)
+	for i := range records {
+		records[i] = Record{
+			ID:        "doc" + strconv.Itoa(i),
+			Document:  schema.Document{PageContent: "content " + strconv.Itoa(i), Metadata: map[string]any{"n": i}},
+			Embedding: []float32{float32(i), 1},
+		}
+	}
+	return records
+}
+
+func TestCopy(t *testing.T) {
+	t.Parallel()
+	src := &sliceSource{records: testRecords(5)}
+	dst := &embeddingStore{}
+	var progress []Progress
+	result, err := Copy(context.Background(), src, dst,
+		WithBatchSize(2),
+		WithProgress(func(p Progress) { progress = append(progress, p) }),
+		WithIDMetadataKey("id"),
+		WithAddOptions(vectorstores.WithNameSpace("copied")))
+	require.NoError(t, err)
+	require.Equal(t, Result{Copied: 5}, result)
+	require.Equal(t, []Progress{{Copied: 2, Cursor: "2"}, {Copied: 4, Cursor: "4"}, {Copied: 5}}, progress)
+	require.Equal(t, 3, dst.batches)
+	require.Equal(t, "copied", dst.nameSpace)
+	require.Len(t, dst.docs, 5)
+	for i, doc := range dst.docs {
+		require.Equal(t, map[string]any{"n": i, "id": "doc" + strconv.Itoa(i)}, doc.Metadata)
+		require.Equal(t, src.records[i].Embedding, dst.embeddings[i])
+	}
+	// The metadata of the source documents is left untouched.
+	require.Equal(t, map[string]any{"n": 0}, src.records[0].Document.Metadata)
+}
+
+func TestCopyResume(t *testing.T) {
+	t.Parallel()
+	errList := errors.New("connection reset")
+	src := &sliceSource{records: testRecords(5), err: errList, failAt: "4"}
+	dst := &embeddingStore{}
+	result, err := Copy(context.Background(), src, dst, WithBatchSize(2))
+	require.ErrorIs(t, err, errList)
+	require.Equal(t, Result{Copied: 4, Cursor: "4"}, result)
+
+	src.err = nil
+	result, err = Copy(context.Background(), src, dst, WithBatchSize(2), WithCursor(result.Cursor))
+	require.NoError(t, err)
+	require.Equal(t, Result{Copied: 1}, result)
+	require.Len(t, dst.docs, 5)
+	require.Equal(t, "content 4", dst.docs[4].PageContent)
+}
+
+func TestCopyEmptySource(t *testing.T) {
+	t.Parallel()
+	dst := &embeddingStore{}
+	result, err := Copy(context.Background(), &sliceSource{}, dst)
+	require.NoError(t, err)
+	require.Equal(t, Result{}, result)
+	require.Zero(t, dst.batches)
+}
+
+func TestCopyValidation(t *testing.T) {
+	t.Parallel()
+	_, err := Copy(context.Background(), &sliceSource{}, &embeddingStore{}, WithBatchSize(0))
+	require.Error(t, err)
+	_, err = Copy(context.Background(), nil, &embeddingStore{})
+	require.Error(t, err)
+}
+
+func TestCopiedEmbeddings(t *testing.T) {
+	t.Parallel()
+	e := copiedEmbeddings{vectors: [][]float32{{1, 2}}}
+	_, err := e.EmbedDocuments(context.Background(), []string{"a", "b"})
+	require.Error(t, err)
+	_, err = e.EmbedQuery(context.Background(), "a")
+	require.ErrorIs(t, err, ErrQueryEmbedding)
+}
@@ -10,6 +10,8 @@
 //   - Perform similarity searches on stored embeddings
 //   - Configurable index and path settings
 //   - Support for custom embedding functions
+//   - Pre-filtering of similarity searches on metadata fields
+//   - Creation of the Atlas Vector Search index definition
 //
 // Main types:
 //   - Store: The main type that implements the VectorStore interface
@@ -37,6 +39,13 @@
 //	// Perform similarity search
 //	results, err := store.SimilaritySearch(context.Background(), "query", 5)
 //
+// The vector search index can be created with the filter fields of the
+// metadata the searches are pre-filtered on:
+//
+//	err = store.CreateIndex(ctx, 1536, mongovector.SimilarityCosine, "kind")
+//	results, err = store.SimilaritySearch(ctx, "query", 5,
+//	    vectorstores.WithFilters(map[string]any{"kind": "article"}))
+//
 // The package also provides options for customizing the Store:
 //   - WithIndex: Set a custom index name
 //   - WithPath: Set a custom path for the vector field
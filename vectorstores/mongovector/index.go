@@ -0,0 +1,198 @@
+package mongovector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tmc/langchaingo/vectorstores"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// indexPollInterval is the interval between the checks of CreateVectorIndex
+// for the index to be queryable.
+const indexPollInterval = 5 * time.Second
+
+// Similarity is the function used by an Atlas Vector Search index to compare
+// the vectors.
+type Similarity string
+
+const (
+	SimilarityCosine     Similarity = "cosine"
+	SimilarityEuclidean  Similarity = "euclidean"
+	SimilarityDotProduct Similarity = "dotProduct"
+)
+
+// IndexField is a field of an Atlas Vector Search index definition.
+type IndexField struct {
+	// Type is "vector" for the field of the embeddings, or "filter" for the
+	// fields the searches can be pre-filtered on.
+	Type string `bson:"type"`
+	Path string `bson:"path"`
+	// NumDimensions and Similarity are only set for the vector field.
+	NumDimensions int        `bson:"numDimensions,omitempty"`
+	Similarity    Similarity `bson:"similarity,omitempty"`
+	// Quantization is the optional quantization of the vector field, "scalar"
+	// or "binary".
+	Quantization string `bson:"quantization,omitempty"`
+}
+
+// IndexDefinition is the definition of an Atlas Vector Search index.
+type IndexDefinition struct {
+	Fields []IndexField `bson:"fields"`
+}
+
+// VectorIndexDefinition returns the definition of an index of the embeddings
+// of path, with filter fields for the given metadata fields so that the
+// searches can be pre-filtered on them with WithFilters.
+func VectorIndexDefinition(
+	path string,
+	dimensions int,
+	similarity Similarity,
+	metadataFields ...string,
+) IndexDefinition {
+	def := IndexDefinition{Fields: []IndexField{{
+		Type:          "vector",
+		Path:          path,
+		NumDimensions: dimensions,
+		Similarity:    similarity,
+	}}}
+	for _, field := range metadataFields {
+		def.Fields = append(def.Fields, IndexField{Type: "filter", Path: metadataName + "." + field})
+	}
+	return def
+}
+
+// CreateVectorIndex creates the Atlas Vector Search index name of coll with
+// the definition def, and blocks until it is queryable or the context is
+// done.
+func CreateVectorIndex(ctx context.Context, coll *mongo.Collection, name string, def IndexDefinition) error {
+	view := coll.SearchIndexes()
+	siOpts := options.SearchIndexes().SetName(name).SetType("vectorSearch")
+	searchName, err := view.CreateOne(ctx, mongo.SearchIndexModel{Definition: def, Options: siOpts})
+	if err != nil {
+		return fmt.Errorf("failed to create the search index: %w", err)
+	}
+
+	for {
+		queryable, err := searchIndexQueryable(ctx, view, searchName)
+		if err != nil {
+			return err
+		}
+		if queryable {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(indexPollInterval):
+		}
+	}
+}
+
+// searchIndexQueryable reports whether the search index name is queryable.
+func searchIndexQueryable(ctx context.Context, view mongo.SearchIndexView, name string) (bool, error) {
+	cursor, err := view.List(ctx, options.SearchIndexes().SetName(name))
+	if err != nil {
+		return false, fmt.Errorf("failed to list search indexes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var index struct {
+			Name      string `bson:"name"`
+			Queryable bool   `bson:"queryable"`
+		}
+		if err := cursor.Decode(&index); err != nil {
+			return false, fmt.Errorf("failed to decode search index: %w", err)
+		}
+		if index.Name == name {
+			return index.Queryable, nil
+		}
+	}
+	return false, cursor.Err()
+}
+
+// CreateIndex creates the index of the store over the embeddings of its
+// path, with filter fields for the given metadata fields, and blocks until
+// it is queryable.
+func (store *Store) CreateIndex(
+	ctx context.Context,
+	dimensions int,
+	similarity Similarity,
+	metadataFields ...string,
+) error {
+	def := VectorIndexDefinition(store.path, dimensions, similarity, metadataFields...)
+	return CreateVectorIndex(ctx, store.coll, store.index, def)
+}
+
+// metadataFilter returns the MQL matching expression of the filters of a
+// map of metadata fields, matching the documents whose metadata fields are
+// equal to the values, or one of them when the value is a []any. Values that
+// are maps of operators, e.g. {"$gte": 2}, are used as is. A
+// vectorstores.Filter is translated to the equivalent expression. Other
+// filters are assumed to be MQL expressions already.
+func metadataFilter(filters any) (any, error) {
+	if f, ok := filters.(vectorstores.Filter); ok {
+		if err := f.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		return filterMQL(f), nil
+	}
+	fields, ok := filters.(map[string]any)
+	if !ok {
+		return filters, nil
+	}
+	expr := bson.D{}
+	for _, key := range sortedKeys(fields) {
+		var cond any
+		switch value := fields[key].(type) {
+		case []any:
+			cond = bson.D{{Key: "$in", Value: value}}
+		case map[string]any:
+			cond = value
+		default:
+			cond = bson.D{{Key: "$eq", Value: value}}
+		}
+		expr = append(expr, bson.E{Key: metadataName + "." + key, Value: cond})
+	}
+	return expr, nil
+}
+
+// filterMQL translates f to an MQL matching expression. The comparison
+// operators of MQL compare numbers by value, don't match the values of other
+// types, and $ne matches the documents without the field, as Filter does.
+func filterMQL(f vectorstores.Filter) bson.D {
+	switch f.Op {
+	case vectorstores.FilterAnd, vectorstores.FilterOr:
+		if len(f.Filters) == 0 {
+			if f.Op == vectorstores.FilterAnd {
+				return bson.D{}
+			}
+			// No document fails to match the empty expression.
+			return bson.D{{Key: "$nor", Value: bson.A{bson.D{}}}}
+		}
+		exprs := make(bson.A, len(f.Filters))
+		for i, filter := range f.Filters {
+			exprs[i] = filterMQL(filter)
+		}
+		return bson.D{{Key: "$" + string(f.Op), Value: exprs}}
+	case vectorstores.FilterNot:
+		return bson.D{{Key: "$nor", Value: bson.A{filterMQL(f.Filters[0])}}}
+	case vectorstores.FilterExists:
+		return bson.D{{Key: metadataName + "." + f.Key, Value: bson.D{{Key: "$exists", Value: true}}}}
+	}
+	return bson.D{{Key: metadataName + "." + f.Key, Value: bson.D{{Key: "$" + string(f.Op), Value: f.Value}}}}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
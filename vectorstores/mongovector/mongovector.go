@@ -25,6 +25,7 @@ var (
 	ErrEmbedderWrongNumberVectors = errors.New("number of vectors from embedder does not match number of documents")
 	ErrUnsupportedOptions         = errors.New("unsupported options")
 	ErrInvalidScoreThreshold      = errors.New("score threshold must be between 0 and 1")
+	ErrInvalidFilters             = errors.New("invalid filters")
 )
 
 // Store wraps a Mongo collection for writing to and searching an Atlas
@@ -166,6 +167,11 @@ func mergeSearchOpts(store *Store, opts ...vectorstores.Option) (*vectorstores.O
 	if mopts.Filters == nil {
 		mopts.Filters = bson.D{}
 	}
+	filters, err := metadataFilter(mopts.Filters)
+	if err != nil {
+		return nil, err
+	}
+	mopts.Filters = filters
 
 	return mopts, nil
 }
@@ -178,6 +184,11 @@ func mergeSearchOpts(store *Store, opts ...vectorstores.Option) (*vectorstores.O
 // Since multiple indexes can be defined for a collection, the options.NameSpace
 // value can be used here to change the search index. The priority is
 // options.NameSpace > Store.index > defaultIndex.
+//
+// The search is pre-filtered with options.Filters, either an MQL matching
+// expression, a map[string]any of metadata fields or a vectorstores.Filter;
+// the filtered fields must be filter fields of the index, see
+// VectorIndexDefinition.
 func (store *Store) SimilaritySearch(
 	ctx context.Context,
 	query string,
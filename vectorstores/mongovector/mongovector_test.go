@@ -472,61 +472,6 @@ func TestStore_SimilaritySearch_NonExactQuery(t *testing.T) {
 	})
 }
 
-// vectorField defines the fields of an index used for vector search.
-type vectorField struct {
-	Type          string `bson:"type,omitempty"`
-	Path          string `bson:"path,omityempty"`
-	NumDimensions int    `bson:"numDimensions,omitempty"`
-	Similarity    string `bson:"similarity,omitempty"`
-}
-
-// createVectorSearchIndex will create a vector search index on the "db.vstore"
-// collection named "vector_index" with the provided field. This function blocks
-// until the index has been created.
-func createVectorSearchIndex(
-	ctx context.Context,
-	coll *mongo.Collection,
-	idxName string,
-	fields ...vectorField,
-) (string, error) {
-	def := struct {
-		Fields []vectorField `bson:"fields"`
-	}{
-		Fields: fields,
-	}
-
-	view := coll.SearchIndexes()
-
-	siOpts := options.SearchIndexes().SetName(idxName).SetType("vectorSearch")
-	searchName, err := view.CreateOne(ctx, mongo.SearchIndexModel{Definition: def, Options: siOpts})
-	if err != nil {
-		return "", fmt.Errorf("failed to create the search index: %w", err)
-	}
-
-	// Await the creation of the index.
-	var doc bson.Raw
-	for doc == nil {
-		cursor, err := view.List(ctx, options.SearchIndexes().SetName(searchName))
-		if err != nil {
-			return "", fmt.Errorf("failed to list search indexes: %w", err)
-		}
-
-		if !cursor.Next(ctx) {
-			break
-		}
-
-		name := cursor.Current.Lookup("name").StringValue()
-		queryable := cursor.Current.Lookup("queryable").Boolean()
-		if name == searchName && queryable {
-			doc = cursor.Current
-		} else {
-			time.Sleep(5 * time.Second)
-		}
-	}
-
-	return searchName, nil
-}
-
 func searchIndexExists(ctx context.Context, coll *mongo.Collection, idx string) (bool, error) {
 	view := coll.SearchIndexes()
 
@@ -567,26 +512,75 @@ func resetForE2E(ctx context.Context, idx string, dim int, filters []string) err
 		return nil
 	}
 
-	fields := []vectorField{}
-
-	fields = append(fields, vectorField{
+	def := IndexDefinition{Fields: []IndexField{{
 		Type:          "vector",
 		Path:          "plot_embedding",
 		NumDimensions: dim,
-		Similarity:    "dotProduct",
-	})
+		Similarity:    SimilarityDotProduct,
+	}}}
 
 	for _, filter := range filters {
-		fields = append(fields, vectorField{
+		def.Fields = append(def.Fields, IndexField{
 			Type: "filter",
 			Path: filter,
 		})
 	}
 
-	_, err = createVectorSearchIndex(ctx, coll, idx, fields...)
-	if err != nil {
+	if err := CreateVectorIndex(ctx, coll, idx, def); err != nil {
 		return fmt.Errorf("faield to create index: %w", err)
 	}
 
 	return nil
 }
+
+func TestVectorIndexDefinition(t *testing.T) {
+	t.Parallel()
+
+	def := VectorIndexDefinition("embedding", 3, SimilarityCosine, "kind", "year")
+	assert.Equal(t, IndexDefinition{Fields: []IndexField{
+		{Type: "vector", Path: "embedding", NumDimensions: 3, Similarity: SimilarityCosine},
+		{Type: "filter", Path: "metadata.kind"},
+		{Type: "filter", Path: "metadata.year"},
+	}}, def)
+}
+
+func TestMetadataFilter(t *testing.T) {
+	t.Parallel()
+
+	mql := bson.D{{Key: "pageContent", Value: "v0001"}}
+	filter, err := metadataFilter(mql)
+	require.NoError(t, err)
+	assert.Equal(t, mql, filter)
+
+	filter, err = metadataFilter(map[string]any{
+		"year": map[string]any{"$gte": 2020},
+		"name": "rex",
+		"kind": []any{"cat", "dog"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, bson.D{
+		{Key: "metadata.kind", Value: bson.D{{Key: "$in", Value: []any{"cat", "dog"}}}},
+		{Key: "metadata.name", Value: bson.D{{Key: "$eq", Value: "rex"}}},
+		{Key: "metadata.year", Value: map[string]any{"$gte": 2020}},
+	}, filter)
+
+	filter, err = metadataFilter(vectorstores.And(
+		vectorstores.Ne("kind", "cat"),
+		vectorstores.Or(vectorstores.Gte("year", 2020), vectorstores.Exists("award")),
+		vectorstores.Not(vectorstores.In("name", "rex", "fido")),
+	))
+	require.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "$and", Value: bson.A{
+		bson.D{{Key: "metadata.kind", Value: bson.D{{Key: "$ne", Value: "cat"}}}},
+		bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "metadata.year", Value: bson.D{{Key: "$gte", Value: 2020}}}},
+			bson.D{{Key: "metadata.award", Value: bson.D{{Key: "$exists", Value: true}}}},
+		}}},
+		bson.D{{Key: "$nor", Value: bson.A{
+			bson.D{{Key: "metadata.name", Value: bson.D{{Key: "$in", Value: []any{"rex", "fido"}}}}},
+		}}},
+	}}}, filter)
+
+	_, err = metadataFilter(vectorstores.Not(vectorstores.Filter{}))
+	require.ErrorIs(t, err, ErrInvalidFilters)
+}
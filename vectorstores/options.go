@@ -17,6 +17,9 @@ type Options struct {
 	Filters        any
 	Embedder       embeddings.Embedder
 	Deduplicater   func(context.Context, schema.Document) bool
+	// Extra holds backend specific options. Backends populate it through
+	// their own Option constructors, keyed by types they own.
+	Extra map[any]any
 }
 
 // WithNameSpace returns an Option for setting the name space.
@@ -36,6 +39,11 @@ func WithScoreThreshold(scoreThreshold float32) Option {
 // filters retrieve exactly the number of nearest-neighbors results that match the filters. In
 // most cases the search latency will be lower than unfiltered searches
 // See https://docs.pinecone.io/docs/metadata-filtering
+//
+// The filters are interpreted by each store. A Filter is portable across the
+// stores supporting it: inmemory, columnar, pgvector, elasticsearch,
+// sqlitevec, duckdb, mongovector and alloydb support all of it, redisvector
+// all but Exists on the fields of its index, and cassandra only Eq and And.
 func WithFilters(filters any) Option {
 	return func(o *Options) {
 		o.Filters = filters
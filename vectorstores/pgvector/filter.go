@@ -0,0 +1,33 @@
+package pgvector
+
+import (
+	"fmt"
+
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/internal/pgfilter"
+)
+
+// filterConditions returns the conditions on the json metadata column of
+// filters, a map[key]value pattern or a vectorstores.Filter, along with args
+// followed by the arguments of the conditions.
+func filterConditions(filters any, column string, args []any) ([]string, []any, error) {
+	switch f := filters.(type) {
+	case map[string]any:
+		conditions := make([]string, 0, len(f))
+		for k, v := range f {
+			conditions = append(conditions, fmt.Sprintf("(%s ->> '%s') = '%s'", column, k, v))
+		}
+		return conditions, args, nil
+	case vectorstores.Filter:
+		if err := f.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		// The filters use the jsonb operators.
+		condition, args, err := pgfilter.Condition(f, column+"::jsonb", args)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		return []string{condition}, args, nil
+	}
+	return nil, args, nil
+}
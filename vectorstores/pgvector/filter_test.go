@@ -0,0 +1,28 @@
+package pgvector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+func TestFilterConditions(t *testing.T) {
+	t.Parallel()
+	f := vectorstores.And(vectorstores.Eq("kind", "pet"), vectorstores.Exists("legs"))
+	conditions, args, err := filterConditions(f, "data.cmetadata", []any{"query"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`(COALESCE((data.cmetadata::jsonb -> $2::text) = $3::jsonb, false) AND (data.cmetadata::jsonb ? $4::text))`,
+	}, conditions)
+	require.Equal(t, []any{"query", "kind", `"pet"`, "legs"}, args)
+
+	conditions, args, err = filterConditions(map[string]any{"kind": "pet"}, "cmetadata", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{`(cmetadata ->> 'kind') = 'pet'`}, conditions)
+	require.Empty(t, args)
+
+	_, _, err = filterConditions(vectorstores.Not(vectorstores.Filter{}), "cmetadata", nil)
+	require.ErrorIs(t, err, ErrInvalidFilters)
+	require.ErrorIs(t, err, vectorstores.ErrInvalidFilter)
+}
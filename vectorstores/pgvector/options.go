@@ -79,7 +79,9 @@ func WithCollectionMetadata(metadata map[string]any) Option {
 	}
 }
 
-// WithVectorDimensions is an option for specifying the vector size.
+// WithVectorDimensions is an option for specifying the vector size of the
+// embedding table created by New. Store.CheckEmbeddingDimensions checks that
+// it's the dimension of the embeddings of the embedder.
 func WithVectorDimensions(size int) Option {
 	return func(p *Store) {
 		p.vectorDimensions = size
@@ -102,6 +104,16 @@ func WithHNSWIndex(m int, efConstruction int, distanceFunction string) Option {
 	}
 }
 
+// WithSparseEmbedder is an option for setting the sparse embedder, e.g.
+// embeddings.NewBM25, whose embeddings are stored in a sparsevec column, which
+// needs pgvector 0.7.0 or later. The searches are then hybrid by default,
+// see WithSearchMode.
+func WithSparseEmbedder(e embeddings.SparseEmbedder) Option {
+	return func(p *Store) {
+		p.sparseEmbedder = e
+	}
+}
+
 func applyClientOptions(opts ...Option) (Store, error) {
 	o := &Store{
 		collectionName:      DefaultCollectionName,
@@ -14,6 +14,7 @@ import (
 	"github.com/tmc/langchaingo/embeddings"
 	"github.com/tmc/langchaingo/schema"
 	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/migrate"
 )
 
 const (
@@ -63,6 +64,7 @@ type Store struct {
 	preDeleteCollection bool
 	vectorDimensions    int
 	hnswIndex           *HNSWIndex
+	sparseEmbedder      embeddings.SparseEmbedder
 }
 
 type HNSWIndex struct {
@@ -71,7 +73,10 @@ type HNSWIndex struct {
 	distanceFunction string
 }
 
-var _ vectorstores.VectorStore = Store{}
+var (
+	_ vectorstores.VectorStore = Store{}
+	_ migrate.Source           = Store{}
+)
 
 // New creates a new Store with options.
 func New(ctx context.Context, opts ...Option) (Store, error) {
@@ -94,6 +99,28 @@ func New(ctx context.Context, opts ...Option) (Store, error) {
 	return store, nil
 }
 
+// CheckEmbeddingDimensions returns an error wrapping
+// embeddings.ErrDimensionMismatch if the embedding column declares a vector
+// size which isn't the dimension of the embeddings of the embedder, which it
+// embeds a probe text to find unless the embedder knows it. Calling it after
+// New makes a misconfigured store fail before its first insert. A column
+// without a declared size stores embeddings of any dimension.
+func (s Store) CheckEmbeddingDimensions(ctx context.Context) error {
+	var size int
+	err := s.conn.QueryRow(ctx, `SELECT atttypmod FROM pg_attribute
+WHERE attrelid = $1::regclass AND attname = 'embedding'`, s.embeddingTableName).Scan(&size)
+	if err != nil {
+		return fmt.Errorf("failed to get the vector size of the embedding column: %w", err)
+	}
+	if size <= 0 {
+		return nil
+	}
+	if err := embeddings.CheckDimensions(ctx, s.embedder, size); err != nil {
+		return fmt.Errorf("embedding table %s: %w", s.embeddingTableName, err)
+	}
+	return nil
+}
+
 // Close closes the connection.
 func (s Store) Close() error {
 	if closer, ok := s.conn.(io.Closer); ok {
@@ -201,6 +228,14 @@ func (s Store) createEmbeddingTableIfNotExists(ctx context.Context, tx pgx.Tx) e
 	if _, err := tx.Exec(ctx, sql); err != nil {
 		return err
 	}
+	// The sparse embeddings are stored in a column added to the existing
+	// tables as well.
+	if s.sparseEmbedder != nil {
+		sql = fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS sparse_embedding sparsevec`, s.embeddingTableName)
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return err
+		}
+	}
 	sql = fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_collection_id ON %s (collection_id)`, s.embeddingTableName, s.embeddingTableName)
 	if _, err := tx.Exec(ctx, sql); err != nil {
 		return err
@@ -254,16 +289,34 @@ func (s Store) AddDocuments(
 	if len(vectors) != len(docs) {
 		return nil, ErrEmbedderWrongNumberVectors
 	}
+	var sparseVectors []embeddings.SparseVector
+	if s.sparseEmbedder != nil {
+		sparseVectors, err = s.sparseEmbedder.EmbedSparseDocuments(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		if len(sparseVectors) != len(docs) {
+			return nil, ErrEmbedderWrongNumberVectors
+		}
+	}
 
 	b := &pgx.Batch{}
 	sql := fmt.Sprintf(`INSERT INTO %s (uuid, document, embedding, cmetadata, collection_id)
 		VALUES($1, $2, $3, $4, $5)`, s.embeddingTableName)
+	if s.sparseEmbedder != nil {
+		sql = fmt.Sprintf(`INSERT INTO %s (uuid, document, embedding, cmetadata, collection_id, sparse_embedding)
+		VALUES($1, $2, $3, $4, $5, $6::sparsevec)`, s.embeddingTableName)
+	}
 
 	ids := make([]string, len(docs))
 	for docIdx, doc := range docs {
 		id := uuid.New().String()
 		ids[docIdx] = id
-		b.Queue(sql, id, doc.PageContent, pgvector.NewVector(vectors[docIdx]), doc.Metadata, s.collectionUUID)
+		args := []any{id, doc.PageContent, pgvector.NewVector(vectors[docIdx]), doc.Metadata, s.collectionUUID}
+		if s.sparseEmbedder != nil {
+			args = append(args, formatSparseVector(sparseVectors[docIdx]))
+		}
+		b.Queue(sql, args...)
 	}
 	return ids, s.conn.SendBatch(ctx, b).Close()
 }
@@ -285,6 +338,9 @@ func (s Store) SimilaritySearch(
 	if err != nil {
 		return nil, err
 	}
+	if so := s.getSearchOptions(opts); so.mode != DenseSearch {
+		return s.sparseSearch(ctx, query, numDocuments, opts, so, filter)
+	}
 	embedder := s.embedder
 	if opts.Embedder != nil {
 		embedder = opts.Embedder
@@ -297,14 +353,16 @@ func (s Store) SimilaritySearch(
 	if scoreThreshold != 0 {
 		whereQuerys = append(whereQuerys, fmt.Sprintf("data.distance < %f", 1-scoreThreshold))
 	}
-	for k, v := range filter {
-		whereQuerys = append(whereQuerys, fmt.Sprintf("(data.cmetadata ->> '%s') = '%s'", k, v))
+	filterQuerys, args, err := filterConditions(filter, "data.cmetadata",
+		[]any{len(embedderData), pgvector.NewVector(embedderData), numDocuments})
+	if err != nil {
+		return nil, err
 	}
+	whereQuerys = append(whereQuerys, filterQuerys...)
 	whereQuery := strings.Join(whereQuerys, " AND ")
 	if len(whereQuery) == 0 {
 		whereQuery = "TRUE"
 	}
-	dims := len(embedderData)
 	sql := fmt.Sprintf(`WITH filtered_embedding_dims AS MATERIALIZED (
     SELECT
         *
@@ -332,7 +390,7 @@ ORDER BY
 LIMIT $3`, s.embeddingTableName,
 		s.collectionTableName, s.collectionTableName, s.collectionTableName, collectionName,
 		whereQuery)
-	rows, err := s.conn.Query(ctx, sql, dims, pgvector.NewVector(embedderData), numDocuments)
+	rows, err := s.conn.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -361,9 +419,9 @@ func (s Store) Search(
 	if err != nil {
 		return nil, err
 	}
-	whereQuerys := make([]string, 0)
-	for k, v := range filter {
-		whereQuerys = append(whereQuerys, fmt.Sprintf("(%s.cmetadata ->> '%s') = '%s'", s.embeddingTableName, k, v))
+	whereQuerys, args, err := filterConditions(filter, s.embeddingTableName+".cmetadata", []any{numDocuments})
+	if err != nil {
+		return nil, err
 	}
 	whereQuery := strings.Join(whereQuerys, " AND ")
 	if len(whereQuery) == 0 {
@@ -378,7 +436,7 @@ WHERE %s.name='%s' AND %s
 LIMIT $1`, s.embeddingTableName, s.embeddingTableName, s.embeddingTableName,
 		s.collectionTableName, s.embeddingTableName, s.collectionTableName, s.collectionTableName, collectionName,
 		whereQuery)
-	rows, err := s.conn.Query(ctx, sql, numDocuments)
+	rows, err := s.conn.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -395,6 +453,49 @@ LIMIT $1`, s.embeddingTableName, s.embeddingTableName, s.embeddingTableName,
 	return docs, rows.Err()
 }
 
+// ListEmbeddedDocuments returns at most limit documents of the collection
+// with a uuid following cursor, ordered by uuid, along with their embeddings
+// and the cursor of the next documents, empty after the last ones. It lets
+// the store be the source of migrate.Copy.
+func (s Store) ListEmbeddedDocuments(
+	ctx context.Context,
+	cursor string,
+	limit int,
+) ([]migrate.Record, string, error) {
+	if limit <= 0 {
+		return nil, "", errors.New("limit must be positive")
+	}
+	sql := fmt.Sprintf(`SELECT uuid::text, document, cmetadata, embedding
+FROM %s
+WHERE collection_id = $1 AND uuid::text > $2
+ORDER BY uuid::text
+LIMIT $3`, s.embeddingTableName)
+	rows, err := s.conn.Query(ctx, sql, s.collectionUUID, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	records := make([]migrate.Record, 0, limit)
+	for rows.Next() {
+		record := migrate.Record{}
+		var embedding pgvector.Vector
+		if err := rows.Scan(&record.ID, &record.Document.PageContent, &record.Document.Metadata, &embedding); err != nil {
+			return nil, "", err
+		}
+		record.Embedding = embedding.Slice()
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(records) == limit {
+		next = records[len(records)-1].ID
+	}
+	return records, next, nil
+}
+
 func (s Store) DropTables(ctx context.Context) error {
 	if _, err := s.conn.Exec(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s`, s.embeddingTableName)); err != nil {
 		return err
@@ -445,16 +546,16 @@ func (s Store) getScoreThreshold(opts vectorstores.Options) (float32, error) {
 	return opts.ScoreThreshold, nil
 }
 
-// getFilters return metadata filters, now only support map[key]value pattern
-// TODO: should support more types like {"key1": {"key2":"values2"}} or {"key": ["value1", "values2"]}.
-func (s Store) getFilters(opts vectorstores.Options) (map[string]any, error) {
-	if opts.Filters != nil {
-		if filters, ok := opts.Filters.(map[string]any); ok {
-			return filters, nil
-		}
-		return nil, ErrInvalidFilters
+// getFilters return metadata filters, either a map[key]value pattern or a
+// vectorstores.Filter.
+func (s Store) getFilters(opts vectorstores.Options) (any, error) {
+	switch filters := opts.Filters.(type) {
+	case nil:
+		return map[string]any{}, nil
+	case map[string]any, vectorstores.Filter:
+		return filters, nil
 	}
-	return map[string]any{}, nil
+	return nil, ErrInvalidFilters
 }
 
 func (s Store) deduplicate(
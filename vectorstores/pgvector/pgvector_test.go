@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
@@ -21,6 +22,7 @@ import (
 	"github.com/tmc/langchaingo/schema"
 	"github.com/tmc/langchaingo/vectorstores"
 	"github.com/tmc/langchaingo/vectorstores/pgvector"
+	"github.com/tmc/langchaingo/vectorstores/vstest"
 )
 
 func preCheckEnvSetting(t *testing.T) string {
@@ -30,6 +32,14 @@ func preCheckEnvSetting(t *testing.T) string {
 		t.Skip("OPENAI_API_KEY not set")
 	}
 
+	return connectionURL(t)
+}
+
+// connectionURL returns the URL of the database of the tests, started in a
+// container unless PGVECTOR_CONNECTION_STRING is set.
+func connectionURL(t *testing.T) string {
+	t.Helper()
+
 	pgvectorURL := os.Getenv("PGVECTOR_CONNECTION_STRING")
 	if pgvectorURL == "" {
 		pgVectorContainer, err := tcpostgres.RunContainer(
@@ -740,3 +750,94 @@ func TestWithAllOptions(t *testing.T) {
 	require.Equal(t, "tokyo", docs[0].PageContent)
 	require.Equal(t, "japan", docs[0].Metadata["country"])
 }
+
+func TestHybridSearch(t *testing.T) {
+	t.Parallel()
+	pgvectorURL := connectionURL(t)
+	ctx := context.Background()
+
+	conn, err := pgx.Connect(ctx, pgvectorURL)
+	require.NoError(t, err)
+
+	corpus := []schema.Document{
+		{PageContent: "cat cat", Metadata: map[string]any{"kind": "cat"}},
+		{PageContent: "cat dog platypus", Metadata: map[string]any{"kind": "mixed"}},
+		{PageContent: "dog dog", Metadata: map[string]any{"kind": "dog"}},
+		{PageContent: "fish", Metadata: map[string]any{"kind": "fish"}},
+	}
+	bm25 := embeddings.NewBM25()
+	texts := make([]string, len(corpus))
+	for i, doc := range corpus {
+		texts[i] = doc.PageContent
+	}
+	bm25.Fit(texts)
+
+	store, err := pgvector.New(
+		ctx,
+		pgvector.WithConn(conn),
+		pgvector.WithEmbedder(vstest.Embedder{}),
+		pgvector.WithSparseEmbedder(bm25),
+		pgvector.WithEmbeddingTableName("langchain_pg_embedding_sparse"),
+		pgvector.WithPreDeleteCollection(true),
+		pgvector.WithCollectionName(makeNewCollectionName()),
+	)
+	require.NoError(t, err)
+
+	defer cleanupTestArtifacts(ctx, t, store, pgvectorURL)
+
+	_, err = store.AddDocuments(ctx, corpus)
+	require.NoError(t, err)
+
+	// Only the sparse embeddings know about platypuses.
+	docs, err := store.SimilaritySearch(ctx, "platypus", 1, pgvector.WithSearchMode(pgvector.SparseSearch))
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	require.Equal(t, "cat dog platypus", docs[0].PageContent)
+	require.Greater(t, docs[0].Score, float32(0))
+
+	// The document matching both searches ranks first.
+	docs, err = store.SimilaritySearch(ctx, "cat platypus", 2)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	require.Equal(t, "cat dog platypus", docs[0].PageContent)
+	require.Equal(t, "mixed", docs[0].Metadata["kind"])
+
+	docs, err = store.SimilaritySearch(ctx, "cat platypus", 4,
+		vectorstores.WithFilters(vectorstores.Ne("kind", "mixed")), pgvector.WithRRF(10, 4))
+	require.NoError(t, err)
+	require.NotEmpty(t, docs)
+	require.Equal(t, "cat cat", docs[0].PageContent)
+
+	docs, err = store.SimilaritySearch(ctx, "dog", 1, pgvector.WithSearchMode(pgvector.DenseSearch))
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	require.Equal(t, "dog dog", docs[0].PageContent)
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+	pgvectorURL := connectionURL(t)
+	ctx := context.Background()
+
+	// The pool is safe for the concurrent additions and searches of the suite.
+	pool, err := pgxpool.New(ctx, pgvectorURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	vstest.Run(t, vstest.Config{
+		NewStore: func(t *testing.T, embedder embeddings.Embedder) vectorstores.VectorStore {
+			t.Helper()
+			store, err := pgvector.New(
+				ctx,
+				pgvector.WithConn(pool),
+				pgvector.WithEmbedder(embedder),
+				pgvector.WithCollectionName(makeNewCollectionName()),
+				pgvector.WithPreDeleteCollection(true),
+			)
+			require.NoError(t, err)
+			t.Cleanup(func() { cleanupTestArtifacts(ctx, t, store, pgvectorURL) })
+			return store
+		},
+		Filters: true,
+	})
+}
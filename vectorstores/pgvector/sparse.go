@@ -0,0 +1,252 @@
+package pgvector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pgvector/pgvector-go"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// _defaultRRFRankConstant is the default rank constant of the reciprocal
+// rank fusion of hybrid searches.
+const _defaultRRFRankConstant = 60
+
+// SearchMode is the kind of search of SimilaritySearch.
+type SearchMode int
+
+const (
+	// DefaultSearch searches with both embeddings when the store has a
+	// sparse embedder, and with the dense one otherwise.
+	DefaultSearch SearchMode = iota
+	// DenseSearch searches the documents with the embeddings nearest to the
+	// embedding of the query.
+	DenseSearch
+	// SparseSearch searches the documents with the sparse embeddings having
+	// the greatest inner product with the sparse embedding of the query.
+	SparseSearch
+	// HybridSearch fuses the results of the dense and sparse searches with
+	// reciprocal rank fusion.
+	HybridSearch
+)
+
+// searchOptionsKey is the vectorstores.Options.Extra key holding the
+// searchOptions of this package.
+type searchOptionsKey struct{}
+
+// searchOptions holds the options of a single search.
+type searchOptions struct {
+	mode SearchMode
+	// rankConstant and windowSize are the parameters of the reciprocal rank
+	// fusion of hybrid searches.
+	rankConstant int
+	windowSize   int
+}
+
+// withSearchOptions returns a vectorstores.Option that modifies the search
+// options of this package.
+func withSearchOptions(fn func(*searchOptions)) vectorstores.Option {
+	return func(o *vectorstores.Options) {
+		if o.Extra == nil {
+			o.Extra = map[any]any{}
+		}
+		so, ok := o.Extra[searchOptionsKey{}].(*searchOptions)
+		if !ok {
+			so = &searchOptions{}
+			o.Extra[searchOptionsKey{}] = so
+		}
+		fn(so)
+	}
+}
+
+// getSearchOptions returns the search options of opts, with the mode of the
+// searches of s resolved.
+func (s Store) getSearchOptions(opts vectorstores.Options) searchOptions {
+	so := searchOptions{}
+	if o, ok := opts.Extra[searchOptionsKey{}].(*searchOptions); ok {
+		so = *o
+	}
+	if so.mode == DefaultSearch {
+		so.mode = DenseSearch
+		if s.sparseEmbedder != nil {
+			so.mode = HybridSearch
+		}
+	}
+	if so.rankConstant <= 0 {
+		so.rankConstant = _defaultRRFRankConstant
+	}
+	return so
+}
+
+// WithSearchMode sets the kind of search.
+func WithSearchMode(mode SearchMode) vectorstores.Option {
+	return withSearchOptions(func(so *searchOptions) {
+		so.mode = mode
+	})
+}
+
+// WithRRF sets the parameters of the reciprocal rank fusion of hybrid
+// searches: the rank constant, 60 by default, and the number of documents
+// of each search fused, the number of documents by default.
+func WithRRF(rankConstant, windowSize int) vectorstores.Option {
+	return withSearchOptions(func(so *searchOptions) {
+		so.rankConstant = rankConstant
+		so.windowSize = windowSize
+	})
+}
+
+// formatSparseVector returns the text representation of v as a sparsevec,
+// whose indices start at 1.
+func formatSparseVector(v embeddings.SparseVector) string {
+	elements := make([]string, len(v.Indices))
+	for i, index := range v.Indices {
+		elements[i] = fmt.Sprintf("%d:%s", index+1, strconv.FormatFloat(float64(v.Values[i]), 'g', -1, 32))
+	}
+	return fmt.Sprintf("{%s}/%d", strings.Join(elements, ","), v.Dimension)
+}
+
+// sparseSearch runs a sparse or hybrid search, the documents of hybrid
+// searches being scored by the sum of the reciprocals of their ranks in the
+// dense and sparse searches.
+func (s Store) sparseSearch(
+	ctx context.Context,
+	query string,
+	numDocuments int,
+	opts vectorstores.Options,
+	so searchOptions,
+	filter any,
+) ([]schema.Document, error) {
+	if s.sparseEmbedder == nil {
+		return nil, fmt.Errorf("%w: sparse searches need a sparse embedder", ErrUnsupportedOptions)
+	}
+	if opts.ScoreThreshold != 0 {
+		return nil, fmt.Errorf("%w: score threshold of a sparse search", ErrUnsupportedOptions)
+	}
+	sparse, err := s.sparseEmbedder.EmbedSparseQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		sql  string
+		args []any
+	)
+	if so.mode == SparseSearch {
+		args = []any{numDocuments, s.getNameSpace(opts), formatSparseVector(sparse)}
+		sql, args, err = s.sparseSearchSQL(filter, args)
+	} else {
+		embedder := s.embedder
+		if opts.Embedder != nil {
+			embedder = opts.Embedder
+		}
+		var dense []float32
+		dense, err = embedder.EmbedQuery(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		windowSize := so.windowSize
+		if windowSize <= 0 {
+			windowSize = numDocuments
+		}
+		args = []any{
+			numDocuments, s.getNameSpace(opts), formatSparseVector(sparse),
+			len(dense), pgvector.NewVector(dense), windowSize, so.rankConstant,
+		}
+		sql, args, err = s.hybridSearchSQL(filter, args)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := make([]schema.Document, 0)
+	for rows.Next() {
+		doc := schema.Document{}
+		if err := rows.Scan(&doc.PageContent, &doc.Metadata, &doc.Score); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+// sparseSearchSQL returns the statement of a sparse search, scoring the
+// documents by the inner product of the sparse embeddings, whose first
+// arguments are the number of documents, the collection name and the sparse
+// embedding of the query.
+func (s Store) sparseSearchSQL(filter any, args []any) (string, []any, error) {
+	whereQuery, args, err := s.collectionConditions(filter, args)
+	if err != nil {
+		return "", nil, err
+	}
+	sql := fmt.Sprintf(`SELECT
+	e.document,
+	e.cmetadata,
+	(-(e.sparse_embedding <#> $3::sparsevec))::real AS score
+FROM %s e
+JOIN %s c ON e.collection_id = c.uuid
+WHERE %s AND e.sparse_embedding IS NOT NULL
+ORDER BY e.sparse_embedding <#> $3::sparsevec
+LIMIT $1`, s.embeddingTableName, s.collectionTableName, whereQuery)
+	return sql, args, nil
+}
+
+// hybridSearchSQL returns the statement of a hybrid search, whose first
+// arguments are the ones of sparseSearchSQL followed by the dimension and
+// the embedding of the query, the number of documents of each search fused
+// and the rank constant of the fusion.
+func (s Store) hybridSearchSQL(filter any, args []any) (string, []any, error) {
+	whereQuery, args, err := s.collectionConditions(filter, args)
+	if err != nil {
+		return "", nil, err
+	}
+	sql := fmt.Sprintf(`WITH candidates AS MATERIALIZED (
+	SELECT e.uuid, e.document, e.cmetadata, e.embedding, e.sparse_embedding
+	FROM %s e
+	JOIN %s c ON e.collection_id = c.uuid
+	WHERE %s
+),
+dense AS (
+	SELECT uuid, ROW_NUMBER() OVER (ORDER BY embedding <=> $5) AS rank
+	FROM candidates
+	WHERE vector_dims(embedding) = $4
+	ORDER BY embedding <=> $5
+	LIMIT $6
+),
+sparse AS (
+	SELECT uuid, ROW_NUMBER() OVER (ORDER BY sparse_embedding <#> $3::sparsevec) AS rank
+	FROM candidates
+	WHERE sparse_embedding IS NOT NULL
+	ORDER BY sparse_embedding <#> $3::sparsevec
+	LIMIT $6
+)
+SELECT
+	candidates.document,
+	candidates.cmetadata,
+	(COALESCE(1.0 / ($7::int + dense.rank), 0) + COALESCE(1.0 / ($7::int + sparse.rank), 0))::real AS score
+FROM dense
+FULL OUTER JOIN sparse ON dense.uuid = sparse.uuid
+JOIN candidates ON candidates.uuid = COALESCE(dense.uuid, sparse.uuid)
+ORDER BY score DESC
+LIMIT $1`, s.embeddingTableName, s.collectionTableName, whereQuery)
+	return sql, args, nil
+}
+
+// collectionConditions returns the conditions selecting the documents of
+// the collection named by $2 matching filter.
+func (s Store) collectionConditions(filter any, args []any) (string, []any, error) {
+	conditions, args, err := filterConditions(filter, "e.cmetadata", args)
+	if err != nil {
+		return "", nil, err
+	}
+	return strings.Join(append([]string{"c.name = $2"}, conditions...), " AND "), args, nil
+}
@@ -0,0 +1,50 @@
+package pgvector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+func TestFormatSparseVector(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "{1:0.5,4:2}/8", formatSparseVector(embeddings.SparseVector{
+		Indices: []int{0, 3}, Values: []float32{0.5, 2}, Dimension: 8,
+	}))
+	require.Equal(t, "{}/8", formatSparseVector(embeddings.SparseVector{Dimension: 8}))
+}
+
+func TestGetSearchOptions(t *testing.T) {
+	t.Parallel()
+	getOptions := func(s Store, options ...vectorstores.Option) searchOptions {
+		return s.getSearchOptions(s.getOptions(options...))
+	}
+
+	require.Equal(t, searchOptions{mode: DenseSearch, rankConstant: 60}, getOptions(Store{}))
+	s := Store{sparseEmbedder: embeddings.NewBM25()}
+	require.Equal(t, searchOptions{mode: HybridSearch, rankConstant: 60}, getOptions(s))
+	require.Equal(t, searchOptions{mode: SparseSearch, rankConstant: 10, windowSize: 20},
+		getOptions(s, WithSearchMode(SparseSearch), WithRRF(10, 20)))
+}
+
+func TestHybridSearchSQL(t *testing.T) {
+	t.Parallel()
+	s := Store{embeddingTableName: "embeddings", collectionTableName: "collections"}
+
+	// The arguments of the filters follow the ones of the search.
+	args := []any{1, "collection", "{}/8", 2, "[1,2]", 3, 60}
+	sql, args, err := s.hybridSearchSQL(vectorstores.Eq("kind", "pet"), args)
+	require.NoError(t, err)
+	require.Contains(t, sql, `WHERE c.name = $2 AND COALESCE((e.cmetadata::jsonb -> $8::text) = $9::jsonb, false)`)
+	require.Equal(t, []any{1, "collection", "{}/8", 2, "[1,2]", 3, 60, "kind", `"pet"`}, args)
+
+	sql, args, err = s.sparseSearchSQL(map[string]any{}, []any{1, "collection", "{}/8"})
+	require.NoError(t, err)
+	require.Contains(t, sql, "WHERE c.name = $2 AND e.sparse_embedding IS NOT NULL")
+	require.Len(t, args, 3)
+
+	_, _, err = s.sparseSearchSQL(vectorstores.Filter{Op: "bogus"}, nil)
+	require.ErrorIs(t, err, ErrInvalidFilters)
+}
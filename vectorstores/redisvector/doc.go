@@ -1,3 +1,8 @@
 // Package redisvector contains an implementation of the VectorStore
 // interface using redisvector.
+//
+// Documents are stored as hashes, or as RedisJSON documents with
+// WithStorageType(JSONIndexType), and searched with the FLAT or HNSW vector
+// indexes of RediSearch. They can expire after a time to live set with
+// WithTTL, or for each AddDocuments call with WithDocumentTTL.
 package redisvector
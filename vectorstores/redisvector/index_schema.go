@@ -8,7 +8,9 @@ import (
 	"log/slog"
 	"os"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 
 	"sigs.k8s.io/yaml"
 )
@@ -279,6 +281,41 @@ func (s *IndexSchema) AsCommand() []string {
 	return argsOut
 }
 
+// jsonPaths returns the schema of a JSON index of documents holding the
+// fields of s: the fields are indexed from their JSON paths, under their
+// names. Fields already named by a JSON path are kept.
+func (s *IndexSchema) jsonPaths() IndexSchema {
+	out := IndexSchema{
+		Tag:     slices.Clone(s.Tag),
+		Text:    slices.Clone(s.Text),
+		Numeric: slices.Clone(s.Numeric),
+		Vector:  slices.Clone(s.Vector),
+	}
+	path := func(name, as *string, suffix string) {
+		if strings.HasPrefix(*name, "$") {
+			return
+		}
+		if *as == "" {
+			*as = *name
+		}
+		*name = "$." + *name + suffix
+	}
+	for i := range out.Tag {
+		// Tags are indexed from the elements of arrays.
+		path(&out.Tag[i].Name, &out.Tag[i].As, "[*]")
+	}
+	for i := range out.Text {
+		path(&out.Text[i].Name, &out.Text[i].As, "")
+	}
+	for i := range out.Numeric {
+		path(&out.Numeric[i].Name, &out.Numeric[i].As, "")
+	}
+	for i := range out.Vector {
+		path(&out.Vector[i].Name, &out.Vector[i].As, "")
+	}
+	return out
+}
+
 type schemaGenerator struct {
 	format   SchemaFormat
 	filePath string
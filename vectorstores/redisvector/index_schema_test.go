@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
 )
 
 func TestGenerateSchema(t *testing.T) {
@@ -190,3 +191,66 @@ func TestIndexSearchAsCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestSchemaJSONPaths(t *testing.T) {
+	t.Parallel()
+
+	s := IndexSchema{
+		Tag:     []TagField{{Name: "tags"}},
+		Text:    []TextField{{Name: "content"}, {Name: "$.title", As: "title"}},
+		Numeric: []NumericField{{Name: "year", As: "published"}},
+		Vector:  []VectorField{{Name: "content_vector", Dims: 3}},
+	}
+	assert.Equal(t, IndexSchema{
+		Tag:     []TagField{{Name: "$.tags[*]", As: "tags"}},
+		Text:    []TextField{{Name: "$.content", As: "content"}, {Name: "$.title", As: "title"}},
+		Numeric: []NumericField{{Name: "$.year", As: "published"}},
+		Vector:  []VectorField{{Name: "$.content_vector", As: "content_vector", Dims: 3}},
+	}, s.jsonPaths())
+	// The schema of the store keeps the attribute names.
+	assert.Equal(t, "tags", s.Tag[0].Name)
+}
+
+func TestMetadataFilter(t *testing.T) {
+	t.Parallel()
+
+	s := Store{indexSchema: &IndexSchema{
+		Tag:     []TagField{{Name: "$.tags[*]", As: "tags"}},
+		Text:    []TextField{{Name: "location"}},
+		Numeric: []NumericField{{Name: "year"}},
+	}}
+
+	filter, err := s.metadataFilter(map[string]any{
+		"tags":     []any{"sci-fi", "new york"},
+		"location": "patio",
+		"year":     []any{2020, 2021.5},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `@location:("patio") @tags:{sci\-fi|new\ york} (@year:[2020 2020] | @year:[2021.5 2021.5])`, filter)
+
+	_, err = s.metadataFilter(map[string]any{"year": "recent"})
+	require.ErrorIs(t, err, ErrInvalidFilters)
+	_, err = s.metadataFilter(map[string]any{"author": "x"})
+	require.ErrorIs(t, err, ErrInvalidFilters)
+
+	filter, err = s.getFilters(vectorstores.Options{Filters: vectorstores.And(
+		vectorstores.In("tags", "sci-fi", "drama"),
+		vectorstores.Or(vectorstores.Gt("year", 2020), vectorstores.Lte("year", 1950)),
+		vectorstores.Ne("location", "patio"),
+		vectorstores.Not(vectorstores.Eq("year", 2000)),
+	)})
+	require.NoError(t, err)
+	assert.Equal(t, `(@tags:{sci\-fi|drama} (@year:[(2020 +inf] | @year:[-inf 1950]) -(@location:("patio")) -((@year:[2000 2000])))`, filter)
+
+	for _, f := range []vectorstores.Filter{
+		vectorstores.Exists("tags"),
+		vectorstores.Gt("location", "a"),
+		vectorstores.Gte("year", "recent"),
+		vectorstores.Eq("author", "x"),
+		vectorstores.Or(),
+		vectorstores.Not(vectorstores.Filter{}),
+	} {
+		_, err = s.getFilters(vectorstores.Options{Filters: f})
+		require.ErrorIs(t, err, ErrInvalidFilters, f)
+	}
+}
@@ -3,8 +3,10 @@ package redisvector
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/vectorstores"
 )
 
 type RedisIndexAlgorithm string
@@ -80,8 +82,64 @@ func WithIndexSchema(format SchemaFormat, schemaFilePath string, schemaBytes []b
 	}
 }
 
+// WithStorageType is an option for specifying how documents are stored,
+// as hashes (HASHIndexType, the default) or as RedisJSON documents
+// (JSONIndexType). It must match the type of an existing index.
+func WithStorageType(storageType IndexType) Option {
+	return func(s *Store) {
+		s.storageType = storageType
+	}
+}
+
+// WithVectorAlgorithm is an option for specifying the algorithm of the vector
+// field of the index schema generated from the documents metadata,
+// FlatVectorAlgorithm by default. It doesn't apply to `WithIndexSchema`.
+func WithVectorAlgorithm(algorithm VectorAlgorithm) Option {
+	return func(s *Store) {
+		s.vectorAlgorithm = algorithm
+	}
+}
+
+// WithDistanceMetric is an option for specifying the distance metric of the
+// vector field of the index schema generated from the documents metadata,
+// CosineDistanceMetric by default. It doesn't apply to `WithIndexSchema`.
+func WithDistanceMetric(metric DistanceMetric) Option {
+	return func(s *Store) {
+		s.distanceMetric = metric
+	}
+}
+
+// WithTTL is an option for specifying the time to live of the added
+// documents, after which redis deletes them. Documents don't expire by
+// default. It can be overridden for each `AddDocuments` call with
+// `WithDocumentTTL`.
+func WithTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.ttl = ttl
+	}
+}
+
+// addOptionsKey is the vectorstores.Options.Extra key holding the TTL of
+// the documents of an AddDocuments call.
+type addOptionsKey struct{}
+
+// WithDocumentTTL sets the time to live of the documents added by an
+// `AddDocuments` call, overriding `WithTTL`. A zero TTL keeps them forever.
+func WithDocumentTTL(ttl time.Duration) vectorstores.Option {
+	return func(o *vectorstores.Options) {
+		if o.Extra == nil {
+			o.Extra = map[any]any{}
+		}
+		o.Extra[addOptionsKey{}] = ttl
+	}
+}
+
 func applyClientOptions(opts ...Option) (*Store, error) {
-	s := &Store{}
+	s := &Store{
+		storageType:     HASHIndexType,
+		vectorAlgorithm: FlatVectorAlgorithm,
+		distanceMetric:  CosineDistanceMetric,
+	}
 
 	for _, opt := range opts {
 		opt(s)
@@ -95,6 +153,14 @@ func applyClientOptions(opts ...Option) (*Store, error) {
 		return nil, fmt.Errorf("%w: missing index name", ErrInvalidOptions)
 	}
 
+	if s.storageType != HASHIndexType && s.storageType != JSONIndexType {
+		return nil, fmt.Errorf("%w: invalid storage type %q", ErrInvalidOptions, s.storageType)
+	}
+
+	if s.ttl < 0 {
+		return nil, fmt.Errorf("%w: negative TTL", ErrInvalidOptions)
+	}
+
 	if s.schemaGenerator != nil {
 		schema, err := s.schemaGenerator.generate()
 		if err != nil {
@@ -2,11 +2,13 @@ package redisvector
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/rueidis"
@@ -19,10 +21,12 @@ import (
 type RedisClient interface {
 	DropIndex(ctx context.Context, index string, deleteDocuments bool) error
 	CheckIndexExists(ctx context.Context, index string) bool
-	CreateIndexIfNotExists(ctx context.Context, index string, schema *IndexSchema) error
+	CreateIndexIfNotExists(ctx context.Context, index string, indexType IndexType, schema *IndexSchema) error
 	AddDocWithHash(ctx context.Context, prefix string, doc schema.Document) (string, error)
 	AddDocsWithHash(ctx context.Context, prefix string, docs []schema.Document) ([]string, error)
-	// TODO AddDocsWithJSON
+	AddDocsWithJSON(ctx context.Context, prefix string, docs []schema.Document) ([]string, error)
+	// Expire sets the time to live of keys.
+	Expire(ctx context.Context, keys []string, ttl time.Duration) error
 	Search(ctx context.Context, search IndexVectorSearch) (int64, []schema.Document, error)
 }
 
@@ -59,7 +63,7 @@ func (c RueidisClient) CheckIndexExists(ctx context.Context, index string) bool
 	return c.client.Do(ctx, c.client.B().FtInfo().Index(index).Build()).Error() == nil
 }
 
-func (c RueidisClient) CreateIndexIfNotExists(ctx context.Context, index string, schema *IndexSchema) error {
+func (c RueidisClient) CreateIndexIfNotExists(ctx context.Context, index string, indexType IndexType, schema *IndexSchema) error { // nolint: lll
 	if index == "" {
 		return ErrEmptyIndexName
 	}
@@ -68,7 +72,11 @@ func (c RueidisClient) CreateIndexIfNotExists(ctx context.Context, index string,
 		return nil
 	}
 
-	redisIndex := NewIndex(index, []string{getPrefix(index)}, HASHIndexType, *schema)
+	indexSchema := *schema
+	if indexType == JSONIndexType {
+		indexSchema = schema.jsonPaths()
+	}
+	redisIndex := NewIndex(index, []string{getPrefix(index)}, indexType, indexSchema)
 	createIndexCmd, err := redisIndex.AsCommand()
 	if err != nil {
 		return err
@@ -99,6 +107,43 @@ func (c RueidisClient) AddDocsWithHash(ctx context.Context, prefix string, docs
 	return docIDs, errors.Join(errs...)
 }
 
+// AddDocsWithJSON adds docs as RedisJSON documents holding their metadata,
+// with the vectors as arrays of numbers.
+func (c RueidisClient) AddDocsWithJSON(ctx context.Context, prefix string, docs []schema.Document) ([]string, error) {
+	cmds := make([]rueidis.Completed, 0, len(docs))
+	docIDs := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		data, err := json.Marshal(doc.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal document: %w", err)
+		}
+		docID := getDocIDWithMetaData(prefix, doc.Metadata)
+		cmds = append(cmds, c.client.B().Arbitrary("JSON.SET").Keys(docID).Args("$", string(data)).Build())
+		docIDs = append(docIDs, docID)
+	}
+	return docIDs, c.doMulti(ctx, cmds)
+}
+
+func (c RueidisClient) Expire(ctx context.Context, keys []string, ttl time.Duration) error {
+	cmds := make([]rueidis.Completed, 0, len(keys))
+	milliseconds := strconv.FormatInt(ttl.Milliseconds(), 10)
+	for _, key := range keys {
+		cmds = append(cmds, c.client.B().Arbitrary("PEXPIRE").Keys(key).Args(milliseconds).Build())
+	}
+	return c.doMulti(ctx, cmds)
+}
+
+// doMulti runs cmds in a pipeline and joins their errors.
+func (c RueidisClient) doMulti(ctx context.Context, cmds []rueidis.Completed) error {
+	errs := make([]error, 0, len(cmds))
+	for _, res := range c.client.DoMulti(ctx, cmds...) {
+		if res.Error() != nil {
+			errs = append(errs, res.Error())
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (c RueidisClient) Search(ctx context.Context, search IndexVectorSearch) (int64, []schema.Document, error) {
 	cmds := search.AsCommand()
 	// fmt.Println(strings.Join(cmds, " "))
@@ -131,6 +176,10 @@ func (c RueidisClient) generateHSetCMD(prefix string, doc schema.Document) (stri
 	return docID, c.client.B().Arbitrary("Hmset").Keys(docID).Args(kvs...).Build()
 }
 
+// jsonRootField is the field of the whole document in the search results
+// of JSON indexes.
+const jsonRootField = "$"
+
 // getPrefix get prefix with index name.
 func getPrefix(index string) string {
 	return fmt.Sprintf("doc:%s", index)
@@ -160,6 +209,21 @@ func convertFTSearchResIntoDocSchema(docs []rueidis.FtSearchDoc) []schema.Docume
 		for k, v := range doc.Doc {
 			if k == defaultContentFieldKey {
 				_doc.PageContent = v
+			} else if k == jsonRootField {
+				// JSON documents are returned whole without RETURN fields.
+				var fields map[string]any
+				if err := json.Unmarshal([]byte(v), &fields); err != nil {
+					continue
+				}
+				for field, value := range fields {
+					switch field {
+					case defaultContentFieldKey:
+						_doc.PageContent, _ = value.(string)
+					case defaultContentVectorFieldKey:
+					default:
+						metadata[field] = value
+					}
+				}
 			} else if k == defaultDistanceFieldKey {
 				score, _ := strconv.ParseFloat(v, 32)
 				_doc.Score = float32(score)
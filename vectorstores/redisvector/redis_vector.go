@@ -3,6 +3,11 @@ package redisvector
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/tmc/langchaingo/embeddings"
 	"github.com/tmc/langchaingo/schema"
@@ -34,6 +39,10 @@ type Store struct {
 	createIndexIfNotExists bool
 	indexSchema            *IndexSchema
 	schemaGenerator        *schemaGenerator
+	storageType            IndexType
+	vectorAlgorithm        VectorAlgorithm
+	distanceMetric         DistanceMetric
+	ttl                    time.Duration
 }
 
 var _ vectorstores.VectorStore = &Store{}
@@ -60,7 +69,7 @@ func New(ctx context.Context, opts ...Option) (*Store, error) {
 			return nil, ErrNotExistedIndex
 		} else if s.indexSchema != nil {
 			// create index with input schema
-			if err := s.client.CreateIndexIfNotExists(ctx, s.indexName, s.indexSchema); err != nil {
+			if err := s.client.CreateIndexIfNotExists(ctx, s.indexName, s.storageType, s.indexSchema); err != nil {
 				return nil, err
 			}
 		}
@@ -71,12 +80,21 @@ func New(ctx context.Context, opts ...Option) (*Store, error) {
 
 // AddDocuments adds the text and metadata from the documents to the redis associated with 'Store'.
 // and returns the ids of the added documents.
-// Note: currently save documents with Hset command
+// Note: documents are saved with Hset command, or JSON.SET with the JSON storage type
 // return `docIDs` that prefix with `doc:{index_name}`
 //
 //	if doc.metadata has `keys` or `ids` field, the docId will use `keys` or `ids` value
 //	if not, the docId is uuid string
-func (s *Store) AddDocuments(ctx context.Context, docs []schema.Document, _ ...vectorstores.Option) ([]string, error) {
+//
+// Support options:
+//
+//	WithDocumentTTL: the time to live of the documents, overriding `WithTTL`
+func (s *Store) AddDocuments(ctx context.Context, docs []schema.Document, options ...vectorstores.Option) ([]string, error) { // nolint: lll
+	ttl := s.ttl
+	if docTTL, ok := s.getOptions(options...).Extra[addOptionsKey{}].(time.Duration); ok {
+		ttl = docTTL
+	}
+
 	err := s.appendDocumentsWithVectors(ctx, docs)
 	if err != nil {
 		return nil, err
@@ -86,22 +104,37 @@ func (s *Store) AddDocuments(ctx context.Context, docs []schema.Document, _ ...v
 	if err != nil {
 		return nil, err
 	}
+	for i := range indexSchema.Vector {
+		indexSchema.Vector[i].Algorithm = s.vectorAlgorithm
+		indexSchema.Vector[i].DistanceMetric = s.distanceMetric
+	}
 
 	if s.indexSchema == nil {
 		s.indexSchema = indexSchema
 	}
 
 	if s.createIndexIfNotExists && !s.client.CheckIndexExists(ctx, s.indexName) {
-		if err := s.client.CreateIndexIfNotExists(ctx, s.indexName, indexSchema); err != nil {
+		if err := s.client.CreateIndexIfNotExists(ctx, s.indexName, s.storageType, indexSchema); err != nil {
 			return nil, err
 		}
 	}
 
-	docIDs, err := s.client.AddDocsWithHash(ctx, getPrefix(s.indexName), docs)
+	var docIDs []string
+	if s.storageType == JSONIndexType {
+		docIDs, err = s.client.AddDocsWithJSON(ctx, getPrefix(s.indexName), docs)
+	} else {
+		docIDs, err = s.client.AddDocsWithHash(ctx, getPrefix(s.indexName), docs)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if ttl > 0 {
+		if err := s.client.Expire(ctx, docIDs, ttl); err != nil {
+			return nil, err
+		}
+	}
+
 	return docIDs, nil
 }
 
@@ -110,6 +143,8 @@ func (s *Store) AddDocuments(ctx context.Context, docs []schema.Document, _ ...v
 //
 //	WithScoreThreshold:
 //	WithFilters: filter string should match redis search pre-filter query pattern.(eg: @title:Dune)
+//		or a map[string]any of the values of indexed metadata fields, matching one of the values when it is a []any,
+//		or a vectorstores.Filter on the indexed metadata fields, without Exists
 //		ref: https://redis.io/docs/latest/develop/interact/search-and-query/advanced-concepts/vectors/#pre-filter-query-attributes-hybrid-approach
 //	WithEmbedder: if set, it will embed query string with this embedder; otherwise embed with vector's embedder
 //
@@ -178,13 +213,177 @@ func (s Store) getScoreThreshold(opts vectorstores.Options) (float32, error) {
 
 // getFilters return metadata filters.
 func (s Store) getFilters(opts vectorstores.Options) (string, error) {
-	if opts.Filters != nil {
-		if filters, ok := opts.Filters.(string); ok {
-			return filters, nil
+	switch filters := opts.Filters.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return filters, nil
+	case map[string]any:
+		return s.metadataFilter(filters)
+	case vectorstores.Filter:
+		if err := filters.Validate(); err != nil {
+			return "", fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		return filterQuery(filters, s.fieldTypes())
+	}
+	return "", ErrInvalidFilters
+}
+
+// metadataFilter returns the pre-filter query of a map of metadata fields to
+// the values the documents must have, or one of when the value is a []any.
+// The fields must be tag, text or numeric fields of the index schema.
+func (s Store) metadataFilter(filters map[string]any) (string, error) {
+	types := s.fieldTypes()
+	keys := maps.Keys(filters)
+	sort.Strings(keys)
+	clauses := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values, ok := filters[key].([]any)
+		if !ok {
+			values = []any{filters[key]}
+		}
+		clause, err := fieldClause(key, values, types)
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, clause)
+	}
+	return strings.Join(clauses, " "), nil
+}
+
+// fieldTypes returns the types of the tag, text and numeric fields of the
+// index schema, by attribute name.
+func (s Store) fieldTypes() map[string]string {
+	types := map[string]string{}
+	if s.indexSchema != nil {
+		for _, f := range s.indexSchema.Tag {
+			types[fieldAttribute(f.Name, f.As)] = "tag"
+		}
+		for _, f := range s.indexSchema.Text {
+			types[fieldAttribute(f.Name, f.As)] = "text"
+		}
+		for _, f := range s.indexSchema.Numeric {
+			types[fieldAttribute(f.Name, f.As)] = "numeric"
+		}
+	}
+	return types
+}
+
+// fieldClause returns the pre-filter query matching the documents whose
+// field key has one of values.
+func fieldClause(key string, values []any, types map[string]string) (string, error) {
+	terms := make([]string, len(values))
+	for i, value := range values {
+		terms[i] = fmt.Sprint(value)
+	}
+	switch types[key] {
+	case "tag":
+		for i, term := range terms {
+			terms[i] = escapeTag(term)
+		}
+		return fmt.Sprintf("@%s:{%s}", key, strings.Join(terms, "|")), nil
+	case "text":
+		for i, term := range terms {
+			terms[i] = strconv.Quote(term)
+		}
+		return fmt.Sprintf("@%s:(%s)", key, strings.Join(terms, "|")), nil
+	case "numeric":
+		ranges := make([]string, len(terms))
+		for i, term := range terms {
+			if _, err := strconv.ParseFloat(term, 64); err != nil {
+				return "", fmt.Errorf("%w: %q is not a number", ErrInvalidFilters, term)
+			}
+			ranges[i] = fmt.Sprintf("@%s:[%s %s]", key, term, term)
+		}
+		return "(" + strings.Join(ranges, " | ") + ")", nil
+	}
+	return "", fmt.Errorf("%w: %q is not a tag, text or numeric field of the index", ErrInvalidFilters, key)
+}
+
+// filterQuery translates f to a pre-filter query on the tag, text and
+// numeric fields of the index schema. The ordering comparisons need numeric
+// fields, and the existence of fields isn't indexed.
+func filterQuery(f vectorstores.Filter, types map[string]string) (string, error) {
+	switch f.Op {
+	case vectorstores.FilterAnd, vectorstores.FilterOr:
+		if len(f.Filters) == 0 {
+			return "", fmt.Errorf("%w: %s filters without operands aren't supported", ErrInvalidFilters, f.Op)
+		}
+		clauses := make([]string, len(f.Filters))
+		for i, filter := range f.Filters {
+			var err error
+			if clauses[i], err = filterQuery(filter, types); err != nil {
+				return "", err
+			}
+		}
+		separator := " "
+		if f.Op == vectorstores.FilterOr {
+			separator = " | "
+		}
+		return "(" + strings.Join(clauses, separator) + ")", nil
+	case vectorstores.FilterNot:
+		clause, err := filterQuery(f.Filters[0], types)
+		if err != nil {
+			return "", err
+		}
+		return "-(" + clause + ")", nil
+	case vectorstores.FilterEq:
+		return fieldClause(f.Key, []any{f.Value}, types)
+	case vectorstores.FilterNe:
+		clause, err := fieldClause(f.Key, []any{f.Value}, types)
+		if err != nil {
+			return "", err
+		}
+		return "-(" + clause + ")", nil
+	case vectorstores.FilterIn:
+		values, _ := f.Value.([]any)
+		if len(values) == 0 {
+			return "", fmt.Errorf("%w: %s filters without values aren't supported", ErrInvalidFilters, f.Op)
+		}
+		return fieldClause(f.Key, values, types)
+	case vectorstores.FilterExists:
+		return "", fmt.Errorf("%w: %s filters aren't supported", ErrInvalidFilters, f.Op)
+	}
+
+	if types[f.Key] != "numeric" {
+		return "", fmt.Errorf("%w: %q is not a numeric field of the index", ErrInvalidFilters, f.Key)
+	}
+	value := fmt.Sprint(f.Value)
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return "", fmt.Errorf("%w: %q is not a number", ErrInvalidFilters, value)
+	}
+	var bounds string
+	switch f.Op { //nolint:exhaustive
+	case vectorstores.FilterGt:
+		bounds = "(" + value + " +inf"
+	case vectorstores.FilterGte:
+		bounds = value + " +inf"
+	case vectorstores.FilterLt:
+		bounds = "-inf (" + value
+	case vectorstores.FilterLte:
+		bounds = "-inf " + value
+	}
+	return fmt.Sprintf("@%s:[%s]", f.Key, bounds), nil
+}
+
+// fieldAttribute returns the attribute name of a field of the index schema.
+func fieldAttribute(name, as string) string {
+	if as != "" {
+		return as
+	}
+	return name
+}
+
+// escapeTag escapes the punctuation and spaces of a tag value.
+func escapeTag(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if strings.ContainsRune(",.<>{}[]\"'`:;!@#$%^&*()-+=~|/\\ ", r) {
+			b.WriteByte('\\')
 		}
-		return "", ErrInvalidFilters
+		b.WriteRune(r)
 	}
-	return "", nil
+	return b.String()
 }
 
 // append content & content_vector into doc.Metadata.
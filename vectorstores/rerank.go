@@ -0,0 +1,205 @@
+package vectorstores
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// RerankScoreMetadataKey is the document metadata key RerankingRetriever sets
+// to the score given by the reranker.
+const RerankScoreMetadataKey = "rerank_score"
+
+// ErrRerankScores is returned when a reranker doesn't return one score per
+// document.
+var ErrRerankScores = errors.New("reranker returned the wrong number of scores")
+
+// Reranker scores candidate documents by their relevance to a query, e.g. a
+// cross-encoder served on a GPU or a remote reranking service.
+type Reranker interface {
+	// Rerank returns one score per document, higher scores being more
+	// relevant.
+	Rerank(ctx context.Context, query string, docs []schema.Document) ([]float64, error)
+}
+
+// RerankingRetriever reorders the documents of a retriever with a Reranker.
+type RerankingRetriever struct {
+	CallbacksHandler callbacks.Handler
+
+	retriever     schema.Retriever
+	reranker      Reranker
+	batchSize     int
+	maxCandidates int
+	topN          int
+	timeout       time.Duration
+	skipOnFailure bool
+	onFailure     func(ctx context.Context, err error)
+}
+
+var _ schema.Retriever = &RerankingRetriever{}
+
+// RerankOption configures a RerankingRetriever.
+type RerankOption func(r *RerankingRetriever)
+
+// WithRerankBatchSize splits the candidates into requests of at most size
+// documents, sent concurrently. By default all the candidates are sent in a
+// single request.
+func WithRerankBatchSize(size int) RerankOption {
+	return func(r *RerankingRetriever) {
+		r.batchSize = size
+	}
+}
+
+// WithMaxRerankCandidates limits the number of documents, in retrieval order,
+// sent to the reranker. The remaining documents follow the reranked ones in
+// retrieval order.
+func WithMaxRerankCandidates(maxCandidates int) RerankOption {
+	return func(r *RerankingRetriever) {
+		r.maxCandidates = maxCandidates
+	}
+}
+
+// WithRerankTopN limits the number of documents returned.
+func WithRerankTopN(n int) RerankOption {
+	return func(r *RerankingRetriever) {
+		r.topN = n
+	}
+}
+
+// WithRerankTimeout sets the deadline of every request to the reranker.
+func WithRerankTimeout(timeout time.Duration) RerankOption {
+	return func(r *RerankingRetriever) {
+		r.timeout = timeout
+	}
+}
+
+// WithSkipRerankOnFailure returns the documents in retrieval order instead
+// of failing when the reranker fails or times out. onFailure, if not nil, is
+// called with the error of every skipped reranking.
+func WithSkipRerankOnFailure(onFailure func(ctx context.Context, err error)) RerankOption {
+	return func(r *RerankingRetriever) {
+		r.skipOnFailure = true
+		r.onFailure = onFailure
+	}
+}
+
+// NewRerankingRetriever creates a RerankingRetriever reordering the documents
+// of retriever with reranker.
+func NewRerankingRetriever(retriever schema.Retriever, reranker Reranker, opts ...RerankOption) *RerankingRetriever {
+	r := &RerankingRetriever{
+		retriever: retriever,
+		reranker:  reranker,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// GetRelevantDocuments returns the documents of the retriever, the candidates
+// ordered by decreasing reranker score and tagged with it in their metadata.
+func (r *RerankingRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]schema.Document, error) {
+	if r.CallbacksHandler != nil {
+		r.CallbacksHandler.HandleRetrieverStart(ctx, query)
+	}
+
+	docs, err := r.retriever.GetRelevantDocuments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := docs
+	if r.maxCandidates > 0 && len(candidates) > r.maxCandidates {
+		candidates = docs[:r.maxCandidates]
+	}
+	scores, err := r.score(ctx, query, candidates)
+	switch {
+	case err != nil && !r.skipOnFailure:
+		return nil, fmt.Errorf("failed to rerank documents: %w", err)
+	case err != nil:
+		if r.onFailure != nil {
+			r.onFailure(ctx, err)
+		}
+	default:
+		reranked := make([]schema.Document, len(candidates))
+		order := make([]int, len(candidates))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool { return scores[order[i]] > scores[order[j]] })
+		for i, j := range order {
+			reranked[i] = candidates[j]
+			if reranked[i].Metadata == nil {
+				reranked[i].Metadata = map[string]any{}
+			}
+			reranked[i].Metadata[RerankScoreMetadataKey] = scores[j]
+		}
+		docs = append(reranked, docs[len(candidates):]...)
+	}
+	if r.topN > 0 && len(docs) > r.topN {
+		docs = docs[:r.topN]
+	}
+
+	if r.CallbacksHandler != nil {
+		r.CallbacksHandler.HandleRetrieverEnd(ctx, query, docs)
+	}
+	return docs, nil
+}
+
+// score returns the reranker scores of docs, requesting the batches
+// concurrently.
+func (r *RerankingRetriever) score(ctx context.Context, query string, docs []schema.Document) ([]float64, error) {
+	batchSize := r.batchSize
+	if batchSize <= 0 || batchSize > len(docs) {
+		batchSize = len(docs)
+	}
+	scores := make([]float64, len(docs))
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for start := 0; start < len(docs); start += batchSize {
+		end := min(start+batchSize, len(docs))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			batchScores, err := r.rerankBatch(ctx, query, docs[start:end])
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+			copy(scores[start:end], batchScores)
+		}()
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return scores, nil
+}
+
+// rerankBatch sends a single request to the reranker.
+func (r *RerankingRetriever) rerankBatch(ctx context.Context, query string, docs []schema.Document) ([]float64, error) {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	scores, err := r.reranker.Rerank(ctx, query, docs)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) != len(docs) {
+		return nil, fmt.Errorf("%w: got %d for %d documents", ErrRerankScores, len(scores), len(docs))
+	}
+	return scores, nil
+}
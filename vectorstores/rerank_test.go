@@ -0,0 +1,96 @@
+package vectorstores
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// lengthReranker scores documents by the length of their content.
+type lengthReranker struct {
+	mu      sync.Mutex
+	batches []int
+	err     error
+	delay   time.Duration
+}
+
+func (r *lengthReranker) Rerank(ctx context.Context, _ string, docs []schema.Document) ([]float64, error) {
+	r.mu.Lock()
+	r.batches = append(r.batches, len(docs))
+	r.mu.Unlock()
+	if r.delay > 0 {
+		select {
+		case <-time.After(r.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	scores := make([]float64, len(docs))
+	for i, doc := range docs {
+		scores[i] = float64(len(doc.PageContent))
+	}
+	return scores, nil
+}
+
+func documents(contents ...string) []schema.Document {
+	docs := make([]schema.Document, len(contents))
+	for i, content := range contents {
+		docs[i] = schema.Document{PageContent: content, Metadata: map[string]any{"rank": strconv.Itoa(i)}}
+	}
+	return docs
+}
+
+func pageContents(docs []schema.Document) []string {
+	contents := make([]string, len(docs))
+	for i, doc := range docs {
+		contents[i] = doc.PageContent
+	}
+	return contents
+}
+
+func TestRerankingRetriever(t *testing.T) {
+	t.Parallel()
+	retriever := staticRetriever{docs: documents("a", "bbb", "cc", "dddd", "eeeee")}
+	reranker := &lengthReranker{}
+
+	r := NewRerankingRetriever(retriever, reranker,
+		WithRerankBatchSize(2),
+		WithMaxRerankCandidates(4),
+		WithRerankTopN(4),
+	)
+	docs, err := r.GetRelevantDocuments(context.Background(), "query")
+	require.NoError(t, err)
+	require.Equal(t, []string{"dddd", "bbb", "cc", "a"}, pageContents(docs))
+	require.InDelta(t, 4.0, docs[0].Metadata[RerankScoreMetadataKey], 0)
+	require.ElementsMatch(t, []int{2, 2}, reranker.batches)
+}
+
+func TestRerankingRetrieverFailure(t *testing.T) {
+	t.Parallel()
+	retriever := staticRetriever{docs: documents("a", "bbb", "cc")}
+	errUnavailable := errors.New("reranker unavailable")
+
+	r := NewRerankingRetriever(retriever, &lengthReranker{err: errUnavailable})
+	_, err := r.GetRelevantDocuments(context.Background(), "query")
+	require.ErrorIs(t, err, errUnavailable)
+
+	var skipped []error
+	r = NewRerankingRetriever(retriever, &lengthReranker{delay: time.Second},
+		WithRerankTimeout(10*time.Millisecond),
+		WithSkipRerankOnFailure(func(_ context.Context, err error) { skipped = append(skipped, err) }),
+	)
+	docs, err := r.GetRelevantDocuments(context.Background(), "query")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "bbb", "cc"}, pageContents(docs))
+	require.Len(t, skipped, 1)
+	require.ErrorIs(t, skipped[0], context.DeadlineExceeded)
+}
@@ -0,0 +1,8 @@
+// Package sqlitevec contains an implementation of the VectorStore interface
+// backed by SQLite with the sqlite-vec extension, for desktop and edge
+// applications that can't run a database server.
+//
+// The extension must be loaded into the connections of the database, e.g.
+// by calling sqlite_vec.Auto() of github.com/asg017/sqlite-vec-go-bindings
+// before opening it.
+package sqlitevec
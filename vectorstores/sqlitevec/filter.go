@@ -0,0 +1,153 @@
+package sqlitevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// filterConditions returns the SQL conditions matching the documents of
+// filters, a map of metadata values or a vectorstores.Filter, along with
+// their arguments.
+func filterConditions(filters any) ([]string, []any, error) {
+	switch f := filters.(type) {
+	case nil:
+		return nil, nil, nil
+	case map[string]any:
+		keys := make([]string, 0, len(f))
+		for key := range f {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		conditions := make([]string, len(keys))
+		args := make([]any, 0, 2*len(keys))
+		for i, key := range keys {
+			path, err := jsonPath(key)
+			if err != nil {
+				return nil, nil, err
+			}
+			conditions[i] = "json_extract(metadata, ?) = ?"
+			args = append(args, path, f[key])
+		}
+		return conditions, args, nil
+	case vectorstores.Filter:
+		if err := f.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+		}
+		condition, args, err := filterSQL(f, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []string{condition}, args, nil
+	}
+	return nil, nil, fmt.Errorf("%w, got %T", ErrInvalidFilters, filters)
+}
+
+// filterSQL translates f to a condition on the json metadata column, along
+// with args followed by the arguments of the condition. The comparisons
+// check the json type of the metadata value, so that numbers are compared by
+// value and the values of different kinds don't match.
+func filterSQL(f vectorstores.Filter, args []any) (string, []any, error) {
+	switch f.Op {
+	case vectorstores.FilterAnd, vectorstores.FilterOr:
+		if len(f.Filters) == 0 {
+			return strings.ToUpper(fmt.Sprint(f.Op == vectorstores.FilterAnd)), args, nil
+		}
+		conditions := make([]string, len(f.Filters))
+		for i, filter := range f.Filters {
+			var err error
+			if conditions[i], args, err = filterSQL(filter, args); err != nil {
+				return "", nil, err
+			}
+		}
+		return "(" + strings.Join(conditions, " "+strings.ToUpper(string(f.Op))+" ") + ")", args, nil
+	case vectorstores.FilterNot:
+		condition, args, err := filterSQL(f.Filters[0], args)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(NOT " + condition + ")", args, nil
+	case vectorstores.FilterIn:
+		values, _ := f.Value.([]any)
+		filters := make([]vectorstores.Filter, len(values))
+		for i, value := range values {
+			filters[i] = vectorstores.Eq(f.Key, value)
+		}
+		return filterSQL(vectorstores.Or(filters...), args)
+	}
+
+	path, err := jsonPath(f.Key)
+	if err != nil {
+		return "", nil, err
+	}
+	if f.Op == vectorstores.FilterExists {
+		return "(json_type(metadata, ?) IS NOT NULL)", append(args, path), nil
+	}
+	// The value is decoded from its json encoding, as the metadata are.
+	encoded, err := json.Marshal(f.Value)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+	}
+	var value any
+	if err := json.Unmarshal(encoded, &value); err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrInvalidFilters, err)
+	}
+
+	var condition string
+	switch v := value.(type) {
+	case float64, string:
+		types := "'integer', 'real'"
+		if _, ok := v.(string); ok {
+			types = "'text'"
+		}
+		op := sqlOps[f.Op]
+		if f.Op == vectorstores.FilterNe {
+			op = "="
+		}
+		condition = fmt.Sprintf("json_type(metadata, ?) IN (%s) AND json_extract(metadata, ?) %s ?", types, op)
+		args = append(args, path, path, v)
+	default:
+		if f.Op != vectorstores.FilterEq && f.Op != vectorstores.FilterNe {
+			// The other values are only compared for equality.
+			return "FALSE", args, nil
+		}
+		switch v {
+		case nil:
+			condition = "json_type(metadata, ?) = 'null'"
+			args = append(args, path)
+		case true, false:
+			condition = "json_type(metadata, ?) = ?"
+			args = append(args, path, fmt.Sprint(v))
+		default:
+			condition = "json_type(metadata, ?) IN ('array', 'object') AND json_extract(metadata, ?) = json(?)"
+			args = append(args, path, path, string(encoded))
+		}
+	}
+	// The condition is null when the document doesn't have the key.
+	condition = "COALESCE(" + condition + ", FALSE)"
+	if f.Op == vectorstores.FilterNe {
+		condition = "(NOT " + condition + ")"
+	}
+	return condition, args, nil
+}
+
+// sqlOps are the SQL operators of the comparisons.
+var sqlOps = map[vectorstores.FilterOp]string{ //nolint:gochecknoglobals
+	vectorstores.FilterEq:  "=",
+	vectorstores.FilterGt:  ">",
+	vectorstores.FilterGte: ">=",
+	vectorstores.FilterLt:  "<",
+	vectorstores.FilterLte: "<=",
+}
+
+// jsonPath returns the json path of a metadata key, which can't contain
+// quotes or backslashes.
+func jsonPath(key string) (string, error) {
+	if strings.ContainsAny(key, `"\`) {
+		return "", fmt.Errorf("%w: invalid key %q", ErrInvalidFilters, key)
+	}
+	return fmt.Sprintf(`$."%s"`, key), nil
+}
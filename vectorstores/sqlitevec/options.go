@@ -0,0 +1,78 @@
+package sqlitevec
+
+import (
+	"database/sql"
+
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+const (
+	// DefaultTableName is the default name of the table of the documents.
+	DefaultTableName = "langchaingo_documents"
+	// DefaultCollection is the default collection of the documents, the name
+	// space of the vectorstores options selecting another one.
+	DefaultCollection = "default"
+)
+
+// Distance is the distance function of the searches.
+type Distance string
+
+const (
+	// Cosine ranks the documents by cosine distance, the score of a
+	// document being 1 - distance.
+	Cosine Distance = "cosine"
+	// L2 ranks the documents by euclidean distance, the score of a document
+	// being 1 / (1 + distance).
+	L2 Distance = "l2"
+)
+
+// Option is a function for configuring the Store.
+type Option func(s *Store)
+
+// WithDB sets the database of the store, whose connections have the
+// sqlite-vec extension loaded.
+func WithDB(db *sql.DB) Option {
+	return func(s *Store) {
+		s.db = db
+	}
+}
+
+// WithDBAddress sets the file path or address of the database opened when
+// WithDB isn't set. It defaults to ":memory:".
+func WithDBAddress(addr string) Option {
+	return func(s *Store) {
+		s.dbAddress = addr
+	}
+}
+
+// WithEmbedder sets the embedder of the documents and queries. It must be
+// set.
+func WithEmbedder(embedder embeddings.Embedder) Option {
+	return func(s *Store) {
+		s.embedder = embedder
+	}
+}
+
+// WithTableName sets the name of the table of the documents, created if it
+// doesn't exist. It defaults to DefaultTableName.
+func WithTableName(name string) Option {
+	return func(s *Store) {
+		s.tableName = name
+	}
+}
+
+// WithCollection sets the collection of the documents. It defaults to
+// DefaultCollection.
+func WithCollection(collection string) Option {
+	return func(s *Store) {
+		s.collection = collection
+	}
+}
+
+// WithDistance sets the distance function of the searches. It defaults to
+// Cosine.
+func WithDistance(distance Distance) Option {
+	return func(s *Store) {
+		s.distance = distance
+	}
+}
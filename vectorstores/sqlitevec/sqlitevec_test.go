@@ -0,0 +1,259 @@
+package sqlitevec
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/migrate"
+	"github.com/tmc/langchaingo/vectorstores/vstest"
+)
+
+var registerDriver sync.Once //nolint:gochecknoglobals
+
+// openTestDB opens an in-memory database with Go implementations of the
+// sqlite-vec functions used by the store, as the extension isn't available
+// to the tests.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	registerDriver.Do(func() {
+		sql.Register("sqlite3_vec_test", &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				functions := map[string]any{
+					"vec_version":         func() string { return "test" },
+					"vec_f32":             vecF32,
+					"vec_to_json":         vecToJSON,
+					"vec_distance_cosine": vecDistanceCosine,
+					"vec_distance_l2":     vecDistanceL2,
+				}
+				for name, impl := range functions {
+					if err := conn.RegisterFunc(name, impl, true); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		})
+	})
+	db, err := sql.Open("sqlite3_vec_test", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func vecF32(text string) ([]byte, error) {
+	var vector []float32
+	if err := json.Unmarshal([]byte(text), &vector); err != nil {
+		return nil, err
+	}
+	blob := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(v))
+	}
+	return blob, nil
+}
+
+func decode(blob []byte) []float64 {
+	vector := make([]float64, len(blob)/4)
+	for i := range vector {
+		vector[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:])))
+	}
+	return vector
+}
+
+func vecToJSON(blob []byte) (string, error) {
+	b, err := json.Marshal(decode(blob))
+	return string(b), err
+}
+
+func vecDistanceCosine(a, b []byte) float64 {
+	x, y := decode(a), decode(b)
+	var dot, normX, normY float64
+	for i := range x {
+		dot += x[i] * y[i]
+		normX += x[i] * x[i]
+		normY += y[i] * y[i]
+	}
+	return 1 - dot/(math.Sqrt(normX)*math.Sqrt(normY))
+}
+
+func vecDistanceL2(a, b []byte) float64 {
+	x, y := decode(a), decode(b)
+	var sum float64
+	for i := range x {
+		sum += (x[i] - y[i]) * (x[i] - y[i])
+	}
+	return math.Sqrt(sum)
+}
+
+func newTestStore(t *testing.T, opts ...Option) Store {
+	t.Helper()
+	opts = append([]Option{WithDB(openTestDB(t)), WithEmbedder(vstest.Embedder{})}, opts...)
+	s, err := New(context.Background(), opts...)
+	require.NoError(t, err)
+	_, err = s.AddDocuments(context.Background(), []schema.Document{
+		{PageContent: "cat cat", Metadata: map[string]any{"id": "1", "kind": "cat", "legs": 4}},
+		{PageContent: "cat dog", Metadata: map[string]any{"id": "2", "kind": "mixed"}},
+		{PageContent: "dog dog", Metadata: map[string]any{"id": "3", "kind": "dog", "legs": 4}},
+		{PageContent: "fish", Metadata: map[string]any{"id": "4", "kind": "fish", "legs": 0}},
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func TestSimilaritySearch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	docs, err := s.SimilaritySearch(ctx, "cat", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat cat", "cat dog"}, vstest.Contents(docs))
+	require.InDelta(t, 1, docs[0].Score, 1e-6)
+	require.InDelta(t, 0.7071, docs[1].Score, 1e-4)
+	require.Equal(t, map[string]any{"id": "1", "kind": "cat", "legs": 4.0}, docs[0].Metadata)
+
+	docs, err = s.SimilaritySearch(ctx, "cat", 4, vectorstores.WithScoreThreshold(0.5))
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat cat", "cat dog"}, vstest.Contents(docs))
+
+	docs, err = s.SimilaritySearch(ctx, "cat", 4, vectorstores.WithFilters(map[string]any{"legs": 4, "kind": "dog"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"dog dog"}, vstest.Contents(docs))
+
+	_, err = s.SimilaritySearch(ctx, "cat", 4, vectorstores.WithFilters("kind = 'dog'"))
+	require.ErrorIs(t, err, ErrInvalidFilters)
+	_, err = s.SimilaritySearch(ctx, "cat", 4, vectorstores.WithFilters(map[string]any{`a"b`: 1}))
+	require.ErrorIs(t, err, ErrInvalidFilters)
+}
+
+func TestSimilaritySearchFilter(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := newTestStore(t)
+	all, err := s.SimilaritySearch(ctx, "cat dog fish", 10)
+	require.NoError(t, err)
+
+	filters := []vectorstores.Filter{
+		vectorstores.Eq("legs", 4.0),
+		vectorstores.Eq("legs", "4"),
+		vectorstores.Ne("legs", 4),
+		vectorstores.Gt("kind", "dog"),
+		vectorstores.Lte("legs", 0),
+		vectorstores.Lt("legs", "5"),
+		vectorstores.In("kind", "cat", "fish"),
+		vectorstores.Exists("legs"),
+		vectorstores.Not(vectorstores.Exists("legs")),
+		vectorstores.And(),
+		vectorstores.Or(),
+		vectorstores.Or(vectorstores.Eq("kind", "mixed"), vectorstores.And(vectorstores.Gte("legs", 1), vectorstores.Ne("id", "1"))),
+	}
+	for _, filter := range filters {
+		var want []string
+		for _, doc := range all {
+			if filter.Match(doc.Metadata) {
+				want = append(want, doc.PageContent)
+			}
+		}
+		docs, err := s.SimilaritySearch(ctx, "cat dog fish", 10, vectorstores.WithFilters(filter))
+		require.NoError(t, err)
+		require.ElementsMatch(t, want, vstest.Contents(docs), filter)
+	}
+
+	_, err = s.SimilaritySearch(ctx, "cat", 4, vectorstores.WithFilters(vectorstores.Not(vectorstores.Filter{})))
+	require.ErrorIs(t, err, ErrInvalidFilters)
+	require.ErrorIs(t, err, vectorstores.ErrInvalidFilter)
+}
+
+func TestSimilaritySearchL2(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t, WithDistance(L2))
+	docs, err := s.SimilaritySearch(context.Background(), "cat cat", 4, vectorstores.WithScoreThreshold(0.5))
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat cat", "cat dog"}, vstest.Contents(docs))
+	require.InDelta(t, 1, docs[0].Score, 1e-6)
+	require.InDelta(t, 1/(1+0.7654), docs[1].Score, 1e-4)
+}
+
+func TestAddAndDeleteDocuments(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	ids, err := s.AddDocuments(ctx, []schema.Document{
+		{PageContent: "fish fish", Metadata: map[string]any{"id": "4"}},
+		{PageContent: "cat"},
+	}, vectorstores.WithNameSpace("other"))
+	require.NoError(t, err)
+	require.Equal(t, "4", ids[0])
+	docs, err := s.SimilaritySearch(ctx, "fish", 4, vectorstores.WithNameSpace("other"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"fish fish", "cat"}, vstest.Contents(docs))
+	docs, err = s.SimilaritySearch(ctx, "fish", 4)
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat cat", "cat dog", "dog dog"}, vstest.Contents(docs))
+
+	deleted, err := s.DeleteDocuments(ctx, []string{"1", "4", "missing"})
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+	docs, err = s.SimilaritySearch(ctx, "cat", 4)
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat dog", "dog dog"}, vstest.Contents(docs))
+}
+
+func TestCopy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	src := newTestStore(t)
+	dst := newTestStore(t, WithTableName("copied"))
+	_, err := dst.DeleteDocuments(ctx, []string{"1", "2", "3", "4"})
+	require.NoError(t, err)
+
+	result, err := migrate.Copy(ctx, src, dst, migrate.WithBatchSize(3), migrate.WithIDMetadataKey("id"))
+	require.NoError(t, err)
+	require.Equal(t, 4, result.Copied)
+	docs, err := dst.SimilaritySearch(ctx, "dog", 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"dog dog"}, vstest.Contents(docs))
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	_, err := New(ctx)
+	require.ErrorIs(t, err, ErrMissingEmbedder)
+	_, err = New(ctx, WithEmbedder(vstest.Embedder{}), WithTableName("docs; DROP TABLE x"))
+	require.Error(t, err)
+	_, err = New(ctx, WithEmbedder(vstest.Embedder{}), WithDistance("dot"))
+	require.Error(t, err)
+	_, err = New(ctx, WithEmbedder(vstest.Embedder{}))
+	require.ErrorIs(t, err, ErrExtensionNotLoaded)
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+	for name, distance := range map[string]Distance{"Cosine": Cosine, "L2": L2} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			vstest.Run(t, vstest.Config{
+				NewStore: func(t *testing.T, embedder embeddings.Embedder) vectorstores.VectorStore {
+					t.Helper()
+					s, err := New(context.Background(), WithDB(openTestDB(t)), WithEmbedder(embedder), WithDistance(distance))
+					require.NoError(t, err)
+					return s
+				},
+				Filters: true,
+			})
+		})
+	}
+}
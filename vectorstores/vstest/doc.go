@@ -0,0 +1,20 @@
+// Package vstest provides a conformance suite for vector stores, checking
+// that a store adds, searches, scores, filters and deletes documents the way
+// the other stores do. Any store, including the ones outside this module,
+// can run it from its tests:
+//
+//	func TestConformance(t *testing.T) {
+//	    vstest.Run(t, vstest.Config{
+//	        NewStore: func(t *testing.T, embedder embeddings.Embedder) vectorstores.VectorStore {
+//	            s, err := mystore.New(mystore.WithEmbedder(embedder))
+//	            require.NoError(t, err)
+//	            return s
+//	        },
+//	        Filters: true,
+//	    })
+//	}
+//
+// The documents are embedded with Embedder, whose unit vectors give the
+// exact matches of a query a score of 1 whether the store ranks them by
+// cosine similarity or by euclidean distance.
+package vstest
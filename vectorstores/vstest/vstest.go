@@ -0,0 +1,276 @@
+package vstest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// Vocabulary are the words embedded by Embedder, one dimension each.
+var Vocabulary = []string{"cat", "dog", "fish", "bird"} //nolint:gochecknoglobals
+
+// Embedder embeds texts as the unit vectors of the counts of the words of
+// Vocabulary they contain.
+type Embedder struct{}
+
+var _ embeddings.Embedder = Embedder{}
+
+// EmbedDocuments embeds texts.
+func (e Embedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i], _ = e.EmbedQuery(ctx, text)
+	}
+	return vectors, nil
+}
+
+// EmbedQuery embeds text.
+func (Embedder) EmbedQuery(_ context.Context, text string) ([]float32, error) {
+	vector := make([]float32, len(Vocabulary))
+	var norm float64
+	for i, word := range Vocabulary {
+		vector[i] = float32(strings.Count(text, word))
+		norm += float64(vector[i] * vector[i])
+	}
+	if norm > 0 {
+		for i := range vector {
+			vector[i] /= float32(math.Sqrt(norm))
+		}
+	}
+	return vector, nil
+}
+
+// Config is the configuration of the suite.
+type Config struct {
+	// NewStore returns an empty store embedding the documents and queries
+	// with embedder. It is called by every test of the suite, and must be
+	// set.
+	NewStore func(t *testing.T, embedder embeddings.Embedder) vectorstores.VectorStore
+	// AfterWrite, if set, is called after documents are added or deleted,
+	// e.g. to refresh the index of an eventually consistent store.
+	AfterWrite func(t *testing.T, store vectorstores.VectorStore)
+	// Filters runs the tests of the searches filtered with a
+	// vectorstores.Filter.
+	Filters bool
+	// MMR, if set, returns the option of a search reranking the fetchK
+	// nearest documents by maximal marginal relevance, and runs its test.
+	MMR func(fetchK int, lambda float32) vectorstores.Option
+	// SkipConcurrency skips the test of concurrent additions and searches,
+	// for the stores which aren't safe for concurrent use.
+	SkipConcurrency bool
+}
+
+// deleter is implemented by the stores able to delete documents, whose
+// deletions are tested.
+type deleter interface {
+	DeleteDocuments(ctx context.Context, ids []string) (int, error)
+}
+
+// Corpus returns the documents added to the stores by the suite.
+func Corpus() []schema.Document {
+	return []schema.Document{
+		{PageContent: "cat cat", Metadata: map[string]any{"kind": "cat", "legs": 4}},
+		{PageContent: "cat dog", Metadata: map[string]any{"kind": "mixed", "legs": 4}},
+		{PageContent: "dog dog", Metadata: map[string]any{"kind": "dog", "legs": 4}},
+		{PageContent: "fish", Metadata: map[string]any{"kind": "fish", "legs": 0}},
+		{PageContent: "bird", Metadata: map[string]any{"kind": "bird", "legs": 2, "wings": true}},
+	}
+}
+
+// Run runs the suite against the stores returned by cfg.NewStore.
+func Run(t *testing.T, cfg Config) {
+	t.Helper()
+	require.NotNil(t, cfg.NewStore, "vstest: Config.NewStore must be set")
+	s := suite{cfg: cfg}
+	t.Run("AddDocuments", s.testAddDocuments)
+	t.Run("SimilaritySearch", s.testSimilaritySearch)
+	t.Run("Scores", s.testScores)
+	if cfg.Filters {
+		t.Run("Filters", s.testFilters)
+	}
+	t.Run("DeleteDocuments", s.testDeleteDocuments)
+	if cfg.MMR != nil {
+		t.Run("MMR", s.testMMR)
+	}
+	if !cfg.SkipConcurrency {
+		t.Run("Concurrency", s.testConcurrency)
+	}
+}
+
+type suite struct {
+	cfg Config
+}
+
+// newStore returns a new store holding docs, and their ids.
+func (s suite) newStore(t *testing.T, docs []schema.Document) (vectorstores.VectorStore, []string) {
+	t.Helper()
+	store := s.cfg.NewStore(t, Embedder{})
+	ids, err := store.AddDocuments(context.Background(), docs)
+	require.NoError(t, err)
+	s.afterWrite(t, store)
+	return store, ids
+}
+
+func (s suite) afterWrite(t *testing.T, store vectorstores.VectorStore) {
+	t.Helper()
+	if s.cfg.AfterWrite != nil {
+		s.cfg.AfterWrite(t, store)
+	}
+}
+
+// Contents returns the page contents of docs, to compare the results of
+// searches.
+func Contents(docs []schema.Document) []string {
+	result := make([]string, len(docs))
+	for i, doc := range docs {
+		result[i] = doc.PageContent
+	}
+	return result
+}
+
+func (s suite) testAddDocuments(t *testing.T) {
+	_, ids := s.newStore(t, Corpus())
+	require.Len(t, ids, len(Corpus()), "AddDocuments must return the id of every document")
+	seen := map[string]bool{}
+	for _, id := range ids {
+		require.NotEmpty(t, id)
+		require.False(t, seen[id], "duplicate id")
+		seen[id] = true
+	}
+}
+
+func (s suite) testSimilaritySearch(t *testing.T) {
+	ctx := context.Background()
+	store, _ := s.newStore(t, Corpus())
+
+	docs, err := store.SimilaritySearch(ctx, "cat", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat cat", "cat dog"}, Contents(docs))
+	require.Equal(t, "cat", docs[0].Metadata["kind"], "the metadata must be returned")
+
+	docs, err = store.SimilaritySearch(ctx, "cat dog fish bird", 10)
+	require.NoError(t, err)
+	require.ElementsMatch(t, Contents(Corpus()), Contents(docs))
+}
+
+func (s suite) testScores(t *testing.T) {
+	ctx := context.Background()
+	store, _ := s.newStore(t, Corpus())
+
+	docs, err := store.SimilaritySearch(ctx, "cat", 5)
+	require.NoError(t, err)
+	require.NotEmpty(t, docs)
+	require.InDelta(t, 1, docs[0].Score, 1e-3, "an exact match must score 1")
+	for i := 1; i < len(docs); i++ {
+		require.LessOrEqual(t, docs[i].Score, docs[i-1].Score, "the documents must be sorted by decreasing score")
+	}
+
+	docs, err = store.SimilaritySearch(ctx, "cat", 5, vectorstores.WithScoreThreshold(0.5))
+	require.NoError(t, err)
+	require.Contains(t, Contents(docs), "cat cat")
+	for _, doc := range docs {
+		require.GreaterOrEqual(t, doc.Score, float32(0.5), "a document is below the score threshold")
+	}
+}
+
+func (s suite) testFilters(t *testing.T) {
+	ctx := context.Background()
+	store, _ := s.newStore(t, Corpus())
+
+	tests := []struct {
+		filter vectorstores.Filter
+		want   []string
+	}{
+		{vectorstores.Eq("kind", "dog"), []string{"dog dog"}},
+		{vectorstores.In("kind", "fish", "bird"), []string{"fish", "bird"}},
+		{
+			vectorstores.And(vectorstores.Gte("legs", 2), vectorstores.Ne("kind", "dog")),
+			[]string{"cat cat", "cat dog", "bird"},
+		},
+		{vectorstores.Or(vectorstores.Eq("kind", "fish"), vectorstores.Exists("wings")), []string{"fish", "bird"}},
+		{vectorstores.Not(vectorstores.Lt("legs", 4)), []string{"cat cat", "cat dog", "dog dog"}},
+	}
+	for _, tt := range tests {
+		docs, err := store.SimilaritySearch(ctx, "cat dog fish bird", 10, vectorstores.WithFilters(tt.filter))
+		require.NoError(t, err)
+		require.ElementsMatch(t, tt.want, Contents(docs), tt.filter)
+	}
+}
+
+func (s suite) testDeleteDocuments(t *testing.T) {
+	ctx := context.Background()
+	store, ids := s.newStore(t, Corpus())
+	d, ok := store.(deleter)
+	if !ok {
+		t.Skip("the store doesn't delete documents")
+	}
+
+	deleted, err := d.DeleteDocuments(ctx, []string{ids[0], ids[3]})
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+	s.afterWrite(t, store)
+
+	docs, err := store.SimilaritySearch(ctx, "cat dog fish bird", 10)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"cat dog", "dog dog", "bird"}, Contents(docs))
+
+	deleted, err = d.DeleteDocuments(ctx, []string{ids[0]})
+	require.NoError(t, err)
+	require.Zero(t, deleted, "deleting a missing document must not count it")
+}
+
+func (s suite) testMMR(t *testing.T) {
+	store, _ := s.newStore(t, []schema.Document{
+		{PageContent: "cat cat"},
+		{PageContent: "cat cat cat"},
+		{PageContent: "dog"},
+		{PageContent: "fish"},
+	})
+	// The duplicate of the most relevant document is less relevant than a
+	// less similar but diverse document.
+	docs, err := store.SimilaritySearch(context.Background(), "cat cat cat dog", 2, s.cfg.MMR(4, 0.5))
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	require.Contains(t, []string{"cat cat", "cat cat cat"}, docs[0].PageContent)
+	require.Equal(t, "dog", docs[1].PageContent)
+}
+
+func (s suite) testConcurrency(t *testing.T) {
+	ctx := context.Background()
+	store, _ := s.newStore(t, Corpus())
+
+	const n = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := store.AddDocuments(ctx, []schema.Document{{PageContent: fmt.Sprintf("bird %d", i)}})
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := store.SimilaritySearch(ctx, "cat", 2)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	s.afterWrite(t, store)
+
+	docs, err := store.SimilaritySearch(ctx, "bird", len(Corpus())+n)
+	require.NoError(t, err)
+	require.Len(t, docs, len(Corpus())+n)
+}